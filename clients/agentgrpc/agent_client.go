@@ -2,7 +2,9 @@ package agentgrpc
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/forta-network/forta-core-go/protocol"
@@ -10,9 +12,19 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 )
 
-const defaultAgentResponseMaxByteCount = 250000 // 250K
+const (
+	defaultAgentResponseMaxByteCount = 250000 // 250K
+
+	defaultKeepaliveTimeSeconds     = 60
+	defaultKeepaliveTimeoutSeconds  = 20
+	defaultMaxReconnectDelaySeconds = 10
+)
 
 // Method is gRPC method type.
 type Method string
@@ -23,64 +35,224 @@ const (
 	MethodEvaluateTx    Method = "/network.forta.Agent/EvaluateTx"
 	MethodEvaluateBlock Method = "/network.forta.Agent/EvaluateBlock"
 	MethodEvaluateAlert Method = "/network.forta.Agent/EvaluateAlert"
+	// only called if the bot declares the feedback capability
+	MethodFeedback Method = "/network.forta.Agent/Feedback"
 )
 
 // Client allows us to communicate with an agent.
 type Client struct {
-	conn *grpc.ClientConn
+	conns       []*grpc.ClientConn
+	next        uint32 // accessed atomically
+	tlsConfig   *tls.Config
+	connCfg     config.AgentGrpcConfig
+	onReconnect func()
+
+	reconnects uint64 // accessed atomically
+	failures   uint64 // accessed atomically
+
 	protocol.AgentClient
 }
 
+// ConnStats contains the state and the state change counts of the agent connections.
+type ConnStats struct {
+	State      string
+	Reconnects uint64
+	Failures   uint64
+}
+
 // NewClient creates a new client.
 func NewClient() *Client {
 	return &Client{}
 }
 
-// Dial dials an agent using the config.
+// WithTLS makes the client use mutual TLS when dialing the agent.
+func (client *Client) WithTLS(tlsConfig *tls.Config) *Client {
+	client.tlsConfig = tlsConfig
+	return client
+}
+
+// WithConnConfig sets the connection count and the keepalive params.
+func (client *Client) WithConnConfig(connCfg config.AgentGrpcConfig) *Client {
+	client.connCfg = connCfg
+	return client
+}
+
+// OnReconnect sets a func which is called when a connection is ready again after failing.
+func (client *Client) OnReconnect(onReconnect func()) *Client {
+	client.onReconnect = onReconnect
+	return client
+}
+
+func (client *Client) dialOptions(cfg config.AgentConfig) []grpc.DialOption {
+	connCfg := client.connCfg
+	if connCfg.KeepaliveTimeSeconds == 0 {
+		connCfg.KeepaliveTimeSeconds = defaultKeepaliveTimeSeconds
+	}
+	if connCfg.KeepaliveTimeoutSeconds == 0 {
+		connCfg.KeepaliveTimeoutSeconds = defaultKeepaliveTimeoutSeconds
+	}
+	if connCfg.MaxReconnectDelaySeconds == 0 {
+		connCfg.MaxReconnectDelaySeconds = defaultMaxReconnectDelaySeconds
+	}
+
+	transportCreds := grpc.WithInsecure()
+	if client.tlsConfig != nil {
+		// the agent certificate is issued for the container name
+		tlsConfig := client.tlsConfig.Clone()
+		tlsConfig.ServerName = cfg.ContainerName()
+		transportCreds = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+	}
+	reconnectBackoff := backoff.DefaultConfig
+	reconnectBackoff.MaxDelay = time.Duration(connCfg.MaxReconnectDelaySeconds) * time.Second
+	callOpts := []grpc.CallOption{grpc.MaxCallRecvMsgSize(defaultAgentResponseMaxByteCount)}
+	if compression := connCfg.GetCompression(); len(compression) > 0 {
+		// the evaluation requests are compressed while encoding so this only sets the header for them
+		callOpts = append(callOpts, grpc.UseCompressor(compression))
+	}
+	return []grpc.DialOption{
+		transportCreds,
+		grpc.WithBlock(),
+		grpc.WithTimeout(10 * time.Second),
+		grpc.WithDefaultCallOptions(callOpts...),
+		// the pings are sent only while there are active requests, so that the bot servers
+		// with the default enforcement policy do not close the idle connections
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    time.Duration(connCfg.KeepaliveTimeSeconds) * time.Second,
+			Timeout: time.Duration(connCfg.KeepaliveTimeoutSeconds) * time.Second,
+		}),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           reconnectBackoff,
+			MinConnectTimeout: 10 * time.Second,
+		}),
+		// spread the requests if the container name resolves to multiple addresses
+		grpc.WithDefaultServiceConfig(`{"loadBalancingConfig": [{"round_robin":{}}]}`),
+	}
+}
+
+// Dial dials an agent using the config. The connections are kept open and reconnected
+// until the client is closed.
 func (client *Client) Dial(cfg config.AgentConfig) error {
-	var (
-		conn *grpc.ClientConn
-		err  error
-	)
+	connCount := client.connCfg.Connections
+	if connCount == 0 {
+		connCount = 1
+	}
+	target := fmt.Sprintf("dns:///%s:%s", cfg.ContainerName(), cfg.GrpcPort())
+	opts := client.dialOptions(cfg)
+	var conns []*grpc.ClientConn
+	for len(conns) < connCount {
+		conn, err := dialWithRetry(target, cfg, opts)
+		if err != nil {
+			for _, conn := range conns {
+				conn.Close()
+			}
+			log.Error(err)
+			return err
+		}
+		conns = append(conns, conn)
+	}
+	client.withConns(conns)
+	log.WithField("connections", len(conns)).Debugf("connected to agent: %s", cfg.ContainerName())
+	return nil
+}
+
+func dialWithRetry(target string, cfg config.AgentConfig, opts []grpc.DialOption) (conn *grpc.ClientConn, err error) {
 	for i := 0; i < 10; i++ {
-		conn, err = grpc.Dial(
-			fmt.Sprintf("%s:%s", cfg.ContainerName(), cfg.GrpcPort()),
-			grpc.WithInsecure(),
-			grpc.WithBlock(),
-			grpc.WithTimeout(10*time.Second),
-			grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(defaultAgentResponseMaxByteCount)),
-		)
+		conn, err = grpc.Dial(target, opts...)
 		if err == nil {
-			break
+			return conn, nil
 		}
 		err = fmt.Errorf("failed to connect to agent '%s': %v", cfg.ContainerName(), err)
 		log.Debug(err)
 		time.Sleep(time.Second * 2)
 	}
-	if err != nil {
-		log.Error(err)
-		return err
-	}
-	client.WithConn(conn)
-	log.Debugf("connected to agent: %s", cfg.ContainerName())
-	return nil
+	return nil, err
 }
 
 // WithConn sets the client conn.
 func (client *Client) WithConn(conn *grpc.ClientConn) {
-	client.conn = conn
-	client.AgentClient = protocol.NewAgentClient(conn)
+	client.withConns([]*grpc.ClientConn{conn})
+}
+
+func (client *Client) withConns(conns []*grpc.ClientConn) {
+	client.conns = conns
+	client.AgentClient = protocol.NewAgentClient((*connPool)(client))
+	for _, conn := range conns {
+		go client.watchState(conn)
+	}
+}
+
+// watchState counts the state changes of the connection until it is closed and makes the idle
+// connection reconnect right away instead of waiting for the next request.
+func (client *Client) watchState(conn *grpc.ClientConn) {
+	state := conn.GetState()
+	wasReady := state == connectivity.Ready
+	for conn.WaitForStateChange(context.Background(), state) {
+		newState := conn.GetState()
+		switch newState {
+		case connectivity.Shutdown:
+			return
+		case connectivity.Idle:
+			conn.Connect()
+		case connectivity.TransientFailure:
+			atomic.AddUint64(&client.failures, 1)
+		case connectivity.Ready:
+			if wasReady {
+				atomic.AddUint64(&client.reconnects, 1)
+				if client.onReconnect != nil {
+					client.onReconnect()
+				}
+			}
+			wasReady = true
+		}
+		state = newState
+	}
+}
+
+// Stats returns the connection stats. The state is ready only if all connections are ready.
+func (client *Client) Stats() ConnStats {
+	state := connectivity.Ready
+	for _, conn := range client.conns {
+		if connState := conn.GetState(); connState != connectivity.Ready {
+			state = connState
+			break
+		}
+	}
+	return ConnStats{
+		State:      state.String(),
+		Reconnects: atomic.LoadUint64(&client.reconnects),
+		Failures:   atomic.LoadUint64(&client.failures),
+	}
+}
+
+// pickConn returns the next connection in round-robin order.
+func (client *Client) pickConn() *grpc.ClientConn {
+	i := atomic.AddUint32(&client.next, 1)
+	return client.conns[int(i)%len(client.conns)]
+}
+
+// connPool spreads the calls of the generated agent client over the connections.
+type connPool Client
+
+func (pool *connPool) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	return (*Client)(pool).pickConn().Invoke(ctx, method, args, reply, opts...)
+}
+
+func (pool *connPool) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return (*Client)(pool).pickConn().NewStream(ctx, desc, method, opts...)
 }
 
 // Invoke is a generalization of client methods.
 func (client *Client) Invoke(ctx context.Context, method Method, in, out interface{}, opts ...grpc.CallOption) error {
-	return client.conn.Invoke(ctx, string(method), in, out, opts...)
+	return client.pickConn().Invoke(ctx, string(method), in, out, opts...)
 }
 
 // Close implements io.Closer.
 func (client *Client) Close() error {
-	if client.conn != nil {
-		return client.conn.Close()
+	var err error
+	for _, conn := range client.conns {
+		if closeErr := conn.Close(); closeErr != nil {
+			err = closeErr
+		}
 	}
-	return nil
+	return err
 }
@@ -0,0 +1,62 @@
+package agentgrpc
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+type peerCountingServer struct {
+	peers map[string]int
+	mu    sync.Mutex
+	protocol.UnimplementedAgentServer
+}
+
+func (server *peerCountingServer) Initialize(ctx context.Context, req *protocol.InitializeRequest) (*protocol.InitializeResponse, error) {
+	p, _ := peer.FromContext(ctx)
+	server.mu.Lock()
+	server.peers[p.Addr.String()]++
+	server.mu.Unlock()
+	return &protocol.InitializeResponse{Status: protocol.ResponseStatus_SUCCESS}, nil
+}
+
+func TestClientConnPool(t *testing.T) {
+	r := require.New(t)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	server := grpc.NewServer()
+	agentServer := &peerCountingServer{peers: make(map[string]int)}
+	protocol.RegisterAgentServer(server, agentServer)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	var conns []*grpc.ClientConn
+	for i := 0; i < 2; i++ {
+		conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure(), grpc.WithBlock())
+		r.NoError(err)
+		conns = append(conns, conn)
+	}
+	client := NewClient()
+	client.withConns(conns)
+	defer client.Close()
+
+	for i := 0; i < 4; i++ {
+		_, err := client.Initialize(context.Background(), &protocol.InitializeRequest{})
+		r.NoError(err)
+	}
+	r.Len(agentServer.peers, 2)
+	for _, count := range agentServer.peers {
+		r.Equal(2, count)
+	}
+
+	stats := client.Stats()
+	r.Equal("READY", stats.State)
+	r.Zero(stats.Reconnects)
+}
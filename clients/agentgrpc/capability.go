@@ -0,0 +1,51 @@
+package agentgrpc
+
+import (
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Capability fields are returned by the bots in the initialize response. Like the event extension
+// fields, they use field numbers which are not used by the protocol messages.
+const (
+	// InitializeFieldTickInterval is the interval in seconds which the bot wants to be evaluated at.
+	InitializeFieldTickInterval protowire.Number = 1000
+	// InitializeFieldFeedback tells that the bot implements the feedback method.
+	InitializeFieldFeedback protowire.Number = 1001
+)
+
+// Capabilities contains the node-specific bot capabilities which are not a part of the protocol messages.
+type Capabilities struct {
+	// TickInterval is how often the bot wants to evaluate the latest block regardless of the new
+	// blocks. Zero means that the bot is only driven by the events.
+	TickInterval time.Duration
+	// Feedback tells that the bot wants to receive the reasons of the rejected findings.
+	Feedback bool
+}
+
+// ReadCapabilities reads the capability fields from the unknown fields of the initialize response.
+func ReadCapabilities(resp *protocol.InitializeResponse) (caps Capabilities, err error) {
+	if resp == nil {
+		return
+	}
+	err = consumeFields(resp.ProtoReflect().GetUnknown(), func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num == InitializeFieldTickInterval && typ == protowire.VarintType {
+			seconds, n := protowire.ConsumeVarint(value)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			caps.TickInterval = time.Duration(seconds) * time.Second
+		}
+		if num == InitializeFieldFeedback && typ == protowire.VarintType {
+			feedback, n := protowire.ConsumeVarint(value)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			caps.Feedback = protowire.DecodeBool(feedback)
+		}
+		return nil
+	})
+	return
+}
@@ -0,0 +1,61 @@
+package agentgrpc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// Supported compressors of the agent requests
+const (
+	CompressionGzip = gzip.Name
+	CompressionZstd = "zstd"
+)
+
+func init() {
+	encoding.RegisterCompressor(&zstdCompressor{})
+}
+
+// zstdCompressor implements the gRPC compressor interface with zstd.
+type zstdCompressor struct{}
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+}
+
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	// the decoder does not start any goroutines with concurrency 1 so it does not need to be closed
+	decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return decoder, nil
+}
+
+func (c *zstdCompressor) Name() string {
+	return CompressionZstd
+}
+
+// compressMessage compresses the encoded message with the registered compressor.
+func compressMessage(compression string, msgB []byte) ([]byte, error) {
+	compressor := encoding.GetCompressor(compression)
+	if compressor == nil {
+		return nil, fmt.Errorf("agentgrpc: unknown compressor: %s", compression)
+	}
+	var buf bytes.Buffer
+	w, err := compressor.Compress(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("agentgrpc: failed to create the compressor: %v", err)
+	}
+	if _, err := w.Write(msgB); err != nil {
+		return nil, fmt.Errorf("agentgrpc: failed to compress message: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("agentgrpc: failed to compress message: %v", err)
+	}
+	return buf.Bytes(), nil
+}
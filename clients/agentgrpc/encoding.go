@@ -31,13 +31,45 @@ func EncodeMessage(msg interface{}) (*grpc.PreparedMsg, error) {
 	if err != nil {
 		return nil, fmt.Errorf("agentgrpc: failed to encode message: %v", err)
 	}
+	return prepareMessage(msgB, msgB, false), nil
+}
+
+// EncodeRequest encodes an evaluation request together with the event extension. The message is
+// compressed if a compressor is specified, so that it is compressed once for all agents. The agent
+// clients must use the same compressor.
+func EncodeRequest(msg interface{}, ext EventExtension, compression string) (*grpc.PreparedMsg, error) {
+	msgB, err := defaultCodec.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("agentgrpc: failed to encode message: %v", err)
+	}
+	msgB = AppendEventExtension(msgB, ext)
+	if len(compression) == 0 {
+		return prepareMessage(msgB, msgB, false), nil
+	}
+	compressed, err := compressMessage(compression, msgB)
+	if err != nil {
+		return nil, err
+	}
+	return prepareMessage(msgB, compressed, true), nil
+}
+
+// MessageSize returns the encoded size and the size on the wire (after compression) of the message.
+func MessageSize(msg *grpc.PreparedMsg) (size, wireSize int) {
+	prepared := (*preparedMsg)((unsafe.Pointer)(msg))
+	return len(prepared.encodedData), len(prepared.payload)
+}
+
+func prepareMessage(msgB, payload []byte, compressed bool) *grpc.PreparedMsg {
 	hdr := make([]byte, 5)
+	if compressed {
+		hdr[0] = 1
+	}
 	// write length of payload into header buffer
-	binary.BigEndian.PutUint32(hdr[1:], uint32(len(msgB)))
+	binary.BigEndian.PutUint32(hdr[1:], uint32(len(payload)))
 	// hacky conversion to avoid compiler error
 	return (*grpc.PreparedMsg)((unsafe.Pointer)(&preparedMsg{
 		encodedData: msgB,
-		payload:     msgB,
+		payload:     payload,
 		hdr:         hdr,
-	})), nil
+	}))
 }
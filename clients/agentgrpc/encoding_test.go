@@ -6,12 +6,15 @@ import (
 	"log"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/forta-network/forta-core-go/protocol"
 	"github.com/forta-network/forta-node/clients/agentgrpc"
 	"github.com/forta-network/forta-node/config"
+	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
 )
 
 var txMsg = &protocol.EvaluateTxRequest{
@@ -79,3 +82,117 @@ func TestEncodeMessage(t *testing.T) {
 	r.NoError(agentClient.Invoke(context.Background(), agentgrpc.MethodEvaluateTx, preparedMsg, &resp))
 	<-as.doneCh
 }
+
+func TestEncodeRequest(t *testing.T) {
+	r := require.New(t)
+
+	_, err := agentgrpc.EncodeRequest(txMsg, agentgrpc.EventExtension{Sequence: 42}, "")
+	r.NoError(err)
+
+	encoded, err := proto.Marshal(txMsg)
+	r.NoError(err)
+	encoded = agentgrpc.AppendEventExtension(encoded, agentgrpc.EventExtension{Sequence: 42, Trimmed: []string{"traces", "logs"}})
+	ext, err := agentgrpc.ReadEventExtension(encoded)
+	r.NoError(err)
+	r.Equal(uint64(42), ext.Sequence)
+	r.Equal([]string{"traces", "logs"}, ext.Trimmed)
+	r.False(ext.Tick)
+
+	tickEncoded := agentgrpc.AppendEventExtension(encoded, agentgrpc.EventExtension{Tick: true})
+	ext, err = agentgrpc.ReadEventExtension(tickEncoded)
+	r.NoError(err)
+	r.True(ext.Tick)
+	r.False(ext.Pending)
+
+	pendingEncoded := agentgrpc.AppendEventExtension(encoded, agentgrpc.EventExtension{Pending: true})
+	ext, err = agentgrpc.ReadEventExtension(pendingEncoded)
+	r.NoError(err)
+	r.True(ext.Pending)
+
+	// the bots which do not know about the extension should see the same request
+	var decoded protocol.EvaluateTxRequest
+	r.NoError(proto.Unmarshal(encoded, &decoded))
+	r.Equal(txMsg.RequestId, decoded.RequestId)
+	r.Equal(txMsg.Event.Type, decoded.Event.Type)
+	r.Equal(txMsg.Event.Transaction.Hash, decoded.Event.Transaction.Hash)
+}
+
+func TestEncodeRequest_Compression(t *testing.T) {
+	for _, compression := range []string{agentgrpc.CompressionGzip, agentgrpc.CompressionZstd} {
+		t.Run(compression, func(t *testing.T) {
+			r := require.New(t)
+
+			preparedMsg, err := agentgrpc.EncodeRequest(txMsg, agentgrpc.EventExtension{Sequence: 42}, compression)
+			r.NoError(err)
+			size, wireSize := agentgrpc.MessageSize(preparedMsg)
+			r.Greater(size, 0)
+			r.Greater(wireSize, 0)
+
+			lis, err := net.Listen("tcp", "localhost:0")
+			r.NoError(err)
+			defer lis.Close()
+
+			server := grpc.NewServer()
+			defer server.Stop()
+			as := &agentServer{r: r, doneCh: make(chan struct{})}
+			protocol.RegisterAgentServer(server, as)
+			go server.Serve(lis)
+
+			agentClient := agentgrpc.NewClient()
+			conn, err := grpc.Dial(
+				lis.Addr().String(), grpc.WithInsecure(),
+				grpc.WithDefaultCallOptions(grpc.UseCompressor(compression)),
+			)
+			r.NoError(err)
+			agentClient.WithConn(conn)
+
+			var resp protocol.EvaluateTxResponse
+			r.NoError(agentClient.Invoke(context.Background(), agentgrpc.MethodEvaluateTx, preparedMsg, &resp))
+			<-as.doneCh
+		})
+	}
+}
+
+func TestReadCapabilities(t *testing.T) {
+	r := require.New(t)
+
+	caps, err := agentgrpc.ReadCapabilities(&protocol.InitializeResponse{})
+	r.NoError(err)
+	r.Zero(caps.TickInterval)
+
+	encoded, err := proto.Marshal(&protocol.InitializeResponse{Status: protocol.ResponseStatus_SUCCESS})
+	r.NoError(err)
+	encoded = protowire.AppendTag(encoded, agentgrpc.InitializeFieldTickInterval, protowire.VarintType)
+	encoded = protowire.AppendVarint(encoded, 600)
+	encoded = protowire.AppendTag(encoded, agentgrpc.InitializeFieldFeedback, protowire.VarintType)
+	encoded = protowire.AppendVarint(encoded, protowire.EncodeBool(true))
+
+	// the bots which know about the capabilities should be able to send them with the response
+	var resp protocol.InitializeResponse
+	r.NoError(proto.Unmarshal(encoded, &resp))
+	r.Equal(protocol.ResponseStatus_SUCCESS, resp.Status)
+	caps, err = agentgrpc.ReadCapabilities(&resp)
+	r.NoError(err)
+	r.Equal(time.Minute*10, caps.TickInterval)
+	r.True(caps.Feedback)
+}
+
+func TestFeedback(t *testing.T) {
+	r := require.New(t)
+
+	feedback := &agentgrpc.Feedback{
+		RequestID: "123",
+		Errors: []agentgrpc.FindingError{
+			{Index: 0, AlertID: "ALERT-1", Field: "severity", Message: "unknown severity: 10"},
+			{Index: 2, Field: "alertId", Message: "empty alert id"},
+		},
+	}
+	decoded, err := agentgrpc.DecodeFeedback(agentgrpc.MarshalFeedback(feedback))
+	r.NoError(err)
+	r.Equal(*feedback, decoded)
+
+	feedback = &agentgrpc.Feedback{RequestID: "456", CancelReason: agentgrpc.CancelReasonReorg}
+	decoded, err = agentgrpc.DecodeFeedback(agentgrpc.MarshalFeedback(feedback))
+	r.NoError(err)
+	r.Equal(*feedback, decoded)
+}
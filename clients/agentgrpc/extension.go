@@ -0,0 +1,136 @@
+package agentgrpc
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Event extension fields are appended by the node to the event of an evaluation request. They use
+// field numbers which are not used by the protocol messages so the bots which use an older version
+// of the protocol ignore them as unknown fields.
+const (
+	EventFieldSequence protowire.Number = 1000
+	EventFieldTrimmed  protowire.Number = 1001
+	EventFieldTick     protowire.Number = 1002
+	EventFieldPending  protowire.Number = 1003
+)
+
+// requestFieldEvent is the field number of the event in all evaluation requests.
+const requestFieldEvent protowire.Number = 2
+
+// EventExtension contains the node-specific event data which is not a part of the protocol messages.
+type EventExtension struct {
+	// Sequence is the position of the event in its stream (tx, block or alert). It increases
+	// by one with every event so the bots can detect the order and the skipped events.
+	Sequence uint64
+	// Trimmed contains the names of the event fields which were removed because the request
+	// was too large. The trimmed data can be fetched from the JSON-RPC API.
+	Trimmed []string
+	// Tick tells that the block event is a scheduled evaluation of the latest block and not a new block.
+	Tick bool
+	// Pending tells that the transaction is in the mempool and not mined yet. The block fields
+	// of a pending transaction event are empty and the sequence is separate from the mined transactions.
+	Pending bool
+}
+
+// IsEmpty tells if there is nothing to append.
+func (ext *EventExtension) IsEmpty() bool {
+	return ext.Sequence == 0 && len(ext.Trimmed) == 0 && !ext.Tick && !ext.Pending
+}
+
+// AppendEventExtension appends the extension fields to the event of an encoded evaluation request.
+// An embedded message which appears more than once in the encoding is merged by the decoders,
+// so appending another event field with only the extension fields extends the original event.
+func AppendEventExtension(encodedReq []byte, ext EventExtension) []byte {
+	if ext.IsEmpty() {
+		return encodedReq
+	}
+	var eventB []byte
+	if ext.Sequence > 0 {
+		eventB = protowire.AppendTag(eventB, EventFieldSequence, protowire.VarintType)
+		eventB = protowire.AppendVarint(eventB, ext.Sequence)
+	}
+	for _, field := range ext.Trimmed {
+		eventB = protowire.AppendTag(eventB, EventFieldTrimmed, protowire.BytesType)
+		eventB = protowire.AppendString(eventB, field)
+	}
+	if ext.Tick {
+		eventB = protowire.AppendTag(eventB, EventFieldTick, protowire.VarintType)
+		eventB = protowire.AppendVarint(eventB, protowire.EncodeBool(true))
+	}
+	if ext.Pending {
+		eventB = protowire.AppendTag(eventB, EventFieldPending, protowire.VarintType)
+		eventB = protowire.AppendVarint(eventB, protowire.EncodeBool(true))
+	}
+	b := make([]byte, len(encodedReq), len(encodedReq)+len(eventB)+8)
+	copy(b, encodedReq)
+	b = protowire.AppendTag(b, requestFieldEvent, protowire.BytesType)
+	return protowire.AppendBytes(b, eventB)
+}
+
+// ReadEventExtension reads the extension fields from an encoded evaluation request.
+func ReadEventExtension(encodedReq []byte) (ext EventExtension, err error) {
+	err = consumeFields(encodedReq, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num != requestFieldEvent || typ != protowire.BytesType {
+			return nil
+		}
+		eventB, n := protowire.ConsumeBytes(value)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		return consumeFields(eventB, func(num protowire.Number, typ protowire.Type, value []byte) error {
+			switch {
+			case num == EventFieldSequence && typ == protowire.VarintType:
+				seq, n := protowire.ConsumeVarint(value)
+				if n < 0 {
+					return protowire.ParseError(n)
+				}
+				ext.Sequence = seq
+
+			case num == EventFieldTrimmed && typ == protowire.BytesType:
+				field, n := protowire.ConsumeString(value)
+				if n < 0 {
+					return protowire.ParseError(n)
+				}
+				ext.Trimmed = append(ext.Trimmed, field)
+
+			case num == EventFieldTick && typ == protowire.VarintType:
+				tick, n := protowire.ConsumeVarint(value)
+				if n < 0 {
+					return protowire.ParseError(n)
+				}
+				ext.Tick = protowire.DecodeBool(tick)
+
+			case num == EventFieldPending && typ == protowire.VarintType:
+				pending, n := protowire.ConsumeVarint(value)
+				if n < 0 {
+					return protowire.ParseError(n)
+				}
+				ext.Pending = protowire.DecodeBool(pending)
+			}
+			return nil
+		})
+	})
+	return
+}
+
+// consumeFields calls the handler with each field and the remaining bytes starting from the field value.
+func consumeFields(b []byte, handler func(num protowire.Number, typ protowire.Type, value []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("invalid tag: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+		if err := handler(num, typ, b); err != nil {
+			return err
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return fmt.Errorf("invalid field %d: %v", num, protowire.ParseError(n))
+		}
+		b = b[n:]
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package agentgrpc
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Feedback message fields. There is no feedback message in the protocol yet, so the message is
+// encoded here and the bots which declare the feedback capability decode it with the same numbers.
+const (
+	FeedbackFieldRequestID protowire.Number = 1
+	FeedbackFieldErrors    protowire.Number = 2
+	FeedbackFieldCancel    protowire.Number = 3
+
+	FindingErrorFieldIndex   protowire.Number = 1
+	FindingErrorFieldAlertID protowire.Number = 2
+	FindingErrorFieldField   protowire.Number = 3
+	FindingErrorFieldMessage protowire.Number = 4
+)
+
+// FindingError is the reason why a finding returned by a bot was rejected.
+type FindingError struct {
+	// Index is the position of the finding in the response.
+	Index   int
+	AlertID string
+	// Field is the name of the invalid finding field.
+	Field   string
+	Message string
+}
+
+// Cancellation reasons tell the bots why the node stopped waiting for an evaluation. The gRPC
+// cancellation reaches the bot without a reason, so the reason follows with the feedback method.
+const (
+	CancelReasonReorg   = "reorg"
+	CancelReasonPaused  = "paused"
+	CancelReasonTimeout = "timeout"
+)
+
+// Feedback contains the rejected findings of an evaluation response or the reason why the
+// evaluation was cancelled.
+type Feedback struct {
+	RequestID string
+	Errors    []FindingError
+	// CancelReason is not empty if the node cancelled the evaluation because it became irrelevant.
+	CancelReason string
+}
+
+// EncodeFeedback encodes the feedback as a PreparedMsg which can be sent with the feedback method.
+func EncodeFeedback(feedback *Feedback) *grpc.PreparedMsg {
+	b := MarshalFeedback(feedback)
+	return prepareMessage(b, b, false)
+}
+
+// MarshalFeedback encodes the feedback message.
+func MarshalFeedback(feedback *Feedback) []byte {
+	var b []byte
+	if len(feedback.RequestID) > 0 {
+		b = protowire.AppendTag(b, FeedbackFieldRequestID, protowire.BytesType)
+		b = protowire.AppendString(b, feedback.RequestID)
+	}
+	for _, findingErr := range feedback.Errors {
+		var errB []byte
+		errB = protowire.AppendTag(errB, FindingErrorFieldIndex, protowire.VarintType)
+		errB = protowire.AppendVarint(errB, uint64(findingErr.Index))
+		if len(findingErr.AlertID) > 0 {
+			errB = protowire.AppendTag(errB, FindingErrorFieldAlertID, protowire.BytesType)
+			errB = protowire.AppendString(errB, findingErr.AlertID)
+		}
+		if len(findingErr.Field) > 0 {
+			errB = protowire.AppendTag(errB, FindingErrorFieldField, protowire.BytesType)
+			errB = protowire.AppendString(errB, findingErr.Field)
+		}
+		errB = protowire.AppendTag(errB, FindingErrorFieldMessage, protowire.BytesType)
+		errB = protowire.AppendString(errB, findingErr.Message)
+
+		b = protowire.AppendTag(b, FeedbackFieldErrors, protowire.BytesType)
+		b = protowire.AppendBytes(b, errB)
+	}
+	if len(feedback.CancelReason) > 0 {
+		b = protowire.AppendTag(b, FeedbackFieldCancel, protowire.BytesType)
+		b = protowire.AppendString(b, feedback.CancelReason)
+	}
+	return b
+}
+
+// DecodeFeedback decodes an encoded feedback message.
+func DecodeFeedback(b []byte) (feedback Feedback, err error) {
+	err = consumeFields(b, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		fieldB, n := protowire.ConsumeBytes(value)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		switch num {
+		case FeedbackFieldRequestID:
+			feedback.RequestID = string(fieldB)
+
+		case FeedbackFieldErrors:
+			findingErr, err := decodeFindingError(fieldB)
+			if err != nil {
+				return err
+			}
+			feedback.Errors = append(feedback.Errors, findingErr)
+
+		case FeedbackFieldCancel:
+			feedback.CancelReason = string(fieldB)
+		}
+		return nil
+	})
+	return
+}
+
+func decodeFindingError(b []byte) (findingErr FindingError, err error) {
+	err = consumeFields(b, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num == FindingErrorFieldIndex && typ == protowire.VarintType {
+			index, n := protowire.ConsumeVarint(value)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			findingErr.Index = int(index)
+			return nil
+		}
+		if typ != protowire.BytesType {
+			return nil
+		}
+		str, n := protowire.ConsumeString(value)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		switch num {
+		case FindingErrorFieldAlertID:
+			findingErr.AlertID = str
+		case FindingErrorFieldField:
+			findingErr.Field = str
+		case FindingErrorFieldMessage:
+			findingErr.Message = str
+		}
+		return nil
+	})
+	return
+}
@@ -4,11 +4,17 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,9 +22,14 @@ import (
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
 	"github.com/forta-network/forta-core-go/utils/workers"
 	"github.com/forta-network/forta-node/config"
 	log "github.com/sirupsen/logrus"
@@ -30,6 +41,14 @@ const (
 	DockerLabelFortaSupervisorStrategyVersion = "network.forta.supervisor.strategy-version"
 
 	DockerLabelFortaSettingsAgentLogsEnable = "network.forta.settings.agent-logs.enable"
+
+	DockerLabelFortaVersion = "network.forta.version"
+	// the hash of the container config which tells if an existing container is stale
+	DockerLabelFortaConfigHash = "network.forta.config-hash"
+
+	DockerLabelFortaBotID        = "network.forta.bot.id"
+	DockerLabelFortaBotImageHash = "network.forta.bot.image-hash"
+	DockerLabelFortaBotChainID   = "network.forta.bot.chain-id"
 )
 
 type dockerLabel struct {
@@ -72,6 +91,27 @@ type DockerContainerConfig struct {
 	Cmd             []string
 	DialHost        bool
 	Labels          map[string]string
+	DNS             []string
+	DNSSearch       []string
+	ExtraHosts      []string // in "host:ip" format
+	User            string
+	NoNewPrivileges bool
+	ShmSize         int64             // in bytes
+	Tmpfs           map[string]string // mount path -> options
+	Ulimits         map[string]int64  // name -> soft and hard limit
+	Runtime         string            // e.g. "nvidia" for exposing the GPUs
+	RestartPolicy   string            // e.g. "unless-stopped" for starting with the Docker daemon
+}
+
+// DockerVolumeConfig is the configuration of a named volume.
+type DockerVolumeConfig struct {
+	Name       string
+	Driver     string
+	DriverOpts map[string]string
+	Labels     map[string]string
+	// the root of the new volume is chowned to the owner by a short-lived container of the init image
+	Owner     string
+	InitImage string
 }
 
 // DockerContainerList contains the full container data.
@@ -125,6 +165,18 @@ func (cfg DockerContainerConfig) envVars() []string {
 	return results
 }
 
+// ConfigHash returns the hash of the settings which need a new container when they change. The files and
+// the networks are not included since they are updated on the existing containers.
+func (cfg DockerContainerConfig) ConfigHash() string {
+	hashed := cfg
+	hashed.Files = nil
+	hashed.NetworkID = ""
+	hashed.LinkNetworkIDs = nil
+	b, _ := json.Marshal(hashed)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
 func registryAuthValue(username, password string) string {
 	if username == "" && password == "" {
 		return ""
@@ -183,15 +235,211 @@ func (d *dockerClient) Prune(ctx context.Context) error {
 	return nil
 }
 
+// EnsureVolume creates the named volume if it does not exist.
+func (d *dockerClient) EnsureVolume(ctx context.Context, config DockerVolumeConfig) error {
+	if _, err := d.cli.VolumeInspect(ctx, config.Name); err == nil {
+		return nil
+	} else if !client.IsErrNotFound(err) {
+		return err
+	}
+	labels := labelsToMap(d.labels)
+	for k, v := range config.Labels {
+		labels[k] = v
+	}
+	_, err := d.cli.VolumeCreate(ctx, volume.VolumeCreateBody{
+		Name:       config.Name,
+		Driver:     config.Driver,
+		DriverOpts: config.DriverOpts,
+		Labels:     labels,
+	})
+	if err != nil {
+		return err
+	}
+	if len(config.Owner) > 0 {
+		if err := d.chownVolume(ctx, config); err != nil {
+			// remove the volume so that the ownership is set again on the next attempt
+			_ = d.RemoveVolume(context.Background(), config.Name)
+			return err
+		}
+	}
+	log.WithField("volume", config.Name).Info("created volume")
+	return nil
+}
+
+// chownVolume makes the volume writable by the non-root user of the containers which mount it.
+func (d *dockerClient) chownVolume(ctx context.Context, config DockerVolumeConfig) error {
+	const mountPath = "/volume"
+	cont, err := d.cli.ContainerCreate(
+		ctx,
+		&container.Config{
+			Image:      config.InitImage,
+			User:       "0:0",
+			Entrypoint: []string{"chown"},
+			Cmd:        []string{config.Owner, mountPath},
+			Labels:     labelsToMap(d.labels),
+		},
+		&container.HostConfig{
+			Binds:       []string{config.Name + ":" + mountPath},
+			NetworkMode: "none",
+		}, nil, "",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create the volume init container: %v", err)
+	}
+	defer d.RemoveContainer(context.Background(), cont.ID)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	statusCh, errCh := d.cli.ContainerWait(ctx, cont.ID, container.WaitConditionNextExit)
+	if err := d.cli.ContainerStart(ctx, cont.ID, types.ContainerStartOptions{}); err != nil {
+		return fmt.Errorf("failed to start the volume init container: %v", err)
+	}
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("failed while waiting for the volume init container: %v", err)
+	case status := <-statusCh:
+		if status.StatusCode == 0 {
+			return nil
+		}
+		logs, _ := d.GetContainerLogs(ctx, cont.ID, "10", 1000)
+		return fmt.Errorf("failed to chown the volume (exit code %d): %s", status.StatusCode, logs)
+	}
+}
+
+// GetVolumes returns the volumes created by this client.
+func (d *dockerClient) GetVolumes(ctx context.Context) ([]*types.Volume, error) {
+	res, err := d.cli.VolumeList(ctx, d.labelFilter())
+	if err != nil {
+		return nil, err
+	}
+	return res.Volumes, nil
+}
+
+// RemoveVolume removes the named volume.
+func (d *dockerClient) RemoveVolume(ctx context.Context, name string) error {
+	if err := d.cli.VolumeRemove(ctx, name, false); err != nil {
+		return err
+	}
+	log.WithField("volume", name).Info("removed volume")
+	return nil
+}
+
+// Container event actions
+const (
+	DockerEventOOM     = "oom"
+	DockerEventDie     = "die"
+	DockerEventStart   = "start"
+	DockerEventRestart = "restart"
+	// the health status events are normalized from "health_status: <status>"
+	DockerEventHealthStatus = "health_status"
+)
+
+// DockerContainerEvent is a lifecycle event of a container.
+type DockerContainerEvent struct {
+	Action        string
+	ContainerID   string
+	ContainerName string
+	// the container labels and the event details like the exit code
+	Attributes map[string]string
+	Time       time.Time
+}
+
+// HealthStatus returns the status of a health_status event.
+func (evt *DockerContainerEvent) HealthStatus() string {
+	return evt.Attributes["healthStatus"]
+}
+
+// ExitCode returns the exit code of a die event.
+func (evt *DockerContainerEvent) ExitCode() int {
+	exitCode, _ := strconv.Atoi(evt.Attributes["exitCode"])
+	return exitCode
+}
+
+// ContainerEvents streams the OOM kill, exit, start, restart and health status events of the containers
+// with the client labels until the context is done or the stream fails.
+func (d *dockerClient) ContainerEvents(ctx context.Context) (<-chan *DockerContainerEvent, <-chan error) {
+	filter := d.labelFilter()
+	filter.Add("type", events.ContainerEventType)
+	for _, action := range []string{DockerEventOOM, DockerEventDie, DockerEventStart, DockerEventRestart, DockerEventHealthStatus} {
+		filter.Add("event", action)
+	}
+	msgCh, errCh := d.cli.Events(ctx, types.EventsOptions{Filters: filter})
+
+	eventCh := make(chan *DockerContainerEvent)
+	streamErrCh := make(chan error, 1)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				streamErrCh <- ctx.Err()
+				return
+			case err := <-errCh:
+				streamErrCh <- err
+				return
+			case msg := <-msgCh:
+				evt := toDockerContainerEvent(msg)
+				select {
+				case <-ctx.Done():
+					streamErrCh <- ctx.Err()
+					return
+				case eventCh <- evt:
+				}
+			}
+		}
+	}()
+	return eventCh, streamErrCh
+}
+
+func toDockerContainerEvent(msg events.Message) *DockerContainerEvent {
+	evt := &DockerContainerEvent{
+		Action:        msg.Action,
+		ContainerID:   msg.Actor.ID,
+		ContainerName: msg.Actor.Attributes["name"],
+		Attributes:    msg.Actor.Attributes,
+		Time:          time.Unix(0, msg.TimeNano),
+	}
+	if evt.Attributes == nil {
+		evt.Attributes = make(map[string]string)
+	}
+	if status := strings.TrimPrefix(msg.Action, DockerEventHealthStatus+":"); status != msg.Action {
+		evt.Action = DockerEventHealthStatus
+		evt.Attributes["healthStatus"] = strings.TrimSpace(status)
+	}
+	return evt
+}
+
 func (d *dockerClient) CreatePublicNetwork(ctx context.Context, name string) (string, error) {
-	return d.createNetwork(ctx, name, false)
+	return d.createNetwork(ctx, name, false, "")
 }
 
 func (d *dockerClient) CreateInternalNetwork(ctx context.Context, name string) (string, error) {
-	return d.createNetwork(ctx, name, true)
+	return d.createNetwork(ctx, name, true, "")
+}
+
+// CreatePublicNetworkWithSubnet creates a public network with the given subnet, if the network does not exist.
+func (d *dockerClient) CreatePublicNetworkWithSubnet(ctx context.Context, name, subnet string) (string, error) {
+	return d.createNetwork(ctx, name, false, subnet)
+}
+
+// GetNetworkSubnets returns the subnets of all networks.
+func (d *dockerClient) GetNetworkSubnets(ctx context.Context) ([]string, error) {
+	networks, err := d.cli.NetworkList(ctx, types.NetworkListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var subnets []string
+	for _, network := range networks {
+		for _, ipamCfg := range network.IPAM.Config {
+			if len(ipamCfg.Subnet) > 0 {
+				subnets = append(subnets, ipamCfg.Subnet)
+			}
+		}
+	}
+	return subnets, nil
 }
 
-func (d *dockerClient) createNetwork(ctx context.Context, name string, internal bool) (string, error) {
+func (d *dockerClient) createNetwork(ctx context.Context, name string, internal bool, subnet string) (string, error) {
 	// Reuse if network exists.
 	networks, err := d.cli.NetworkList(ctx, types.NetworkListOptions{})
 	if err != nil {
@@ -203,10 +451,14 @@ func (d *dockerClient) createNetwork(ctx context.Context, name string, internal
 		}
 	}
 
-	resp, err := d.cli.NetworkCreate(ctx, name, types.NetworkCreate{
+	networkCreate := types.NetworkCreate{
 		Labels:   labelsToMap(d.labels),
 		Internal: internal,
-	})
+	}
+	if len(subnet) > 0 {
+		networkCreate.IPAM = &network.IPAM{Config: []network.IPAMConfig{{Subnet: subnet}}}
+	}
+	resp, err := d.cli.NetworkCreate(ctx, name, networkCreate)
 	if err != nil {
 		return "", err
 	}
@@ -241,6 +493,20 @@ func withTcp(port string) string {
 	return fmt.Sprintf("%s/tcp", port)
 }
 
+// toUlimits converts the ulimits to the Docker ulimits with the same soft and hard limits.
+func toUlimits(ulimits map[string]int64) []*units.Ulimit {
+	names := make([]string, 0, len(ulimits))
+	for name := range ulimits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var dockerUlimits []*units.Ulimit
+	for _, name := range names {
+		dockerUlimits = append(dockerUlimits, &units.Ulimit{Name: name, Soft: ulimits[name], Hard: ulimits[name]})
+	}
+	return dockerUlimits
+}
+
 // copyFile copies content bytes into container at given file path.
 func copyFile(cli *client.Client, ctx context.Context, filePath string, content []byte, containerId string) error {
 	if len(filePath) == 0 {
@@ -405,6 +671,17 @@ func (d *dockerClient) StartContainer(ctx context.Context, config DockerContaine
 			break
 		}
 	}
+	configHash := config.ConfigHash()
+	if foundContainer != nil && foundContainer.Labels[DockerLabelFortaConfigHash] != configHash {
+		log.WithFields(log.Fields{
+			"id":   foundContainer.ID,
+			"name": config.Name,
+		}).Info("container config has changed - recreating the container")
+		if err := d.RemoveContainer(ctx, foundContainer.ID); err != nil {
+			return nil, fmt.Errorf("failed to remove the stale container: %v", err)
+		}
+		foundContainer = nil
+	}
 	if foundContainer != nil {
 		if err := d.cli.ContainerStart(ctx, foundContainer.ID, types.ContainerStartOptions{}); err != nil {
 			return nil, err
@@ -461,11 +738,16 @@ func (d *dockerClient) StartContainer(ctx context.Context, config DockerContaine
 	for k, v := range config.Labels {
 		cntCfg.Labels[k] = v
 	}
+	cntCfg.Labels[DockerLabelFortaConfigHash] = configHash
 
 	if len(config.Cmd) > 0 {
 		cntCfg.Cmd = config.Cmd
 	}
 
+	if len(config.User) > 0 {
+		cntCfg.User = config.User
+	}
+
 	hostCfg := &container.HostConfig{
 		NetworkMode:     container.NetworkMode(config.NetworkID),
 		PortBindings:    bindings,
@@ -481,9 +763,23 @@ func (d *dockerClient) StartContainer(ctx context.Context, config DockerContaine
 		Resources: container.Resources{
 			CPUQuota: config.CPUQuota,
 			Memory:   config.Memory,
+			Ulimits:  toUlimits(config.Ulimits),
+		},
+		DNS:        config.DNS,
+		DNSSearch:  config.DNSSearch,
+		ExtraHosts: config.ExtraHosts,
+		ShmSize:    config.ShmSize,
+		Tmpfs:      config.Tmpfs,
+		Runtime:    config.Runtime,
+		RestartPolicy: container.RestartPolicy{
+			Name: config.RestartPolicy,
 		},
 	}
 
+	if config.NoNewPrivileges {
+		hostCfg.SecurityOpt = append(hostCfg.SecurityOpt, "no-new-privileges")
+	}
+
 	if config.DialHost {
 		hostCfg.ExtraHosts = append(hostCfg.ExtraHosts, "host.docker.internal:host-gateway")
 	}
@@ -557,6 +853,21 @@ func (d *dockerClient) stopContainer(ctx context.Context, containerID, signal st
 	return err
 }
 
+// ReloadContainer sends the config reload signal to a container.
+func (d *dockerClient) ReloadContainer(ctx context.Context, containerID string) error {
+	log.WithField("id", containerID).Info("sending reload signal to container")
+	return d.SignalContainer(ctx, containerID, "SIGHUP")
+}
+
+// SignalContainer sends a signal to a container. It ignores the containers which are not running.
+func (d *dockerClient) SignalContainer(ctx context.Context, containerID, signal string) error {
+	err := d.cli.ContainerKill(ctx, containerID, signal)
+	if err == nil || isNoSuchContainerErr(err) || isNotRunningErr(err) {
+		return nil
+	}
+	return err
+}
+
 // RemoveContainer kills and a container by ID.
 func (d *dockerClient) RemoveContainer(ctx context.Context, containerID string) error {
 	return d.cli.ContainerRemove(ctx, containerID, types.ContainerRemoveOptions{
@@ -666,6 +977,37 @@ func (d *dockerClient) HasLocalImage(ctx context.Context, ref string) bool {
 	return err == nil
 }
 
+// GetImageID returns the ID of the local image.
+func (d *dockerClient) GetImageID(ctx context.Context, ref string) (string, error) {
+	inspect, _, err := d.cli.ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	return inspect.ID, nil
+}
+
+// SaveImages writes the local images to the writer as a tarball.
+func (d *dockerClient) SaveImages(ctx context.Context, refs []string, w io.Writer) error {
+	r, err := d.cli.ImageSave(ctx, refs)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// LoadImages loads the images from a tarball which was written by SaveImages.
+func (d *dockerClient) LoadImages(ctx context.Context, r io.Reader) error {
+	resp, err := d.cli.ImageLoad(ctx, r, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
+
 // EnsureLocalImage ensures that we have the image locally.
 func (d *dockerClient) EnsureLocalImage(ctx context.Context, name, ref string) error {
 	log.WithFields(log.Fields{
@@ -701,6 +1043,125 @@ func (d *dockerClient) EnsureLocalImage(ctx context.Context, name, ref string) e
 	return nil
 }
 
+// EnsureImageWithPolicy makes sure that the image is available locally according to the pull policy.
+// The Always policy falls back to the local image if the pull fails, to keep working while the registry
+// is unreachable.
+func EnsureImageWithPolicy(ctx context.Context, client DockerClient, name, ref, policy string) error {
+	switch policy {
+	case config.ImagePullNever:
+		if !client.HasLocalImage(ctx, ref) {
+			return fmt.Errorf("image %s of %s not found locally and the pull policy is %s", ref, name, policy)
+		}
+		return nil
+
+	case config.ImagePullAlways:
+		err := client.PullImage(ctx, ref)
+		if err == nil {
+			log.Infof("pulled image for '%s': %s", name, ref)
+			return nil
+		}
+		log.WithFields(log.Fields{
+			"name":  name,
+			"ref":   ref,
+			"error": err,
+		}).Warn("failed to pull image - ensuring the local image")
+		return client.EnsureLocalImage(ctx, name, ref)
+
+	default:
+		return client.EnsureLocalImage(ctx, name, ref)
+	}
+}
+
+// BuildImage builds the image from the source directory and tags it with the given ref.
+func (d *dockerClient) BuildImage(ctx context.Context, sourceDir, dockerfile, ref string) error {
+	log.WithFields(log.Fields{
+		"image":  ref,
+		"source": sourceDir,
+	}).Info("building image")
+	if len(dockerfile) == 0 {
+		dockerfile = "Dockerfile"
+	}
+	buildContext, err := tarDirectory(sourceDir)
+	if err != nil {
+		return fmt.Errorf("failed to archive the source directory: %v", err)
+	}
+	res, err := d.cli.ImageBuild(ctx, buildContext, types.ImageBuildOptions{
+		Tags:       []string{ref},
+		Dockerfile: dockerfile,
+		Remove:     true,
+	})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return readBuildOutput(res.Body)
+}
+
+// tarDirectory archives the files in the directory for using as the build context.
+func tarDirectory(dir string) (io.Reader, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	err := filepath.Walk(dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		b, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    filepath.ToSlash(relPath),
+			Mode:    int64(info.Mode().Perm()),
+			Size:    int64(len(b)),
+			ModTime: info.ModTime(),
+		}); err != nil {
+			return err
+		}
+		_, err = tw.Write(b)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+type buildMessage struct {
+	Stream string `json:"stream"`
+	Error  string `json:"error"`
+}
+
+// readBuildOutput reads the build messages until the end and returns the build error, if any.
+func readBuildOutput(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	for {
+		var msg buildMessage
+		err := dec.Decode(&msg)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read the build output: %v", err)
+		}
+		if len(msg.Error) > 0 {
+			return fmt.Errorf("build failed: %s", msg.Error)
+		}
+		if stream := strings.TrimSpace(msg.Stream); len(stream) > 0 {
+			log.Debug(stream)
+		}
+	}
+}
+
 // GetContainerLogs gets the container logs.
 func (d *dockerClient) GetContainerLogs(ctx context.Context, containerID, tail string, truncate int) (string, error) {
 	r, err := d.cli.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
@@ -734,6 +1195,201 @@ func (d *dockerClient) GetContainerLogs(ctx context.Context, containerID, tail s
 	return strings.Join(lines, "\n"), nil
 }
 
+// ExecContainer runs the command in the running container and writes the output of the command
+// to the writer. The command fails if it exits with a non-zero code.
+func (d *dockerClient) ExecContainer(ctx context.Context, containerID string, cmd []string, stdout io.Writer) error {
+	exec, err := d.cli.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create the exec: %v", err)
+	}
+	resp, err := d.cli.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{})
+	if err != nil {
+		return fmt.Errorf("failed to attach to the exec: %v", err)
+	}
+	defer resp.Close()
+
+	var stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(stdout, &stderr, resp.Reader); err != nil {
+		return fmt.Errorf("failed to read the exec output: %v", err)
+	}
+	inspect, err := d.cli.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect the exec: %v", err)
+	}
+	if inspect.ExitCode != 0 {
+		return fmt.Errorf("exit code %d: %s", inspect.ExitCode, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// ContainerResourceUsage contains the resource usage of a container.
+type ContainerResourceUsage struct {
+	CPUPercent     float64
+	MemoryBytes    uint64
+	MaxMemoryBytes uint64
+}
+
+// GetContainerResourceUsage gets the current resource usage of a container.
+func (d *dockerClient) GetContainerResourceUsage(ctx context.Context, containerID string) (*ContainerResourceUsage, error) {
+	resp, err := d.cli.ContainerStats(ctx, containerID, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var stats types.StatsJSON
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("failed to decode container stats: %v", err)
+	}
+
+	usage := &ContainerResourceUsage{
+		MemoryBytes:    stats.MemoryStats.Usage,
+		MaxMemoryBytes: stats.MemoryStats.MaxUsage,
+	}
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(stats.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		usage.CPUPercent = cpuDelta / systemDelta * onlineCPUs * 100
+	}
+	return usage, nil
+}
+
+// BandwidthLimits contains the egress and the ingress limits of a container. Zero values mean no limits.
+type BandwidthLimits struct {
+	EgressKbit  int64
+	IngressKbit int64
+}
+
+// IsZero tells if there are no limits.
+func (limits BandwidthLimits) IsZero() bool {
+	return limits.EgressKbit == 0 && limits.IngressKbit == 0
+}
+
+// script returns the tc commands which apply the limits. The traffic within the subnet of the container
+// is not limited since the scanner and the other node containers talk to the bot in that network.
+func (limits BandwidthLimits) script() string {
+	if limits.IsZero() {
+		return ""
+	}
+	cmds := []string{
+		`SUBNET=$(ip -4 -o addr show dev eth0 | awk '{print $4}' | head -n 1)`,
+		`[ -n "$SUBNET" ]`,
+	}
+	if limits.EgressKbit > 0 {
+		cmds = append(cmds,
+			"tc qdisc replace dev eth0 root handle 1: htb default 20",
+			"tc class replace dev eth0 parent 1: classid 1:10 htb rate 10gbit",
+			fmt.Sprintf(
+				"tc class replace dev eth0 parent 1: classid 1:20 htb rate %dkbit ceil %dkbit burst %dkbit",
+				limits.EgressKbit, limits.EgressKbit, bandwidthBurstKbit(limits.EgressKbit),
+			),
+			`tc filter add dev eth0 parent 1: protocol ip prio 1 u32 match ip dst "$SUBNET" flowid 1:10`,
+		)
+	}
+	if limits.IngressKbit > 0 {
+		cmds = append(cmds,
+			"{ tc qdisc del dev eth0 ingress 2>/dev/null; tc qdisc add dev eth0 handle ffff: ingress; }",
+			`tc filter add dev eth0 parent ffff: protocol ip prio 1 u32 match ip src "$SUBNET" action pass`,
+			fmt.Sprintf(
+				"tc filter add dev eth0 parent ffff: protocol all prio 2 u32 match u32 0 0 police rate %dkbit burst %dkbit drop flowid :1",
+				limits.IngressKbit, bandwidthBurstKbit(limits.IngressKbit),
+			),
+		)
+	}
+	return strings.Join(cmds, " && ")
+}
+
+// bandwidthBurstKbit returns a burst size which is large enough for the rate with low kernel timer frequencies.
+func bandwidthBurstKbit(rateKbit int64) int64 {
+	burst := rateKbit / 50
+	if burst < 32 {
+		burst = 32
+	}
+	return burst
+}
+
+// LimitContainerBandwidth shapes the network traffic of a container by running tc in a short-lived
+// container which shares the network of the container. The image should contain tc.
+func (d *dockerClient) LimitContainerBandwidth(ctx context.Context, containerID, image string, limits BandwidthLimits) error {
+	script := limits.script()
+	if len(script) == 0 {
+		return nil
+	}
+
+	cont, err := d.cli.ContainerCreate(
+		ctx,
+		&container.Config{
+			Image:      image,
+			Entrypoint: []string{"/bin/sh", "-c"},
+			Cmd:        []string{script},
+			Labels:     labelsToMap(d.labels),
+		},
+		&container.HostConfig{
+			NetworkMode: container.NetworkMode(fmt.Sprintf("container:%s", containerID)),
+			CapAdd:      []string{"NET_ADMIN"},
+		}, nil, "",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create the bandwidth limiter container: %v", err)
+	}
+	defer d.RemoveContainer(context.Background(), cont.ID)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	statusCh, errCh := d.cli.ContainerWait(ctx, cont.ID, container.WaitConditionNextExit)
+	if err := d.cli.ContainerStart(ctx, cont.ID, types.ContainerStartOptions{}); err != nil {
+		return fmt.Errorf("failed to start the bandwidth limiter container: %v", err)
+	}
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("failed while waiting for the bandwidth limiter container: %v", err)
+	case status := <-statusCh:
+		if status.StatusCode == 0 {
+			return nil
+		}
+		logs, _ := d.GetContainerLogs(ctx, cont.ID, "10", 1000)
+		return fmt.Errorf("failed to limit the bandwidth (exit code %d): %s", status.StatusCode, logs)
+	}
+}
+
+// IsUsernsRemapEnabled tells if the Docker daemon remaps the user namespaces of the containers.
+func (d *dockerClient) IsUsernsRemapEnabled(ctx context.Context) (bool, error) {
+	info, err := d.cli.Info(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the docker info: %v", err)
+	}
+	for _, opt := range info.SecurityOptions {
+		if opt == "userns" || strings.Contains(opt, "name=userns") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// HostResources contains the total resources of the Docker host.
+type HostResources struct {
+	Memory int64 // in bytes
+	CPUs   int
+}
+
+// GetHostResources returns the total memory and the CPU count of the Docker host.
+func (d *dockerClient) GetHostResources(ctx context.Context) (*HostResources, error) {
+	info, err := d.cli.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the docker info: %v", err)
+	}
+	return &HostResources{Memory: info.MemTotal, CPUs: info.NCPU}, nil
+}
+
 func (d *dockerClient) labelFilter() filters.Args {
 	filter := filters.NewArgs()
 	for _, label := range d.labels {
@@ -3,6 +3,7 @@ package clients
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/forta-network/forta-core-go/domain"
 	"google.golang.org/grpc"
@@ -20,6 +21,8 @@ type DockerClient interface {
 	PullImage(ctx context.Context, refStr string) error
 	CreatePublicNetwork(ctx context.Context, name string) (string, error)
 	CreateInternalNetwork(ctx context.Context, name string) (string, error)
+	CreatePublicNetworkWithSubnet(ctx context.Context, name, subnet string) (string, error)
+	GetNetworkSubnets(ctx context.Context) ([]string, error)
 	AttachNetwork(ctx context.Context, containerID string, networkID string) error
 	RemoveNetworkByName(ctx context.Context, networkName string) error
 	GetContainers(ctx context.Context) (DockerContainerList, error)
@@ -31,6 +34,8 @@ type DockerClient interface {
 	StopContainer(ctx context.Context, id string) error
 	InterruptContainer(ctx context.Context, id string) error
 	TerminateContainer(ctx context.Context, id string) error
+	ReloadContainer(ctx context.Context, id string) error
+	SignalContainer(ctx context.Context, id, signal string) error
 	RemoveContainer(ctx context.Context, containerID string) error
 	WaitContainerExit(ctx context.Context, id string) error
 	WaitContainerStart(ctx context.Context, id string) error
@@ -39,7 +44,20 @@ type DockerClient interface {
 	Nuke(ctx context.Context) error
 	HasLocalImage(ctx context.Context, ref string) bool
 	EnsureLocalImage(ctx context.Context, name, ref string) error
+	GetImageID(ctx context.Context, ref string) (string, error)
+	SaveImages(ctx context.Context, refs []string, w io.Writer) error
+	LoadImages(ctx context.Context, r io.Reader) error
+	BuildImage(ctx context.Context, sourceDir, dockerfile, ref string) error
 	GetContainerLogs(ctx context.Context, containerID, tail string, truncate int) (string, error)
+	ExecContainer(ctx context.Context, containerID string, cmd []string, stdout io.Writer) error
+	GetContainerResourceUsage(ctx context.Context, containerID string) (*ContainerResourceUsage, error)
+	LimitContainerBandwidth(ctx context.Context, containerID, image string, limits BandwidthLimits) error
+	IsUsernsRemapEnabled(ctx context.Context) (bool, error)
+	GetHostResources(ctx context.Context) (*HostResources, error)
+	EnsureVolume(ctx context.Context, config DockerVolumeConfig) error
+	GetVolumes(ctx context.Context) ([]*types.Volume, error)
+	RemoveVolume(ctx context.Context, name string) error
+	ContainerEvents(ctx context.Context) (<-chan *DockerContainerEvent, <-chan error)
 }
 
 // MessageClient receives and publishes messages.
@@ -47,6 +65,8 @@ type MessageClient interface {
 	Subscribe(subject string, handler interface{})
 	Publish(subject string, payload interface{})
 	PublishProto(subject string, payload proto.Message)
+	Respond(subject string, handler interface{})
+	Request(subject string, payload interface{}, response interface{}, timeout time.Duration) error
 }
 
 // AgentClient makes the gRPC requests to evaluate block and txs and receive results.
@@ -0,0 +1,24 @@
+package messaging
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/tlsutils"
+	"github.com/nats-io/nats.go"
+)
+
+// containerAuthOptions returns the options which authenticate the node containers to the message bus
+// if the supervisor secured it. The token is in the env and the certificate is in the container files.
+func containerAuthOptions() ([]nats.Option, error) {
+	token := os.Getenv(config.EnvNatsToken)
+	if len(token) == 0 {
+		return nil, nil
+	}
+	tlsConfig, err := tlsutils.NatsClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the message bus tls config: %v", err)
+	}
+	return []nats.Option{nats.Token(token), nats.Secure(tlsConfig)}, nil
+}
@@ -1,9 +1,13 @@
 package messaging
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/forta-network/forta-core-go/clients/health"
 	"github.com/forta-network/forta-core-go/protocol"
 	"github.com/goccy/go-json"
 	"github.com/golang/protobuf/proto"
@@ -11,27 +15,39 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+var errNoHandler = errors.New("no handler found")
+
 // Notification and client globals
 var (
 	BufferSize = 1000
 )
 
+// CorrelationIDHeader carries the ID which the publish and the receive logs of a message are correlated by.
+const CorrelationIDHeader = "Forta-Correlation-Id"
+
 // Client wraps the NATS client to publish and receive our messages.
 type Client struct {
-	logger *log.Entry
-	nc     *nats.Conn
+	logger      *log.Entry
+	nc          *nats.Conn
+	deadLetters *deadLetterStore
+	metrics     *messageMetrics
+	tracing     bool
 }
 
-// NewClient creates and starts a new client.
-func NewClient(name, natsURL string) *Client {
+// NewClient creates and starts a new client. The client authenticates with the message bus credentials
+// of the container if there are any.
+func NewClient(name, natsURL string, opts ...nats.Option) *Client {
 	logger := log.WithField("name", fmt.Sprintf("%s/messaging", name)).WithField("nats", natsURL)
+	authOpts, err := containerAuthOptions()
+	if err != nil {
+		logger.Panic(err)
+	}
+	opts = append(authOpts, opts...)
+
 	logger.Infof("connecting to: %s", natsURL)
-	var (
-		nc  *nats.Conn
-		err error
-	)
+	var nc *nats.Conn
 	for i := 0; i < 10; i++ {
-		nc, err = nats.Connect(natsURL)
+		nc, err = nats.Connect(natsURL, opts...)
 		if err == nil {
 			break
 		}
@@ -43,103 +59,315 @@ func NewClient(name, natsURL string) *Client {
 	}
 	logger.Info("successfully connected")
 	client := &Client{
-		logger: logger,
-		nc:     nc,
+		logger:      logger,
+		nc:          nc,
+		deadLetters: newDeadLetterStore(),
+		metrics:     newMessageMetrics(),
 	}
 	return client
 }
 
+// SetTracing enables adding correlation IDs to the published messages and logging them
+// when the messages are published and received.
+func (client *Client) SetTracing(enable bool) {
+	client.tracing = enable
+}
+
+func newCorrelationID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// newMsg creates a message with a new correlation ID if the tracing is enabled.
+func (client *Client) newMsg(subject string, data []byte) (*nats.Msg, *log.Entry) {
+	logger := client.logger.WithField("subject", subject)
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	if client.tracing {
+		correlationID := newCorrelationID()
+		msg.Header.Set(CorrelationIDHeader, correlationID)
+		logger = logger.WithField("correlationId", correlationID)
+	}
+	return msg, logger
+}
+
+// msgLogger adds the correlation ID of the received message to the logger.
+func msgLogger(logger *log.Entry, m *nats.Msg) *log.Entry {
+	if m.Header == nil {
+		return logger
+	}
+	if correlationID := m.Header.Get(CorrelationIDHeader); len(correlationID) > 0 {
+		return logger.WithField("correlationId", correlationID)
+	}
+	return logger
+}
+
+// logMsg logs the message at the info level if the tracing is enabled.
+func (client *Client) logMsg(logger *log.Entry, format string, args ...interface{}) {
+	if client.tracing {
+		logger.Infof(format, args...)
+		return
+	}
+	logger.Debugf(format, args...)
+}
+
 // AgentsHandler handles agents.* subjects.
 type AgentsHandler func(AgentPayload) error
 type SubscriptionHandler func(SubscriptionPayload) error
 type AgentMetricHandler func(*protocol.AgentMetricList) error
 type InspectionResultsHandler func(results *protocol.InspectionResults) error
 type ScannerHandler func(ScannerPayload) error
+type ReorgHandler func(ReorgPayload) error
+type ContainerEventHandler func(ContainerEventPayload) error
+
+// Request handlers
+type ScannerStatusHandler func() (*ScannerStatus, error)
+type PublisherStatusHandler func() (*PublisherStatus, error)
+type ReadinessHandler func() (*Readiness, error)
 
-// Subscribe subscribes the consumer to this client.
+// replyPayload wraps the response data so the errors can be sent back to the requester.
+type replyPayload struct {
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// Subscribe subscribes the consumer to this client. A message which fails is moved to the dead-letter
+// store. The handlers are not retried because they are not all idempotent. A payload which cannot be
+// decoded or makes the handler panic is poisoned and is not handled again for a while, so that one bad
+// message does not keep failing the subscriber.
 func (client *Client) Subscribe(subject string, handler interface{}) {
-	// TODO: Configure redelivery options somehow.
 	logger := client.logger.WithField("subject", subject)
 	_, err := client.nc.Subscribe(subject, func(m *nats.Msg) {
-		logger.Debugf("received: %s", string(m.Data))
+		logger := msgLogger(logger, m)
+		client.logMsg(logger, "received: %s", string(m.Data))
+		start := time.Now()
+		err := client.handleMsg(logger, subject, m.Data, handler)
+		client.metrics.Consumed(subject, time.Since(start), err != nil)
+	})
+	if err != nil {
+		logger.Panicf("failed to subscribe: %v", err)
+	}
+	logger.Info("subscribed")
+}
+
+// handleMsg returns the error if the message was moved to the dead-letter store.
+func (client *Client) handleMsg(logger *log.Entry, subject string, data []byte, handler interface{}) error {
+	if client.deadLetters.IsPoisoned(subject, data) {
+		err := errors.New("poisoned payload")
+		client.addDeadLetter(logger, subject, data, err, false)
+		return err
+	}
+
+	poison, err := callHandler(data, handler)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, errNoHandler) {
+		logger.Panicf("no handler found")
+	}
+	client.addDeadLetter(logger, subject, data, err, poison)
+	return err
+}
+
+func (client *Client) addDeadLetter(logger *log.Entry, subject string, data []byte, err error, poison bool) {
+	logger.WithFields(log.Fields{
+		"poisoned": poison,
+		"data":     string(data),
+	}).Errorf("moved msg to dead-letter store: %v", err)
+	client.deadLetters.Add(&DeadLetter{
+		Subject:  subject,
+		Data:     data,
+		Error:    err.Error(),
+		Poisoned: poison,
+		Time:     time.Now().UTC(),
+	})
+}
+
+// callHandler decodes the payload and calls the handler. It returns true when the payload should be
+// poisoned, i.e. the payload could not be decoded or the handler panicked. The handler errors can be
+// transient so they do not poison the payload.
+func callHandler(data []byte, handler interface{}) (poison bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			poison, err = true, fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+
+	switch h := handler.(type) {
+	case AgentsHandler:
+		var payload AgentPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return true, fmt.Errorf("failed to decode payload: %v", err)
+		}
+		return false, h(payload)
+
+	case AgentMetricHandler:
+		var payload protocol.AgentMetricList
+		if err := proto.Unmarshal(data, &payload); err != nil {
+			return true, fmt.Errorf("failed to decode payload: %v", err)
+		}
+		return false, h(&payload)
+
+	case InspectionResultsHandler:
+		var payload protocol.InspectionResults
+		if err := proto.Unmarshal(data, &payload); err != nil {
+			return true, fmt.Errorf("failed to decode payload: %v", err)
+		}
+		return false, h(&payload)
+
+	case ScannerHandler:
+		var payload ScannerPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return true, fmt.Errorf("failed to decode payload: %v", err)
+		}
+		return false, h(payload)
+
+	case ReorgHandler:
+		var payload ReorgPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return true, fmt.Errorf("failed to decode payload: %v", err)
+		}
+		return false, h(payload)
+
+	case ContainerEventHandler:
+		var payload ContainerEventPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return true, fmt.Errorf("failed to decode payload: %v", err)
+		}
+		return false, h(payload)
+
+	case SubscriptionHandler:
+		var payload SubscriptionPayload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return true, fmt.Errorf("failed to decode payload: %v", err)
+		}
+		return false, h(payload)
+	}
+	return false, errNoHandler
+}
+
+// DeadLetters returns the messages which could not be handled.
+func (client *Client) DeadLetters() []*DeadLetter {
+	return client.deadLetters.List()
+}
+
+// Name implements health.Reporter interface.
+func (client *Client) Name() string {
+	return "messaging"
+}
+
+// Health implements health.Reporter interface.
+func (client *Client) Health() health.Reports {
+	return append(client.deadLetters.Health(), client.metrics.Health()...)
+}
+
+// Respond registers a handler which responds to the requests sent to a subject.
+func (client *Client) Respond(subject string, handler interface{}) {
+	logger := client.logger.WithField("subject", subject)
+	_, err := client.nc.Subscribe(subject, func(m *nats.Msg) {
+		logger := msgLogger(logger, m)
+		client.logMsg(logger, "received request: %s", string(m.Data))
+		start := time.Now()
 
-		var err error
+		var (
+			resp interface{}
+			err  error
+		)
 		switch h := handler.(type) {
-		case AgentsHandler:
-			var payload AgentPayload
-			err = json.Unmarshal(m.Data, &payload)
-			if err != nil {
-				break
-			}
-			err = h(payload)
-
-		case AgentMetricHandler:
-			var payload protocol.AgentMetricList
-			err = proto.Unmarshal(m.Data, &payload)
-			if err != nil {
-				break
-			}
-			err = h(&payload)
-
-		case InspectionResultsHandler:
-			var payload protocol.InspectionResults
-			err = proto.Unmarshal(m.Data, &payload)
-			if err != nil {
-				break
-			}
-			err = h(&payload)
-
-		case ScannerHandler:
-			var payload ScannerPayload
-			err = json.Unmarshal(m.Data, &payload)
-			if err != nil {
-				break
-			}
-			err = h(payload)
-		case SubscriptionHandler:
-			var payload SubscriptionPayload
-			err = json.Unmarshal(m.Data, &payload)
-			if err != nil {
-				break
-			}
-			err = h(payload)
+		case ScannerStatusHandler:
+			resp, err = h()
+
+		case PublisherStatusHandler:
+			resp, err = h()
+
+		case ReadinessHandler:
+			resp, err = h()
 
 		default:
-			logger.Panicf("no handler found")
+			logger.Panicf("no request handler found")
 		}
 
-		if err != nil {
-			// TODO: Replace nak with whatever is recent.
-			// if err := m.Nak() (); err != nil {
-			// 	logger.Errorf("failed to send nak: %v", err)
-			// }
-			logger.Errorf("failed to handle msg: %v", err)
+		client.metrics.Consumed(subject, time.Since(start), err != nil)
+		if err := m.Respond(encodeReply(resp, err)); err != nil {
+			logger.Errorf("failed to respond to msg: %v", err)
 		}
 	})
 	if err != nil {
-		logger.Panicf("failed to subscribe: %v", err)
+		logger.Panicf("failed to subscribe for requests: %v", err)
 	}
-	logger.Info("subscribed")
+	logger.Info("responding")
+}
+
+// Request sends a request to the subject and decodes the reply into the response.
+func (client *Client) Request(subject string, payload interface{}, response interface{}, timeout time.Duration) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %v", err)
+	}
+	reqMsg, logger := client.newMsg(subject, data)
+	client.metrics.Published(subject)
+	msg, err := client.nc.RequestMsg(reqMsg, timeout)
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	client.logMsg(logger, "received reply: %s", string(msg.Data))
+	return decodeReply(msg.Data, response)
+}
+
+// encodeReply wraps the response or the handler error into a reply payload.
+func encodeReply(resp interface{}, err error) []byte {
+	var reply replyPayload
+	if err != nil {
+		reply.Error = err.Error()
+	} else {
+		reply.Data, err = json.Marshal(resp)
+		if err != nil {
+			reply.Error = fmt.Sprintf("failed to encode response: %v", err)
+		}
+	}
+	data, _ := json.Marshal(&reply)
+	return data
+}
+
+// decodeReply unwraps the reply payload and decodes the data into the response.
+func decodeReply(data []byte, response interface{}) error {
+	var reply replyPayload
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return fmt.Errorf("failed to decode reply: %v", err)
+	}
+	if len(reply.Error) > 0 {
+		return errors.New(reply.Error)
+	}
+	if response == nil {
+		return nil
+	}
+	if err := json.Unmarshal(reply.Data, response); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
 }
 
 // Publish publishes new messages.
 func (client *Client) Publish(subject string, payload interface{}) {
-	logger := client.logger.WithField("subject", subject)
 	data, _ := json.Marshal(payload)
-	if err := client.nc.Publish(subject, data); err != nil {
-		logger.Errorf("failed to publish msg: %v", err)
-	}
-	logger.Debugf("published: %s", string(data))
+	client.publish(subject, data)
 }
 
 // PublishProto publishes new messages.
 func (client *Client) PublishProto(subject string, payload proto.Message) {
-	logger := client.logger.WithField("subject", subject)
 	data, _ := proto.Marshal(payload)
-	if err := client.nc.Publish(subject, data); err != nil {
+	client.publish(subject, data)
+}
+
+func (client *Client) publish(subject string, data []byte) {
+	msg, logger := client.newMsg(subject, data)
+	if err := client.nc.PublishMsg(msg); err != nil {
 		logger.Errorf("failed to publish msg: %v", err)
+		return
 	}
-	logger.Debugf("published: %s", string(data))
+	client.metrics.Published(subject)
+	client.logMsg(logger, "published: %s", string(data))
 }
 
 type nopClient struct{}
@@ -159,3 +387,11 @@ func (sc *nopClient) Publish(subject string, payload interface{}) {
 func (sc *nopClient) PublishProto(subject string, payload proto.Message) {
 
 }
+
+func (sc *nopClient) Respond(subject string, handler interface{}) {
+
+}
+
+func (sc *nopClient) Request(subject string, payload interface{}, response interface{}, timeout time.Duration) error {
+	return nil
+}
@@ -0,0 +1,201 @@
+package messaging
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecodeReply(t *testing.T) {
+	r := require.New(t)
+
+	var status ScannerStatus
+	r.NoError(decodeReply(encodeReply(&ScannerStatus{LatestBlockInput: 123, LaggingAgents: 2}, nil), &status))
+	r.Equal(uint64(123), status.LatestBlockInput)
+	r.Equal(2, status.LaggingAgents)
+
+	err := decodeReply(encodeReply(nil, errors.New("some error")), &status)
+	r.EqualError(err, "some error")
+
+	r.NoError(decodeReply(encodeReply(&ScannerStatus{}, nil), nil))
+
+	err = decodeReply([]byte("not json"), &status)
+	r.Error(err)
+	r.Contains(err.Error(), "failed to decode reply")
+}
+
+func TestRequestRespond(t *testing.T) {
+	r := require.New(t)
+
+	natsServer, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	r.NoError(err)
+	go natsServer.Start()
+	defer natsServer.Shutdown()
+	r.True(natsServer.ReadyForConnections(5 * time.Second))
+
+	client := NewClient("test", natsServer.ClientURL())
+	client.Respond(SubjectScannerStatusRequest, ScannerStatusHandler(func() (*ScannerStatus, error) {
+		return &ScannerStatus{LatestBlockInput: 10}, nil
+	}))
+	client.Respond(SubjectPublisherStatusRequest, PublisherStatusHandler(func() (*PublisherStatus, error) {
+		return nil, errors.New("not ready")
+	}))
+
+	var scannerStatus ScannerStatus
+	r.NoError(client.Request(SubjectScannerStatusRequest, nil, &scannerStatus, time.Second))
+	r.Equal(uint64(10), scannerStatus.LatestBlockInput)
+
+	var publisherStatus PublisherStatus
+	r.EqualError(client.Request(SubjectPublisherStatusRequest, nil, &publisherStatus, time.Second), "not ready")
+
+	err = client.Request("some.subject.without.responders", nil, nil, 100*time.Millisecond)
+	r.Error(err)
+	r.Contains(err.Error(), "request failed")
+}
+
+func TestSubscribe_DeadLetter(t *testing.T) {
+	r := require.New(t)
+
+	natsServer, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	r.NoError(err)
+	go natsServer.Start()
+	defer natsServer.Shutdown()
+	r.True(natsServer.ReadyForConnections(5 * time.Second))
+
+	client := NewClient("test", natsServer.ClientURL())
+
+	var (
+		calls   = make(map[string]int)
+		handled = make(chan string, 10)
+	)
+	client.Subscribe(SubjectAgentsActionRun, AgentsHandler(func(payload AgentPayload) error {
+		id := payload[0].ID
+		calls[id]++
+		switch id {
+		case "panic":
+			panic("bad payload")
+		case "flaky":
+			if calls[id] == 1 {
+				return errors.New("failed once")
+			}
+		}
+		handled <- id
+		return nil
+	}))
+
+	client.Publish(SubjectAgentsActionRun, AgentPayload{{ID: "panic"}})
+	client.Publish(SubjectAgentsActionRun, AgentPayload{{ID: "flaky"}})
+	client.Publish(SubjectAgentsActionRun, AgentPayload{{ID: "panic"}})
+	client.Publish(SubjectAgentsActionRun, "not a payload")
+	client.Publish(SubjectAgentsActionRun, AgentPayload{{ID: "flaky"}})
+	client.Publish(SubjectAgentsActionRun, AgentPayload{{ID: "ok"}})
+
+	// the same payload is handled again after a handler error
+	r.Equal("flaky", <-handled)
+	r.Equal("ok", <-handled)
+
+	// the panicking payload was isolated after the first time
+	r.Equal(1, calls["panic"])
+	r.Equal(2, calls["flaky"])
+
+	deadLetters := client.DeadLetters()
+	r.Len(deadLetters, 4)
+	r.Contains(deadLetters[0].Error, "handler panicked")
+	r.True(deadLetters[0].Poisoned)
+	r.Equal(SubjectAgentsActionRun, deadLetters[0].Subject)
+	r.Equal("failed once", deadLetters[1].Error)
+	r.False(deadLetters[1].Poisoned)
+	r.Equal("poisoned payload", deadLetters[2].Error)
+	r.Contains(deadLetters[3].Error, "failed to decode payload")
+	r.True(deadLetters[3].Poisoned)
+
+	reports := client.Health()
+	r.Equal("dead-letter.depth", reports[0].Name)
+	r.Equal("4", reports[0].Details)
+}
+
+func TestDeadLetterStore_Limit(t *testing.T) {
+	r := require.New(t)
+
+	store := newDeadLetterStore()
+	for i := 0; i < DeadLetterLimit+1; i++ {
+		store.Add(&DeadLetter{Subject: "test", Data: []byte{byte(i)}, Poisoned: i > 0})
+	}
+	r.Len(store.List(), DeadLetterLimit)
+	r.False(store.IsPoisoned("test", []byte{0}))
+	r.True(store.IsPoisoned("test", []byte{1}))
+	r.False(store.IsPoisoned("other", []byte{1}))
+}
+
+func TestDeadLetterStore_PoisonTTL(t *testing.T) {
+	r := require.New(t)
+
+	defaultTTL := PoisonTTL
+	defer func() {
+		PoisonTTL = defaultTTL
+	}()
+	PoisonTTL = time.Millisecond * 10
+
+	store := newDeadLetterStore()
+	store.Add(&DeadLetter{Subject: "test", Data: []byte{1}, Poisoned: true})
+	r.True(store.IsPoisoned("test", []byte{1}))
+	time.Sleep(PoisonTTL * 2)
+	r.False(store.IsPoisoned("test", []byte{1}))
+}
+
+func TestSubscribe_Tracing(t *testing.T) {
+	r := require.New(t)
+
+	natsServer, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	r.NoError(err)
+	go natsServer.Start()
+	defer natsServer.Shutdown()
+	r.True(natsServer.ReadyForConnections(5 * time.Second))
+
+	client := NewClient("test", natsServer.ClientURL())
+	client.SetTracing(true)
+
+	correlationIDs := make(chan string, 1)
+	_, err = client.nc.Subscribe(SubjectScannerBlock, func(m *nats.Msg) {
+		correlationIDs <- m.Header.Get(CorrelationIDHeader)
+	})
+	r.NoError(err)
+	handled := make(chan struct{}, 1)
+	client.Subscribe(SubjectScannerBlock, ScannerHandler(func(payload ScannerPayload) error {
+		handled <- struct{}{}
+		return nil
+	}))
+
+	client.Publish(SubjectScannerBlock, &ScannerPayload{LatestBlockInput: 1})
+	r.Len(<-correlationIDs, 16)
+	<-handled
+
+	// the metrics are counted after the handler returns
+	r.Eventually(func() bool {
+		for _, report := range client.Health() {
+			if report.Name == "subject.scanner.block" {
+				return strings.HasPrefix(report.Details, "published=1 consumed=1 failed=0")
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestSubjectMetrics_Quantile(t *testing.T) {
+	r := require.New(t)
+
+	var sm subjectMetrics
+	r.Equal("-", sm.quantile(0.5))
+	for i := 0; i < 98; i++ {
+		sm.observeLatency(time.Millisecond * 3)
+	}
+	sm.observeLatency(time.Millisecond * 200)
+	sm.observeLatency(time.Second * 10)
+	r.Equal("<=5ms", sm.quantile(0.5))
+	r.Equal(">5s", sm.quantile(0.99))
+}
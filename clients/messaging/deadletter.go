@@ -0,0 +1,112 @@
+package messaging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+)
+
+// Dead-letter globals
+var (
+	DeadLetterLimit = 100
+	PoisonTTL       = time.Hour
+)
+
+// DeadLetter is a message which could not be handled.
+type DeadLetter struct {
+	Subject string `json:"subject"`
+	Data    []byte `json:"data"`
+	Error   string `json:"error"`
+	// the payload could not be decoded or made the handler panic
+	Poisoned bool      `json:"poisoned"`
+	Time     time.Time `json:"time"`
+}
+
+// deadLetterStore keeps the latest dead letters and the poisoned payloads so that the same
+// payload does not reach the handler again until the poisoning expires.
+type deadLetterStore struct {
+	letters  []*DeadLetter
+	poisoned map[string]time.Time
+	total    uint64
+	mu       sync.RWMutex
+}
+
+func newDeadLetterStore() *deadLetterStore {
+	return &deadLetterStore{
+		poisoned: make(map[string]time.Time),
+	}
+}
+
+func payloadKey(subject string, data []byte) string {
+	hash := sha256.Sum256(data)
+	return fmt.Sprintf("%s/%s", subject, hex.EncodeToString(hash[:]))
+}
+
+// IsPoisoned checks if the payload was poisoned recently.
+func (store *deadLetterStore) IsPoisoned(subject string, data []byte) bool {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	expiresAt, ok := store.poisoned[payloadKey(subject, data)]
+	return ok && time.Now().Before(expiresAt)
+}
+
+// Add adds a dead letter and marks the payload as poisoned for a while if the letter is poisoned.
+// The oldest letters are dropped when the limit is exceeded.
+func (store *deadLetterStore) Add(letter *DeadLetter) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.total++
+	now := time.Now()
+	for key, expiresAt := range store.poisoned {
+		if !now.Before(expiresAt) {
+			delete(store.poisoned, key)
+		}
+	}
+	if letter.Poisoned {
+		store.poisoned[payloadKey(letter.Subject, letter.Data)] = now.Add(PoisonTTL)
+	}
+	store.letters = append(store.letters, letter)
+	if overflow := len(store.letters) - DeadLetterLimit; overflow > 0 {
+		store.letters = store.letters[overflow:]
+	}
+}
+
+// List returns a copy of the dead letters.
+func (store *deadLetterStore) List() []*DeadLetter {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	return append([]*DeadLetter(nil), store.letters...)
+}
+
+// Health implements health.Reporter interface.
+func (store *deadLetterStore) Health() health.Reports {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	reports := health.Reports{
+		{
+			Name:    "dead-letter.depth",
+			Status:  health.StatusInfo,
+			Details: fmt.Sprintf("%d", len(store.letters)),
+		},
+		{
+			Name:    "dead-letter.total",
+			Status:  health.StatusInfo,
+			Details: fmt.Sprintf("%d", store.total),
+		},
+	}
+	if len(store.letters) > 0 {
+		last := store.letters[len(store.letters)-1]
+		reports = append(reports, &health.Report{
+			Name:    "dead-letter.last",
+			Status:  health.StatusInfo,
+			Details: fmt.Sprintf("%s: %s (%s)", last.Subject, last.Error, last.Time.Format(time.RFC3339)),
+		})
+	}
+	return reports
+}
@@ -0,0 +1,140 @@
+package messaging
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+)
+
+// the upper bounds of the handler latency histogram buckets
+var latencyBuckets = []time.Duration{
+	time.Millisecond,
+	time.Millisecond * 5,
+	time.Millisecond * 10,
+	time.Millisecond * 50,
+	time.Millisecond * 100,
+	time.Millisecond * 500,
+	time.Second,
+	time.Second * 5,
+}
+
+// subjectMetrics counts the messages of a subject and keeps the handler latency histogram.
+type subjectMetrics struct {
+	published uint64
+	consumed  uint64
+	failed    uint64
+	// the last bucket counts the latencies above the largest bound
+	latencies    []uint64
+	latencyTotal time.Duration
+}
+
+func (sm *subjectMetrics) observeLatency(latency time.Duration) {
+	if sm.latencies == nil {
+		sm.latencies = make([]uint64, len(latencyBuckets)+1)
+	}
+	sm.latencyTotal += latency
+	for i, bound := range latencyBuckets {
+		if latency <= bound {
+			sm.latencies[i]++
+			return
+		}
+	}
+	sm.latencies[len(latencyBuckets)]++
+}
+
+// quantile returns the upper bound of the bucket which contains the quantile.
+func (sm *subjectMetrics) quantile(q float64) string {
+	var total uint64
+	for _, count := range sm.latencies {
+		total += count
+	}
+	if total == 0 {
+		return "-"
+	}
+	target := uint64(q * float64(total))
+	var cumulative uint64
+	for i, count := range sm.latencies {
+		cumulative += count
+		if cumulative > target || cumulative == total {
+			if i == len(latencyBuckets) {
+				return fmt.Sprintf(">%s", latencyBuckets[len(latencyBuckets)-1])
+			}
+			return fmt.Sprintf("<=%s", latencyBuckets[i])
+		}
+	}
+	return "-"
+}
+
+// messageMetrics keeps the metrics of all subjects.
+type messageMetrics struct {
+	subjects map[string]*subjectMetrics
+	mu       sync.Mutex
+}
+
+func newMessageMetrics() *messageMetrics {
+	return &messageMetrics{
+		subjects: make(map[string]*subjectMetrics),
+	}
+}
+
+// getUnsafe expects the lock to be held.
+func (mm *messageMetrics) getUnsafe(subject string) *subjectMetrics {
+	sm, ok := mm.subjects[subject]
+	if !ok {
+		sm = &subjectMetrics{}
+		mm.subjects[subject] = sm
+	}
+	return sm
+}
+
+// Published counts a published message.
+func (mm *messageMetrics) Published(subject string) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.getUnsafe(subject).published++
+}
+
+// Consumed counts a handled message and observes the handler latency.
+func (mm *messageMetrics) Consumed(subject string, latency time.Duration, failed bool) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	sm := mm.getUnsafe(subject)
+	sm.consumed++
+	if failed {
+		sm.failed++
+	}
+	sm.observeLatency(latency)
+}
+
+// Health implements health.Reporter interface.
+func (mm *messageMetrics) Health() health.Reports {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	var subjects []string
+	for subject := range mm.subjects {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+
+	var reports health.Reports
+	for _, subject := range subjects {
+		sm := mm.subjects[subject]
+		details := fmt.Sprintf("published=%d consumed=%d failed=%d", sm.published, sm.consumed, sm.failed)
+		if sm.consumed > 0 {
+			details += fmt.Sprintf(
+				" latency.avg=%s latency.p50%s latency.p99%s",
+				(sm.latencyTotal / time.Duration(sm.consumed)).Round(time.Microsecond), sm.quantile(0.5), sm.quantile(0.99),
+			)
+		}
+		reports = append(reports, &health.Report{
+			Name:    fmt.Sprintf("subject.%s", subject),
+			Status:  health.StatusInfo,
+			Details: details,
+		})
+	}
+	return reports
+}
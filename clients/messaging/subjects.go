@@ -1,24 +1,43 @@
 package messaging
 
 import (
+	"time"
+
 	"github.com/forta-network/forta-core-go/protocol"
 	"github.com/forta-network/forta-node/config"
 )
 
 // Message types
 const (
-	SubjectAgentsVersionsLatest   = "agents.versions.latest"
-	SubjectAgentsActionRun        = "agents.action.run"
-	SubjectAgentsActionStop       = "agents.action.stop"
-	SubjectAgentsAlertSubscribe   = "agents.alert.subscribe"
-	SubjectAgentsAlertUnsubscribe = "agents.alert.unsubscribe"
-	SubjectAgentsStatusRunning    = "agents.status.running"
-	SubjectAgentsStatusAttached   = "agents.status.attached"
-	SubjectAgentsStatusStopped    = "agents.status.stopped"
-	SubjectMetricAgent            = "metric.agent"
-	SubjectScannerBlock           = "scanner.block"
-	SubjectScannerAlert           = "scanner.alert"
-	SubjectInspectionDone         = "inspection.done"
+	SubjectAgentsVersionsLatest           = "agents.versions.latest"
+	SubjectAgentsActionRun                = "agents.action.run"
+	SubjectAgentsActionStop               = "agents.action.stop"
+	SubjectAgentsAlertSubscribe           = "agents.alert.subscribe"
+	SubjectAgentsAlertUnsubscribe         = "agents.alert.unsubscribe"
+	SubjectAgentsStatusRunning            = "agents.status.running"
+	SubjectAgentsStatusAttached           = "agents.status.attached"
+	SubjectAgentsStatusFailedToInitialize = "agents.status.failed-to-initialize"
+	SubjectAgentsStatusStopped            = "agents.status.stopped"
+	SubjectAgentsStatusRefused            = "agents.status.refused"
+	SubjectAgentsStatusRecreated          = "agents.status.recreated"
+	SubjectMetricAgent                    = "metric.agent"
+	SubjectScannerBlock                   = "scanner.block"
+	SubjectScannerAlert                   = "scanner.alert"
+	SubjectScannerReorg                   = "scanner.reorg"
+	SubjectInspectionDone                 = "inspection.done"
+	SubjectContainerStart                 = "container.start"
+	SubjectContainerDie                   = "container.die"
+	SubjectContainerOOM                   = "container.oom"
+	SubjectContainerHealthStatus          = "container.health-status"
+)
+
+// Request subjects
+const (
+	SubjectScannerStatusRequest   = "scanner.status.request"
+	SubjectPublisherStatusRequest = "publisher.status.request"
+
+	SubjectJSONRPCProxyReadyRequest = "json-rpc-proxy.ready.request"
+	SubjectPublisherReadyRequest    = "publisher.ready.request"
 )
 
 // AgentPayload is the message payload.
@@ -39,3 +58,40 @@ type SubscriptionPayload []CombinerBotSubscription
 type ScannerPayload struct {
 	LatestBlockInput uint64 `json:"latestBlockInput"`
 }
+
+// ReorgPayload is the message payload for the evaluated blocks which were reorged out.
+type ReorgPayload struct {
+	BlockNumber   uint64 `json:"blockNumber"`
+	OrphanedHash  string `json:"orphanedHash"`
+	CanonicalHash string `json:"canonicalHash"`
+}
+
+// ContainerEventPayload is the message payload for the container lifecycle events.
+type ContainerEventPayload struct {
+	ContainerID   string    `json:"containerId"`
+	ContainerName string    `json:"containerName"`
+	BotID         string    `json:"botId,omitempty"`
+	ExitCode      int       `json:"exitCode,omitempty"`
+	HealthStatus  string    `json:"healthStatus,omitempty"`
+	Time          time.Time `json:"time"`
+}
+
+// ScannerStatus is the response payload for the scanner status requests.
+type ScannerStatus struct {
+	LatestBlockInput     uint64 `json:"latestBlockInput"`
+	LatestBlockTimestamp int64  `json:"latestBlockTimestamp"` // in unix seconds
+	LaggingAgents        int    `json:"laggingAgents"`
+}
+
+// PublisherStatus is the response payload for the publisher status requests.
+type PublisherStatus struct {
+	PendingNotifications int `json:"pendingNotifications"`
+	PendingBatches       int `json:"pendingBatches"`
+}
+
+// Readiness is the response payload for the readiness requests which the scanner sends before it starts
+// dispatching the blocks.
+type Readiness struct {
+	Ready  bool   `json:"ready"`
+	Reason string `json:"reason,omitempty"`
+}
@@ -6,7 +6,9 @@ package mock_clients
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
+	time "time"
 
 	types "github.com/docker/docker/api/types"
 	domain "github.com/forta-network/forta-core-go/domain"
@@ -56,6 +58,35 @@ func (mr *MockDockerClientMockRecorder) AttachNetwork(ctx, containerID, networkI
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachNetwork", reflect.TypeOf((*MockDockerClient)(nil).AttachNetwork), ctx, containerID, networkID)
 }
 
+// BuildImage mocks base method.
+func (m *MockDockerClient) BuildImage(ctx context.Context, sourceDir, dockerfile, ref string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuildImage", ctx, sourceDir, dockerfile, ref)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BuildImage indicates an expected call of BuildImage.
+func (mr *MockDockerClientMockRecorder) BuildImage(ctx, sourceDir, dockerfile, ref interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildImage", reflect.TypeOf((*MockDockerClient)(nil).BuildImage), ctx, sourceDir, dockerfile, ref)
+}
+
+// ContainerEvents mocks base method.
+func (m *MockDockerClient) ContainerEvents(ctx context.Context) (<-chan *clients.DockerContainerEvent, <-chan error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ContainerEvents", ctx)
+	ret0, _ := ret[0].(<-chan *clients.DockerContainerEvent)
+	ret1, _ := ret[1].(<-chan error)
+	return ret0, ret1
+}
+
+// ContainerEvents indicates an expected call of ContainerEvents.
+func (mr *MockDockerClientMockRecorder) ContainerEvents(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContainerEvents", reflect.TypeOf((*MockDockerClient)(nil).ContainerEvents), ctx)
+}
+
 // CreateInternalNetwork mocks base method.
 func (m *MockDockerClient) CreateInternalNetwork(ctx context.Context, name string) (string, error) {
 	m.ctrl.T.Helper()
@@ -86,6 +117,21 @@ func (mr *MockDockerClientMockRecorder) CreatePublicNetwork(ctx, name interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePublicNetwork", reflect.TypeOf((*MockDockerClient)(nil).CreatePublicNetwork), ctx, name)
 }
 
+// CreatePublicNetworkWithSubnet mocks base method.
+func (m *MockDockerClient) CreatePublicNetworkWithSubnet(ctx context.Context, name, subnet string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePublicNetworkWithSubnet", ctx, name, subnet)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePublicNetworkWithSubnet indicates an expected call of CreatePublicNetworkWithSubnet.
+func (mr *MockDockerClientMockRecorder) CreatePublicNetworkWithSubnet(ctx, name, subnet interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePublicNetworkWithSubnet", reflect.TypeOf((*MockDockerClient)(nil).CreatePublicNetworkWithSubnet), ctx, name, subnet)
+}
+
 // EnsureLocalImage mocks base method.
 func (m *MockDockerClient) EnsureLocalImage(ctx context.Context, name, ref string) error {
 	m.ctrl.T.Helper()
@@ -100,6 +146,34 @@ func (mr *MockDockerClientMockRecorder) EnsureLocalImage(ctx, name, ref interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnsureLocalImage", reflect.TypeOf((*MockDockerClient)(nil).EnsureLocalImage), ctx, name, ref)
 }
 
+// EnsureVolume mocks base method.
+func (m *MockDockerClient) EnsureVolume(ctx context.Context, config clients.DockerVolumeConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnsureVolume", ctx, config)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnsureVolume indicates an expected call of EnsureVolume.
+func (mr *MockDockerClientMockRecorder) EnsureVolume(ctx, config interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnsureVolume", reflect.TypeOf((*MockDockerClient)(nil).EnsureVolume), ctx, config)
+}
+
+// ExecContainer mocks base method.
+func (m *MockDockerClient) ExecContainer(ctx context.Context, containerID string, cmd []string, stdout io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecContainer", ctx, containerID, cmd, stdout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExecContainer indicates an expected call of ExecContainer.
+func (mr *MockDockerClientMockRecorder) ExecContainer(ctx, containerID, cmd, stdout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContainer", reflect.TypeOf((*MockDockerClient)(nil).ExecContainer), ctx, containerID, cmd, stdout)
+}
+
 // GetContainerByID mocks base method.
 func (m *MockDockerClient) GetContainerByID(ctx context.Context, id string) (*types.Container, error) {
 	m.ctrl.T.Helper()
@@ -145,6 +219,21 @@ func (mr *MockDockerClientMockRecorder) GetContainerLogs(ctx, containerID, tail,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContainerLogs", reflect.TypeOf((*MockDockerClient)(nil).GetContainerLogs), ctx, containerID, tail, truncate)
 }
 
+// GetContainerResourceUsage mocks base method.
+func (m *MockDockerClient) GetContainerResourceUsage(ctx context.Context, containerID string) (*clients.ContainerResourceUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContainerResourceUsage", ctx, containerID)
+	ret0, _ := ret[0].(*clients.ContainerResourceUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetContainerResourceUsage indicates an expected call of GetContainerResourceUsage.
+func (mr *MockDockerClientMockRecorder) GetContainerResourceUsage(ctx, containerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContainerResourceUsage", reflect.TypeOf((*MockDockerClient)(nil).GetContainerResourceUsage), ctx, containerID)
+}
+
 // GetContainers mocks base method.
 func (m *MockDockerClient) GetContainers(ctx context.Context) (clients.DockerContainerList, error) {
 	m.ctrl.T.Helper()
@@ -175,6 +264,66 @@ func (mr *MockDockerClientMockRecorder) GetFortaServiceContainers(ctx interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFortaServiceContainers", reflect.TypeOf((*MockDockerClient)(nil).GetFortaServiceContainers), ctx)
 }
 
+// GetHostResources mocks base method.
+func (m *MockDockerClient) GetHostResources(ctx context.Context) (*clients.HostResources, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHostResources", ctx)
+	ret0, _ := ret[0].(*clients.HostResources)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHostResources indicates an expected call of GetHostResources.
+func (mr *MockDockerClientMockRecorder) GetHostResources(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHostResources", reflect.TypeOf((*MockDockerClient)(nil).GetHostResources), ctx)
+}
+
+// GetImageID mocks base method.
+func (m *MockDockerClient) GetImageID(ctx context.Context, ref string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImageID", ctx, ref)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImageID indicates an expected call of GetImageID.
+func (mr *MockDockerClientMockRecorder) GetImageID(ctx, ref interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImageID", reflect.TypeOf((*MockDockerClient)(nil).GetImageID), ctx, ref)
+}
+
+// GetNetworkSubnets mocks base method.
+func (m *MockDockerClient) GetNetworkSubnets(ctx context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNetworkSubnets", ctx)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNetworkSubnets indicates an expected call of GetNetworkSubnets.
+func (mr *MockDockerClientMockRecorder) GetNetworkSubnets(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNetworkSubnets", reflect.TypeOf((*MockDockerClient)(nil).GetNetworkSubnets), ctx)
+}
+
+// GetVolumes mocks base method.
+func (m *MockDockerClient) GetVolumes(ctx context.Context) ([]*types.Volume, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVolumes", ctx)
+	ret0, _ := ret[0].([]*types.Volume)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVolumes indicates an expected call of GetVolumes.
+func (mr *MockDockerClientMockRecorder) GetVolumes(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVolumes", reflect.TypeOf((*MockDockerClient)(nil).GetVolumes), ctx)
+}
+
 // HasLocalImage mocks base method.
 func (m *MockDockerClient) HasLocalImage(ctx context.Context, ref string) bool {
 	m.ctrl.T.Helper()
@@ -218,6 +367,49 @@ func (mr *MockDockerClientMockRecorder) InterruptContainer(ctx, id interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InterruptContainer", reflect.TypeOf((*MockDockerClient)(nil).InterruptContainer), ctx, id)
 }
 
+// IsUsernsRemapEnabled mocks base method.
+func (m *MockDockerClient) IsUsernsRemapEnabled(ctx context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsUsernsRemapEnabled", ctx)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsUsernsRemapEnabled indicates an expected call of IsUsernsRemapEnabled.
+func (mr *MockDockerClientMockRecorder) IsUsernsRemapEnabled(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUsernsRemapEnabled", reflect.TypeOf((*MockDockerClient)(nil).IsUsernsRemapEnabled), ctx)
+}
+
+// LimitContainerBandwidth mocks base method.
+func (m *MockDockerClient) LimitContainerBandwidth(ctx context.Context, containerID, image string, limits clients.BandwidthLimits) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LimitContainerBandwidth", ctx, containerID, image, limits)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LimitContainerBandwidth indicates an expected call of LimitContainerBandwidth.
+func (mr *MockDockerClientMockRecorder) LimitContainerBandwidth(ctx, containerID, image, limits interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LimitContainerBandwidth", reflect.TypeOf((*MockDockerClient)(nil).LimitContainerBandwidth), ctx, containerID, image, limits)
+}
+
+// LoadImages mocks base method.
+func (m *MockDockerClient) LoadImages(ctx context.Context, r io.Reader) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadImages", ctx, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LoadImages indicates an expected call of LoadImages.
+func (mr *MockDockerClientMockRecorder) LoadImages(ctx, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadImages", reflect.TypeOf((*MockDockerClient)(nil).LoadImages), ctx, r)
+}
+
 // Nuke mocks base method.
 func (m *MockDockerClient) Nuke(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -260,6 +452,20 @@ func (mr *MockDockerClientMockRecorder) PullImage(ctx, refStr interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PullImage", reflect.TypeOf((*MockDockerClient)(nil).PullImage), ctx, refStr)
 }
 
+// ReloadContainer mocks base method.
+func (m *MockDockerClient) ReloadContainer(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReloadContainer", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReloadContainer indicates an expected call of ReloadContainer.
+func (mr *MockDockerClientMockRecorder) ReloadContainer(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReloadContainer", reflect.TypeOf((*MockDockerClient)(nil).ReloadContainer), ctx, id)
+}
+
 // RemoveContainer mocks base method.
 func (m *MockDockerClient) RemoveContainer(ctx context.Context, containerID string) error {
 	m.ctrl.T.Helper()
@@ -288,6 +494,48 @@ func (mr *MockDockerClientMockRecorder) RemoveNetworkByName(ctx, networkName int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveNetworkByName", reflect.TypeOf((*MockDockerClient)(nil).RemoveNetworkByName), ctx, networkName)
 }
 
+// RemoveVolume mocks base method.
+func (m *MockDockerClient) RemoveVolume(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveVolume", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveVolume indicates an expected call of RemoveVolume.
+func (mr *MockDockerClientMockRecorder) RemoveVolume(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveVolume", reflect.TypeOf((*MockDockerClient)(nil).RemoveVolume), ctx, name)
+}
+
+// SaveImages mocks base method.
+func (m *MockDockerClient) SaveImages(ctx context.Context, refs []string, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveImages", ctx, refs, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveImages indicates an expected call of SaveImages.
+func (mr *MockDockerClientMockRecorder) SaveImages(ctx, refs, w interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveImages", reflect.TypeOf((*MockDockerClient)(nil).SaveImages), ctx, refs, w)
+}
+
+// SignalContainer mocks base method.
+func (m *MockDockerClient) SignalContainer(ctx context.Context, id, signal string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignalContainer", ctx, id, signal)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SignalContainer indicates an expected call of SignalContainer.
+func (mr *MockDockerClientMockRecorder) SignalContainer(ctx, id, signal interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignalContainer", reflect.TypeOf((*MockDockerClient)(nil).SignalContainer), ctx, id, signal)
+}
+
 // StartContainer mocks base method.
 func (m *MockDockerClient) StartContainer(ctx context.Context, config clients.DockerContainerConfig) (*clients.DockerContainer, error) {
 	m.ctrl.T.Helper()
@@ -420,6 +668,32 @@ func (mr *MockMessageClientMockRecorder) PublishProto(subject, payload interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishProto", reflect.TypeOf((*MockMessageClient)(nil).PublishProto), subject, payload)
 }
 
+// Request mocks base method.
+func (m *MockMessageClient) Request(subject string, payload, response interface{}, timeout time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Request", subject, payload, response, timeout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Request indicates an expected call of Request.
+func (mr *MockMessageClientMockRecorder) Request(subject, payload, response, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Request", reflect.TypeOf((*MockMessageClient)(nil).Request), subject, payload, response, timeout)
+}
+
+// Respond mocks base method.
+func (m *MockMessageClient) Respond(subject string, handler interface{}) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Respond", subject, handler)
+}
+
+// Respond indicates an expected call of Respond.
+func (mr *MockMessageClientMockRecorder) Respond(subject, handler interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Respond", reflect.TypeOf((*MockMessageClient)(nil).Respond), subject, handler)
+}
+
 // Subscribe mocks base method.
 func (m *MockMessageClient) Subscribe(subject string, handler interface{}) {
 	m.ctrl.T.Helper()
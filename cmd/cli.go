@@ -15,6 +15,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services/scanner/agentpool/poolagent"
 	"gopkg.in/yaml.v3"
 
 	"github.com/go-playground/validator/v10"
@@ -33,8 +34,11 @@ var (
 	cfg config.Config
 
 	parsedArgs struct {
-		Version uint64
-		NoCheck bool
+		Version        uint64
+		NoCheck        bool
+		ReleaseChannel string
+		StartFrom      string
+		Yes            bool
 	}
 
 	cmdForta = &cobra.Command{
@@ -51,6 +55,7 @@ publishes alerts about them`,
 	cmdFortaInit = &cobra.Command{
 		Use:   "init",
 		Short: "initialize a config file and a private key (doesn't overwrite)",
+		Long:  "initialize a config file and a private key (doesn't overwrite) - asks for the settings interactively when the input is a terminal",
 		RunE:  handleFortaInit,
 	}
 
@@ -113,6 +118,200 @@ publishes alerts about them`,
 		RunE:  handleFortaStatus,
 	}
 
+	cmdFortaInspect = &cobra.Command{
+		Use:   "inspect",
+		Short: "node inspection utils",
+		RunE:  handleFortaInspect,
+	}
+
+	cmdFortaInspectReport = &cobra.Command{
+		Use:   "report",
+		Short: "show the latest (or a specified) inspection result as a readable report",
+		RunE:  handleFortaInspectReport,
+	}
+
+	cmdFortaReload = &cobra.Command{
+		Use:   "reload",
+		Short: "apply the runtime-reloadable config values without restarting the node",
+		RunE:  withInitialized(withValidConfig(handleFortaReload)),
+	}
+
+	cmdFortaPause = &cobra.Command{
+		Use:   "pause",
+		Short: "stop dispatching events to bots and publishing alerts while keeping the containers running",
+		RunE:  withInitialized(handleFortaPause),
+	}
+
+	cmdFortaResume = &cobra.Command{
+		Use:   "resume",
+		Short: "resume the paused node",
+		RunE:  withInitialized(handleFortaResume),
+	}
+
+	cmdFortaDisableBot = &cobra.Command{
+		Use:   "disable-bot <id>",
+		Short: "stop running an assigned bot on this node until it is enabled again",
+		Args:  cobra.ExactArgs(1),
+		RunE:  withInitialized(handleFortaDisableBot),
+	}
+
+	cmdFortaEnableBot = &cobra.Command{
+		Use:   "enable-bot <id>",
+		Short: "run a bot which was disabled on this node again",
+		Args:  cobra.ExactArgs(1),
+		RunE:  withInitialized(handleFortaEnableBot),
+	}
+
+	cmdFortaInstallService = &cobra.Command{
+		Use:   "install-service",
+		Short: "install a systemd service which runs the node and restarts it when it is unhealthy",
+		RunE:  withInitialized(handleFortaInstallService),
+	}
+
+	cmdFortaBenchmark = &cobra.Command{
+		Use:   "benchmark",
+		Short: "run a bot image locally against bundled blocks and txs and report performance",
+		RunE:  handleFortaBenchmark,
+	}
+
+	cmdFortaTestBot = &cobra.Command{
+		Use:   "test-bot <image>",
+		Short: "run conformance checks against a bot image locally and report pass/fail",
+		Args:  cobra.ExactArgs(1),
+		RunE:  handleFortaTestBot,
+	}
+
+	cmdFortaRPCUsage = &cobra.Command{
+		Use:   "rpc-usage",
+		Short: "export the per-bot json-rpc requests and compute units per hour",
+		RunE:  handleFortaRPCUsage,
+	}
+
+	cmdFortaAlerts = &cobra.Command{
+		Use:   "alerts",
+		Short: "show the findings published by this node",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmdFortaAlertsList = &cobra.Command{
+		Use:   "list",
+		Short: "list the last findings published by this node",
+		RunE:  withInitialized(handleFortaAlertsList),
+	}
+
+	cmdFortaAssignments = &cobra.Command{
+		Use:   "assignments",
+		Short: "show the changes in the bots assigned to this node",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmdFortaAssignmentsHistory = &cobra.Command{
+		Use:   "history",
+		Short: "list the recorded assignment changes",
+		RunE:  withInitialized(handleFortaAssignmentsHistory),
+	}
+
+	cmdFortaAssignmentsDiff = &cobra.Command{
+		Use:   "diff",
+		Short: "show the bots assigned and unassigned between two points in time",
+		RunE:  withInitialized(handleFortaAssignmentsDiff),
+	}
+
+	cmdFortaBots = &cobra.Command{
+		Use:   "bots",
+		Short: "show the bots assigned to this node",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmdFortaBotsDescribe = &cobra.Command{
+		Use:   "describe <id>",
+		Short: "show the manifest data, the image and the local status of an assigned bot",
+		Args:  cobra.ExactArgs(1),
+		RunE:  withInitialized(handleFortaBotsDescribe),
+	}
+
+	cmdFortaDebug = &cobra.Command{
+		Use:   "debug",
+		Short: "collect debugging data from the running node",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmdFortaDebugProfile = &cobra.Command{
+		Use:   "profile <service|all>",
+		Short: "collect and bundle the cpu, memory, goroutine profiles and a runtime trace of a node service",
+		Args:  cobra.ExactArgs(1),
+		RunE:  withInitialized(handleFortaDebugProfile),
+	}
+
+	cmdFortaDebugBundle = &cobra.Command{
+		Use:   "bundle",
+		Short: "bundle the redacted config, service logs, health reports, inspection results and versions for support requests",
+		RunE:  withInitialized(handleFortaDebugBundle),
+	}
+
+	cmdFortaConfig = &cobra.Command{
+		Use:   "config",
+		Short: "manage the config file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmdFortaConfigMigrate = &cobra.Command{
+		Use:   "migrate",
+		Short: "migrate the deprecated fields in the config file to the current schema",
+		RunE:  withInitialized(handleFortaConfigMigrate),
+	}
+
+	cmdFortaConfigGet = &cobra.Command{
+		Use:   "get <path>",
+		Short: "print the value at the dot-separated path in the config (e.g. scan.blockRateLimit)",
+		Args:  cobra.ExactArgs(1),
+		RunE:  withInitialized(handleFortaConfigGet),
+	}
+
+	cmdFortaConfigSet = &cobra.Command{
+		Use:   "set <path> <value>",
+		Short: "set the value at the dot-separated path in the config file and back up the previous file",
+		Args:  cobra.ExactArgs(2),
+		RunE:  withInitialized(handleFortaConfigSet),
+	}
+
+	cmdFortaConfigSignRemote = &cobra.Command{
+		Use:   "sign-remote",
+		Short: "sign the config overrides with the scanner key to serve them from the remote config URL",
+		RunE:  withInitialized(handleFortaConfigSignRemote),
+	}
+
+	cmdFortaUpdate = &cobra.Command{
+		Use:   "update",
+		Short: "update the offline nodes with the release bundles",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmdFortaUpdateExport = &cobra.Command{
+		Use:   "export",
+		Short: "package a release with its images into a signed bundle to import on an offline node",
+		RunE:  withInitialized(handleFortaUpdateExport),
+	}
+
+	cmdFortaUpdateImport = &cobra.Command{
+		Use:   "import <bundle>",
+		Short: "verify and load a release bundle and make it the release of this offline node",
+		Args:  cobra.ExactArgs(1),
+		RunE:  withInitialized(handleFortaUpdateImport),
+	}
+
 	cmdFortaAuthorize = &cobra.Command{
 		Use:   "authorize",
 		Short: "generate a signature for a specific action",
@@ -126,6 +325,12 @@ publishes alerts about them`,
 		Short: "generate a pool registration signature",
 		RunE:  withInitialized(withValidConfig(handleFortaAuthorizePool)),
 	}
+
+	cmdFortaRegisterPool = &cobra.Command{
+		Use:   "register-pool",
+		Short: "generate the registration signature to run this node under a scanner pool owned by another address",
+		RunE:  withInitialized(withValidConfig(handleFortaRegisterPool)),
+	}
 )
 
 // Execute executes the root command.
@@ -152,9 +357,53 @@ func init() {
 
 	cmdForta.AddCommand(cmdFortaStatus)
 
+	cmdForta.AddCommand(cmdFortaInspect)
+	cmdFortaInspect.AddCommand(cmdFortaInspectReport)
+
+	cmdForta.AddCommand(cmdFortaReload)
+
+	cmdForta.AddCommand(cmdFortaPause)
+	cmdForta.AddCommand(cmdFortaResume)
+
+	cmdForta.AddCommand(cmdFortaDisableBot)
+	cmdForta.AddCommand(cmdFortaEnableBot)
+
+	cmdForta.AddCommand(cmdFortaInstallService)
+
+	cmdForta.AddCommand(cmdFortaBenchmark)
+	cmdForta.AddCommand(cmdFortaTestBot)
+
+	cmdForta.AddCommand(cmdFortaRPCUsage)
+
+	cmdForta.AddCommand(cmdFortaAlerts)
+	cmdFortaAlerts.AddCommand(cmdFortaAlertsList)
+
+	cmdForta.AddCommand(cmdFortaAssignments)
+	cmdFortaAssignments.AddCommand(cmdFortaAssignmentsHistory)
+	cmdFortaAssignments.AddCommand(cmdFortaAssignmentsDiff)
+
+	cmdForta.AddCommand(cmdFortaBots)
+	cmdFortaBots.AddCommand(cmdFortaBotsDescribe)
+
+	cmdForta.AddCommand(cmdFortaDebug)
+	cmdFortaDebug.AddCommand(cmdFortaDebugProfile)
+	cmdFortaDebug.AddCommand(cmdFortaDebugBundle)
+
+	cmdForta.AddCommand(cmdFortaConfig)
+	cmdFortaConfig.AddCommand(cmdFortaConfigMigrate)
+	cmdFortaConfig.AddCommand(cmdFortaConfigGet)
+	cmdFortaConfig.AddCommand(cmdFortaConfigSet)
+	cmdFortaConfig.AddCommand(cmdFortaConfigSignRemote)
+
+	cmdForta.AddCommand(cmdFortaUpdate)
+	cmdFortaUpdate.AddCommand(cmdFortaUpdateExport)
+	cmdFortaUpdate.AddCommand(cmdFortaUpdateImport)
+
 	cmdForta.AddCommand(cmdFortaAuthorize)
 	cmdFortaAuthorize.AddCommand(cmdFortaAuthorizePool)
 
+	cmdForta.AddCommand(cmdFortaRegisterPool)
+
 	// Global (persistent) flags
 
 	cmdForta.PersistentFlags().String("dir", "", "Forta dir (default is $HOME/.forta) (overrides $FORTA_DIR)")
@@ -169,12 +418,25 @@ func init() {
 	cmdForta.PersistentFlags().Bool("expose-nats", false, "expose nats via public docker network")
 	viper.BindPFlag(keyFortaExposeNats, cmdForta.PersistentFlags().Lookup("expose-nats"))
 
+	// forta init
+	cmdFortaInit.Flags().BoolP("interactive", "i", false, "ask for the settings interactively (default when the input is a terminal)")
+
 	// forta account import
 	cmdFortaAccountImport.Flags().String("file", "", "path to a file that contains a private key hex")
 	cmdFortaAccountImport.MarkFlagRequired("file")
 
 	// forta run
 	cmdFortaRun.Flags().BoolVar(&parsedArgs.NoCheck, "no-check", false, "disable scanner registry check and just run")
+	cmdFortaRun.Flags().StringVar(&parsedArgs.ReleaseChannel, "release-channel", "", "release channel to auto-update from: stable, rc, canary (overrides autoUpdate.releaseChannel)")
+	cmdFortaRun.Flags().StringVar(&parsedArgs.StartFrom, "start-from", "", "start scanning once from: latest, a block number or an RFC3339 timestamp (overrides the evaluation checkpoints)")
+	cmdFortaRun.Flags().BoolVarP(&parsedArgs.Yes, "yes", "y", false, "do not ask before overriding the evaluation checkpoints")
+
+	// forta inspect
+	cmdFortaInspect.Flags().Bool("pre-registration", false, "run a full inspection from this host and check if the node is ready to be registered")
+
+	// forta inspect report
+	cmdFortaInspectReport.Flags().Uint64("block", 0, "block number of the inspection (default is the latest)")
+	cmdFortaInspectReport.Flags().String("file", "", "read the inspection from a file (inspector logs or results JSON) instead of the running node")
 
 	// forta batch decode
 	cmdFortaBatchDecode.Flags().String("cid", "", "batch IPFS CID (content ID)")
@@ -186,6 +448,83 @@ func init() {
 	cmdFortaStatus.Flags().String("format", StatusFormatPretty, "output formatting/encoding: pretty (default), oneline, json, csv")
 	cmdFortaStatus.Flags().Bool("no-color", false, "disable colors")
 	cmdFortaStatus.Flags().String("show", StatusShowSummary, "filter statuses to show: summary (default), important, all")
+	cmdFortaStatus.Flags().Bool("watch", false, "show a live dashboard which refreshes in place")
+	cmdFortaStatus.Flags().Int("interval", 2, "seconds between the dashboard refreshes in watch mode")
+
+	// forta benchmark
+	cmdFortaBenchmark.Flags().String("image", "", "bot image reference")
+	cmdFortaBenchmark.MarkFlagRequired("image")
+	cmdFortaBenchmark.Flags().Int("requests", 100, "number of blocks and transactions to send")
+	cmdFortaBenchmark.Flags().Int("concurrency", 1, "number of concurrent requests")
+	cmdFortaBenchmark.Flags().String("port", "50099", "local port to expose the bot gRPC server at")
+	cmdFortaBenchmark.Flags().Int("chain-id", 1, "chain ID to pass to the bot")
+
+	// forta test-bot
+	cmdFortaTestBot.Flags().String("port", "50098", "local port to expose the bot gRPC server at")
+	cmdFortaTestBot.Flags().Int("chain-id", 1, "chain ID to pass to the bot")
+	cmdFortaTestBot.Flags().Int("timeout", int(poolagent.AgentTimeout.Seconds()), "max seconds to wait for each evaluation")
+
+	// forta rpc-usage
+	cmdFortaRPCUsage.Flags().String("format", "csv", "output format: csv (default), json")
+	cmdFortaRPCUsage.Flags().String("bot", "", "show only the usage of a bot")
+	cmdFortaRPCUsage.Flags().Int("hours", 0, "show only the last given hours (default is all)")
+
+	// forta alerts list
+	cmdFortaAlertsList.Flags().String("format", "text", "output format: text (default), json")
+	cmdFortaAlertsList.Flags().String("bot", "", "show only the findings of a bot")
+	cmdFortaAlertsList.Flags().String("severity", "", "show only the findings with this severity or higher: info, low, medium, high, critical")
+	cmdFortaAlertsList.Flags().String("from", "", "start time as RFC3339 or a duration before now (default is all)")
+	cmdFortaAlertsList.Flags().String("to", "", "end time as RFC3339 or a duration before now (default is now)")
+	cmdFortaAlertsList.Flags().Int("limit", 20, "show only the last N findings (0 for all)")
+
+	// forta assignments history
+	cmdFortaAssignmentsHistory.Flags().String("format", "text", "output format: text (default), json")
+	cmdFortaAssignmentsHistory.Flags().String("bot", "", "show only the changes of a bot")
+	cmdFortaAssignmentsHistory.Flags().Int("hours", 0, "show only the last given hours (default is all)")
+
+	// forta assignments diff
+	cmdFortaAssignmentsDiff.Flags().String("from", "24h", "start time as RFC3339 or a duration before now")
+	cmdFortaAssignmentsDiff.Flags().String("to", "", "end time as RFC3339 or a duration before now (default is now)")
+
+	// forta bots describe
+	cmdFortaBotsDescribe.Flags().String("format", "text", "output format: text (default), json")
+	cmdFortaBotsDescribe.Flags().Bool("docs", false, "print the bot documentation too")
+
+	// forta debug profile
+	cmdFortaDebugProfile.Flags().Int("seconds", 30, "duration of the cpu profile")
+	cmdFortaDebugProfile.Flags().Int("trace-seconds", 5, "duration of the runtime trace (0 to skip)")
+	cmdFortaDebugProfile.Flags().String("output", ".", "directory to write the profile bundles to")
+
+	// forta debug bundle
+	cmdFortaDebugBundle.Flags().String("output", ".", "directory to write the bundle to")
+	cmdFortaDebugBundle.Flags().Int("log-lines", 1000, "number of the last log lines to collect from each service")
+
+	// forta version
+	cmdFortaVersion.Flags().Bool("all", false, "show the versions of the node and all of the running containers")
+	cmdFortaVersion.Flags().String("format", "json", "output format with --all: json (default), text")
+
+	// forta config migrate
+	cmdFortaConfigMigrate.Flags().Bool("dry-run", false, "print the migrated config file instead of writing it")
+
+	// forta config set
+	cmdFortaConfigSet.Flags().Bool("dry-run", false, "print the updated config file instead of writing it")
+
+	// forta config sign-remote
+	cmdFortaConfigSignRemote.Flags().String("file", "", "the config overrides file")
+	cmdFortaConfigSignRemote.MarkFlagRequired("file")
+	cmdFortaConfigSignRemote.Flags().Int64("version", 0, "the version of the overrides, must increase with every change")
+	cmdFortaConfigSignRemote.MarkFlagRequired("version")
+
+	// forta update export
+	cmdFortaUpdateExport.Flags().String("release", "", "IPFS reference of the release (default is the latest stable release)")
+	cmdFortaUpdateExport.Flags().String("output", "", "path to write the bundle to (default is forta-update-<version>.tar.gz)")
+
+	// forta install-service
+	cmdFortaInstallService.Flags().String("path", defaultServiceUnitPath, "path to write the systemd unit file to")
+	cmdFortaInstallService.Flags().String("user", "", "user to run the node as (default is the sudo user or the current user)")
+	cmdFortaInstallService.Flags().String("env-file", "/etc/forta/forta.env", "environment file which contains FORTA_PASSPHRASE")
+	cmdFortaInstallService.Flags().Bool("dry-run", false, "print the unit file instead of writing it")
+	cmdFortaInstallService.Flags().Bool("force", false, "overwrite the existing unit file")
 
 	// forta authorize pool
 	cmdFortaAuthorizePool.Flags().String("id", "", "scanner pool ID (integer)")
@@ -193,6 +532,16 @@ func init() {
 	cmdFortaAuthorizePool.Flags().Bool("polygonscan", false, "see the registerScannerNode() inputs to use in Polygonscan")
 	cmdFortaAuthorizePool.Flags().BoolP("force", "f", false, "ignore warning(s)")
 	cmdFortaAuthorizePool.Flags().Bool("clean", false, "output only the encoded registration info")
+	cmdFortaAuthorizePool.Flags().Bool("pre-inspect", false, "inspect the node first and do not authorize if it would fail the inspection")
+
+	// forta register-pool
+	cmdFortaRegisterPool.Flags().String("id", "", "scanner pool ID (integer) (default is scannerPool.poolId in the config file)")
+	cmdFortaRegisterPool.Flags().String("owner", "", "pool owner address (default is scannerPool.ownerAddress in the config file)")
+	cmdFortaRegisterPool.Flags().Bool("polygonscan", false, "see the registerScannerNode() inputs to use in Polygonscan")
+	cmdFortaRegisterPool.Flags().BoolP("force", "f", false, "ignore warning(s)")
+	cmdFortaRegisterPool.Flags().Bool("clean", false, "output only the encoded registration info")
+	cmdFortaRegisterPool.Flags().Bool("pre-inspect", false, "inspect the node first and do not register if it would fail the inspection")
+	cmdFortaRegisterPool.Flags().Bool("save", false, "save the pool ID and the owner address to the config file")
 }
 
 func initConfig() {
@@ -215,6 +564,18 @@ func initConfig() {
 
 	configPath := path.Join(fortaDir, config.DefaultConfigFileName)
 	configBytes, _ := ioutil.ReadFile(configPath)
+	// migrate the deprecated fields in memory so that they are not silently ignored
+	configBytes, migration, err := config.MigrateConfig(configBytes)
+	if err != nil {
+		yellowBold("Your config file is invalid! Please check the values and fix any formatting issues.\n")
+		logrus.WithError(err).Fatal("failed to migrate config")
+	}
+	if len(migration.Deprecated) > 0 {
+		yellowBold("Your config file has deprecated fields! Please run 'forta config migrate' to update it:\n")
+		for _, deprecated := range migration.Deprecated {
+			fmt.Fprintf(os.Stderr, "  - %s\n", deprecated)
+		}
+	}
 	if err := yaml.Unmarshal(configBytes, &cfg); err != nil {
 		yellowBold("Your config file is invalid! Please check the values and fix any formatting issues.\n")
 		logrus.WithError(err).Fatal("failed to read config")
@@ -229,6 +590,16 @@ func initConfig() {
 	cfg.Development = viper.GetBool(keyFortaDevelopment)
 	cfg.Passphrase = viper.GetString(keyFortaPassphrase)
 
+	if err := config.ApplyRegistryProfile(&cfg); err != nil {
+		yellowBold("Your config file has an invalid registry profile! Please check registry.profile and registry.profiles.\n")
+		logrus.WithError(err).Fatal("failed to apply the registry profile")
+	}
+	if len(cfg.Registry.Profile) > 0 {
+		if err := os.MkdirAll(cfg.StateDir(), 0755); err != nil {
+			logrus.WithError(err).Fatal("failed to create the registry state dir")
+		}
+	}
+
 	viper.ReadConfig(bytes.NewBuffer(configBytes))
 	config.InitLogLevel(cfg)
 }
@@ -248,6 +619,10 @@ func resolveEnvVarsHookFunc(from reflect.Kind, to reflect.Kind, data interface{}
 }
 
 func validateConfig() error {
+	return validateConfigValues(&cfg)
+}
+
+func validateConfigValues(c *config.Config) error {
 	validate := validator.New()
 
 	// Use the YAML names while validating the struct.
@@ -259,7 +634,7 @@ func validateConfig() error {
 		return name
 	})
 
-	if err := validate.Struct(&cfg); err != nil {
+	if err := validate.Struct(c); err != nil {
 		validationErrs := err.(validator.ValidationErrors)
 		fmt.Fprintln(os.Stderr, "The config file has invalid or missing fields:")
 		for _, validationErr := range validationErrs {
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services/publisher"
+	"github.com/spf13/cobra"
+)
+
+func handleFortaAlertsList(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	botID, _ := cmd.Flags().GetString("bot")
+	severity, _ := cmd.Flags().GetString("severity")
+	fromStr, _ := cmd.Flags().GetString("from")
+	toStr, _ := cmd.Flags().GetString("to")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	var minSeverity int32
+	if len(severity) > 0 {
+		var ok bool
+		minSeverity, ok = protocol.Finding_Severity_value[strings.ToUpper(severity)]
+		if !ok {
+			return fmt.Errorf("unknown severity: %s", severity)
+		}
+	}
+	now := time.Now()
+	var from time.Time
+	if len(fromStr) > 0 {
+		var err error
+		from, err = parseHistoryTime(fromStr, now)
+		if err != nil {
+			return fmt.Errorf("invalid --from value: %v", err)
+		}
+	}
+	to, err := parseHistoryTime(toStr, now)
+	if err != nil {
+		return fmt.Errorf("invalid --to value: %v", err)
+	}
+
+	// the publisher records the findings after publishing them
+	findings, err := publisher.LoadPublishedFindings(path.Join(cfg.StateDir(), config.DefaultPublishedFindingsFileName))
+	if err != nil {
+		return fmt.Errorf("failed to load the published findings: %v", err)
+	}
+	findings = filterPublishedFindings(findings, botID, minSeverity, from, to, limit)
+
+	switch format {
+	case "text":
+		if len(findings) == 0 {
+			yellowBold("No published findings found.\n")
+			return nil
+		}
+		writePublishedFindings(os.Stdout, findings)
+		return nil
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(findings)
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+// filterPublishedFindings returns the last findings which match the filters, in the publish order.
+func filterPublishedFindings(findings []*publisher.PublishedFinding, botID string, minSeverity int32, from, to time.Time, limit int) []*publisher.PublishedFinding {
+	filtered := []*publisher.PublishedFinding{}
+	for _, finding := range findings {
+		if finding.Time.Before(from) || finding.Time.After(to) {
+			continue
+		}
+		if len(botID) > 0 && !strings.EqualFold(finding.BotID, botID) {
+			continue
+		}
+		if protocol.Finding_Severity_value[finding.Severity] < minSeverity {
+			continue
+		}
+		filtered = append(filtered, finding)
+	}
+	if limit > 0 && len(filtered) > limit {
+		filtered = filtered[len(filtered)-limit:]
+	}
+	return filtered
+}
+
+func writePublishedFindings(w io.Writer, findings []*publisher.PublishedFinding) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PUBLISHED\tSEVERITY\tBOT\tALERT ID\tBLOCK\tTX\tHASH\tBATCH")
+	for _, finding := range findings {
+		block := "-"
+		if finding.BlockNumber > 0 {
+			block = fmt.Sprint(finding.BlockNumber)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			finding.Time.UTC().Format(time.RFC3339), finding.Severity, finding.BotID, valueOrDash(finding.AlertID),
+			block, valueOrDash(finding.TxHash), finding.Hash, valueOrDash(finding.Batch))
+	}
+	tw.Flush()
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/services/publisher"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterPublishedFindings(t *testing.T) {
+	r := require.New(t)
+
+	now := time.Date(2023, 3, 20, 10, 30, 0, 0, time.UTC)
+	findings := []*publisher.PublishedFinding{
+		{Time: now.Add(-time.Hour * 3), Hash: "0x01", BotID: "0xabcd", Severity: "LOW"},
+		{Time: now.Add(-time.Hour), Hash: "0x02", BotID: "0x1234", Severity: "HIGH"},
+		{Time: now.Add(-time.Minute), Hash: "0x03", BotID: "0xABCD", Severity: "CRITICAL"},
+	}
+	high := protocol.Finding_Severity_value["HIGH"]
+
+	r.Len(filterPublishedFindings(findings, "", 0, time.Time{}, now, 0), 3)
+	r.Len(filterPublishedFindings(findings, "0xabcd", 0, time.Time{}, now, 0), 2)
+	r.Len(filterPublishedFindings(findings, "", high, time.Time{}, now, 0), 2)
+	r.Len(filterPublishedFindings(findings, "", 0, now.Add(-time.Hour*2), now, 0), 2)
+	r.Len(filterPublishedFindings(findings, "", 0, time.Time{}, now.Add(-time.Minute*30), 0), 2)
+	limited := filterPublishedFindings(findings, "", 0, time.Time{}, now, 1)
+	r.Len(limited, 1)
+	r.Equal("0x03", limited[0].Hash)
+
+	w := new(bytes.Buffer)
+	writePublishedFindings(w, []*publisher.PublishedFinding{
+		{Time: now, Hash: "0x01", BotID: "0xabcd", AlertID: "ALERT-1", Severity: "HIGH", BlockNumber: 100, Batch: "Qm1234"},
+	})
+	r.Equal("PUBLISHED             SEVERITY  BOT     ALERT ID  BLOCK  TX  HASH  BATCH\n"+
+		"2023-03-20T10:30:00Z  HIGH      0xabcd  ALERT-1   100    -   0x01  Qm1234\n", w.String())
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services/registry"
+	"github.com/spf13/cobra"
+)
+
+func loadAssignmentHistory() ([]*registry.AssignmentChange, error) {
+	// the registry service records the changes when it detects them
+	changes, err := registry.LoadAssignmentHistory(path.Join(cfg.StateDir(), config.DefaultAssignmentHistoryFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the assignment history: %v", err)
+	}
+	return changes, nil
+}
+
+func handleFortaAssignmentsHistory(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	botID, _ := cmd.Flags().GetString("bot")
+	hours, _ := cmd.Flags().GetInt("hours")
+
+	changes, err := loadAssignmentHistory()
+	if err != nil {
+		return err
+	}
+	changes = filterAssignmentChanges(changes, botID, hours, time.Now())
+
+	switch format {
+	case "text":
+		writeAssignmentChanges(os.Stdout, changes)
+		return nil
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(changes)
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+func handleFortaAssignmentsDiff(cmd *cobra.Command, args []string) error {
+	fromStr, _ := cmd.Flags().GetString("from")
+	toStr, _ := cmd.Flags().GetString("to")
+
+	now := time.Now()
+	from, err := parseHistoryTime(fromStr, now)
+	if err != nil {
+		return fmt.Errorf("invalid --from value: %v", err)
+	}
+	to, err := parseHistoryTime(toStr, now)
+	if err != nil {
+		return fmt.Errorf("invalid --to value: %v", err)
+	}
+	if to.Before(from) {
+		return fmt.Errorf("--to must be after --from")
+	}
+
+	changes, err := loadAssignmentHistory()
+	if err != nil {
+		return err
+	}
+	fromBots := registry.AssignmentsAt(changes, from)
+	toBots := registry.AssignmentsAt(changes, to)
+	assigned, unassigned := registry.DiffAssignments(fromBots, toBots)
+
+	cmd.Printf("%s: %d bots\n", from.UTC().Format(time.RFC3339), len(fromBots))
+	cmd.Printf("%s: %d bots\n", to.UTC().Format(time.RFC3339), len(toBots))
+	if len(assigned) == 0 && len(unassigned) == 0 {
+		greenBold("No assignment changes.\n")
+		return nil
+	}
+	for _, bot := range assigned {
+		cmd.Printf("+ %s\n", bot)
+	}
+	for _, bot := range unassigned {
+		cmd.Printf("- %s\n", bot)
+	}
+	return nil
+}
+
+// parseHistoryTime parses an RFC3339 timestamp or a duration before now, like 24h.
+func parseHistoryTime(value string, now time.Time) (time.Time, error) {
+	if len(value) == 0 {
+		return now, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func filterAssignmentChanges(changes []*registry.AssignmentChange, botID string, hours int, now time.Time) []*registry.AssignmentChange {
+	filtered := []*registry.AssignmentChange{}
+	minTime := now.Add(-time.Duration(hours) * time.Hour)
+	botID = strings.ToLower(botID)
+	for _, change := range changes {
+		if hours > 0 && change.Time.Before(minTime) {
+			continue
+		}
+		if len(botID) > 0 && !containsBot(change.Assigned, botID) && !containsBot(change.Unassigned, botID) {
+			continue
+		}
+		filtered = append(filtered, change)
+	}
+	return filtered
+}
+
+func containsBot(bots []string, botID string) bool {
+	for _, bot := range bots {
+		if bot == botID {
+			return true
+		}
+	}
+	return false
+}
+
+func writeAssignmentChanges(w io.Writer, changes []*registry.AssignmentChange) {
+	for _, change := range changes {
+		fmt.Fprintf(w, "%s (%d bots)\n", change.Time.UTC().Format(time.RFC3339), len(change.Bots))
+		for _, bot := range change.Assigned {
+			fmt.Fprintf(w, "  + %s\n", bot)
+		}
+		for _, bot := range change.Unassigned {
+			fmt.Fprintf(w, "  - %s\n", bot)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/services/registry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterAssignmentChanges(t *testing.T) {
+	r := require.New(t)
+
+	now := time.Date(2023, 3, 20, 10, 30, 0, 0, time.UTC)
+	changes := []*registry.AssignmentChange{
+		{Time: now.Add(-time.Hour * 3), Bots: []string{"0x01"}, Assigned: []string{"0x01"}},
+		{Time: now.Add(-time.Minute), Bots: []string{"0x02"}, Assigned: []string{"0x02"}, Unassigned: []string{"0x01"}},
+	}
+
+	r.Len(filterAssignmentChanges(changes, "", 0, now), 2)
+	r.Len(filterAssignmentChanges(changes, "", 1, now), 1)
+	r.Len(filterAssignmentChanges(changes, "0X01", 0, now), 2)
+	r.Len(filterAssignmentChanges(changes, "0x02", 0, now), 1)
+
+	w := new(bytes.Buffer)
+	writeAssignmentChanges(w, changes[1:])
+	r.Equal("2023-03-20T10:29:00Z (1 bots)\n  + 0x02\n  - 0x01\n", w.String())
+}
+
+func TestParseHistoryTime(t *testing.T) {
+	r := require.New(t)
+
+	now := time.Date(2023, 3, 20, 10, 30, 0, 0, time.UTC)
+	parsed, err := parseHistoryTime("", now)
+	r.NoError(err)
+	r.Equal(now, parsed)
+
+	parsed, err = parseHistoryTime("24h", now)
+	r.NoError(err)
+	r.Equal(now.Add(-time.Hour*24), parsed)
+
+	parsed, err = parseHistoryTime("2023-03-19T00:00:00Z", now)
+	r.NoError(err)
+	r.Equal(time.Date(2023, 3, 19, 0, 0, 0, 0, time.UTC), parsed)
+
+	_, err = parseHistoryTime("yesterday", now)
+	r.Error(err)
+}
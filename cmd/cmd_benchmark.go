@@ -0,0 +1,340 @@
+package cmd
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/clients/agentgrpc"
+	"github.com/forta-network/forta-node/config"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+)
+
+const (
+	benchmarkContainerName    = "forta-benchmark-bot"
+	localBotConnectTimeout    = time.Minute * 2
+	benchmarkUsageSampleEvery = time.Second
+)
+
+var (
+	//go:embed benchdata/blocks.json
+	benchmarkBlockData []byte
+	//go:embed benchdata/txs.json
+	benchmarkTxData []byte
+)
+
+type benchmarkResult struct {
+	Name       string
+	Count      int
+	Errors     int
+	Duration   time.Duration
+	Latencies  []time.Duration
+	Throughput float64
+}
+
+type benchmarkUsage struct {
+	samples   int
+	totalCPU  float64
+	maxCPU    float64
+	maxMemory uint64
+	sampleErr error // the first error
+	mu        sync.Mutex
+}
+
+func handleFortaBenchmark(cmd *cobra.Command, args []string) error {
+	image, err := cmd.Flags().GetString("image")
+	if err != nil {
+		return err
+	}
+	requestCount, err := cmd.Flags().GetInt("requests")
+	if err != nil {
+		return err
+	}
+	concurrency, err := cmd.Flags().GetInt("concurrency")
+	if err != nil {
+		return err
+	}
+	hostPort, err := cmd.Flags().GetString("port")
+	if err != nil {
+		return err
+	}
+	chainID, err := cmd.Flags().GetInt("chain-id")
+	if err != nil {
+		return err
+	}
+	if requestCount <= 0 || concurrency <= 0 {
+		return fmt.Errorf("requests and concurrency should be greater than zero")
+	}
+
+	var blockEvents []*protocol.BlockEvent
+	if err := json.Unmarshal(benchmarkBlockData, &blockEvents); err != nil {
+		return fmt.Errorf("failed to decode bundled blocks: %v", err)
+	}
+	var txEvents []*protocol.TransactionEvent
+	if err := json.Unmarshal(benchmarkTxData, &txEvents); err != nil {
+		return fmt.Errorf("failed to decode bundled txs: %v", err)
+	}
+
+	// cancel on interrupt so the deferred container cleanup still runs
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	dockerClient, err := clients.NewDockerClient("")
+	if err != nil {
+		return fmt.Errorf("failed to create the docker client: %v", err)
+	}
+	if err := dockerClient.EnsureLocalImage(ctx, "benchmark bot", image); err != nil {
+		return err
+	}
+	if err := removeLocalBotContainer(ctx, dockerClient, benchmarkContainerName); err != nil {
+		return err
+	}
+
+	limits := config.GetAgentResourceLimits(cfg.ResourcesConfig)
+	cmd.PrintErrf("Starting bot container from %s\n", image)
+	botContainer, err := dockerClient.StartContainer(ctx, clients.DockerContainerConfig{
+		Name:  benchmarkContainerName,
+		Image: image,
+		Env: map[string]string{
+			config.EnvAgentGrpcPort: config.AgentGrpcPort,
+			config.EnvFortaChainID:  fmt.Sprintf("%d", chainID),
+		},
+		Ports: map[string]string{
+			fmt.Sprintf("127.0.0.1:%s", hostPort): config.AgentGrpcPort,
+		},
+		CPUQuota: limits.CPUQuota,
+		Memory:   limits.Memory,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start the bot container: %v", err)
+	}
+	defer func() {
+		cmd.PrintErrln("Removing the bot container")
+		if err := dockerClient.TerminateContainer(context.Background(), botContainer.ID); err != nil {
+			cmd.PrintErrf("failed to stop the bot container: %v\n", err)
+		}
+		if err := dockerClient.RemoveContainer(context.Background(), botContainer.ID); err != nil {
+			cmd.PrintErrf("failed to remove the bot container: %v\n", err)
+		}
+	}()
+
+	agentClient, err := dialLocalBot(ctx, hostPort)
+	if err != nil {
+		return err
+	}
+	defer agentClient.Close()
+
+	cmd.PrintErrln("Initializing the bot")
+	if err := agentClient.Invoke(ctx, agentgrpc.MethodInitialize, &protocol.InitializeRequest{
+		AgentId: "benchmark",
+	}, &protocol.InitializeResponse{}); err != nil {
+		cmd.PrintErrf("bot initialization failed (continuing): %v\n", err)
+	}
+
+	usage := &benchmarkUsage{}
+	usageCtx, stopUsage := context.WithCancel(ctx)
+	go usage.sample(usageCtx, dockerClient, botContainer.ID)
+
+	cmd.PrintErrf(
+		"Sending %d blocks and %d transactions from %d bundled blocks and %d bundled transactions (concurrency=%d)\n",
+		requestCount, requestCount, len(blockEvents), len(txEvents), concurrency,
+	)
+	results := []*benchmarkResult{
+		runBenchmark(ctx, "block", requestCount, concurrency, func(ctx context.Context, i int) error {
+			req := &protocol.EvaluateBlockRequest{
+				RequestId: fmt.Sprintf("benchmark-block-%d", i),
+				Event:     blockEvents[i%len(blockEvents)],
+			}
+			return agentClient.Invoke(ctx, agentgrpc.MethodEvaluateBlock, req, &protocol.EvaluateBlockResponse{})
+		}),
+		runBenchmark(ctx, "tx", requestCount, concurrency, func(ctx context.Context, i int) error {
+			req := &protocol.EvaluateTxRequest{
+				RequestId: fmt.Sprintf("benchmark-tx-%d", i),
+				Event:     txEvents[i%len(txEvents)],
+			}
+			return agentClient.Invoke(ctx, agentgrpc.MethodEvaluateTx, req, &protocol.EvaluateTxResponse{})
+		}),
+	}
+	stopUsage()
+	if ctx.Err() != nil {
+		cmd.PrintErrln("Benchmark was interrupted")
+	}
+
+	printBenchmarkResults(cmd, results, usage)
+	return nil
+}
+
+// removeLocalBotContainer removes the container left from a previous run so that
+// a new one is started from the requested image.
+func removeLocalBotContainer(ctx context.Context, dockerClient clients.DockerClient, name string) error {
+	container, err := dockerClient.GetContainerByName(ctx, name)
+	if errors.Is(err, clients.ErrContainerNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up the previous bot container: %v", err)
+	}
+	if err := dockerClient.TerminateContainer(ctx, container.ID); err != nil {
+		return fmt.Errorf("failed to stop the previous bot container: %v", err)
+	}
+	if err := dockerClient.RemoveContainer(ctx, container.ID); err != nil {
+		return fmt.Errorf("failed to remove the previous bot container: %v", err)
+	}
+	return nil
+}
+
+func dialLocalBot(ctx context.Context, hostPort string) (*agentgrpc.Client, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, localBotConnectTimeout)
+	defer cancel()
+	conn, err := grpc.DialContext(
+		dialCtx, fmt.Sprintf("127.0.0.1:%s", hostPort),
+		grpc.WithInsecure(),
+		grpc.WithBlock(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the bot: %v", err)
+	}
+	agentClient := agentgrpc.NewClient()
+	agentClient.WithConn(conn)
+	return agentClient, nil
+}
+
+// runBenchmark calls the function count times with the request index and collects the latencies.
+// It stops sending requests when the context is cancelled.
+func runBenchmark(ctx context.Context, name string, count, concurrency int, do func(context.Context, int) error) *benchmarkResult {
+	result := &benchmarkResult{
+		Name:      name,
+		Latencies: make([]time.Duration, count),
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		reqCh    = make(chan int)
+		errCount int
+	)
+	start := time.Now()
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range reqCh {
+				reqStart := time.Now()
+				err := do(ctx, i)
+				result.Latencies[i] = time.Since(reqStart)
+				if err != nil {
+					mu.Lock()
+					errCount++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	sent := 0
+send:
+	for ; sent < count; sent++ {
+		select {
+		case <-ctx.Done():
+			break send
+		case reqCh <- sent:
+		}
+	}
+	close(reqCh)
+	wg.Wait()
+
+	result.Duration = time.Since(start)
+	result.Count = sent
+	result.Errors = errCount
+	result.Latencies = result.Latencies[:sent]
+	result.Throughput = float64(sent) / result.Duration.Seconds()
+	sort.Slice(result.Latencies, func(i, j int) bool {
+		return result.Latencies[i] < result.Latencies[j]
+	})
+	return result
+}
+
+// percentile returns the p-th percentile of the sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted))*p/100+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+func (usage *benchmarkUsage) sample(ctx context.Context, dockerClient clients.DockerClient, containerID string) {
+	ticker := time.NewTicker(benchmarkUsageSampleEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		stats, err := dockerClient.GetContainerResourceUsage(ctx, containerID)
+		if err != nil {
+			usage.mu.Lock()
+			if usage.sampleErr == nil {
+				usage.sampleErr = err
+			}
+			usage.mu.Unlock()
+			continue
+		}
+		usage.mu.Lock()
+		usage.samples++
+		usage.totalCPU += stats.CPUPercent
+		if stats.CPUPercent > usage.maxCPU {
+			usage.maxCPU = stats.CPUPercent
+		}
+		if stats.MemoryBytes > usage.maxMemory {
+			usage.maxMemory = stats.MemoryBytes
+		}
+		if stats.MaxMemoryBytes > usage.maxMemory {
+			usage.maxMemory = stats.MaxMemoryBytes
+		}
+		usage.mu.Unlock()
+	}
+}
+
+func printBenchmarkResults(cmd *cobra.Command, results []*benchmarkResult, usage *benchmarkUsage) {
+	whiteBold("\nThroughput and latency\n")
+	for _, result := range results {
+		cmd.Printf(
+			"  %-5s  requests=%d  errors=%d  throughput=%.2f/s  p50=%s  p95=%s  max=%s\n",
+			result.Name, result.Count, result.Errors, result.Throughput,
+			percentile(result.Latencies, 50), percentile(result.Latencies, 95),
+			percentile(result.Latencies, 100),
+		)
+	}
+
+	usage.mu.Lock()
+	defer usage.mu.Unlock()
+	whiteBold("\nResource usage\n")
+	if usage.samples == 0 {
+		if usage.sampleErr != nil {
+			yellowBold("  Failed to collect resource usage: %v\n", usage.sampleErr)
+		} else {
+			yellowBold("  Benchmark was too short to collect resource usage\n")
+		}
+		return
+	}
+	cmd.Printf("  cpu     avg=%.2f%%  max=%.2f%%\n", usage.totalCPU/float64(usage.samples), usage.maxCPU)
+	cmd.Printf("  memory  max=%.2f MiB\n", float64(usage.maxMemory)/(1024*1024))
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBenchmarkData(t *testing.T) {
+	r := require.New(t)
+
+	var blockEvents []*protocol.BlockEvent
+	r.NoError(json.Unmarshal(benchmarkBlockData, &blockEvents))
+	r.Greater(len(blockEvents), 1)
+
+	var txEvents []*protocol.TransactionEvent
+	r.NoError(json.Unmarshal(benchmarkTxData, &txEvents))
+	r.Greater(len(txEvents), 1)
+}
+
+func TestRunBenchmark(t *testing.T) {
+	r := require.New(t)
+
+	var (
+		mu      sync.Mutex
+		indexes = make(map[int]bool)
+	)
+	result := runBenchmark(context.Background(), "test", 10, 3, func(ctx context.Context, i int) error {
+		mu.Lock()
+		indexes[i] = true
+		mu.Unlock()
+		time.Sleep(time.Millisecond * time.Duration(i))
+		if i%5 == 0 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	r.Equal("test", result.Name)
+	r.Equal(10, result.Count)
+	r.Equal(2, result.Errors)
+	r.Len(indexes, 10)
+	r.Len(result.Latencies, 10)
+	r.Greater(result.Throughput, float64(0))
+	for i := 1; i < len(result.Latencies); i++ {
+		r.LessOrEqual(result.Latencies[i-1], result.Latencies[i])
+	}
+}
+
+func TestRunBenchmark_Cancel(t *testing.T) {
+	r := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := runBenchmark(ctx, "test", 100, 1, func(ctx context.Context, i int) error {
+		if i == 4 {
+			cancel()
+		}
+		return nil
+	})
+
+	r.Less(result.Count, 100)
+	r.Len(result.Latencies, result.Count)
+}
+
+func TestPercentile(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal(time.Duration(0), percentile(nil, 50))
+
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i))
+	}
+	r.Equal(time.Duration(1), percentile(sorted, 0))
+	r.Equal(time.Duration(50), percentile(sorted, 50))
+	r.Equal(time.Duration(95), percentile(sorted, 95))
+	r.Equal(time.Duration(100), percentile(sorted, 100))
+
+	r.Equal(time.Duration(7), percentile([]time.Duration{7}, 95))
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/manifest"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services/registry"
+	"github.com/forta-network/forta-node/store"
+	"github.com/spf13/cobra"
+)
+
+// botDescription is what the node knows about an assigned bot.
+type botDescription struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name,omitempty"`
+	Version          string  `json:"version,omitempty"`
+	Developer        string  `json:"developer,omitempty"`
+	Repository       string  `json:"repository,omitempty"`
+	ChainIDs         []int64 `json:"chainIds,omitempty"`
+	Documentation    string  `json:"documentation,omitempty"`
+	DocumentationURL string  `json:"documentationUrl,omitempty"`
+	Manifest         string  `json:"manifest"`
+	Image            string  `json:"image"`
+	ImageDigest      string  `json:"imageDigest,omitempty"`
+	Status           string  `json:"status"`
+}
+
+func handleFortaBotsDescribe(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	showDocs, _ := cmd.Flags().GetBool("docs")
+	botID := strings.ToLower(args[0])
+
+	// the registry service saves the assigned bots whenever they change
+	agentConfigs, err := registry.LoadAssignments(path.Join(cfg.StateDir(), config.DefaultAssignmentsFileName))
+	if err != nil {
+		return fmt.Errorf("failed to load the assignments: %v", err)
+	}
+	var agentConfig *config.AgentConfig
+	for _, ac := range agentConfigs {
+		if strings.ToLower(ac.ID) == botID {
+			agentConfig = ac
+			break
+		}
+	}
+	if agentConfig == nil {
+		return fmt.Errorf("bot %s is not assigned to this node", botID)
+	}
+
+	// the manifests and the docs are read from the cache which the registry service fills
+	fileStore, err := store.NewIPFSFileStore(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create the manifest store: %v", err)
+	}
+	signedManifest, err := fileStore.GetAgentManifest(context.Background(), agentConfig.Manifest)
+	if err != nil {
+		return fmt.Errorf("failed to get the bot manifest: %v", err)
+	}
+
+	disabledBots, err := config.GetDisabledBots(cfg.FortaDir)
+	if err != nil {
+		return fmt.Errorf("failed to read the disabled bots: %v", err)
+	}
+	reports := health.NewClient().CheckHealth("forta", config.DefaultHealthPort)
+	desc := describeBot(agentConfig, signedManifest.Manifest, cfg.Registry.IPFS.GatewayURL)
+	desc.Status = getBotStatus(botID, disabledBots, reports)
+
+	switch format {
+	case "text":
+		writeBotDescription(os.Stdout, desc)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(desc); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+
+	if !showDocs {
+		return nil
+	}
+	if len(desc.Documentation) == 0 {
+		yellowBold("The bot has no documentation.\n")
+		return nil
+	}
+	docs, err := fileStore.GetFile(context.Background(), desc.Documentation)
+	if err != nil {
+		return fmt.Errorf("failed to get the bot documentation: %v", err)
+	}
+	fmt.Fprintf(os.Stdout, "\n%s\n", docs)
+	return nil
+}
+
+func describeBot(agentConfig *config.AgentConfig, m *manifest.AgentManifest, gatewayURL string) *botDescription {
+	desc := &botDescription{
+		ID:       agentConfig.ID,
+		Manifest: agentConfig.Manifest,
+		Image:    agentConfig.Image,
+	}
+	if i := strings.LastIndex(agentConfig.Image, "@"); i >= 0 {
+		desc.ImageDigest = agentConfig.Image[i+1:]
+	}
+	if m == nil {
+		return desc
+	}
+	desc.Name = stringValue(m.Name)
+	desc.Version = stringValue(m.Version)
+	desc.Developer = stringValue(m.From)
+	desc.Repository = stringValue(m.Repository)
+	desc.ChainIDs = m.ChainIDs
+	desc.Documentation = stringValue(m.Documentation)
+	if len(desc.Documentation) > 0 && len(gatewayURL) > 0 {
+		desc.DocumentationURL = fmt.Sprintf("%s/ipfs/%s", strings.TrimSuffix(gatewayURL, "/"), desc.Documentation)
+	}
+	return desc
+}
+
+// getBotStatus finds the status of the bot in the agent pool reports of the running node.
+func getBotStatus(botID string, disabledBots []string, reports health.Reports) string {
+	for _, disabledBot := range disabledBots {
+		if strings.ToLower(disabledBot) == botID {
+			return "disabled"
+		}
+	}
+	status := "not running"
+	for _, report := range reports {
+		if strings.HasSuffix(strings.ToLower(report.Name), "agent."+botID) {
+			status = fmt.Sprintf("running (%s)", report.Details)
+			break
+		}
+		if strings.HasSuffix(report.Name, "agents.refused") && isListed(botID, report.Details) {
+			status = "refused"
+			break
+		}
+	}
+	if dropped := getDroppedFindings(botID, reports); dropped > 0 {
+		status = fmt.Sprintf("%s - %d findings dropped over the quota", status, dropped)
+	}
+	return status
+}
+
+// getDroppedFindings finds the amount of the findings of the bot which the publisher dropped because
+// the bot exceeded the findings quota.
+func getDroppedFindings(botID string, reports health.Reports) int {
+	for _, report := range reports {
+		if !strings.HasSuffix(report.Name, "findings.over-quota") || len(report.Details) == 0 {
+			continue
+		}
+		for _, entry := range strings.Split(report.Details, ",") {
+			parts := strings.SplitN(entry, "=", 2)
+			if len(parts) != 2 || strings.ToLower(parts[0]) != botID {
+				continue
+			}
+			dropped, _ := strconv.Atoi(parts[1])
+			return dropped
+		}
+	}
+	return 0
+}
+
+// isListed tells if the bot is in the comma separated list of the report details.
+func isListed(botID, details string) bool {
+	for _, listedID := range strings.Split(details, ",") {
+		if strings.ToLower(strings.TrimSpace(listedID)) == botID {
+			return true
+		}
+	}
+	return false
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func writeBotDescription(w io.Writer, desc *botDescription) {
+	var chainIDs []string
+	for _, chainID := range desc.ChainIDs {
+		chainIDs = append(chainIDs, strconv.FormatInt(chainID, 10))
+	}
+	docs := desc.Documentation
+	if len(desc.DocumentationURL) > 0 {
+		docs = desc.DocumentationURL
+	}
+	fields := [][2]string{
+		{"ID", desc.ID},
+		{"Name", desc.Name},
+		{"Version", desc.Version},
+		{"Developer", desc.Developer},
+		{"Repository", desc.Repository},
+		{"Chains", strings.Join(chainIDs, ", ")},
+		{"Documentation", docs},
+		{"Manifest", desc.Manifest},
+		{"Image", desc.Image},
+		{"Image digest", desc.ImageDigest},
+		{"Status", desc.Status},
+	}
+	for _, field := range fields {
+		if len(field[1]) == 0 {
+			field[1] = "-"
+		}
+		fmt.Fprintf(w, "%-14s %s\n", field[0]+":", field[1])
+	}
+}
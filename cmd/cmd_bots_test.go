@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/manifest"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribeBot(t *testing.T) {
+	r := require.New(t)
+
+	name := "Test Bot"
+	from := "0x01"
+	docs := "QmDocs"
+	agentConfig := &config.AgentConfig{
+		ID:       "0xabc",
+		Manifest: "QmManifest",
+		Image:    "disco.forta.network/bafybeibot@sha256:1234",
+	}
+	desc := describeBot(agentConfig, &manifest.AgentManifest{
+		Name:          &name,
+		From:          &from,
+		Documentation: &docs,
+		ChainIDs:      []int64{1, 137},
+	}, "https://ipfs.forta.network/")
+	r.Equal("sha256:1234", desc.ImageDigest)
+	r.Equal("https://ipfs.forta.network/ipfs/QmDocs", desc.DocumentationURL)
+
+	reports := health.Reports{
+		{Name: "forta.container.forta-scanner.agent-pool.agent.0xabc", Details: "latency=10ms"},
+	}
+	r.Equal("disabled", getBotStatus("0xabc", []string{"0xABC"}, reports))
+	r.Equal("running (latency=10ms)", getBotStatus("0xabc", nil, reports))
+	r.Equal("not running", getBotStatus("0xdef", nil, reports))
+	refusedReports := health.Reports{
+		{Name: "forta.container.forta-scanner.agent-pool.agents.refused", Details: "0x123, 0xdef"},
+	}
+	r.Equal("refused", getBotStatus("0xdef", nil, refusedReports))
+
+	reports = append(reports, &health.Report{
+		Name: "forta.container.forta-publisher.findings.over-quota", Details: "0xabc=12,0xdef=3",
+	})
+	r.Equal("running (latency=10ms) - 12 findings dropped over the quota", getBotStatus("0xabc", nil, reports))
+	r.Equal("not running - 3 findings dropped over the quota", getBotStatus("0xdef", nil, reports))
+
+	desc.Status = "not running"
+	w := new(bytes.Buffer)
+	writeBotDescription(w, desc)
+	r.True(strings.HasPrefix(w.String(), "ID:            0xabc\nName:          Test Bot\nVersion:       -\n"))
+	r.Contains(w.String(), "Chains:        1, 137\n")
+}
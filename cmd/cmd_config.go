@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/creasty/defaults"
+	"github.com/forta-network/forta-core-go/security"
+	"github.com/forta-network/forta-node/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func handleFortaConfigMigrate(cmd *cobra.Command, args []string) error {
+	configPath := cfg.ConfigFilePath()
+	configBytes, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read the config file: %v", err)
+	}
+	migrated, report, err := config.MigrateConfig(configBytes)
+	if err != nil {
+		return fmt.Errorf("failed to migrate the config file: %v", err)
+	}
+	if !report.HasChanges() {
+		greenBold("The config file is already up to date (version %d).\n", report.ToVersion)
+		return nil
+	}
+
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if dryRun {
+		cmd.Print(string(migrated))
+		return nil
+	}
+
+	backupPath := configPath + ".bak"
+	if err := os.WriteFile(backupPath, configBytes, 0644); err != nil {
+		return fmt.Errorf("failed to back up the config file: %v", err)
+	}
+	if err := os.WriteFile(configPath, migrated, 0644); err != nil {
+		return fmt.Errorf("failed to write the migrated config file: %v", err)
+	}
+	for _, deprecated := range report.Deprecated {
+		cmd.Printf("- %s\n", deprecated)
+	}
+	greenBold("Migrated the config file from version %d to %d. The old file is at %s\n", report.FromVersion, report.ToVersion, backupPath)
+	return nil
+}
+
+func handleFortaConfigGet(cmd *cobra.Command, args []string) error {
+	value, err := config.GetConfigValue(cfg, args[0])
+	if err != nil {
+		return err
+	}
+	cmd.Print(string(value))
+	return nil
+}
+
+func handleFortaConfigSet(cmd *cobra.Command, args []string) error {
+	configPath := cfg.ConfigFilePath()
+	configBytes, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read the config file: %v", err)
+	}
+	updated, err := config.SetConfigValue(configBytes, args[0], args[1])
+	if err != nil {
+		return fmt.Errorf("failed to set the config value: %v", err)
+	}
+	if err := validateConfigFile(updated); err != nil {
+		return err
+	}
+
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if dryRun {
+		cmd.Print(string(updated))
+		return nil
+	}
+
+	backupPath := configPath + ".bak"
+	if err := os.WriteFile(backupPath, configBytes, 0644); err != nil {
+		return fmt.Errorf("failed to back up the config file: %v", err)
+	}
+	if err := os.WriteFile(configPath, updated, 0644); err != nil {
+		return fmt.Errorf("failed to write the config file: %v", err)
+	}
+	greenBold("Set %s in the config file. The old file is at %s\n", args[0], backupPath)
+	return nil
+}
+
+// validateConfigFile checks that the config file can be loaded and that the values are valid.
+func validateConfigFile(configBytes []byte) error {
+	migrated, _, err := config.MigrateConfig(configBytes)
+	if err != nil {
+		return fmt.Errorf("invalid config file: %v", err)
+	}
+	var updatedCfg config.Config
+	if err := yaml.Unmarshal(migrated, &updatedCfg); err != nil {
+		return fmt.Errorf("invalid config file: %v", err)
+	}
+	if err := defaults.Set(&updatedCfg); err != nil {
+		return fmt.Errorf("failed to set the config defaults: %v", err)
+	}
+	return validateConfigValues(&updatedCfg)
+}
+
+func handleFortaConfigSignRemote(cmd *cobra.Command, args []string) error {
+	filePath, _ := cmd.Flags().GetString("file")
+	version, _ := cmd.Flags().GetInt64("version")
+	overrides, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read the overrides file: %v", err)
+	}
+	_, _, ignored, err := config.FilterReloadableOverrides(overrides)
+	if err != nil {
+		return fmt.Errorf("failed to decode the overrides file: %v", err)
+	}
+	if len(ignored) > 0 {
+		yellowBold("These fields are not reloadable and will be ignored by the nodes: %s\n", strings.Join(ignored, ", "))
+	}
+
+	key, err := security.LoadKeyWithPassphrase(cfg.KeyDirPath, cfg.Passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to load the key: %v", err)
+	}
+	doc, err := config.SignRemoteConfig(key, version, string(overrides))
+	if err != nil {
+		return fmt.Errorf("failed to sign the overrides: %v", err)
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/config"
+	"github.com/spf13/cobra"
+)
+
+// profilingContainers are the service containers which serve the profiling endpoints.
+var profilingContainers = map[string]string{
+	"supervisor":   config.DockerSupervisorContainerName,
+	"updater":      config.DockerUpdaterContainerName,
+	"scanner":      config.DockerScannerContainerName,
+	"inspector":    config.DockerInspectorContainerName,
+	"json-rpc":     config.DockerJSONRPCProxyContainerName,
+	"jwt-provider": config.DockerJWTProviderContainerName,
+	"storage":      config.DockerStorageContainerName,
+}
+
+type profileSpec struct {
+	FileName string
+	Path     string
+	Query    string
+	Seconds  int
+}
+
+func profileSpecs(seconds, traceSeconds int) []*profileSpec {
+	specs := []*profileSpec{
+		{FileName: "cpu.pprof", Path: "profile", Query: "seconds=" + strconv.Itoa(seconds), Seconds: seconds},
+		{FileName: "heap.pprof", Path: "heap"},
+		{FileName: "allocs.pprof", Path: "allocs"},
+		{FileName: "block.pprof", Path: "block"},
+		{FileName: "mutex.pprof", Path: "mutex"},
+		{FileName: "goroutine.pprof", Path: "goroutine"},
+		{FileName: "goroutines.txt", Path: "goroutine", Query: "debug=2"},
+	}
+	if traceSeconds > 0 {
+		specs = append(specs, &profileSpec{
+			FileName: "trace.out", Path: "trace", Query: "seconds=" + strconv.Itoa(traceSeconds), Seconds: traceSeconds,
+		})
+	}
+	return specs
+}
+
+func handleFortaDebugProfile(cmd *cobra.Command, args []string) error {
+	seconds, _ := cmd.Flags().GetInt("seconds")
+	traceSeconds, _ := cmd.Flags().GetInt("trace-seconds")
+	outputDir, _ := cmd.Flags().GetString("output")
+
+	services := []string{args[0]}
+	if args[0] == "all" {
+		services = profilingServiceNames()
+	} else if _, ok := profilingContainers[args[0]]; !ok {
+		return fmt.Errorf("unknown service '%s' - should be one of: all, %s", args[0], strings.Join(profilingServiceNames(), ", "))
+	}
+
+	dockerClient, err := clients.NewDockerClient("")
+	if err != nil {
+		return fmt.Errorf("failed to create the docker client: %v", err)
+	}
+	ctx := context.Background()
+	timestamp := time.Now().UTC().Format("20060102-150405")
+	specs := profileSpecs(seconds, traceSeconds)
+
+	cmd.PrintErrf("Collecting the profiles for %d seconds...\n", seconds+traceSeconds)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []string
+	)
+	for _, service := range services {
+		wg.Add(1)
+		go func(service string) {
+			defer wg.Done()
+			filePath := path.Join(outputDir, fmt.Sprintf("forta-profile-%s-%s.tar.gz", service, timestamp))
+			err := collectServiceProfiles(ctx, dockerClient, profilingContainers[service], filePath, specs)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", service, err))
+				return
+			}
+			cmd.Printf("%s: %s\n", service, filePath)
+		}(service)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("failed to collect the profiles:\n%s", strings.Join(errs, "\n"))
+	}
+	greenBold("Collected the profiles - use 'go tool pprof' and 'go tool trace' to analyze them.\n")
+	return nil
+}
+
+func profilingServiceNames() []string {
+	var names []string
+	for service := range profilingContainers {
+		names = append(names, service)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func collectServiceProfiles(ctx context.Context, dockerClient clients.DockerClient, containerName, filePath string, specs []*profileSpec) error {
+	if !cfg.Profiling.Enable {
+		return fmt.Errorf("profiling is not enabled - set profiling.enable in the config and restart the node")
+	}
+	container, err := dockerClient.GetContainerByName(ctx, containerName)
+	if err != nil {
+		return fmt.Errorf("failed to find the container - is the node running? (%v)", err)
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create the bundle file: %v", err)
+	}
+	defer f.Close()
+	return writeProfileBundle(ctx, f, execProfileFetcher(dockerClient, container.ID), specs)
+}
+
+// profileFetcher fetches the profile described by the spec.
+type profileFetcher func(ctx context.Context, spec *profileSpec) ([]byte, error)
+
+// execProfileFetcher fetches the profiles from inside the container since the profiling
+// server listens only on the loopback interface of the container.
+func execProfileFetcher(dockerClient clients.DockerClient, containerID string) profileFetcher {
+	return func(ctx context.Context, spec *profileSpec) ([]byte, error) {
+		var buf bytes.Buffer
+		url := profileURL(fmt.Sprintf("http://127.0.0.1:%s", config.DefaultProfilingPort), spec)
+		if err := dockerClient.ExecContainer(ctx, containerID, []string{"wget", "-q", "-O", "-", url}, &buf); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+func profileURL(baseURL string, spec *profileSpec) string {
+	url := fmt.Sprintf("%s/debug/pprof/%s", baseURL, spec.Path)
+	if len(spec.Query) > 0 {
+		url = fmt.Sprintf("%s?%s", url, spec.Query)
+	}
+	return url
+}
+
+// writeProfileBundle collects the profiles and writes them to a tar.gz bundle.
+func writeProfileBundle(ctx context.Context, w io.Writer, fetch profileFetcher, specs []*profileSpec) error {
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, spec := range specs {
+		fetchCtx, cancel := context.WithTimeout(ctx, time.Duration(spec.Seconds)*time.Second+time.Minute)
+		b, err := fetch(fetchCtx, spec)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to collect %s: %v", spec.FileName, err)
+		}
+		if err := tarWriter.WriteHeader(&tar.Header{
+			Name:    spec.FileName,
+			Mode:    0644,
+			Size:    int64(len(b)),
+			ModTime: time.Now(),
+		}); err != nil {
+			return err
+		}
+		if _, err := tarWriter.Write(b); err != nil {
+			return err
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// bundleFile is a file in the debug bundle.
+type bundleFile struct {
+	Name    string
+	Content []byte
+}
+
+func handleFortaDebugBundle(cmd *cobra.Command, args []string) error {
+	outputDir, _ := cmd.Flags().GetString("output")
+	logLines, _ := cmd.Flags().GetInt("log-lines")
+
+	filePath := path.Join(outputDir, fmt.Sprintf("forta-debug-%s.tar.gz", time.Now().UTC().Format("20060102-150405")))
+	cmd.PrintErrln("Collecting the debugging data...")
+	files, collectErrs := collectDebugBundle(context.Background(), logLines)
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create the bundle file: %v", err)
+	}
+	defer f.Close()
+	if err := writeDebugBundle(f, files); err != nil {
+		return fmt.Errorf("failed to write the bundle: %v", err)
+	}
+
+	for _, collectErr := range collectErrs {
+		yellowBold("Skipped: %s\n", collectErr)
+	}
+	greenBold("Wrote the debug bundle to %s - the secrets are redacted but please review it before sharing.\n", filePath)
+	return nil
+}
+
+// collectDebugBundle collects everything it can and records what failed in the bundle, so that a bundle
+// can be created even if the node is not running.
+func collectDebugBundle(ctx context.Context, logLines int) ([]*bundleFile, []string) {
+	var (
+		files []*bundleFile
+		errs  []string
+	)
+	rd := &redactor{}
+	if len(cfg.Passphrase) > 0 {
+		rd.addSecret(cfg.Passphrase)
+	}
+	addJSON := func(name string, v interface{}) {
+		b, _ := json.MarshalIndent(v, "", "  ")
+		files = append(files, &bundleFile{Name: name, Content: b})
+	}
+
+	// the config goes first so that the secrets in it are redacted from the rest
+	configBytes, err := os.ReadFile(cfg.ConfigFilePath())
+	if err == nil {
+		configBytes, err = rd.RedactConfig(configBytes)
+	}
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("config: %v", err))
+	} else {
+		files = append(files, &bundleFile{Name: "config.yml", Content: configBytes})
+	}
+
+	addJSON("health.json", health.NewClient().CheckHealth("forta", config.DefaultHealthPort))
+
+	releaseSummary, _ := config.GetBuildReleaseSummary()
+	dockerClient, err := clients.NewDockerClient("")
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("docker: %v", err))
+		addJSON("version.json", &versionReport{Node: releaseSummary, Containers: []*containerVersion{}})
+		return rd.redactFiles(files), errs
+	}
+	containers, err := dockerClient.GetContainers(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("containers: %v", err))
+	}
+	addJSON("version.json", &versionReport{Node: releaseSummary, Containers: makeContainerVersions(containers)})
+	addJSON("docker.json", containers)
+
+	serviceContainers, err := dockerClient.GetFortaServiceContainers(ctx)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("service containers: %v", err))
+	}
+	for _, container := range serviceContainers {
+		name := strings.TrimPrefix(container.Names[0], "/")
+		logs, err := dockerClient.GetContainerLogs(ctx, container.ID, fmt.Sprint(logLines), -1)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("logs of %s: %v", name, err))
+			continue
+		}
+		files = append(files, &bundleFile{Name: fmt.Sprintf("logs/%s.log", name), Content: []byte(logs)})
+	}
+
+	// the inspection results are logged only after each inspection so all logs are searched
+	inspector, ok := serviceContainers.FindByName(config.DockerInspectorContainerName)
+	if ok {
+		logs, err := dockerClient.GetContainerLogs(ctx, inspector.ID, "all", -1)
+		if err == nil {
+			results, findErr := findInspectionResults(logs, 0)
+			if findErr == nil {
+				addJSON("inspection.json", results)
+			}
+			err = findErr
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("inspection: %v", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		files = append(files, &bundleFile{Name: "errors.txt", Content: []byte(strings.Join(errs, "\n") + "\n")})
+	}
+	return rd.redactFiles(files), errs
+}
+
+// writeDebugBundle writes the files to a tar.gz bundle.
+func writeDebugBundle(w io.Writer, files []*bundleFile) error {
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, file := range files {
+		if err := tarWriter.WriteHeader(&tar.Header{
+			Name:    file.Name,
+			Mode:    0644,
+			Size:    int64(len(file.Content)),
+			ModTime: time.Now(),
+		}); err != nil {
+			return err
+		}
+		if _, err := tarWriter.Write(file.Content); err != nil {
+			return err
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+// redactor removes the secrets from the config and then the same secrets from the other files.
+type redactor struct {
+	secrets map[string]bool
+}
+
+func (rd *redactor) addSecret(secret string) {
+	// the short values would redact too much from the logs
+	if len(secret) < 4 {
+		return
+	}
+	if rd.secrets == nil {
+		rd.secrets = make(map[string]bool)
+	}
+	rd.secrets[secret] = true
+}
+
+// RedactConfig redacts the values of the secret keys and the credentials in the URLs.
+func (rd *redactor) RedactConfig(b []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse the config file: %v", err)
+	}
+	rd.redactNode(&doc, false)
+	return yaml.Marshal(&doc)
+}
+
+func (rd *redactor) redactNode(node *yaml.Node, secret bool) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			rd.redactNode(child, secret)
+		}
+
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			rd.redactNode(node.Content[i+1], secret || config.IsSecretKey(node.Content[i].Value))
+		}
+
+	case yaml.ScalarNode:
+		if secret && len(node.Value) > 0 {
+			rd.addSecret(node.Value)
+			node.Value = config.RedactedValue
+			return
+		}
+		if redactedURL, ok := config.RedactURL(node.Value); ok {
+			rd.addSecret(node.Value)
+			node.Value = redactedURL
+		}
+	}
+}
+
+// redactFiles replaces the collected secrets in all files. The longer secrets are replaced first
+// so that a secret which contains another one is not left partially.
+func (rd *redactor) redactFiles(files []*bundleFile) []*bundleFile {
+	if len(rd.secrets) == 0 {
+		return files
+	}
+	secrets := make([]string, 0, len(rd.secrets))
+	for secret := range rd.secrets {
+		secrets = append(secrets, secret)
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+	var oldNew []string
+	for _, secret := range secrets {
+		oldNew = append(oldNew, secret, config.RedactedValue)
+	}
+	replacer := strings.NewReplacer(oldNew...)
+	for _, file := range files {
+		file.Content = []byte(replacer.Replace(string(file.Content)))
+	}
+	return files
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mock_clients "github.com/forta-network/forta-node/clients/mocks"
+	"github.com/forta-network/forta-node/services"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteProfileBundle(t *testing.T) {
+	r := require.New(t)
+
+	server := httptest.NewServer(services.NewProfilingHandler())
+	defer server.Close()
+
+	// the profiles are fetched from inside the container - serve them from the test server instead
+	dockerClient := mock_clients.NewMockDockerClient(gomock.NewController(t))
+	dockerClient.EXPECT().ExecContainer(gomock.Any(), "container-id", gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, containerID string, cmd []string, stdout io.Writer) error {
+			r.Equal([]string{"wget", "-q", "-O", "-"}, cmd[:4])
+			url := strings.Replace(cmd[4], "http://127.0.0.1:6060", server.URL, 1)
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			r.NoError(err)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return fmt.Errorf("exit code 1")
+			}
+			_, err = io.Copy(stdout, resp.Body)
+			return err
+		},
+	).AnyTimes()
+	fetch := execProfileFetcher(dockerClient, "container-id")
+
+	var buf bytes.Buffer
+	r.NoError(writeProfileBundle(context.Background(), &buf, fetch, profileSpecs(1, 1)))
+
+	gzipReader, err := gzip.NewReader(&buf)
+	r.NoError(err)
+	tarReader := tar.NewReader(gzipReader)
+	var files []string
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		r.NoError(err)
+		r.NotZero(header.Size, header.Name)
+		files = append(files, header.Name)
+	}
+	r.Equal([]string{
+		"cpu.pprof", "heap.pprof", "allocs.pprof", "block.pprof", "mutex.pprof",
+		"goroutine.pprof", "goroutines.txt", "trace.out",
+	}, files)
+
+	// unknown profiles fail
+	r.Error(writeProfileBundle(context.Background(), io.Discard, fetch, []*profileSpec{{FileName: "x", Path: "unknown"}}))
+}
+
+func TestDebugBundleRedaction(t *testing.T) {
+	r := require.New(t)
+
+	rd := &redactor{}
+	rd.addSecret("test-passphrase")
+	redacted, err := rd.RedactConfig([]byte(`chainId: 1
+scan:
+  jsonRpc:
+    url: https://mainnet.infura.io/v3/0123456789abcdef0123456789abcdef
+    headers:
+      Authorization: Bearer abcdef
+registry:
+  jsonRpc:
+    url: https://polygon-rpc.com
+  ipfs:
+    username: user
+    password: hunter22
+agentEnv:
+  bots:
+    "0x01":
+      vars:
+        API_KEY: key-value-1
+publish:
+  apiUrl: https://alerts.forta.network?key=query-secret
+`))
+	r.NoError(err)
+	config := string(redacted)
+	r.Contains(config, "url: https://mainnet.infura.io/v3/[REDACTED]")
+	r.Contains(config, "Authorization: '[REDACTED]'")
+	r.Contains(config, "url: https://polygon-rpc.com\n")
+	r.Contains(config, "username: user\n")
+	r.Contains(config, "password: '[REDACTED]'")
+	r.Contains(config, "API_KEY: '[REDACTED]'")
+	r.Contains(config, "apiUrl: https://alerts.forta.network?key=[REDACTED]")
+	for _, secret := range []string{"0123456789abcdef0123456789abcdef", "abcdef", "hunter22", "key-value-1", "query-secret"} {
+		r.NotContains(config, secret)
+	}
+
+	// the secrets found in the config are redacted from the other files too
+	files := rd.redactFiles([]*bundleFile{
+		{Name: "logs/forta-scanner.log", Content: []byte("connecting to https://mainnet.infura.io/v3/0123456789abcdef0123456789abcdef with test-passphrase")},
+	})
+	r.Equal("connecting to [REDACTED] with [REDACTED]", string(files[0].Content))
+
+	var buf bytes.Buffer
+	r.NoError(writeDebugBundle(&buf, files))
+	gzipReader, err := gzip.NewReader(&buf)
+	r.NoError(err)
+	header, err := tar.NewReader(gzipReader).Next()
+	r.NoError(err)
+	r.Equal("logs/forta-scanner.log", header.Name)
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	"github.com/spf13/cobra"
+)
+
+func handleFortaDisableBot(cmd *cobra.Command, args []string) error {
+	return setBotDisabled(cmd, args[0], true)
+}
+
+func handleFortaEnableBot(cmd *cobra.Command, args []string) error {
+	return setBotDisabled(cmd, args[0], false)
+}
+
+// setBotDisabled disables or enables a bot in the Forta dir, so that the state is kept
+// after restarts, and signals the running node to stop or run the bot.
+func setBotDisabled(cmd *cobra.Command, botID string, disabled bool) error {
+	botID = strings.ToLower(botID)
+	if err := config.SetBotDisabled(cfg.FortaDir, botID, disabled); err != nil {
+		return fmt.Errorf("failed to update the disabled bots: %v", err)
+	}
+	action := "enabled"
+	if disabled {
+		action = "disabled"
+	}
+
+	running, err := sendPauseStateSignal()
+	if err != nil {
+		return fmt.Errorf("failed to signal the node: %v", err)
+	}
+	if !running {
+		yellowBold("The node is not running - the bot will be %s when started.\n", action)
+		return nil
+	}
+	cmd.PrintErrln("Signaled the node. Waiting for the report...")
+
+	reports, applied := waitForReports(getNodeReports, func(reports health.Reports) bool {
+		return isBotDisabledApplied(reports, botID, disabled)
+	}, pauseReportTimeout)
+	for _, report := range reports {
+		if strings.HasSuffix(report.Name, "agents.disabled") {
+			cmd.Printf("%s: %s\n", report.Name, report.Details)
+		}
+	}
+	if !applied {
+		yellowBold("The bot is not reported as %s yet - please check 'forta status --show all' later.\n", action)
+		return nil
+	}
+	if disabled {
+		greenBold("Disabled the bot on this node - use 'forta enable-bot %s' to run it again.\n", botID)
+	} else {
+		greenBold("Enabled the bot on this node.\n")
+	}
+	return nil
+}
+
+// isBotDisabledApplied tells if the disabled bots are reported and the bot is in them as expected.
+func isBotDisabledApplied(reports health.Reports, botID string, disabled bool) bool {
+	var found bool
+	for _, report := range reports {
+		if !strings.HasSuffix(report.Name, "agents.disabled") {
+			continue
+		}
+		if strings.Contains(report.Details, botID) != disabled {
+			return false
+		}
+		found = true
+	}
+	return found
+}
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsBotDisabledApplied(t *testing.T) {
+	r := require.New(t)
+
+	reports := health.Reports{
+		{Name: "agent-pool.agents.disabled", Details: "0xabcd"},
+	}
+	r.True(isBotDisabledApplied(reports, "0xabcd", true))
+	r.False(isBotDisabledApplied(reports, "0xabcd", false))
+	r.True(isBotDisabledApplied(reports, "0x1234", false))
+	r.False(isBotDisabledApplied(nil, "0x1234", false))
+}
@@ -29,6 +29,30 @@ func handleFortaInit(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	interactive, err := isInteractiveInit(cmd)
+	if err != nil {
+		return err
+	}
+	if interactive && isConfigFileInitialized() {
+		yellowBold("The config file at %s already exists and will not be overwritten.\n", cfg.ConfigFilePath())
+	}
+	if interactive && !isConfigFileInitialized() {
+		answers, err := newInitWizard(os.Stdin, os.Stdout, !isKeyInitialized()).Run()
+		if err != nil {
+			return err
+		}
+		b, err := makeWizardConfig(answers, config.GetEnvDefaults(cfg.Development))
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(cfg.ConfigFilePath(), b, 0644); err != nil {
+			return err
+		}
+		if len(answers.Passphrase) > 0 {
+			cfg.Passphrase = answers.Passphrase
+		}
+	}
+
 	if !isConfigFileInitialized() {
 		tmpl, err := template.New("config-template").Parse(defaultConfig)
 		if err != nil {
@@ -69,6 +93,13 @@ func handleFortaInit(cmd *cobra.Command, args []string) error {
 	}
 
 	color.Green("\nSuccessfully initialized at %s\n", cfg.FortaDir)
+	if interactive {
+		whiteBold("\n%s\n", strings.Join([]string{
+			"- Please keep your passphrase safe and set it as $FORTA_PASSPHRASE before running the node.",
+			"- Please register this node after making sure that you have staked enough.",
+		}, "\n"))
+		return nil
+	}
 	whiteBold("\n%s\n", strings.Join([]string{
 		"- Please make sure that all of the values in config.yml are set correctly.",
 		"- Please register this node after making sure that you have staked enough.",
@@ -77,6 +108,19 @@ func handleFortaInit(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// isInteractiveInit tells if the init wizard should be used. Unless the flag is set explicitly,
+// the wizard is used only when the input is a terminal.
+func isInteractiveInit(cmd *cobra.Command) (bool, error) {
+	if cmd.Flags().Changed("interactive") {
+		return cmd.Flags().GetBool("interactive")
+	}
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false, nil
+	}
+	return info.Mode()&os.ModeCharDevice != 0, nil
+}
+
 func isValidPassphrase(passphrase string) bool {
 	matches, _ := regexp.MatchString(`([a-zA-Z0-9]+)`, passphrase)
 	return matches
@@ -87,6 +131,9 @@ func printScannerAddress(address string) {
 }
 
 const defaultConfig = `# Auto generated by 'forta init' - safe to modify
+# The version of the config schema - see 'forta config migrate'
+version: 1
+
 # The chainId is the chainId of the network that is analyzed (1=mainnet)
 chainId: 1
 
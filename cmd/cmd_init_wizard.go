@@ -0,0 +1,304 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/creasty/defaults"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/forta-network/forta-node/config"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	initModeRegistry = "registry"
+	initModeLocal    = "local"
+
+	defaultRegistryChainID = 137
+	chainIDCheckTimeout    = time.Second * 10
+)
+
+// initAnswers contains the values collected by the init wizard.
+type initAnswers struct {
+	ChainID     uint64
+	ScanURL     string
+	TraceURL    string
+	Mode        string
+	RegistryURL string
+	BotImages   []string
+	WebhookURL  string
+	Passphrase  string
+}
+
+// initWizard asks the operator for the essential settings of a new node.
+type initWizard struct {
+	in  *bufio.Reader
+	out io.Writer
+	// askPassphrase is false when the key already exists
+	askPassphrase bool
+	getChainID    func(ctx context.Context, url string) (uint64, error)
+}
+
+func newInitWizard(in io.Reader, out io.Writer, askPassphrase bool) *initWizard {
+	return &initWizard{
+		in:            bufio.NewReader(in),
+		out:           out,
+		askPassphrase: askPassphrase,
+		getChainID:    getRPCChainID,
+	}
+}
+
+// Run asks the questions until all of the answers are valid.
+func (w *initWizard) Run() (*initAnswers, error) {
+	var (
+		answers initAnswers
+		err     error
+	)
+
+	answers.ChainID, err = w.askChainID()
+	if err != nil {
+		return nil, err
+	}
+	answers.ScanURL, err = w.askRPCURL("Scan JSON-RPC URL", "", answers.ChainID, false)
+	if err != nil {
+		return nil, err
+	}
+	answers.TraceURL, err = w.askRPCURL("Trace JSON-RPC URL (must support trace_block, leave empty to disable tracing)", "", answers.ChainID, true)
+	if err != nil {
+		return nil, err
+	}
+	answers.Mode, err = w.askChoice("Mode", initModeRegistry, initModeRegistry, initModeLocal)
+	if err != nil {
+		return nil, err
+	}
+
+	switch answers.Mode {
+	case initModeRegistry:
+		answers.RegistryURL, err = w.askRPCURL("Registry (Polygon) JSON-RPC URL", "https://polygon-rpc.com", defaultRegistryChainID, false)
+		if err != nil {
+			return nil, err
+		}
+
+	case initModeLocal:
+		images, err := w.ask("Bot images to run (comma separated)", "")
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range strings.Split(images, ",") {
+			if image = strings.TrimSpace(image); len(image) > 0 {
+				answers.BotImages = append(answers.BotImages, image)
+			}
+		}
+		answers.WebhookURL, err = w.ask("Webhook URL to send the alerts to (leave empty to write to a log file)", "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if w.askPassphrase {
+		answers.Passphrase, err = w.askNewPassphrase()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &answers, nil
+}
+
+// ask prints the question and reads the answer, falling back to the default value if the answer is empty.
+func (w *initWizard) ask(question, defaultValue string) (string, error) {
+	if len(defaultValue) > 0 {
+		fmt.Fprintf(w.out, "%s [%s]: ", question, defaultValue)
+	} else {
+		fmt.Fprintf(w.out, "%s: ", question)
+	}
+	line, err := w.in.ReadString('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		return "", fmt.Errorf("failed to read the answer: %v", err)
+	}
+	line = strings.TrimSpace(line)
+	if len(line) == 0 {
+		return defaultValue, nil
+	}
+	return line, nil
+}
+
+func (w *initWizard) askChoice(question, defaultValue string, choices ...string) (string, error) {
+	question = fmt.Sprintf("%s (%s)", question, strings.Join(choices, "/"))
+	for {
+		answer, err := w.ask(question, defaultValue)
+		if err != nil {
+			return "", err
+		}
+		for _, choice := range choices {
+			if strings.EqualFold(answer, choice) {
+				return choice, nil
+			}
+		}
+		fmt.Fprintf(w.out, "Please choose one of: %s\n", strings.Join(choices, ", "))
+	}
+}
+
+func (w *initWizard) askChainID() (uint64, error) {
+	for {
+		answer, err := w.ask("Chain ID of the network to scan", "1")
+		if err != nil {
+			return 0, err
+		}
+		chainID, err := strconv.ParseUint(answer, 10, 64)
+		if err == nil && chainID > 0 {
+			return chainID, nil
+		}
+		fmt.Fprintln(w.out, "Please enter a positive number (e.g. 1 for Ethereum mainnet).")
+	}
+}
+
+// askRPCURL asks for a URL until it serves the expected chain. An empty answer is accepted
+// only if the URL is optional.
+func (w *initWizard) askRPCURL(question, defaultValue string, expectedChainID uint64, optional bool) (string, error) {
+	for {
+		url, err := w.ask(question, defaultValue)
+		if err != nil {
+			return "", err
+		}
+		if len(url) == 0 {
+			if optional {
+				return "", nil
+			}
+			fmt.Fprintln(w.out, "Please enter a URL.")
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), chainIDCheckTimeout)
+		chainID, err := w.getChainID(ctx, url)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(w.out, "Failed to get the chain ID from %s: %v\n", url, err)
+			continue
+		}
+		if chainID != expectedChainID {
+			fmt.Fprintf(w.out, "The chain ID of %s is %d but expected %d.\n", url, chainID, expectedChainID)
+			continue
+		}
+		return url, nil
+	}
+}
+
+func (w *initWizard) askNewPassphrase() (string, error) {
+	fmt.Fprintln(w.out, "The passphrase encrypts the scanner key. Please do not lose it.")
+	for {
+		passphrase, err := w.ask(fmt.Sprintf("Passphrase (alphanumeric, at least %d characters)", minPassphraseLength), "")
+		if err != nil {
+			return "", err
+		}
+		if !isValidPassphrase(passphrase) || len(passphrase) < minPassphraseLength {
+			fmt.Fprintf(w.out, "Please enter an alphanumeric passphrase (a-z, A-Z, 0-9) with at least %d characters.\n", minPassphraseLength)
+			continue
+		}
+		confirmed, err := w.ask("Confirm the passphrase", "")
+		if err != nil {
+			return "", err
+		}
+		if confirmed != passphrase {
+			fmt.Fprintln(w.out, "The passphrases do not match.")
+			continue
+		}
+		return passphrase, nil
+	}
+}
+
+func getRPCChainID(ctx context.Context, url string) (uint64, error) {
+	client, err := ethclient.DialContext(ctx, url)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return chainID.Uint64(), nil
+}
+
+// makeWizardConfig renders the config file from the answers and validates it.
+func makeWizardConfig(answers *initAnswers, envDefaults config.EnvDefaults) ([]byte, error) {
+	tmpl, err := template.New("wizard-config-template").Parse(wizardConfig)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		*initAnswers
+		config.EnvDefaults
+		LocalMode bool
+	}{
+		initAnswers: answers,
+		EnvDefaults: envDefaults,
+		LocalMode:   answers.Mode == initModeLocal,
+	}); err != nil {
+		return nil, err
+	}
+
+	var generated config.Config
+	if err := yaml.Unmarshal(buf.Bytes(), &generated); err != nil {
+		return nil, fmt.Errorf("generated an invalid config: %v", err)
+	}
+	if err := defaults.Set(&generated); err != nil {
+		return nil, err
+	}
+	if err := validateConfigValues(&generated); err != nil {
+		return nil, errors.New("generated an invalid config")
+	}
+	return buf.Bytes(), nil
+}
+
+const wizardConfig = `# Auto generated by 'forta init --interactive' - safe to modify
+# The version of the config schema - see 'forta config migrate'
+version: 1
+
+# The chainId is the chainId of the network that is analyzed (1=mainnet)
+chainId: {{ .ChainID }}
+
+# The scan settings are used to retrieve the transactions that are analyzed
+scan:
+  jsonRpc:
+    url: {{ printf "%q" .ScanURL }}
+{{ if .TraceURL }}
+# The trace endpoint must support trace_block (such as alchemy)
+trace:
+  enabled: true
+  jsonRpc:
+    url: {{ printf "%q" .TraceURL }}
+{{ else }}
+# Tracing is disabled - the trace endpoint must support trace_block (such as alchemy)
+trace:
+  enabled: false
+{{ end }}{{ if .LocalMode }}
+# The local mode runs the listed bots without the registry
+localMode:
+  enable: true
+  botImages:{{ range .BotImages }}
+    - {{ printf "%q" . }}{{ else }} []{{ end }}
+{{- if .WebhookURL }}
+  webhookUrl: {{ printf "%q" .WebhookURL }}
+{{- end }}
+{{ else }}
+# The registry settings are used to discover and load agents
+registry:
+  jsonRpc:
+    url: {{ printf "%q" .RegistryURL }}
+  containerRegistry: {{ .DiscoSubdomain }}.forta.network
+{{ end }}
+# The log settings drive the log output of the scan node
+# log:
+#  level: info
+#  maxLogSize: 50m
+#  maxLogFiles: 10
+`
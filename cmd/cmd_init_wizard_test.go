@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func testInitWizard(input string, askPassphrase bool) (*initWizard, *bytes.Buffer) {
+	var out bytes.Buffer
+	w := newInitWizard(strings.NewReader(input), &out, askPassphrase)
+	w.getChainID = func(ctx context.Context, url string) (uint64, error) {
+		switch url {
+		case "https://mainnet.example.com", "https://trace.example.com":
+			return 1, nil
+		case "https://polygon.example.com":
+			return 137, nil
+		}
+		return 0, errors.New("connection refused")
+	}
+	return w, &out
+}
+
+func TestInitWizard_Registry(t *testing.T) {
+	r := require.New(t)
+
+	w, out := testInitWizard(strings.Join([]string{
+		"",                            // default chain ID
+		"https://down.example.com",    // unreachable
+		"https://polygon.example.com", // wrong chain
+		"https://mainnet.example.com",
+		"https://trace.example.com",
+		"remote", // invalid mode
+		"",       // default mode
+		"https://polygon.example.com",
+		"short",
+		"Passphrase12345",
+		"Passphrase12345",
+	}, "\n")+"\n", true)
+
+	answers, err := w.Run()
+	r.NoError(err)
+	r.Equal(&initAnswers{
+		ChainID:     1,
+		ScanURL:     "https://mainnet.example.com",
+		TraceURL:    "https://trace.example.com",
+		Mode:        initModeRegistry,
+		RegistryURL: "https://polygon.example.com",
+		Passphrase:  "Passphrase12345",
+	}, answers)
+	r.Contains(out.String(), "Failed to get the chain ID from https://down.example.com")
+	r.Contains(out.String(), "The chain ID of https://polygon.example.com is 137 but expected 1.")
+	r.Contains(out.String(), "Please choose one of: registry, local")
+	r.Contains(out.String(), "Please enter an alphanumeric passphrase")
+
+	b, err := makeWizardConfig(answers, config.GetEnvDefaults(false))
+	r.NoError(err)
+	var generated config.Config
+	r.NoError(yaml.Unmarshal(b, &generated))
+	r.Equal(1, generated.ChainID)
+	r.Equal("https://mainnet.example.com", generated.Scan.JsonRpc.Url)
+	r.True(generated.Trace.Enabled)
+	r.Equal("https://trace.example.com", generated.Trace.JsonRpc.Url)
+	r.Equal("https://polygon.example.com", generated.Registry.JsonRpc.Url)
+	r.False(generated.LocalModeConfig.Enable)
+}
+
+func TestInitWizard_Local(t *testing.T) {
+	r := require.New(t)
+
+	w, _ := testInitWizard(strings.Join([]string{
+		"1",
+		"https://mainnet.example.com",
+		"", // no tracing
+		"local",
+		"bot-image-1, bot-image-2",
+		"https://webhook.example.com",
+	}, "\n"), false)
+
+	answers, err := w.Run()
+	r.NoError(err)
+	r.Equal(initModeLocal, answers.Mode)
+	r.Empty(answers.TraceURL)
+	r.Equal([]string{"bot-image-1", "bot-image-2"}, answers.BotImages)
+	r.Empty(answers.Passphrase)
+
+	b, err := makeWizardConfig(answers, config.GetEnvDefaults(false))
+	r.NoError(err)
+	var generated config.Config
+	r.NoError(yaml.Unmarshal(b, &generated))
+	r.False(generated.Trace.Enabled)
+	r.True(generated.LocalModeConfig.Enable)
+	r.Equal([]string{"bot-image-1", "bot-image-2"}, generated.LocalModeConfig.BotImages)
+	r.Equal("https://webhook.example.com", generated.LocalModeConfig.WebhookURL)
+}
+
+func TestInitWizard_EndOfInput(t *testing.T) {
+	r := require.New(t)
+
+	w, _ := testInitWizard("1\n", true)
+	_, err := w.Run()
+	r.Error(err)
+}
@@ -0,0 +1,337 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/forta-network/forta-core-go/inspect"
+	"github.com/forta-network/forta-core-go/inspect/scorecalc"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/config"
+	"github.com/spf13/cobra"
+)
+
+// inspection check statuses
+const (
+	inspectionPass    = "PASS"
+	inspectionFail    = "FAIL"
+	inspectionUnknown = "UNKNOWN"
+	inspectionInfo    = "INFO"
+)
+
+const (
+	inspectionDoneMsg                = "inspection done"
+	preRegistrationInspectionTimeout = time.Minute * 2
+)
+
+var errInspectionNotFound = errors.New("inspection result not found")
+
+// inspectionHints are the remediation hints for the failing indicators.
+var inspectionHints = map[string]string{
+	inspect.IndicatorNetworkOutboundAccess:  "allow outbound internet access from the node host",
+	inspect.IndicatorScanAPIAccessible:      "check scan.jsonRpc.url and make sure the node can reach it",
+	inspect.IndicatorScanAPIChainID:         "scan.jsonRpc.url should point to an API of chain %d",
+	inspect.IndicatorScanAPIModuleEth:       "enable the eth module of the scan API",
+	inspect.IndicatorScanAPIModuleNet:       "enable the net module of the scan API",
+	inspect.IndicatorScanAPIIsETH2:          "the scan API should be upgraded to Ethereum 2.0",
+	inspect.IndicatorProxyAPIAccessible:     "check jsonRpcProxy.jsonRpc.url (or scan.jsonRpc.url) and make sure the node can reach it",
+	inspect.IndicatorProxyAPIChainID:        "jsonRpcProxy.jsonRpc.url should point to an API of chain %d",
+	inspect.IndicatorProxyAPIModuleWeb3:     "enable the web3 module of the proxy API",
+	inspect.IndicatorProxyAPIModuleEth:      "enable the eth module of the proxy API",
+	inspect.IndicatorProxyAPIModuleNet:      "enable the net module of the proxy API",
+	inspect.IndicatorProxyAPIHistorySupport: "use a proxy API which can serve the historical blocks (e.g. an archive node)",
+	inspect.IndicatorProxyAPIIsETH2:         "the proxy API should be upgraded to Ethereum 2.0",
+	inspect.IndicatorTraceAccessible:        "check trace.jsonRpc.url and make sure the node can reach it",
+	inspect.IndicatorTraceSupported:         "use a trace API which supports trace_block or disable trace if it is not required for the chain",
+	inspect.IndicatorTraceAPIChainID:        "trace.jsonRpc.url should point to an API of chain %d",
+	inspect.IndicatorTraceAPIIsETH2:         "the trace API should be upgraded to Ethereum 2.0",
+	inspect.IndicatorRegistryAPIAccessible:  "check registry.jsonRpc.url and make sure the node can reach it",
+	inspect.IndicatorRegistryAPIENS:         "registry.jsonRpc.url should point to a Polygon API which can resolve the Forta contracts",
+	inspect.IndicatorRegistryAPIAssignments: "registry.jsonRpc.url should point to a Polygon API which can read the bot assignments",
+	inspect.IndicatorValidAPIReferences:     "the scan, proxy and trace APIs should return the same blocks - make sure they are the same chain and in sync",
+	inspect.IndicatorResourcesMemoryTotal:   "the node host should have at least %s of memory",
+}
+
+// inspectionCheck is a row of the inspection report.
+type inspectionCheck struct {
+	Indicator string
+	Status    string
+	Value     string
+	Hint      string
+}
+
+// inspectionLogLine is the log line of the inspector which contains the results.
+type inspectionLogLine struct {
+	Msg               string `json:"msg"`
+	Results           string `json:"results"`
+	InspectingAtBlock uint64 `json:"inspectingAtBlock"`
+}
+
+func handleFortaInspect(cmd *cobra.Command, args []string) error {
+	preRegistration, err := cmd.Flags().GetBool("pre-registration")
+	if err != nil {
+		return err
+	}
+	if !preRegistration {
+		return cmd.Help()
+	}
+	var scannerAddress string
+	ks := keystore.NewKeyStore(cfg.KeyDirPath, keystore.StandardScryptN, keystore.StandardScryptP)
+	if accounts := ks.Accounts(); len(accounts) == 1 {
+		scannerAddress = accounts[0].Address.Hex()
+	}
+	if err := runPreRegistrationInspection(cmd, scannerAddress, false); err != nil {
+		return err
+	}
+	greenBold("\nThe node is ready to be registered.\n")
+	return nil
+}
+
+// runPreRegistrationInspection inspects the configured APIs and the resources from this host and returns
+// an error if the node would fail the inspection right after the registration. The report is printed
+// only if the inspection fails when quiet.
+func runPreRegistrationInspection(cmd *cobra.Command, scannerAddress string, quiet bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), preRegistrationInspectionTimeout)
+	defer cancel()
+
+	proxyAPIURL := cfg.JsonRpcProxy.JsonRpc.Url
+	if len(proxyAPIURL) == 0 {
+		proxyAPIURL = cfg.Scan.JsonRpc.Url
+	}
+	toStderr("Inspecting the node before the registration...\n")
+	// the inspection results are useful even if there are errors
+	results, _ := inspect.Inspect(ctx, inspect.InspectionConfig{
+		ScanAPIURL:         cfg.Scan.JsonRpc.Url,
+		ProxyAPIURL:        proxyAPIURL,
+		TraceAPIURL:        cfg.Trace.JsonRpc.Url,
+		BlockNumber:        getLatestBlockNumber(ctx, cfg.Scan.JsonRpc.Url),
+		CheckTrace:         cfg.Trace.Enabled,
+		RegistryAPIURL:     cfg.Registry.JsonRpc.Url,
+		ENSContractAddress: cfg.ENSConfig.ContractAddress,
+		ScannerAddress:     scannerAddress,
+	})
+	if results == nil {
+		return errors.New("failed to inspect the node")
+	}
+
+	err := checkPreRegistrationResults(results, cfg.ChainID)
+	if err != nil || !quiet {
+		printInspectionReport(cmd, results, cfg.ChainID)
+	}
+	return err
+}
+
+// checkPreRegistrationResults returns an error which lists the failing checks if the inspection
+// score is zero.
+func checkPreRegistrationResults(results *inspect.InspectionResults, chainID int) error {
+	score, err := scorecalc.NewScoreCalculator([]scorecalc.ScoreCalculatorConfig{{ChainID: uint64(chainID)}}).CalculateScore(uint64(chainID), results)
+	if err != nil {
+		return fmt.Errorf("failed to calculate the inspection score: %v", err)
+	}
+	if score > 0 {
+		return nil
+	}
+	var failing []string
+	for _, check := range makeInspectionReport(results, chainID) {
+		if check.Status == inspectionFail {
+			failing = append(failing, check.Indicator)
+		}
+	}
+	return fmt.Errorf("the node would fail the inspection - please fix the failing checks before registering: %s", strings.Join(failing, ", "))
+}
+
+// getLatestBlockNumber returns the latest block number or zero if the API is failing.
+func getLatestBlockNumber(ctx context.Context, apiURL string) uint64 {
+	client, err := ethclient.DialContext(ctx, apiURL)
+	if err != nil {
+		return 0
+	}
+	defer client.Close()
+	blockNumber, err := client.BlockNumber(ctx)
+	if err != nil {
+		return 0
+	}
+	return blockNumber
+}
+
+func handleFortaInspectReport(cmd *cobra.Command, args []string) error {
+	file, err := cmd.Flags().GetString("file")
+	if err != nil {
+		return err
+	}
+	blockNumber, err := cmd.Flags().GetUint64("block")
+	if err != nil {
+		return err
+	}
+
+	var results *inspect.InspectionResults
+	if len(file) > 0 {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read the inspection file: %v", err)
+		}
+		results, err = findInspectionResults(string(b), blockNumber)
+		if err != nil {
+			return err
+		}
+	} else {
+		dockerClient, err := clients.NewDockerClient("")
+		if err != nil {
+			return fmt.Errorf("failed to create the docker client: %v", err)
+		}
+		inspector, err := dockerClient.GetContainerByName(context.Background(), config.DockerInspectorContainerName)
+		if err != nil {
+			return fmt.Errorf("failed to find the inspector - is the node running? (%v)", err)
+		}
+		logs, err := dockerClient.GetContainerLogs(context.Background(), inspector.ID, "all", -1)
+		if err != nil {
+			return fmt.Errorf("failed to get the inspector logs: %v", err)
+		}
+		results, err = findInspectionResults(logs, blockNumber)
+		if err != nil {
+			return err
+		}
+	}
+
+	printInspectionReport(cmd, results, cfg.ChainID)
+	return nil
+}
+
+// findInspectionResults finds the latest inspection results in the inspector logs or the inspection
+// at the specified block. A file which contains only the inspection results JSON is accepted too.
+func findInspectionResults(logs string, blockNumber uint64) (*inspect.InspectionResults, error) {
+	var results inspect.InspectionResults
+	if err := json.Unmarshal([]byte(logs), &results); err == nil && results.Indicators != nil {
+		return &results, nil
+	}
+
+	lines := strings.Split(logs, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		start := strings.Index(lines[i], "{")
+		if start < 0 {
+			continue
+		}
+		var logLine inspectionLogLine
+		if err := json.Unmarshal([]byte(lines[i][start:]), &logLine); err != nil || logLine.Msg != inspectionDoneMsg {
+			continue
+		}
+		if blockNumber > 0 && logLine.InspectingAtBlock != blockNumber {
+			continue
+		}
+		var results inspect.InspectionResults
+		if err := json.Unmarshal([]byte(logLine.Results), &results); err != nil {
+			return nil, fmt.Errorf("failed to decode the inspection results: %v", err)
+		}
+		return &results, nil
+	}
+	if blockNumber > 0 {
+		return nil, fmt.Errorf("%w at block %d", errInspectionNotFound, blockNumber)
+	}
+	return nil, errInspectionNotFound
+}
+
+// makeInspectionReport evaluates each indicator and adds the remediation hints to the failing ones.
+func makeInspectionReport(results *inspect.InspectionResults, chainID int) []*inspectionCheck {
+	var checks []*inspectionCheck
+	for indicator, value := range results.Indicators {
+		check := &inspectionCheck{
+			Indicator: indicator,
+			Status:    inspectionInfo,
+			Value:     formatIndicatorValue(indicator, value),
+		}
+		switch {
+		case value == inspect.ResultUnknown:
+			check.Status = inspectionUnknown
+		case value == inspect.ResultFailure || value == inspect.ResultInternalProblem:
+			check.Status = inspectionFail
+		case strings.HasSuffix(indicator, ".chain-id"):
+			check.Status = inspectionPass
+			if value != float64(chainID) {
+				check.Status = inspectionFail
+			}
+		case indicator == inspect.IndicatorResourcesMemoryTotal:
+			check.Status = inspectionPass
+			if value < scorecalc.DefaultMinTotalMemory {
+				check.Status = inspectionFail
+			}
+		case value == inspect.ResultSuccess:
+			if _, ok := inspectionHints[indicator]; ok {
+				check.Status = inspectionPass
+			}
+		}
+		if check.Status == inspectionFail {
+			check.Hint = inspectionHint(indicator, chainID)
+		}
+		checks = append(checks, check)
+	}
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].Indicator < checks[j].Indicator
+	})
+	return checks
+}
+
+func inspectionHint(indicator string, chainID int) string {
+	hint, ok := inspectionHints[indicator]
+	switch {
+	case !ok:
+		return ""
+	case indicator == inspect.IndicatorResourcesMemoryTotal:
+		return fmt.Sprintf(hint, formatBytes(scorecalc.DefaultMinTotalMemory))
+	case strings.Contains(hint, "%d"):
+		return fmt.Sprintf(hint, chainID)
+	}
+	return hint
+}
+
+func formatIndicatorValue(indicator string, value float64) string {
+	switch {
+	case value == inspect.ResultSuccess && !strings.HasSuffix(indicator, ".chain-id"):
+		return "yes"
+	case value == inspect.ResultFailure:
+		return "no"
+	case value == inspect.ResultInternalProblem:
+		return "internal error"
+	case value == inspect.ResultUnknown:
+		return "-"
+	case strings.HasPrefix(indicator, "resources.memory") || strings.HasPrefix(indicator, "resources.storage"):
+		return formatBytes(value)
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func formatBytes(value float64) string {
+	const gb = 1e9
+	return fmt.Sprintf("%.1f GB", math.Round(value/gb*10)/10)
+}
+
+func printInspectionReport(cmd *cobra.Command, results *inspect.InspectionResults, chainID int) {
+	whiteBold("Inspection at block %d\n", results.Inputs.BlockNumber)
+	score, err := scorecalc.NewScoreCalculator([]scorecalc.ScoreCalculatorConfig{{ChainID: uint64(chainID)}}).CalculateScore(uint64(chainID), results)
+	switch {
+	case err != nil:
+		yellowBold("Failed to calculate the score: %v\n", err)
+	case score > 0:
+		greenBold("Expected score: %s\n", strconv.FormatFloat(score, 'f', -1, 64))
+	default:
+		redBold("Expected score: %s\n", strconv.FormatFloat(score, 'f', -1, 64))
+	}
+	cmd.Println()
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tINDICATOR\tVALUE\tHINT")
+	for _, check := range makeInspectionReport(results, chainID) {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", check.Status, check.Indicator, check.Value, check.Hint)
+	}
+	w.Flush()
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/inspect"
+	"github.com/stretchr/testify/require"
+)
+
+func testInspectionLogLine(t *testing.T, blockNumber uint64, results *inspect.InspectionResults) string {
+	b, err := json.Marshal(results)
+	require.NoError(t, err)
+	line, err := json.Marshal(&inspectionLogLine{
+		Msg:               inspectionDoneMsg,
+		Results:           string(b),
+		InspectingAtBlock: blockNumber,
+	})
+	require.NoError(t, err)
+	return fmt.Sprintf("2023-03-20T10:00:00.000000000Z %s", line)
+}
+
+func TestFindInspectionResults(t *testing.T) {
+	r := require.New(t)
+
+	logs := strings.Join([]string{
+		testInspectionLogLine(t, 100, &inspect.InspectionResults{Indicators: map[string]float64{"a": 1}}),
+		`2023-03-20T10:00:01.000000000Z {"level":"info","msg":"triggering inspection"}`,
+		testInspectionLogLine(t, 200, &inspect.InspectionResults{Indicators: map[string]float64{"b": 1}}),
+		"",
+	}, "\n")
+
+	results, err := findInspectionResults(logs, 0)
+	r.NoError(err)
+	r.Contains(results.Indicators, "b")
+
+	results, err = findInspectionResults(logs, 100)
+	r.NoError(err)
+	r.Contains(results.Indicators, "a")
+
+	_, err = findInspectionResults(logs, 300)
+	r.ErrorIs(err, errInspectionNotFound)
+
+	results, err = findInspectionResults(`{"indicators":{"c":1}}`, 0)
+	r.NoError(err)
+	r.Contains(results.Indicators, "c")
+}
+
+func TestMakeInspectionReport(t *testing.T) {
+	r := require.New(t)
+
+	checks := makeInspectionReport(&inspect.InspectionResults{
+		Indicators: map[string]float64{
+			inspect.IndicatorScanAPIAccessible:    inspect.ResultSuccess,
+			inspect.IndicatorScanAPIChainID:       137,
+			inspect.IndicatorTraceSupported:       inspect.ResultUnknown,
+			inspect.IndicatorResourcesMemoryTotal: 4e9,
+			inspect.IndicatorNetworkDownloadSpeed: 120.5,
+		},
+	}, 1)
+
+	checksByName := make(map[string]*inspectionCheck)
+	for _, check := range checks {
+		checksByName[check.Indicator] = check
+	}
+	r.Len(checksByName, 5)
+
+	r.Equal(inspectionPass, checksByName[inspect.IndicatorScanAPIAccessible].Status)
+	r.Equal("yes", checksByName[inspect.IndicatorScanAPIAccessible].Value)
+
+	r.Equal(inspectionFail, checksByName[inspect.IndicatorScanAPIChainID].Status)
+	r.Equal("137", checksByName[inspect.IndicatorScanAPIChainID].Value)
+	r.Contains(checksByName[inspect.IndicatorScanAPIChainID].Hint, "chain 1")
+
+	r.Equal(inspectionUnknown, checksByName[inspect.IndicatorTraceSupported].Status)
+
+	r.Equal(inspectionFail, checksByName[inspect.IndicatorResourcesMemoryTotal].Status)
+	r.Equal("4.0 GB", checksByName[inspect.IndicatorResourcesMemoryTotal].Value)
+	r.Contains(checksByName[inspect.IndicatorResourcesMemoryTotal].Hint, "8.0 GB")
+
+	r.Equal(inspectionInfo, checksByName[inspect.IndicatorNetworkDownloadSpeed].Status)
+	r.Equal("120.5", checksByName[inspect.IndicatorNetworkDownloadSpeed].Value)
+}
+
+func TestCheckPreRegistrationResults(t *testing.T) {
+	r := require.New(t)
+
+	results := &inspect.InspectionResults{
+		Indicators: map[string]float64{
+			inspect.IndicatorScanAPIAccessible:    inspect.ResultSuccess,
+			inspect.IndicatorScanAPIChainID:       1,
+			inspect.IndicatorProxyAPIChainID:      1,
+			inspect.IndicatorResourcesMemoryTotal: 16e9,
+		},
+	}
+	r.NoError(checkPreRegistrationResults(results, 1))
+
+	results.Indicators[inspect.IndicatorProxyAPIChainID] = 137
+	results.Indicators[inspect.IndicatorResourcesMemoryTotal] = 4e9
+	err := checkPreRegistrationResults(results, 1)
+	r.Error(err)
+	r.Contains(err.Error(), inspect.IndicatorProxyAPIChainID)
+	r.Contains(err.Error(), inspect.IndicatorResourcesMemoryTotal)
+	r.NotContains(err.Error(), inspect.IndicatorScanAPIChainID)
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+const defaultServiceUnitPath = "/etc/systemd/system/forta.service"
+
+var serviceUnitTemplate = template.Must(template.New("forta.service").Parse(`[Unit]
+Description=Forta scan node
+Documentation=https://docs.forta.network
+Wants=network-online.target
+After=network-online.target docker.service
+Requires=docker.service
+
+[Service]
+# the node notifies systemd when all of the services are healthy and keeps notifying the
+# watchdog while they stay healthy
+Type=notify
+NotifyAccess=main
+WatchdogSec=5min
+TimeoutStartSec=15min
+TimeoutStopSec=2min
+Restart=on-failure
+RestartSec=30s
+
+User={{.User}}
+Environment=FORTA_DIR={{.FortaDir}}
+# put FORTA_PASSPHRASE in this file and make it readable only by the node user
+EnvironmentFile=-{{.EnvFile}}
+ExecStart={{.Executable}} run
+
+# hardening: the node only needs to write to the Forta dir and to reach the Docker socket
+NoNewPrivileges=true
+PrivateTmp=true
+PrivateDevices=true
+ProtectSystem=full
+ProtectHome=read-only
+ReadWritePaths={{.FortaDir}}
+ProtectKernelTunables=true
+ProtectKernelModules=true
+ProtectKernelLogs=true
+ProtectControlGroups=true
+ProtectClock=true
+ProtectHostname=true
+RestrictSUIDSGID=true
+RestrictRealtime=true
+RestrictNamespaces=true
+LockPersonality=true
+RestrictAddressFamilies=AF_UNIX AF_INET AF_INET6
+CapabilityBoundingSet=
+LimitNOFILE=65536
+
+[Install]
+WantedBy=multi-user.target
+`))
+
+type serviceUnitArgs struct {
+	User       string
+	FortaDir   string
+	EnvFile    string
+	Executable string
+}
+
+func handleFortaInstallService(cmd *cobra.Command, args []string) error {
+	unitPath, _ := cmd.Flags().GetString("path")
+	envFile, _ := cmd.Flags().GetString("env-file")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	force, _ := cmd.Flags().GetBool("force")
+
+	unitArgs, err := getServiceUnitArgs(cmd, envFile)
+	if err != nil {
+		return err
+	}
+	unit, err := renderServiceUnit(unitArgs)
+	if err != nil {
+		return fmt.Errorf("failed to render the service unit: %v", err)
+	}
+	if dryRun {
+		cmd.Print(string(unit))
+		return nil
+	}
+
+	if _, err := os.Stat(unitPath); err == nil && !force {
+		return fmt.Errorf("%s already exists - use --force to overwrite", unitPath)
+	}
+	if err := os.WriteFile(unitPath, unit, 0644); err != nil {
+		return fmt.Errorf("failed to write the service unit: %v", err)
+	}
+	greenBold("Installed the service unit at %s\n", unitPath)
+	cmd.Printf("Put FORTA_PASSPHRASE=<passphrase> in %s (chmod 600) and then run:\n", envFile)
+	cmd.Printf("  sudo systemctl daemon-reload\n")
+	cmd.Printf("  sudo systemctl enable --now %s\n", filepath.Base(unitPath))
+	return nil
+}
+
+func getServiceUnitArgs(cmd *cobra.Command, envFile string) (*serviceUnitArgs, error) {
+	username, _ := cmd.Flags().GetString("user")
+	if len(username) == 0 {
+		// prefer the user who ran sudo instead of root
+		username = os.Getenv("SUDO_USER")
+	}
+	if len(username) == 0 {
+		currentUser, err := user.Current()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the current user: %v", err)
+		}
+		username = currentUser.Username
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the forta executable: %v", err)
+	}
+	executable, err = filepath.EvalSymlinks(executable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the forta executable path: %v", err)
+	}
+
+	fortaDir, err := filepath.Abs(cfg.FortaDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the forta dir: %v", err)
+	}
+
+	return &serviceUnitArgs{
+		User:       username,
+		FortaDir:   fortaDir,
+		EnvFile:    envFile,
+		Executable: executable,
+	}, nil
+}
+
+func renderServiceUnit(args *serviceUnitArgs) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := serviceUnitTemplate.Execute(&buf, args); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
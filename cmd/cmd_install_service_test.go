@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderServiceUnit(t *testing.T) {
+	r := require.New(t)
+
+	unit, err := renderServiceUnit(&serviceUnitArgs{
+		User:       "forta",
+		FortaDir:   "/home/forta/.forta",
+		EnvFile:    "/etc/forta/forta.env",
+		Executable: "/usr/bin/forta",
+	})
+	r.NoError(err)
+
+	lines := strings.Split(string(unit), "\n")
+	r.Contains(lines, "Type=notify")
+	r.Contains(lines, "User=forta")
+	r.Contains(lines, "Environment=FORTA_DIR=/home/forta/.forta")
+	r.Contains(lines, "EnvironmentFile=-/etc/forta/forta.env")
+	r.Contains(lines, "ExecStart=/usr/bin/forta run")
+	r.Contains(lines, "ReadWritePaths=/home/forta/.forta")
+}
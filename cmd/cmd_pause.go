@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services"
+	"github.com/spf13/cobra"
+)
+
+const (
+	pauseReportTimeout      = time.Second * 30
+	pauseReportPollInterval = time.Millisecond * 500
+)
+
+func handleFortaPause(cmd *cobra.Command, args []string) error {
+	return setNodePaused(cmd, true)
+}
+
+func handleFortaResume(cmd *cobra.Command, args []string) error {
+	return setNodePaused(cmd, false)
+}
+
+// setNodePaused marks the node as paused or resumed in the Forta dir, so that the state is kept
+// after restarts, and signals the running node to apply it.
+func setNodePaused(cmd *cobra.Command, paused bool) error {
+	if err := config.SetPaused(cfg.FortaDir, paused); err != nil {
+		return fmt.Errorf("failed to update the pause state: %v", err)
+	}
+	action := "resume"
+	if paused {
+		action = "pause"
+	}
+	// the node stays paused while the inspection mark is there
+	reportedPaused := paused
+	if !paused && config.IsPausedByInspection(cfg.FortaDir) {
+		yellowBold("The node is also paused by the inspection actions until the failed indicators recover.\n")
+		reportedPaused = true
+	}
+
+	running, err := sendPauseStateSignal()
+	if err != nil {
+		return fmt.Errorf("failed to send the %s signal: %v", action, err)
+	}
+	if !running {
+		yellowBold("The node is not running - it will %s when started.\n", action)
+		return nil
+	}
+	cmd.PrintErrf("Sent the %s signal. Waiting for the report...\n", action)
+
+	reports, applied := waitForReports(getNodeReports, func(reports health.Reports) bool {
+		return isPauseStateApplied(reports, reportedPaused)
+	}, pauseReportTimeout)
+	for _, report := range reports {
+		if strings.HasSuffix(report.Name, ".paused") {
+			cmd.Printf("%s: %s\n", report.Name, report.Details)
+		}
+	}
+	if !applied {
+		yellowBold("The pause state is not reported yet - please check 'forta status --show all' later.\n")
+		return nil
+	}
+	if paused {
+		greenBold("Paused the node. The containers are kept running - use 'forta resume' to continue scanning.\n")
+	} else {
+		greenBold("Resumed the node.\n")
+	}
+	return nil
+}
+
+// isPauseStateApplied tells if all of the services report the expected pause state.
+func isPauseStateApplied(reports health.Reports, paused bool) bool {
+	var found bool
+	for _, report := range reports {
+		if !strings.HasSuffix(report.Name, ".paused") {
+			continue
+		}
+		if report.Details != fmt.Sprintf("%t", paused) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+func getNodeReports() health.Reports {
+	return health.NewClient().CheckHealth("forta", config.DefaultHealthPort)
+}
+
+// waitForReports polls the node reports until they are applied or the timeout expires,
+// and returns the latest reports.
+func waitForReports(getReports func() health.Reports, isApplied func(health.Reports) bool, timeout time.Duration) (health.Reports, bool) {
+	deadline := time.Now().Add(timeout)
+	for {
+		reports := getReports()
+		if isApplied(reports) {
+			return reports, true
+		}
+		if time.Now().Add(pauseReportPollInterval).After(deadline) {
+			return reports, false
+		}
+		time.Sleep(pauseReportPollInterval)
+	}
+}
+
+// sendPauseStateSignal makes the running node check the pause state and the disabled bots
+// in the Forta dir again. It tells if the node is running.
+func sendPauseStateSignal() (bool, error) {
+	dockerClient, err := clients.NewDockerClient("")
+	if err != nil {
+		return false, fmt.Errorf("failed to create the docker client: %v", err)
+	}
+	supervisor, err := dockerClient.GetContainerByName(context.Background(), config.DockerSupervisorContainerName)
+	if errors.Is(err, clients.ErrContainerNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to find the supervisor: %v", err)
+	}
+	if err := dockerClient.SignalContainer(context.Background(), supervisor.ID, services.PauseStateSignalName); err != nil {
+		return false, err
+	}
+	return true, nil
+}
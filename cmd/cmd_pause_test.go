@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPauseStateApplied(t *testing.T) {
+	r := require.New(t)
+
+	reports := health.Reports{
+		{Name: "scanner.paused", Details: "true"},
+		{Name: "publisher.paused", Details: "false"},
+	}
+	r.False(isPauseStateApplied(reports, true))
+	r.False(isPauseStateApplied(reports, false))
+
+	reports[1].Details = "true"
+	r.True(isPauseStateApplied(reports, true))
+
+	// the state is not applied until the services report it
+	r.False(isPauseStateApplied(nil, false))
+}
+
+func TestWaitForReports(t *testing.T) {
+	r := require.New(t)
+
+	var polls int
+	getReports := func() health.Reports {
+		polls++
+		if polls < 3 {
+			return health.Reports{{Name: "scanner.paused", Details: "false"}}
+		}
+		return health.Reports{{Name: "scanner.paused", Details: "true"}}
+	}
+	isApplied := func(reports health.Reports) bool {
+		return isPauseStateApplied(reports, true)
+	}
+
+	// should poll until the reports are applied
+	reports, applied := waitForReports(getReports, isApplied, time.Minute)
+	r.True(applied)
+	r.Equal(3, polls)
+	r.Equal("true", reports[0].Details)
+
+	// should give up after the timeout
+	polls = 0
+	_, applied = waitForReports(getReports, func(health.Reports) bool { return false }, time.Second)
+	r.False(applied)
+	r.Equal(2, polls)
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/config"
+	"github.com/spf13/cobra"
+)
+
+const reloadReportWait = time.Second * 3
+
+func handleFortaReload(cmd *cobra.Command, args []string) error {
+	dockerClient, err := clients.NewDockerClient("")
+	if err != nil {
+		return fmt.Errorf("failed to create the docker client: %v", err)
+	}
+	supervisor, err := dockerClient.GetContainerByName(context.Background(), config.DockerSupervisorContainerName)
+	if err != nil {
+		return fmt.Errorf("failed to find the supervisor - is the node running? (%v)", err)
+	}
+	if err := dockerClient.ReloadContainer(context.Background(), supervisor.ID); err != nil {
+		return fmt.Errorf("failed to send the reload signal: %v", err)
+	}
+	cmd.PrintErrln("Sent the reload signal. Waiting for the report...")
+	time.Sleep(reloadReportWait)
+
+	var found bool
+	for _, report := range health.NewClient().CheckHealth("forta", config.DefaultHealthPort) {
+		if !strings.Contains(report.Name, "config-reload") {
+			continue
+		}
+		found = true
+		cmd.Printf("%s: %s\n", report.Name, report.Details)
+	}
+	if !found {
+		yellowBold("The reload report is not available yet - please check 'forta status --show all' later.\n")
+		return nil
+	}
+	greenBold("Reloaded the config. The fields which require a restart are applied after 'forta run' is restarted.\n")
+	return nil
+}
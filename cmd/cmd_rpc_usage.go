@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	jrp "github.com/forta-network/forta-node/services/json-rpc"
+	"github.com/spf13/cobra"
+)
+
+func handleFortaRPCUsage(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	botID, _ := cmd.Flags().GetString("bot")
+	hours, _ := cmd.Flags().GetInt("hours")
+
+	// the proxy saves the usage every minute
+	list, err := jrp.LoadUsage(path.Join(cfg.FortaDir, config.DefaultRPCUsageFileName))
+	if err != nil {
+		return fmt.Errorf("failed to load the rpc usage: %v", err)
+	}
+	list = filterRPCUsage(list, botID, hours, time.Now())
+
+	switch format {
+	case "csv":
+		return writeRPCUsageCSV(os.Stdout, list)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+func filterRPCUsage(list []*jrp.BotUsage, botID string, hours int, now time.Time) []*jrp.BotUsage {
+	filtered := []*jrp.BotUsage{}
+	minHour := now.UTC().Truncate(time.Hour).Add(-time.Duration(hours-1) * time.Hour)
+	for _, botUsage := range list {
+		if len(botID) > 0 && !strings.EqualFold(botUsage.BotID, botID) {
+			continue
+		}
+		if hours > 0 && botUsage.Hour.Before(minHour) {
+			continue
+		}
+		filtered = append(filtered, botUsage)
+	}
+	jrp.SortUsage(filtered)
+	return filtered
+}
+
+func writeRPCUsageCSV(w io.Writer, list []*jrp.BotUsage) error {
+	csvWriter := csv.NewWriter(w)
+	defer csvWriter.Flush()
+
+	if err := csvWriter.Write([]string{"hour", "botId", "requests", "computeUnits"}); err != nil {
+		return fmt.Errorf("failed to write csv record: %v", err)
+	}
+	for _, botUsage := range list {
+		if err := csvWriter.Write([]string{
+			botUsage.Hour.UTC().Format(time.RFC3339),
+			botUsage.BotID,
+			strconv.FormatUint(botUsage.Requests, 10),
+			strconv.FormatUint(botUsage.ComputeUnits, 10),
+		}); err != nil {
+			return fmt.Errorf("failed to write csv record: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	jrp "github.com/forta-network/forta-node/services/json-rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterRPCUsage(t *testing.T) {
+	r := require.New(t)
+
+	now := time.Date(2023, 3, 20, 10, 30, 0, 0, time.UTC)
+	list := []*jrp.BotUsage{
+		{Hour: now.Truncate(time.Hour), BotID: "0x02", Requests: 3, ComputeUnits: 30},
+		{Hour: now.Truncate(time.Hour).Add(-time.Hour), BotID: "0x01", Requests: 1, ComputeUnits: 5},
+		{Hour: now.Truncate(time.Hour).Add(-time.Hour * 2), BotID: "0x01", Requests: 2, ComputeUnits: 2},
+	}
+
+	r.Len(filterRPCUsage(list, "", 0, now), 3)
+	r.Len(filterRPCUsage(list, "0X01", 0, now), 2)
+	filtered := filterRPCUsage(list, "", 2, now)
+	r.Len(filtered, 2)
+	r.Equal("0x01", filtered[0].BotID)
+
+	w := new(bytes.Buffer)
+	r.NoError(writeRPCUsageCSV(w, filtered))
+	r.Equal(
+		"hour,botId,requests,computeUnits\n"+
+			"2023-03-20T09:00:00Z,0x01,1,5\n"+
+			"2023-03-20T10:00:00Z,0x02,3,30\n",
+		w.String(),
+	)
+}
@@ -1,13 +1,20 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
 
 	"github.com/forta-network/forta-core-go/registry"
 	"github.com/forta-network/forta-core-go/security"
 	"github.com/forta-network/forta-node/cmd/runner"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services/scanner"
 	"github.com/forta-network/forta-node/store"
 	"github.com/spf13/cobra"
 )
@@ -18,9 +25,31 @@ var (
 )
 
 func handleFortaRun(cmd *cobra.Command, args []string) error {
+	if len(parsedArgs.ReleaseChannel) > 0 {
+		switch parsedArgs.ReleaseChannel {
+		case config.ReleaseChannelStable, config.ReleaseChannelRC, config.ReleaseChannelCanary:
+			cfg.AutoUpdate.ReleaseChannel = parsedArgs.ReleaseChannel
+			cfg.AutoUpdate.TrackPrereleases = false
+		default:
+			return fmt.Errorf("invalid release channel: %s", parsedArgs.ReleaseChannel)
+		}
+	}
+	if len(cfg.Scan.StartFrom) > 0 {
+		if _, err := scanner.ParseStartFrom(cfg.Scan.StartFrom); err != nil {
+			return fmt.Errorf("invalid scan.startFrom: %v", err)
+		}
+	}
 	if err := checkScannerState(); err != nil {
 		return err
 	}
+	if len(parsedArgs.StartFrom) > 0 {
+		if err := requestStartFrom(os.Stdin, os.Stdout, parsedArgs.StartFrom, parsedArgs.Yes); err != nil {
+			return err
+		}
+	}
+	if cfg.AutoUpdate.GetReleaseChannel() != config.ReleaseChannelStable {
+		yellowBold("Auto-updating from the %s release channel\n", cfg.AutoUpdate.GetReleaseChannel())
+	}
 	if cfg.LocalModeConfig.Enable {
 		whiteBold("Running in local mode...\n")
 		if len(cfg.LocalModeConfig.WebhookURL) > 0 {
@@ -33,6 +62,47 @@ func handleFortaRun(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// requestStartFrom saves the start-from override for the scanner after confirming that the evaluation
+// checkpoints can be discarded, since the blocks between the checkpoints and the start are not scanned.
+func requestStartFrom(in io.Reader, out io.Writer, value string, yes bool) error {
+	sf, err := scanner.ParseStartFrom(value)
+	if err != nil {
+		return err
+	}
+	checkpoints, err := scanner.LoadEvalCheckpoints(path.Join(cfg.StateDir(), config.DefaultEvalCheckpointFileName))
+	if err != nil {
+		return fmt.Errorf("failed to load the evaluation checkpoints: %v", err)
+	}
+	if len(checkpoints) > 0 && !yes {
+		oldest := checkpoints[0]
+		for _, checkpoint := range checkpoints {
+			if checkpoint.BlockNumber < oldest.BlockNumber {
+				oldest = checkpoint
+			}
+		}
+		fmt.Fprintf(out, "The evaluation checkpoints of %d bot(s) (oldest: block %d) will be discarded and the scanning will start from %s.\n",
+			len(checkpoints), oldest.BlockNumber, sf)
+		fmt.Fprint(out, "Continue? [y/N]: ")
+		answer, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && (err != io.EOF || len(answer) == 0) {
+			return fmt.Errorf("failed to read the answer (use --yes to skip the confirmation): %v", err)
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+		default:
+			return errors.New("aborted - the checkpoints are kept")
+		}
+	}
+	if err := os.MkdirAll(cfg.StateDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create the state dir: %v", err)
+	}
+	if err := scanner.SaveStartFrom(path.Join(cfg.StateDir(), config.DefaultStartFromFileName), sf); err != nil {
+		return fmt.Errorf("failed to save the start-from override: %v", err)
+	}
+	yellowBold("Scanning will start from %s\n", sf)
+	return nil
+}
+
 func checkScannerState() error {
 	scannerKey, err := security.LoadKeyWithPassphrase(cfg.KeyDirPath, cfg.Passphrase)
 	if err != nil {
@@ -58,19 +128,22 @@ func checkScannerState() error {
 	if err != nil {
 		return fmt.Errorf("failed to create registry client: %v", err)
 	}
-	scanner, err := registry.GetScanner(scannerAddressStr)
+	eligibility, err := store.CheckScannerEligibility(registry, scannerAddressStr, int64(cfg.ChainID), cfg.ScannerPool)
 	if err != nil {
 		return fmt.Errorf("failed to check scanner state: %v", err)
 	}
 
-	// treat reverts the same as non-registered
-	if scanner == nil {
+	if !eligibility.Registered {
 		yellowBold("Scanner not registered - please make sure you register first.\n")
 		toStderr("You can disable this behaviour with --no-check flag.\n")
 		return ErrCannotRunScanner
 	}
-	if !scanner.Enabled {
-		yellowBold("Warning! Your scan node is either disabled or does not meet with the minimum stake requirement. It will not receive any detection bots yet.\n")
+	problems := eligibility.Problems()
+	if len(problems) > 0 {
+		yellowBold("Warning! Your scan node will not receive any detection bots yet:\n")
+		for _, problem := range problems {
+			yellowBold("  - %s\n", problem)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services/scanner"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestStartFrom(t *testing.T) {
+	r := require.New(t)
+
+	cfg.FortaDir = t.TempDir()
+	defer func() { cfg = config.Config{} }()
+	overridePath := path.Join(cfg.StateDir(), config.DefaultStartFromFileName)
+
+	// no checkpoints to override
+	r.NoError(requestStartFrom(strings.NewReader(""), &bytes.Buffer{}, "latest", false))
+	sf, err := scanner.LoadStartFrom(overridePath)
+	r.NoError(err)
+	r.True(sf.Latest)
+
+	checkpoints := scanner.NewEvalCheckpoints(context.Background(), path.Join(cfg.StateDir(), config.DefaultEvalCheckpointFileName), time.Second)
+	checkpoints.Set("bot1", "0x64")
+	r.NoError(checkpoints.Save())
+
+	// the checkpoints are kept unless confirmed
+	var out bytes.Buffer
+	r.Error(requestStartFrom(strings.NewReader("n\n"), &out, "90", false))
+	r.Contains(out.String(), "oldest: block 100")
+	sf, err = scanner.LoadStartFrom(overridePath)
+	r.NoError(err)
+	r.True(sf.Latest)
+
+	r.NoError(requestStartFrom(strings.NewReader("y\n"), &bytes.Buffer{}, "90", false))
+	sf, err = scanner.LoadStartFrom(overridePath)
+	r.NoError(err)
+	r.Equal(uint64(90), *sf.Block)
+
+	r.NoError(requestStartFrom(strings.NewReader(""), &bytes.Buffer{}, "80", true))
+	sf, err = scanner.LoadStartFrom(overridePath)
+	r.NoError(err)
+	r.Equal(uint64(80), *sf.Block)
+
+	r.Error(requestStartFrom(strings.NewReader(""), &bytes.Buffer{}, "invalid", true))
+}
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/forta-network/forta-core-go/clients/health"
@@ -50,6 +52,21 @@ func handleFortaStatus(cmd *cobra.Command, args []string) error {
 		ballPrefix = ""
 	}
 
+	watch, err := cmd.Flags().GetBool("watch")
+	if err != nil {
+		return err
+	}
+	if watch {
+		interval, err := cmd.Flags().GetInt("interval")
+		if err != nil {
+			return err
+		}
+		if interval <= 0 {
+			return errors.New("interval must be positive")
+		}
+		return watchFortaStatus(time.Duration(interval) * time.Second)
+	}
+
 	// call the runner health server on localhost
 	allReports := health.NewClient().CheckHealth("forta", config.DefaultHealthPort)
 	sort.Slice(allReports, func(i, j int) bool {
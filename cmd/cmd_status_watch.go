@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/config"
+)
+
+// clearScreen moves the cursor to the top and clears the terminal so that the dashboard is refreshed in place.
+const clearScreen = "\033[H\033[2J"
+
+// statusDashboard is a snapshot of the node status which is rendered in the watch mode.
+type statusDashboard struct {
+	Time     time.Time
+	Reports  health.Reports
+	Usage    map[string]*clients.ContainerResourceUsage
+	UsageErr error
+}
+
+func watchFortaStatus(interval time.Duration) error {
+	dockerClient, err := clients.NewDockerClient("")
+	if err != nil {
+		return fmt.Errorf("failed to create the docker client: %v", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		dashboard := collectStatusDashboard(ctx, dockerClient)
+		w := new(bytes.Buffer)
+		fmt.Fprint(w, clearScreen)
+		dashboard.render(w)
+		fmt.Fprint(os.Stdout, w.String())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func collectStatusDashboard(ctx context.Context, dockerClient clients.DockerClient) *statusDashboard {
+	dashboard := &statusDashboard{
+		Time:    time.Now(),
+		Reports: health.NewClient().CheckHealth("forta", config.DefaultHealthPort),
+		Usage:   make(map[string]*clients.ContainerResourceUsage),
+	}
+
+	containers, err := dockerClient.GetContainers(ctx)
+	if err != nil {
+		dashboard.UsageErr = err
+		return dashboard
+	}
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, container := range containers {
+		if container.State != "running" {
+			continue
+		}
+		wg.Add(1)
+		go func(name, containerID string) {
+			defer wg.Done()
+			usage, err := dockerClient.GetContainerResourceUsage(ctx, containerID)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			dashboard.Usage[name] = usage
+			mu.Unlock()
+		}(container.Names[0][1:], container.ID)
+	}
+	wg.Wait()
+	return dashboard
+}
+
+// findReport finds the first report which has the name suffix.
+func (dashboard *statusDashboard) findReport(suffix string) *health.Report {
+	for _, report := range dashboard.Reports {
+		if strings.HasSuffix(report.Name, suffix) {
+			return report
+		}
+	}
+	return nil
+}
+
+func (dashboard *statusDashboard) details(suffix string) string {
+	report := dashboard.findReport(suffix)
+	if report == nil || len(report.Details) == 0 {
+		return "-"
+	}
+	return report.Details
+}
+
+func (dashboard *statusDashboard) render(w io.Writer) {
+	writeName(w, fmt.Sprintf("Forta node status - %s\n", dashboard.Time.Format(time.RFC1123)))
+
+	var summaries health.Reports
+	for _, report := range dashboard.Reports {
+		if strings.Contains(report.Name, "summary") {
+			summaries = append(summaries, report)
+		}
+	}
+	writeDashboardSection(w, "Node")
+	if len(summaries) == 0 {
+		writeDashboardLine(w, health.StatusUnknown, "summary", "no reports - is the node running?")
+	}
+	for _, report := range summaries {
+		writeDashboardLine(w, report.Status, report.Name, report.Details)
+	}
+
+	writeDashboardSection(w, "Blocks")
+	lastBlock := dashboard.details(".block-feed.last-block")
+	latestInput := dashboard.details("scanner.latest-block-input")
+	blockLag := "-"
+	lagStatus := health.StatusUnknown
+	if feedNum, err := strconv.ParseUint(lastBlock, 10, 64); err == nil {
+		if inputNum, err := strconv.ParseUint(latestInput, 10, 64); err == nil && feedNum >= inputNum {
+			blockLag = strconv.FormatUint(feedNum-inputNum, 10)
+			lagStatus = health.StatusOK
+		}
+	}
+	writeDashboardLine(w, lagStatus, "lag", fmt.Sprintf("%s blocks (feed=%s, processed=%s)", blockLag, lastBlock, latestInput))
+	if report := dashboard.findReport(".event.block.time"); report != nil {
+		writeDashboardLine(w, report.Status, "last block event", report.Details)
+	}
+	writeDashboardLine(w, health.StatusInfo, "offset", dashboard.details(".block.offset"))
+	writeDashboardLine(w, health.StatusInfo, "reorgs", dashboard.details(".reorg.count"))
+
+	writeDashboardSection(w, "Bots")
+	writeDashboardLine(w, health.StatusInfo, "total", fmt.Sprintf(
+		"%s (lagging: %s)", dashboard.details(".agents.total"), dashboard.details(".agents.lagging"),
+	))
+	for _, report := range dashboard.Reports {
+		i := strings.Index(report.Name, ".agent-pool.agent.")
+		if i < 0 {
+			continue
+		}
+		writeDashboardLine(w, report.Status, report.Name[i+len(".agent-pool.agent."):], report.Details)
+	}
+
+	writeDashboardSection(w, "Publisher")
+	writeDashboardLine(w, health.StatusInfo, "pending batches", dashboard.details("publisher.pending-batches"))
+	writeDashboardLine(w, health.StatusInfo, "pending notifications", dashboard.details("publisher.pending-notifications"))
+	if report := dashboard.findReport(".event.batch-publish.time"); report != nil {
+		writeDashboardLine(w, report.Status, "last publish", report.Details)
+	}
+
+	writeDashboardSection(w, "Inspection")
+	writeDashboardLine(w, health.StatusInfo, "expected score", dashboard.details(".expected-score"))
+
+	writeDashboardSection(w, "Containers")
+	if dashboard.UsageErr != nil {
+		writeDashboardLine(w, health.StatusUnknown, "usage", dashboard.UsageErr.Error())
+	}
+	var names []string
+	for name := range dashboard.Usage {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		usage := dashboard.Usage[name]
+		writeDashboardLine(w, health.StatusInfo, name, fmt.Sprintf(
+			"cpu=%.1f%% mem=%.1fMiB", usage.CPUPercent, float64(usage.MemoryBytes)/1024/1024,
+		))
+	}
+}
+
+func writeDashboardSection(w io.Writer, title string) {
+	color.New(color.Bold, color.Underline).Fprintf(w, "\n%s\n", title)
+}
+
+func writeDashboardLine(w io.Writer, status health.Status, name, details string) {
+	fmt.Fprint(w, "  ")
+	writeStatusBall(w, status)
+	writeStatus(w, fmt.Sprintf("%-24s", name))
+	fmt.Fprint(w, " ")
+	writeDetails(w, status, details)
+	fmt.Fprint(w, "\n")
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusDashboard_Render(t *testing.T) {
+	r := require.New(t)
+
+	color.NoColor = true
+	dashboard := &statusDashboard{
+		Time: time.Now(),
+		Reports: health.Reports{
+			{Name: "forta.summary", Status: health.StatusOK},
+			{Name: "forta.container.forta-scanner.service.block-feed.last-block", Status: health.StatusInfo, Details: "110"},
+			{Name: "forta.container.forta-supervisor.service.health-checker.scanner.latest-block-input", Status: health.StatusInfo, Details: "100"},
+			{Name: "forta.container.forta-scanner.service.agent-pool.agent.0x01", Status: health.StatusLagging, Details: "latency=25ms"},
+			{Name: "forta.container.forta-inspector.service.inspector.expected-score", Status: health.StatusInfo, Details: "0.9"},
+		},
+		Usage: map[string]*clients.ContainerResourceUsage{
+			"forta-scanner": {CPUPercent: 12.5, MemoryBytes: 64 * 1024 * 1024},
+		},
+	}
+
+	w := new(bytes.Buffer)
+	dashboard.render(w)
+	out := w.String()
+	r.Contains(out, "10 blocks (feed=110, processed=100)")
+	r.Contains(out, "0x01")
+	r.Contains(out, "latency=25ms")
+	r.Contains(out, "0.9")
+	r.Contains(out, "cpu=12.5% mem=64.0MiB")
+	r.Contains(out, "pending batches")
+
+	dashboard.Reports = nil
+	dashboard.UsageErr = errors.New("docker is not available")
+	w.Reset()
+	dashboard.render(w)
+	out = w.String()
+	r.Contains(out, "is the node running?")
+	r.Contains(out, "docker is not available")
+}
@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/clients/agentgrpc"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services/scanner/agentpool/poolagent"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testBotContainerName = "forta-test-bot"
+	// shorter than the bot timeout so that the bot is left with a cancelled request
+	testBotCancelAfter = time.Millisecond * 10
+)
+
+var errCheckSkipped = errors.New("skipped")
+
+// botInvoker calls the bot gRPC methods.
+type botInvoker interface {
+	Invoke(ctx context.Context, method agentgrpc.Method, in, out interface{}, opts ...grpc.CallOption) error
+}
+
+type conformanceResult struct {
+	Name    string
+	Err     error
+	Details string
+}
+
+// conformanceSuite checks if a bot behaves the way the node expects.
+type conformanceSuite struct {
+	bot         botInvoker
+	chainID     int
+	timeout     time.Duration
+	initTimeout time.Duration
+	blocks      []*protocol.BlockEvent
+	txs         []*protocol.TransactionEvent
+
+	subscriptions []*protocol.CombinerBotSubscription
+}
+
+func handleFortaTestBot(cmd *cobra.Command, args []string) error {
+	image := args[0]
+	hostPort, err := cmd.Flags().GetString("port")
+	if err != nil {
+		return err
+	}
+	chainID, err := cmd.Flags().GetInt("chain-id")
+	if err != nil {
+		return err
+	}
+	timeoutSeconds, err := cmd.Flags().GetInt("timeout")
+	if err != nil {
+		return err
+	}
+	if timeoutSeconds <= 0 {
+		return fmt.Errorf("timeout should be greater than zero")
+	}
+
+	var blockEvents []*protocol.BlockEvent
+	if err := json.Unmarshal(benchmarkBlockData, &blockEvents); err != nil {
+		return fmt.Errorf("failed to decode bundled blocks: %v", err)
+	}
+	var txEvents []*protocol.TransactionEvent
+	if err := json.Unmarshal(benchmarkTxData, &txEvents); err != nil {
+		return fmt.Errorf("failed to decode bundled txs: %v", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	dockerClient, err := clients.NewDockerClient("")
+	if err != nil {
+		return fmt.Errorf("failed to create the docker client: %v", err)
+	}
+	if err := dockerClient.EnsureLocalImage(ctx, "test bot", image); err != nil {
+		return err
+	}
+	if err := removeLocalBotContainer(ctx, dockerClient, testBotContainerName); err != nil {
+		return err
+	}
+
+	limits := config.GetAgentResourceLimits(cfg.ResourcesConfig)
+	cmd.PrintErrf("Starting bot container from %s\n", image)
+	botContainer, err := dockerClient.StartContainer(ctx, clients.DockerContainerConfig{
+		Name:  testBotContainerName,
+		Image: image,
+		Env: map[string]string{
+			config.EnvAgentGrpcPort: config.AgentGrpcPort,
+			config.EnvFortaChainID:  fmt.Sprintf("%d", chainID),
+		},
+		Ports: map[string]string{
+			fmt.Sprintf("127.0.0.1:%s", hostPort): config.AgentGrpcPort,
+		},
+		CPUQuota: limits.CPUQuota,
+		Memory:   limits.Memory,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start the bot container: %v", err)
+	}
+	defer func() {
+		cmd.PrintErrln("Removing the bot container")
+		if err := dockerClient.TerminateContainer(context.Background(), botContainer.ID); err != nil {
+			cmd.PrintErrf("failed to stop the bot container: %v\n", err)
+		}
+		if err := dockerClient.RemoveContainer(context.Background(), botContainer.ID); err != nil {
+			cmd.PrintErrf("failed to remove the bot container: %v\n", err)
+		}
+	}()
+
+	agentClient, err := dialLocalBot(ctx, hostPort)
+	if err != nil {
+		return err
+	}
+	defer agentClient.Close()
+
+	suite := &conformanceSuite{
+		bot:         agentClient,
+		chainID:     chainID,
+		timeout:     time.Duration(timeoutSeconds) * time.Second,
+		initTimeout: poolagent.DefaultAgentInitializeTimeout,
+		blocks:      blockEvents,
+		txs:         txEvents,
+	}
+	cmd.PrintErrln("Running the conformance checks")
+	results := suite.Run(ctx)
+	if ctx.Err() != nil {
+		return fmt.Errorf("conformance checks were interrupted")
+	}
+
+	if !printConformanceResults(cmd, results) {
+		return fmt.Errorf("the bot failed the conformance checks")
+	}
+	return nil
+}
+
+// Run runs all checks in order. The later checks use the alert subscriptions from the initialization.
+func (suite *conformanceSuite) Run(ctx context.Context) []*conformanceResult {
+	return []*conformanceResult{
+		suite.run(ctx, "initialize", suite.checkInitialize),
+		suite.run(ctx, "evaluate blocks", suite.checkEvaluateBlocks),
+		suite.run(ctx, "evaluate txs", suite.checkEvaluateTxs),
+		suite.run(ctx, "alert subscriptions", suite.checkAlertSubscriptions),
+		suite.run(ctx, "recover after timeout", suite.checkTimeoutRecovery),
+	}
+}
+
+func (suite *conformanceSuite) run(ctx context.Context, name string, check func(context.Context) (string, error)) *conformanceResult {
+	details, err := check(ctx)
+	return &conformanceResult{Name: name, Err: err, Details: details}
+}
+
+func (suite *conformanceSuite) checkInitialize(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, suite.initTimeout)
+	defer cancel()
+
+	var resp protocol.InitializeResponse
+	err := suite.bot.Invoke(ctx, agentgrpc.MethodInitialize, &protocol.InitializeRequest{
+		AgentId:   "test-bot",
+		ProxyHost: config.DockerJSONRPCProxyContainerName,
+	}, &resp)
+	if status.Code(err) == codes.Unimplemented {
+		return "not implemented", errCheckSkipped
+	}
+	if err != nil {
+		return "", fmt.Errorf("initialize failed within %s: %v", suite.initTimeout, err)
+	}
+	if resp.Status == protocol.ResponseStatus_ERROR {
+		return "", fmt.Errorf("initialize responded with error: %s", responseErrors(resp.Errors))
+	}
+	if err := poolagent.ValidateInitializeResponse(&resp); err != nil {
+		return "", err
+	}
+	if resp.AlertConfig != nil {
+		suite.subscriptions = resp.AlertConfig.Subscriptions
+	}
+	return fmt.Sprintf("%d alert subscriptions", len(suite.subscriptions)), nil
+}
+
+func (suite *conformanceSuite) checkEvaluateBlocks(ctx context.Context) (string, error) {
+	var findingCount int
+	for i, event := range suite.blocks {
+		var resp protocol.EvaluateBlockResponse
+		if err := suite.evaluate(ctx, agentgrpc.MethodEvaluateBlock, &protocol.EvaluateBlockRequest{
+			RequestId: fmt.Sprintf("test-block-%d", i),
+			Event:     event,
+		}, &resp); err != nil {
+			return "", fmt.Errorf("block %s: %v", event.BlockNumber, err)
+		}
+		if err := validateResponse(resp.Status, resp.Errors, resp.Findings); err != nil {
+			return "", fmt.Errorf("block %s: %v", event.BlockNumber, err)
+		}
+		findingCount += len(resp.Findings)
+	}
+	return fmt.Sprintf("%d blocks, %d findings", len(suite.blocks), findingCount), nil
+}
+
+func (suite *conformanceSuite) checkEvaluateTxs(ctx context.Context) (string, error) {
+	var findingCount int
+	for i, event := range suite.txs {
+		var resp protocol.EvaluateTxResponse
+		if err := suite.evaluate(ctx, agentgrpc.MethodEvaluateTx, &protocol.EvaluateTxRequest{
+			RequestId: fmt.Sprintf("test-tx-%d", i),
+			Event:     event,
+		}, &resp); err != nil {
+			return "", fmt.Errorf("tx %s: %v", event.Transaction.Hash, err)
+		}
+		if err := validateResponse(resp.Status, resp.Errors, resp.Findings); err != nil {
+			return "", fmt.Errorf("tx %s: %v", event.Transaction.Hash, err)
+		}
+		findingCount += len(resp.Findings)
+	}
+	return fmt.Sprintf("%d txs, %d findings", len(suite.txs), findingCount), nil
+}
+
+// checkAlertSubscriptions sends an alert for each subscription of the bot.
+func (suite *conformanceSuite) checkAlertSubscriptions(ctx context.Context) (string, error) {
+	if len(suite.subscriptions) == 0 {
+		return "no alert subscriptions", errCheckSkipped
+	}
+	for i, subscription := range suite.subscriptions {
+		alertID := subscription.AlertId
+		if len(alertID) == 0 && len(subscription.AlertIds) > 0 {
+			alertID = subscription.AlertIds[0]
+		}
+		chainID := subscription.ChainId
+		if chainID == 0 {
+			chainID = uint64(suite.chainID)
+		}
+		var resp protocol.EvaluateAlertResponse
+		if err := suite.evaluate(ctx, agentgrpc.MethodEvaluateAlert, &protocol.EvaluateAlertRequest{
+			RequestId: fmt.Sprintf("test-alert-%d", i),
+			Event:     testAlertEvent(subscription.BotId, alertID, chainID),
+		}, &resp); err != nil {
+			return "", fmt.Errorf("alert from %s: %v", subscription.BotId, err)
+		}
+		if err := validateResponse(resp.Status, resp.Errors, resp.Findings); err != nil {
+			return "", fmt.Errorf("alert from %s: %v", subscription.BotId, err)
+		}
+	}
+	return fmt.Sprintf("%d alerts", len(suite.subscriptions)), nil
+}
+
+// checkTimeoutRecovery cancels a request and then expects the bot to keep responding.
+func (suite *conformanceSuite) checkTimeoutRecovery(ctx context.Context) (string, error) {
+	if len(suite.blocks) == 0 {
+		return "no blocks", errCheckSkipped
+	}
+	req := &protocol.EvaluateBlockRequest{
+		RequestId: "test-block-cancelled",
+		Event:     suite.blocks[len(suite.blocks)-1],
+	}
+	cancelCtx, cancel := context.WithTimeout(ctx, testBotCancelAfter)
+	suite.bot.Invoke(cancelCtx, agentgrpc.MethodEvaluateBlock, req, &protocol.EvaluateBlockResponse{})
+	cancel()
+
+	req.RequestId = "test-block-after-cancel"
+	var resp protocol.EvaluateBlockResponse
+	if err := suite.evaluate(ctx, agentgrpc.MethodEvaluateBlock, req, &resp); err != nil {
+		return "", fmt.Errorf("bot did not respond after a cancelled request: %v", err)
+	}
+	return "", nil
+}
+
+// evaluate sends the request within the bot timeout.
+func (suite *conformanceSuite) evaluate(ctx context.Context, method agentgrpc.Method, req, resp interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, suite.timeout)
+	defer cancel()
+	start := time.Now()
+	err := suite.bot.Invoke(ctx, method, req, resp)
+	if status.Code(err) == codes.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", time.Since(start).Round(time.Millisecond))
+	}
+	return err
+}
+
+func validateResponse(respStatus protocol.ResponseStatus, respErrors []*protocol.Error, findings []*protocol.Finding) error {
+	if respStatus != protocol.ResponseStatus_SUCCESS {
+		return fmt.Errorf("response status is %s: %s", respStatus, responseErrors(respErrors))
+	}
+	if len(findings) > poolagent.MaxFindings {
+		return fmt.Errorf("returned %d findings - the node drops the findings after the first %d", len(findings), poolagent.MaxFindings)
+	}
+	for i, finding := range findings {
+		if err := validateFindingSchema(finding); err != nil {
+			return fmt.Errorf("finding %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// validateFindingSchema checks the finding fields which are required for publishing.
+func validateFindingSchema(finding *protocol.Finding) error {
+	if err := poolagent.ValidateFinding(finding); err != nil {
+		return err
+	}
+	if len(finding.Description) == 0 {
+		return errors.New("empty description")
+	}
+	return nil
+}
+
+func responseErrors(respErrors []*protocol.Error) string {
+	if len(respErrors) == 0 {
+		return "no error details"
+	}
+	var msgs []string
+	for _, respErr := range respErrors {
+		msgs = append(msgs, respErr.Message)
+	}
+	return fmt.Sprintf("%v", msgs)
+}
+
+func testAlertEvent(botID, alertID string, chainID uint64) *protocol.AlertEvent {
+	return &protocol.AlertEvent{
+		Alert: &protocol.AlertEvent_Alert{
+			AlertId:     alertID,
+			Hash:        hexutil.Encode(make([]byte, 32)),
+			Name:        "Test alert",
+			Description: "Test alert from forta test-bot",
+			CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+			Severity:    protocol.Finding_INFO.String(),
+			FindingType: protocol.Finding_INFORMATION.String(),
+			ChainId:     chainID,
+			Source: &protocol.AlertEvent_Alert_Source{
+				Bot: &protocol.AlertEvent_Alert_Bot{Id: botID},
+				Block: &protocol.AlertEvent_Alert_Block{
+					Number:  1,
+					Hash:    hexutil.Encode(make([]byte, 32)),
+					ChainId: chainID,
+				},
+			},
+		},
+	}
+}
+
+// printConformanceResults prints the report and tells if all checks passed.
+func printConformanceResults(cmd *cobra.Command, results []*conformanceResult) bool {
+	allPassed := true
+	whiteBold("\nConformance checks\n")
+	for _, result := range results {
+		switch {
+		case errors.Is(result.Err, errCheckSkipped):
+			yellowBold("  SKIP  ")
+			cmd.Printf("%s (%s)\n", result.Name, result.Details)
+		case result.Err != nil:
+			allPassed = false
+			redBold("  FAIL  ")
+			cmd.Printf("%s: %v\n", result.Name, result.Err)
+		default:
+			greenBold("  PASS  ")
+			if len(result.Details) > 0 {
+				cmd.Printf("%s (%s)\n", result.Name, result.Details)
+			} else {
+				cmd.Printf("%s\n", result.Name)
+			}
+		}
+	}
+	return allPassed
+}
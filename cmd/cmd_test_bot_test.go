@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients/agentgrpc"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testSubscribedBotID = "0x1d646c4045189991fdfd24a66b192a294158b839a6ec121d740474bdacb3ab23"
+
+type testBot struct {
+	initErr       error
+	subscriptions []*protocol.CombinerBotSubscription
+	findings      []*protocol.Finding
+	delay         time.Duration
+	alertCalls    int
+}
+
+func (bot *testBot) Invoke(ctx context.Context, method agentgrpc.Method, in, out interface{}, opts ...grpc.CallOption) error {
+	select {
+	case <-ctx.Done():
+	case <-time.After(bot.delay):
+	}
+	if ctx.Err() != nil {
+		return status.Error(codes.DeadlineExceeded, "deadline exceeded")
+	}
+	switch resp := out.(type) {
+	case *protocol.InitializeResponse:
+		if bot.initErr != nil {
+			return bot.initErr
+		}
+		resp.Status = protocol.ResponseStatus_SUCCESS
+		resp.AlertConfig = &protocol.AlertConfig{Subscriptions: bot.subscriptions}
+	case *protocol.EvaluateBlockResponse:
+		resp.Status = protocol.ResponseStatus_SUCCESS
+		resp.Findings = bot.findings
+	case *protocol.EvaluateTxResponse:
+		resp.Status = protocol.ResponseStatus_SUCCESS
+		resp.Findings = bot.findings
+	case *protocol.EvaluateAlertResponse:
+		bot.alertCalls++
+		resp.Status = protocol.ResponseStatus_SUCCESS
+	}
+	return nil
+}
+
+func testConformanceSuite(bot *testBot) *conformanceSuite {
+	return &conformanceSuite{
+		bot:         bot,
+		chainID:     1,
+		timeout:     time.Second,
+		initTimeout: time.Second,
+		blocks:      []*protocol.BlockEvent{{BlockNumber: "0x1"}},
+		txs:         []*protocol.TransactionEvent{{Transaction: &protocol.TransactionEvent_EthTransaction{Hash: "0x1"}}},
+	}
+}
+
+func requireCheck(t *testing.T, results []*conformanceResult, name string, expectedErr error) {
+	for _, result := range results {
+		if result.Name != name {
+			continue
+		}
+		if expectedErr == nil {
+			require.NoError(t, result.Err, name)
+		} else {
+			require.ErrorIs(t, result.Err, expectedErr, name)
+		}
+		return
+	}
+	t.Fatalf("check %s not found", name)
+}
+
+func TestConformanceSuite_Pass(t *testing.T) {
+	bot := &testBot{
+		subscriptions: []*protocol.CombinerBotSubscription{{BotId: testSubscribedBotID, AlertId: "ALERT-1"}},
+		findings: []*protocol.Finding{{
+			AlertId:     "ALERT-2",
+			Name:        "Finding",
+			Description: "Finding description",
+			Severity:    protocol.Finding_LOW,
+			Type:        protocol.Finding_SUSPICIOUS,
+		}},
+	}
+	results := testConformanceSuite(bot).Run(context.Background())
+	for _, result := range results {
+		require.NoError(t, result.Err, result.Name)
+	}
+	require.Equal(t, 1, bot.alertCalls)
+}
+
+func TestConformanceSuite_Skip(t *testing.T) {
+	bot := &testBot{initErr: status.Error(codes.Unimplemented, "not implemented")}
+	results := testConformanceSuite(bot).Run(context.Background())
+	requireCheck(t, results, "initialize", errCheckSkipped)
+	requireCheck(t, results, "evaluate blocks", nil)
+	requireCheck(t, results, "alert subscriptions", errCheckSkipped)
+}
+
+func TestConformanceSuite_Fail(t *testing.T) {
+	r := require.New(t)
+
+	bot := &testBot{
+		subscriptions: []*protocol.CombinerBotSubscription{{BotId: "bad-bot-id"}},
+		findings:      []*protocol.Finding{{Name: "Finding without alert id"}},
+	}
+	results := testConformanceSuite(bot).Run(context.Background())
+	r.Error(results[0].Err)
+	r.Error(results[1].Err)
+	r.Contains(results[1].Err.Error(), "empty alert id")
+	r.Error(results[2].Err)
+}
+
+func TestConformanceSuite_Timeout(t *testing.T) {
+	bot := &testBot{delay: time.Millisecond * 500}
+	suite := testConformanceSuite(bot)
+	suite.timeout = time.Millisecond * 20
+
+	results := suite.Run(context.Background())
+	require.Error(t, results[1].Err)
+	require.Contains(t, results[1].Err.Error(), "timed out")
+	require.False(t, errors.Is(results[1].Err, errCheckSkipped))
+}
@@ -4,16 +4,20 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"os"
 	"strconv"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/fatih/color"
 	"github.com/forta-network/forta-core-go/registry"
 	"github.com/forta-network/forta-core-go/security"
 	"github.com/forta-network/forta-core-go/security/eip712"
+	"github.com/forta-network/forta-node/config"
 	"github.com/forta-network/forta-node/store"
 	"github.com/spf13/cobra"
 )
@@ -23,18 +27,73 @@ func handleFortaAuthorizePool(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	_, err = authorizePool(cmd, poolIDStr, "")
+	return err
+}
+
+func handleFortaRegisterPool(cmd *cobra.Command, args []string) error {
+	poolIDStr, _ := cmd.Flags().GetString("id")
+	if len(poolIDStr) == 0 {
+		poolIDStr = cfg.ScannerPool.PoolID
+	}
+	if len(poolIDStr) == 0 {
+		return errors.New("please specify the pool ID with --id or set scannerPool.poolId in the config file")
+	}
+	ownerAddr, _ := cmd.Flags().GetString("owner")
+	if len(ownerAddr) == 0 {
+		ownerAddr = cfg.ScannerPool.OwnerAddress
+	}
+	if len(ownerAddr) > 0 && !common.IsHexAddress(ownerAddr) {
+		return fmt.Errorf("invalid owner address: %s", ownerAddr)
+	}
+
+	generated, err := authorizePool(cmd, poolIDStr, ownerAddr)
+	if err != nil || !generated {
+		return err
+	}
+
+	save, _ := cmd.Flags().GetBool("save")
+	if !save {
+		return nil
+	}
+	values := map[string]string{"scannerPool.poolId": poolIDStr}
+	if len(ownerAddr) > 0 {
+		values["scannerPool.ownerAddress"] = common.HexToAddress(ownerAddr).Hex()
+	}
+	configPath := cfg.ConfigFilePath()
+	configBytes, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read the config file: %v", err)
+	}
+	updated, err := config.SetConfigValues(configBytes, values)
+	if err != nil {
+		return fmt.Errorf("failed to update the config file: %v", err)
+	}
+	if err := os.WriteFile(configPath, updated, 0644); err != nil {
+		return fmt.Errorf("failed to write the config file: %v", err)
+	}
+	// keep the stdout clean for --clean
+	toStderr(fmt.Sprintf("Saved the scanner pool to %s\n", configPath))
+	return nil
+}
+
+// authorizePool generates the registration signature of the scanner for the pool and returns false
+// if the signature was not generated because of a warning. If the owner address is not empty, the
+// pool is expected to be owned by it.
+func authorizePool(cmd *cobra.Command, poolIDStr, ownerAddr string) (bool, error) {
 	poolID, err := strconv.ParseInt(poolIDStr, 10, 64)
 	if err != nil {
-		return fmt.Errorf("failed to decode pool ID: %v", err)
+		return false, fmt.Errorf("failed to decode pool ID: %v", err)
 	}
 
 	polygonscan, _ := cmd.Flags().GetBool("polygonscan")
 	force, _ := cmd.Flags().GetBool("force")
 	clean, _ := cmd.Flags().GetBool("clean")
+	preInspect, _ := cmd.Flags().GetBool("pre-inspect")
 
 	scannerKey, err := security.LoadKeyWithPassphrase(cfg.KeyDirPath, cfg.Passphrase)
 	if err != nil {
-		return fmt.Errorf("failed to load scanner key: %v", err)
+		return false, fmt.Errorf("failed to load scanner key: %v", err)
 	}
 	scannerPrivateKey := scannerKey.PrivateKey
 
@@ -45,26 +104,51 @@ func handleFortaAuthorizePool(cmd *cobra.Command, args []string) error {
 		PrivateKey: scannerPrivateKey,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create registry client: %v", err)
+		return false, fmt.Errorf("failed to create registry client: %v", err)
 	}
 	regClient.SetRegistryChainID(cfg.Registry.ChainID)
 
 	scanner, err := regClient.GetPoolScanner(scannerKey.Address.Hex())
 	if err != nil {
-		return fmt.Errorf("failed to get scanner from registry: %v", err)
+		return false, fmt.Errorf("failed to get scanner from registry: %v", err)
 	}
 	if scanner != nil && !force {
 		color.New(color.FgYellow).Printf("This scanner is already registered to pool %s!\n", scanner.PoolID)
-		return nil
+		return false, nil
+	}
+
+	if len(ownerAddr) > 0 {
+		if scannerKey.Address == common.HexToAddress(ownerAddr) {
+			yellowBold("The scanner key is the pool owner! Please keep the owner key off the scan node and use a separate scanner key.\n")
+		}
+		contracts := regClient.Contracts()
+		if contracts == nil || contracts.ScannerPoolReg == nil {
+			return false, errors.New("scanner pool registry contracts are not ready")
+		}
+		owner, err := contracts.ScannerPoolReg.OwnerOf(nil, big.NewInt(poolID))
+		if err != nil {
+			return false, fmt.Errorf("failed to get the pool owner: %v", err)
+		}
+		if owner != common.HexToAddress(ownerAddr) && !force {
+			redBold("The pool (id = %d) is owned by %s and not by %s!\n", poolID, owner.Hex(), common.HexToAddress(ownerAddr).Hex())
+			return false, nil
+		}
 	}
 
 	willShutdown, err := regClient.WillNewScannerShutdownPool(big.NewInt(poolID))
 	if err != nil {
-		return fmt.Errorf("failed to check pool shutdown condition: %v", err)
+		return false, fmt.Errorf("failed to check pool shutdown condition: %v", err)
 	}
 	if willShutdown && !force {
 		redBold("Registering this scanner will shutdown the pool! Please stake more on the pool (id = %d) first.\n", poolID)
-		return nil
+		return false, nil
+	}
+
+	// do not generate the signature if the node would fail the inspection right after the registration
+	if preInspect {
+		if err := runPreRegistrationInspection(cmd, scannerKey.Address.Hex(), clean); err != nil {
+			return false, err
+		}
 	}
 
 	ts := time.Now().Unix()
@@ -76,18 +160,18 @@ func handleFortaAuthorizePool(cmd *cobra.Command, args []string) error {
 		Timestamp:     big.NewInt(ts),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to generate registration signature: %v", err)
+		return false, fmt.Errorf("failed to generate registration signature: %v", err)
 	}
 
 	infoB, err := json.Marshal(regInfo)
 	if err != nil {
-		return fmt.Errorf("failed to marshal registration info: %v", err)
+		return false, fmt.Errorf("failed to marshal registration info: %v", err)
 	}
 	infoStr := base64.URLEncoding.EncodeToString(infoB)
 
 	if clean {
 		fmt.Println(infoStr)
-		return nil
+		return true, nil
 	}
 
 	if polygonscan {
@@ -99,7 +183,7 @@ func handleFortaAuthorizePool(cmd *cobra.Command, args []string) error {
 		color.New(color.FgYellow).Println(infoStr)
 	}
 
-	return nil
+	return true, nil
 }
 
 //	struct ScannerNodeRegistration {
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/forta-network/forta-core-go/registry"
+	"github.com/forta-network/forta-core-go/release"
+	"github.com/forta-network/forta-core-go/security"
+	"github.com/forta-network/forta-core-go/utils"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/store"
+	"github.com/spf13/cobra"
+)
+
+func handleFortaUpdateExport(cmd *cobra.Command, args []string) error {
+	releaseRef, _ := cmd.Flags().GetString("release")
+	outputPath, _ := cmd.Flags().GetString("output")
+	ctx := context.Background()
+
+	key, err := security.LoadKeyWithPassphrase(cfg.KeyDirPath, cfg.Passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to load the key: %v", err)
+	}
+
+	if len(releaseRef) == 0 {
+		registryClient, err := store.GetRegistryClient(ctx, cfg, registry.ClientConfig{
+			JsonRpcUrl: cfg.Registry.JsonRpc.Url,
+			ENSAddress: cfg.ENSConfig.ContractAddress,
+			Name:       "registry-client",
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create registry client: %v", err)
+		}
+		releaseRef, err = registryClient.GetScannerNodeVersion()
+		if err != nil {
+			return fmt.Errorf("failed to get the latest release: %v", err)
+		}
+	}
+	releaseClient, err := release.NewClient(cfg.Registry.IPFS.GatewayURL)
+	if err != nil {
+		return err
+	}
+	releaseManifest, err := releaseClient.GetReleaseManifest(ctx, releaseRef)
+	if err != nil {
+		return fmt.Errorf("failed to get the release manifest: %v", err)
+	}
+	cmd.Printf("Exporting release %s (%s)\n", releaseManifest.Release.Version, releaseRef)
+
+	// the exported refs should be the same with the ones which the node uses
+	services := &releaseManifest.Release.Services
+	for _, ref := range []*string{&services.Updater, &services.Supervisor} {
+		if fixedRef, err := utils.ValidateDiscoImageRef(cfg.Registry.ContainerRegistry, *ref); err == nil {
+			*ref = fixedRef
+		}
+	}
+
+	dockerClient, err := clients.NewDockerClient("")
+	if err != nil {
+		return fmt.Errorf("failed to create the docker client: %v", err)
+	}
+	refs := []string{services.Updater, services.Supervisor, config.DockerNatsImage, config.DockerIpfsImage}
+	images := make(map[string]string)
+	for _, ref := range refs {
+		cmd.Println("Pulling", ref)
+		if err := dockerClient.EnsureLocalImage(ctx, ref, ref); err != nil {
+			return fmt.Errorf("failed to pull %s: %v", ref, err)
+		}
+		images[ref], err = dockerClient.GetImageID(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("failed to inspect %s: %v", ref, err)
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "forta-update")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	imagesPath := path.Join(tmpDir, "images.tar")
+	if err := saveImages(ctx, dockerClient, refs, imagesPath); err != nil {
+		return fmt.Errorf("failed to save the images: %v", err)
+	}
+
+	bundle, err := store.NewUpdateBundle(release.ReleaseInfo{
+		IPFS:     releaseRef,
+		Manifest: *releaseManifest,
+	}, images, imagesPath)
+	if err != nil {
+		return err
+	}
+	if err := bundle.Sign(key); err != nil {
+		return fmt.Errorf("failed to sign the bundle: %v", err)
+	}
+
+	if len(outputPath) == 0 {
+		outputPath = fmt.Sprintf("forta-update-%s.tar.gz", releaseManifest.Release.Version)
+	}
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := store.WriteUpdateBundle(f, bundle, imagesPath); err != nil {
+		return fmt.Errorf("failed to write the bundle: %v", err)
+	}
+	greenBold("Exported the release to %s, signed by %s\n", outputPath, bundle.Signer)
+	return nil
+}
+
+func saveImages(ctx context.Context, dockerClient clients.DockerClient, refs []string, imagesPath string) error {
+	f, err := os.Create(imagesPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return dockerClient.SaveImages(ctx, refs, f)
+}
+
+func handleFortaUpdateImport(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open the bundle: %v", err)
+	}
+	defer f.Close()
+	tmpDir, err := os.MkdirTemp("", "forta-update")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	imagesPath := path.Join(tmpDir, "images.tar")
+	bundle, err := store.ReadUpdateBundle(f, imagesPath)
+	if err != nil {
+		return err
+	}
+
+	// the bundles exported by this node are always allowed
+	signers := cfg.AutoUpdate.BundleSigners
+	ks := keystore.NewKeyStore(cfg.KeyDirPath, keystore.StandardScryptN, keystore.StandardScryptP)
+	if accounts := ks.Accounts(); len(accounts) == 1 {
+		signers = append(signers, accounts[0].Address.Hex())
+	}
+	if err := bundle.Verify(signers); err != nil {
+		redBold("The bundle can not be trusted. Please add the exporting node address to autoUpdate.bundleSigners if you trust it.\n")
+		return fmt.Errorf("failed to verify the bundle: %v", err)
+	}
+	localRelease, err := bundle.LocalRelease()
+	if err != nil {
+		return err
+	}
+
+	dockerClient, err := clients.NewDockerClient("")
+	if err != nil {
+		return fmt.Errorf("failed to create the docker client: %v", err)
+	}
+	if err := loadImages(ctx, dockerClient, imagesPath); err != nil {
+		return fmt.Errorf("failed to load the images: %v", err)
+	}
+	for ref, imageID := range bundle.Images {
+		if !dockerClient.HasLocalImage(ctx, imageID) {
+			return fmt.Errorf("image %s (%s) was not loaded from the bundle", ref, imageID)
+		}
+	}
+
+	if err := store.WriteImportedRelease(cfg.FortaDir, localRelease); err != nil {
+		return fmt.Errorf("failed to write the imported release: %v", err)
+	}
+	greenBold("Imported release %s (%s)\n", bundle.Release.Manifest.Release.Version, bundle.Release.IPFS)
+	if !cfg.AutoUpdate.Offline {
+		yellowBold("Please set autoUpdate.offline to true in your config so that the node follows the imported releases.\n")
+	}
+	return nil
+}
+
+func loadImages(ctx context.Context, dockerClient clients.DockerClient, imagesPath string) error {
+	f, err := os.Open(imagesPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return dockerClient.LoadImages(ctx, f)
+}
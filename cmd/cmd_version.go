@@ -1,18 +1,117 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
 
+	"github.com/docker/docker/api/types"
+	"github.com/forta-network/forta-core-go/release"
+	"github.com/forta-network/forta-node/clients"
 	"github.com/forta-network/forta-node/config"
 	"github.com/spf13/cobra"
 )
 
+// versionReport contains the versions of the node and all of the running containers.
+type versionReport struct {
+	Node       *release.ReleaseSummary `json:"node,omitempty"`
+	Containers []*containerVersion     `json:"containers"`
+}
+
+type containerVersion struct {
+	Name         string `json:"name"`
+	State        string `json:"state"`
+	Image        string `json:"image"`
+	ImageID      string `json:"imageId"`
+	Version      string `json:"version,omitempty"`
+	BotID        string `json:"botId,omitempty"`
+	BotImageHash string `json:"botImageHash,omitempty"`
+}
+
 func handleFortaVersion(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	format, _ := cmd.Flags().GetString("format")
+
 	releaseSummary, ok := config.GetBuildReleaseSummary()
-	if !ok {
+	if !all {
+		if !ok {
+			return nil
+		}
+		b, _ := json.MarshalIndent(releaseSummary, "", "  ")
+		cmd.Println(string(b))
+		return nil
+	}
+
+	dockerClient, err := clients.NewDockerClient("")
+	if err != nil {
+		return fmt.Errorf("failed to create the docker client: %v", err)
+	}
+	containers, err := dockerClient.GetContainers(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get the containers: %v", err)
+	}
+	report := &versionReport{
+		Node:       releaseSummary,
+		Containers: makeContainerVersions(containers),
+	}
+
+	switch format {
+	case "text":
+		writeVersionReport(cmd.OutOrStdout(), report)
 		return nil
+	case "json":
+		b, _ := json.MarshalIndent(report, "", "  ")
+		cmd.Println(string(b))
+		return nil
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+// makeContainerVersions collects the versions from the container labels, ordered by the container names.
+func makeContainerVersions(containers []types.Container) []*containerVersion {
+	versions := []*containerVersion{}
+	for _, container := range containers {
+		var name string
+		if len(container.Names) > 0 {
+			name = strings.TrimPrefix(container.Names[0], "/")
+		}
+		versions = append(versions, &containerVersion{
+			Name:         name,
+			State:        container.State,
+			Image:        container.Image,
+			ImageID:      container.ImageID,
+			Version:      container.Labels[clients.DockerLabelFortaVersion],
+			BotID:        container.Labels[clients.DockerLabelFortaBotID],
+			BotImageHash: container.Labels[clients.DockerLabelFortaBotImageHash],
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Name < versions[j].Name
+	})
+	return versions
+}
+
+func writeVersionReport(w io.Writer, report *versionReport) {
+	if report.Node != nil {
+		fmt.Fprintf(w, "Node: %s (commit: %s)\n\n", report.Node.Version, report.Node.Commit)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONTAINER\tSTATE\tVERSION\tIMAGE\tBOT")
+	for _, container := range report.Containers {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			container.Name, container.State, valueOrDash(container.Version), container.Image, valueOrDash(container.BotID))
+	}
+	tw.Flush()
+}
+
+func valueOrDash(value string) string {
+	if len(value) == 0 {
+		return "-"
 	}
-	b, _ := json.MarshalIndent(releaseSummary, "", "  ")
-	cmd.Println(string(b))
-	return nil
+	return value
 }
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/forta-network/forta-core-go/release"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakeContainerVersions(t *testing.T) {
+	r := require.New(t)
+
+	versions := makeContainerVersions([]types.Container{
+		{
+			Names:   []string{"/forta-scanner"},
+			State:   "running",
+			Image:   "disco.forta.network/bafybeinode@sha256:1234",
+			ImageID: "sha256:abcd",
+			Labels:  map[string]string{clients.DockerLabelFortaVersion: "v1.2.3"},
+		},
+		{
+			Names: []string{"/forta-agent-0x1234"},
+			State: "running",
+			Image: "disco.forta.network/bafybeibot@sha256:5678",
+			Labels: map[string]string{
+				clients.DockerLabelFortaBotID:        "0x1234",
+				clients.DockerLabelFortaBotImageHash: "5678",
+			},
+		},
+		{
+			Names: []string{"/forta-nats"},
+			State: "exited",
+			Image: "nats:2.3.2",
+		},
+	})
+	r.Len(versions, 3)
+	r.Equal("forta-agent-0x1234", versions[0].Name)
+	r.Equal("0x1234", versions[0].BotID)
+	r.Equal("5678", versions[0].BotImageHash)
+	r.Empty(versions[0].Version)
+	r.Equal("forta-nats", versions[1].Name)
+	r.Equal("exited", versions[1].State)
+	r.Equal("forta-scanner", versions[2].Name)
+	r.Equal("v1.2.3", versions[2].Version)
+	r.Equal("sha256:abcd", versions[2].ImageID)
+
+	var buf bytes.Buffer
+	writeVersionReport(&buf, &versionReport{
+		Node:       &release.ReleaseSummary{Version: "v1.2.3", Commit: "abc"},
+		Containers: versions,
+	})
+	r.Contains(buf.String(), "Node: v1.2.3 (commit: abc)")
+	r.Regexp(`forta-nats\s+exited\s+-\s+nats:2.3.2\s+-`, buf.String())
+	r.Regexp(`forta-scanner\s+running\s+v1.2.3\s+`, buf.String())
+}
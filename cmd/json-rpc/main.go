@@ -19,6 +19,9 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 	// can't dial localhost - need to dial host gateway from container
 	cfg.Scan.JsonRpc.Url = utils.ConvertToDockerHostURL(cfg.Scan.JsonRpc.Url)
 	cfg.JsonRpcProxy.JsonRpc.Url = utils.ConvertToDockerHostURL(cfg.JsonRpcProxy.JsonRpc.Url)
+	for i := range cfg.JsonRpcProxy.Providers {
+		cfg.JsonRpcProxy.Providers[i].Url = utils.ConvertToDockerHostURL(cfg.JsonRpcProxy.Providers[i].Url)
+	}
 
 	proxy, err := initJsonRpcProxy(ctx, cfg)
 	if err != nil {
@@ -42,6 +45,12 @@ func summarizeReports(reports health.Reports) *health.Report {
 		summary.Addf("last time the api failed with error '%s'.", apiErr.Details)
 	}
 
+	healthyProviders, ok := reports.NameContains("service.json-rpc-proxy.providers.healthy")
+	if ok && healthyProviders.Details == "0" {
+		summary.Addf("all json-rpc providers are failing.")
+		summary.Status(health.StatusFailing)
+	}
+
 	return summary.Finish()
 }
 
@@ -19,6 +19,7 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 	cfg.Publish.IPFS.APIURL = utils.ConvertToDockerHostURL(cfg.Publish.IPFS.APIURL)
 	cfg.Publish.IPFS.GatewayURL = utils.ConvertToDockerHostURL(cfg.Publish.IPFS.GatewayURL)
 	cfg.LocalModeConfig.WebhookURL = utils.ConvertToDockerHostURL(cfg.LocalModeConfig.WebhookURL)
+	cfg.Publish.Private.WebhookURL = utils.ConvertToDockerHostURL(cfg.Publish.Private.WebhookURL)
 
 	p, err := publisher.NewPublisher(ctx, cfg)
 	if err != nil {
@@ -52,6 +52,11 @@ func Run(cfg config.Config) {
 		return
 	}
 
+	// the host config is not reloaded here - the runner only passes the signal to the supervisor
+	go services.HandleConfigReloads(ctx, logger, cfg, func() (config.Config, error) {
+		return cfg, nil
+	}, serviceList)
+
 	err = services.StartServices(ctx, cancel, log.NewEntry(log.StandardLogger()), serviceList)
 	if err == services.ErrExitTriggered {
 		logger.Info("exiting successfully after internal trigger")
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"os"
+	"path"
 	"strconv"
 	"strings"
 	"time"
@@ -16,6 +18,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	gethlog "github.com/ethereum/go-ethereum/log"
 
 	"github.com/forta-network/forta-core-go/clients/health"
@@ -30,9 +33,10 @@ import (
 	"github.com/forta-network/forta-node/services/registry"
 	"github.com/forta-network/forta-node/services/scanner"
 	"github.com/forta-network/forta-node/services/scanner/agentpool"
+	"github.com/forta-network/forta-node/services/scanner/datasource"
 )
 
-func initTxStream(ctx context.Context, ethClient, traceClient ethereum.Client, cfg config.Config) (*scanner.TxStreamService, feeds.BlockFeed, error) {
+func initTxStream(ctx context.Context, ethClient, traceClient ethereum.Client, cfg config.Config, checkpoints *scanner.EvalCheckpoints) (*scanner.TxStreamService, feeds.BlockFeed, error) {
 	cfg.Scan.JsonRpc.Url = utils.ConvertToDockerHostURL(cfg.Scan.JsonRpc.Url)
 	cfg.JsonRpcProxy.JsonRpc.Url = utils.ConvertToDockerHostURL(cfg.Scan.JsonRpc.Url)
 	cfg.Registry.JsonRpc.Url = utils.ConvertToDockerHostURL(cfg.Registry.JsonRpc.Url)
@@ -76,24 +80,34 @@ func initTxStream(ctx context.Context, ethClient, traceClient ethereum.Client, c
 		}
 	}
 
+	if startBlock == nil {
+		startBlock, maxAgePtr = getStartBlock(ctx, ethClient, cfg, checkpoints, maxAgePtr)
+	}
+
 	if startBlock != nil && stopBlock != nil && !(stopBlock.Cmp(startBlock) > 0) {
 		log.Fatal("stop block is not greater than the start block - please check the runtime limits")
 	}
 
 	ethClient.SetRetryInterval(time.Second * time.Duration(cfg.Scan.RetryIntervalSeconds))
 
-	blockFeed, err := feeds.NewBlockFeed(ctx, ethClient, traceClient, feeds.BlockFeedConfig{
-		ChainID:             chainID,
-		Tracing:             cfg.Trace.Enabled,
-		RateLimit:           rateLimit,
-		SkipBlocksOlderThan: maxAgePtr,
-		Offset:              getBlockOffset(cfg),
-		Start:               startBlock,
-		End:                 stopBlock,
+	dataSource, err := datasource.New(ctx, cfg.Scan.DataSource, datasource.Params{
+		Config:      cfg,
+		ChainID:     chainID,
+		EthClient:   ethClient,
+		TraceClient: traceClient,
+		Feed: feeds.BlockFeedConfig{
+			Tracing:             cfg.Trace.Enabled,
+			RateLimit:           rateLimit,
+			SkipBlocksOlderThan: maxAgePtr,
+			Offset:              getBlockOffset(cfg),
+			Start:               startBlock,
+			End:                 stopBlock,
+		},
 	})
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, fmt.Errorf("failed to create the chain data source: %v", err)
 	}
+	blockFeed := dataSource.BlockFeed()
 
 	// subscribe to block feed so we can detect block end and trigger exit
 	blockErrCh := blockFeed.Subscribe(func(evt *domain.BlockEvent) error {
@@ -121,7 +135,7 @@ func initTxStream(ctx context.Context, ethClient, traceClient ethereum.Client, c
 		services.TriggerExit(delay)
 	}()
 
-	txStream, err := scanner.NewTxStreamService(ctx, ethClient, blockFeed, scanner.TxStreamServiceConfig{
+	txStream, err := scanner.NewTxStreamService(ctx, dataSource.TransactionFeed(), scanner.TxStreamServiceConfig{
 		JsonRpcConfig:       cfg.Scan.JsonRpc,
 		TraceJsonRpcConfig:  cfg.Trace.JsonRpc,
 		SkipBlocksOlderThan: maxAgePtr,
@@ -133,22 +147,130 @@ func initTxStream(ctx context.Context, ethClient, traceClient ethereum.Client, c
 	return txStream, blockFeed, nil
 }
 
-// getBlockOffset either returns the default offset configured for the chain or
-// the safe offset if required.
-func getBlockOffset(cfg config.Config) int {
-	chainSettings := settings.GetChainSettings(cfg.ChainID)
+// getStartBlock returns the block to start the feed from. The start-from override requested with the CLI
+// wins over the checkpoints once, and the configured start-from is used only if there is nothing to resume.
+func getStartBlock(
+	ctx context.Context, ethClient ethereum.Client, cfg config.Config, checkpoints *scanner.EvalCheckpoints, maxAgePtr *time.Duration,
+) (*big.Int, *time.Duration) {
+	overridePath := path.Join(cfg.StateDir(), config.DefaultStartFromFileName)
+	override, err := scanner.LoadStartFrom(overridePath)
+	if err != nil {
+		log.WithError(err).Warn("failed to load the start-from override - ignoring")
+	}
+	if override != nil {
+		// the override is applied only once
+		if err := os.Remove(overridePath); err != nil {
+			log.WithError(err).Warn("failed to remove the start-from override")
+		}
+		if checkpoints != nil {
+			checkpoints.Reset()
+		}
+		return startFrom(ctx, ethClient, cfg, override, maxAgePtr)
+	}
+
+	if checkpoints != nil {
+		startBlock, resumedMaxAgePtr := resumeFromCheckpoint(ctx, ethClient, cfg, checkpoints, maxAgePtr)
+		if startBlock != nil {
+			if len(cfg.Scan.StartFrom) > 0 {
+				log.WithField("startFrom", cfg.Scan.StartFrom).Warn("resuming from the checkpoints instead of the configured start-from")
+			}
+			return startBlock, resumedMaxAgePtr
+		}
+	}
+
+	if len(cfg.Scan.StartFrom) == 0 {
+		return nil, maxAgePtr
+	}
+	sf, err := scanner.ParseStartFrom(cfg.Scan.StartFrom)
+	if err != nil {
+		log.WithError(err).Fatal("invalid scan.startFrom")
+	}
+	return startFrom(ctx, ethClient, cfg, sf, maxAgePtr)
+}
+
+// startFrom resolves the block to start from. The max block age is extended by the age of the start
+// block to avoid skipping the blocks until the latest.
+func startFrom(
+	ctx context.Context, ethClient ethereum.Client, cfg config.Config, sf *scanner.StartFrom, maxAgePtr *time.Duration,
+) (*big.Int, *time.Duration) {
+	if sf.Latest {
+		log.Info("starting from the latest block")
+		return nil, maxAgePtr
+	}
+	latestBlock, err := ethClient.BlockNumber(ctx)
+	if err != nil {
+		log.WithError(err).Warn("failed to get the latest block - starting from the latest block")
+		return nil, maxAgePtr
+	}
+	block, err := sf.ResolveBlock(ctx, ethClient, latestBlock.Uint64())
+	if err != nil {
+		log.WithError(err).WithField("startFrom", sf.String()).Warn("failed to find the start block - starting from the latest block")
+		return nil, maxAgePtr
+	}
+	blockNumber, err := hexutil.DecodeUint64(block.Number)
+	if err != nil {
+		log.WithError(err).Warn("invalid start block number - starting from the latest block")
+		return nil, maxAgePtr
+	}
+	log.WithFields(log.Fields{
+		"startFrom":   sf.String(),
+		"startBlock":  blockNumber,
+		"latestBlock": latestBlock.Uint64(),
+	}).Info("starting from the requested block")
+	if maxAgePtr != nil {
+		if blockTime, err := block.GetTimestamp(); err == nil {
+			maxAge := *maxAgePtr + time.Since(*blockTime)
+			maxAgePtr = &maxAge
+		}
+	}
+	// the feed analyzes the blocks behind the latest by the offset
+	return big.NewInt(0).SetUint64(blockNumber + uint64(getBlockOffset(cfg))), maxAgePtr
+}
 
-	if cfg.AdvancedConfig.SafeOffset {
-		return chainSettings.SafeOffset
+// resumeFromCheckpoint returns the block to start the feed from so that the bots evaluate the blocks
+// after their checkpoints again. The max block age is extended to avoid skipping the resumed blocks.
+func resumeFromCheckpoint(
+	ctx context.Context, ethClient ethereum.Client, cfg config.Config, checkpoints *scanner.EvalCheckpoints, maxAgePtr *time.Duration,
+) (*big.Int, *time.Duration) {
+	latestBlock, err := ethClient.BlockNumber(ctx)
+	if err != nil {
+		log.WithError(err).Warn("failed to get the latest block - not resuming from the evaluation checkpoints")
+		return nil, maxAgePtr
+	}
+	// the feed analyzes the blocks behind the latest by the offset
+	offset := uint64(getBlockOffset(cfg))
+	if latestBlock.Uint64() <= offset {
+		return nil, maxAgePtr
+	}
+	resume, ok := checkpoints.ResumeFrom(latestBlock.Uint64()-offset, cfg.Scan.Checkpoint.MaxResumeBlocks)
+	if !ok {
+		return nil, maxAgePtr
+	}
+	log.WithFields(log.Fields{
+		"bot":         resume.BotID,
+		"checkpoint":  resume.BlockNumber,
+		"latestBlock": latestBlock.Uint64(),
+	}).Info("resuming from the evaluation checkpoint")
+	if maxAgePtr != nil {
+		maxAge := *maxAgePtr + time.Since(resume.Time)
+		maxAgePtr = &maxAge
 	}
+	return big.NewInt(0).SetUint64(resume.BlockNumber + offset), maxAgePtr
+}
+
+// getBlockOffset returns the offset configured for the chain, the default offset of the chain
+// or the safe offset if required. The offset is at least the configured confirmation depth.
+func getBlockOffset(cfg config.Config) int {
+	chainSettings := settings.GetChainSettings(cfg.ChainID)
 
+	defaultOffset := chainSettings.DefaultOffset
 	scanURL := strings.Trim(cfg.Scan.JsonRpc.Url, "/")
 	proxyURL := strings.Trim(cfg.JsonRpcProxy.JsonRpc.Url, "/")
-	if len(proxyURL) > 0 && proxyURL != scanURL {
-		return chainSettings.SafeOffset
+	if cfg.AdvancedConfig.SafeOffset || (len(proxyURL) > 0 && proxyURL != scanURL) {
+		defaultOffset = chainSettings.SafeOffset
 	}
 
-	return chainSettings.DefaultOffset
+	return cfg.Scan.GetBlockOffset(cfg.ChainID, defaultOffset)
 }
 
 func initCombinationStream(ctx context.Context, msgClient clients.MessageClient, cfg config.Config) (*scanner.CombinerAlertStreamService, feeds.AlertFeed, error) {
@@ -166,8 +288,9 @@ func initCombinationStream(ctx context.Context, msgClient clients.MessageClient,
 
 	combinerStream, err := scanner.NewCombinerAlertStreamService(
 		ctx, combinerFeed, msgClient, scanner.CombinerAlertStreamServiceConfig{
-			Start: cfg.LocalModeConfig.RuntimeLimits.StartCombiner,
-			End:   cfg.LocalModeConfig.RuntimeLimits.StopCombiner,
+			Start:                cfg.LocalModeConfig.RuntimeLimits.StartCombiner,
+			End:                  cfg.LocalModeConfig.RuntimeLimits.StopCombiner,
+			DisableLocalDelivery: cfg.CombinerConfig.DisableLocalDelivery,
 		},
 	)
 	if err != nil {
@@ -207,21 +330,42 @@ func initCombinationStream(ctx context.Context, msgClient clients.MessageClient,
 	return combinerStream, combinerFeed, nil
 }
 
-func initTxAnalyzer(ctx context.Context, cfg config.Config, as clients.AlertSender, stream *scanner.TxStreamService, ap *agentpool.AgentPool, msgClient clients.MessageClient) (*scanner.TxAnalyzerService, error) {
+func initPendingTxStream(ctx context.Context, cfg config.Config, blockFeed feeds.BlockFeed) (*scanner.PendingTxStreamService, error) {
+	pendingTxStream, err := scanner.NewPendingTxStreamService(ctx, blockFeed, scanner.PendingTxStreamServiceConfig{
+		JsonRpcConfig: cfg.Scan.JsonRpc,
+		MempoolConfig: cfg.Scan.Mempool,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the pending tx stream service: %v", err)
+	}
+	return pendingTxStream, nil
+}
+
+func initTxAnalyzer(ctx context.Context, cfg config.Config, as clients.AlertSender, stream *scanner.TxStreamService, pendingStream *scanner.PendingTxStreamService, ap *agentpool.AgentPool, msgClient clients.MessageClient, crossCheck *scanner.CrossCheckedClient, clock *scanner.ClockSkewMonitor) (*scanner.TxAnalyzerService, error) {
+	var pendingTxChannel <-chan *domain.TransactionEvent
+	if pendingStream != nil {
+		pendingTxChannel = pendingStream.ReadOnlyPendingTxStream()
+	}
 	return scanner.NewTxAnalyzerService(ctx, scanner.TxAnalyzerServiceConfig{
-		TxChannel:   stream.ReadOnlyTxStream(),
-		AlertSender: as,
-		AgentPool:   ap,
-		MsgClient:   msgClient,
+		TxChannel:        stream.ReadOnlyTxStream(),
+		PendingTxChannel: pendingTxChannel,
+		AlertSender:      as,
+		AgentPool:        ap,
+		MsgClient:        msgClient,
+		CrossCheck:       crossCheck,
+		Clock:            clock,
 	})
 }
 
-func initBlockAnalyzer(ctx context.Context, cfg config.Config, as clients.AlertSender, stream *scanner.TxStreamService, ap *agentpool.AgentPool, msgClient clients.MessageClient) (*scanner.BlockAnalyzerService, error) {
+func initBlockAnalyzer(ctx context.Context, cfg config.Config, as clients.AlertSender, stream *scanner.TxStreamService, ap *agentpool.AgentPool, msgClient clients.MessageClient, checkpoints *scanner.EvalCheckpoints, crossCheck *scanner.CrossCheckedClient, clock *scanner.ClockSkewMonitor) (*scanner.BlockAnalyzerService, error) {
 	return scanner.NewBlockAnalyzerService(ctx, scanner.BlockAnalyzerServiceConfig{
 		BlockChannel: stream.ReadOnlyBlockStream(),
 		AlertSender:  as,
 		AgentPool:    ap,
 		MsgClient:    msgClient,
+		Checkpoints:  checkpoints,
+		CrossCheck:   crossCheck,
+		Clock:        clock,
 	})
 }
 
@@ -237,6 +381,20 @@ func initCombinerAlertAnalyzer(ctx context.Context, cfg config.Config, as client
 	)
 }
 
+func initCrossCheckedClient(ctx context.Context, ethClient ethereum.Client, cfg config.Config) (*scanner.CrossCheckedClient, error) {
+	var secondaries []ethereum.Client
+	for i, jsonRpc := range cfg.Scan.CrossValidation.JsonRpcs {
+		secondaryClient, err := scanner.NewPacedClient(
+			ctx, fmt.Sprintf("chain-secondary-%d", i), utils.ConvertToDockerHostURL(jsonRpc.Url), cfg.Scan.RateLimitPacing,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the secondary client %d: %v", i, err)
+		}
+		secondaries = append(secondaries, secondaryClient)
+	}
+	return scanner.NewCrossCheckedClient(ethClient, secondaries, cfg.Scan.CrossValidation), nil
+}
+
 func initAlertSender(ctx context.Context, key *keystore.Key, pubClient clients.PublishClient, cfg config.Config) (clients.AlertSender, error) {
 	ds, err := store.NewDeduplicationStore(cfg)
 	if err != nil {
@@ -260,6 +418,7 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 	cfg.Publish.IPFS.GatewayURL = utils.ConvertToDockerHostURL(cfg.Publish.IPFS.GatewayURL)
 	cfg.LocalModeConfig.WebhookURL = utils.ConvertToDockerHostURL(cfg.LocalModeConfig.WebhookURL)
 	msgClient := messaging.NewClient("scanner", fmt.Sprintf("%s:%s", config.DockerNatsContainerName, config.DefaultNatsPort))
+	msgClient.SetTracing(cfg.Messaging.Tracing)
 
 	key, err := config.LoadKeyInContainer(cfg)
 	if err != nil {
@@ -276,24 +435,64 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 		return nil, err
 	}
 
-	ethClient, err := ethereum.NewStreamEthClient(ctx, "chain", cfg.Scan.JsonRpc.Url)
+	ethClient, err := scanner.NewPacedClient(ctx, "chain", cfg.Scan.JsonRpc.Url, cfg.Scan.RateLimitPacing)
 	if err != nil {
 		return nil, err
 	}
 
-	traceClient, err := ethereum.NewStreamEthClient(ctx, "trace", cfg.Trace.JsonRpc.Url)
+	// compare the block data of the scan provider to the secondary providers if configured
+	var crossCheck *scanner.CrossCheckedClient
+	if len(cfg.Scan.CrossValidation.JsonRpcs) > 0 {
+		crossCheck, err = initCrossCheckedClient(ctx, ethClient, cfg)
+		if err != nil {
+			return nil, err
+		}
+		ethClient = crossCheck
+	}
+
+	traceClient, err := scanner.NewPacedClient(ctx, "trace", cfg.Trace.JsonRpc.Url, cfg.Scan.RateLimitPacing)
 	if err != nil {
 		return nil, err
 	}
 
-	txStream, blockFeed, err := initTxStream(ctx, ethClient, traceClient, cfg)
+	// the block feed gets the next block from the prefetcher while the bots evaluate the current block
+	feedEthClient, feedTraceClient := ethereum.Client(ethClient), ethereum.Client(traceClient)
+	var prefetcher *scanner.BlockPrefetcher
+	if !cfg.Scan.Prefetch.Disable {
+		prefetcher, err = scanner.NewBlockPrefetcher(ctx, ethClient, traceClient, cfg.Trace.Enabled, getBlockOffset(cfg), cfg.Scan.Prefetch)
+		if err != nil {
+			return nil, err
+		}
+		feedEthClient, feedTraceClient = prefetcher.ChainClient(), prefetcher.TraceClient()
+	}
+
+	// resume the evaluation from the checkpoints after restarts, unless scanning a range in local mode
+	var checkpoints *scanner.EvalCheckpoints
+	if cfg.Scan.Checkpoint.Enable && !(cfg.LocalModeConfig.Enable && cfg.LocalModeConfig.RuntimeLimits.StartBlock != nil) {
+		checkpoints = scanner.NewEvalCheckpoints(
+			ctx, path.Join(cfg.StateDir(), config.DefaultEvalCheckpointFileName),
+			time.Duration(cfg.Scan.Checkpoint.SaveIntervalSeconds)*time.Second,
+		)
+	}
+
+	txStream, blockFeed, err := initTxStream(ctx, feedEthClient, feedTraceClient, cfg, checkpoints)
 	if err != nil {
 		return nil, err
 	}
+	reorgTracker := scanner.NewReorgTracker(blockFeed, getBlockOffset(cfg), msgClient)
+	var anomalyDetector *scanner.AnomalyDetector
+	if !cfg.Scan.Anomaly.Disable {
+		anomalyDetector = scanner.NewAnomalyDetector(blockFeed, cfg.Scan.Anomaly, key.Address.Hex(), msgClient)
+	}
+	var clockSkewMonitor *scanner.ClockSkewMonitor
+	if !cfg.Scan.ClockSkew.Disable {
+		clockSkewMonitor = scanner.NewClockSkewMonitor(ctx, cfg.Scan.ClockSkew, key.Address.Hex(), msgClient)
+	}
 
 	var waitBots int
 	if cfg.LocalModeConfig.Enable {
 		waitBots += len(cfg.LocalModeConfig.BotImages)
+		waitBots += len(cfg.LocalModeConfig.LocalBots)
 		waitBots += len(cfg.LocalModeConfig.Standalone.BotContainers)
 		// sharded bots spawn on multiple containers, so total "wait bot" count is shards * target
 		for _, bot := range cfg.LocalModeConfig.ShardedBots {
@@ -303,24 +502,45 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 		}
 	}
 
+	var pendingTxStream *scanner.PendingTxStreamService
+	if cfg.Scan.Mempool.Enable {
+		pendingTxStream, err = initPendingTxStream(ctx, cfg, blockFeed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	combinationStream, combinationFeed, err := initCombinationStream(ctx, msgClient, cfg)
+	if err != nil {
+		return nil, err
+	}
+	// deliver the alerts to the subscriber bots on this node without waiting for the alert api
+	localAlertSender := scanner.NewLocalAlertSender(alertSender, combinationStream)
+
 	agentPool := agentpool.NewAgentPool(ctx, cfg, msgClient, waitBots)
-	txAnalyzer, err := initTxAnalyzer(ctx, cfg, alertSender, txStream, agentPool, msgClient)
+	txAnalyzer, err := initTxAnalyzer(ctx, cfg, localAlertSender, txStream, pendingTxStream, agentPool, msgClient, crossCheck, clockSkewMonitor)
 	if err != nil {
 		return nil, err
 	}
-	blockAnalyzer, err := initBlockAnalyzer(ctx, cfg, alertSender, txStream, agentPool, msgClient)
+	blockAnalyzer, err := initBlockAnalyzer(ctx, cfg, localAlertSender, txStream, agentPool, msgClient, checkpoints, crossCheck, clockSkewMonitor)
 	if err != nil {
 		return nil, err
 	}
 
-	// Start the main block feed so all transaction feeds can start consuming.
+	// Start the main block feed so all transaction feeds can start consuming, after the services
+	// which the evaluation depends on are ready.
+	startupBarrier := scanner.NewStartupBarrier(ctx, cfg.Scan.StartupBarrier,
+		scanner.MessagingReadinessCheck("json-rpc-proxy", msgClient, messaging.SubjectJSONRPCProxyReadyRequest),
+		scanner.MessagingReadinessCheck("publisher", msgClient, messaging.SubjectPublisherReadyRequest),
+		scanner.ReadinessCheck{Name: "bots", Check: agentPool.CheckReady},
+	)
 	if !cfg.Scan.DisableAutostart {
-		blockFeed.Start()
-	}
-
-	combinationStream, combinationFeed, err := initCombinationStream(ctx, msgClient, cfg)
-	if err != nil {
-		return nil, err
+		go func() {
+			startupBarrier.Wait()
+			if ctx.Err() == nil {
+				blockFeed.Start()
+			}
+		}()
 	}
 
 	registryClient, err := ethereum.NewStreamEthClient(ctx, "registry", cfg.Registry.JsonRpc.Url)
@@ -329,16 +549,34 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 	}
 	registryService := registry.New(cfg, key.Address, msgClient, registryClient, blockFeed)
 
-	combinationAnalyzer, err := initCombinerAlertAnalyzer(ctx, cfg, alertSender, combinationStream, agentPool, msgClient)
+	combinationAnalyzer, err := initCombinerAlertAnalyzer(ctx, cfg, localAlertSender, combinationStream, agentPool, msgClient)
 	if err != nil {
 		return nil, err
 	}
 
+	healthReporters := []health.Reporter{
+		ethClient, traceClient, combinationFeed, combinationStream, blockFeed, txStream, txAnalyzer, blockAnalyzer, combinationAnalyzer, agentPool, registryService,
+		publisherSvc, msgClient, reorgTracker, startupBarrier,
+	}
+	if pendingTxStream != nil {
+		healthReporters = append(healthReporters, pendingTxStream)
+	}
+	if prefetcher != nil {
+		healthReporters = append(healthReporters, prefetcher)
+	}
+	if checkpoints != nil {
+		healthReporters = append(healthReporters, checkpoints)
+	}
+	if anomalyDetector != nil {
+		healthReporters = append(healthReporters, anomalyDetector)
+	}
+	if clockSkewMonitor != nil {
+		healthReporters = append(healthReporters, clockSkewMonitor)
+	}
+
 	svcs := []services.Service{
 		health.NewService(ctx, "", healthutils.DefaultHealthServerErrHandler, health.CheckerFrom(
-			summarizeReports,
-			ethClient, traceClient, combinationFeed, blockFeed, txStream, txAnalyzer, blockAnalyzer, combinationAnalyzer, agentPool, registryService,
-			publisherSvc,
+			summarizeReports, healthReporters...,
 		)),
 		txStream,
 		txAnalyzer,
@@ -346,6 +584,17 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 		combinationStream,
 		combinationAnalyzer,
 		publisherSvc,
+		agentPool,
+	}
+
+	if pendingTxStream != nil {
+		svcs = append(svcs, pendingTxStream)
+	}
+	if checkpoints != nil {
+		svcs = append(svcs, checkpoints)
+	}
+	if clockSkewMonitor != nil {
+		svcs = append(svcs, clockSkewMonitor)
 	}
 
 	// for performance tests, this flag avoids using registry service
@@ -398,6 +647,16 @@ func summarizeReports(reports health.Reports) *health.Report {
 	}
 	summary.Punc(".")
 
+	paused, ok := reports.NameContains("tx-stream.paused")
+	if ok && paused.Details == "true" {
+		summary.Addf("scanning is paused - run 'forta resume' to resume.")
+	}
+
+	disabled, ok := reports.NameContains("agent-pool.agents.disabled")
+	if ok && len(disabled.Details) > 0 {
+		summary.Addf("disabled bots on this node: %s - run 'forta enable-bot <id>' to enable.", disabled.Details)
+	}
+
 	lastBlock, ok := reports.NameContains("block-feed.last-block")
 	if ok && len(lastBlock.Details) > 0 {
 		summary.Addf("at block %s.", lastBlock.Details)
@@ -423,6 +682,16 @@ func summarizeReports(reports health.Reports) *health.Report {
 	}
 	summary.Punc(".")
 
+	anomaly, ok := reports.NameContains("anomaly.warning")
+	if ok && anomaly.Status == health.StatusLagging {
+		summary.Addf("the block stream looks abnormal (%s) - the json-rpc provider may be degraded.", anomaly.Details)
+	}
+
+	clockSkew, ok := reports.NameContains("clock.skew")
+	if ok && clockSkew.Status == health.StatusLagging {
+		summary.Addf("the node clock is off by %s - please sync the host clock with ntp.", clockSkew.Details)
+	}
+
 	getTxReceiptErr, ok := reports.NameContains("chain-json-rpc-client.request.get-transaction-receipt.error")
 	if ok && len(getTxReceiptErr.Details) > 0 {
 		summary.Addf("failing to get transaction receipt with error '%s', this can slow down block processing.", getTxReceiptErr.Details)
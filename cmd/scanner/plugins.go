@@ -0,0 +1,7 @@
+package scanner
+
+// The chain data source plugins are compiled in by importing them here. Each plugin registers
+// itself by a name which can be selected with the scan.dataSource setting.
+import (
+	_ "github.com/forta-network/forta-node/services/scanner/datasource/evm"
+)
@@ -46,17 +46,36 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 func summarizeReports(reports health.Reports) *health.Report {
 	summary := health.NewSummary()
 
+	degraded, isDegraded := reports.NameContains("containers.degraded")
+	isDegraded = isDegraded && len(degraded.Details) > 0
+
 	containersManager, ok := reports.NameContains("containers.managed")
 	if ok {
 		count, _ := strconv.Atoi(containersManager.Details)
 		if count < config.DockerSupervisorManagedContainers {
 			summary.Addf("missing %d containers.", config.DockerSupervisorManagedContainers-count)
 			summary.Status(health.StatusFailing)
-		} else {
+		} else if !isDegraded {
 			summary.Addf("all %d service containers are running.", config.DockerSupervisorManagedContainers)
 		}
 	}
 
+	if isDegraded {
+		summary.Addf("node is degraded, not restarting: %s.", degraded.Details)
+		summary.Status(health.StatusFailing)
+	}
+
+	paused, ok := reports.NameContains("supervisor.paused")
+	if ok && paused.Details == "true" {
+		summary.Addf("node is paused - run 'forta resume' to resume.")
+	}
+
+	eligibility, ok := reports.NameContains("scanner.eligibility")
+	if ok && eligibility.Status == health.StatusFailing {
+		summary.Addf("scanner is not eligible to receive bots: %s.", eligibility.Details)
+		summary.Status(health.StatusFailing)
+	}
+
 	telemetryErr, ok := reports.NameContains("telemetry-sync.error")
 	if ok && len(telemetryErr.Details) > 0 {
 		summary.Addf("telemetry sync is failing with error '%s' (non-critical).", telemetryErr.Details)
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math/big"
+	"os"
 	"path"
 	"time"
 
@@ -68,19 +69,24 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 	if err != nil {
 		return nil, err
 	}
-	registryClient, err := store.GetRegistryClient(ctx, cfg, registry.ClientConfig{
-		JsonRpcUrl: cfg.Registry.JsonRpc.Url,
-		ENSAddress: cfg.ENSConfig.ContractAddress,
-		Name:       "updater",
-	})
-	if err != nil {
-		return nil, err
+	// the offline nodes can not reach the registry and follow the imported releases
+	var registryClient registry.Client
+	if !cfg.AutoUpdate.Offline {
+		registryClient, err = store.GetRegistryClient(ctx, cfg, registry.ClientConfig{
+			JsonRpcUrl: cfg.Registry.JsonRpc.Url,
+			ENSAddress: cfg.ENSConfig.ContractAddress,
+			Name:       "updater",
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	developmentMode := utils.ParseBoolEnvVar(config.EnvDevelopment)
 
 	log.WithFields(log.Fields{
 		"developmentMode": developmentMode,
+		"offline":         cfg.AutoUpdate.Offline,
 	}).Info("updater modes")
 
 	address, err := loadAddressFromKeyFile()
@@ -94,9 +100,15 @@ func initServices(ctx context.Context, cfg config.Config) ([]services.Service, e
 		updateDelay = *cfg.AutoUpdate.UpdateDelay
 	}
 
+	releaseChannel := cfg.AutoUpdate.GetReleaseChannel()
+	if envChannel := os.Getenv(config.EnvReleaseChannel); len(envChannel) > 0 {
+		releaseChannel = envChannel
+	}
+	log.WithField("releaseChannel", releaseChannel).Info("following the release channel")
+
 	updaterService := updater.NewUpdaterService(
 		ctx, registryClient, releaseClient, config.DefaultContainerPort,
-		developmentMode, cfg.AutoUpdate.TrackPrereleases, updateDelay, cfg.AutoUpdate.CheckIntervalSeconds,
+		developmentMode, cfg.AutoUpdate.Offline, releaseChannel, updateDelay, cfg.AutoUpdate.CheckIntervalSeconds,
 	)
 
 	return []services.Service{
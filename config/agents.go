@@ -12,6 +12,9 @@ const (
 	AgentGrpcPort = "50051"
 )
 
+// AgentCapabilityGPU is declared by the bots which need a GPU.
+const AgentCapabilityGPU = "gpu"
+
 type AgentConfig struct {
 	ID           string  `yaml:"id" json:"id"`
 	Image        string  `yaml:"image" json:"image"`
@@ -21,10 +24,22 @@ type AgentConfig struct {
 	StartBlock   *uint64 `yaml:"startBlock" json:"startBlock,omitempty"`
 	StopBlock    *uint64 `yaml:"stopBlock" json:"stopBlock,omitempty"`
 	Owner        string  `yaml:"owner "json:"owner"`
+	// the image exists only in the local docker daemon and is not pulled
+	LocalImage bool              `yaml:"localImage" json:"localImage"`
+	Build      *AgentBuildConfig `yaml:"build" json:"build,omitempty"`
+	// the node capabilities which the bot manifest declares as needed
+	Capabilities []string `yaml:"capabilities" json:"capabilities,omitempty"`
+
+	ChainID       int
+	AlertConfig   *protocol.AlertConfig
+	ShardConfig   *ShardConfig
+	ReplicaConfig *ReplicaConfig
+}
 
-	ChainID     int
-	AlertConfig *protocol.AlertConfig
-	ShardConfig *ShardConfig
+// AgentBuildConfig is used for building the agent image from the local source directory.
+type AgentBuildConfig struct {
+	Source     string `yaml:"source" json:"source"`
+	Dockerfile string `yaml:"dockerfile" json:"dockerfile"`
 }
 
 type ShardConfig struct {
@@ -33,6 +48,13 @@ type ShardConfig struct {
 	Target  uint `yaml:"target" json:"target"`
 }
 
+// ReplicaConfig is set when the bot runs in multiple containers on this node. Each replica is
+// a separate container and the transactions are partitioned between the replicas.
+type ReplicaConfig struct {
+	Replica  uint `yaml:"replica" json:"replica"`
+	Replicas uint `yaml:"replicas" json:"replicas"`
+}
+
 // ToAgentInfo transforms the agent config to the agent info.
 func (ac AgentConfig) ToAgentInfo() *protocol.AgentInfo {
 	return &protocol.AgentInfo{
@@ -53,13 +75,44 @@ func (ac AgentConfig) ContainerName() string {
 		// the container is already running - don't mess with the name
 		return ac.ID
 	}
+	var name string
 	if ac.IsLocal {
-		return fmt.Sprintf("%s-agent-%s", ContainerNamePrefix, utils.ShortenString(ac.ID, 8))
+		name = fmt.Sprintf("%s-agent-%s", ContainerNamePrefix, utils.ShortenString(ac.ID, 8))
+	} else {
+		_, digest := utils.SplitImageRef(ac.Image)
+		name = fmt.Sprintf(
+			"%s-agent-%s-%s", ContainerNamePrefix, utils.ShortenString(ac.ID, 8), utils.ShortenString(digest, 4),
+		)
 	}
-	_, digest := utils.SplitImageRef(ac.Image)
-	return fmt.Sprintf(
-		"%s-agent-%s-%s", ContainerNamePrefix, utils.ShortenString(ac.ID, 8), utils.ShortenString(digest, 4),
-	)
+	// the first replica keeps the name of the bot container
+	if replica := ac.ReplicaIndex(); replica > 0 {
+		name = fmt.Sprintf("%s-replica-%d", name, replica)
+	}
+	return name
+}
+
+// ReplicaIndex returns the index of the replica, which is zero if the bot is not replicated.
+func (ac AgentConfig) ReplicaIndex() uint {
+	if ac.ReplicaConfig == nil {
+		return 0
+	}
+	return ac.ReplicaConfig.Replica
+}
+
+// WithReplica returns the config of a replica of the bot.
+func (ac AgentConfig) WithReplica(replica, replicas uint) AgentConfig {
+	ac.ReplicaConfig = &ReplicaConfig{Replica: replica, Replicas: replicas}
+	return ac
+}
+
+// HasCapability tells if the bot needs the capability.
+func (ac AgentConfig) HasCapability(capability string) bool {
+	for _, c := range ac.Capabilities {
+		if strings.EqualFold(c, capability) {
+			return true
+		}
+	}
+	return false
 }
 
 func (ac AgentConfig) IsEqual(b AgentConfig) bool {
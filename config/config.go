@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strconv"
+	"strings"
 
 	"github.com/creasty/defaults"
 	"github.com/forta-network/forta-core-go/protocol/settings"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
 )
 
 type JsonRpcConfig struct {
@@ -16,12 +20,197 @@ type JsonRpcConfig struct {
 }
 
 type ScannerConfig struct {
-	JsonRpc              JsonRpcConfig `yaml:"jsonRpc" json:"jsonRpc"`
-	DisableAutostart     bool          `yaml:"disableAutostart" json:"disableAutostart"`
-	BlockRateLimit       int           `yaml:"blockRateLimit" json:"blockRateLimit" default:"200"`
-	BlockMaxAgeSeconds   int64         `yaml:"blockMaxAgeSeconds" json:"blockMaxAgeSeconds" default:"600"`
-	RetryIntervalSeconds int64         `yaml:"retryIntervalSeconds" json:"retryIntervalSeconds" default:"8"`
-	AlertAPIURL          string        `yaml:"apiUrl" json:"apiUrl" default:"https://api.forta.network/graphql" validate:"url"`
+	JsonRpc                 JsonRpcConfig `yaml:"jsonRpc" json:"jsonRpc"`
+	DisableAutostart        bool          `yaml:"disableAutostart" json:"disableAutostart"`
+	BlockRateLimit          int           `yaml:"blockRateLimit" json:"blockRateLimit" default:"200"`
+	BlockMaxAgeSeconds      int64         `yaml:"blockMaxAgeSeconds" json:"blockMaxAgeSeconds" default:"600"`
+	RetryIntervalSeconds    int64         `yaml:"retryIntervalSeconds" json:"retryIntervalSeconds" default:"8"`
+	AlertAPIURL             string        `yaml:"apiUrl" json:"apiUrl" default:"https://api.forta.network/graphql" validate:"url"`
+	BotWarmUpTimeoutSeconds int           `yaml:"botWarmUpTimeoutSeconds" json:"botWarmUpTimeoutSeconds" default:"300"`
+	// the tx results of a block which arrive later than this after the block is dispatched are tagged
+	// as late, zero disables the deadline
+	ResultDeadlineSeconds int `yaml:"resultDeadlineSeconds" json:"resultDeadlineSeconds" default:"15" validate:"min=0"`
+	// the shortest interval which the bots can request to evaluate the latest block at
+	MinTickIntervalSeconds int `yaml:"minTickIntervalSeconds" json:"minTickIntervalSeconds" default:"60" validate:"min=1"`
+	// the name of the registered data source plugin which provides the blocks and the transactions
+	DataSource string `yaml:"dataSource" json:"dataSource" default:"evm" validate:"required"`
+	// where to start scanning from when there are no checkpoints to resume from: "latest", a block
+	// number or an RFC3339 timestamp - use 'forta run --start-from' to override the checkpoints once
+	StartFrom string `yaml:"startFrom" json:"startFrom,omitempty"`
+
+	PayloadLimits   PayloadLimitsConfig    `yaml:"payloadLimits" json:"payloadLimits"`
+	Mempool         MempoolConfig          `yaml:"mempool" json:"mempool"`
+	RateLimitPacing RateLimitPacingConfig  `yaml:"rateLimitPacing" json:"rateLimitPacing"`
+	CrossValidation CrossValidationConfig  `yaml:"crossValidation" json:"crossValidation"`
+	Prefetch        BlockPrefetchConfig    `yaml:"prefetch" json:"prefetch"`
+	Checkpoint      EvalCheckpointConfig   `yaml:"checkpoint" json:"checkpoint"`
+	Canary          CanaryConfig           `yaml:"canary" json:"canary"`
+	Replicas        BotReplicasConfig      `yaml:"replicas" json:"replicas"`
+	Anomaly         AnomalyDetectionConfig `yaml:"anomaly" json:"anomaly"`
+	ClockSkew       ClockSkewConfig        `yaml:"clockSkew" json:"clockSkew"`
+	StartupBarrier  StartupBarrierConfig   `yaml:"startupBarrier" json:"startupBarrier"`
+
+	// the minimum number of confirmations a block needs before it is evaluated
+	ConfirmationDepth int `yaml:"confirmationDepth" json:"confirmationDepth" validate:"min=0"`
+	// overrides the default block offsets by the chain ID
+	ChainOffsets map[int]int `yaml:"chainOffsets" json:"chainOffsets"`
+}
+
+// GetBlockOffset returns the offset configured for the chain or the default offset.
+// The offset is never less than the confirmation depth.
+func (sc ScannerConfig) GetBlockOffset(chainID int, defaultOffset int) int {
+	offset := defaultOffset
+	if chainOffset, ok := sc.ChainOffsets[chainID]; ok {
+		offset = chainOffset
+	}
+	if offset < sc.ConfirmationDepth {
+		offset = sc.ConfirmationDepth
+	}
+	return offset
+}
+
+// RateLimitPacingConfig controls how the scanner slows down the block and trace requests
+// when the JSON-RPC provider starts rate limiting.
+type RateLimitPacingConfig struct {
+	Disable         bool `yaml:"disable" json:"disable"`
+	MinDelayMs      int  `yaml:"minDelayMs" json:"minDelayMs" default:"250" validate:"min=1"`
+	MaxDelaySeconds int  `yaml:"maxDelaySeconds" json:"maxDelaySeconds" default:"60" validate:"min=1"`
+}
+
+// CrossValidationConfig enables fetching the blocks also from the secondary JSON-RPC providers and
+// comparing them to the data of the scan provider. On divergence, the data which the majority of the
+// providers agree on is used and the scan provider wins the ties.
+type CrossValidationConfig struct {
+	JsonRpcs        []JsonRpcConfig `yaml:"jsonRpcs" json:"jsonRpcs" validate:"dive"`
+	CompareReceipts bool            `yaml:"compareReceipts" json:"compareReceipts"`
+	TimeoutSeconds  int             `yaml:"timeoutSeconds" json:"timeoutSeconds" default:"10" validate:"min=1"`
+}
+
+// StartupBarrierConfig holds the block feed after the scanner starts until the JSON-RPC proxy, the publisher
+// and at least one bot report ready, so that the first blocks are not dropped. The scanning starts anyway
+// after the timeout, e.g. when there are no bots assigned yet.
+type StartupBarrierConfig struct {
+	Disable        bool `yaml:"disable" json:"disable"`
+	TimeoutSeconds int  `yaml:"timeoutSeconds" json:"timeoutSeconds" default:"300" validate:"min=1"`
+}
+
+// EvalCheckpointConfig enables persisting the last block which each bot evaluated. After a restart, the
+// scanning resumes from the oldest checkpoint so the blocks which were in flight are evaluated at least once.
+type EvalCheckpointConfig struct {
+	Enable bool `yaml:"enable" json:"enable"`
+	// the max number of blocks to go back from the latest block when resuming
+	MaxResumeBlocks     int `yaml:"maxResumeBlocks" json:"maxResumeBlocks" default:"100" validate:"min=1"`
+	SaveIntervalSeconds int `yaml:"saveIntervalSeconds" json:"saveIntervalSeconds" default:"5" validate:"min=1"`
+}
+
+// CanaryBotID is the ID of the built-in canary bot.
+const CanaryBotID = "forta-node-canary"
+
+// CanaryConfig enables the synthetic end-to-end evaluations. A marked transaction is sent through the
+// pipeline to the built-in canary bot periodically and the publisher measures how long the canary
+// finding takes to arrive. The canary findings are never published.
+type CanaryConfig struct {
+	Enable          bool `yaml:"enable" json:"enable"`
+	IntervalSeconds int  `yaml:"intervalSeconds" json:"intervalSeconds" default:"60" validate:"min=10"`
+	// the publisher health fails if no canary finding arrives for this long
+	TimeoutSeconds int `yaml:"timeoutSeconds" json:"timeoutSeconds" default:"300" validate:"min=10"`
+}
+
+// BlockPrefetchConfig controls fetching the data of the next block while the current block
+// is being evaluated.
+type BlockPrefetchConfig struct {
+	Disable   bool `yaml:"disable" json:"disable"`
+	CacheSize int  `yaml:"cacheSize" json:"cacheSize" default:"8" validate:"min=1"`
+}
+
+// MempoolConfig enables sending the pending transactions to the bots which opt in. Not all JSON-RPC
+// providers support the pending transaction filters and subscriptions so this is disabled by default.
+type MempoolConfig struct {
+	Enable              bool     `yaml:"enable" json:"enable"`
+	Subscribe           bool     `yaml:"subscribe" json:"subscribe"`
+	PollIntervalSeconds int      `yaml:"pollIntervalSeconds" json:"pollIntervalSeconds" default:"2" validate:"min=1"`
+	Bots                []string `yaml:"bots" json:"bots"`
+}
+
+// IsBotEnabled tells if the bot opted in to receive the pending transactions.
+func (cfg MempoolConfig) IsBotEnabled(botID string) bool {
+	if !cfg.Enable {
+		return false
+	}
+	for _, bot := range cfg.Bots {
+		if strings.EqualFold(bot, botID) {
+			return true
+		}
+	}
+	return false
+}
+
+// BotReplicasConfig runs the heavy bots in multiple containers on this node, to keep up with the chains
+// which have many transactions. The transactions are partitioned between the ready replicas of a bot by
+// the tx hash and the blocks and the alerts are sent to the first replica only. Each replica gets its own
+// copy of the volumes of the bot, which starts empty, and the first replica keeps the bot volumes.
+type BotReplicasConfig struct {
+	// the number of the replicas by the bot ID, one replica by default
+	Bots      map[string]int         `yaml:"bots" json:"bots" validate:"dive,min=1"`
+	AutoScale ReplicaAutoScaleConfig `yaml:"autoScale" json:"autoScale"`
+}
+
+// GetReplicas returns the configured number of the replicas of a bot.
+func (cfg BotReplicasConfig) GetReplicas(botID string) int {
+	for bot, replicas := range cfg.Bots {
+		if strings.EqualFold(bot, botID) {
+			return replicas
+		}
+	}
+	return 1
+}
+
+// ReplicaAutoScaleConfig adds replicas to the bots which fall behind by their tx queue depth and removes
+// them when the queues are mostly empty. The configured replicas of a bot are the minimum.
+type ReplicaAutoScaleConfig struct {
+	Enable      bool `yaml:"enable" json:"enable"`
+	MaxReplicas int  `yaml:"maxReplicas" json:"maxReplicas" default:"4" validate:"min=1"`
+	// a replica is added when the tx queues of the replicas are fuller than this on average
+	ScaleUpPercent int `yaml:"scaleUpPercent" json:"scaleUpPercent" default:"50" validate:"min=1,max=100"`
+	// a replica is removed when the tx queues of the replicas are emptier than this on average
+	ScaleDownPercent int `yaml:"scaleDownPercent" json:"scaleDownPercent" default:"5" validate:"min=0,max=100,ltfield=ScaleUpPercent"`
+	IntervalSeconds  int `yaml:"intervalSeconds" json:"intervalSeconds" default:"60" validate:"min=1"`
+}
+
+// AnomalyDetectionConfig flags the abnormal changes in the block stream as health warnings. They usually
+// mean that the JSON-RPC provider is silently degraded, e.g. returning blocks with fewer transactions,
+// blocks without logs or skipping blocks.
+type AnomalyDetectionConfig struct {
+	Disable bool `yaml:"disable" json:"disable"`
+	// the number of the recent blocks which the tx count of a block is compared to
+	WindowBlocks int `yaml:"windowBlocks" json:"windowBlocks" default:"100" validate:"min=10"`
+	// a block is flagged if it has fewer transactions than this percentage of the recent average
+	TxDropPercent int `yaml:"txDropPercent" json:"txDropPercent" default:"20" validate:"min=1,max=99"`
+	// the number of the consecutive blocks with transactions but without any logs which is flagged
+	EmptyLogStreak int `yaml:"emptyLogStreak" json:"emptyLogStreak" default:"20" validate:"min=1"`
+	// how long the last anomaly is reported as a warning
+	WarningSeconds int `yaml:"warningSeconds" json:"warningSeconds" default:"900" validate:"min=1"`
+}
+
+// ClockSkewConfig configures measuring the offset of the node clock from the NTP servers. The offset
+// is compensated in the latency metrics.
+type ClockSkewConfig struct {
+	Disable bool `yaml:"disable" json:"disable"`
+	// the NTP servers which are queried in order until one responds
+	Servers []string `yaml:"servers" json:"servers" default:"[\"pool.ntp.org\",\"time.google.com\"]" validate:"dive,required"`
+	// how often the offset is measured
+	IntervalSeconds int `yaml:"intervalSeconds" json:"intervalSeconds" default:"300" validate:"min=10"`
+	TimeoutSeconds  int `yaml:"timeoutSeconds" json:"timeoutSeconds" default:"5" validate:"min=1"`
+	// the node clock is reported as skewed if the offset is larger than this
+	MaxSkewMs int64 `yaml:"maxSkewMs" json:"maxSkewMs" default:"1000" validate:"min=1"`
+}
+
+// PayloadLimitsConfig contains the max sizes (in bytes) of the events sent to the bots. The events which
+// are larger are trimmed before sending.
+type PayloadLimitsConfig struct {
+	MaxTxBytes    int `yaml:"maxTxBytes" json:"maxTxBytes" default:"4000000" validate:"min=1024"`
+	MaxBlockBytes int `yaml:"maxBlockBytes" json:"maxBlockBytes" default:"4000000" validate:"min=1024"`
+	MaxAlertBytes int `yaml:"maxAlertBytes" json:"maxAlertBytes" default:"4000000" validate:"min=1024"`
 }
 
 type TraceConfig struct {
@@ -35,8 +224,79 @@ type RateLimitConfig struct {
 }
 
 type JsonRpcProxyConfig struct {
-	JsonRpc         JsonRpcConfig    `yaml:"jsonRpc" json:"jsonRpc"`
-	RateLimitConfig *RateLimitConfig `yaml:"rateLimit" json:"rateLimit"`
+	JsonRpc          JsonRpcConfig    `yaml:"jsonRpc" json:"jsonRpc"`
+	RateLimitConfig  *RateLimitConfig `yaml:"rateLimit" json:"rateLimit"`
+	MaxBatchSize     int              `yaml:"maxBatchSize" json:"maxBatchSize" default:"100" validate:"min=1"`
+	BatchConcurrency int              `yaml:"batchConcurrency" json:"batchConcurrency" default:"4" validate:"min=1"`
+
+	// disables converting the upstream errors to consistent codes and classifying them in the
+	// X-Forta-Error-Class header
+	DisableErrorNormalization bool `yaml:"disableErrorNormalization" json:"disableErrorNormalization"`
+
+	// disables answering the requests with the X-Forta-Block-Number header or the blockNumber query param
+	// from the pinned block instead of the latest block
+	DisableBlockPinning bool `yaml:"disableBlockPinning" json:"disableBlockPinning"`
+
+	// the bot requests are balanced between the providers when specified, instead of using
+	// the jsonRpc or the scan endpoint
+	Providers      []JsonRpcConfig           `yaml:"providers" json:"providers" validate:"dive"`
+	ProviderHealth ProviderHealthCheckConfig `yaml:"providerHealth" json:"providerHealth"`
+
+	Usage         RPCUsageConfig      `yaml:"usage" json:"usage"`
+	ResponseCache ResponseCacheConfig `yaml:"responseCache" json:"responseCache"`
+	Archive       ArchiveConfig       `yaml:"archive" json:"archive"`
+	GraphQL       GraphQLConfig       `yaml:"graphql" json:"graphql"`
+
+	// serves canned responses from the JSON fixture files in this dir (relative to the Forta dir) and
+	// passes the other requests upstream - only in local mode, for testing the bots with synthetic data
+	FixturesDir string `yaml:"fixturesDir" json:"fixturesDir"`
+}
+
+// ResponseCacheConfig configures the short-lived cache of the responses which are pinned to a block,
+// so that the bots which request the same block data do not all reach the upstream provider.
+type ResponseCacheConfig struct {
+	Disable    bool `yaml:"disable" json:"disable"`
+	Size       int  `yaml:"size" json:"size" default:"1000" validate:"min=1"`
+	TTLSeconds int  `yaml:"ttlSeconds" json:"ttlSeconds" default:"30" validate:"min=1"`
+}
+
+// ArchiveConfig configures routing the requests for the old state to an archive node, so that
+// a cheaper full node can serve the recent state. It is disabled when the URL is empty.
+type ArchiveConfig struct {
+	JsonRpc JsonRpcConfig `yaml:"jsonRpc" json:"jsonRpc"`
+	// the requests for the state of the blocks older than this many blocks from the head go to the archive node
+	RecentBlocks int `yaml:"recentBlocks" json:"recentBlocks" default:"128" validate:"min=1"`
+}
+
+// GraphQLConfig enables the /graphql endpoint of the proxy for the bots which query the chain data with
+// GraphQL. The queries are proxied to the upstream GraphQL endpoint if specified, e.g. the /graphql endpoint
+// of Geth, or a subset of the EIP-1767 schema is translated to the JSON-RPC requests. The translated requests
+// are rate limited and accounted per request and the proxied queries cost the "graphql" method weight.
+type GraphQLConfig struct {
+	Enable   bool          `yaml:"enable" json:"enable"`
+	Upstream JsonRpcConfig `yaml:"upstream" json:"upstream"`
+	// the max number of JSON-RPC requests which a translated query can make
+	MaxRequests int `yaml:"maxRequests" json:"maxRequests" default:"100" validate:"min=1"`
+}
+
+// RPCUsageConfig configures the per-bot accounting of the proxied requests. Each request costs
+// compute units by its method so that the upstream costs can be attributed to the bots.
+type RPCUsageConfig struct {
+	// overrides the default compute units of the methods
+	MethodWeights  map[string]int `yaml:"methodWeights" json:"methodWeights" validate:"dive,min=0"`
+	RetentionHours int            `yaml:"retentionHours" json:"retentionHours" default:"720" validate:"min=1"`
+}
+
+// ProviderHealthCheckConfig configures the checks which eject the failing providers.
+type ProviderHealthCheckConfig struct {
+	IntervalSeconds    int `yaml:"intervalSeconds" json:"intervalSeconds" default:"15" validate:"min=1"`
+	TimeoutSeconds     int `yaml:"timeoutSeconds" json:"timeoutSeconds" default:"5" validate:"min=1"`
+	EjectAfterFailures int `yaml:"ejectAfterFailures" json:"ejectAfterFailures" default:"3" validate:"min=1"`
+	// while all providers are ejected, the requests fail right away except for one request in this
+	// interval which is sent to an ejected provider as a probe
+	ProbeIntervalSeconds int `yaml:"probeIntervalSeconds" json:"probeIntervalSeconds" default:"5" validate:"min=0"`
+	// keeps sending all requests to the ejected providers when all providers are ejected
+	DisableCircuitBreaker bool `yaml:"disableCircuitBreaker" json:"disableCircuitBreaker"`
 }
 
 type LogConfig struct {
@@ -46,14 +306,45 @@ type LogConfig struct {
 }
 
 type RegistryConfig struct {
-	ChainID              uint64        `yaml:"chainId" json:"chainId" default:"137"`
-	JsonRpc              JsonRpcConfig `yaml:"jsonRpc" json:"jsonRpc" default:"{\"url\": \"https://rpc.ankr.com/polygon\"}"`
-	IPFS                 IPFSConfig    `yaml:"ipfs" json:"ipfs"`
-	ContainerRegistry    string        `yaml:"containerRegistry" json:"containerRegistry" validate:"hostname|hostname_port" default:"disco.forta.network" `
-	Username             string        `yaml:"username" json:"username"`
-	Password             string        `yaml:"password" json:"password"`
-	Disable              bool          `yaml:"disable" json:"disable"` // for testing situations
-	CheckIntervalSeconds int           `yaml:"checkIntervalSeconds" json:"checkIntervalSeconds" default:"15"`
+	ChainID              uint64         `yaml:"chainId" json:"chainId" default:"137"`
+	JsonRpc              JsonRpcConfig  `yaml:"jsonRpc" json:"jsonRpc" default:"{\"url\": \"https://rpc.ankr.com/polygon\"}"`
+	IPFS                 IPFSConfig     `yaml:"ipfs" json:"ipfs"`
+	ContainerRegistry    string         `yaml:"containerRegistry" json:"containerRegistry" validate:"hostname|hostname_port" default:"disco.forta.network" `
+	Username             string         `yaml:"username" json:"username"`
+	Password             string         `yaml:"password" json:"password"`
+	Disable              bool           `yaml:"disable" json:"disable"` // for testing situations
+	CheckIntervalSeconds int            `yaml:"checkIntervalSeconds" json:"checkIntervalSeconds" default:"15"`
+	Manifest             ManifestConfig `yaml:"manifest" json:"manifest"`
+	// switches to one of the alternate registries, the default registry is used if empty
+	Profile  string                           `yaml:"profile" json:"profile"`
+	Profiles map[string]RegistryProfileConfig `yaml:"profiles" json:"profiles" validate:"dive"`
+}
+
+// RegistryProfileConfig is an alternate registry like a testnet or a staging registry. The empty values
+// are taken from the default registry config. The node state which depends on the registry is kept in
+// a separate directory for each profile.
+type RegistryProfileConfig struct {
+	ChainID            uint64        `yaml:"chainId" json:"chainId"`
+	JsonRpc            JsonRpcConfig `yaml:"jsonRpc" json:"jsonRpc"`
+	ENSContractAddress string        `yaml:"ensContractAddress" json:"ensContractAddress" validate:"required,eth_addr"`
+	ContainerRegistry  string        `yaml:"containerRegistry" json:"containerRegistry" validate:"omitempty,hostname|hostname_port"`
+}
+
+type ManifestConfig struct {
+	FallbackGatewayURLs []string             `yaml:"fallbackGatewayUrls" json:"fallbackGatewayUrls" validate:"dive,url"`
+	AllowedGatewayHosts []string             `yaml:"allowedGatewayHosts" json:"allowedGatewayHosts"`
+	DisableCache        bool                 `yaml:"disableCache" json:"disableCache"`
+	Policy              ManifestPolicyConfig `yaml:"policy" json:"policy"`
+}
+
+// ManifestPolicyConfig is the local policy which the bot manifests should conform to. The bots
+// which do not conform are rejected before their containers are started.
+type ManifestPolicyConfig struct {
+	RequiredFields []string `yaml:"requiredFields" json:"requiredFields" validate:"dive,oneof=from name agentIdHash version timestamp repository documentation chainIds"`
+	// the registry hosts which the manifest image references can point to
+	AllowedRegistries []string `yaml:"allowedRegistries" json:"allowedRegistries"`
+	// zero means no limit
+	MaxImageSizeMB int `yaml:"maxImageSizeMb" json:"maxImageSizeMb" validate:"min=0"`
 }
 
 type IPFSConfig struct {
@@ -66,22 +357,311 @@ type IPFSConfig struct {
 type BatchConfig struct {
 	SkipEmpty                    bool `yaml:"skipEmpty" json:"skipEmpty"`
 	IntervalSeconds              *int `yaml:"intervalSeconds" json:"intervalSeconds" default:"15"`
-	MetricsBucketIntervalSeconds *int `yaml:"metricsBucketIntervalSeconds" json:"metricsBucketIntervalSeconds" default:"60"`
+	MetricsBucketIntervalSeconds *int `yaml:"metricsBucketIntervalSeconds" json:"metricsBucketIntervalSeconds" default:"60" validate:"omitempty,min=10"`
 	MaxAlerts                    *int `yaml:"maxAlerts" json:"maxAlerts" default:"1000" `
+	// the max number of open metric buckets per bot and the max number of samples kept per metric for the percentiles
+	MetricsMaxBucketsPerBot int `yaml:"metricsMaxBucketsPerBot" json:"metricsMaxBucketsPerBot" default:"10" validate:"min=1"`
+	MetricsMaxSamples       int `yaml:"metricsMaxSamples" json:"metricsMaxSamples" default:"500" validate:"min=1"`
+
+	AutoTune BatchAutoTuneConfig `yaml:"autoTune" json:"autoTune"`
+}
+
+// BatchAutoTuneConfig contains the floors and the ceilings for auto-tuning the batch interval and size.
+type BatchAutoTuneConfig struct {
+	Enable             bool `yaml:"enable" json:"enable"`
+	MinIntervalSeconds int  `yaml:"minIntervalSeconds" json:"minIntervalSeconds" default:"5" validate:"min=1"`
+	MaxIntervalSeconds int  `yaml:"maxIntervalSeconds" json:"maxIntervalSeconds" default:"60" validate:"gtefield=MinIntervalSeconds"`
+	MinAlerts          int  `yaml:"minAlerts" json:"minAlerts" default:"50" validate:"min=1"`
+	MaxAlerts          int  `yaml:"maxAlerts" json:"maxAlerts" default:"5000" validate:"gtefield=MinAlerts"`
+}
+
+// FindingQuotaConfig limits the findings which are published per bot. The findings over the quota
+// are dropped, except for the sampled ones. Zero quota means no limit and the quota is disabled by default.
+type FindingQuotaConfig struct {
+	FindingsPerMinute int            `yaml:"findingsPerMinute" json:"findingsPerMinute" validate:"min=0"`
+	SampleRate        float64        `yaml:"sampleRate" json:"sampleRate" validate:"min=0,max=1"`
+	Bots              map[string]int `yaml:"bots" json:"bots" validate:"dive,min=0"`
+}
+
+// GetFindingsPerMinute returns the quota of a bot.
+func (qc FindingQuotaConfig) GetFindingsPerMinute(botID string) int {
+	for id, quota := range qc.Bots {
+		if strings.EqualFold(id, botID) {
+			return quota
+		}
+	}
+	return qc.FindingsPerMinute
+}
+
+// FindingSamplingConfig publishes only a part of the findings of the noisy bots. The skipped findings
+// are counted in the batch metrics.
+type FindingSamplingConfig struct {
+	Bots map[string]BotSamplingConfig `yaml:"bots" json:"bots" validate:"dive"`
+}
+
+// BotSamplingConfig keeps one in every N findings of a bot which are below a severity.
+type BotSamplingConfig struct {
+	KeepOneIn int `yaml:"keepOneIn" json:"keepOneIn" validate:"min=1"`
+	// the findings with this severity or higher are always kept (default is HIGH)
+	AlwaysKeepSeverity string `yaml:"alwaysKeepSeverity" json:"alwaysKeepSeverity" validate:"omitempty,oneof=INFO LOW MEDIUM HIGH CRITICAL"`
+}
+
+// GetBotSampling returns the sampling config of a bot.
+func (sc FindingSamplingConfig) GetBotSampling(botID string) (BotSamplingConfig, bool) {
+	for id, botCfg := range sc.Bots {
+		if strings.EqualFold(id, botID) {
+			return botCfg, true
+		}
+	}
+	return BotSamplingConfig{}, false
+}
+
+// FindingSeverityConfig skips publishing the findings below a severity, globally or per bot. The skipped
+// findings are still counted in the batch metrics.
+type FindingSeverityConfig struct {
+	MinSeverity string            `yaml:"minSeverity" json:"minSeverity" validate:"omitempty,oneof=INFO LOW MEDIUM HIGH CRITICAL"`
+	Bots        map[string]string `yaml:"bots" json:"bots" validate:"dive,oneof=UNKNOWN INFO LOW MEDIUM HIGH CRITICAL"`
+}
+
+// GetMinSeverity returns the severity floor of a bot. The bot config overrides the global one.
+func (sc FindingSeverityConfig) GetMinSeverity(botID string) string {
+	for id, minSeverity := range sc.Bots {
+		if strings.EqualFold(id, botID) {
+			return minSeverity
+		}
+	}
+	return sc.MinSeverity
+}
+
+// Finding processors
+const (
+	FindingProcessorAddressLabels = "address-labels"
+	FindingProcessorChainMetadata = "chain-metadata"
+	FindingProcessorRedact        = "redact"
+)
+
+// FindingProcessorsConfig enables the processors which modify the findings before they are batched.
+// The processors run in the listed order.
+type FindingProcessorsConfig struct {
+	Enable []string `yaml:"enable" json:"enable" validate:"dive,oneof=address-labels chain-metadata redact"`
+	// a YAML or JSON file which maps the addresses to the labels (relative to the Forta dir)
+	AddressLabelsFile string `yaml:"addressLabelsFile" json:"addressLabelsFile"`
+	// description, addresses or metadata.<key>
+	RedactFields []string `yaml:"redactFields" json:"redactFields" validate:"dive,required"`
+}
+
+// PrivateAlertsConfig routes the private findings only to an operator endpoint so that they are
+// never included in the public batches.
+type PrivateAlertsConfig struct {
+	Enable     bool   `yaml:"enable" json:"enable"`
+	WebhookURL string `yaml:"webhookUrl" json:"webhookUrl" validate:"omitempty,url"`
+}
+
+// CosignerConfig is a key which signs the alert batches together with the scanner key. The key is either
+// loaded from a local key dir or a remote cosigner service is asked to sign the batch digest.
+type CosignerConfig struct {
+	Name string `yaml:"name" json:"name" validate:"required"`
+	// an absolute dir on the host, outside the Forta dir, which is mounted only to the scanner container
+	KeyDir string `yaml:"keyDir" json:"keyDir" validate:"required_without=URL,omitempty,startswith=/"`
+	// relative to the key dir
+	PassphraseFile string `yaml:"passphraseFile" json:"passphraseFile" validate:"required_with=KeyDir"`
+	URL            string `yaml:"url" json:"url" validate:"required_without=KeyDir,excluded_with=KeyDir,omitempty,url"`
+	// the expected signer address of the remote cosigner
+	Address        string            `yaml:"address" json:"address" validate:"required_with=URL,omitempty,eth_addr"`
+	Headers        map[string]string `yaml:"headers" json:"headers"`
+	TimeoutSeconds int               `yaml:"timeoutSeconds" json:"timeoutSeconds" default:"10" validate:"min=1"`
+}
+
+// BatchSigningConfig makes the alert batches signed also by the cosigners. The batch is not published
+// unless the threshold number of cosignatures are collected. Zero threshold requires all cosigners.
+type BatchSigningConfig struct {
+	Cosigners []CosignerConfig `yaml:"cosigners" json:"cosigners" validate:"dive"`
+	Threshold int              `yaml:"threshold" json:"threshold" validate:"min=0"`
+}
+
+// CheckKeyDirs makes sure that the local cosigner keys are kept outside the Forta dir, which is mounted
+// to all node containers, so that the batches can not be cosigned with the node files only.
+func (cfg BatchSigningConfig) CheckKeyDirs(fortaDir string) error {
+	for _, cosigner := range cfg.Cosigners {
+		if len(cosigner.KeyDir) == 0 {
+			continue
+		}
+		if !path.IsAbs(cosigner.KeyDir) {
+			return fmt.Errorf("the key dir of cosigner %s must be an absolute path", cosigner.Name)
+		}
+		keyDir := path.Clean(cosigner.KeyDir)
+		fortaDir := path.Clean(fortaDir)
+		if keyDir == fortaDir || strings.HasPrefix(keyDir, fortaDir+"/") {
+			return fmt.Errorf("the key dir of cosigner %s must be outside the forta dir", cosigner.Name)
+		}
+	}
+	return nil
+}
+
+// CosignerContainerKeyDir returns the path which the key dir of the local cosigner at the index is
+// mounted to in the scanner container.
+func CosignerContainerKeyDir(index int) string {
+	return path.Join(DefaultContainerCosignerKeysPath, strconv.Itoa(index))
+}
+
+// BatchExportConfig uploads the published batches and their receipts to an S3-compatible bucket.
+// The objects are partitioned by the chain and the publish date. The bucket is addressed in the
+// path style (<endpoint>/<bucket>/<key>) so that it works also with MinIO and similar services.
+type BatchExportConfig struct {
+	Enable   bool   `yaml:"enable" json:"enable"`
+	Endpoint string `yaml:"endpoint" json:"endpoint" validate:"required_if=Enable true,omitempty,url"`
+	Region   string `yaml:"region" json:"region" default:"us-east-1"`
+	Bucket   string `yaml:"bucket" json:"bucket" validate:"required_if=Enable true"`
+	Prefix   string `yaml:"prefix" json:"prefix"`
+	// the requests are not signed if the keys are not set
+	AccessKeyID     string `yaml:"accessKeyId" json:"accessKeyId" validate:"required_with=SecretAccessKey"`
+	SecretAccessKey string `yaml:"secretAccessKey" json:"secretAccessKey" validate:"required_with=AccessKeyID"`
+	TimeoutSeconds  int    `yaml:"timeoutSeconds" json:"timeoutSeconds" default:"30" validate:"min=1"`
+}
+
+// FindingIndexConfig keeps the last published findings in the Forta dir, so that the operators can
+// list them with 'forta alerts list'.
+type FindingIndexConfig struct {
+	Disable     bool `yaml:"disable" json:"disable"`
+	MaxFindings int  `yaml:"maxFindings" json:"maxFindings" default:"10000" validate:"min=1"`
 }
 
 type PublisherConfig struct {
-	SkipPublish   bool        `yaml:"skipPublish" json:"skipPublish" default:"false"`
-	AlwaysPublish bool        `yaml:"alwaysPublish" json:"alwaysPublish" default:"false"`
-	APIURL        string      `yaml:"apiUrl" json:"apiUrl" default:"https://alerts.forta.network" validate:"url"`
-	IPFS          IPFSConfig  `yaml:"ipfs" json:"ipfs" validate:"required_unless=SkipPublish true"`
-	Batch         BatchConfig `yaml:"batch" json:"batch"`
+	SkipPublish   bool                    `yaml:"skipPublish" json:"skipPublish" default:"false"`
+	AlwaysPublish bool                    `yaml:"alwaysPublish" json:"alwaysPublish" default:"false"`
+	APIURL        string                  `yaml:"apiUrl" json:"apiUrl" default:"https://alerts.forta.network" validate:"url"`
+	IPFS          IPFSConfig              `yaml:"ipfs" json:"ipfs" validate:"required_unless=SkipPublish true"`
+	Batch         BatchConfig             `yaml:"batch" json:"batch"`
+	Quota         FindingQuotaConfig      `yaml:"quota" json:"quota"`
+	Sampling      FindingSamplingConfig   `yaml:"sampling" json:"sampling"`
+	Severity      FindingSeverityConfig   `yaml:"severity" json:"severity"`
+	Processors    FindingProcessorsConfig `yaml:"processors" json:"processors"`
+	Private       PrivateAlertsConfig     `yaml:"private" json:"private"`
+	Signing       BatchSigningConfig      `yaml:"signing" json:"signing"`
+	Export        BatchExportConfig       `yaml:"export" json:"export"`
+	FindingIndex  FindingIndexConfig      `yaml:"findingIndex" json:"findingIndex"`
 }
 
 type ResourcesConfig struct {
 	DisableAgentLimits bool    `yaml:"disableAgentLimits" json:"disableAgentLimits" default:"false" `
 	AgentMaxMemoryMiB  int     `yaml:"agentMaxMemoryMib" json:"agentMaxMemoryMib" validate:"omitempty,min=100"`
 	AgentMaxCPUs       float64 `yaml:"agentMaxCpus" json:"agentMaxCpus" validate:"omitempty,gt=0"`
+	// the bandwidth limits are applied with tc in the network of each agent container and do not apply
+	// to the traffic between the agent and the node containers
+	AgentMaxEgressMbps  float64 `yaml:"agentMaxEgressMbps" json:"agentMaxEgressMbps" validate:"omitempty,gt=0"`
+	AgentMaxIngressMbps float64 `yaml:"agentMaxIngressMbps" json:"agentMaxIngressMbps" validate:"omitempty,gt=0"`
+
+	Admission AdmissionConfig `yaml:"admission" json:"admission"`
+
+	// the shared memory, the tmpfs mounts and the ulimits of the agent containers and the overrides per bot
+	AgentContainer AgentContainerResources            `yaml:"agentContainer" json:"agentContainer"`
+	Bots           map[string]AgentContainerResources `yaml:"bots" json:"bots" validate:"dive"`
+
+	GPU AgentGPUConfig `yaml:"gpu" json:"gpu"`
+}
+
+// AgentGPUConfig passes the GPUs of the host to the bots which declare the "gpu" capability in their
+// manifests and to the listed bots. The host needs the NVIDIA container runtime.
+type AgentGPUConfig struct {
+	Enable bool `yaml:"enable" json:"enable"`
+	// the container runtime which exposes the GPUs
+	Runtime string `yaml:"runtime" json:"runtime" default:"nvidia" validate:"required_if=Enable true"`
+	// the indexes or the UUIDs of the GPUs which the bots can use, all GPUs by default
+	Devices []string `yaml:"devices" json:"devices"`
+	// the bots which get the GPUs without declaring the capability
+	Bots []string `yaml:"bots" json:"bots"`
+}
+
+// IsBotEnabled tells if the bot should get the GPUs.
+func (cfg AgentGPUConfig) IsBotEnabled(agent AgentConfig) bool {
+	if !cfg.Enable {
+		return false
+	}
+	if agent.HasCapability(AgentCapabilityGPU) {
+		return true
+	}
+	for _, bot := range cfg.Bots {
+		if strings.EqualFold(bot, agent.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// VisibleDevices returns the GPUs which are visible to the bots, in the NVIDIA runtime format.
+func (cfg AgentGPUConfig) VisibleDevices() string {
+	if len(cfg.Devices) == 0 {
+		return "all"
+	}
+	return strings.Join(cfg.Devices, ",")
+}
+
+// AgentContainerResources contains the container settings which the bots using the shared memory or
+// many files and processes need. Zero values mean the Docker defaults.
+type AgentContainerResources struct {
+	ShmSizeMiB int `yaml:"shmSizeMib" json:"shmSizeMib" validate:"min=0"`
+	// the mount paths and the mount options like "size=64m"
+	Tmpfs   map[string]string  `yaml:"tmpfs" json:"tmpfs" validate:"dive,keys,startswith=/,endkeys"`
+	Ulimits AgentUlimitsConfig `yaml:"ulimits" json:"ulimits"`
+}
+
+// AgentUlimitsConfig contains the ulimits of the agent containers. The soft and the hard limits are the same.
+type AgentUlimitsConfig struct {
+	NoFile int64 `yaml:"nofile" json:"nofile" validate:"min=0"`
+	NProc  int64 `yaml:"nproc" json:"nproc" validate:"min=0"`
+}
+
+// GetAgentContainerResources returns the container settings of a bot. The non-zero settings of the bot
+// replace the global ones and the tmpfs mounts of the bot are added to the global ones.
+func (rc ResourcesConfig) GetAgentContainerResources(botID string) AgentContainerResources {
+	resources := AgentContainerResources{
+		ShmSizeMiB: rc.AgentContainer.ShmSizeMiB,
+		Tmpfs:      make(map[string]string),
+		Ulimits:    rc.AgentContainer.Ulimits,
+	}
+	for path, options := range rc.AgentContainer.Tmpfs {
+		resources.Tmpfs[path] = options
+	}
+	for id, botCfg := range rc.Bots {
+		if !strings.EqualFold(id, botID) {
+			continue
+		}
+		if botCfg.ShmSizeMiB > 0 {
+			resources.ShmSizeMiB = botCfg.ShmSizeMiB
+		}
+		if botCfg.Ulimits.NoFile > 0 {
+			resources.Ulimits.NoFile = botCfg.Ulimits.NoFile
+		}
+		if botCfg.Ulimits.NProc > 0 {
+			resources.Ulimits.NProc = botCfg.Ulimits.NProc
+		}
+		for path, options := range botCfg.Tmpfs {
+			resources.Tmpfs[path] = options
+		}
+	}
+	return resources
+}
+
+// AdmissionConfig caps the number of the bot containers which run at once. When the assigned bots do not
+// fit, the bots with the higher priority are admitted first and the rest are rejected.
+type AdmissionConfig struct {
+	Enable bool `yaml:"enable" json:"enable"`
+	// zero derives the max bot count from the host resources and the agent limits
+	MaxBots           int     `yaml:"maxBots" json:"maxBots" validate:"min=0"`
+	ReservedMemoryMiB int     `yaml:"reservedMemoryMib" json:"reservedMemoryMib" default:"2048" validate:"min=0"`
+	ReservedCPUs      float64 `yaml:"reservedCpus" json:"reservedCpus" default:"1" validate:"min=0"`
+	// the bots have zero priority by default
+	BotPriorities map[string]int `yaml:"botPriorities" json:"botPriorities"`
+}
+
+// GetBotPriority returns the priority of the bot.
+func (ac AdmissionConfig) GetBotPriority(botID string) int {
+	for id, priority := range ac.BotPriorities {
+		if strings.EqualFold(id, botID) {
+			return priority
+		}
+	}
+	return 0
 }
 
 type ENSConfig struct {
@@ -96,11 +676,44 @@ type TelemetryConfig struct {
 	Disable   bool   `yaml:"disable" json:"disable"`
 }
 
+// Release channels
+const (
+	ReleaseChannelStable = "stable"
+	ReleaseChannelRC     = "rc"
+	ReleaseChannelCanary = "canary"
+)
+
 type AutoUpdateConfig struct {
-	Disable              bool `yaml:"disable" json:"disable"`
-	UpdateDelay          *int `yaml:"updateDelay" json:"updateDelay"`
-	TrackPrereleases     bool `yaml:"trackPrereleases" json:"trackPrereleases"`
-	CheckIntervalSeconds int  `yaml:"checkIntervalSeconds" json:"checkIntervalSeconds" default:"60"` // 1m
+	Disable     bool `yaml:"disable" json:"disable"`
+	UpdateDelay *int `yaml:"updateDelay" json:"updateDelay"`
+	// deprecated: same as the canary release channel
+	TrackPrereleases     bool   `yaml:"trackPrereleases" json:"trackPrereleases"`
+	ReleaseChannel       string `yaml:"releaseChannel" json:"releaseChannel" default:"stable" validate:"omitempty,oneof=stable rc canary"`
+	CheckIntervalSeconds int    `yaml:"checkIntervalSeconds" json:"checkIntervalSeconds" default:"60"` // 1m
+	// follow the releases imported with 'forta update import' instead of the registry
+	Offline bool `yaml:"offline" json:"offline"`
+	// the addresses which are allowed to sign the update bundles, in addition to the scanner address
+	BundleSigners []string `yaml:"bundleSigners" json:"bundleSigners" validate:"dive,eth_addr"`
+}
+
+// GetReleaseChannel returns the release channel to follow.
+func (cfg AutoUpdateConfig) GetReleaseChannel() string {
+	if cfg.TrackPrereleases && (cfg.ReleaseChannel == "" || cfg.ReleaseChannel == ReleaseChannelStable) {
+		return ReleaseChannelCanary
+	}
+	if cfg.ReleaseChannel == "" {
+		return ReleaseChannelStable
+	}
+	return cfg.ReleaseChannel
+}
+
+// AutoStartConfig makes the node resume after an unplanned host reboot without running it manually
+// again. The systemd service from 'forta install-service' starts the runner which then takes over.
+type AutoStartConfig struct {
+	Enable bool `yaml:"enable" json:"enable"`
+	// the Docker restart policy of the supervisor and the updater containers, so that they are
+	// started with the Docker daemon even before the runner - not used with the systemd service
+	RestartPolicy string `yaml:"restartPolicy" json:"restartPolicy" default:"unless-stopped" validate:"oneof=unless-stopped always"`
 }
 
 type AgentLogsConfig struct {
@@ -159,6 +772,7 @@ type LocalModeConfig struct {
 	ShardedBots           []*LocalShardedBot       `yaml:"shardedBots" json:"shardedBots"`
 	PrivateKeyHex         string                   `yaml:"privateKeyHex" json:"privateKeyHex"`
 	Standalone            StandaloneModeConfig     `yaml:"standalone" json:"standalone"`
+	LocalBots             []LocalBotConfig         `yaml:"localBots" json:"localBots" validate:"dive"`
 }
 
 // IsStandalone checks if the node is in standalone mode. It should only be available
@@ -167,6 +781,23 @@ func (lmc LocalModeConfig) IsStandalone() bool {
 	return lmc.Enable && lmc.Standalone.Enable
 }
 
+// LocalBotConfig is a bot which runs from an image in the local docker daemon instead of the registry.
+// If the source directory is specified, the image is built from it before starting the bot.
+type LocalBotConfig struct {
+	Image string `yaml:"image" json:"image" validate:"required"`
+	// relative to the Forta dir if not absolute
+	Source     string `yaml:"source" json:"source"`
+	Dockerfile string `yaml:"dockerfile" json:"dockerfile"`
+}
+
+// SourcePath returns the absolute path of the source directory.
+func (lbc LocalBotConfig) SourcePath(fortaDir string) string {
+	if len(lbc.Source) == 0 || path.IsAbs(lbc.Source) {
+		return lbc.Source
+	}
+	return path.Join(fortaDir, lbc.Source)
+}
+
 type LocalShardedBot struct {
 	BotImage *string `yaml:"botImage" json:"botImage"`
 	// number of shards for bot
@@ -176,9 +807,60 @@ type LocalShardedBot struct {
 }
 
 type InspectionConfig struct {
-	BlockInterval     *int `yaml:"blockInterval" json:"blockInterval"`
-	NetworkSavingMode bool `yaml:"networkSavingMode" json:"networkSavingMode"`
-	InspectAtStartup  bool `yaml:"inspectAtStartup" json:"inspectAtStartup" default:"true"`
+	BlockInterval     *int                    `yaml:"blockInterval" json:"blockInterval"`
+	NetworkSavingMode bool                    `yaml:"networkSavingMode" json:"networkSavingMode"`
+	InspectAtStartup  bool                    `yaml:"inspectAtStartup" json:"inspectAtStartup" default:"true"`
+	Probes            []InspectionProbeConfig `yaml:"probes" json:"probes" validate:"dive"`
+	// adds the host capabilities (CPU, memory, GPU) to the inspection metadata
+	ReportHardware bool `yaml:"reportHardware" json:"reportHardware" default:"true"`
+	// adds the error, timeout and latency aggregates of the bot evaluations since the last inspection
+	ReportBotHealth bool `yaml:"reportBotHealth" json:"reportBotHealth" default:"true"`
+	// the actions to take when the inspection indicators fail
+	Actions          []InspectionActionConfig `yaml:"actions" json:"actions" validate:"dive"`
+	ActionWebhookURL string                   `yaml:"actionWebhookUrl" json:"actionWebhookUrl" validate:"omitempty,url"`
+	// the trace API is also inspected on this interval, independently of the scan API
+	TraceIntervalSeconds int `yaml:"traceIntervalSeconds" json:"traceIntervalSeconds" default:"300" validate:"min=10"`
+	// checks the reachability, the DNS resolution and the path MTU issues over IPv4
+	Connectivity ConnectivityCheckConfig `yaml:"connectivity" json:"connectivity"`
+}
+
+// ConnectivityCheckConfig configures the IPv4 connectivity checks. The target host should serve
+// HTTPS, so that the TLS handshake sends packets which are larger than the usual MTU and exposes
+// the path MTU issues.
+type ConnectivityCheckConfig struct {
+	Disable        bool   `yaml:"disable" json:"disable"`
+	URL            string `yaml:"url" json:"url" default:"https://cloudflare.com/cdn-cgi/trace" validate:"url"`
+	TimeoutSeconds int    `yaml:"timeoutSeconds" json:"timeoutSeconds" default:"10" validate:"min=1"`
+}
+
+// inspection failure actions
+const (
+	InspectionActionPause   = "pause"
+	InspectionActionNotify  = "notify"
+	InspectionActionRestart = "restart"
+)
+
+// InspectionActionConfig maps a failed inspection indicator to an action. The indicator fails when it is
+// out of the min-max range if specified or, otherwise, when it has the failure value (-1).
+// The node is paused while the indicator is failing. The notifications are sent to the action webhook
+// and the scanner is restarted only when the indicator starts failing.
+type InspectionActionConfig struct {
+	Indicator string   `yaml:"indicator" json:"indicator" validate:"required"`
+	Action    string   `yaml:"action" json:"action" validate:"oneof=pause notify restart"`
+	Min       *float64 `yaml:"min" json:"min"`
+	Max       *float64 `yaml:"max" json:"max"`
+}
+
+// InspectionProbeConfig is an operator-defined check which runs with every inspection. A probe either
+// sends a GET request to the URL or executes the command. The command should print a JSON object
+// to stdout. The probe results are added to the inspection metadata with the "probe.<name>." prefix.
+type InspectionProbeConfig struct {
+	Name           string   `yaml:"name" json:"name" validate:"required"`
+	URL            string   `yaml:"url" json:"url" validate:"required_without=Command,excluded_with=Command,omitempty,url"`
+	ExpectedStatus int      `yaml:"expectedStatus" json:"expectedStatus" default:"200"`
+	ExpectedBody   string   `yaml:"expectedBody" json:"expectedBody"`
+	Command        []string `yaml:"command" json:"command" validate:"required_without=URL"`
+	TimeoutSeconds int      `yaml:"timeoutSeconds" json:"timeoutSeconds" default:"10" validate:"min=1"`
 }
 
 type StorageConfig struct {
@@ -189,12 +871,309 @@ type StorageConfig struct {
 type CombinerConfig struct {
 	AlertAPIURL       string `yaml:"alertApiUrl" json:"alertApiUrl" default:"https://api.forta.network/graphql" validate:"url"`
 	CombinerCachePath string `yaml:"alertCachePath" json:"alert_cache_path"`
+	// disables delivering the alerts of the bots on this node to the local subscriber bots
+	// before the alerts are served by the alert API
+	DisableLocalDelivery bool `yaml:"disableLocalDelivery" json:"disableLocalDelivery"`
 }
 
 type AdvancedConfig struct {
 	SafeOffset bool `yaml:"safeOffset" json:"safeOffset"`
 }
 
+// Restart policies for the node service containers
+const (
+	RestartPolicyAlways    = "always"
+	RestartPolicyOnFailure = "on-failure"
+	RestartPolicyNever     = "never"
+)
+
+type RestartPolicyConfig struct {
+	Policy     string `yaml:"policy" json:"policy" validate:"omitempty,oneof=always on-failure never"`
+	MaxRetries int    `yaml:"maxRetries" json:"maxRetries" validate:"omitempty,min=1"`
+}
+
+type RestartConfig struct {
+	Default  RestartPolicyConfig            `yaml:"default" json:"default" default:"{\"policy\": \"always\", \"maxRetries\": 10}"`
+	Services map[string]RestartPolicyConfig `yaml:"services" json:"services" validate:"dive"`
+
+	// the circuit breaker stops restarting a service which restarted too many times in the window
+	// and retries once after the cool-down, or never if the cool-down is zero
+	CircuitBreakerMaxRestarts     int `yaml:"circuitBreakerMaxRestarts" json:"circuitBreakerMaxRestarts" default:"5"`
+	CircuitBreakerWindowSeconds   int `yaml:"circuitBreakerWindowSeconds" json:"circuitBreakerWindowSeconds" default:"600"`
+	CircuitBreakerCooldownSeconds int `yaml:"circuitBreakerCooldownSeconds" json:"circuitBreakerCooldownSeconds" default:"300"`
+}
+
+// GetPolicy returns the restart policy of a service. The values which are not specified for
+// the service are taken from the default policy.
+func (rc RestartConfig) GetPolicy(service string) RestartPolicyConfig {
+	policy := rc.Default
+	servicePolicy, ok := rc.Services[service]
+	if !ok {
+		return policy
+	}
+	if len(servicePolicy.Policy) > 0 {
+		policy.Policy = servicePolicy.Policy
+	}
+	if servicePolicy.MaxRetries > 0 {
+		policy.MaxRetries = servicePolicy.MaxRetries
+	}
+	return policy
+}
+
+// ScannerPoolConfig is the scanner pool which the node runs under. The pool is owned by a separate
+// owner address and the node only holds the scanner key which is registered to the pool, so the
+// pool stake and the ownership do not depend on the key on the node.
+type ScannerPoolConfig struct {
+	PoolID       string `yaml:"poolId" json:"poolId" validate:"omitempty,number"`
+	OwnerAddress string `yaml:"ownerAddress" json:"ownerAddress" validate:"omitempty,eth_addr"`
+}
+
+// MessagingConfig configures the messaging between the node services.
+type MessagingConfig struct {
+	// adds correlation IDs to the messages and logs them where the messages are published and received
+	Tracing bool `yaml:"tracing" json:"tracing"`
+	// requires a token and a client certificate to connect to the message bus and encrypts the connections.
+	// The supervisor generates the credentials at start and gives them only to the node containers.
+	Secure bool `yaml:"secure" json:"secure"`
+}
+
+// Agent image scan policies
+const (
+	ImageScanPolicyFlag   = "flag"
+	ImageScanPolicyRefuse = "refuse"
+)
+
+// AgentImageScanConfig enables scanning the bot images for vulnerabilities before the bots are started.
+// The scanner is an external hook which either receives a POST request with {"image": "<reference>"}
+// at the URL or is executed as the command with the image reference appended to the arguments.
+// The hook should respond with a Trivy JSON report, e.g. the output of "trivy image --format json".
+type AgentImageScanConfig struct {
+	Enable  bool     `yaml:"enable" json:"enable"`
+	URL     string   `yaml:"url" json:"url" validate:"omitempty,url,excluded_with=Command"`
+	Command []string `yaml:"command" json:"command"`
+	// the vulnerabilities with this severity or higher are reported
+	Severity string `yaml:"severity" json:"severity" default:"CRITICAL" validate:"oneof=CRITICAL HIGH MEDIUM LOW"`
+	// the bots with vulnerabilities are only flagged in the bot status or are not started at all
+	Policy string `yaml:"policy" json:"policy" default:"flag" validate:"oneof=flag refuse"`
+	// does not start the bots when their images could not be scanned, with the refuse policy
+	RefuseOnError  bool `yaml:"refuseOnError" json:"refuseOnError"`
+	TimeoutSeconds int  `yaml:"timeoutSeconds" json:"timeoutSeconds" default:"300" validate:"min=1"`
+}
+
+// Image pull policies
+const (
+	ImagePullAlways       = "Always"
+	ImagePullIfNotPresent = "IfNotPresent"
+	ImagePullNever        = "Never"
+)
+
+// ImagePullConfig sets when the images are pulled from the registry, separately for the node service images
+// and the bot images. The nodes without registry access can run with Never from the images loaded beforehand.
+type ImagePullConfig struct {
+	Services string `yaml:"services" json:"services" default:"IfNotPresent" validate:"oneof=Always IfNotPresent Never"`
+	Bots     string `yaml:"bots" json:"bots" default:"IfNotPresent" validate:"oneof=Always IfNotPresent Never"`
+}
+
+// AgentDNSConfig contains the name resolution settings of the agent containers.
+type AgentDNSConfig struct {
+	Servers []string `yaml:"servers" json:"servers" validate:"dive,ip"`
+	Search  []string `yaml:"search" json:"search" validate:"dive,hostname"`
+	// in "host:ip" format
+	ExtraHosts []string `yaml:"extraHosts" json:"extraHosts" validate:"dive,contains=:"`
+}
+
+// AgentAddressPoolConfig is a range which the agent network subnets are allocated from.
+type AgentAddressPoolConfig struct {
+	Base string `yaml:"base" json:"base" validate:"required,cidrv4"`
+	// the prefix length of the subnets allocated from the base
+	Size int `yaml:"size" json:"size" default:"28" validate:"min=16,max=29"`
+}
+
+type AgentNetworkConfig struct {
+	DNS  AgentDNSConfig            `yaml:"dns" json:"dns"`
+	Bots map[string]AgentDNSConfig `yaml:"bots" json:"bots" validate:"dive"`
+	// the agent network subnets are allocated from these pools instead of the Docker default pools,
+	// avoiding the subnets of the other networks, the host routes and the excluded subnets
+	AddressPools    []AgentAddressPoolConfig `yaml:"addressPools" json:"addressPools" validate:"dive"`
+	ExcludedSubnets []string                 `yaml:"excludedSubnets" json:"excludedSubnets" validate:"dive,cidrv4"`
+	// attaches all agents to a single network instead of a network per agent, for the
+	// deployments which run many bots and do not need the isolation between them
+	Shared bool `yaml:"shared" json:"shared"`
+}
+
+// GetDNSConfig returns the name resolution settings of a bot. The servers and the search domains
+// of the bot replace the global ones and the extra hosts are added to the global ones.
+func (nc AgentNetworkConfig) GetDNSConfig(botID string) AgentDNSConfig {
+	dnsCfg := AgentDNSConfig{
+		Servers:    append([]string{}, nc.DNS.Servers...),
+		Search:     append([]string{}, nc.DNS.Search...),
+		ExtraHosts: append([]string{}, nc.DNS.ExtraHosts...),
+	}
+	for id, botCfg := range nc.Bots {
+		if !strings.EqualFold(id, botID) {
+			continue
+		}
+		if len(botCfg.Servers) > 0 {
+			dnsCfg.Servers = botCfg.Servers
+		}
+		if len(botCfg.Search) > 0 {
+			dnsCfg.Search = botCfg.Search
+		}
+		dnsCfg.ExtraHosts = append(dnsCfg.ExtraHosts, botCfg.ExtraHosts...)
+	}
+	return dnsCfg
+}
+
+// AgentUserConfig contains the user settings of the agent containers. The agents run as a non-root
+// user by default and the user can be overridden per bot for the images which require root.
+type AgentUserConfig struct {
+	User string            `yaml:"user" json:"user" default:"65534:65534"`
+	Bots map[string]string `yaml:"bots" json:"bots"`
+	// fails the supervisor if the Docker daemon does not remap the user namespaces
+	RequireUsernsRemap bool `yaml:"requireUsernsRemap" json:"requireUsernsRemap"`
+}
+
+// GetUser returns the user which the bot container should run as.
+func (uc AgentUserConfig) GetUser(botID string) string {
+	for id, user := range uc.Bots {
+		if strings.EqualFold(id, botID) {
+			return user
+		}
+	}
+	return uc.User
+}
+
+// AgentTLSConfig enables mutual TLS between the node and the agent containers. The supervisor issues
+// a certificate to each agent container at start so the agents and the node can verify each other.
+// The node services get the certificates only for the roles they play and the peers are checked by name.
+// The bots should use an SDK version which supports TLS.
+type AgentTLSConfig struct {
+	Enable bool `yaml:"enable" json:"enable"`
+}
+
+// AgentGrpcConfig tunes the long-lived gRPC connections to the agent containers. The keepalive pings
+// detect the broken connections before the requests fail and the requests are spread over the
+// connections so that a busy bot does not queue up on a single connection.
+type AgentGrpcConfig struct {
+	Connections int `yaml:"connections" json:"connections" default:"2" validate:"min=1,max=8"`
+	// the bot servers reject the pings which are too frequent so this should not be too low
+	KeepaliveTimeSeconds     int `yaml:"keepaliveTimeSeconds" json:"keepaliveTimeSeconds" default:"60" validate:"min=10"`
+	KeepaliveTimeoutSeconds  int `yaml:"keepaliveTimeoutSeconds" json:"keepaliveTimeoutSeconds" default:"20" validate:"min=1"`
+	MaxReconnectDelaySeconds int `yaml:"maxReconnectDelaySeconds" json:"maxReconnectDelaySeconds" default:"10" validate:"min=1"`
+	// compresses the requests to all bots - the official bot SDKs support gzip but not zstd, so
+	// zstd breaks the JS and the Python bots
+	Compression string `yaml:"compression" json:"compression" default:"gzip" validate:"omitempty,oneof=none gzip zstd"`
+}
+
+// GetCompression returns the compressor of the requests to the bots or empty if disabled.
+func (cfg AgentGrpcConfig) GetCompression() string {
+	if cfg.Compression == "none" {
+		return ""
+	}
+	return cfg.Compression
+}
+
+// ProfilingConfig serves the pprof and the runtime trace endpoints in the node service containers.
+// The endpoints listen only on the loopback interface of each container and the profiles can be
+// collected with 'forta debug profile'.
+type ProfilingConfig struct {
+	Enable bool `yaml:"enable" json:"enable"`
+	// the block and the mutex profiles are disabled when zero since they add some overhead
+	BlockProfileRate     int `yaml:"blockProfileRate" json:"blockProfileRate" validate:"min=0"`
+	MutexProfileFraction int `yaml:"mutexProfileFraction" json:"mutexProfileFraction" validate:"min=0"`
+}
+
+// AgentVolumesConfig declares the persistent named volumes of the bots which need to keep state
+// across container restarts. The supervisor creates the volumes before starting the bots and
+// removes the volumes which are no longer declared.
+type AgentVolumesConfig struct {
+	Bots map[string][]AgentVolumeConfig `yaml:"bots" json:"bots" validate:"dive,dive"`
+}
+
+// AgentVolumeConfig is a named volume of a bot.
+type AgentVolumeConfig struct {
+	Name      string `yaml:"name" json:"name" validate:"required,alphanum"`
+	MountPath string `yaml:"mountPath" json:"mountPath" validate:"required,startswith=/"`
+	// the size quota requires a volume driver other than "local" which supports the size option
+	SizeMB     int               `yaml:"sizeMb" json:"sizeMb" validate:"min=0"`
+	Driver     string            `yaml:"driver" json:"driver"`
+	DriverOpts map[string]string `yaml:"driverOpts" json:"driverOpts"`
+}
+
+// GetVolumes returns the volumes declared for the bot.
+func (vc AgentVolumesConfig) GetVolumes(botID string) []AgentVolumeConfig {
+	for id, volumes := range vc.Bots {
+		if strings.EqualFold(id, botID) {
+			return volumes
+		}
+	}
+	return nil
+}
+
+// AgentEnvConfig injects extra env vars into the containers of the bots, e.g. the API keys which the bots
+// need in the private deployments. The secrets are read from the files in the secrets dir of the Forta dir
+// so that they are not kept in the config. The env vars which are set by the node cannot be overridden.
+type AgentEnvConfig struct {
+	Bots map[string]BotEnvConfig `yaml:"bots" json:"bots" validate:"dive"`
+}
+
+// BotEnvConfig contains the extra env vars of a bot.
+type BotEnvConfig struct {
+	Vars map[string]string `yaml:"vars" json:"vars" validate:"dive,keys,required,endkeys"`
+	// the env var names mapped to the names of the secret files
+	Secrets map[string]string `yaml:"secrets" json:"secrets" validate:"dive,keys,required,endkeys,required,excludesall=/"`
+}
+
+// GetBotEnv returns the extra env vars of the bot.
+func (ec AgentEnvConfig) GetBotEnv(botID string) BotEnvConfig {
+	for id, botEnv := range ec.Bots {
+		if strings.EqualFold(id, botID) {
+			return botEnv
+		}
+	}
+	return BotEnvConfig{}
+}
+
+// SecretsDir returns the dir which contains the secret files.
+func (cfg *Config) SecretsDir() string {
+	return path.Join(cfg.FortaDir, DefaultSecretsDirName)
+}
+
+// ContainerLabelsConfig contains the custom Docker labels which are attached to the containers
+// managed by the node, e.g. for cost allocation or for the log collectors which route by label.
+// The labels which start with "network.forta" are reserved for the node and are ignored.
+type ContainerLabelsConfig struct {
+	// attached to all of the node-managed containers
+	Labels map[string]string `yaml:"labels" json:"labels"`
+	// attached only to the agent containers
+	AgentLabels map[string]string `yaml:"agentLabels" json:"agentLabels"`
+}
+
+// IsRootUser tells if the container user is root. An empty user is the image default
+// which is root for most of the images.
+func IsRootUser(user string) bool {
+	name := strings.Split(user, ":")[0]
+	return name == "" || name == "root" || name == "0"
+}
+
+// HeartbeatConfig configures the heartbeats which are sent to an operator endpoint.
+type HeartbeatConfig struct {
+	URL             string            `yaml:"url" json:"url" validate:"omitempty,url"`
+	Headers         map[string]string `yaml:"headers" json:"headers"`
+	IntervalSeconds int               `yaml:"intervalSeconds" json:"intervalSeconds" default:"60" validate:"min=1"`
+}
+
+// RemoteConfigConfig enables pulling the signed config overrides from a URL, so that the config of many
+// nodes can be changed from one place. Only the reloadable fields are applied from the overrides.
+type RemoteConfigConfig struct {
+	// an http(s) URL or an s3://bucket/key reference to a public or presigned object
+	URL     string            `yaml:"url" json:"url" validate:"omitempty,url"`
+	Headers map[string]string `yaml:"headers" json:"headers"`
+	// the addresses which are allowed to sign the overrides
+	Signers         []string `yaml:"signers" json:"signers" validate:"required_with=URL,dive,eth_addr"`
+	IntervalSeconds int      `yaml:"intervalSeconds" json:"intervalSeconds" default:"300" validate:"min=10"`
+}
+
 type Config struct {
 	// runtime values
 
@@ -205,25 +1184,44 @@ type Config struct {
 
 	// yaml config values
 
+	// the config schema version which the deprecated fields are migrated by
+	Version int `yaml:"version" json:"version"`
+
 	ChainID int `yaml:"chainId" json:"chainId" default:"1" `
 
 	Scan  ScannerConfig `yaml:"scan" json:"scan"`
 	Trace TraceConfig   `yaml:"trace" json:"trace"`
 
-	Registry         RegistryConfig     `yaml:"registry" json:"registry"`
-	Publish          PublisherConfig    `yaml:"publish" json:"publish"`
-	JsonRpcProxy     JsonRpcProxyConfig `yaml:"jsonRpcProxy" json:"jsonRpcProxy"`
-	Log              LogConfig          `yaml:"log" json:"log"`
-	ResourcesConfig  ResourcesConfig    `yaml:"resources" json:"resources"`
-	ENSConfig        ENSConfig          `yaml:"ens" json:"ens"`
-	TelemetryConfig  TelemetryConfig    `yaml:"telemetry" json:"telemetry"`
-	AutoUpdate       AutoUpdateConfig   `yaml:"autoUpdate" json:"autoUpdate"`
-	AgentLogsConfig  AgentLogsConfig    `yaml:"agentLogs" json:"agentLogs"`
-	LocalModeConfig  LocalModeConfig    `yaml:"localMode" json:"localMode"`
-	InspectionConfig InspectionConfig   `yaml:"inspection" json:"inspection"`
-	StorageConfig    StorageConfig      `yaml:"storage" json:"storage"`
-	CombinerConfig   CombinerConfig     `yaml:"combiner" json:"combiner"`
-	AdvancedConfig   AdvancedConfig     `yaml:"advanced" json:"advanced"`
+	Registry         RegistryConfig        `yaml:"registry" json:"registry"`
+	Publish          PublisherConfig       `yaml:"publish" json:"publish"`
+	JsonRpcProxy     JsonRpcProxyConfig    `yaml:"jsonRpcProxy" json:"jsonRpcProxy"`
+	Log              LogConfig             `yaml:"log" json:"log"`
+	ResourcesConfig  ResourcesConfig       `yaml:"resources" json:"resources"`
+	ENSConfig        ENSConfig             `yaml:"ens" json:"ens"`
+	TelemetryConfig  TelemetryConfig       `yaml:"telemetry" json:"telemetry"`
+	AutoUpdate       AutoUpdateConfig      `yaml:"autoUpdate" json:"autoUpdate"`
+	AgentLogsConfig  AgentLogsConfig       `yaml:"agentLogs" json:"agentLogs"`
+	LocalModeConfig  LocalModeConfig       `yaml:"localMode" json:"localMode"`
+	InspectionConfig InspectionConfig      `yaml:"inspection" json:"inspection"`
+	StorageConfig    StorageConfig         `yaml:"storage" json:"storage"`
+	CombinerConfig   CombinerConfig        `yaml:"combiner" json:"combiner"`
+	AdvancedConfig   AdvancedConfig        `yaml:"advanced" json:"advanced"`
+	RestartConfig    RestartConfig         `yaml:"restart" json:"restart"`
+	AutoStart        AutoStartConfig       `yaml:"autoStart" json:"autoStart"`
+	AgentNetwork     AgentNetworkConfig    `yaml:"agentNetwork" json:"agentNetwork"`
+	Heartbeat        HeartbeatConfig       `yaml:"heartbeat" json:"heartbeat"`
+	AgentUser        AgentUserConfig       `yaml:"agentUser" json:"agentUser"`
+	AgentTLS         AgentTLSConfig        `yaml:"agentTls" json:"agentTls"`
+	AgentGrpc        AgentGrpcConfig       `yaml:"agentGrpc" json:"agentGrpc"`
+	Profiling        ProfilingConfig       `yaml:"profiling" json:"profiling"`
+	ContainerLabels  ContainerLabelsConfig `yaml:"containerLabels" json:"containerLabels"`
+	AgentVolumes     AgentVolumesConfig    `yaml:"agentVolumes" json:"agentVolumes"`
+	AgentEnv         AgentEnvConfig        `yaml:"agentEnv" json:"agentEnv"`
+	AgentImageScan   AgentImageScanConfig  `yaml:"agentImageScan" json:"agentImageScan"`
+	ImagePull        ImagePullConfig       `yaml:"imagePull" json:"imagePull"`
+	Messaging        MessagingConfig       `yaml:"messaging" json:"messaging"`
+	ScannerPool      ScannerPoolConfig     `yaml:"scannerPool" json:"scannerPool"`
+	RemoteConfig     RemoteConfigConfig    `yaml:"remoteConfig" json:"remoteConfig"`
 }
 
 func (cfg *Config) ConfigFilePath() string {
@@ -237,6 +1235,9 @@ func GetConfigForContainer() (Config, error) {
 		return Config{}, err
 	}
 	applyContextDefaults(&cfg)
+	if err := ApplyRegistryProfile(&cfg); err != nil {
+		return cfg, err
+	}
 
 	// initialize combiner cache dump path if cache is persistent
 	if cfg.CombinerConfig.CombinerCachePath != "" {
@@ -266,7 +1267,7 @@ func applyContextDefaults(cfg *Config) {
 	}
 	cfg.FortaDir = DefaultContainerFortaDirPath
 	cfg.KeyDirPath = path.Join(cfg.FortaDir, DefaultKeysDirName)
-	cfg.CombinerConfig.CombinerCachePath = path.Join(cfg.FortaDir, DefaultCombinerCacheFileName)
+	cfg.CombinerConfig.CombinerCachePath = path.Join(cfg.StateDir(), DefaultCombinerCacheFileName)
 }
 
 func getConfigFromFile() (cfg Config, err error) {
@@ -277,7 +1278,11 @@ func getConfigFromFile() (cfg Config, err error) {
 
 	// if the default config file exists, load from there
 	if err = checkIfConfigFileExists(DefaultContainerConfigPath); err == nil {
-		if err = readYamlFile(DefaultContainerConfigPath, &cfg); err != nil {
+		var doc yaml.Node
+		if err = readYamlFile(DefaultContainerConfigPath, &doc); err != nil {
+			return
+		}
+		if err = decodeConfigNode(&doc, &cfg); err != nil {
 			return
 		}
 		successfullyLoadedTimes++
@@ -288,16 +1293,18 @@ func getConfigFromFile() (cfg Config, err error) {
 
 	// if the wrapped config file exists, load from there
 	if err = checkIfConfigFileExists(DefaultContainerWrappedConfigPath); err == nil {
-		var wrapped map[string]Config
+		var wrapped map[string]yaml.Node
 		if err = readYamlFile(DefaultContainerWrappedConfigPath, &wrapped); err != nil {
 			return
 		}
-		var found bool
-		cfg, found = wrapped[DefaultConfigWrapperKey]
+		node, found := wrapped[DefaultConfigWrapperKey]
 		if !found {
 			err = fmt.Errorf("wrapped config file was found but did not have the config under '%s'", DefaultConfigWrapperKey)
 			return
 		}
+		if err = decodeConfigNode(&node, &cfg); err != nil {
+			return
+		}
 		successfullyLoadedTimes++
 	}
 	if err != nil {
@@ -321,11 +1328,34 @@ func getConfigFromFile() (cfg Config, err error) {
 		err = fmt.Errorf("successfully loaded unexpected amount of config files (%d) - errors: %w", successfullyLoadedTimes, wrappedErr)
 	}
 
+	// apply the overrides pulled from the remote config URL
+	if err = applyRemoteOverrides(DefaultContainerFortaDirPath, &cfg); err != nil {
+		log.WithError(err).Warn("failed to apply the remote config overrides - ignoring")
+	}
+
 	// finally set the defaults
 	err = defaults.Set(&cfg)
 	return
 }
 
+// decodeConfigNode migrates the deprecated fields in memory and decodes the config.
+func decodeConfigNode(node *yaml.Node, cfg *Config) error {
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+		node = node.Content[0]
+	}
+	report, err := MigrateConfigNode(node)
+	if err != nil {
+		return err
+	}
+	for _, deprecated := range report.Deprecated {
+		log.Warnf("%s - please run 'forta config migrate'", deprecated)
+	}
+	return node.Decode(cfg)
+}
+
 func checkIfConfigFileExists(configPath string) error {
 	_, err := os.Stat(configPath)
 	if os.IsNotExist(err) {
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgentNetworkConfig_GetDNSConfig(t *testing.T) {
+	nc := AgentNetworkConfig{
+		DNS: AgentDNSConfig{
+			Servers:    []string{"1.1.1.1"},
+			Search:     []string{"internal"},
+			ExtraHosts: []string{"rpc.internal:10.0.0.1"},
+		},
+		Bots: map[string]AgentDNSConfig{
+			"0xABCD": {
+				Servers:    []string{"10.0.0.53"},
+				ExtraHosts: []string{"archive.internal:10.0.0.2"},
+			},
+		},
+	}
+
+	dnsCfg := nc.GetDNSConfig("0xabcd")
+	assert.Equal(t, []string{"10.0.0.53"}, dnsCfg.Servers)
+	assert.Equal(t, []string{"internal"}, dnsCfg.Search)
+	assert.Equal(t, []string{"rpc.internal:10.0.0.1", "archive.internal:10.0.0.2"}, dnsCfg.ExtraHosts)
+
+	dnsCfg = nc.GetDNSConfig("0x1234")
+	assert.Equal(t, nc.DNS, dnsCfg)
+	// the global config is not modified
+	assert.Len(t, nc.DNS.ExtraHosts, 1)
+}
+
+func TestMempoolConfig_IsBotEnabled(t *testing.T) {
+	mc := MempoolConfig{Bots: []string{"0xABCD"}}
+	assert.False(t, mc.IsBotEnabled("0xabcd"))
+
+	mc.Enable = true
+	assert.True(t, mc.IsBotEnabled("0xabcd"))
+	assert.False(t, mc.IsBotEnabled("0x1234"))
+}
+
+func TestBotReplicasConfig_GetReplicas(t *testing.T) {
+	rc := BotReplicasConfig{Bots: map[string]int{"0xABCD": 3}}
+	assert.Equal(t, 3, rc.GetReplicas("0xabcd"))
+	assert.Equal(t, 1, rc.GetReplicas("0x1234"))
+}
+
+func TestBatchSigningConfig_CheckKeyDirs(t *testing.T) {
+	sc := BatchSigningConfig{Cosigners: []CosignerConfig{{Name: "remote", URL: "http://cosigner"}, {Name: "org", KeyDir: "/etc/forta-org-key"}}}
+	assert.NoError(t, sc.CheckKeyDirs("/home/user/.forta"))
+	assert.NoError(t, sc.CheckKeyDirs("/etc/forta"))
+
+	sc.Cosigners[1].KeyDir = "/home/user/.forta/org-key"
+	assert.Error(t, sc.CheckKeyDirs("/home/user/.forta/"))
+	sc.Cosigners[1].KeyDir = "org-key"
+	assert.Error(t, sc.CheckKeyDirs("/home/user/.forta"))
+}
+
+func TestAgentGPUConfig_IsBotEnabled(t *testing.T) {
+	gc := AgentGPUConfig{Bots: []string{"0xABCD"}}
+	gpuBot := AgentConfig{ID: "0x1234", Capabilities: []string{"GPU"}}
+	assert.False(t, gc.IsBotEnabled(gpuBot))
+
+	gc.Enable = true
+	assert.True(t, gc.IsBotEnabled(gpuBot))
+	assert.True(t, gc.IsBotEnabled(AgentConfig{ID: "0xabcd"}))
+	assert.False(t, gc.IsBotEnabled(AgentConfig{ID: "0x5678"}))
+	assert.Equal(t, "all", gc.VisibleDevices())
+
+	gc.Devices = []string{"0", "1"}
+	assert.Equal(t, "0,1", gc.VisibleDevices())
+}
+
+func TestAgentUserConfig_GetUser(t *testing.T) {
+	uc := AgentUserConfig{
+		User: "65534:65534",
+		Bots: map[string]string{"0xABCD": "root"},
+	}
+	assert.Equal(t, "root", uc.GetUser("0xabcd"))
+	assert.Equal(t, "65534:65534", uc.GetUser("0x1234"))
+
+	assert.True(t, IsRootUser(""))
+	assert.True(t, IsRootUser("root"))
+	assert.True(t, IsRootUser("0:0"))
+	assert.False(t, IsRootUser("65534:65534"))
+}
+
+func TestAutoUpdateConfig_GetReleaseChannel(t *testing.T) {
+	assert.Equal(t, ReleaseChannelStable, AutoUpdateConfig{}.GetReleaseChannel())
+	assert.Equal(t, ReleaseChannelRC, AutoUpdateConfig{ReleaseChannel: ReleaseChannelRC}.GetReleaseChannel())
+	assert.Equal(t, ReleaseChannelCanary, AutoUpdateConfig{TrackPrereleases: true, ReleaseChannel: ReleaseChannelStable}.GetReleaseChannel())
+}
+
+func TestAgentGrpcConfig_GetCompression(t *testing.T) {
+	assert.Equal(t, "gzip", AgentGrpcConfig{Compression: "gzip"}.GetCompression())
+	assert.Empty(t, AgentGrpcConfig{Compression: "none"}.GetCompression())
+	assert.Empty(t, AgentGrpcConfig{}.GetCompression())
+}
+
+func TestScannerConfig_GetBlockOffset(t *testing.T) {
+	var cfg ScannerConfig
+	assert.Equal(t, 2, cfg.GetBlockOffset(1, 2))
+
+	cfg.ChainOffsets = map[int]int{137: 10, 56: 0}
+	assert.Equal(t, 10, cfg.GetBlockOffset(137, 2))
+	assert.Equal(t, 0, cfg.GetBlockOffset(56, 2))
+	assert.Equal(t, 2, cfg.GetBlockOffset(1, 2))
+
+	cfg.ConfirmationDepth = 5
+	assert.Equal(t, 10, cfg.GetBlockOffset(137, 2))
+	assert.Equal(t, 5, cfg.GetBlockOffset(56, 2))
+	assert.Equal(t, 5, cfg.GetBlockOffset(1, 2))
+}
+
+func TestAgentVolumesConfig_GetVolumes(t *testing.T) {
+	vc := AgentVolumesConfig{
+		Bots: map[string][]AgentVolumeConfig{"0xABCD": {{Name: "state", MountPath: "/data"}}},
+	}
+	assert.Equal(t, []AgentVolumeConfig{{Name: "state", MountPath: "/data"}}, vc.GetVolumes("0xabcd"))
+	assert.Nil(t, vc.GetVolumes("0x1234"))
+}
+
+func TestAgentEnvConfig(t *testing.T) {
+	ec := AgentEnvConfig{
+		Bots: map[string]BotEnvConfig{"0xABCD": {Vars: map[string]string{"NETWORK": "testnet"}}},
+	}
+	assert.Equal(t, "testnet", ec.GetBotEnv("0xabcd").Vars["NETWORK"])
+	assert.Empty(t, ec.GetBotEnv("0x1234").Vars)
+
+	ec.Bots["0xABCD"] = BotEnvConfig{Secrets: map[string]string{"API_KEY": "api-key"}}
+	assert.NoError(t, validator.New().Struct(ec))
+	ec.Bots["0xABCD"] = BotEnvConfig{Secrets: map[string]string{"API_KEY": "../api-key"}}
+	assert.Error(t, validator.New().Struct(ec))
+}
+
+func TestGetMaxBots(t *testing.T) {
+	cfg := ResourcesConfig{Admission: AdmissionConfig{ReservedMemoryMiB: 2048, ReservedCPUs: 1}}
+
+	// 6 bots fit the memory and 15 bots fit the cpus
+	assert.Equal(t, 6, GetMaxBots(cfg, 8*1024*1024*1024, 4))
+	// the max bot count is lower
+	cfg.Admission.MaxBots = 4
+	assert.Equal(t, 4, GetMaxBots(cfg, 8*1024*1024*1024, 4))
+	// the unknown host resources are ignored
+	assert.Equal(t, 4, GetMaxBots(cfg, 0, 0))
+	// there is always room for one bot
+	assert.Equal(t, 1, GetMaxBots(cfg, 1024*1024*1024, 4))
+
+	// no limit
+	cfg.Admission.MaxBots = 0
+	cfg.DisableAgentLimits = true
+	assert.Equal(t, 0, GetMaxBots(cfg, 8*1024*1024*1024, 4))
+}
+
+func TestResourcesConfig_GetAgentContainerResources(t *testing.T) {
+	rc := ResourcesConfig{
+		AgentContainer: AgentContainerResources{
+			ShmSizeMiB: 64,
+			Tmpfs:      map[string]string{"/tmp": "size=64m"},
+			Ulimits:    AgentUlimitsConfig{NoFile: 1024},
+		},
+		Bots: map[string]AgentContainerResources{
+			"0xABCD": {
+				ShmSizeMiB: 2048,
+				Tmpfs:      map[string]string{"/cache": "size=512m"},
+				Ulimits:    AgentUlimitsConfig{NProc: 512},
+			},
+		},
+	}
+
+	assert.Equal(t, AgentContainerResources{
+		ShmSizeMiB: 2048,
+		Tmpfs:      map[string]string{"/tmp": "size=64m", "/cache": "size=512m"},
+		Ulimits:    AgentUlimitsConfig{NoFile: 1024, NProc: 512},
+	}, rc.GetAgentContainerResources("0xabcd"))
+	assert.Equal(t, AgentContainerResources{
+		ShmSizeMiB: 64,
+		Tmpfs:      map[string]string{"/tmp": "size=64m"},
+		Ulimits:    AgentUlimitsConfig{NoFile: 1024},
+	}, rc.GetAgentContainerResources("0x1234"))
+
+	// the tmpfs mounts need absolute paths
+	rc.AgentContainer.Tmpfs = map[string]string{"tmp": ""}
+	assert.Error(t, validator.New().Struct(rc))
+}
@@ -13,6 +13,10 @@ var (
 	DockerUpdaterImage    = "forta-network/forta-node:latest"
 	UseDockerImages       = "local"
 
+	// the third-party images which the supervisor runs
+	DockerNatsImage = "nats:2.3.2"
+	DockerIpfsImage = "ipfs/kubo:v0.16.0"
+
 	DockerSupervisorManagedContainers = 7
 	DockerUpdaterContainerName        = fmt.Sprintf("%s-updater", ContainerNamePrefix)
 	DockerSupervisorContainerName     = fmt.Sprintf("%s-supervisor", ContainerNamePrefix)
@@ -23,8 +27,12 @@ var (
 	DockerJSONRPCProxyContainerName   = fmt.Sprintf("%s-json-rpc", ContainerNamePrefix)
 	DockerJWTProviderContainerName    = fmt.Sprintf("%s-jwt-provider", ContainerNamePrefix)
 	DockerStorageContainerName        = fmt.Sprintf("%s-storage", ContainerNamePrefix)
+	// the names of the agent containers which the supervisor starts begin with this
+	AgentContainerNamePrefix = fmt.Sprintf("%s-agent-", ContainerNamePrefix)
 
 	DockerNetworkName = DockerScannerContainerName
+	// shared by all agents when the agent network is in the shared mode
+	DockerAgentsNetworkName = fmt.Sprintf("%s-agents", ContainerNamePrefix)
 
 	DefaultContainerFortaDirPath      = "/.forta"
 	DefaultContainerConfigPath        = path.Join(DefaultContainerFortaDirPath, DefaultConfigFileName)
@@ -5,6 +5,9 @@ const (
 	DefaultCombinerCacheFileName = ".combiner_cache.json"
 	DefaultConfigFileName        = "config.yml"
 	DefaultWrappedConfigFileName = "wrapped-config.yml"
+	DefaultPausedFileName        = ".paused"
+	DefaultDisabledBotsFileName  = ".disabled-bots.json"
+	DefaultRPCUsageFileName      = ".rpc-usage.json"
 	DefaultConfigWrapperKey      = "x-forta-config"
 	DefaultNatsPort              = "4222"
 	DefaultContainerPort         = "8089"
@@ -12,5 +15,53 @@ const (
 	DefaultJSONRPCProxyPort      = "8545"
 	DefaultStoragePort           = "8525"
 	DefaultJWTProviderPort       = "8515"
+	DefaultProfilingPort         = "6060"
 	DefaultFortaNodeBinaryPath   = "/forta-node" // the path for the common binary in the container image
+
+	// marks the node as paused by the inspection actions, separately from the operator
+	DefaultInspectionPausedFileName = ".paused-by-inspection"
+
+	// the bot assignment changes recorded by the registry service
+	DefaultAssignmentHistoryFileName = ".assignment-history.json"
+
+	// the last findings published by the node
+	DefaultPublishedFindingsFileName = ".published-findings.jsonl"
+
+	// the last blocks evaluated by the bots
+	DefaultEvalCheckpointFileName = ".eval-checkpoints.json"
+
+	// the block to start scanning from once, requested with 'forta run --start-from'
+	DefaultStartFromFileName = ".start-from.json"
+
+	// the reloadable config overrides pulled from the remote config URL
+	DefaultRemoteConfigFileName = ".remote-config.yml"
+
+	// the release imported from an update bundle, for the offline nodes
+	DefaultImportedReleaseFileName = ".imported-release.json"
+
+	// the uptime, the restarts and the failures of the service containers
+	DefaultServiceHistoryFileName = ".service-history.json"
+
+	// the secret files which are injected into the bot containers as env vars
+	DefaultSecretsDirName = ".secrets"
+
+	// tells if the runner was stopped cleanly, to detect the unplanned host reboots
+	DefaultRunStateFileName = ".run-state.json"
+
+	// the last bot assignments, to resume the bots before the registry responds after a restart
+	DefaultAssignmentsFileName = ".assignments.json"
+
+	// the paths of the TLS files copied to the node and the agent containers
+	DefaultContainerTLSCertPath = "/forta-tls.crt"
+	DefaultContainerTLSKeyPath  = "/forta-tls.key"
+	DefaultContainerTLSCAPath   = "/forta-tls-ca.crt"
+
+	// the paths of the message bus files copied to the NATS and the node containers
+	DefaultContainerNatsCertPath   = "/forta-nats.crt"
+	DefaultContainerNatsKeyPath    = "/forta-nats.key"
+	DefaultContainerNatsCAPath     = "/forta-nats-ca.crt"
+	DefaultContainerNatsConfigPath = "/forta-nats.conf"
+
+	// the local cosigner key dirs are mounted under this path in the scanner container
+	DefaultContainerCosignerKeysPath = "/forta-cosigners"
 )
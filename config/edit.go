@@ -0,0 +1,88 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GetConfigValue returns the YAML encoding of the value at the dot-separated path in the config.
+func GetConfigValue(cfg Config, path string) ([]byte, error) {
+	if err := CheckConfigPath(path); err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := doc.Encode(cfg); err != nil {
+		return nil, err
+	}
+	value := findConfigPath(&doc, strings.Split(path, "."))
+	if value == nil {
+		return nil, fmt.Errorf("%s is not set", path)
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SetConfigValue sets the value at the dot-separated path in the YAML config file. The value is
+// decoded as YAML so that the numbers, the booleans and the lists keep their types.
+func SetConfigValue(data []byte, path, value string) ([]byte, error) {
+	if err := CheckConfigPath(path); err != nil {
+		return nil, err
+	}
+	var valueDoc yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &valueDoc); err != nil {
+		return nil, fmt.Errorf("invalid value: %v", err)
+	}
+	valueNode := stringNode(value)
+	if len(valueDoc.Content) > 0 {
+		valueNode = valueDoc.Content[0]
+	}
+	return setConfigNodes(data, map[string]*yaml.Node{path: valueNode})
+}
+
+// CheckConfigPath checks that the dot-separated path exists in the config schema. The keys of
+// the maps are not checked.
+func CheckConfigPath(path string) error {
+	if len(path) == 0 {
+		return fmt.Errorf("empty config path")
+	}
+	keys := strings.Split(path, ".")
+	t := reflect.TypeOf(Config{})
+	for i, key := range keys {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := findYAMLField(t, key)
+			if !ok {
+				return fmt.Errorf("unknown config key: %s", strings.Join(keys[:i+1], "."))
+			}
+			t = field.Type
+		case reflect.Map:
+			t = t.Elem()
+		default:
+			return fmt.Errorf("%s does not contain any keys", strings.Join(keys[:i], "."))
+		}
+	}
+	return nil
+}
+
+func findYAMLField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.SplitN(field.Tag.Get("yaml"), ",", 2)[0]
+		if name == key && name != "-" {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckConfigPath(t *testing.T) {
+	assert.NoError(t, CheckConfigPath("scan.blockRateLimit"))
+	assert.NoError(t, CheckConfigPath("resources.bots.0xabcd.shmSizeMib"))
+	assert.NoError(t, CheckConfigPath("agentUser.bots.0xabcd"))
+	assert.Error(t, CheckConfigPath("scan.blockRateLimitt"))
+	assert.Error(t, CheckConfigPath("scan.blockRateLimit.foo"))
+	assert.Error(t, CheckConfigPath(""))
+}
+
+func TestGetConfigValue(t *testing.T) {
+	cfg := Config{ChainID: 137, Scan: ScannerConfig{BlockRateLimit: 200}}
+	cfg.AgentNetwork.DNS.Servers = []string{"1.1.1.1"}
+
+	value, err := GetConfigValue(cfg, "chainId")
+	assert.NoError(t, err)
+	assert.Equal(t, "137\n", string(value))
+
+	value, err = GetConfigValue(cfg, "agentNetwork.dns.servers")
+	assert.NoError(t, err)
+	assert.Equal(t, "- 1.1.1.1\n", string(value))
+
+	_, err = GetConfigValue(cfg, "scan.unknown")
+	assert.Error(t, err)
+}
+
+func TestSetConfigValue(t *testing.T) {
+	updated, err := SetConfigValue([]byte(`# node config
+chainId: 1
+scan:
+  # blocks per second
+  blockRateLimit: 100
+`), "scan.blockRateLimit", "50")
+	assert.NoError(t, err)
+	assert.Equal(t, `# node config
+chainId: 1
+scan:
+  # blocks per second
+  blockRateLimit: 50
+`, string(updated))
+
+	updated, err = SetConfigValue(nil, "agentNetwork.dns.servers", "[1.1.1.1, 8.8.8.8]")
+	assert.NoError(t, err)
+	assert.Equal(t, "agentNetwork:\n  dns:\n    servers: [1.1.1.1, 8.8.8.8]\n", string(updated))
+
+	_, err = SetConfigValue(nil, "scan.blockRateLimitt", "50")
+	assert.Error(t, err)
+}
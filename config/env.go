@@ -4,6 +4,12 @@ const (
 	EnvHostFortaDir = "HOST_FORTA_DIR" // for retrieving forta dir path on the host os
 	EnvDevelopment  = "FORTA_DEVELOPMENT"
 	EnvReleaseInfo  = "FORTA_RELEASE_INFO"
+	// overrides the release channel in the config
+	EnvReleaseChannel = "FORTA_RELEASE_CHANNEL"
+	// the subnets of the host routes, which the supervisor can not see from the container
+	EnvHostRoutes = "FORTA_HOST_ROUTES"
+	// the token which the node containers authenticate to the message bus with
+	EnvNatsToken = "FORTA_NATS_TOKEN"
 
 	// Agent env vars
 	EnvJsonRpcHost     = "JSON_RPC_HOST"
@@ -14,6 +20,12 @@ const (
 	EnvFortaBotID      = "FORTA_BOT_ID"
 	EnvFortaBotOwner   = "FORTA_BOT_OWNER"
 	EnvFortaChainID    = "FORTA_CHAIN_ID"
+	EnvFortaTLSCert    = "FORTA_TLS_CERT"
+	EnvFortaTLSKey     = "FORTA_TLS_KEY"
+	EnvFortaTLSCA      = "FORTA_TLS_CA"
+	// read by the NVIDIA container runtime
+	EnvNvidiaVisibleDevices     = "NVIDIA_VISIBLE_DEVICES"
+	EnvNvidiaDriverCapabilities = "NVIDIA_DRIVER_CAPABILITIES"
 )
 
 // EnvDefaults contain default values for one env.
@@ -0,0 +1,280 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentConfigVersion is the latest config file schema version.
+const CurrentConfigVersion = 1
+
+const configVersionKey = "version"
+
+// configRename moves the value of an old config key to a new one. The keys are dot-separated YAML paths.
+type configRename struct {
+	From string
+	To   string
+}
+
+// configMigration upgrades the config file from the previous schema version to this one.
+type configMigration struct {
+	Version int
+	Renames []configRename
+	// migrates the values which can not be expressed as renames
+	Migrate func(root *yaml.Node, report *MigrationReport)
+}
+
+var configMigrations = []configMigration{
+	{
+		Version: 1,
+		Migrate: migrateTrackPrereleases,
+	},
+}
+
+// MigrationReport tells which deprecated config fields were migrated.
+type MigrationReport struct {
+	FromVersion int      `json:"fromVersion"`
+	ToVersion   int      `json:"toVersion"`
+	Deprecated  []string `json:"deprecated"`
+}
+
+// HasChanges tells if the config file needs to be updated.
+func (report *MigrationReport) HasChanges() bool {
+	return report.FromVersion != report.ToVersion || len(report.Deprecated) > 0
+}
+
+func (report *MigrationReport) addDeprecated(format string, args ...interface{}) {
+	report.Deprecated = append(report.Deprecated, fmt.Sprintf(format, args...))
+}
+
+// MigrateConfig migrates the old keys in the YAML config file to the current schema.
+// The comments and the order of the keys are preserved.
+func MigrateConfig(data []byte) ([]byte, *MigrationReport, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+	if len(doc.Content) == 0 {
+		// empty config file: nothing to migrate
+		return data, &MigrationReport{FromVersion: CurrentConfigVersion, ToVersion: CurrentConfigVersion}, nil
+	}
+	report, err := MigrateConfigNode(doc.Content[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	if !report.HasChanges() {
+		return data, report, nil
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), report, nil
+}
+
+// SetConfigValues sets the string values at the dot-separated paths in the YAML config file.
+// The comments and the order of the other keys are preserved.
+func SetConfigValues(data []byte, values map[string]string) ([]byte, error) {
+	nodes := make(map[string]*yaml.Node)
+	for path, value := range values {
+		nodes[path] = stringNode(value)
+	}
+	return setConfigNodes(data, nodes)
+}
+
+// setConfigNodes sets the nodes at the dot-separated paths in the YAML config file.
+func setConfigNodes(data []byte, nodes map[string]*yaml.Node) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config file must contain a mapping at the top level")
+	}
+	var paths []string
+	for path := range nodes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		setConfigKey(root, strings.Split(path, "."), nodes[path])
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MigrateConfigNode migrates the config mapping node in place.
+func MigrateConfigNode(root *yaml.Node) (*MigrationReport, error) {
+	return migrateConfigNode(root, configMigrations)
+}
+
+func migrateConfigNode(root *yaml.Node, migrations []configMigration) (*MigrationReport, error) {
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config file must contain a mapping at the top level")
+	}
+	report := &MigrationReport{}
+	if versionNode := findConfigKey(root, configVersionKey); versionNode != nil {
+		version, err := strconv.Atoi(versionNode.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config version '%s'", versionNode.Value)
+		}
+		report.FromVersion = version
+	}
+	report.ToVersion = report.FromVersion
+	if report.FromVersion > CurrentConfigVersion {
+		return nil, fmt.Errorf(
+			"config version %d is newer than the supported version %d - please upgrade the node",
+			report.FromVersion, CurrentConfigVersion,
+		)
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= report.FromVersion {
+			continue
+		}
+		for _, rename := range migration.Renames {
+			renameConfigKey(root, rename, report)
+		}
+		if migration.Migrate != nil {
+			migration.Migrate(root, report)
+		}
+		report.ToVersion = migration.Version
+	}
+
+	if report.ToVersion != report.FromVersion {
+		setConfigVersion(root, report.ToVersion)
+	}
+	return report, nil
+}
+
+// migrateTrackPrereleases replaces autoUpdate.trackPrereleases with the canary release channel.
+func migrateTrackPrereleases(root *yaml.Node, report *MigrationReport) {
+	autoUpdate := findConfigKey(root, "autoUpdate")
+	if autoUpdate == nil || autoUpdate.Kind != yaml.MappingNode {
+		return
+	}
+	trackPrereleases := findConfigKey(autoUpdate, "trackPrereleases")
+	if trackPrereleases == nil {
+		return
+	}
+	removeConfigKey(autoUpdate, "trackPrereleases")
+	var enabled bool
+	if err := trackPrereleases.Decode(&enabled); err != nil || !enabled {
+		report.addDeprecated("autoUpdate.trackPrereleases is deprecated and was removed")
+		return
+	}
+	releaseChannel := findConfigKey(autoUpdate, "releaseChannel")
+	if releaseChannel != nil && releaseChannel.Value != "" && releaseChannel.Value != ReleaseChannelStable {
+		report.addDeprecated(
+			"autoUpdate.trackPrereleases is deprecated and was removed (autoUpdate.releaseChannel is already %s)",
+			releaseChannel.Value,
+		)
+		return
+	}
+	setConfigKey(autoUpdate, []string{"releaseChannel"}, stringNode(ReleaseChannelCanary))
+	report.addDeprecated("autoUpdate.trackPrereleases is deprecated and was migrated to autoUpdate.releaseChannel: canary")
+}
+
+func renameConfigKey(root *yaml.Node, rename configRename, report *MigrationReport) {
+	fromPath := strings.Split(rename.From, ".")
+	parent := findConfigPath(root, fromPath[:len(fromPath)-1])
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return
+	}
+	value := findConfigKey(parent, fromPath[len(fromPath)-1])
+	if value == nil {
+		return
+	}
+	removeConfigKey(parent, fromPath[len(fromPath)-1])
+	toPath := strings.Split(rename.To, ".")
+	if findConfigPath(root, toPath) != nil {
+		report.addDeprecated("%s is deprecated and was removed (%s is already set)", rename.From, rename.To)
+		return
+	}
+	setConfigKey(root, toPath, value)
+	report.addDeprecated("%s is deprecated and was migrated to %s", rename.From, rename.To)
+}
+
+func findConfigPath(node *yaml.Node, path []string) *yaml.Node {
+	for _, key := range path {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return nil
+		}
+		node = findConfigKey(node, key)
+	}
+	return node
+}
+
+func findConfigKey(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func removeConfigKey(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// setConfigKey sets the value at the path and creates the missing mappings on the way.
+func setConfigKey(mapping *yaml.Node, path []string, value *yaml.Node) {
+	for i, key := range path {
+		existing := findConfigKey(mapping, key)
+		if i == len(path)-1 {
+			if existing != nil {
+				*existing = *value
+				return
+			}
+			mapping.Content = append(mapping.Content, stringNode(key), value)
+			return
+		}
+		if existing == nil || existing.Kind != yaml.MappingNode {
+			existing = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			removeConfigKey(mapping, key)
+			mapping.Content = append(mapping.Content, stringNode(key), existing)
+		}
+		mapping = existing
+	}
+}
+
+func setConfigVersion(root *yaml.Node, version int) {
+	versionNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(version)}
+	if existing := findConfigKey(root, configVersionKey); existing != nil {
+		*existing = *versionNode
+		return
+	}
+	// put the version on top and keep the file header on top of it
+	keyNode := stringNode(configVersionKey)
+	if len(root.Content) > 0 {
+		keyNode.HeadComment = root.Content[0].HeadComment
+		root.Content[0].HeadComment = ""
+	}
+	root.Content = append([]*yaml.Node{keyNode, versionNode}, root.Content...)
+}
+
+func stringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
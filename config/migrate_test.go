@@ -0,0 +1,126 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMigrateConfig_TrackPrereleases(t *testing.T) {
+	data := []byte(`# header comment
+chainId: 1
+autoUpdate:
+  # tracks the rc and the canary releases
+  trackPrereleases: true
+`)
+
+	migrated, report, err := MigrateConfig(data)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.FromVersion)
+	assert.Equal(t, CurrentConfigVersion, report.ToVersion)
+	assert.Len(t, report.Deprecated, 1)
+
+	var cfg Config
+	assert.NoError(t, yaml.Unmarshal(migrated, &cfg))
+	assert.Equal(t, CurrentConfigVersion, cfg.Version)
+	assert.Equal(t, 1, cfg.ChainID)
+	assert.False(t, cfg.AutoUpdate.TrackPrereleases)
+	assert.Equal(t, ReleaseChannelCanary, cfg.AutoUpdate.ReleaseChannel)
+	assert.Contains(t, string(migrated), "# header comment\nversion: 1\n")
+
+	// migrating again does not change anything
+	again, report, err := MigrateConfig(migrated)
+	assert.NoError(t, err)
+	assert.False(t, report.HasChanges())
+	assert.Equal(t, migrated, again)
+}
+
+func TestMigrateConfig_KeepReleaseChannel(t *testing.T) {
+	data := []byte(`autoUpdate:
+  trackPrereleases: true
+  releaseChannel: rc
+`)
+
+	migrated, report, err := MigrateConfig(data)
+	assert.NoError(t, err)
+	assert.Len(t, report.Deprecated, 1)
+
+	var cfg Config
+	assert.NoError(t, yaml.Unmarshal(migrated, &cfg))
+	assert.Equal(t, ReleaseChannelRC, cfg.AutoUpdate.ReleaseChannel)
+}
+
+func TestMigrateConfig_NewerVersion(t *testing.T) {
+	_, _, err := MigrateConfig([]byte("version: 1000\n"))
+	assert.Error(t, err)
+}
+
+func TestMigrateConfig_Empty(t *testing.T) {
+	migrated, report, err := MigrateConfig(nil)
+	assert.NoError(t, err)
+	assert.False(t, report.HasChanges())
+	assert.Empty(t, migrated)
+}
+
+func TestMigrateConfigNode_Renames(t *testing.T) {
+	migrations := []configMigration{
+		{
+			Version: 1,
+			Renames: []configRename{
+				{From: "scan.oldRateLimit", To: "scan.blockRateLimit"},
+				{From: "oldLog.level", To: "log.level"},
+			},
+		},
+		{
+			Version: 2,
+			Renames: []configRename{
+				{From: "scan.blockRateLimit", To: "jsonRpcProxy.rateLimit.rate"},
+			},
+		},
+	}
+
+	var doc yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte(`scan:
+  oldRateLimit: 100
+oldLog:
+  level: debug
+log:
+  level: info
+`), &doc))
+	root := doc.Content[0]
+
+	report, err := migrateConfigNode(root, migrations)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.ToVersion)
+	assert.Len(t, report.Deprecated, 3)
+
+	// the renamed key is moved along the versions
+	assert.Equal(t, "100", findConfigPath(root, []string{"jsonRpcProxy", "rateLimit", "rate"}).Value)
+	assert.Nil(t, findConfigPath(root, []string{"scan", "blockRateLimit"}))
+	// the new key wins if both are set
+	assert.Equal(t, "info", findConfigPath(root, []string{"log", "level"}).Value)
+	assert.Equal(t, "2", findConfigKey(root, configVersionKey).Value)
+}
+
+func TestSetConfigValues(t *testing.T) {
+	updated, err := SetConfigValues([]byte(`# node config
+chainId: 1
+scannerPool:
+  poolId: "1"
+`), map[string]string{
+		"scannerPool.poolId":       "5",
+		"scannerPool.ownerAddress": "0x3DC45b47B7559Ca3b231E5384D825F9B461A0398",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `# node config
+chainId: 1
+scannerPool:
+  poolId: "5"
+  ownerAddress: 0x3DC45b47B7559Ca3b231E5384D825F9B461A0398
+`, string(updated))
+
+	updated, err = SetConfigValues(nil, map[string]string{"scannerPool.poolId": "5"})
+	assert.NoError(t, err)
+	assert.Equal(t, "scannerPool:\n  poolId: \"5\"\n", string(updated))
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// IsPaused tells if the node is marked as paused in the Forta dir, either by the operator
+// or by the inspection actions.
+func IsPaused(fortaDir string) bool {
+	return hasMark(fortaDir, DefaultPausedFileName) || hasMark(fortaDir, DefaultInspectionPausedFileName)
+}
+
+// IsPausedByInspection tells if the node is marked as paused by the inspection actions.
+func IsPausedByInspection(fortaDir string) bool {
+	return hasMark(fortaDir, DefaultInspectionPausedFileName)
+}
+
+// SetPaused marks the node as paused in the Forta dir or removes the mark.
+func SetPaused(fortaDir string, paused bool) error {
+	return setMark(fortaDir, DefaultPausedFileName, paused)
+}
+
+// SetPausedByInspection marks the node as paused by the inspection actions or removes the mark.
+// The operator mark is not affected.
+func SetPausedByInspection(fortaDir string, paused bool) error {
+	return setMark(fortaDir, DefaultInspectionPausedFileName, paused)
+}
+
+func hasMark(fortaDir, fileName string) bool {
+	_, err := os.Stat(path.Join(fortaDir, fileName))
+	return err == nil
+}
+
+func setMark(fortaDir, fileName string, marked bool) error {
+	markFile := path.Join(fortaDir, fileName)
+	if marked {
+		return os.WriteFile(markFile, []byte(time.Now().UTC().Format(time.RFC3339)), 0644)
+	}
+	err := os.Remove(markFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// GetDisabledBots returns the bots which are disabled locally in the Forta dir.
+func GetDisabledBots(fortaDir string) ([]string, error) {
+	b, err := os.ReadFile(path.Join(fortaDir, DefaultDisabledBotsFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var botIDs []string
+	if err := json.Unmarshal(b, &botIDs); err != nil {
+		return nil, err
+	}
+	return botIDs, nil
+}
+
+// SetBotDisabled disables a bot locally in the Forta dir or enables it back.
+func SetBotDisabled(fortaDir, botID string, disabled bool) error {
+	botIDs, err := GetDisabledBots(fortaDir)
+	if err != nil {
+		return err
+	}
+	botID = strings.ToLower(botID)
+	var updated []string
+	for _, disabledBotID := range botIDs {
+		if disabledBotID != botID {
+			updated = append(updated, disabledBotID)
+		}
+	}
+	if disabled {
+		updated = append(updated, botID)
+	}
+	disabledBotsFile := path.Join(fortaDir, DefaultDisabledBotsFileName)
+	if len(updated) == 0 {
+		err := os.Remove(disabledBotsFile)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	sort.Strings(updated)
+	b, err := json.Marshal(updated)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(disabledBotsFile, b, 0644)
+}
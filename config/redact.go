@@ -0,0 +1,60 @@
+package config
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// RedactedValue replaces the secrets.
+const RedactedValue = "[REDACTED]"
+
+var (
+	// secretKeyPattern matches the config keys which contain the secrets, like the passwords,
+	// the request headers and the env vars of the bots.
+	secretKeyPattern = regexp.MustCompile(`(?i)(passphrase|password|secret|token|apikey|privatekey|headers|vars)`)
+	// secretPathPattern matches the URL path segments which look like API keys.
+	secretPathPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{20,}$`)
+)
+
+// IsSecretKey tells if the value of the config key is a secret.
+func IsSecretKey(key string) bool {
+	return secretKeyPattern.MatchString(key)
+}
+
+// RedactURL redacts the user info, the query values and the path segments which look like API keys.
+func RedactURL(value string) (string, bool) {
+	u, err := url.Parse(value)
+	if err != nil || len(u.Scheme) == 0 || len(u.Host) == 0 {
+		return "", false
+	}
+	var redacted bool
+	if u.User != nil {
+		u.User = url.User(RedactedValue)
+		redacted = true
+	}
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		if secretPathPattern.MatchString(segment) {
+			segments[i] = RedactedValue
+			redacted = true
+		}
+	}
+	if redacted {
+		u.Path = strings.Join(segments, "/")
+		u.RawPath = ""
+	}
+	if len(u.RawQuery) > 0 {
+		query := u.Query()
+		for key := range query {
+			query.Set(key, RedactedValue)
+		}
+		u.RawQuery = query.Encode()
+		redacted = true
+	}
+	if !redacted {
+		return "", false
+	}
+	redactedURL, _ := url.PathUnescape(u.String())
+	return redactedURL, true
+}
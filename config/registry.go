@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"path"
+)
+
+// DefaultRegistryStateDirName is the directory in the Forta dir which keeps the node state of each
+// registry profile.
+const DefaultRegistryStateDirName = "registries"
+
+// ApplyRegistryProfile replaces the registry settings with the settings of the selected profile.
+func ApplyRegistryProfile(cfg *Config) error {
+	profileName := cfg.Registry.Profile
+	if len(profileName) == 0 {
+		return nil
+	}
+	profile, ok := cfg.Registry.Profiles[profileName]
+	if !ok {
+		return fmt.Errorf("unknown registry profile: %s", profileName)
+	}
+	if profile.ChainID > 0 {
+		cfg.Registry.ChainID = profile.ChainID
+	}
+	if len(profile.JsonRpc.Url) > 0 {
+		cfg.Registry.JsonRpc = profile.JsonRpc
+	}
+	if len(profile.ContainerRegistry) > 0 {
+		cfg.Registry.ContainerRegistry = profile.ContainerRegistry
+	}
+	cfg.ENSConfig.DefaultContract = false
+	cfg.ENSConfig.ContractAddress = profile.ENSContractAddress
+	return nil
+}
+
+// StateDir returns the directory of the node state which depends on the registry, like the bot
+// assignments and the last batch. It is the Forta dir unless a registry profile is selected.
+func (cfg *Config) StateDir() string {
+	if len(cfg.Registry.Profile) == 0 {
+		return cfg.FortaDir
+	}
+	return path.Join(cfg.FortaDir, DefaultRegistryStateDirName, cfg.Registry.Profile)
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyRegistryProfile(t *testing.T) {
+	cfg := Config{
+		FortaDir: "/forta",
+		Registry: RegistryConfig{
+			ChainID:           137,
+			JsonRpc:           JsonRpcConfig{Url: "https://polygon"},
+			ContainerRegistry: "disco.forta.network",
+			Profiles: map[string]RegistryProfileConfig{
+				"testnet": {
+					ChainID:            80001,
+					JsonRpc:            JsonRpcConfig{Url: "https://mumbai"},
+					ENSContractAddress: "0x3DC45b47B7559Ca3b231E5384D825F9B461A0398",
+				},
+			},
+		},
+		ENSConfig: ENSConfig{DefaultContract: true},
+	}
+
+	// the default registry
+	assert.NoError(t, ApplyRegistryProfile(&cfg))
+	assert.Equal(t, uint64(137), cfg.Registry.ChainID)
+	assert.Equal(t, "/forta", cfg.StateDir())
+
+	cfg.Registry.Profile = "testnet"
+	assert.NoError(t, ApplyRegistryProfile(&cfg))
+	assert.Equal(t, uint64(80001), cfg.Registry.ChainID)
+	assert.Equal(t, "https://mumbai", cfg.Registry.JsonRpc.Url)
+	assert.Equal(t, "disco.forta.network", cfg.Registry.ContainerRegistry)
+	assert.False(t, cfg.ENSConfig.DefaultContract)
+	assert.Equal(t, "0x3DC45b47B7559Ca3b231E5384D825F9B461A0398", cfg.ENSConfig.ContractAddress)
+	assert.Equal(t, "/forta/registries/testnet", cfg.StateDir())
+
+	cfg.Registry.Profile = "staging"
+	assert.Error(t, ApplyRegistryProfile(&cfg))
+}
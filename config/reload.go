@@ -0,0 +1,98 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ReloadableFields are the config fields which can be applied at runtime without restarting the services.
+var ReloadableFields = []string{
+	"log.level",
+	"jsonRpcProxy.rateLimit",
+	"publish.batch.intervalSeconds",
+	"publish.batch.maxAlerts",
+	"publish.batch.autoTune",
+	"publish.quota",
+	"publish.sampling",
+	"publish.severity",
+	"publish.processors",
+	"inspection.blockInterval",
+	"resources",
+	"restart",
+	"agentNetwork",
+	"agentUser.user",
+	"agentUser.bots",
+}
+
+// ReloadReport tells which config fields were applied at runtime and which require a restart.
+type ReloadReport struct {
+	Applied         []string `json:"applied"`
+	RequiresRestart []string `json:"requiresRestart"`
+}
+
+// HasChanges tells if there were any changes in the config.
+func (report *ReloadReport) HasChanges() bool {
+	return len(report.Applied) > 0 || len(report.RequiresRestart) > 0
+}
+
+// ApplyReloadable copies the reloadable fields from the updated config to the current config
+// and reports the changes in the rest of the fields as requiring a restart.
+func ApplyReloadable(current *Config, updated Config) *ReloadReport {
+	report := &ReloadReport{}
+
+	curr := reflect.ValueOf(current).Elem()
+	upd := reflect.ValueOf(&updated).Elem()
+	for _, fieldPath := range ReloadableFields {
+		currField := fieldByYamlPath(curr, fieldPath)
+		updField := fieldByYamlPath(upd, fieldPath)
+		if !currField.IsValid() || !updField.IsValid() {
+			continue
+		}
+		if reflect.DeepEqual(currField.Interface(), updField.Interface()) {
+			continue
+		}
+		currField.Set(updField)
+		report.Applied = append(report.Applied, fieldPath)
+	}
+
+	// after the reloadable fields are applied, any remaining differences require a restart
+	for i := 0; i < curr.NumField(); i++ {
+		name := yamlName(curr.Type().Field(i))
+		if name == "" {
+			continue
+		}
+		if !reflect.DeepEqual(curr.Field(i).Interface(), upd.Field(i).Interface()) {
+			report.RequiresRestart = append(report.RequiresRestart, name)
+		}
+	}
+
+	return report
+}
+
+func fieldByYamlPath(v reflect.Value, fieldPath string) reflect.Value {
+	for _, name := range strings.Split(fieldPath, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}
+		}
+		var found bool
+		for i := 0; i < v.NumField(); i++ {
+			if yamlName(v.Type().Field(i)) == name {
+				v = v.Field(i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return reflect.Value{}
+		}
+	}
+	return v
+}
+
+func yamlName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("yaml"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
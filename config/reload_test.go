@@ -0,0 +1,48 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyReloadable(t *testing.T) {
+	interval := 30
+	current := Config{ChainID: 1}
+	current.Log.Level = "info"
+	current.Log.MaxLogFiles = 10
+
+	updated := current
+	updated.Log.Level = "debug"
+	updated.Log.MaxLogFiles = 20
+	updated.Publish.Batch.IntervalSeconds = &interval
+	updated.ChainID = 137
+
+	report := ApplyReloadable(&current, updated)
+	assert.True(t, report.HasChanges())
+	assert.Equal(t, []string{"log.level", "publish.batch.intervalSeconds"}, report.Applied)
+	assert.Equal(t, []string{"chainId", "log"}, report.RequiresRestart)
+
+	assert.Equal(t, "debug", current.Log.Level)
+	assert.Equal(t, 30, *current.Publish.Batch.IntervalSeconds)
+	// non-reloadable values are not applied
+	assert.Equal(t, 1, current.ChainID)
+	assert.Equal(t, 10, current.Log.MaxLogFiles)
+}
+
+func TestApplyReloadable_SeverityFloor(t *testing.T) {
+	current := Config{}
+	updated := current
+	updated.Publish.Severity.MinSeverity = "HIGH"
+
+	report := ApplyReloadable(&current, updated)
+	assert.Equal(t, []string{"publish.severity"}, report.Applied)
+	assert.Empty(t, report.RequiresRestart)
+	assert.Equal(t, "HIGH", current.Publish.Severity.MinSeverity)
+}
+
+func TestApplyReloadable_NoChanges(t *testing.T) {
+	current := Config{ChainID: 1}
+	report := ApplyReloadable(&current, current)
+	assert.False(t, report.HasChanges())
+}
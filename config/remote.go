@@ -0,0 +1,179 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/forta-network/forta-core-go/security"
+	"gopkg.in/yaml.v3"
+)
+
+// the first line of the overrides file which keeps the version of the applied document
+const remoteConfigVersionHeader = "# remote config version: %d - do not edit\n"
+
+// RemoteConfigDocument contains the config overrides signed by the operator.
+type RemoteConfigDocument struct {
+	// must increase with every change so that the older documents can not be applied again
+	Version int64 `json:"version"`
+	// the overrides in the config file format
+	Config    string `json:"config"`
+	Signer    string `json:"signer"`
+	Signature string `json:"signature"`
+}
+
+func (doc *RemoteConfigDocument) signingMessage() []byte {
+	return []byte(fmt.Sprintf("forta-remote-config\n%d\n%s", doc.Version, doc.Config))
+}
+
+// SignRemoteConfig creates a remote config document with the overrides and signs it with the key.
+func SignRemoteConfig(key *keystore.Key, version int64, overrides string) (*RemoteConfigDocument, error) {
+	doc := &RemoteConfigDocument{
+		Version: version,
+		Config:  overrides,
+	}
+	sig, err := security.SignBytes(key, doc.signingMessage())
+	if err != nil {
+		return nil, err
+	}
+	doc.Signer = sig.Signer
+	doc.Signature = sig.Signature
+	return doc, nil
+}
+
+// Verify checks that the document is signed by one of the signers.
+func (doc *RemoteConfigDocument) Verify(signers []string) error {
+	var allowed bool
+	for _, signer := range signers {
+		if strings.EqualFold(signer, doc.Signer) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("signer %s is not allowed", doc.Signer)
+	}
+	signer := common.HexToAddress(doc.Signer).Hex()
+	if err := security.VerifySignature(doc.signingMessage(), signer, doc.Signature); err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	return nil
+}
+
+// FilterReloadableOverrides returns the overrides with only the reloadable fields and the paths of
+// the applied and the ignored fields.
+func FilterReloadableOverrides(data []byte) (filtered []byte, applied, ignored []string, err error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil, nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, nil, errors.New("config overrides must contain a mapping at the top level")
+	}
+	kept, applied, ignored := filterReloadableNode(root, "")
+	if kept == nil {
+		return nil, applied, ignored, nil
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(kept); err != nil {
+		return nil, nil, nil, err
+	}
+	return buf.Bytes(), applied, ignored, nil
+}
+
+// filterReloadableNode returns a copy of the mapping with only the reloadable fields, or nil if
+// none of the fields are reloadable.
+func filterReloadableNode(node *yaml.Node, prefix string) (kept *yaml.Node, applied, ignored []string) {
+	kept = &yaml.Node{Kind: yaml.MappingNode, Tag: node.Tag}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		fieldPath := prefix + key.Value
+		switch {
+		case isReloadableField(fieldPath):
+			kept.Content = append(kept.Content, key, value)
+			applied = append(applied, fieldPath)
+
+		case value.Kind == yaml.MappingNode && hasReloadableSubfield(fieldPath):
+			keptValue, subApplied, subIgnored := filterReloadableNode(value, fieldPath+".")
+			if keptValue != nil {
+				kept.Content = append(kept.Content, key, keptValue)
+			}
+			applied = append(applied, subApplied...)
+			ignored = append(ignored, subIgnored...)
+
+		default:
+			ignored = append(ignored, fieldPath)
+		}
+	}
+	if len(kept.Content) == 0 {
+		return nil, applied, ignored
+	}
+	return kept, applied, ignored
+}
+
+func isReloadableField(fieldPath string) bool {
+	for _, reloadable := range ReloadableFields {
+		if fieldPath == reloadable {
+			return true
+		}
+	}
+	return false
+}
+
+func hasReloadableSubfield(fieldPath string) bool {
+	for _, reloadable := range ReloadableFields {
+		if strings.HasPrefix(reloadable, fieldPath+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// MakeRemoteOverridesFile prefixes the filtered overrides with the version of the document.
+func MakeRemoteOverridesFile(version int64, filtered []byte) []byte {
+	return append([]byte(fmt.Sprintf(remoteConfigVersionHeader, version)), filtered...)
+}
+
+// GetRemoteConfigVersion returns the version of the overrides which were applied last.
+func GetRemoteConfigVersion(fortaDir string) (int64, error) {
+	b, err := os.ReadFile(path.Join(fortaDir, DefaultRemoteConfigFileName))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var version int64
+	_, err = fmt.Sscanf(string(b), remoteConfigVersionHeader, &version)
+	return version, err
+}
+
+// applyRemoteOverrides decodes the reloadable overrides pulled from the remote config URL onto the config.
+func applyRemoteOverrides(fortaDir string, cfg *Config) error {
+	if len(cfg.RemoteConfig.URL) == 0 {
+		return nil
+	}
+	b, err := os.ReadFile(path.Join(fortaDir, DefaultRemoteConfigFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	filtered, _, _, err := FilterReloadableOverrides(b)
+	if err != nil || len(filtered) == 0 {
+		return err
+	}
+	return yaml.Unmarshal(filtered, cfg)
+}
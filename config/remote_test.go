@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+const testOverrides = `log:
+  level: debug
+  maxLogFiles: 20
+chainId: 137
+publish:
+  batch:
+    maxAlerts: 100
+  apiUrl: https://example.com
+`
+
+func TestFilterReloadableOverrides(t *testing.T) {
+	filtered, applied, ignored, err := FilterReloadableOverrides([]byte(testOverrides))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"log.level", "publish.batch.maxAlerts"}, applied)
+	assert.Equal(t, []string{"log.maxLogFiles", "chainId", "publish.apiUrl"}, ignored)
+	assert.Equal(t, "log:\n  level: debug\npublish:\n  batch:\n    maxAlerts: 100\n", string(filtered))
+
+	filtered, _, ignored, err = FilterReloadableOverrides([]byte("chainId: 137\n"))
+	assert.NoError(t, err)
+	assert.Nil(t, filtered)
+	assert.Equal(t, []string{"chainId"}, ignored)
+}
+
+func TestRemoteConfigDocument(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	key := &keystore.Key{PrivateKey: privateKey, Address: crypto.PubkeyToAddress(privateKey.PublicKey)}
+
+	doc, err := SignRemoteConfig(key, 2, testOverrides)
+	assert.NoError(t, err)
+	assert.NoError(t, doc.Verify([]string{"0x1111111111111111111111111111111111111111", key.Address.Hex()}))
+	assert.Error(t, doc.Verify([]string{"0x1111111111111111111111111111111111111111"}))
+
+	// the version is signed
+	doc.Version = 3
+	assert.Error(t, doc.Verify([]string{key.Address.Hex()}))
+}
+
+func TestApplyRemoteOverrides(t *testing.T) {
+	fortaDir := t.TempDir()
+	filtered, _, _, err := FilterReloadableOverrides([]byte(testOverrides))
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(
+		path.Join(fortaDir, DefaultRemoteConfigFileName), MakeRemoteOverridesFile(5, filtered), 0644,
+	))
+
+	version, err := GetRemoteConfigVersion(fortaDir)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), version)
+
+	cfg := Config{ChainID: 1}
+	cfg.Log.Level = "info"
+	cfg.Log.MaxLogFiles = 10
+
+	// not applied when the remote config is not enabled
+	assert.NoError(t, applyRemoteOverrides(fortaDir, &cfg))
+	assert.Equal(t, "info", cfg.Log.Level)
+
+	cfg.RemoteConfig.URL = "https://example.com/config.json"
+	assert.NoError(t, applyRemoteOverrides(fortaDir, &cfg))
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, 100, *cfg.Publish.Batch.MaxAlerts)
+	assert.Equal(t, 10, cfg.Log.MaxLogFiles)
+	assert.Equal(t, 1, cfg.ChainID)
+}
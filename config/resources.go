@@ -2,8 +2,10 @@ package config
 
 // AgentResourceLimits contain the agent resource limits data.
 type AgentResourceLimits struct {
-	CPUQuota int64 // in microseconds
-	Memory   int64 // in bytes
+	CPUQuota    int64 // in microseconds
+	Memory      int64 // in bytes
+	EgressKbit  int64
+	IngressKbit int64
 }
 
 // GetAgentResourceLimits calculates and returns the resource limits by
@@ -25,14 +27,46 @@ func GetAgentResourceLimits(resourcesCfg ResourcesConfig) *AgentResourceLimits {
 		limits.Memory = int64(resourcesCfg.AgentMaxMemoryMiB * 104858)
 	}
 
+	limits.EgressKbit = mbpsToKbit(resourcesCfg.AgentMaxEgressMbps)
+	limits.IngressKbit = mbpsToKbit(resourcesCfg.AgentMaxIngressMbps)
+
 	return &limits
 }
 
+// GetMaxBots returns how many bots fit the host resources after the reserved resources, with the agent
+// resource limits. The max bot count in the admission config is used if it is lower. Zero host values
+// are ignored and zero return value means no limit.
+func GetMaxBots(resourcesCfg ResourcesConfig, hostMemory int64, hostCPUs int) int {
+	admission := resourcesCfg.Admission
+	maxBots := admission.MaxBots
+	limitTo := func(n int64) {
+		if n < 1 {
+			n = 1 // always leave room for one bot
+		}
+		if maxBots == 0 || int(n) < maxBots {
+			maxBots = int(n)
+		}
+	}
+
+	limits := GetAgentResourceLimits(resourcesCfg)
+	if limits.Memory > 0 && hostMemory > 0 {
+		limitTo((hostMemory - int64(admission.ReservedMemoryMiB)*1024*1024) / limits.Memory)
+	}
+	if limits.CPUQuota > 0 && hostCPUs > 0 {
+		limitTo(CPUsToMicroseconds(float64(hostCPUs)-admission.ReservedCPUs) / limits.CPUQuota)
+	}
+	return maxBots
+}
+
 // CPUsToMicroseconds converts given CPU amount to microseconds.
 func CPUsToMicroseconds(cpus float64) int64 {
 	return int64(cpus * float64(100000))
 }
 
+func mbpsToKbit(mbps float64) int64 {
+	return int64(mbps * 1000)
+}
+
 // getDefaultCPUQuotaPerAgent returns the default CFS microseconds value allowed per agent
 func getDefaultCPUQuotaPerAgent() int64 {
 	return 20000 // just 20%
@@ -12,32 +12,67 @@ import (
 )
 
 const (
-	MetricFinding          = "finding"
-	MetricTxRequest        = "tx.request"
-	MetricTxLatency        = "tx.latency"
-	MetricTxError          = "tx.error"
-	MetricTxSuccess        = "tx.success"
-	MetricTxDrop           = "tx.drop"
-	MetricTxBlockAge       = "tx.block.age"
-	MetricTxEventAge       = "tx.event.age"
-	MetricBlockBlockAge    = "block.block.age"
-	MetricBlockEventAge    = "block.event.age"
-	MetricBlockRequest     = "block.request"
-	MetricBlockLatency     = "block.latency"
-	MetricBlockError       = "block.error"
-	MetricBlockSuccess     = "block.success"
-	MetricBlockDrop        = "block.drop"
-	MetricStop             = "agent.stop"
-	MetricJSONRPCLatency   = "jsonrpc.latency"
-	MetricJSONRPCRequest   = "jsonrpc.request"
-	MetricJSONRPCSuccess   = "jsonrpc.success"
-	MetricJSONRPCThrottled = "jsonrpc.throttled"
-	MetricFindingsDropped  = "findings.dropped"
-	MetricCombinerRequest  = "combiner.request"
-	MetricCombinerLatency  = "combiner.latency"
-	MetricCombinerError    = "combiner.error"
-	MetricCombinerSuccess  = "combiner.success"
-	MetricCombinerDrop     = "combiner.drop"
+	MetricFinding             = "finding"
+	MetricTxRequest           = "tx.request"
+	MetricTxLatency           = "tx.latency"
+	MetricTxError             = "tx.error"
+	MetricTxSuccess           = "tx.success"
+	MetricTxDrop              = "tx.drop"
+	MetricTxTrimmed           = "tx.trimmed"
+	MetricTxTooLarge          = "tx.too-large"
+	MetricTxLate              = "tx.late"
+	MetricDataDivergence      = "data.divergence"
+	MetricTxBlockAge          = "tx.block.age"
+	MetricTxEventAge          = "tx.event.age"
+	MetricBlockBlockAge       = "block.block.age"
+	MetricBlockEventAge       = "block.event.age"
+	MetricBlockRequest        = "block.request"
+	MetricBlockLatency        = "block.latency"
+	MetricBlockError          = "block.error"
+	MetricBlockSuccess        = "block.success"
+	MetricBlockDrop           = "block.drop"
+	MetricBlockTrimmed        = "block.trimmed"
+	MetricBlockTooLarge       = "block.too-large"
+	MetricStop                = "agent.stop"
+	MetricInitializeFailed    = "agent.initialize.failed"
+	MetricAgentRejected       = "agent.rejected"
+	MetricAgentRequestBytes   = "agent.request.bytes"
+	MetricAgentRequestWire    = "agent.request.wire-bytes"
+	MetricAgentTick           = "agent.tick"
+	MetricAgentTickDrop       = "agent.tick.drop"
+	MetricAgentTimeout        = "agent.timeout"
+	MetricAgentCancelled      = "agent.cancelled"
+	MetricJSONRPCLatency      = "jsonrpc.latency"
+	MetricJSONRPCRequest      = "jsonrpc.request"
+	MetricJSONRPCSuccess      = "jsonrpc.success"
+	MetricJSONRPCThrottled    = "jsonrpc.throttled"
+	MetricJSONRPCComputeUnits = "jsonrpc.compute-units"
+	MetricJSONRPCError        = "jsonrpc.error"
+	MetricJSONRPCCircuit      = "jsonrpc.circuit"
+	MetricFindingsDropped     = "findings.dropped"
+	MetricFindingsInvalid     = "findings.invalid"
+	MetricFindingsQuota       = "findings.over-quota"
+	MetricFindingsSampled     = "findings.sampled"
+	MetricFindingsBelowSev    = "findings.below-severity"
+	MetricFindingsReorged     = "findings.reorged"
+	MetricCombinerRequest     = "combiner.request"
+	MetricCombinerLatency     = "combiner.latency"
+	MetricCombinerError       = "combiner.error"
+	MetricCombinerSuccess     = "combiner.success"
+	MetricCombinerDrop        = "combiner.drop"
+	MetricCombinerTrimmed     = "combiner.trimmed"
+	MetricCombinerTooLarge    = "combiner.too-large"
+	MetricContainerOOMKill    = "container.oom-kill"
+	MetricContainerExit       = "container.exit"
+	MetricContainerRestart    = "container.restart"
+	MetricGrpcReconnect       = "agent.grpc.reconnect"
+	MetricImageFindings       = "agent.image.vulnerabilities"
+	MetricAnomalyTxDrop       = "stream.anomaly.tx-drop"
+	MetricAnomalyEmptyLogs    = "stream.anomaly.empty-logs"
+	MetricAnomalyDataGap      = "stream.anomaly.data-gap"
+	MetricServiceRestart      = "service.restart"
+	MetricServiceUptime       = "service.uptime"
+	MetricClockSkew           = "clock.skew"
 )
 
 func SendAgentMetrics(client clients.MessageClient, ms []*protocol.AgentMetric) {
@@ -80,7 +115,9 @@ func GetBlockMetrics(agt config.AgentConfig, resp *protocol.EvaluateBlockRespons
 	metrics[MetricBlockRequest] = 1
 	metrics[MetricFinding] = float64(len(resp.Findings))
 	metrics[MetricBlockLatency] = float64(resp.LatencyMs)
-	metrics[MetricBlockBlockAge] = durationMs(times.Block, times.BotRequest)
+	if !times.Block.IsZero() {
+		metrics[MetricBlockBlockAge] = durationMs(times.Block, times.BotRequest)
+	}
 	metrics[MetricBlockEventAge] = durationMs(times.Feed, times.BotRequest)
 
 	if resp.Status == protocol.ResponseStatus_ERROR {
@@ -98,7 +135,10 @@ func GetTxMetrics(agt config.AgentConfig, resp *protocol.EvaluateTxResponse, tim
 	metrics[MetricTxRequest] = 1
 	metrics[MetricFinding] = float64(len(resp.Findings))
 	metrics[MetricTxLatency] = float64(resp.LatencyMs)
-	metrics[MetricTxBlockAge] = durationMs(times.Block, times.BotRequest)
+	// zero if the chain timestamp of a pending tx is unknown
+	if !times.Block.IsZero() {
+		metrics[MetricTxBlockAge] = durationMs(times.Block, times.BotRequest)
+	}
 	metrics[MetricTxEventAge] = durationMs(times.Feed, times.BotRequest)
 
 	if resp.Status == protocol.ResponseStatus_ERROR {
@@ -141,3 +181,12 @@ func GetJSONRPCMetrics(agt config.AgentConfig, at time.Time, success, throttled
 	}
 	return createMetrics(agt.ID, at.Format(time.RFC3339), values)
 }
+
+// GetJSONRPCErrorMetrics creates a metric for each class of the upstream errors, like "jsonrpc.error.rate-limited".
+func GetJSONRPCErrorMetrics(agentID string, errorClasses map[string]int) []*protocol.AgentMetric {
+	values := make(map[string]float64)
+	for class, count := range errorClasses {
+		values[MetricJSONRPCError+"."+class] = float64(count)
+	}
+	return createMetrics(agentID, time.Now().Format(time.RFC3339), values)
+}
@@ -0,0 +1,53 @@
+package sdnotify
+
+import (
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotify(t *testing.T) {
+	r := require.New(t)
+
+	t.Setenv("NOTIFY_SOCKET", "")
+	sent, err := Notify(StateReady)
+	r.NoError(err)
+	r.False(sent)
+	r.False(Enabled())
+
+	socketPath := path.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	r.NoError(err)
+	defer conn.Close()
+
+	t.Setenv("NOTIFY_SOCKET", socketPath)
+	r.True(Enabled())
+	sent, err = Notify(StateReady, Status("healthy"))
+	r.NoError(err)
+	r.True(sent)
+
+	buf := make([]byte, 256)
+	n, err := conn.Read(buf)
+	r.NoError(err)
+	r.Equal("READY=1\nSTATUS=healthy", string(buf[:n]))
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	r := require.New(t)
+
+	t.Setenv("WATCHDOG_USEC", "")
+	r.Zero(WatchdogInterval())
+
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	r.Equal(30*time.Second, WatchdogInterval())
+
+	// the watchdog is for another process
+	t.Setenv("WATCHDOG_PID", "1")
+	r.Zero(WatchdogInterval())
+}
@@ -0,0 +1,114 @@
+package inspector
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/metrics"
+)
+
+const botsMetadataPrefix = "bots."
+
+// a bot is failing if at least half of its evaluations fail or time out
+const botFailingRate = 0.5
+
+// botEvaluationStats is the count of the evaluations of a bot in the inspection window.
+type botEvaluationStats struct {
+	requests uint64
+	errors   uint64
+	timeouts uint64
+}
+
+// botEvaluations aggregates the evaluation metrics of the bots over the inspection window, so that
+// the inspection can tell a broken bot from a broken node.
+type botEvaluations struct {
+	bots         map[string]*botEvaluationStats
+	latencySum   float64
+	latencyCount uint64
+	windowStart  time.Time
+	mu           sync.Mutex
+}
+
+func newBotEvaluations(now time.Time) *botEvaluations {
+	return &botEvaluations{
+		bots:        make(map[string]*botEvaluationStats),
+		windowStart: now,
+	}
+}
+
+// AddAgentMetrics adds the metrics which the scanner sends for the bot evaluations.
+func (be *botEvaluations) AddAgentMetrics(list *protocol.AgentMetricList) error {
+	be.mu.Lock()
+	defer be.mu.Unlock()
+
+	for _, metric := range list.Metrics {
+		switch metric.Name {
+		case metrics.MetricTxRequest, metrics.MetricBlockRequest, metrics.MetricCombinerRequest:
+			be.getStats(metric.AgentId).requests += uint64(metric.Value)
+		case metrics.MetricTxError, metrics.MetricBlockError, metrics.MetricCombinerError:
+			be.getStats(metric.AgentId).errors += uint64(metric.Value)
+		case metrics.MetricAgentTimeout:
+			be.getStats(metric.AgentId).timeouts += uint64(metric.Value)
+		case metrics.MetricTxLatency, metrics.MetricBlockLatency, metrics.MetricCombinerLatency:
+			be.latencySum += metric.Value
+			be.latencyCount++
+		}
+	}
+	return nil
+}
+
+func (be *botEvaluations) getStats(botID string) *botEvaluationStats {
+	stats, ok := be.bots[botID]
+	if !ok {
+		stats = &botEvaluationStats{}
+		be.bots[botID] = stats
+	}
+	return stats
+}
+
+// Flush returns the aggregates of the current window as the inspection metadata and starts a new window.
+// The timed out evaluations have no response so they are counted in addition to the requests.
+func (be *botEvaluations) Flush(now time.Time) map[string]string {
+	be.mu.Lock()
+	defer be.mu.Unlock()
+
+	var (
+		evaluations, errors, timeouts uint64
+		failing                       int
+	)
+	for _, stats := range be.bots {
+		botEvaluations := stats.requests + stats.timeouts
+		evaluations += botEvaluations
+		errors += stats.errors
+		timeouts += stats.timeouts
+		if botEvaluations > 0 && float64(stats.errors+stats.timeouts)/float64(botEvaluations) >= botFailingRate {
+			failing++
+		}
+	}
+	var errorRate, timeoutRate, avgLatency float64
+	if evaluations > 0 {
+		errorRate = float64(errors) / float64(evaluations)
+		timeoutRate = float64(timeouts) / float64(evaluations)
+	}
+	if be.latencyCount > 0 {
+		avgLatency = be.latencySum / float64(be.latencyCount)
+	}
+
+	metadata := map[string]string{
+		botsMetadataPrefix + "count":         strconv.Itoa(len(be.bots)),
+		botsMetadataPrefix + "failing":       strconv.Itoa(failing),
+		botsMetadataPrefix + "evaluations":   strconv.FormatUint(evaluations, 10),
+		botsMetadataPrefix + "errorRate":     strconv.FormatFloat(errorRate, 'f', 4, 64),
+		botsMetadataPrefix + "timeoutRate":   strconv.FormatFloat(timeoutRate, 'f', 4, 64),
+		botsMetadataPrefix + "avgLatencyMs":  strconv.FormatFloat(avgLatency, 'f', 0, 64),
+		botsMetadataPrefix + "windowSeconds": strconv.FormatInt(int64(now.Sub(be.windowStart).Seconds()), 10),
+	}
+
+	be.bots = make(map[string]*botEvaluationStats)
+	be.latencySum = 0
+	be.latencyCount = 0
+	be.windowStart = now
+	return metadata
+}
@@ -0,0 +1,52 @@
+package inspector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotEvaluations(t *testing.T) {
+	r := require.New(t)
+
+	start := time.Now()
+	evals := newBotEvaluations(start)
+
+	metric := func(botID, name string, value float64) *protocol.AgentMetric {
+		return &protocol.AgentMetric{AgentId: botID, Name: name, Value: value}
+	}
+	r.NoError(evals.AddAgentMetrics(&protocol.AgentMetricList{Metrics: []*protocol.AgentMetric{
+		metric("bot1", metrics.MetricTxRequest, 1),
+		metric("bot1", metrics.MetricTxLatency, 100),
+		metric("bot1", metrics.MetricTxRequest, 1),
+		metric("bot1", metrics.MetricTxLatency, 300),
+		metric("bot1", metrics.MetricBlockRequest, 1),
+		metric("bot1", metrics.MetricBlockLatency, 200),
+		metric("bot2", metrics.MetricTxRequest, 1),
+		metric("bot2", metrics.MetricTxError, 1),
+		metric("bot2", metrics.MetricTxLatency, 200),
+		metric("bot2", metrics.MetricAgentTimeout, 1),
+		metric("bot2", metrics.MetricFinding, 3),
+	}}))
+
+	metadata := evals.Flush(start.Add(time.Minute))
+	r.Equal(map[string]string{
+		"bots.count":         "2",
+		"bots.failing":       "1",
+		"bots.evaluations":   "5",
+		"bots.errorRate":     "0.2000",
+		"bots.timeoutRate":   "0.2000",
+		"bots.avgLatencyMs":  "200",
+		"bots.windowSeconds": "60",
+	}, metadata)
+
+	// the next window starts empty
+	metadata = evals.Flush(start.Add(2 * time.Minute))
+	r.Equal("0", metadata["bots.count"])
+	r.Equal("0", metadata["bots.evaluations"])
+	r.Equal("0.0000", metadata["bots.errorRate"])
+	r.Equal("60", metadata["bots.windowSeconds"])
+}
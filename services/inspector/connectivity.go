@@ -0,0 +1,162 @@
+package inspector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/inspect"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// The indicators of the IPv4 connectivity checks. The reachability and the path indicators are
+// unknown if the previous check failed.
+//
+// The inspector runs on the node network which does not have IPv6 enabled, so an IPv6 check or
+// the interface MTU from inside the container would describe the Docker network instead of the host.
+const (
+	IndicatorNetworkIPv4DNS       = "network.ipv4.dns"
+	IndicatorNetworkIPv4Reachable = "network.ipv4.reachable"
+	IndicatorNetworkIPv4Path      = "network.ipv4.path"
+)
+
+const maxConnectivityResponseSize = 64 * 1024
+
+type ipStack struct {
+	name       string
+	ipNetwork  string
+	tcpNetwork string
+
+	dnsIndicator       string
+	reachableIndicator string
+	pathIndicator      string
+}
+
+var ipStacks = []ipStack{
+	{
+		name: "ipv4", ipNetwork: "ip4", tcpNetwork: "tcp4",
+		dnsIndicator:       IndicatorNetworkIPv4DNS,
+		reachableIndicator: IndicatorNetworkIPv4Reachable,
+		pathIndicator:      IndicatorNetworkIPv4Path,
+	},
+}
+
+// checkConnectivity checks the DNS resolution, the reachability and the path of the IP stacks
+// concurrently and returns the inspection indicators and metadata.
+func checkConnectivity(ctx context.Context, cfg config.ConnectivityCheckConfig) (map[string]float64, map[string]string) {
+	indicators := make(map[string]float64)
+	metadata := make(map[string]string)
+
+	target, err := url.Parse(cfg.URL)
+	if err != nil {
+		log.WithError(err).Warn("invalid connectivity check url")
+		return indicators, metadata
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, stack := range ipStacks {
+		wg.Add(1)
+		go func(stack ipStack) {
+			defer wg.Done()
+			stackIndicators, stackMetadata := checkStack(ctx, target, stack)
+			mu.Lock()
+			defer mu.Unlock()
+			for key, value := range stackIndicators {
+				indicators[key] = value
+			}
+			for key, value := range stackMetadata {
+				metadata[key] = value
+			}
+		}(stack)
+	}
+	wg.Wait()
+	return indicators, metadata
+}
+
+// checkStack resolves the target host, connects to it and sends a request over one IP stack. A request
+// which fails after a successful connection usually means that the large packets are dropped on the path.
+func checkStack(ctx context.Context, target *url.URL, stack ipStack) (map[string]float64, map[string]string) {
+	prefix := "network." + stack.name + "."
+	indicators := map[string]float64{
+		stack.dnsIndicator:       inspect.ResultFailure,
+		stack.reachableIndicator: inspect.ResultUnknown,
+		stack.pathIndicator:      inspect.ResultUnknown,
+	}
+	metadata := make(map[string]string)
+	fail := func(check string, err error) (map[string]float64, map[string]string) {
+		metadata[prefix+"error"] = fmt.Sprintf("%s: %v", check, err)
+		log.WithError(err).WithField("stack", stack.name).Warnf("%s check failed", check)
+		return indicators, metadata
+	}
+
+	ips, err := net.DefaultResolver.LookupIP(ctx, stack.ipNetwork, target.Hostname())
+	if err == nil && len(ips) == 0 {
+		err = fmt.Errorf("no %s address for %s", stack.name, target.Hostname())
+	}
+	if err != nil {
+		return fail("dns", err)
+	}
+	indicators[stack.dnsIndicator] = inspect.ResultSuccess
+	addr := net.JoinHostPort(ips[0].String(), targetPort(target))
+	metadata[prefix+"address"] = ips[0].String()
+
+	indicators[stack.reachableIndicator] = inspect.ResultFailure
+	var dialer net.Dialer
+	startTime := time.Now()
+	conn, err := dialer.DialContext(ctx, stack.tcpNetwork, addr)
+	if err != nil {
+		return fail("connect", err)
+	}
+	conn.Close()
+	indicators[stack.reachableIndicator] = inspect.ResultSuccess
+	metadata[prefix+"latencyMs"] = strconv.FormatInt(time.Since(startTime).Milliseconds(), 10)
+
+	indicators[stack.pathIndicator] = inspect.ResultFailure
+	client := &http.Client{
+		Transport: &http.Transport{
+			// the request host is still used for the TLS server name
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, stack.tcpNetwork, addr)
+			},
+			DisableKeepAlives: true,
+		},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return fail("path", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fail("path", err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxConnectivityResponseSize)); err != nil {
+		return fail("path", err)
+	}
+	indicators[stack.pathIndicator] = inspect.ResultSuccess
+	return indicators, metadata
+}
+
+func targetPort(target *url.URL) string {
+	if port := target.Port(); len(port) > 0 {
+		return port
+	}
+	if target.Scheme == "http" {
+		return "80"
+	}
+	return "443"
+}
@@ -0,0 +1,54 @@
+package inspector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/inspect"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckConnectivity(t *testing.T) {
+	r := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	indicators, metadata := checkConnectivity(context.Background(), config.ConnectivityCheckConfig{
+		URL:            server.URL,
+		TimeoutSeconds: 5,
+	})
+	r.Equal(inspect.ResultSuccess, indicators[IndicatorNetworkIPv4DNS])
+	r.Equal(inspect.ResultSuccess, indicators[IndicatorNetworkIPv4Reachable])
+	r.Equal(inspect.ResultSuccess, indicators[IndicatorNetworkIPv4Path])
+	r.Equal("127.0.0.1", metadata["network.ipv4.address"])
+
+	// the checks which depend on the node network are not reported
+	r.Len(indicators, 3)
+	r.NotContains(metadata, "network.ipv6.error")
+	r.NotContains(metadata, "network.stack")
+	r.NotContains(metadata, "network.mtu")
+}
+
+func TestCheckConnectivity_PathFailure(t *testing.T) {
+	r := require.New(t)
+
+	// accepts the connections but never responds, like a path which drops the large packets
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+	}))
+	defer server.Close()
+
+	indicators, metadata := checkConnectivity(context.Background(), config.ConnectivityCheckConfig{
+		URL:            server.URL,
+		TimeoutSeconds: 1,
+	})
+	r.Equal(inspect.ResultSuccess, indicators[IndicatorNetworkIPv4Reachable])
+	r.Equal(inspect.ResultFailure, indicators[IndicatorNetworkIPv4Path])
+	r.Contains(metadata["network.ipv4.error"], "path: ")
+}
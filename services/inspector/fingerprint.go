@@ -0,0 +1,79 @@
+package inspector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+// Node metadata keys
+const (
+	MetadataNodeVersion       = "node.version"
+	MetadataNodeCommit        = "node.commit"
+	MetadataConfigFingerprint = "config.fingerprint"
+)
+
+// configFingerprint returns the hash of the effective config so that the configuration drift between the nodes
+// can be detected. The runtime values which do not come from the config file, like the passphrase and the Forta
+// dir, are left out so that the identical configs on different hosts have the same fingerprint. The secrets and
+// the credentials in the URLs are redacted the same way as in the debug bundle, so that the published hash
+// cannot be used to guess them.
+func configFingerprint(cfg config.Config) string {
+	cfg.Development = false
+	cfg.FortaDir = ""
+	cfg.KeyDirPath = ""
+	cfg.Passphrase = ""
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return ""
+	}
+	var doc interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return ""
+	}
+	// the map keys are sorted when encoded, which makes the encoding deterministic
+	b, err = json.Marshal(redactSecrets(doc, false))
+	if err != nil {
+		return ""
+	}
+	hash := sha256.Sum256(b)
+	return hex.EncodeToString(hash[:])
+}
+
+func redactSecrets(value interface{}, secret bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = redactSecrets(child, secret || config.IsSecretKey(key))
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactSecrets(child, secret)
+		}
+	case string:
+		if secret && len(v) > 0 {
+			return config.RedactedValue
+		}
+		if redactedURL, ok := config.RedactURL(v); ok {
+			return redactedURL
+		}
+	}
+	return value
+}
+
+// nodeMetadata returns the node version and the config fingerprint as inspection metadata.
+func nodeMetadata(fingerprint string) map[string]string {
+	metadata := make(map[string]string)
+	if len(config.Version) > 0 {
+		metadata[MetadataNodeVersion] = config.Version
+	}
+	if len(config.CommitHash) > 0 {
+		metadata[MetadataNodeCommit] = config.CommitHash
+	}
+	if len(fingerprint) > 0 {
+		metadata[MetadataConfigFingerprint] = fingerprint
+	}
+	return metadata
+}
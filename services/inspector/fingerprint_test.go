@@ -0,0 +1,55 @@
+package inspector
+
+import (
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFingerprint(t *testing.T) {
+	r := require.New(t)
+
+	cfg1 := config.Config{ChainID: 1, FortaDir: "/home/user1/.forta", Passphrase: "pass1"}
+	cfg1.Scan.ChainOffsets = map[int]int{1: 2, 137: 5}
+	cfg2 := config.Config{ChainID: 1, FortaDir: "/home/user2/.forta", Passphrase: "pass2"}
+	cfg2.Scan.ChainOffsets = map[int]int{137: 5, 1: 2}
+
+	fingerprint := configFingerprint(cfg1)
+	r.Len(fingerprint, 64)
+	r.Equal(fingerprint, configFingerprint(cfg2))
+
+	cfg2.ChainID = 137
+	r.NotEqual(fingerprint, configFingerprint(cfg2))
+}
+
+func TestConfigFingerprint_Secrets(t *testing.T) {
+	r := require.New(t)
+
+	cfg1 := config.Config{ChainID: 1}
+	cfg1.Scan.JsonRpc.Url = "https://eth-mainnet.g.alchemy.com/v2/abcdefghijklmnopqrstuvwxyz1"
+	cfg1.AgentEnv.Bots = map[string]config.BotEnvConfig{"0x01": {Vars: map[string]string{"API_KEY": "key1"}}}
+	cfg2 := config.Config{ChainID: 1}
+	cfg2.Scan.JsonRpc.Url = "https://eth-mainnet.g.alchemy.com/v2/abcdefghijklmnopqrstuvwxyz2"
+	cfg2.AgentEnv.Bots = map[string]config.BotEnvConfig{"0x01": {Vars: map[string]string{"API_KEY": "key2"}}}
+
+	// only the secrets differ
+	r.Equal(configFingerprint(cfg1), configFingerprint(cfg2))
+
+	cfg2.Scan.JsonRpc.Url = "https://polygon-mainnet.g.alchemy.com/v2/abcdefghijklmnopqrstuvwxyz2"
+	r.NotEqual(configFingerprint(cfg1), configFingerprint(cfg2))
+}
+
+func TestNodeMetadata(t *testing.T) {
+	r := require.New(t)
+
+	defaultVersion := config.Version
+	config.Version = "v1.2.3"
+	defer func() {
+		config.Version = defaultVersion
+	}()
+
+	metadata := nodeMetadata("abcd")
+	r.Equal("v1.2.3", metadata[MetadataNodeVersion])
+	r.Equal("abcd", metadata[MetadataConfigFingerprint])
+}
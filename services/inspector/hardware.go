@@ -0,0 +1,125 @@
+package inspector
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+const hardwareMetadataPrefix = "host."
+
+// procDir is where the host info is read from. The CPU, memory and driver info in procfs are not
+// namespaced so the inspector container sees the host values.
+var procDir = "/proc"
+
+var nvidiaDriverVersionRegexp = regexp.MustCompile(`Kernel Module\s+([0-9.]+)`)
+
+// detectHardware detects the host capabilities which are useful for assigning the heavy bots
+// and returns them as inspection metadata.
+func detectHardware() map[string]string {
+	metadata := map[string]string{
+		"cpu.cores": strconv.Itoa(runtime.NumCPU()),
+		"gpu.count": "0",
+	}
+
+	if flags := readCPUFlags(); flags != nil {
+		metadata["cpu.avx"] = strconv.FormatBool(flags["avx"])
+		metadata["cpu.avx2"] = strconv.FormatBool(flags["avx2"])
+		metadata["cpu.avx512"] = strconv.FormatBool(flags["avx512f"])
+	}
+
+	if memTotalKB, ok := readMemTotalKB(); ok {
+		metadata["memory.totalMb"] = strconv.FormatUint(memTotalKB/1024, 10)
+	}
+
+	if gpuCount, ok := countGPUs(); ok {
+		metadata["gpu.count"] = strconv.Itoa(gpuCount)
+		metadata["gpu.vendor"] = "nvidia"
+	}
+	if b, err := ioutil.ReadFile(path.Join(procDir, "driver/nvidia/version")); err == nil {
+		if matches := nvidiaDriverVersionRegexp.FindSubmatch(b); len(matches) == 2 {
+			metadata["gpu.driver"] = string(matches[1])
+		}
+	}
+
+	withPrefix := make(map[string]string)
+	for key, value := range metadata {
+		withPrefix[hardwareMetadataPrefix+key] = value
+	}
+	return withPrefix
+}
+
+// detectGPUPassthrough advertises if the bots which need a GPU can run on this node.
+func detectGPUPassthrough(cfg config.AgentGPUConfig) map[string]string {
+	gpuCount, _ := countGPUs()
+	metadata := map[string]string{
+		"gpu.count":       strconv.Itoa(gpuCount),
+		"gpu.passthrough": strconv.FormatBool(cfg.Enable && gpuCount > 0),
+	}
+	if cfg.Enable {
+		metadata["gpu.devices"] = cfg.VisibleDevices()
+	}
+
+	withPrefix := make(map[string]string)
+	for key, value := range metadata {
+		withPrefix[hardwareMetadataPrefix+key] = value
+	}
+	return withPrefix
+}
+
+func countGPUs() (int, bool) {
+	gpus, err := ioutil.ReadDir(path.Join(procDir, "driver/nvidia/gpus"))
+	if err != nil {
+		return 0, false
+	}
+	return len(gpus), true
+}
+
+// readCPUFlags reads the flags of the first processor.
+func readCPUFlags() map[string]bool {
+	f, err := os.Open(path.Join(procDir, "cpuinfo"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || strings.TrimSpace(name) != "flags" {
+			continue
+		}
+		flags := make(map[string]bool)
+		for _, flag := range strings.Fields(value) {
+			flags[flag] = true
+		}
+		return flags
+	}
+	return nil
+}
+
+func readMemTotalKB() (uint64, bool) {
+	f, err := os.Open(path.Join(procDir, "meminfo"))
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemTotal:" {
+			continue
+		}
+		memTotalKB, err := strconv.ParseUint(fields[1], 10, 64)
+		return memTotalKB, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,56 @@
+package inspector
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectHardware(t *testing.T) {
+	r := require.New(t)
+
+	dir := t.TempDir()
+	r.NoError(ioutil.WriteFile(path.Join(dir, "cpuinfo"), []byte(
+		"processor\t: 0\nflags\t\t: fpu sse4_2 avx avx2 aes\n\nprocessor\t: 1\nflags\t\t: fpu sse4_2 avx avx2 aes\n",
+	), 0644))
+	r.NoError(ioutil.WriteFile(path.Join(dir, "meminfo"), []byte(
+		"MemTotal:       16318464 kB\nMemFree:         1024000 kB\n",
+	), 0644))
+	r.NoError(os.MkdirAll(path.Join(dir, "driver/nvidia/gpus/0000:01:00.0"), 0755))
+	r.NoError(ioutil.WriteFile(path.Join(dir, "driver/nvidia/version"), []byte(
+		"NVRM version: NVIDIA UNIX x86_64 Kernel Module  525.85.12  Sat Jan 28 02:10:06 UTC 2023\n",
+	), 0644))
+
+	defaultProcDir := procDir
+	procDir = dir
+	defer func() {
+		procDir = defaultProcDir
+	}()
+
+	metadata := detectHardware()
+	r.NotEmpty(metadata["host.cpu.cores"])
+	r.Equal("true", metadata["host.cpu.avx"])
+	r.Equal("true", metadata["host.cpu.avx2"])
+	r.Equal("false", metadata["host.cpu.avx512"])
+	r.Equal("15936", metadata["host.memory.totalMb"])
+	r.Equal("1", metadata["host.gpu.count"])
+	r.Equal("nvidia", metadata["host.gpu.vendor"])
+	r.Equal("525.85.12", metadata["host.gpu.driver"])
+
+	metadata = detectGPUPassthrough(config.AgentGPUConfig{Enable: true, Devices: []string{"0"}})
+	r.Equal("1", metadata["host.gpu.count"])
+	r.Equal("true", metadata["host.gpu.passthrough"])
+	r.Equal("0", metadata["host.gpu.devices"])
+
+	procDir = t.TempDir()
+	metadata = detectGPUPassthrough(config.AgentGPUConfig{Enable: true})
+	r.Equal("false", metadata["host.gpu.passthrough"])
+	metadata = detectHardware()
+	r.Equal("0", metadata["host.gpu.count"])
+	r.NotContains(metadata, "host.cpu.avx")
+	r.NotContains(metadata, "host.memory.totalMb")
+}
@@ -4,12 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strconv"
 	"sync"
 	"time"
 
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/forta-network/forta-core-go/clients/health"
 	"github.com/forta-network/forta-core-go/inspect"
 	"github.com/forta-network/forta-core-go/inspect/scorecalc"
@@ -17,6 +16,7 @@ import (
 	"github.com/forta-network/forta-node/clients"
 	"github.com/forta-network/forta-node/clients/messaging"
 	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/tlsutils"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -33,6 +33,7 @@ type Inspector struct {
 	msgClient clients.MessageClient
 
 	lastErr          health.ErrorTracker
+	lastTraceErr     health.ErrorTracker
 	indicatorReports []health.Report
 	trackerMu        sync.RWMutex
 
@@ -40,9 +41,19 @@ type Inspector struct {
 	latestInspectionMu        sync.RWMutex
 	inspectionPublishInterval time.Duration
 
-	inspectEvery int
-	inspectTrace bool
-	inspectCh    chan uint64
+	latestTraceInspection   *inspect.InspectionResults
+	latestTraceInspectionMu sync.RWMutex
+	traceInspectionInterval time.Duration
+
+	botEvals *botEvaluations
+
+	fingerprint   string
+	fingerprintMu sync.RWMutex
+
+	inspectEvery   int
+	inspectEveryMu sync.RWMutex
+	inspectTrace   bool
+	inspectCh      chan uint64
 }
 
 type InspectorConfig struct {
@@ -67,6 +78,10 @@ func (ins *Inspector) Start() error {
 
 	go ins.inspectionPublisher(ins.ctx)
 
+	if ins.inspectTrace {
+		go ins.traceInspectionLoop()
+	}
+
 	go func() {
 		for {
 			select {
@@ -94,7 +109,10 @@ func (ins *Inspector) inspectionPublisher(ctx context.Context) error {
 			return ctx.Err()
 		case <-t.C:
 			ins.latestInspectionMu.RLock()
-			ins.msgClient.PublishProto(messaging.SubjectInspectionDone, inspect.ToProtoInspectionResults(ins.latestInspection))
+			ins.latestTraceInspectionMu.RLock()
+			results := withTraceResults(ins.latestInspection, ins.latestTraceInspection)
+			ins.latestTraceInspectionMu.RUnlock()
+			ins.msgClient.PublishProto(messaging.SubjectInspectionDone, inspect.ToProtoInspectionResults(results))
 			ins.latestInspectionMu.RUnlock()
 		}
 	}
@@ -105,7 +123,7 @@ func (ins *Inspector) runInspection(blockNum uint64) error {
 	results, err := inspect.Inspect(
 		inspectCtx, inspect.InspectionConfig{
 			ScanAPIURL:         ins.cfg.Config.Scan.JsonRpc.Url,
-			ProxyAPIURL:        fmt.Sprintf("http://%s:%s", ins.cfg.ProxyHost, ins.cfg.ProxyPort),
+			ProxyAPIURL:        fmt.Sprintf("%s://%s:%s", ins.proxyScheme(), ins.cfg.ProxyHost, ins.cfg.ProxyPort),
 			TraceAPIURL:        ins.cfg.Config.Trace.JsonRpc.Url,
 			BlockNumber:        blockNum,
 			CheckTrace:         ins.inspectTrace,
@@ -123,6 +141,62 @@ func (ins *Inspector) runInspection(blockNum uint64) error {
 
 	cancel()
 
+	if ins.cfg.Config.InspectionConfig.ReportHardware {
+		if results.Metadata == nil {
+			results.Metadata = make(map[string]string)
+		}
+		for key, value := range detectHardware() {
+			results.Metadata[key] = value
+		}
+	}
+
+	// advertised even without the hardware report, so that the GPU bots can be assigned to this node
+	if results.Metadata == nil {
+		results.Metadata = make(map[string]string)
+	}
+	for key, value := range detectGPUPassthrough(ins.cfg.Config.ResourcesConfig.GPU) {
+		results.Metadata[key] = value
+	}
+	ins.fingerprintMu.RLock()
+	for key, value := range nodeMetadata(ins.fingerprint) {
+		results.Metadata[key] = value
+	}
+	ins.fingerprintMu.RUnlock()
+
+	if ins.botEvals != nil {
+		if results.Metadata == nil {
+			results.Metadata = make(map[string]string)
+		}
+		for key, value := range ins.botEvals.Flush(time.Now()) {
+			results.Metadata[key] = value
+		}
+	}
+
+	if probes := ins.cfg.Config.InspectionConfig.Probes; len(probes) > 0 {
+		if results.Metadata == nil {
+			results.Metadata = make(map[string]string)
+		}
+		for key, value := range runProbes(ins.ctx, probes) {
+			results.Metadata[key] = value
+		}
+	}
+
+	if connCfg := ins.cfg.Config.InspectionConfig.Connectivity; !connCfg.Disable {
+		if results.Indicators == nil {
+			results.Indicators = make(map[string]float64)
+		}
+		if results.Metadata == nil {
+			results.Metadata = make(map[string]string)
+		}
+		indicators, metadata := checkConnectivity(ins.ctx, connCfg)
+		for key, value := range indicators {
+			results.Indicators[key] = value
+		}
+		for key, value := range metadata {
+			results.Metadata[key] = value
+		}
+	}
+
 	// use inspection results even if there are errors
 	// because inspection results are independent of errors
 	ins.latestInspectionMu.Lock()
@@ -175,12 +249,15 @@ func (ins *Inspector) runInspection(blockNum uint64) error {
 
 func (ins *Inspector) registerMessageHandlers() {
 	ins.msgClient.Subscribe(messaging.SubjectScannerBlock, messaging.ScannerHandler(ins.handleScannerBlock))
+	if ins.botEvals != nil {
+		ins.msgClient.Subscribe(messaging.SubjectMetricAgent, messaging.AgentMetricHandler(ins.botEvals.AddAgentMetrics))
+	}
 }
 
 func (ins *Inspector) handleScannerBlock(payload messaging.ScannerPayload) error {
 	if payload.LatestBlockInput > 0 && ins.blockNumRemainder(payload.LatestBlockInput) == 0 {
 		// inspect from N blocks back to avoid synchronizations issues
-		inspectionBlockNum := payload.LatestBlockInput - uint64(ins.inspectEvery)
+		inspectionBlockNum := payload.LatestBlockInput - uint64(ins.getInspectEvery())
 		logger := log.WithFields(
 			log.Fields{
 				"triggeredAtBlock":  payload.LatestBlockInput,
@@ -200,7 +277,28 @@ func (ins *Inspector) handleScannerBlock(payload messaging.ScannerPayload) error
 }
 
 func (ins *Inspector) blockNumRemainder(blockNum uint64) uint64 {
-	return blockNum % uint64(ins.inspectEvery)
+	return blockNum % uint64(ins.getInspectEvery())
+}
+
+func (ins *Inspector) getInspectEvery() int {
+	ins.inspectEveryMu.RLock()
+	defer ins.inspectEveryMu.RUnlock()
+	return ins.inspectEvery
+}
+
+// ReloadConfig implements services.ConfigReloader.
+func (ins *Inspector) ReloadConfig(cfg config.Config, report *config.ReloadReport) {
+	inspectEvery := settings.GetChainSettings(cfg.ChainID).InspectionInterval
+	if cfg.InspectionConfig.BlockInterval != nil {
+		inspectEvery = *cfg.InspectionConfig.BlockInterval
+	}
+	ins.inspectEveryMu.Lock()
+	ins.inspectEvery = inspectEvery
+	ins.inspectEveryMu.Unlock()
+
+	ins.fingerprintMu.Lock()
+	ins.fingerprint = configFingerprint(cfg)
+	ins.fingerprintMu.Unlock()
 }
 
 func (ins *Inspector) Stop() error {
@@ -222,33 +320,50 @@ func (ins *Inspector) Health() health.Reports {
 		reports = append(reports, &reportCopy)
 	}
 	ins.trackerMu.RUnlock()
+	if ins.inspectTrace {
+		reports = append(reports, ins.traceHealth()...)
+	}
+	if reporter, ok := ins.msgClient.(health.Reporter); ok {
+		reports = append(reports, reporter.Health()...)
+	}
 
 	return reports
 }
 
 func (ins *Inspector) getClosestBlockToInspect() uint64 {
 	// if scan api is failing, run a placeholder-like inspection with genesis block
-	dialCtx, cancel := context.WithTimeout(ins.ctx, time.Second*3)
-	rpcClient, err := rpc.DialContext(dialCtx, ins.cfg.Config.Scan.JsonRpc.Url)
-	cancel()
+	blockNum, err := ins.getClosestBlock(ins.ctx, ins.cfg.Config.Scan.JsonRpc.Url)
 	if err != nil {
 		return 0
 	}
+	return blockNum
+}
 
-	reqCtx, cancel := context.WithTimeout(ins.ctx, time.Second*3)
-	blockNum, err := ethclient.NewClient(rpcClient).BlockNumber(reqCtx)
-	cancel()
-	if err != nil {
-		return 0
+// proxyScheme returns the scheme of the JSON-RPC proxy which requires TLS if agent TLS is enabled.
+func (ins *Inspector) proxyScheme() string {
+	if ins.cfg.Config.AgentTLS.Enable {
+		return "https"
 	}
-
-	blockNum -= ins.blockNumRemainder(blockNum) // turn it into an expected block num
-
-	return blockNum
+	return "http"
 }
 
 func NewInspector(ctx context.Context, cfg InspectorConfig) (*Inspector, error) {
+	// the inspection library uses the default transport for accessing the proxy so only the requests
+	// to the proxy are routed to the transport which presents the inspector certificate
+	if cfg.Config.AgentTLS.Enable {
+		proxyTransport, err := tlsutils.ClientTransport(tlsutils.AllowPeers(config.DockerJSONRPCProxyContainerName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the proxy transport: %v", err)
+		}
+		http.DefaultTransport = &tlsutils.HostTransport{
+			Host:      cfg.ProxyHost,
+			Transport: proxyTransport,
+			Next:      http.DefaultTransport,
+		}
+	}
+
 	msgClient := messaging.NewClient("inspector", fmt.Sprintf("%s:%s", config.DockerNatsContainerName, config.DefaultNatsPort))
+	msgClient.SetTracing(cfg.Config.Messaging.Tracing)
 
 	chainSettings := settings.GetChainSettings(cfg.Config.ChainID)
 	inspectionInterval := chainSettings.InspectionInterval
@@ -262,13 +377,21 @@ func NewInspector(ctx context.Context, cfg InspectorConfig) (*Inspector, error)
 	}
 	inspect.DownloadTestSavingMode = cfg.Config.InspectionConfig.NetworkSavingMode
 
+	var botEvals *botEvaluations
+	if cfg.Config.InspectionConfig.ReportBotHealth {
+		botEvals = newBotEvaluations(time.Now())
+	}
+
 	return &Inspector{
 		ctx:                       ctx,
 		msgClient:                 msgClient,
 		cfg:                       cfg,
 		inspectEvery:              inspectionInterval,
+		botEvals:                  botEvals,
+		fingerprint:               configFingerprint(cfg.Config),
 		inspectTrace:              chainSettings.EnableTrace,
 		inspectCh:                 make(chan uint64, 1), // let it tolerate being late on one block inspection
 		inspectionPublishInterval: publishInterval,
+		traceInspectionInterval:   time.Duration(cfg.Config.InspectionConfig.TraceIntervalSeconds) * time.Second,
 	}, nil
 }
@@ -0,0 +1,177 @@
+package inspector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	probeMetadataPrefix = "probe."
+	maxProbeOutputSize  = 64 * 1024
+	defaultProbeTimeout = 10 * time.Second
+)
+
+var errProbeOutputTooLarge = fmt.Errorf("command output is larger than %d bytes", maxProbeOutputSize)
+
+// limitedWriter fails the writes after the limit is exceeded so that
+// the command output is never buffered beyond the limit.
+type limitedWriter struct {
+	buf      bytes.Buffer
+	limit    int
+	exceeded bool
+}
+
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+	if lw.buf.Len()+len(p) > lw.limit {
+		lw.exceeded = true
+		return 0, errProbeOutputTooLarge
+	}
+	return lw.buf.Write(p)
+}
+
+// runProbes runs the operator-defined probes concurrently and returns the results as inspection metadata.
+// All probes share one deadline which is the largest of the probe timeouts.
+func runProbes(ctx context.Context, probes []config.InspectionProbeConfig) map[string]string {
+	var maxTimeout time.Duration
+	for _, probe := range probes {
+		if timeout := time.Duration(probe.TimeoutSeconds) * time.Second; timeout > maxTimeout {
+			maxTimeout = timeout
+		}
+	}
+	if maxTimeout == 0 {
+		maxTimeout = defaultProbeTimeout
+	}
+	probesCtx, cancel := context.WithTimeout(ctx, maxTimeout)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		metadata = make(map[string]string)
+	)
+	for _, probe := range probes {
+		wg.Add(1)
+		go func(probe config.InspectionProbeConfig) {
+			defer wg.Done()
+			values := runProbe(probesCtx, probe)
+			mu.Lock()
+			for key, value := range values {
+				metadata[key] = value
+			}
+			mu.Unlock()
+		}(probe)
+	}
+	wg.Wait()
+	return metadata
+}
+
+func runProbe(ctx context.Context, probe config.InspectionProbeConfig) map[string]string {
+	var (
+		values map[string]string
+		err    error
+	)
+	startTime := time.Now()
+	if probe.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(probe.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	switch {
+	case len(probe.URL) > 0:
+		values, err = runHTTPProbe(ctx, probe)
+	case len(probe.Command) > 0:
+		values, err = runCommandProbe(ctx, probe)
+	default:
+		err = errors.New("probe needs either a url or a command")
+	}
+
+	prefix := probeMetadataPrefix + probe.Name + "."
+	metadata := make(map[string]string)
+	for key, value := range values {
+		metadata[prefix+key] = value
+	}
+	metadata[prefix+"ok"] = strconv.FormatBool(err == nil)
+	metadata[prefix+"durationMs"] = strconv.FormatInt(time.Since(startTime).Milliseconds(), 10)
+	if err != nil {
+		metadata[prefix+"error"] = err.Error()
+		log.WithError(err).WithField("probe", probe.Name).Warn("inspection probe failed")
+	}
+	return metadata
+}
+
+func runHTTPProbe(ctx context.Context, probe config.InspectionProbeConfig) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probe.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	values := map[string]string{"status": strconv.Itoa(resp.StatusCode)}
+	expectedStatus := probe.ExpectedStatus
+	if expectedStatus == 0 {
+		expectedStatus = http.StatusOK
+	}
+	if resp.StatusCode != expectedStatus {
+		return values, fmt.Errorf("expected status %d but got %d", expectedStatus, resp.StatusCode)
+	}
+	if len(probe.ExpectedBody) == 0 {
+		return values, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeOutputSize))
+	if err != nil {
+		return values, fmt.Errorf("failed to read the response body: %v", err)
+	}
+	if !strings.Contains(string(body), probe.ExpectedBody) {
+		return values, fmt.Errorf("response body does not contain %q", probe.ExpectedBody)
+	}
+	return values, nil
+}
+
+// runCommandProbe executes the command and expects a JSON object from the stdout. The values
+// in the object are converted to strings.
+func runCommandProbe(ctx context.Context, probe config.InspectionProbeConfig) (map[string]string, error) {
+	stdout := &limitedWriter{limit: maxProbeOutputSize}
+	stderr := &limitedWriter{limit: maxProbeOutputSize}
+	cmd := exec.CommandContext(ctx, probe.Command[0], probe.Command[1:]...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		if stdout.exceeded || stderr.exceeded {
+			return nil, errProbeOutputTooLarge
+		}
+		return nil, fmt.Errorf("command failed: %v: %s", err, strings.TrimSpace(stderr.buf.String()))
+	}
+
+	var output map[string]interface{}
+	if err := json.Unmarshal(stdout.buf.Bytes(), &output); err != nil {
+		return nil, fmt.Errorf("command output is not a json object: %v", err)
+	}
+	values := make(map[string]string)
+	for key, value := range output {
+		switch v := value.(type) {
+		case string:
+			values[key] = v
+		default:
+			b, _ := json.Marshal(v)
+			values[key] = string(b)
+		}
+	}
+	return values, nil
+}
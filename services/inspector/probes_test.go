@@ -0,0 +1,65 @@
+package inspector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunProbes(t *testing.T) {
+	r := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"healthy"}`))
+	}))
+	defer server.Close()
+
+	metadata := runProbes(context.Background(), []config.InspectionProbeConfig{
+		{Name: "api", URL: server.URL, ExpectedStatus: 200, ExpectedBody: "healthy", TimeoutSeconds: 1},
+		{Name: "api-body", URL: server.URL, ExpectedStatus: 200, ExpectedBody: "unhealthy", TimeoutSeconds: 1},
+		{Name: "script", Command: []string{"echo", `{"peers": 12, "region": "eu"}`}, TimeoutSeconds: 1},
+		{Name: "bad-script", Command: []string{"echo", "not json"}, TimeoutSeconds: 1},
+	})
+
+	r.Equal("true", metadata["probe.api.ok"])
+	r.Equal("200", metadata["probe.api.status"])
+	r.Equal("false", metadata["probe.api-body.ok"])
+	r.Contains(metadata["probe.api-body.error"], "does not contain")
+	r.Equal("true", metadata["probe.script.ok"])
+	r.Equal("12", metadata["probe.script.peers"])
+	r.Equal("eu", metadata["probe.script.region"])
+	r.Equal("false", metadata["probe.bad-script.ok"])
+	r.Contains(metadata["probe.bad-script.error"], "not a json object")
+}
+
+func TestRunProbes_LargeOutput(t *testing.T) {
+	r := require.New(t)
+
+	metadata := runProbes(context.Background(), []config.InspectionProbeConfig{
+		{Name: "large", Command: []string{"head", "-c", "100000", "/dev/zero"}, TimeoutSeconds: 1},
+	})
+
+	r.Equal("false", metadata["probe.large.ok"])
+	r.Contains(metadata["probe.large.error"], "larger than")
+}
+
+func TestRunProbes_Concurrent(t *testing.T) {
+	r := require.New(t)
+
+	startTime := time.Now()
+	metadata := runProbes(context.Background(), []config.InspectionProbeConfig{
+		{Name: "slow1", Command: []string{"sh", "-c", `sleep 0.5; echo '{}'`}, TimeoutSeconds: 2},
+		{Name: "slow2", Command: []string{"sh", "-c", `sleep 0.5; echo '{}'`}, TimeoutSeconds: 2},
+		{Name: "slow3", Command: []string{"sh", "-c", `sleep 0.5; echo '{}'`}, TimeoutSeconds: 2},
+	})
+
+	r.Less(time.Since(startTime), time.Second+500*time.Millisecond)
+	r.Equal("true", metadata["probe.slow1.ok"])
+	r.Equal("true", metadata["probe.slow2.ok"])
+	r.Equal("true", metadata["probe.slow3.ok"])
+}
@@ -0,0 +1,129 @@
+package inspector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/inspect"
+	log "github.com/sirupsen/logrus"
+)
+
+// The indicators of the trace API inspection which runs independently of the scan API.
+const (
+	IndicatorTraceIndependentAccessible = "trace-api.independent.accessible"
+	IndicatorTraceIndependentSupported  = "trace-api.independent.supported"
+	MetadataTraceIndependentBlockNumber = "trace-api.independent.block-number"
+)
+
+const traceInspectionTimeout = time.Minute
+
+// traceInspectionLoop inspects the trace API on its own interval. The block to inspect is found by
+// using the trace API so the trace provider outages are not affected by the scan provider outages.
+func (ins *Inspector) traceInspectionLoop() {
+	ticker := time.NewTicker(ins.traceInspectionInterval)
+	defer ticker.Stop()
+
+	for {
+		ins.runTraceInspection()
+		select {
+		case <-ins.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (ins *Inspector) runTraceInspection() {
+	ctx, cancel := context.WithTimeout(ins.ctx, traceInspectionTimeout)
+	defer cancel()
+
+	results := inspect.NewInspectionResults()
+	results.Indicators[IndicatorTraceIndependentAccessible] = inspect.ResultFailure
+	results.Indicators[IndicatorTraceIndependentSupported] = inspect.ResultFailure
+
+	blockNum, err := ins.getClosestBlock(ctx, ins.cfg.Config.Trace.JsonRpc.Url)
+	if err == nil {
+		var traceResults *inspect.InspectionResults
+		traceResults, err = (&inspect.TraceAPIInspector{}).Inspect(
+			ctx, inspect.InspectionConfig{
+				TraceAPIURL: ins.cfg.Config.Trace.JsonRpc.Url,
+				BlockNumber: blockNum,
+				CheckTrace:  true,
+			},
+		)
+		results.Indicators[IndicatorTraceIndependentAccessible] = traceResults.Indicators[inspect.IndicatorTraceAccessible]
+		results.Indicators[IndicatorTraceIndependentSupported] = traceResults.Indicators[inspect.IndicatorTraceSupported]
+		results.Metadata[MetadataTraceIndependentBlockNumber] = strconv.FormatUint(blockNum, 10)
+	}
+
+	ins.latestTraceInspectionMu.Lock()
+	ins.latestTraceInspection = results
+	ins.latestTraceInspectionMu.Unlock()
+
+	ins.lastTraceErr.Set(err)
+	if err != nil {
+		log.WithError(err).WithField("inspectingAtBlock", blockNum).Warn("trace inspection failed")
+	}
+}
+
+// withTraceResults returns a copy of the inspection results with the latest independent trace
+// inspection results. If the inspection could not use the scan API, the trace API indicators
+// fall back to the independent ones.
+func withTraceResults(results, traceResults *inspect.InspectionResults) *inspect.InspectionResults {
+	if results == nil || traceResults == nil {
+		return results
+	}
+	merged := inspect.NewInspectionResults().CopyFrom(results).CopyFrom(traceResults)
+	merged.Inputs = results.Inputs
+	if results.Inputs.BlockNumber == 0 {
+		merged.Indicators[inspect.IndicatorTraceAccessible] = traceResults.Indicators[IndicatorTraceIndependentAccessible]
+		merged.Indicators[inspect.IndicatorTraceSupported] = traceResults.Indicators[IndicatorTraceIndependentSupported]
+	}
+	return merged
+}
+
+func (ins *Inspector) traceHealth() health.Reports {
+	reports := health.Reports{
+		ins.lastTraceErr.GetReport("trace.last-error"),
+	}
+	ins.latestTraceInspectionMu.RLock()
+	defer ins.latestTraceInspectionMu.RUnlock()
+	if ins.latestTraceInspection == nil {
+		return reports
+	}
+	for _, name := range []string{IndicatorTraceIndependentAccessible, IndicatorTraceIndependentSupported} {
+		reports = append(reports, &health.Report{
+			Name:    name,
+			Status:  health.StatusInfo,
+			Details: strconv.FormatFloat(ins.latestTraceInspection.Indicators[name], 'f', -1, 64),
+		})
+	}
+	return reports
+}
+
+// getClosestBlock finds the closest block to inspect from the latest block of the API.
+func (ins *Inspector) getClosestBlock(ctx context.Context, apiURL string) (uint64, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, time.Second*3)
+	rpcClient, err := rpc.DialContext(dialCtx, apiURL)
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to dial api: %v", err)
+	}
+	defer rpcClient.Close()
+
+	reqCtx, cancel := context.WithTimeout(ctx, time.Second*3)
+	blockNum, err := ethclient.NewClient(rpcClient).BlockNumber(reqCtx)
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the latest block number: %v", err)
+	}
+
+	blockNum -= ins.blockNumRemainder(blockNum) // turn it into an expected block num
+
+	return blockNum, nil
+}
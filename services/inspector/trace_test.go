@@ -0,0 +1,61 @@
+package inspector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/inspect"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func testTraceResults(value float64) *inspect.InspectionResults {
+	results := inspect.NewInspectionResults()
+	results.Indicators[IndicatorTraceIndependentAccessible] = value
+	results.Indicators[IndicatorTraceIndependentSupported] = value
+	results.Metadata[MetadataTraceIndependentBlockNumber] = "100"
+	return results
+}
+
+func TestWithTraceResults(t *testing.T) {
+	r := require.New(t)
+
+	results := inspect.NewInspectionResults()
+	results.Inputs.BlockNumber = 50
+	results.Indicators[inspect.IndicatorTraceAccessible] = inspect.ResultFailure
+	results.Indicators[inspect.IndicatorTraceSupported] = inspect.ResultFailure
+
+	r.Nil(withTraceResults(nil, testTraceResults(inspect.ResultSuccess)))
+	r.Equal(results, withTraceResults(results, nil))
+
+	merged := withTraceResults(results, testTraceResults(inspect.ResultSuccess))
+	r.Equal(uint64(50), merged.Inputs.BlockNumber)
+	r.Equal(inspect.ResultFailure, merged.Indicators[inspect.IndicatorTraceAccessible])
+	r.Equal(inspect.ResultSuccess, merged.Indicators[IndicatorTraceIndependentAccessible])
+	r.Equal("100", merged.Metadata[MetadataTraceIndependentBlockNumber])
+	// the original results are not modified
+	r.NotContains(results.Indicators, IndicatorTraceIndependentAccessible)
+
+	// falls back to the independent trace results if the scan api was failing
+	results.Inputs.BlockNumber = 0
+	merged = withTraceResults(results, testTraceResults(inspect.ResultSuccess))
+	r.Equal(inspect.ResultSuccess, merged.Indicators[inspect.IndicatorTraceAccessible])
+	r.Equal(inspect.ResultSuccess, merged.Indicators[inspect.IndicatorTraceSupported])
+}
+
+func TestRunTraceInspection_Failure(t *testing.T) {
+	r := require.New(t)
+
+	var cfg config.Config
+	cfg.Trace.JsonRpc.Url = "http://127.0.0.1:1"
+	ins := &Inspector{
+		ctx:          context.Background(),
+		cfg:          InspectorConfig{Config: cfg},
+		inspectEvery: 10,
+	}
+	ins.runTraceInspection()
+
+	r.Equal(inspect.ResultFailure, ins.latestTraceInspection.Indicators[IndicatorTraceIndependentAccessible])
+	r.Equal(inspect.ResultFailure, ins.latestTraceInspection.Indicators[IndicatorTraceIndependentSupported])
+	r.NotEmpty(ins.lastTraceErr.String())
+}
@@ -0,0 +1,339 @@
+package json_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// the methods which read the state at a block and the index of their block param
+var stateMethodBlockParams = map[string]int{
+	"eth_getBalance":          1,
+	"eth_getCode":             1,
+	"eth_getTransactionCount": 1,
+	"eth_call":                1,
+	"eth_estimateGas":         1,
+	"eth_createAccessList":    1,
+	"eth_getStorageAt":        2,
+	"eth_getProof":            2,
+}
+
+// the error messages of the full nodes which do not have the requested state anymore
+var missingStatePatterns = []string{
+	"missing trie node", "header not found", "state not available", "historical state", "pruned",
+}
+
+// archiveRouter sends the requests for the state of the old blocks to the archive node and the rest
+// to the regular providers. The requests which fail on the regular providers because the state is
+// pruned are retried on the archive node.
+type archiveRouter struct {
+	next         http.Handler
+	archive      *provider
+	recentBlocks uint64
+	interval     time.Duration
+	healthCfg    config.ProviderHealthCheckConfig
+
+	head      uint64 // accessed atomically
+	routed    uint64 // accessed atomically
+	fallbacks uint64 // accessed atomically
+	skipped   uint64 // accessed atomically
+}
+
+func newArchiveRouter(next http.Handler, cfg config.ArchiveConfig, healthCfg config.ProviderHealthCheckConfig) (*archiveRouter, error) {
+	archive, err := newProvider("archive", cfg.JsonRpc)
+	if err != nil {
+		return nil, err
+	}
+	return &archiveRouter{
+		next:         next,
+		archive:      archive,
+		recentBlocks: uint64(cfg.RecentBlocks),
+		interval:     time.Duration(healthCfg.IntervalSeconds) * time.Second,
+		healthCfg:    healthCfg,
+	}, nil
+}
+
+func (ar *archiveRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Body == nil || req.Method != http.MethodPost {
+		ar.next.ServeHTTP(w, req)
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		log.WithError(err).Error("failed to read jsonrpc request body")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	setRequestBody(req, body)
+
+	rpcReqs := parseRPCRequests(body)
+	for _, rpcReq := range rpcReqs {
+		if ar.needsArchive(rpcReq) {
+			atomic.AddUint64(&ar.routed, 1)
+			ar.serveArchive(w, req, body)
+			return
+		}
+	}
+
+	// only the single requests for an explicit block can be retried
+	if len(rpcReqs) != 1 || isBatch(body) || !hasBlockNumberParam(rpcReqs[0]) {
+		ar.next.ServeHTTP(w, req)
+		return
+	}
+
+	// the response is decoded here so it should not be compressed
+	req.Header.Del("Accept-Encoding")
+	respBuf := newResponseBuffer()
+	ar.next.ServeHTTP(respBuf, req)
+	if isMissingStateResponse(respBuf) {
+		atomic.AddUint64(&ar.fallbacks, 1)
+		log.WithField("method", rpcReqs[0].Method).Debug("state is missing on the regular provider - retrying on the archive node")
+		setRequestBody(req, body)
+		ar.serveArchive(w, req, body)
+		return
+	}
+	writeResponseBuffer(w, respBuf)
+}
+
+// serveArchive proxies the request to the archive node and falls back to the regular providers
+// if the archive node fails or is ejected.
+func (ar *archiveRouter) serveArchive(w http.ResponseWriter, req *http.Request, body []byte) {
+	if !ar.useArchive() {
+		atomic.AddUint64(&ar.skipped, 1)
+		setRequestBody(req, body)
+		ar.next.ServeHTTP(w, req)
+		return
+	}
+
+	var proxyErr error
+	archiveReq := req.Clone(context.WithValue(req.Context(), proxyErrKey{}, &proxyErr))
+	setRequestBody(archiveReq, body)
+
+	respBuf := newResponseBuffer()
+	start := time.Now()
+	ar.archive.proxy.ServeHTTP(respBuf, archiveReq)
+	if proxyErr == nil && respBuf.code < http.StatusInternalServerError {
+		ar.archive.observeSuccess(time.Since(start))
+		writeResponseBuffer(w, respBuf)
+		return
+	}
+	if proxyErr == nil {
+		proxyErr = fmt.Errorf("status code %d", respBuf.code)
+	}
+	ar.archive.observeFailure(proxyErr, ar.healthCfg.EjectAfterFailures)
+	log.WithError(proxyErr).Warn("archive node request failed - using the regular providers")
+
+	setRequestBody(req, body)
+	ar.next.ServeHTTP(w, req)
+}
+
+// useArchive tells if the request can be sent to the archive node. The ejected archive node gets only
+// one request in each probe interval, like the ejected providers of the pool.
+func (ar *archiveRouter) useArchive() bool {
+	if !ar.archive.isEjected() {
+		return true
+	}
+	return ar.archive.tryProbe(time.Duration(ar.healthCfg.ProbeIntervalSeconds) * time.Second)
+}
+
+// needsArchive tells if the request reads the state of a block which the full nodes may have pruned.
+func (ar *archiveRouter) needsArchive(rpcReq *rpcRequest) bool {
+	blockNumber, ok := stateBlockNumber(rpcReq)
+	if !ok {
+		return false
+	}
+	// the regular providers are tried first until the head is known
+	head := atomic.LoadUint64(&ar.head)
+	return head > ar.recentBlocks && blockNumber < head-ar.recentBlocks
+}
+
+// trackHead polls the latest block number from the regular providers.
+func (ar *archiveRouter) trackHead(ctx context.Context) {
+	pollHead(ctx, ar.next, ar.interval, &ar.head, "archive routing")
+}
+
+// pollHead stores the latest block number from the handler on every interval until the context is done.
+func pollHead(ctx context.Context, next http.Handler, interval time.Duration, head *uint64, purpose string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if blockNumber, err := getHead(ctx, next); err != nil {
+			log.WithError(err).Debugf("failed to get the latest block number for the %s", purpose)
+		} else {
+			atomic.StoreUint64(head, blockNumber)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func getHead(ctx context.Context, next http.Handler) (uint64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/", bytes.NewReader(healthCheckBody))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	respBuf := newResponseBuffer()
+	next.ServeHTTP(respBuf, req)
+
+	var result struct {
+		Result string        `json:"result"`
+		Error  *jsonRpcError `json:"error"`
+	}
+	if err := json.Unmarshal(respBuf.body.Bytes(), &result); err != nil {
+		return 0, fmt.Errorf("failed to decode response (status %d): %v", respBuf.code, err)
+	}
+	if result.Error != nil {
+		return 0, fmt.Errorf("error response: %s", result.Error.Message)
+	}
+	return hexutil.DecodeUint64(result.Result)
+}
+
+// Health implements health.Reporter interface.
+func (ar *archiveRouter) Health() health.Reports {
+	ar.archive.mu.RLock()
+	status := health.StatusOK
+	details := fmt.Sprintf("latency=%dms", ar.archive.latency.Milliseconds())
+	if ar.archive.ejected {
+		status = health.StatusFailing
+		details += " ejected"
+	}
+	if ar.archive.lastErr != nil {
+		details += fmt.Sprintf(" error=%v", ar.archive.lastErr)
+	}
+	ar.archive.mu.RUnlock()
+
+	return health.Reports{
+		{
+			Name:    "archive.provider",
+			Status:  status,
+			Details: details,
+		},
+		{
+			Name:    "archive.head",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&ar.head), 10),
+		},
+		{
+			Name:    "archive.routed",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&ar.routed), 10),
+		},
+		{
+			Name:    "archive.fallbacks",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&ar.fallbacks), 10),
+		},
+		{
+			Name:    "archive.skipped",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&ar.skipped), 10),
+		},
+	}
+}
+
+// parseRPCRequests parses the single or the batch request. The invalid requests are skipped.
+func parseRPCRequests(body []byte) []*rpcRequest {
+	if !isBatch(body) {
+		var rpcReq rpcRequest
+		if json.Unmarshal(body, &rpcReq) != nil {
+			return nil
+		}
+		return []*rpcRequest{&rpcReq}
+	}
+	var batch []json.RawMessage
+	if json.Unmarshal(body, &batch) != nil {
+		return nil
+	}
+	var rpcReqs []*rpcRequest
+	for _, item := range batch {
+		var rpcReq rpcRequest
+		if json.Unmarshal(item, &rpcReq) == nil {
+			rpcReqs = append(rpcReqs, &rpcReq)
+		}
+	}
+	return rpcReqs
+}
+
+// stateBlockNumber returns the block number of the state which the request reads. The tags other
+// than "earliest" and the block hashes are not numbers.
+func stateBlockNumber(rpcReq *rpcRequest) (uint64, bool) {
+	index, ok := stateMethodBlockParams[rpcReq.Method]
+	if !ok || len(rpcReq.Params) <= index {
+		return 0, false
+	}
+	param := rpcReq.Params[index]
+
+	var blockTag string
+	if err := json.Unmarshal(param, &blockTag); err != nil {
+		// EIP-1898 block param
+		var blockParam struct {
+			BlockNumber string `json:"blockNumber"`
+		}
+		if err := json.Unmarshal(param, &blockParam); err != nil {
+			return 0, false
+		}
+		blockTag = blockParam.BlockNumber
+	}
+	if blockTag == "earliest" {
+		return 0, true
+	}
+	blockNumber, err := hexutil.DecodeUint64(blockTag)
+	if err != nil {
+		return 0, false
+	}
+	return blockNumber, true
+}
+
+func hasBlockNumberParam(rpcReq *rpcRequest) bool {
+	_, ok := stateBlockNumber(rpcReq)
+	return ok
+}
+
+func isMissingStateResponse(respBuf *responseBuffer) bool {
+	var resp struct {
+		Error *jsonRpcError `json:"error"`
+	}
+	if json.Unmarshal(respBuf.body.Bytes(), &resp) != nil || resp.Error == nil {
+		return false
+	}
+	message := strings.ToLower(resp.Error.Message)
+	for _, pattern := range missingStatePatterns {
+		if strings.Contains(message, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func setRequestBody(req *http.Request, body []byte) {
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+}
+
+func writeResponseBuffer(w http.ResponseWriter, respBuf *responseBuffer) {
+	for k, v := range respBuf.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(respBuf.code)
+	if _, err := w.Write(respBuf.body.Bytes()); err != nil {
+		log.WithError(err).Debug("failed to write jsonrpc response")
+	}
+}
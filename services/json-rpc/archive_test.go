@@ -0,0 +1,126 @@
+package json_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateBlockNumber(t *testing.T) {
+	r := require.New(t)
+
+	for _, testCase := range []struct {
+		body        string
+		blockNumber uint64
+		ok          bool
+	}{
+		{`{"id":1,"method":"eth_getBalance","params":["0xabcd","0x10"]}`, 16, true},
+		{`{"id":1,"method":"eth_getBalance","params":["0xabcd","latest"]}`, 0, false},
+		{`{"id":1,"method":"eth_getBalance","params":["0xabcd","earliest"]}`, 0, true},
+		{`{"id":1,"method":"eth_getBalance","params":["0xabcd"]}`, 0, false},
+		{`{"id":1,"method":"eth_call","params":[{"to":"0xabcd"},{"blockNumber":"0x20"}]}`, 32, true},
+		{`{"id":1,"method":"eth_call","params":[{"to":"0xabcd"},{"blockHash":"0x1234"}]}`, 0, false},
+		{`{"id":1,"method":"eth_getStorageAt","params":["0xabcd","0x0","0x30"]}`, 48, true},
+		{`{"id":1,"method":"eth_getBlockByNumber","params":["0x10",false]}`, 0, false},
+	} {
+		var rpcReq rpcRequest
+		r.NoError(json.Unmarshal([]byte(testCase.body), &rpcReq))
+		blockNumber, ok := stateBlockNumber(&rpcReq)
+		r.Equal(testCase.ok, ok, testCase.body)
+		r.Equal(testCase.blockNumber, blockNumber, testCase.body)
+	}
+}
+
+func TestArchiveRouter(t *testing.T) {
+	r := require.New(t)
+
+	var archiveCalls int32
+	archiveNode := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&archiveCalls, 1)
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"archive"}`))
+	}))
+	t.Cleanup(archiveNode.Close)
+
+	var regularCalls int32
+	regular := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&regularCalls, 1)
+		var rpcReq rpcRequest
+		r.NoError(json.NewDecoder(req.Body).Decode(&rpcReq))
+		switch {
+		case rpcReq.Method == "eth_blockNumber":
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1000"}`))
+		case rpcReq.Method == "eth_getCode":
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"missing trie node abcd (path )"}}`))
+		default:
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"regular"}`))
+		}
+	})
+
+	router, err := newArchiveRouter(regular, config.ArchiveConfig{
+		JsonRpc:      config.JsonRpcConfig{Url: archiveNode.URL},
+		RecentBlocks: 128,
+	}, testProviderHealthCfg)
+	r.NoError(err)
+
+	doRequest := func(body string) string {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		r.Equal(http.StatusOK, rec.Code)
+		return rec.Body.String()
+	}
+
+	// the old blocks go to the regular providers until the head is known
+	r.Contains(doRequest(`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xabcd","0x10"]}`), "regular")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	router.trackHead(ctx)
+	r.Equal(uint64(0x1000), atomic.LoadUint64(&router.head))
+
+	r.Contains(doRequest(`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xabcd","0x10"]}`), "archive")
+	r.Contains(doRequest(`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xabcd","0xff0"]}`), "regular")
+	r.Contains(doRequest(`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xabcd","latest"]}`), "regular")
+	r.Contains(doRequest(`[{"jsonrpc":"2.0","id":1,"method":"eth_chainId"},{"jsonrpc":"2.0","id":2,"method":"eth_call","params":[{},"earliest"]}]`), "archive")
+	r.Equal(int32(2), atomic.LoadInt32(&archiveCalls))
+
+	// the pruned state is retried on the archive node
+	r.Contains(doRequest(`{"jsonrpc":"2.0","id":1,"method":"eth_getCode","params":["0xabcd","0xff0"]}`), "archive")
+	r.Equal(int32(3), atomic.LoadInt32(&archiveCalls))
+
+	// the failing archive node falls back to the regular providers
+	archiveNode.Close()
+	r.Contains(doRequest(`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xabcd","0x10"]}`), "regular")
+
+	// the ejected archive node is skipped until the next probe
+	router.healthCfg.ProbeIntervalSeconds = 60
+	r.Contains(doRequest(`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xabcd","0x10"]}`), "regular")
+	r.True(router.archive.isEjected())
+	r.Contains(doRequest(`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xabcd","0x10"]}`), "regular")
+
+	reports := router.Health()
+	routed, ok := reports.NameContains("archive.routed")
+	r.True(ok)
+	r.Equal("5", routed.Details)
+	fallbacks, ok := reports.NameContains("archive.fallbacks")
+	r.True(ok)
+	r.Equal("1", fallbacks.Details)
+	skipped, ok := reports.NameContains("archive.skipped")
+	r.True(ok)
+	r.Equal("1", skipped.Details)
+
+	// the archive node gets a probe request after the interval
+	router.archive.mu.Lock()
+	router.archive.ejectedAt = time.Now().Add(-time.Minute * 2)
+	router.archive.mu.Unlock()
+	r.True(router.useArchive())
+	r.False(router.useArchive())
+}
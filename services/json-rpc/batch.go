@@ -0,0 +1,219 @@
+package json_rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMaxBatchSize     = 100
+	defaultBatchConcurrency = 4
+)
+
+// batchSplitter splits large JSON-RPC batch requests into smaller batches before sending
+// them upstream and reassembles the results in the original request order.
+type batchSplitter struct {
+	next        http.Handler
+	maxSize     int
+	concurrency int
+}
+
+func newBatchSplitter(next http.Handler, maxSize, concurrency int) *batchSplitter {
+	if maxSize <= 0 {
+		maxSize = defaultMaxBatchSize
+	}
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	return &batchSplitter{
+		next:        next,
+		maxSize:     maxSize,
+		concurrency: concurrency,
+	}
+}
+
+type batchChunkResult struct {
+	responses []json.RawMessage
+	err       error
+}
+
+func (bs *batchSplitter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Body == nil || req.Method != http.MethodPost {
+		bs.next.ServeHTTP(w, req)
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		log.WithError(err).Error("failed to read jsonrpc request body")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var batch []json.RawMessage
+	if !isBatch(body) || json.Unmarshal(body, &batch) != nil || len(batch) <= bs.maxSize {
+		bs.passThrough(w, req, body)
+		return
+	}
+
+	chunks := splitBatch(batch, bs.maxSize)
+	results := make([]*batchChunkResult, len(chunks))
+	sem := make(chan struct{}, bs.concurrency)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, chunk []json.RawMessage) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = bs.doChunk(req, chunk)
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	responses := reassembleBatch(chunks, results)
+	// a batch of notifications gets no response body
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(responses); err != nil {
+		log.WithError(err).Error("failed to write jsonrpc batch response body")
+	}
+}
+
+func (bs *batchSplitter) passThrough(w http.ResponseWriter, req *http.Request, body []byte) {
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	bs.next.ServeHTTP(w, req)
+}
+
+func (bs *batchSplitter) doChunk(req *http.Request, chunk []json.RawMessage) *batchChunkResult {
+	body, err := json.Marshal(chunk)
+	if err != nil {
+		return &batchChunkResult{err: err}
+	}
+	chunkReq := req.Clone(req.Context())
+	chunkReq.Body = io.NopCloser(bytes.NewReader(body))
+	chunkReq.ContentLength = int64(len(body))
+	// the chunk responses are decoded here so they should not be compressed
+	chunkReq.Header.Del("Accept-Encoding")
+
+	respBuf := newResponseBuffer()
+	bs.next.ServeHTTP(respBuf, chunkReq)
+
+	var responses []json.RawMessage
+	if err := json.Unmarshal(respBuf.body.Bytes(), &responses); err != nil {
+		log.WithError(err).WithField("status", respBuf.code).Warn("failed to decode jsonrpc batch chunk response")
+		return &batchChunkResult{err: err}
+	}
+	return &batchChunkResult{responses: responses}
+}
+
+// responseBuffer collects the upstream response of a chunk request.
+type responseBuffer struct {
+	header http.Header
+	body   bytes.Buffer
+	code   int
+}
+
+func newResponseBuffer() *responseBuffer {
+	return &responseBuffer{
+		header: make(http.Header),
+		code:   http.StatusOK,
+	}
+}
+
+func (rb *responseBuffer) Header() http.Header {
+	return rb.header
+}
+
+func (rb *responseBuffer) Write(b []byte) (int, error) {
+	return rb.body.Write(b)
+}
+
+func (rb *responseBuffer) WriteHeader(code int) {
+	rb.code = code
+}
+
+func isBatch(body []byte) bool {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+func splitBatch(batch []json.RawMessage, maxSize int) (chunks [][]json.RawMessage) {
+	for len(batch) > maxSize {
+		chunks = append(chunks, batch[:maxSize])
+		batch = batch[maxSize:]
+	}
+	if len(batch) > 0 {
+		chunks = append(chunks, batch)
+	}
+	return
+}
+
+type batchItem struct {
+	ID json.RawMessage `json:"id"`
+}
+
+// reassembleBatch puts the responses in the same order with the requests. Requests from
+// the failed chunks and the requests which did not get a response with a matching id
+// receive error responses.
+func reassembleBatch(chunks [][]json.RawMessage, results []*batchChunkResult) []json.RawMessage {
+	var responses []json.RawMessage
+	for i, chunk := range chunks {
+		result := results[i]
+
+		byID := make(map[string]json.RawMessage)
+		for _, resp := range result.responses {
+			var item batchItem
+			if err := json.Unmarshal(resp, &item); err != nil || len(item.ID) == 0 {
+				log.WithField("response", string(resp)).Warn("jsonrpc batch chunk response has no id")
+				continue
+			}
+			byID[string(item.ID)] = resp
+		}
+
+		for _, reqItem := range chunk {
+			var item batchItem
+			if err := json.Unmarshal(reqItem, &item); err != nil || len(item.ID) == 0 {
+				continue // notifications do not get a response
+			}
+			if resp, ok := byID[string(item.ID)]; ok {
+				responses = append(responses, resp)
+				delete(byID, string(item.ID))
+				continue
+			}
+			responses = append(responses, makeBatchErrResponse(item.ID))
+		}
+	}
+	return responses
+}
+
+func makeBatchErrResponse(id json.RawMessage) json.RawMessage {
+	return makeErrResponse(id, -32603, "failed to execute batch request")
+}
+
+func makeErrResponse(id json.RawMessage, code int, message string) json.RawMessage {
+	b, _ := json.Marshal(&struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Error   jsonRpcError    `json:"error"`
+	}{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error: jsonRpcError{
+			Code:    code,
+			Message: message,
+		},
+	})
+	return b
+}
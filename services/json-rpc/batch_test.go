@@ -0,0 +1,144 @@
+package json_rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchSplitter(t *testing.T) {
+	r := require.New(t)
+
+	var upstreamCalls int32
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&upstreamCalls, 1)
+		r.Empty(req.Header.Get("Accept-Encoding"))
+		var batch []batchItem
+		r.NoError(json.NewDecoder(req.Body).Decode(&batch))
+		r.LessOrEqual(len(batch), 2)
+		// respond in reverse order to check the reassembly
+		var responses []map[string]interface{}
+		for i := len(batch) - 1; i >= 0; i-- {
+			responses = append(responses, map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      batch[i].ID,
+				"result":  batch[i].ID,
+			})
+		}
+		r.NoError(json.NewEncoder(w).Encode(responses))
+	})
+
+	splitter := newBatchSplitter(upstream, 2, 2)
+
+	body := `[
+		{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"},
+		{"jsonrpc":"2.0","id":2,"method":"eth_blockNumber"},
+		{"jsonrpc":"2.0","id":3,"method":"eth_blockNumber"},
+		{"jsonrpc":"2.0","id":4,"method":"eth_blockNumber"},
+		{"jsonrpc":"2.0","id":5,"method":"eth_blockNumber"}
+	]`
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:8545", bytes.NewBufferString(body))
+	req.Header.Set("Accept-Encoding", "gzip")
+	recorder := httptest.NewRecorder()
+	splitter.ServeHTTP(recorder, req)
+
+	r.Equal(int32(3), atomic.LoadInt32(&upstreamCalls))
+
+	var responses []batchItem
+	r.NoError(json.NewDecoder(recorder.Body).Decode(&responses))
+	r.Len(responses, 5)
+	for i, resp := range responses {
+		r.Equal(json.RawMessage([]byte{byte('1' + i)}), resp.ID)
+	}
+}
+
+func TestBatchSplitter_SmallBatch(t *testing.T) {
+	r := require.New(t)
+
+	body := `[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}]`
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var b bytes.Buffer
+		_, err := b.ReadFrom(req.Body)
+		r.NoError(err)
+		r.Equal(body, b.String())
+		w.Write([]byte(`[{"jsonrpc":"2.0","id":1,"result":"0x1"}]`))
+	})
+
+	splitter := newBatchSplitter(upstream, 2, 2)
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:8545", bytes.NewBufferString(body))
+	recorder := httptest.NewRecorder()
+	splitter.ServeHTTP(recorder, req)
+	r.Equal(`[{"jsonrpc":"2.0","id":1,"result":"0x1"}]`, recorder.Body.String())
+}
+
+func TestBatchSplitter_FailedChunk(t *testing.T) {
+	r := require.New(t)
+
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	splitter := newBatchSplitter(upstream, 1, 1)
+	body := `[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"},{"jsonrpc":"2.0","id":2,"method":"eth_blockNumber"}]`
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:8545", bytes.NewBufferString(body))
+	recorder := httptest.NewRecorder()
+	splitter.ServeHTTP(recorder, req)
+
+	var responses []errorResponse
+	r.NoError(json.NewDecoder(recorder.Body).Decode(&responses))
+	r.Len(responses, 2)
+	r.Equal(1, responses[0].ID)
+	r.Equal(2, responses[1].ID)
+	r.Equal(-32603, responses[0].Error.Code)
+}
+
+func TestBatchSplitter_UnmatchedResponse(t *testing.T) {
+	r := require.New(t)
+
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var batch []batchItem
+		r.NoError(json.NewDecoder(req.Body).Decode(&batch))
+		if string(batch[0].ID) == "2" {
+			// the upstream fails to echo the id
+			w.Write([]byte(`[{"jsonrpc":"2.0","result":"0x2"}]`))
+			return
+		}
+		w.Write([]byte(`[{"jsonrpc":"2.0","id":` + string(batch[0].ID) + `,"result":"0x1"}]`))
+	})
+
+	splitter := newBatchSplitter(upstream, 1, 1)
+	body := `[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"},{"jsonrpc":"2.0","id":2,"method":"eth_blockNumber"}]`
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:8545", bytes.NewBufferString(body))
+	recorder := httptest.NewRecorder()
+	splitter.ServeHTTP(recorder, req)
+
+	var responses []errorResponse
+	r.NoError(json.NewDecoder(recorder.Body).Decode(&responses))
+	r.Len(responses, 2)
+	r.Equal(1, responses[0].ID)
+	r.Zero(responses[0].Error.Code)
+	r.Equal(2, responses[1].ID)
+	r.Equal(-32603, responses[1].Error.Code)
+}
+
+func TestBatchSplitter_Notifications(t *testing.T) {
+	r := require.New(t)
+
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	splitter := newBatchSplitter(upstream, 1, 1)
+	body := `[{"jsonrpc":"2.0","method":"eth_subscribe"},{"jsonrpc":"2.0","method":"eth_subscribe"}]`
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:8545", bytes.NewBufferString(body))
+	recorder := httptest.NewRecorder()
+	splitter.ServeHTTP(recorder, req)
+
+	r.Equal(http.StatusOK, recorder.Code)
+	r.Empty(recorder.Body.String())
+}
@@ -0,0 +1,253 @@
+package json_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	lru "github.com/hashicorp/golang-lru"
+	log "github.com/sirupsen/logrus"
+)
+
+// the methods which always return the same result for the same params
+var immutableMethods = map[string]bool{
+	"eth_getBlockByHash":        true,
+	"eth_getTransactionByHash":  true,
+	"eth_getTransactionReceipt": true,
+}
+
+// the methods which return the same result when the block number is explicit
+var blockNumberMethods = map[string]bool{
+	"eth_getBlockByNumber": true,
+	"trace_block":          true,
+}
+
+// responseCache serves the repeated requests for the data of a block from a small cache, since
+// many bots request the same block and receipts right after the scanner sends the block to them.
+type responseCache struct {
+	next              http.Handler
+	entries           *lru.Cache // request key -> *cachedResponse
+	ttl               time.Duration
+	confirmationDepth uint64
+	interval          time.Duration
+
+	head   uint64 // accessed atomically
+	hits   uint64 // accessed atomically
+	misses uint64 // accessed atomically
+}
+
+type cachedResponse struct {
+	result    json.RawMessage
+	expiresAt time.Time
+}
+
+type rpcRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type cacheableResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  json.RawMessage `json:"error"`
+}
+
+// newResponseCache creates the cache. The state reads are cached only for the blocks which have at
+// least the confirmation depth, so that the state of a block which is reorged out is not served.
+func newResponseCache(next http.Handler, cfg config.ResponseCacheConfig, confirmationDepth int, interval time.Duration) (*responseCache, error) {
+	entries, err := lru.New(cfg.Size)
+	if err != nil {
+		return nil, err
+	}
+	if confirmationDepth < 1 {
+		confirmationDepth = 1
+	}
+	return &responseCache{
+		next:              next,
+		entries:           entries,
+		ttl:               time.Duration(cfg.TTLSeconds) * time.Second,
+		confirmationDepth: uint64(confirmationDepth),
+		interval:          interval,
+	}, nil
+}
+
+// trackHead polls the latest block number from the upstream.
+func (rc *responseCache) trackHead(ctx context.Context) {
+	pollHead(ctx, rc.next, rc.interval, &rc.head, "response cache")
+}
+
+// isConfirmed tells if the block is deep enough to not be reorged out. Nothing is confirmed until
+// the head is known.
+func (rc *responseCache) isConfirmed(blockNumber uint64) bool {
+	head := atomic.LoadUint64(&rc.head)
+	return head >= rc.confirmationDepth && blockNumber <= head-rc.confirmationDepth
+}
+
+func (rc *responseCache) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Body == nil || req.Method != http.MethodPost {
+		rc.next.ServeHTTP(w, req)
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		log.WithError(err).Error("failed to read jsonrpc request body")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+
+	var rpcReq rpcRequest
+	if isBatch(body) || json.Unmarshal(body, &rpcReq) != nil {
+		rc.next.ServeHTTP(w, req)
+		return
+	}
+	key, ok := cacheKey(&rpcReq)
+	if !ok {
+		rc.next.ServeHTTP(w, req)
+		return
+	}
+	if blockNumber, ok := stateBlockNumber(&rpcReq); ok && !rc.isConfirmed(blockNumber) {
+		rc.next.ServeHTTP(w, req)
+		return
+	}
+
+	if cached, ok := rc.entries.Get(key); ok {
+		resp := cached.(*cachedResponse)
+		if time.Now().Before(resp.expiresAt) {
+			atomic.AddUint64(&rc.hits, 1)
+			rc.writeCached(w, rpcReq.ID, resp.result)
+			return
+		}
+		rc.entries.Remove(key)
+	}
+	atomic.AddUint64(&rc.misses, 1)
+
+	// the response is decoded here so it should not be compressed
+	req.Header.Del("Accept-Encoding")
+	respBuf := newResponseBuffer()
+	rc.next.ServeHTTP(respBuf, req)
+	for k, v := range respBuf.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(respBuf.code)
+	w.Write(respBuf.body.Bytes())
+
+	if respBuf.code != http.StatusOK {
+		return
+	}
+	var rpcResp cacheableResponse
+	if err := json.Unmarshal(respBuf.body.Bytes(), &rpcResp); err != nil {
+		return
+	}
+	// the nodes which are behind return null for the blocks they do not have yet
+	if len(rpcResp.Error) > 0 || len(rpcResp.Result) == 0 || string(rpcResp.Result) == "null" {
+		return
+	}
+	if isPendingResult(rpcResp.Result) {
+		return
+	}
+	rc.entries.Add(key, &cachedResponse{result: rpcResp.Result, expiresAt: time.Now().Add(rc.ttl)})
+}
+
+func (rc *responseCache) writeCached(w http.ResponseWriter, id, result json.RawMessage) {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&struct {
+		JsonRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  json.RawMessage `json:"result"`
+	}{
+		JsonRPC: "2.0",
+		ID:      id,
+		Result:  result,
+	}); err != nil {
+		log.WithError(err).Error("failed to write cached jsonrpc response body")
+	}
+}
+
+// cacheKey returns the key of the request if the result of the request can not change.
+func cacheKey(rpcReq *rpcRequest) (string, bool) {
+	switch {
+	case immutableMethods[rpcReq.Method]:
+	case blockNumberMethods[rpcReq.Method]:
+		if len(rpcReq.Params) == 0 || !isBlockNumberParam(rpcReq.Params[0]) {
+			return "", false
+		}
+	case hasBlockNumberParam(rpcReq):
+		// the state at an explicit block, like the reads of the bots which are pinned to a block -
+		// only cached if the block is confirmed
+	default:
+		return "", false
+	}
+	params, err := canonicalParams(rpcReq.Params)
+	if err != nil {
+		return "", false
+	}
+	return rpcReq.Method + params, true
+}
+
+// isPendingResult tells if the result is about a transaction which is not in a block yet, since
+// the same request returns the mined transaction later.
+func isPendingResult(result json.RawMessage) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(result, &fields); err != nil {
+		return false
+	}
+	for _, name := range []string{"blockHash", "blockNumber"} {
+		if value, ok := fields[name]; ok && string(value) == "null" {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalParams returns a comparable form of the params.
+func canonicalParams(params []json.RawMessage) (string, error) {
+	var key strings.Builder
+	for _, param := range params {
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, param); err != nil {
+			return "", err
+		}
+		key.WriteByte('|')
+		key.Write(bytes.ToLower(compacted.Bytes()))
+	}
+	return key.String(), nil
+}
+
+// isBlockNumberParam tells if the param is a hex block number instead of a tag like "latest".
+func isBlockNumberParam(param json.RawMessage) bool {
+	var blockNumber string
+	if err := json.Unmarshal(param, &blockNumber); err != nil {
+		return false
+	}
+	return strings.HasPrefix(blockNumber, "0x") && len(blockNumber) > 2
+}
+
+// Health implements health.Reporter interface.
+func (rc *responseCache) Health() health.Reports {
+	return health.Reports{
+		{
+			Name:    "response-cache.hits",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&rc.hits), 10),
+		},
+		{
+			Name:    "response-cache.misses",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&rc.misses), 10),
+		},
+	}
+}
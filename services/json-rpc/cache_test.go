@@ -0,0 +1,111 @@
+package json_rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheKey(t *testing.T) {
+	r := require.New(t)
+
+	for _, testCase := range []struct {
+		body      string
+		cacheable bool
+	}{
+		{`{"id":1,"method":"eth_getBlockByNumber","params":["0x10",true]}`, true},
+		{`{"id":1,"method":"eth_getBlockByNumber","params":["latest",true]}`, false},
+		{`{"id":1,"method":"eth_getBlockByNumber","params":[]}`, false},
+		{`{"id":1,"method":"trace_block","params":["0x10"]}`, true},
+		{`{"id":1,"method":"eth_getTransactionReceipt","params":["0xabcd"]}`, true},
+		{`{"id":1,"method":"eth_blockNumber","params":[]}`, false},
+		{`{"id":1,"method":"eth_call","params":[{"to":"0xabcd"},"0x10"]}`, true},
+		{`{"id":1,"method":"eth_call","params":[{"to":"0xabcd"},"latest"]}`, false},
+	} {
+		var rpcReq rpcRequest
+		r.NoError(json.Unmarshal([]byte(testCase.body), &rpcReq))
+		_, ok := cacheKey(&rpcReq)
+		r.Equal(testCase.cacheable, ok, testCase.body)
+	}
+}
+
+func TestIsPendingResult(t *testing.T) {
+	r := require.New(t)
+
+	r.True(isPendingResult(json.RawMessage(`{"hash":"0xabcd","blockHash":null,"blockNumber":null}`)))
+	r.True(isPendingResult(json.RawMessage(`{"transactionHash":"0xabcd","blockNumber":null}`)))
+	r.False(isPendingResult(json.RawMessage(`{"hash":"0xabcd","blockHash":"0x01","blockNumber":"0x10"}`)))
+	r.False(isPendingResult(json.RawMessage(`{"number":"0x10"}`)))
+	r.False(isPendingResult(json.RawMessage(`[{"blockHash":null}]`)))
+}
+
+func TestResponseCache(t *testing.T) {
+	r := require.New(t)
+
+	var upstreamCalls int
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		upstreamCalls++
+		var rpcReq rpcRequest
+		r.NoError(json.NewDecoder(req.Body).Decode(&rpcReq))
+		result := `{"number":"0x10"}`
+		switch rpcReq.Method {
+		case "trace_block":
+			// the provider does not have the block yet
+			result = "null"
+		case "eth_getTransactionByHash":
+			// the transaction is still in the mempool
+			result = `{"hash":"0xabcd","blockHash":null,"blockNumber":null}`
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(rpcReq.ID) + `,"result":` + result + `}`))
+	})
+	cache, err := newResponseCache(upstream, config.ResponseCacheConfig{Size: 10, TTLSeconds: 30}, 5, time.Second)
+	r.NoError(err)
+
+	doRequest := func(body string) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:8545", bytes.NewBufferString(body))
+		recorder := httptest.NewRecorder()
+		cache.ServeHTTP(recorder, req)
+		var resp map[string]interface{}
+		r.NoError(json.NewDecoder(recorder.Body).Decode(&resp))
+		return resp
+	}
+
+	resp := doRequest(`{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["0x10",false]}`)
+	r.Equal(float64(1), resp["id"])
+	resp = doRequest(`{"jsonrpc":"2.0","id":2,"method":"eth_getBlockByNumber","params":["0x10", false]}`)
+	r.Equal(float64(2), resp["id"])
+	r.Equal(map[string]interface{}{"number": "0x10"}, resp["result"])
+	r.Equal(1, upstreamCalls)
+
+	// null results are not cached
+	doRequest(`{"jsonrpc":"2.0","id":3,"method":"trace_block","params":["0x10"]}`)
+	doRequest(`{"jsonrpc":"2.0","id":4,"method":"trace_block","params":["0x10"]}`)
+	r.Equal(3, upstreamCalls)
+
+	// pending transactions are not cached
+	doRequest(`{"jsonrpc":"2.0","id":5,"method":"eth_getTransactionByHash","params":["0xabcd"]}`)
+	doRequest(`{"jsonrpc":"2.0","id":6,"method":"eth_getTransactionByHash","params":["0xabcd"]}`)
+	r.Equal(5, upstreamCalls)
+
+	// the state reads are not cached until the block is confirmed
+	doRequest(`{"jsonrpc":"2.0","id":7,"method":"eth_call","params":[{"to":"0xabcd"},"0x10"]}`)
+	atomic.StoreUint64(&cache.head, 0x14)
+	doRequest(`{"jsonrpc":"2.0","id":8,"method":"eth_call","params":[{"to":"0xabcd"},"0x10"]}`)
+	r.Equal(7, upstreamCalls)
+	atomic.StoreUint64(&cache.head, 0x15)
+	doRequest(`{"jsonrpc":"2.0","id":9,"method":"eth_call","params":[{"to":"0xabcd"},"0x10"]}`)
+	doRequest(`{"jsonrpc":"2.0","id":10,"method":"eth_call","params":[{"to":"0xabcd"},"0x10"]}`)
+	r.Equal(8, upstreamCalls)
+
+	reports := cache.Health()
+	hits, ok := reports.GetByName("response-cache.hits")
+	r.True(ok)
+	r.Equal("2", hits.Details)
+}
@@ -23,6 +23,7 @@ type jsonRpcError struct {
 }
 
 func writeTooManyReqsErr(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set(ErrorClassHeader, errorClassRateLimited)
 	w.WriteHeader(http.StatusTooManyRequests)
 
 	var reqPayload requestPayload
@@ -0,0 +1,228 @@
+package json_rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	log "github.com/sirupsen/logrus"
+)
+
+// fixture is a canned response for a method. The fixture matches any params if the params are not specified.
+type fixture struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	Result json.RawMessage   `json:"result"`
+	Error  json.RawMessage   `json:"error"`
+
+	paramsKey string
+}
+
+// fixtureServer serves the matching requests from the fixtures and passes the rest upstream,
+// so that the bots can be tested against synthetic data in an otherwise live environment.
+type fixtureServer struct {
+	next     http.Handler
+	fixtures []*fixture
+
+	served uint64 // accessed atomically
+}
+
+// loadFixtures reads the fixtures from the JSON files in the dir. Each file contains a list of fixtures
+// and the first matching fixture is used, in the order of the file names.
+func loadFixtures(dir string) ([]*fixture, error) {
+	files, err := filepath.Glob(path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	var fixtures []*fixture
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var fileFixtures []*fixture
+		if err := json.Unmarshal(b, &fileFixtures); err != nil {
+			return nil, fmt.Errorf("invalid fixture file %s: %v", file, err)
+		}
+		for i, f := range fileFixtures {
+			if len(f.Method) == 0 {
+				return nil, fmt.Errorf("fixture %d in %s has no method", i, file)
+			}
+			if f.Params != nil {
+				if f.paramsKey, err = canonicalParams(f.Params); err != nil {
+					return nil, fmt.Errorf("fixture %d in %s has invalid params: %v", i, file, err)
+				}
+			}
+		}
+		fixtures = append(fixtures, fileFixtures...)
+	}
+	return fixtures, nil
+}
+
+func newFixtureServer(next http.Handler, fixtures []*fixture) *fixtureServer {
+	return &fixtureServer{next: next, fixtures: fixtures}
+}
+
+func (fs *fixtureServer) match(req *rpcRequest) (*fixture, bool) {
+	var paramsKey string
+	for _, f := range fs.fixtures {
+		if f.Method != req.Method {
+			continue
+		}
+		if f.Params == nil {
+			return f, true
+		}
+		if len(paramsKey) == 0 {
+			var err error
+			if paramsKey, err = canonicalParams(req.Params); err != nil {
+				return nil, false
+			}
+		}
+		if f.paramsKey == paramsKey {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+func (fs *fixtureServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Body == nil || req.Method != http.MethodPost {
+		fs.next.ServeHTTP(w, req)
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		log.WithError(err).Error("failed to read jsonrpc request body")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+
+	if isBatch(body) {
+		fs.serveBatch(w, req, body)
+		return
+	}
+	var rpcReq rpcRequest
+	if err := json.Unmarshal(body, &rpcReq); err != nil {
+		fs.next.ServeHTTP(w, req)
+		return
+	}
+	f, ok := fs.match(&rpcReq)
+	if !ok {
+		fs.next.ServeHTTP(w, req)
+		return
+	}
+	atomic.AddUint64(&fs.served, 1)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(makeFixtureResponse(rpcReq.ID, f))
+}
+
+// serveBatch serves the matching requests of the batch from the fixtures and sends the rest upstream
+// as a smaller batch.
+func (fs *fixtureServer) serveBatch(w http.ResponseWriter, req *http.Request, body []byte) {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		fs.next.ServeHTTP(w, req)
+		return
+	}
+	var (
+		responses []json.RawMessage
+		upstream  []json.RawMessage
+	)
+	for _, item := range batch {
+		var rpcReq rpcRequest
+		if err := json.Unmarshal(item, &rpcReq); err != nil {
+			upstream = append(upstream, item)
+			continue
+		}
+		f, ok := fs.match(&rpcReq)
+		if !ok {
+			upstream = append(upstream, item)
+			continue
+		}
+		atomic.AddUint64(&fs.served, 1)
+		responses = append(responses, makeFixtureResponse(rpcReq.ID, f))
+	}
+	if len(responses) == 0 {
+		fs.next.ServeHTTP(w, req)
+		return
+	}
+
+	if len(upstream) > 0 {
+		upstreamBody, _ := json.Marshal(upstream)
+		upstreamReq := req.Clone(req.Context())
+		upstreamReq.Body = io.NopCloser(bytes.NewReader(upstreamBody))
+		upstreamReq.ContentLength = int64(len(upstreamBody))
+		// the upstream response is decoded here so it should not be compressed
+		upstreamReq.Header.Del("Accept-Encoding")
+
+		respBuf := newResponseBuffer()
+		fs.next.ServeHTTP(respBuf, upstreamReq)
+		var upstreamResponses []json.RawMessage
+		if err := json.Unmarshal(respBuf.body.Bytes(), &upstreamResponses); err != nil {
+			log.WithError(err).WithField("status", respBuf.code).Warn("failed to decode jsonrpc batch response")
+			for _, item := range upstream {
+				var rpcReq rpcRequest
+				if json.Unmarshal(item, &rpcReq) == nil && len(rpcReq.ID) > 0 {
+					upstreamResponses = append(upstreamResponses, makeBatchErrResponse(rpcReq.ID))
+				}
+			}
+		}
+		responses = append(responses, upstreamResponses...)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(responses); err != nil {
+		log.WithError(err).Error("failed to write jsonrpc batch response body")
+	}
+}
+
+func makeFixtureResponse(id json.RawMessage, f *fixture) json.RawMessage {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	resp := struct {
+		JsonRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  json.RawMessage `json:"result,omitempty"`
+		Error   json.RawMessage `json:"error,omitempty"`
+	}{
+		JsonRPC: "2.0",
+		ID:      id,
+		Result:  f.Result,
+		Error:   f.Error,
+	}
+	if len(resp.Result) == 0 && len(resp.Error) == 0 {
+		resp.Result = json.RawMessage("null")
+	}
+	b, _ := json.Marshal(&resp)
+	return b
+}
+
+// Health implements health.Reporter interface.
+func (fs *fixtureServer) Health() health.Reports {
+	return health.Reports{
+		{
+			Name:    "fixtures.loaded",
+			Status:  health.StatusInfo,
+			Details: strconv.Itoa(len(fs.fixtures)),
+		},
+		{
+			Name:    "fixtures.served",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&fs.served), 10),
+		},
+	}
+}
@@ -0,0 +1,94 @@
+package json_rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testFixtures = `[
+	{"method": "eth_getBlockByNumber", "params": ["0x10", true], "result": {"number": "0x10", "transactions": []}},
+	{"method": "eth_call", "error": {"code": -32000, "message": "execution reverted"}}
+]`
+
+func testFixtureServer(t *testing.T, upstream http.Handler) *fixtureServer {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(path.Join(dir, "exploit.json"), []byte(testFixtures), 0644))
+	require.NoError(t, os.WriteFile(path.Join(dir, "ignored.txt"), []byte("not a fixture"), 0644))
+	fixtures, err := loadFixtures(dir)
+	require.NoError(t, err)
+	require.Len(t, fixtures, 2)
+	return newFixtureServer(upstream, fixtures)
+}
+
+func TestFixtureServer(t *testing.T) {
+	r := require.New(t)
+
+	var upstreamCalls int
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		upstreamCalls++
+		w.Write([]byte(`{"jsonrpc":"2.0","id":3,"result":"0x1"}`))
+	})
+	server := testFixtureServer(t, upstream)
+
+	doRequest := func(body string) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:8545", bytes.NewBufferString(body))
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+		var resp map[string]interface{}
+		r.NoError(json.NewDecoder(recorder.Body).Decode(&resp))
+		return resp
+	}
+
+	resp := doRequest(`{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["0x10", true]}`)
+	r.Equal(float64(1), resp["id"])
+	r.Equal("0x10", resp["result"].(map[string]interface{})["number"])
+
+	resp = doRequest(`{"jsonrpc":"2.0","id":2,"method":"eth_call","params":[{"to":"0x1"},"latest"]}`)
+	r.Equal(float64(2), resp["id"])
+	r.NotNil(resp["error"])
+	r.Nil(resp["result"])
+	r.Equal(0, upstreamCalls)
+
+	// other blocks are passed upstream
+	resp = doRequest(`{"jsonrpc":"2.0","id":3,"method":"eth_getBlockByNumber","params":["0x11", true]}`)
+	r.Equal("0x1", resp["result"])
+	r.Equal(1, upstreamCalls)
+
+	served, ok := server.Health().GetByName("fixtures.served")
+	r.True(ok)
+	r.Equal("2", served.Details)
+}
+
+func TestFixtureServer_Batch(t *testing.T) {
+	r := require.New(t)
+
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var batch []rpcRequest
+		r.NoError(json.NewDecoder(req.Body).Decode(&batch))
+		r.Len(batch, 1)
+		r.Equal("eth_blockNumber", batch[0].Method)
+		w.Write([]byte(`[{"jsonrpc":"2.0","id":2,"result":"0x20"}]`))
+	})
+	server := testFixtureServer(t, upstream)
+
+	body := `[
+		{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["0x10",true]},
+		{"jsonrpc":"2.0","id":2,"method":"eth_blockNumber","params":[]}
+	]`
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:8545", bytes.NewBufferString(body))
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, req)
+
+	var responses []batchItem
+	r.NoError(json.NewDecoder(recorder.Body).Decode(&responses))
+	r.Len(responses, 2)
+	r.Equal(json.RawMessage("1"), responses[0].ID)
+	r.Equal(json.RawMessage("2"), responses[1].ID)
+}
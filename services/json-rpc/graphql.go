@@ -0,0 +1,600 @@
+package json_rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	graphQLPath = "/graphql"
+	// the max number of blocks which can be queried at once with the blocks field
+	maxGraphQLBlockRange = 100
+	// the max size of the request body - the parser recurses on the nested values and selections
+	maxGraphQLBodySize = 1 << 20
+)
+
+var errGraphQLTooManyRequests = errors.New("the query needs too many json-rpc requests")
+
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+type graphQLResponse struct {
+	Data   interface{}     `json:"data"`
+	Errors []*graphQLError `json:"errors,omitempty"`
+}
+
+// graphQLServer serves the GraphQL queries of the bots at /graphql and passes the other requests to the
+// JSON-RPC handler. The queries are proxied to the upstream GraphQL endpoint if there is one, or the
+// supported subset of the EIP-1767 schema is translated to the JSON-RPC requests which go through the
+// same handler as the JSON-RPC requests of the bots.
+type graphQLServer struct {
+	next http.Handler
+	// the proxied queries go to this handler in the upstream mode so that they can be accounted
+	upstreamHandler http.Handler
+	upstream        *provider
+	maxRequests     int
+
+	queries    uint64 // accessed atomically
+	translated uint64 // accessed atomically
+	failed     uint64 // accessed atomically
+}
+
+func newGraphQLServer(next http.Handler, cfg config.GraphQLConfig) (*graphQLServer, error) {
+	gs := &graphQLServer{
+		next:        next,
+		maxRequests: cfg.MaxRequests,
+	}
+	if len(cfg.Upstream.Url) > 0 {
+		upstream, err := newProvider("graphql", cfg.Upstream)
+		if err != nil {
+			return nil, err
+		}
+		gs.upstream = upstream
+		gs.upstreamHandler = upstream.proxy
+	}
+	return gs, nil
+}
+
+func (gs *graphQLServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if strings.TrimSuffix(req.URL.Path, "/") != graphQLPath {
+		gs.next.ServeHTTP(w, req)
+		return
+	}
+	atomic.AddUint64(&gs.queries, 1)
+	if gs.upstream != nil {
+		gs.upstreamHandler.ServeHTTP(w, req)
+		return
+	}
+	atomic.AddUint64(&gs.translated, 1)
+
+	if req.Method != http.MethodPost || req.Body == nil {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxGraphQLBodySize+1))
+	req.Body.Close()
+	if err != nil {
+		log.WithError(err).Error("failed to read graphql request body")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxGraphQLBodySize {
+		gs.writeResponse(w, http.StatusRequestEntityTooLarge, nil, fmt.Errorf("the request body exceeds %d bytes", maxGraphQLBodySize))
+		return
+	}
+	var gqlReq graphQLRequest
+	if err := json.Unmarshal(body, &gqlReq); err != nil {
+		gs.writeResponse(w, http.StatusBadRequest, nil, fmt.Errorf("invalid graphql request: %v", err))
+		return
+	}
+	selection, err := parseGraphQLQuery(gqlReq.Query, gqlReq.Variables)
+	if err != nil {
+		gs.writeResponse(w, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	rs := &gqlResolver{
+		next:        gs.next,
+		req:         req,
+		maxRequests: gs.maxRequests,
+	}
+	data, err := rs.resolveQuery(selection)
+	if err != nil {
+		gs.writeResponse(w, http.StatusOK, nil, err)
+		return
+	}
+	gs.writeResponse(w, http.StatusOK, data, nil)
+}
+
+func (gs *graphQLServer) writeResponse(w http.ResponseWriter, code int, data interface{}, err error) {
+	resp := &graphQLResponse{Data: data}
+	if err != nil {
+		atomic.AddUint64(&gs.failed, 1)
+		resp.Errors = []*graphQLError{{Message: err.Error()}}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.WithError(err).Error("failed to write graphql response body")
+	}
+}
+
+// Health implements health.Reporter interface.
+func (gs *graphQLServer) Health() health.Reports {
+	mode := "translate"
+	if gs.upstream != nil {
+		mode = "upstream"
+	}
+	return health.Reports{
+		{
+			Name:    "graphql.queries",
+			Status:  health.StatusInfo,
+			Details: fmt.Sprintf("%d (%s)", atomic.LoadUint64(&gs.queries), mode),
+		},
+		{
+			Name:    "graphql.failed",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&gs.failed), 10),
+		},
+	}
+}
+
+// gqlObject is the JSON-RPC result which a GraphQL object is resolved from.
+type gqlObject map[string]interface{}
+
+// gqlFieldResolver resolves a field of an object.
+type gqlFieldResolver func(rs *gqlResolver, obj gqlObject, field *gqlField) (interface{}, error)
+
+// gqlType is the resolvers of the supported fields of an object type.
+type gqlType struct {
+	Name   string
+	Fields map[string]gqlFieldResolver
+}
+
+var (
+	gqlBlockType       *gqlType
+	gqlTransactionType *gqlType
+	gqlLogType         *gqlType
+	gqlAccountType     *gqlType
+)
+
+func init() {
+	gqlAccountType = &gqlType{
+		Name: "Account",
+		Fields: map[string]gqlFieldResolver{
+			"address":          gqlKey("address"),
+			"balance":          gqlAccountState("eth_getBalance"),
+			"transactionCount": gqlAccountState("eth_getTransactionCount"),
+			"code":             gqlAccountState("eth_getCode"),
+		},
+	}
+	gqlLogType = &gqlType{
+		Name: "Log",
+		Fields: map[string]gqlFieldResolver{
+			"index":   gqlKey("logIndex"),
+			"topics":  gqlKey("topics"),
+			"data":    gqlKey("data"),
+			"account": gqlAccount("address", "blockNumber"),
+			"transaction": func(rs *gqlResolver, obj gqlObject, field *gqlField) (interface{}, error) {
+				return rs.resolveCall(field, gqlTransactionType, "eth_getTransactionByHash", obj["transactionHash"])
+			},
+		},
+	}
+	gqlTransactionType = &gqlType{
+		Name: "Transaction",
+		Fields: map[string]gqlFieldResolver{
+			"hash":                 gqlKey("hash"),
+			"nonce":                gqlKey("nonce"),
+			"index":                gqlKey("transactionIndex"),
+			"value":                gqlKey("value"),
+			"gas":                  gqlKey("gas"),
+			"gasPrice":             gqlKey("gasPrice"),
+			"maxFeePerGas":         gqlKey("maxFeePerGas"),
+			"maxPriorityFeePerGas": gqlKey("maxPriorityFeePerGas"),
+			"inputData":            gqlKey("input"),
+			"type":                 gqlKey("type"),
+			"from":                 gqlAccount("from", "blockNumber"),
+			"to":                   gqlAccount("to", "blockNumber"),
+			"block": func(rs *gqlResolver, obj gqlObject, field *gqlField) (interface{}, error) {
+				return rs.resolveCall(field, gqlBlockType, "eth_getBlockByHash", obj["blockHash"], true)
+			},
+			"status":            gqlReceiptKey("status"),
+			"gasUsed":           gqlReceiptKey("gasUsed"),
+			"cumulativeGasUsed": gqlReceiptKey("cumulativeGasUsed"),
+			"effectiveGasPrice": gqlReceiptKey("effectiveGasPrice"),
+			"createdContract": func(rs *gqlResolver, obj gqlObject, field *gqlField) (interface{}, error) {
+				receipt, err := rs.receipt(obj)
+				if err != nil || receipt == nil {
+					return nil, err
+				}
+				return gqlAccount("contractAddress", "blockNumber")(rs, receipt, field)
+			},
+			"logs": func(rs *gqlResolver, obj gqlObject, field *gqlField) (interface{}, error) {
+				receipt, err := rs.receipt(obj)
+				if err != nil || receipt == nil {
+					return nil, err
+				}
+				return rs.resolveList(field, gqlLogType, receipt["logs"])
+			},
+		},
+	}
+	gqlBlockType = &gqlType{
+		Name: "Block",
+		Fields: map[string]gqlFieldResolver{
+			"number":           gqlKey("number"),
+			"hash":             gqlKey("hash"),
+			"nonce":            gqlKey("nonce"),
+			"transactionsRoot": gqlKey("transactionsRoot"),
+			"stateRoot":        gqlKey("stateRoot"),
+			"receiptsRoot":     gqlKey("receiptsRoot"),
+			"ommerHash":        gqlKey("sha3Uncles"),
+			"extraData":        gqlKey("extraData"),
+			"gasLimit":         gqlKey("gasLimit"),
+			"gasUsed":          gqlKey("gasUsed"),
+			"baseFeePerGas":    gqlKey("baseFeePerGas"),
+			"timestamp":        gqlKey("timestamp"),
+			"logsBloom":        gqlKey("logsBloom"),
+			"mixHash":          gqlKey("mixHash"),
+			"difficulty":       gqlKey("difficulty"),
+			"totalDifficulty":  gqlKey("totalDifficulty"),
+			"miner":            gqlAccount("miner", "number"),
+			"parent": func(rs *gqlResolver, obj gqlObject, field *gqlField) (interface{}, error) {
+				return rs.resolveCall(field, gqlBlockType, "eth_getBlockByHash", obj["parentHash"], true)
+			},
+			"transactionCount": func(rs *gqlResolver, obj gqlObject, field *gqlField) (interface{}, error) {
+				txs, _ := obj["transactions"].([]interface{})
+				return len(txs), nil
+			},
+			"transactions": func(rs *gqlResolver, obj gqlObject, field *gqlField) (interface{}, error) {
+				return rs.resolveList(field, gqlTransactionType, obj["transactions"])
+			},
+			"transactionAt": func(rs *gqlResolver, obj gqlObject, field *gqlField) (interface{}, error) {
+				index, err := gqlIntArg(field, "index")
+				if err != nil {
+					return nil, err
+				}
+				txs, _ := obj["transactions"].([]interface{})
+				if index < 0 || int(index) >= len(txs) {
+					return nil, nil
+				}
+				tx, _ := txs[index].(map[string]interface{})
+				return rs.resolveObject(field, gqlTransactionType, tx)
+			},
+			"logs": func(rs *gqlResolver, obj gqlObject, field *gqlField) (interface{}, error) {
+				filter, _ := field.Args["filter"].(map[string]interface{})
+				return rs.resolveLogs(field, map[string]interface{}{
+					"blockHash": obj["hash"],
+					"address":   filter["addresses"],
+					"topics":    filter["topics"],
+				})
+			},
+		},
+	}
+}
+
+// gqlResolver resolves a translated query with the JSON-RPC requests.
+type gqlResolver struct {
+	next        http.Handler
+	req         *http.Request
+	maxRequests int
+	requests    int
+}
+
+func (rs *gqlResolver) resolveQuery(selection []*gqlField) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	for _, field := range selection {
+		value, err := rs.resolveQueryField(field)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", field.Key(), err)
+		}
+		data[field.Key()] = value
+	}
+	return data, nil
+}
+
+func (rs *gqlResolver) resolveQueryField(field *gqlField) (interface{}, error) {
+	switch field.Name {
+	case "block":
+		if hash, ok := field.Args["hash"]; ok {
+			return rs.resolveCall(field, gqlBlockType, "eth_getBlockByHash", hash, true)
+		}
+		blockTag := "latest"
+		if number, ok := field.Args["number"]; ok {
+			var err error
+			if blockTag, err = gqlBlockTag(number); err != nil {
+				return nil, err
+			}
+		}
+		return rs.resolveCall(field, gqlBlockType, "eth_getBlockByNumber", blockTag, true)
+
+	case "blocks":
+		from, err := gqlIntArg(field, "from")
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if _, ok := field.Args["to"]; ok {
+			if to, err = gqlIntArg(field, "to"); err != nil {
+				return nil, err
+			}
+		}
+		if to < from || to-from >= maxGraphQLBlockRange {
+			return nil, fmt.Errorf("the block range must be between 1 and %d blocks", maxGraphQLBlockRange)
+		}
+		var blocks []interface{}
+		for number := from; number <= to; number++ {
+			block, err := rs.resolveCall(field, gqlBlockType, "eth_getBlockByNumber", hexutil.EncodeUint64(uint64(number)), true)
+			if err != nil {
+				return nil, err
+			}
+			if block != nil {
+				blocks = append(blocks, block)
+			}
+		}
+		return blocks, nil
+
+	case "transaction":
+		return rs.resolveCall(field, gqlTransactionType, "eth_getTransactionByHash", field.Args["hash"])
+
+	case "logs":
+		filter, _ := field.Args["filter"].(map[string]interface{})
+		rpcFilter := map[string]interface{}{
+			"address": filter["addresses"],
+			"topics":  filter["topics"],
+		}
+		for _, key := range []string{"fromBlock", "toBlock"} {
+			if number, ok := filter[key]; ok {
+				blockTag, err := gqlBlockTag(number)
+				if err != nil {
+					return nil, err
+				}
+				rpcFilter[key] = blockTag
+			}
+		}
+		return rs.resolveLogs(field, rpcFilter)
+
+	case "chainID":
+		return rs.callScalar("eth_chainId")
+
+	case "gasPrice":
+		return rs.callScalar("eth_gasPrice")
+
+	default:
+		return nil, fmt.Errorf("query field '%s' is not supported", field.Name)
+	}
+}
+
+func (rs *gqlResolver) resolveObject(field *gqlField, typ *gqlType, obj gqlObject) (interface{}, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	if len(field.Selection) == 0 {
+		return nil, fmt.Errorf("field '%s' of type %s must have a selection", field.Name, typ.Name)
+	}
+	result := make(map[string]interface{})
+	for _, subField := range field.Selection {
+		if subField.Name == "__typename" {
+			result[subField.Key()] = typ.Name
+			continue
+		}
+		resolve, ok := typ.Fields[subField.Name]
+		if !ok {
+			return nil, fmt.Errorf("field '%s' is not supported on %s", subField.Name, typ.Name)
+		}
+		value, err := resolve(rs, obj, subField)
+		if err != nil {
+			return nil, err
+		}
+		result[subField.Key()] = value
+	}
+	return result, nil
+}
+
+func (rs *gqlResolver) resolveList(field *gqlField, typ *gqlType, items interface{}) (interface{}, error) {
+	list, _ := items.([]interface{})
+	results := make([]interface{}, 0, len(list))
+	for _, item := range list {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			// the transaction hashes of the blocks which were not requested with the full transactions
+			return nil, fmt.Errorf("unexpected %s in the json-rpc response", typ.Name)
+		}
+		result, err := rs.resolveObject(field, typ, obj)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (rs *gqlResolver) resolveCall(field *gqlField, typ *gqlType, method string, params ...interface{}) (interface{}, error) {
+	var obj gqlObject
+	if err := rs.call(&obj, method, params...); err != nil {
+		return nil, err
+	}
+	return rs.resolveObject(field, typ, obj)
+}
+
+func (rs *gqlResolver) resolveLogs(field *gqlField, filter map[string]interface{}) (interface{}, error) {
+	for key, value := range filter {
+		if value == nil {
+			delete(filter, key)
+		}
+	}
+	var logs []interface{}
+	if err := rs.call(&logs, "eth_getLogs", filter); err != nil {
+		return nil, err
+	}
+	return rs.resolveList(field, gqlLogType, logs)
+}
+
+// receipt gets the receipt of the transaction once for all of the receipt fields.
+func (rs *gqlResolver) receipt(tx gqlObject) (gqlObject, error) {
+	if receipt, ok := tx["_receipt"].(gqlObject); ok {
+		return receipt, nil
+	}
+	var receipt gqlObject
+	if err := rs.call(&receipt, "eth_getTransactionReceipt", tx["hash"]); err != nil {
+		return nil, err
+	}
+	tx["_receipt"] = receipt
+	return receipt, nil
+}
+
+func (rs *gqlResolver) callScalar(method string) (interface{}, error) {
+	var result interface{}
+	if err := rs.call(&result, method); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// call sends a JSON-RPC request through the handler of the bot requests, with the headers and the
+// remote address of the GraphQL request, and decodes the result. Each call is rate limited and
+// accounted to the bot like the JSON-RPC requests it sends directly.
+func (rs *gqlResolver) call(result interface{}, method string, params ...interface{}) error {
+	rs.requests++
+	if rs.requests > rs.maxRequests {
+		return errGraphQLTooManyRequests
+	}
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      rs.requests,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+	rpcReq := rs.req.Clone(rs.req.Context())
+	rpcReq.URL.Path = "/"
+	rpcReq.Method = http.MethodPost
+	rpcReq.Header.Set("Content-Type", "application/json")
+	// the response is decoded here so it should not be compressed
+	rpcReq.Header.Del("Accept-Encoding")
+	setRequestBody(rpcReq, body)
+
+	respBuf := newResponseBuffer()
+	rs.next.ServeHTTP(respBuf, rpcReq)
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpcErrorBody   `json:"error"`
+	}
+	if err := json.Unmarshal(respBuf.body.Bytes(), &resp); err != nil {
+		return fmt.Errorf("%s failed with status %d", method, respBuf.code)
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%s failed: %s", method, resp.Error.Message)
+	}
+	if len(resp.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(resp.Result, result)
+}
+
+// gqlKey resolves a field from a key of the JSON-RPC result.
+func gqlKey(key string) gqlFieldResolver {
+	return func(rs *gqlResolver, obj gqlObject, field *gqlField) (interface{}, error) {
+		return obj[key], nil
+	}
+}
+
+func gqlReceiptKey(key string) gqlFieldResolver {
+	return func(rs *gqlResolver, obj gqlObject, field *gqlField) (interface{}, error) {
+		receipt, err := rs.receipt(obj)
+		if err != nil || receipt == nil {
+			return nil, err
+		}
+		return receipt[key], nil
+	}
+}
+
+// gqlAccount resolves an account from an address of the JSON-RPC result. The state of the account is
+// read at the block of the result.
+func gqlAccount(addressKey, blockKey string) gqlFieldResolver {
+	return func(rs *gqlResolver, obj gqlObject, field *gqlField) (interface{}, error) {
+		address, ok := obj[addressKey].(string)
+		if !ok {
+			return nil, nil
+		}
+		blockTag, ok := obj[blockKey].(string)
+		if !ok {
+			blockTag = "latest"
+		}
+		return rs.resolveObject(field, gqlAccountType, gqlObject{"address": address, "_block": blockTag})
+	}
+}
+
+func gqlAccountState(method string) gqlFieldResolver {
+	return func(rs *gqlResolver, obj gqlObject, field *gqlField) (interface{}, error) {
+		var result interface{}
+		if err := rs.call(&result, method, obj["address"], obj["_block"]); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+}
+
+func gqlIntArg(field *gqlField, name string) (int64, error) {
+	value, ok := field.Args[name]
+	if !ok {
+		return 0, fmt.Errorf("argument '%s' is required", name)
+	}
+	blockTag, err := gqlBlockTag(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid argument '%s': %v", name, err)
+	}
+	n, err := hexutil.DecodeUint64(blockTag)
+	if err != nil {
+		return 0, fmt.Errorf("invalid argument '%s': %v", name, err)
+	}
+	return int64(n), nil
+}
+
+// gqlBlockTag converts a Long argument to a JSON-RPC block number. The variables are decoded from JSON
+// as float64 and the Long values can be the decimal or the hex strings.
+func gqlBlockTag(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case int64:
+		if v < 0 {
+			return "", fmt.Errorf("negative number %d", v)
+		}
+		return hexutil.EncodeUint64(uint64(v)), nil
+	case float64:
+		if v < 0 || v != float64(int64(v)) {
+			return "", fmt.Errorf("invalid number %v", v)
+		}
+		return hexutil.EncodeUint64(uint64(v)), nil
+	case string:
+		n, ok := new(big.Int).SetString(v, 0)
+		if !ok || n.Sign() < 0 || !n.IsUint64() {
+			return "", fmt.Errorf("invalid number '%s'", v)
+		}
+		return hexutil.EncodeUint64(n.Uint64()), nil
+	default:
+		return "", fmt.Errorf("invalid number %v", value)
+	}
+}
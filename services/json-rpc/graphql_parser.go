@@ -0,0 +1,353 @@
+package json_rpc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// gqlField is a selected field of a GraphQL query. The argument values are resolved from the variables.
+type gqlField struct {
+	Alias     string
+	Name      string
+	Args      map[string]interface{}
+	Selection []*gqlField
+}
+
+// Key returns the name of the field in the response.
+func (f *gqlField) Key() string {
+	if len(f.Alias) > 0 {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// gqlParser parses the subset of the GraphQL query language which the translated queries need: a single
+// query operation with the fields, the aliases, the arguments and the variables. The fragments and the
+// directives are not supported.
+type gqlParser struct {
+	src       string
+	pos       int
+	depth     int
+	variables map[string]interface{}
+}
+
+// the max nesting of the selection sets and the values in a query
+const maxGraphQLQueryDepth = 32
+
+// parseGraphQLQuery parses the query and returns the selection of the query operation.
+func parseGraphQLQuery(query string, variables map[string]interface{}) ([]*gqlField, error) {
+	p := &gqlParser{src: query, variables: variables}
+	p.skipIgnored()
+	if name := p.peekName(); len(name) > 0 {
+		if name != "query" {
+			return nil, fmt.Errorf("only the query operations are supported, got '%s'", name)
+		}
+		p.readName()
+		p.skipIgnored()
+		p.readName() // the optional operation name
+		p.skipIgnored()
+		if p.peek() == '(' {
+			// the variable types are not checked
+			if err := p.skipUntil(')'); err != nil {
+				return nil, err
+			}
+		}
+	}
+	selection, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	p.skipIgnored()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected '%c' after the query - only one operation is supported", p.peek())
+	}
+	return selection, nil
+}
+
+func (p *gqlParser) parseSelectionSet() ([]*gqlField, error) {
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+	if err := p.nest(); err != nil {
+		return nil, err
+	}
+	defer p.unnest()
+	var selection []*gqlField
+	for {
+		p.skipIgnored()
+		switch p.peek() {
+		case '}':
+			p.pos++
+			if len(selection) == 0 {
+				return nil, p.errorf("empty selection set")
+			}
+			return selection, nil
+		case '.':
+			return nil, p.errorf("fragments are not supported")
+		case '@':
+			return nil, p.errorf("directives are not supported")
+		case 0:
+			return nil, p.errorf("unexpected end of the query")
+		}
+		field, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		selection = append(selection, field)
+	}
+}
+
+func (p *gqlParser) parseField() (*gqlField, error) {
+	name := p.readName()
+	if len(name) == 0 {
+		return nil, p.errorf("expected a field name, got '%c'", p.peek())
+	}
+	field := &gqlField{Name: name}
+	p.skipIgnored()
+	if p.peek() == ':' {
+		p.pos++
+		p.skipIgnored()
+		field.Alias = name
+		if field.Name = p.readName(); len(field.Name) == 0 {
+			return nil, p.errorf("expected a field name after the alias '%s'", name)
+		}
+		p.skipIgnored()
+	}
+	if p.peek() == '(' {
+		p.pos++
+		field.Args = make(map[string]interface{})
+		for {
+			p.skipIgnored()
+			if p.peek() == ')' {
+				p.pos++
+				break
+			}
+			argName := p.readName()
+			if len(argName) == 0 {
+				return nil, p.errorf("expected an argument name")
+			}
+			p.skipIgnored()
+			if err := p.expect(':'); err != nil {
+				return nil, err
+			}
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			field.Args[argName] = value
+		}
+		p.skipIgnored()
+	}
+	if p.peek() == '@' {
+		return nil, p.errorf("directives are not supported")
+	}
+	if p.peek() == '{' {
+		selection, err := p.parseSelectionSet()
+		if err != nil {
+			return nil, err
+		}
+		field.Selection = selection
+	}
+	return field, nil
+}
+
+func (p *gqlParser) parseValue() (interface{}, error) {
+	p.skipIgnored()
+	c := p.peek()
+	switch {
+	case c == '$':
+		p.pos++
+		name := p.readName()
+		value, ok := p.variables[name]
+		if !ok {
+			return nil, p.errorf("variable $%s is not defined", name)
+		}
+		return value, nil
+
+	case c == '"':
+		return p.readString()
+
+	case c == '[':
+		p.pos++
+		if err := p.nest(); err != nil {
+			return nil, err
+		}
+		defer p.unnest()
+		var list []interface{}
+		for {
+			p.skipIgnored()
+			if p.peek() == ']' {
+				p.pos++
+				return list, nil
+			}
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+
+	case c == '{':
+		p.pos++
+		if err := p.nest(); err != nil {
+			return nil, err
+		}
+		defer p.unnest()
+		obj := make(map[string]interface{})
+		for {
+			p.skipIgnored()
+			if p.peek() == '}' {
+				p.pos++
+				return obj, nil
+			}
+			key := p.readName()
+			if len(key) == 0 {
+				return nil, p.errorf("expected an object field name")
+			}
+			p.skipIgnored()
+			if err := p.expect(':'); err != nil {
+				return nil, err
+			}
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			obj[key] = value
+		}
+
+	case c == '-' || unicode.IsDigit(rune(c)):
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.src) && strings.ContainsRune("0123456789.eE+-", rune(p.src[p.pos])) {
+			p.pos++
+		}
+		number := p.src[start:p.pos]
+		if n, err := strconv.ParseInt(number, 10, 64); err == nil {
+			return n, nil
+		}
+		f, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number '%s'", number)
+		}
+		return f, nil
+
+	default:
+		name := p.readName()
+		switch name {
+		case "":
+			return nil, p.errorf("expected a value, got '%c'", c)
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		default:
+			// enum values
+			return name, nil
+		}
+	}
+}
+
+func (p *gqlParser) nest() error {
+	p.depth++
+	if p.depth > maxGraphQLQueryDepth {
+		return p.errorf("the query is nested deeper than %d levels", maxGraphQLQueryDepth)
+	}
+	return nil
+}
+
+func (p *gqlParser) unnest() {
+	p.depth--
+}
+
+func (p *gqlParser) readString() (string, error) {
+	if strings.HasPrefix(p.src[p.pos:], `"""`) {
+		return "", p.errorf("block strings are not supported")
+	}
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos += 2
+			continue
+		case '"':
+			p.pos++
+			str, err := strconv.Unquote(p.src[start:p.pos])
+			if err != nil {
+				return "", p.errorf("invalid string: %v", err)
+			}
+			return str, nil
+		}
+		p.pos++
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *gqlParser) peek() byte {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *gqlParser) peekName() string {
+	pos := p.pos
+	name := p.readName()
+	p.pos = pos
+	return name
+}
+
+func (p *gqlParser) readName() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := rune(p.src[p.pos])
+		if c == '_' || unicode.IsLetter(c) || (p.pos > start && unicode.IsDigit(c)) {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *gqlParser) expect(c byte) error {
+	p.skipIgnored()
+	if p.peek() != c {
+		return p.errorf("expected '%c'", c)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *gqlParser) skipUntil(c byte) error {
+	i := strings.IndexByte(p.src[p.pos:], c)
+	if i < 0 {
+		return p.errorf("expected '%c'", c)
+	}
+	p.pos += i + 1
+	return nil
+}
+
+// skipIgnored skips the white space, the commas and the comments.
+func (p *gqlParser) skipIgnored() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ',' || unicode.IsSpace(rune(c)):
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *gqlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("syntax error at position %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
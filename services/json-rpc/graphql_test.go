@@ -0,0 +1,224 @@
+package json_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/clients/messaging"
+	mock_clients "github.com/forta-network/forta-node/clients/mocks"
+	"github.com/forta-network/forta-node/config"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGraphQLQuery(t *testing.T) {
+	r := require.New(t)
+
+	selection, err := parseGraphQLQuery(`
+		# the latest block and a transaction
+		query Test($hash: Bytes32!) {
+			latest: block { number, miner { address } }
+			transaction(hash: $hash) { hash }
+			logs(filter: {fromBlock: 1, addresses: ["0x1", "0x2"], topics: [[]]}) { data }
+		}`, map[string]interface{}{"hash": "0xabcd"})
+	r.NoError(err)
+	r.Len(selection, 3)
+	r.Equal("latest", selection[0].Key())
+	r.Equal("block", selection[0].Name)
+	r.Len(selection[0].Selection, 2)
+	r.Equal("address", selection[0].Selection[1].Selection[0].Name)
+	r.Equal("0xabcd", selection[1].Args["hash"])
+	filter := selection[2].Args["filter"].(map[string]interface{})
+	r.Equal(int64(1), filter["fromBlock"])
+	r.Equal([]interface{}{"0x1", "0x2"}, filter["addresses"])
+
+	for _, query := range []string{
+		`mutation { sendRawTransaction(data: "0x") }`,
+		`{ block { ...blockFields } }`,
+		`{ block(number: $undefined) { number } }`,
+		`{ block { number }`,
+		`{ block { } }`,
+		`{ block { number } } { block { hash } }`,
+		`{ logs(filter: {topics: ` + strings.Repeat("[", 100) + `}) { data } }`,
+		strings.Repeat("{ block ", 100) + strings.Repeat("}", 100),
+	} {
+		_, err := parseGraphQLQuery(query, nil)
+		r.Error(err, query)
+	}
+}
+
+func testGraphQLUpstream(t *testing.T, calls *[]string) http.Handler {
+	block := map[string]interface{}{
+		"number":     "0x10",
+		"hash":       "0xb10",
+		"parentHash": "0xb0f",
+		"miner":      "0xminer",
+		"transactions": []interface{}{
+			map[string]interface{}{"hash": "0xt1", "from": "0xfrom", "to": "0xto", "blockNumber": "0x10", "blockHash": "0xb10"},
+		},
+	}
+	results := map[string]interface{}{
+		"eth_getBlockByNumber":      block,
+		"eth_getBlockByHash":        map[string]interface{}{"number": "0xf", "hash": "0xb0f"},
+		"eth_getTransactionReceipt": map[string]interface{}{"status": "0x1", "logs": []interface{}{map[string]interface{}{"logIndex": "0x0", "data": "0xdata"}}},
+		"eth_getBalance":            "0x64",
+		"eth_chainId":               "0x1",
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var rpcReq rpcRequest
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&rpcReq))
+		*calls = append(*calls, rpcReq.Method)
+		result, ok := results[rpcReq.Method]
+		if !ok {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": rpcReq.ID, "result": result})
+	})
+}
+
+func doGraphQLRequest(t *testing.T, h http.Handler, query string) *graphQLResponse {
+	body, _ := json.Marshal(&graphQLRequest{Query: query})
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:8545/graphql", bytes.NewReader(body))
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+	var resp graphQLResponse
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+	return &resp
+}
+
+func TestGraphQLServer_Translate(t *testing.T) {
+	r := require.New(t)
+
+	var calls []string
+	gs, err := newGraphQLServer(testGraphQLUpstream(t, &calls), config.GraphQLConfig{Enable: true, MaxRequests: 5})
+	r.NoError(err)
+
+	resp := doGraphQLRequest(t, gs, `{
+		chainID
+		block(number: 16) {
+			number
+			parent { hash }
+			miner { address balance }
+			transactionCount
+			transactions { hash from { address } status logs { index data } }
+		}
+	}`)
+	r.Empty(resp.Errors)
+	b, _ := json.Marshal(resp.Data)
+	r.JSONEq(`{
+		"chainID": "0x1",
+		"block": {
+			"number": "0x10",
+			"parent": {"hash": "0xb0f"},
+			"miner": {"address": "0xminer", "balance": "0x64"},
+			"transactionCount": 1,
+			"transactions": [{"hash": "0xt1", "from": {"address": "0xfrom"}, "status": "0x1", "logs": [{"index": "0x0", "data": "0xdata"}]}]
+		}
+	}`, string(b))
+	// the receipt is requested once for both of the receipt fields
+	r.Equal([]string{"eth_chainId", "eth_getBlockByNumber", "eth_getBlockByHash", "eth_getBalance", "eth_getTransactionReceipt"}, calls)
+
+	resp = doGraphQLRequest(t, gs, `{ block { unknownField } }`)
+	r.Len(resp.Errors, 1)
+	r.Contains(resp.Errors[0].Message, "not supported")
+
+	resp = doGraphQLRequest(t, gs, `{ gasPrice }`)
+	r.Len(resp.Errors, 1)
+	r.Contains(resp.Errors[0].Message, "method not found")
+
+	resp = doGraphQLRequest(t, gs, `{ blocks(from: 1, to: 10) { number } }`)
+	r.Len(resp.Errors, 1)
+	r.Contains(resp.Errors[0].Message, "too many")
+
+	// the other paths go to the json-rpc handler
+	calls = nil
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:8545", bytes.NewBufferString(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`))
+	gs.ServeHTTP(httptest.NewRecorder(), req)
+	r.Equal([]string{"eth_chainId"}, calls)
+}
+
+func TestGraphQLServer_Upstream(t *testing.T) {
+	r := require.New(t)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.Equal("/graphql", req.URL.Path)
+		r.Equal("secret", req.Header.Get("X-Api-Key"))
+		w.Write([]byte(`{"data":{"block":{"number":"0x20"}}}`))
+	}))
+	defer upstream.Close()
+
+	gs, err := newGraphQLServer(nil, config.GraphQLConfig{
+		Enable:   true,
+		Upstream: config.JsonRpcConfig{Url: upstream.URL + "/graphql", Headers: map[string]string{"X-Api-Key": "secret"}},
+	})
+	r.NoError(err)
+
+	resp := doGraphQLRequest(t, gs, `{ block { number } }`)
+	r.Empty(resp.Errors)
+	r.Equal(map[string]interface{}{"block": map[string]interface{}{"number": "0x20"}}, resp.Data)
+}
+
+func TestGraphQLServer_BodyTooLarge(t *testing.T) {
+	r := require.New(t)
+
+	gs, err := newGraphQLServer(http.NotFoundHandler(), config.GraphQLConfig{Enable: true, MaxRequests: 5})
+	r.NoError(err)
+
+	resp := doGraphQLRequest(t, gs, `{ chainID }`+strings.Repeat(" ", maxGraphQLBodySize))
+	r.Len(resp.Errors, 1)
+	r.Contains(resp.Errors[0].Message, "exceeds")
+}
+
+func TestGraphQLServer_Accounting(t *testing.T) {
+	r := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	dockerClient := mock_clients.NewMockDockerClient(ctrl)
+	msgClient := mock_clients.NewMockMessageClient(ctrl)
+	dockerClient.EXPECT().GetContainers(gomock.Any()).Return(clients.DockerContainerList{
+		{
+			Names: []string{"/test-bot"},
+			NetworkSettings: &types.SummaryNetworkSettings{
+				Networks: map[string]*network.EndpointSettings{"test": {IPAddress: "192.0.2.1"}},
+			},
+		},
+	}, nil).AnyTimes()
+	msgClient.EXPECT().PublishProto(messaging.SubjectMetricAgent, gomock.Any()).AnyTimes()
+
+	p := &JsonRpcProxy{
+		ctx:          context.Background(),
+		dockerClient: dockerClient,
+		msgClient:    msgClient,
+		agentConfigs: []config.AgentConfig{{ID: "test-bot", IsStandalone: true}},
+		rateLimiter:  NewRateLimiter(0.001, 4),
+		usage:        newUsageTracker(path.Join(t.TempDir(), config.DefaultRPCUsageFileName), config.RPCUsageConfig{RetentionHours: 24}),
+	}
+	var calls []string
+	gs, err := newGraphQLServer(p.metricHandler(testGraphQLUpstream(t, &calls), p.usage.Track), config.GraphQLConfig{Enable: true, MaxRequests: 10})
+	r.NoError(err)
+
+	// each translated request is accounted to the bot
+	resp := doGraphQLRequest(t, gs, `{ chainID block { number parent { hash } } }`)
+	r.Empty(resp.Errors)
+	usage := p.usage.List()
+	r.Len(usage, 1)
+	r.Equal("test-bot", usage[0].BotID)
+	r.Equal(uint64(3), usage[0].Requests)
+	r.Equal(uint64(5), usage[0].ComputeUnits)
+
+	// and counts against the rate limit of the bot
+	resp = doGraphQLRequest(t, gs, `{ chainID gasPrice }`)
+	r.Len(resp.Errors, 1)
+	r.Contains(resp.Errors[0].Message, "exceeds scan node request limit")
+	r.Equal("eth_chainId", calls[len(calls)-1])
+}
@@ -1,16 +1,21 @@
 package json_rpc
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
-	"net/http/httputil"
-	"net/url"
+	"path"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/docker/docker/api/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/rs/cors"
 	log "github.com/sirupsen/logrus"
 
@@ -23,12 +28,13 @@ import (
 	"github.com/forta-network/forta-node/clients/messaging"
 	"github.com/forta-network/forta-node/config"
 	"github.com/forta-network/forta-node/metrics"
+	"github.com/forta-network/forta-node/tlsutils"
 )
 
 // JsonRpcProxy proxies requests from agents to json-rpc endpoint
 type JsonRpcProxy struct {
 	ctx          context.Context
-	cfg          config.JsonRpcConfig
+	providers    *providerPool
 	server       *http.Server
 	dockerClient clients.DockerClient
 	msgClient    clients.MessageClient
@@ -36,28 +42,33 @@ type JsonRpcProxy struct {
 	agentConfigs  []config.AgentConfig
 	agentConfigMu sync.RWMutex
 
-	rateLimiter *RateLimiter
+	rateLimiter   *RateLimiter
+	usage         *usageTracker
+	archive       *archiveRouter
+	responseCache *responseCache
+	fixtures      *fixtureServer
+	blockPinner   *blockPinner
+	graphQL       *graphQLServer
+
+	maxBatchSize     int
+	batchConcurrency int
+	normalizeErrors  bool
+	tlsConfig        *tls.Config
+	// presents the proxy certificate to the proxy itself while testing the api
+	testTransport *http.Transport
 
 	lastErr health.ErrorTracker
 }
 
 func (p *JsonRpcProxy) Start() error {
 	p.registerMessageHandlers()
-
-	rpcUrl, err := url.Parse(p.cfg.Url)
-	if err != nil {
-		return err
+	go p.providers.checkHealth(p.ctx)
+	go p.usage.saveLoop(p.ctx)
+	if p.archive != nil {
+		go p.archive.trackHead(p.ctx)
 	}
-	rp := httputil.NewSingleHostReverseProxy(rpcUrl)
-
-	d := rp.Director
-	rp.Director = func(r *http.Request) {
-		d(r)
-		r.Host = rpcUrl.Host
-		r.URL = rpcUrl
-		for h, v := range p.cfg.Headers {
-			r.Header.Set(h, v)
-		}
+	if p.responseCache != nil {
+		go p.responseCache.trackHead(p.ctx)
 	}
 
 	c := cors.New(cors.Options{
@@ -65,15 +76,59 @@ func (p *JsonRpcProxy) Start() error {
 		AllowCredentials: true,
 	})
 
+	var upstream http.Handler = p.providers
+	if p.archive != nil {
+		upstream = p.archive
+	}
+	if p.responseCache != nil {
+		upstream = p.responseCache
+	}
+	if p.blockPinner != nil {
+		p.blockPinner.next = upstream
+		upstream = p.blockPinner
+	}
+	if p.fixtures != nil {
+		p.fixtures.next = upstream
+		upstream = p.fixtures
+	}
+
+	var handler http.Handler = newBatchSplitter(upstream, p.maxBatchSize, p.batchConcurrency)
+	if p.normalizeErrors {
+		handler = newErrorNormalizer(handler)
+	}
+	// the json-rpc requests which the graphql queries are translated to are rate limited and
+	// accounted one by one, like the requests which the bots send directly
+	handler = p.metricHandler(handler, p.usage.Track)
+	if p.graphQL != nil {
+		p.graphQL.next = handler
+		if p.graphQL.upstream != nil {
+			p.graphQL.upstreamHandler = p.metricHandler(p.graphQL.upstreamHandler, p.usage.TrackGraphQL)
+		}
+		handler = p.graphQL
+	}
+
 	p.server = &http.Server{
-		Addr:    ":8545",
-		Handler: p.metricHandler(c.Handler(rp)),
+		Addr:      ":8545",
+		Handler:   c.Handler(handler),
+		TLSConfig: p.tlsConfig,
 	}
-	utils.GoListenAndServe(p.server)
+	if p.tlsConfig == nil {
+		utils.GoListenAndServe(p.server)
+		return nil
+	}
+	go func() {
+		switch err := p.server.ListenAndServeTLS("", ""); err {
+		case nil, http.ErrServerClosed:
+		default:
+			log.WithError(err).Panic("server error")
+		}
+	}()
 	return nil
 }
 
-func (p *JsonRpcProxy) metricHandler(h http.Handler) http.Handler {
+// metricHandler rate limits the requests of the bots and accounts the compute units of the request
+// bodies with the track function.
+func (p *JsonRpcProxy) metricHandler(h http.Handler, track func(botID string, body []byte) int) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		t := time.Now()
 		agentConfig, foundAgent := p.findAgentFromRemoteAddr(req.RemoteAddr)
@@ -85,17 +140,52 @@ func (p *JsonRpcProxy) metricHandler(h http.Handler) http.Handler {
 			return
 		}
 
+		var computeUnits int
+		if foundAgent && req.Body != nil {
+			body, err := io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				log.WithError(err).Error("failed to read jsonrpc request body")
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			computeUnits = track(agentConfig.ID, body)
+		}
+
+		// the error normalizer counts the upstream errors by class
+		errorClasses := make(map[string]int)
+		req = req.WithContext(context.WithValue(req.Context(), errorClassesKey{}, errorClasses))
+
 		h.ServeHTTP(w, req)
 
 		if foundAgent {
 			duration := time.Since(t)
+			agentMetrics := metrics.GetJSONRPCMetrics(*agentConfig, t, 1, 0, duration)
+			if computeUnits > 0 {
+				agentMetrics = append(agentMetrics, metrics.CreateAgentMetric(
+					agentConfig.ID, metrics.MetricJSONRPCComputeUnits, float64(computeUnits),
+				))
+			}
+			agentMetrics = append(agentMetrics, metrics.GetJSONRPCErrorMetrics(agentConfig.ID, errorClasses)...)
 			p.msgClient.PublishProto(messaging.SubjectMetricAgent, &protocol.AgentMetricList{
-				Metrics: metrics.GetJSONRPCMetrics(*agentConfig, t, 1, 0, duration),
+				Metrics: agentMetrics,
 			})
 		}
 	})
 }
 
+// sendCircuitMetric sends the circuit state transitions of the providers as node metrics.
+func (p *JsonRpcProxy) sendCircuitMetric(provider *provider, state string) {
+	log.WithFields(log.Fields{
+		"provider": provider.name,
+		"state":    state,
+	}).Info("json-rpc provider circuit state changed")
+	metrics.SendAgentMetrics(p.msgClient, []*protocol.AgentMetric{
+		metrics.CreateAgentMetric(config.DockerJSONRPCProxyContainerName, metrics.MetricJSONRPCCircuit+"."+state, 1),
+	})
+}
+
 func (p *JsonRpcProxy) findAgentFromRemoteAddr(hostPort string) (*config.AgentConfig, bool) {
 	containers, err := p.dockerClient.GetContainers(p.ctx)
 	if err != nil {
@@ -130,6 +220,14 @@ func (p *JsonRpcProxy) findAgentFromRemoteAddr(hostPort string) (*config.AgentCo
 			return &agentConfig, true
 		}
 	}
+	// the replicas of a bot have different container names
+	if botID := agentContainer.Labels[clients.DockerLabelFortaBotID]; len(botID) > 0 {
+		for _, agentConfig := range p.agentConfigs {
+			if strings.EqualFold(agentConfig.ID, botID) {
+				return &agentConfig, true
+			}
+		}
+	}
 
 	log.WithFields(log.Fields{
 		"agentIpAddr":   ipAddr,
@@ -146,6 +244,9 @@ func (p *JsonRpcProxy) handleAgentVersionsUpdate(payload messaging.AgentPayload)
 }
 
 func (p *JsonRpcProxy) Stop() error {
+	if err := p.usage.Save(); err != nil {
+		log.WithError(err).Warn("failed to save the rpc usage")
+	}
 	if p.server != nil {
 		return p.server.Close()
 	}
@@ -158,9 +259,29 @@ func (p *JsonRpcProxy) Name() string {
 
 // Health implements health.Reporter interface.
 func (p *JsonRpcProxy) Health() health.Reports {
-	return health.Reports{
+	reports := append(health.Reports{
 		p.lastErr.GetReport("api"),
+	}, p.providers.Health()...)
+	reports = append(reports, p.usage.Health()...)
+	if p.archive != nil {
+		reports = append(reports, p.archive.Health()...)
+	}
+	if p.responseCache != nil {
+		reports = append(reports, p.responseCache.Health()...)
+	}
+	if p.fixtures != nil {
+		reports = append(reports, p.fixtures.Health()...)
+	}
+	if p.blockPinner != nil {
+		reports = append(reports, p.blockPinner.Health()...)
 	}
+	if p.graphQL != nil {
+		reports = append(reports, p.graphQL.Health()...)
+	}
+	if reporter, ok := p.msgClient.(health.Reporter); ok {
+		reports = append(reports, reporter.Health()...)
+	}
+	return reports
 }
 
 func (p *JsonRpcProxy) apiHealthChecker() {
@@ -172,38 +293,166 @@ func (p *JsonRpcProxy) apiHealthChecker() {
 }
 
 func (p *JsonRpcProxy) testAPI() {
-	err := ethereum.TestAPI(p.ctx, "http://localhost:8545")
-	p.lastErr.Set(err)
+	if p.testTransport == nil {
+		p.lastErr.Set(ethereum.TestAPI(p.ctx, "http://localhost:8545"))
+		return
+	}
+	rpcClient, err := rpc.DialHTTPWithClient("https://localhost:8545", &http.Client{Transport: p.testTransport})
+	if err != nil {
+		p.lastErr.Set(fmt.Errorf("failed to dial: %v", err))
+		return
+	}
+	defer rpcClient.Close()
+	if _, err := ethclient.NewClient(rpcClient).BlockNumber(p.ctx); err != nil {
+		p.lastErr.Set(fmt.Errorf("failed to get latest block number: %v", err))
+		return
+	}
+	p.lastErr.Set(nil)
 }
 
 func (p *JsonRpcProxy) registerMessageHandlers() {
 	p.msgClient.Subscribe(messaging.SubjectAgentsVersionsLatest, messaging.AgentsHandler(p.handleAgentVersionsUpdate))
+	p.msgClient.Respond(messaging.SubjectJSONRPCProxyReadyRequest, messaging.ReadinessHandler(p.handleReadyRequest))
+}
+
+// handleReadyRequest tells the scanner if the proxy is listening and can reach a provider.
+func (p *JsonRpcProxy) handleReadyRequest() (*messaging.Readiness, error) {
+	conn, err := net.DialTimeout("tcp", "localhost:8545", time.Second)
+	if err != nil {
+		return &messaging.Readiness{Reason: "not listening yet"}, nil
+	}
+	conn.Close()
+	if !p.providers.hasHealthy() {
+		return &messaging.Readiness{Reason: "all providers are ejected"}, nil
+	}
+	return &messaging.Readiness{Ready: true}, nil
+}
+
+// ReloadConfig implements services.ConfigReloader.
+func (p *JsonRpcProxy) ReloadConfig(cfg config.Config, report *config.ReloadReport) {
+	rateLimiting := cfg.JsonRpcProxy.RateLimitConfig
+	if rateLimiting == nil {
+		rateLimiting = (*config.RateLimitConfig)(settings.GetChainSettings(cfg.ChainID).JsonRpcRateLimiting)
+	}
+	p.rateLimiter.SetLimits(rateLimiting.Rate, rateLimiting.Burst)
 }
 
 func NewJsonRpcProxy(ctx context.Context, cfg config.Config) (*JsonRpcProxy, error) {
-	jCfg := cfg.Scan.JsonRpc
-	if len(cfg.JsonRpcProxy.JsonRpc.Url) > 0 {
-		jCfg = cfg.JsonRpcProxy.JsonRpc
+	providerCfgs := cfg.JsonRpcProxy.Providers
+	if len(providerCfgs) == 0 {
+		jCfg := cfg.Scan.JsonRpc
+		if len(cfg.JsonRpcProxy.JsonRpc.Url) > 0 {
+			jCfg = cfg.JsonRpcProxy.JsonRpc
+		}
+		providerCfgs = []config.JsonRpcConfig{jCfg}
+	}
+	providers, err := newProviderPool(providerCfgs, cfg.JsonRpcProxy.ProviderHealth)
+	if err != nil {
+		return nil, err
 	}
 	globalClient, err := clients.NewDockerClient("")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create the global docker client: %v", err)
 	}
 	msgClient := messaging.NewClient("json-rpc-proxy", fmt.Sprintf("%s:%s", config.DockerNatsContainerName, config.DefaultNatsPort))
+	msgClient.SetTracing(cfg.Messaging.Tracing)
 
 	rateLimiting := cfg.JsonRpcProxy.RateLimitConfig
 	if rateLimiting == nil {
 		rateLimiting = (*config.RateLimitConfig)(settings.GetChainSettings(cfg.ChainID).JsonRpcRateLimiting)
 	}
 
-	return &JsonRpcProxy{
+	// only the agents and the node containers which have a client certificate can access the proxy
+	var (
+		tlsConfig     *tls.Config
+		testTransport *http.Transport
+	)
+	if cfg.AgentTLS.Enable {
+		tlsConfig, err = tlsutils.ServerConfig(tlsutils.AllowPeers(
+			config.AgentContainerNamePrefix+"*",
+			config.DockerScannerContainerName,
+			config.DockerInspectorContainerName,
+			config.DockerJSONRPCProxyContainerName,
+		))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the agent tls config: %v", err)
+		}
+		testTransport, err = tlsutils.ClientTransport(tlsutils.AllowPeers(config.DockerJSONRPCProxyContainerName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the api test transport: %v", err)
+		}
+	}
+
+	var (
+		archive  *archiveRouter
+		upstream http.Handler = providers
+	)
+	if len(cfg.JsonRpcProxy.Archive.JsonRpc.Url) > 0 {
+		archive, err = newArchiveRouter(providers, cfg.JsonRpcProxy.Archive, cfg.JsonRpcProxy.ProviderHealth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the archive router: %v", err)
+		}
+		upstream = archive
+	}
+
+	var respCache *responseCache
+	if !cfg.JsonRpcProxy.ResponseCache.Disable {
+		respCache, err = newResponseCache(
+			upstream, cfg.JsonRpcProxy.ResponseCache, cfg.Scan.ConfirmationDepth,
+			time.Duration(cfg.JsonRpcProxy.ProviderHealth.IntervalSeconds)*time.Second,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the response cache: %v", err)
+		}
+	}
+
+	var pinner *blockPinner
+	if !cfg.JsonRpcProxy.DisableBlockPinning {
+		pinner = newBlockPinner(nil)
+	}
+
+	var fixtures *fixtureServer
+	if fixturesDir := cfg.JsonRpcProxy.FixturesDir; len(fixturesDir) > 0 {
+		if !cfg.LocalModeConfig.Enable {
+			return nil, fmt.Errorf("jsonRpcProxy.fixturesDir can only be used in local mode")
+		}
+		loaded, err := loadFixtures(path.Join(cfg.FortaDir, fixturesDir))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the fixtures: %v", err)
+		}
+		log.WithField("fixtures", len(loaded)).Warn("serving synthetic responses from the fixtures")
+		fixtures = newFixtureServer(nil, loaded)
+	}
+
+	var graphQL *graphQLServer
+	if cfg.JsonRpcProxy.GraphQL.Enable {
+		graphQL, err = newGraphQLServer(nil, cfg.JsonRpcProxy.GraphQL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the graphql server: %v", err)
+		}
+	}
+
+	proxy := &JsonRpcProxy{
 		ctx:          ctx,
-		cfg:          jCfg,
+		providers:    providers,
 		dockerClient: globalClient,
 		msgClient:    msgClient,
 		rateLimiter: NewRateLimiter(
 			rateLimiting.Rate,
 			rateLimiting.Burst,
 		),
-	}, nil
+		usage:            newUsageTracker(path.Join(cfg.FortaDir, config.DefaultRPCUsageFileName), cfg.JsonRpcProxy.Usage),
+		archive:          archive,
+		responseCache:    respCache,
+		fixtures:         fixtures,
+		blockPinner:      pinner,
+		graphQL:          graphQL,
+		maxBatchSize:     cfg.JsonRpcProxy.MaxBatchSize,
+		batchConcurrency: cfg.JsonRpcProxy.BatchConcurrency,
+		normalizeErrors:  !cfg.JsonRpcProxy.DisableErrorNormalization,
+		tlsConfig:        tlsConfig,
+		testTransport:    testTransport,
+	}
+	providers.OnCircuitChange(proxy.sendCircuitMetric)
+	return proxy, nil
 }
@@ -0,0 +1,235 @@
+package json_rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrorClassHeader contains the classes of the upstream errors in the response, separated by commas.
+const ErrorClassHeader = "X-Forta-Error-Class"
+
+// the max length of the non-JSON upstream responses which are used as the error messages
+const maxErrorMessageLength = 256
+
+// upstream error classes
+const (
+	errorClassRateLimited    = "rate-limited"
+	errorClassComputeUnits   = "cu-exceeded"
+	errorClassMethodNotFound = "method-not-found"
+	errorClassReverted       = "execution-reverted"
+	errorClassInvalidParams  = "invalid-params"
+	errorClassUpstream       = "upstream"
+	errorClassOther          = "other"
+)
+
+// the consistent codes of the error classes
+var errorClassCodes = map[string]int{
+	errorClassRateLimited:    -32005,
+	errorClassComputeUnits:   -32005,
+	errorClassMethodNotFound: -32601,
+	errorClassReverted:       3,
+	errorClassInvalidParams:  -32602,
+	errorClassUpstream:       -32603,
+}
+
+// the message patterns of the providers, checked in order
+var errorClassPatterns = []struct {
+	class    string
+	patterns []string
+}{
+	{errorClassComputeUnits, []string{"compute unit", "capacity exceeded", "daily request count", "quota"}},
+	{errorClassRateLimited, []string{"rate limit", "too many requests", "request limit"}},
+	{errorClassMethodNotFound, []string{"method not found", "does not exist/is not available", "not supported", "unsupported method"}},
+	{errorClassReverted, []string{"execution reverted", "reverted"}},
+	{errorClassInvalidParams, []string{"invalid argument", "invalid params"}},
+}
+
+type errorClassesKey struct{}
+
+type rpcErrorItem struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   *rpcErrorBody   `json:"error"`
+}
+
+type rpcErrorBody struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// classifyError returns the class of the upstream error by the code and the message.
+func classifyError(code int, message string) string {
+	message = strings.ToLower(message)
+	for _, classPatterns := range errorClassPatterns {
+		for _, pattern := range classPatterns.patterns {
+			if strings.Contains(message, pattern) {
+				return classPatterns.class
+			}
+		}
+	}
+	switch code {
+	case -32005, -32029:
+		return errorClassRateLimited
+	case -32601:
+		return errorClassMethodNotFound
+	case 3:
+		return errorClassReverted
+	case -32602:
+		return errorClassInvalidParams
+	}
+	return errorClassOther
+}
+
+// errorNormalizer converts the different errors of the upstream providers to consistent JSON-RPC
+// error codes and adds the error classes to the response header, so that the bots can handle
+// the errors the same way regardless of the provider.
+type errorNormalizer struct {
+	next http.Handler
+}
+
+func newErrorNormalizer(next http.Handler) *errorNormalizer {
+	return &errorNormalizer{next: next}
+}
+
+func (en *errorNormalizer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Body == nil || req.Method != http.MethodPost {
+		en.next.ServeHTTP(w, req)
+		return
+	}
+	reqBody, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		log.WithError(err).Error("failed to read jsonrpc request body")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	req.ContentLength = int64(len(reqBody))
+	// the responses are decoded here so they should not be compressed
+	req.Header.Del("Accept-Encoding")
+
+	respBuf := newResponseBuffer()
+	en.next.ServeHTTP(respBuf, req)
+
+	body, classes := normalizeResponse(reqBody, respBuf.code, respBuf.body.Bytes())
+	if counts, ok := req.Context().Value(errorClassesKey{}).(map[string]int); ok {
+		for _, class := range classes {
+			counts[class]++
+		}
+	}
+
+	for k, v := range respBuf.header {
+		w.Header()[k] = v
+	}
+	if len(classes) > 0 {
+		w.Header().Set(ErrorClassHeader, strings.Join(uniqueClasses(classes), ","))
+		w.Header().Del("Content-Length")
+	}
+	w.WriteHeader(respBuf.code)
+	if _, err := w.Write(body); err != nil {
+		log.WithError(err).Debug("failed to write jsonrpc response")
+	}
+}
+
+// normalizeResponse rewrites the error codes in the response and returns the class of each error.
+// The failed responses which are not JSON-RPC responses are replaced with error responses.
+func normalizeResponse(reqBody []byte, status int, body []byte) ([]byte, []string) {
+	if isBatch(body) {
+		var items []json.RawMessage
+		if err := json.Unmarshal(body, &items); err == nil {
+			var classes []string
+			for i, item := range items {
+				normalized, class := normalizeItem(item)
+				if len(class) > 0 {
+					items[i] = normalized
+					classes = append(classes, class)
+				}
+			}
+			if len(classes) == 0 {
+				return body, nil
+			}
+			b, _ := json.Marshal(items)
+			return b, classes
+		}
+	} else if normalized, class := normalizeItem(body); len(class) > 0 {
+		return normalized, []string{class}
+	}
+
+	if status < http.StatusBadRequest {
+		return body, nil
+	}
+	class := errorClassUpstream
+	if status == http.StatusTooManyRequests {
+		class = errorClassRateLimited
+	}
+	message := strings.TrimSpace(string(body))
+	if len(message) > maxErrorMessageLength {
+		message = message[:maxErrorMessageLength]
+	}
+	if len(message) == 0 {
+		message = http.StatusText(status)
+	}
+	return makeRequestErrResponse(reqBody, class, message)
+}
+
+// normalizeItem returns the item with the consistent error code and the error class, if the item
+// is an error response.
+func normalizeItem(b []byte) ([]byte, string) {
+	var item rpcErrorItem
+	if err := json.Unmarshal(b, &item); err != nil || item.Error == nil {
+		return b, ""
+	}
+	class := classifyError(item.Error.Code, item.Error.Message)
+	code, ok := errorClassCodes[class]
+	if !ok || code == item.Error.Code {
+		return b, class
+	}
+	item.Error.Code = code
+	normalized, err := json.Marshal(&item)
+	if err != nil {
+		return b, class
+	}
+	return normalized, class
+}
+
+// makeRequestErrResponse makes an error response for each of the requests in the body.
+func makeRequestErrResponse(reqBody []byte, class, message string) ([]byte, []string) {
+	code := errorClassCodes[class]
+	if isBatch(reqBody) {
+		var reqItems []batchItem
+		_ = json.Unmarshal(reqBody, &reqItems)
+		var (
+			responses []json.RawMessage
+			classes   []string
+		)
+		for _, reqItem := range reqItems {
+			responses = append(responses, makeErrResponse(reqItem.ID, code, message))
+			classes = append(classes, class)
+		}
+		b, _ := json.Marshal(responses)
+		return b, classes
+	}
+	var reqItem batchItem
+	_ = json.Unmarshal(reqBody, &reqItem)
+	return makeErrResponse(reqItem.ID, code, message), []string{class}
+}
+
+func uniqueClasses(classes []string) []string {
+	found := make(map[string]bool)
+	var unique []string
+	for _, class := range classes {
+		if !found[class] {
+			found[class] = true
+			unique = append(unique, class)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
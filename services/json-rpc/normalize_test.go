@@ -0,0 +1,118 @@
+package json_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal(errorClassComputeUnits, classifyError(429, "Your app has exceeded its compute units per second capacity"))
+	r.Equal(errorClassRateLimited, classifyError(-32000, "Too Many Requests"))
+	r.Equal(errorClassRateLimited, classifyError(-32005, "slow down"))
+	r.Equal(errorClassMethodNotFound, classifyError(-32000, "The method trace_block does not exist/is not available"))
+	r.Equal(errorClassReverted, classifyError(-32000, "execution reverted: not owner"))
+	r.Equal(errorClassInvalidParams, classifyError(-32602, "bad block number"))
+	r.Equal(errorClassOther, classifyError(-32000, "header not found"))
+}
+
+func testNormalizedRequest(upstream http.HandlerFunc, body string) (*httptest.ResponseRecorder, map[string]int) {
+	errorClasses := make(map[string]int)
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:8545", bytes.NewBufferString(body))
+	req = req.WithContext(context.WithValue(req.Context(), errorClassesKey{}, errorClasses))
+	req.Header.Set("Accept-Encoding", "gzip")
+	recorder := httptest.NewRecorder()
+	newErrorNormalizer(upstream).ServeHTTP(recorder, req)
+	return recorder, errorClasses
+}
+
+func TestErrorNormalizer(t *testing.T) {
+	r := require.New(t)
+
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.Empty(req.Header.Get("Accept-Encoding"))
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"execution reverted","data":"0x01"}}`))
+	})
+	recorder, errorClasses := testNormalizedRequest(upstream, `{"jsonrpc":"2.0","id":1,"method":"eth_call"}`)
+
+	r.Equal(http.StatusOK, recorder.Code)
+	r.Equal(errorClassReverted, recorder.Header().Get(ErrorClassHeader))
+	var resp rpcErrorItem
+	r.NoError(json.NewDecoder(recorder.Body).Decode(&resp))
+	r.Equal(3, resp.Error.Code)
+	r.Equal("execution reverted", resp.Error.Message)
+	r.Equal(`"0x01"`, string(resp.Error.Data))
+	r.Equal(map[string]int{errorClassReverted: 1}, errorClasses)
+}
+
+func TestErrorNormalizer_Batch(t *testing.T) {
+	r := require.New(t)
+
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`[
+			{"jsonrpc":"2.0","id":1,"result":"0x1"},
+			{"jsonrpc":"2.0","id":2,"error":{"code":-32000,"message":"rate limit exceeded"}},
+			{"jsonrpc":"2.0","id":3,"error":{"code":-32601,"message":"the method is not available"}},
+			{"jsonrpc":"2.0","id":4,"error":{"code":429,"message":"too many requests"}}
+		]`))
+	})
+	recorder, errorClasses := testNormalizedRequest(upstream, `[{"id":1},{"id":2},{"id":3},{"id":4}]`)
+
+	r.Equal("method-not-found,rate-limited", recorder.Header().Get(ErrorClassHeader))
+	var resps []rpcErrorItem
+	r.NoError(json.NewDecoder(recorder.Body).Decode(&resps))
+	r.Len(resps, 4)
+	r.Nil(resps[0].Error)
+	r.Equal(-32005, resps[1].Error.Code)
+	r.Equal(-32601, resps[2].Error.Code)
+	r.Equal(-32005, resps[3].Error.Code)
+	r.Equal(map[string]int{errorClassRateLimited: 2, errorClassMethodNotFound: 1}, errorClasses)
+}
+
+func TestErrorNormalizer_NonJSON(t *testing.T) {
+	r := require.New(t)
+
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte("slow down"))
+	})
+	recorder, errorClasses := testNormalizedRequest(upstream, `{"jsonrpc":"2.0","id":7,"method":"eth_blockNumber"}`)
+
+	r.Equal(http.StatusTooManyRequests, recorder.Code)
+	r.Equal(errorClassRateLimited, recorder.Header().Get(ErrorClassHeader))
+	var resp rpcErrorItem
+	r.NoError(json.NewDecoder(recorder.Body).Decode(&resp))
+	r.Equal("7", string(resp.ID))
+	r.Equal(-32005, resp.Error.Code)
+	r.Equal("slow down", resp.Error.Message)
+	r.Equal(map[string]int{errorClassRateLimited: 1}, errorClasses)
+
+	upstream = func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	recorder, _ = testNormalizedRequest(upstream, `{"jsonrpc":"2.0","id":7,"method":"eth_blockNumber"}`)
+	r.Equal(errorClassUpstream, recorder.Header().Get(ErrorClassHeader))
+	r.NoError(json.NewDecoder(recorder.Body).Decode(&resp))
+	r.Equal(-32603, resp.Error.Code)
+	r.Equal("Bad Gateway", resp.Error.Message)
+}
+
+func TestErrorNormalizer_Success(t *testing.T) {
+	r := require.New(t)
+
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
+	})
+	recorder, errorClasses := testNormalizedRequest(upstream, `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}`)
+
+	r.Empty(recorder.Header().Get(ErrorClassHeader))
+	r.Equal(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`, recorder.Body.String())
+	r.Empty(errorClasses)
+}
@@ -0,0 +1,242 @@
+package json_rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/forta-network/forta-core-go/clients/health"
+	log "github.com/sirupsen/logrus"
+)
+
+// BlockNumberHeader pins the requests of a bot to a block, so that all of its calls during the
+// evaluation of the block read the same state. The value is a hex or a decimal block number.
+const BlockNumberHeader = "X-Forta-Block-Number"
+
+// the query param which can be used instead of the header, for the bots which can not set headers
+const blockNumberQueryParam = "blockNumber"
+
+// the methods which are pinned and the index of their block param
+var pinnedMethodBlockParams = map[string]int{
+	"eth_getBlockByNumber": 0,
+	"trace_block":          0,
+}
+
+func init() {
+	for method, index := range stateMethodBlockParams {
+		pinnedMethodBlockParams[method] = index
+	}
+}
+
+// blockPinner answers the requests of the bots which are pinned to a block from that block instead
+// of the moving head. The "latest" and the "pending" block params and the missing ones are replaced
+// with the pinned block and eth_blockNumber returns it, so the state reads have explicit block numbers
+// and the response cache can serve them.
+type blockPinner struct {
+	next http.Handler
+
+	pinned    uint64 // accessed atomically
+	rewritten uint64 // accessed atomically
+}
+
+func newBlockPinner(next http.Handler) *blockPinner {
+	return &blockPinner{next: next}
+}
+
+func (bp *blockPinner) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	blockNumber, ok, err := pinnedBlockNumber(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !ok || req.Body == nil || req.Method != http.MethodPost {
+		bp.next.ServeHTTP(w, req)
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		log.WithError(err).Error("failed to read jsonrpc request body")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	atomic.AddUint64(&bp.pinned, 1)
+	blockTag, _ := json.Marshal(hexutil.EncodeUint64(blockNumber))
+
+	if !isBatch(body) {
+		var rpcReq rpcRequest
+		if json.Unmarshal(body, &rpcReq) == nil && rpcReq.Method == "eth_blockNumber" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(makeResultResponse(rpcReq.ID, blockTag))
+			return
+		}
+		setRequestBody(req, bp.pinRequest(body, blockTag))
+		bp.next.ServeHTTP(w, req)
+		return
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil || len(batch) == 0 {
+		setRequestBody(req, body)
+		bp.next.ServeHTTP(w, req)
+		return
+	}
+	var (
+		upstreamBatch []json.RawMessage
+		local         []json.RawMessage
+	)
+	for _, item := range batch {
+		var rpcReq rpcRequest
+		if json.Unmarshal(item, &rpcReq) == nil && rpcReq.Method == "eth_blockNumber" {
+			if len(rpcReq.ID) > 0 {
+				local = append(local, makeResultResponse(rpcReq.ID, blockTag))
+			}
+			continue
+		}
+		upstreamBatch = append(upstreamBatch, bp.pinRequest(item, blockTag))
+	}
+	if len(upstreamBatch) == 0 {
+		writeBatchResponse(w, local)
+		return
+	}
+	upstreamBody, err := json.Marshal(upstreamBatch)
+	if err != nil {
+		log.WithError(err).Error("failed to encode the pinned jsonrpc batch")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	setRequestBody(req, upstreamBody)
+	if len(local) == 0 {
+		bp.next.ServeHTTP(w, req)
+		return
+	}
+
+	// the response is decoded here so it should not be compressed
+	req.Header.Del("Accept-Encoding")
+	respBuf := newResponseBuffer()
+	bp.next.ServeHTTP(respBuf, req)
+	var responses []json.RawMessage
+	if err := json.Unmarshal(respBuf.body.Bytes(), &responses); err != nil {
+		writeResponseBuffer(w, respBuf)
+		return
+	}
+	writeBatchResponse(w, append(responses, local...))
+}
+
+// pinRequest replaces the moving block param of the request with the pinned block.
+func (bp *blockPinner) pinRequest(item json.RawMessage, blockTag json.RawMessage) json.RawMessage {
+	var rpcReq rpcRequest
+	if json.Unmarshal(item, &rpcReq) != nil {
+		return item
+	}
+	index, ok := pinnedMethodBlockParams[rpcReq.Method]
+	if !ok {
+		return item
+	}
+	params := rpcReq.Params
+	switch {
+	case len(params) == index:
+		params = append(params, blockTag)
+	case len(params) > index && isMovingBlockParam(params[index]):
+		params[index] = blockTag
+	default:
+		return item
+	}
+
+	// the other fields of the request are kept as they are
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(item, &fields) != nil {
+		return item
+	}
+	fields["params"], _ = json.Marshal(params)
+	pinned, err := json.Marshal(fields)
+	if err != nil {
+		return item
+	}
+	atomic.AddUint64(&bp.rewritten, 1)
+	return pinned
+}
+
+// pinnedBlockNumber reads the pinned block from the request and removes it, so that it does not reach
+// the upstream providers.
+func pinnedBlockNumber(req *http.Request) (uint64, bool, error) {
+	value := req.Header.Get(BlockNumberHeader)
+	req.Header.Del(BlockNumberHeader)
+	if query := req.URL.Query(); query.Has(blockNumberQueryParam) {
+		if len(value) == 0 {
+			value = query.Get(blockNumberQueryParam)
+		}
+		query.Del(blockNumberQueryParam)
+		req.URL.RawQuery = query.Encode()
+	}
+	if len(value) == 0 {
+		return 0, false, nil
+	}
+
+	var (
+		blockNumber uint64
+		err         error
+	)
+	if strings.HasPrefix(value, "0x") {
+		blockNumber, err = hexutil.DecodeUint64(value)
+	} else {
+		blockNumber, err = strconv.ParseUint(value, 10, 64)
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid pinned block number '%s': %v", value, err)
+	}
+	return blockNumber, true, nil
+}
+
+// isMovingBlockParam tells if the param is a block tag which follows the head of the chain.
+func isMovingBlockParam(param json.RawMessage) bool {
+	var blockTag string
+	if err := json.Unmarshal(param, &blockTag); err != nil {
+		return false
+	}
+	return blockTag == "latest" || blockTag == "pending"
+}
+
+func makeResultResponse(id, result json.RawMessage) json.RawMessage {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	b, _ := json.Marshal(&struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  json.RawMessage `json:"result"`
+	}{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  result,
+	})
+	return b
+}
+
+func writeBatchResponse(w http.ResponseWriter, responses []json.RawMessage) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(responses); err != nil {
+		log.WithError(err).Error("failed to write jsonrpc batch response body")
+	}
+}
+
+// Health implements health.Reporter interface.
+func (bp *blockPinner) Health() health.Reports {
+	return health.Reports{
+		{
+			Name:    "block-pinning.requests",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&bp.pinned), 10),
+		},
+		{
+			Name:    "block-pinning.rewritten",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&bp.rewritten), 10),
+		},
+	}
+}
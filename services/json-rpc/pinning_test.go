@@ -0,0 +1,99 @@
+package json_rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPinRequest(t *testing.T) {
+	r := require.New(t)
+
+	bp := newBlockPinner(nil)
+	blockTag := json.RawMessage(`"0x10"`)
+	for _, testCase := range []struct {
+		body   string
+		pinned string
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xabcd","latest"]}`, `["0xabcd","0x10"]`},
+		{`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xabcd"]}`, `["0xabcd","0x10"]`},
+		{`{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{"to":"0xabcd"},"pending"]}`, `[{"to":"0xabcd"},"0x10"]`},
+		{`{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["latest",false]}`, `["0x10",false]`},
+		{`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xabcd","0x5"]}`, `["0xabcd","0x5"]`},
+		{`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xabcd","finalized"]}`, `["0xabcd","finalized"]`},
+		{`{"jsonrpc":"2.0","id":1,"method":"eth_getTransactionReceipt","params":["0xabcd"]}`, `["0xabcd"]`},
+	} {
+		var pinned struct {
+			JSONRPC string          `json:"jsonrpc"`
+			Params  json.RawMessage `json:"params"`
+		}
+		r.NoError(json.Unmarshal(bp.pinRequest(json.RawMessage(testCase.body), blockTag), &pinned))
+		r.Equal("2.0", pinned.JSONRPC, testCase.body)
+		r.JSONEq(testCase.pinned, string(pinned.Params), testCase.body)
+	}
+}
+
+func TestBlockPinner(t *testing.T) {
+	r := require.New(t)
+
+	var upstreamReq *http.Request
+	var upstreamBody string
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		upstreamReq = req
+		body, _ := io.ReadAll(req.Body)
+		upstreamBody = string(body)
+		if isBatch(body) {
+			w.Write([]byte(`[{"jsonrpc":"2.0","id":2,"result":"0x1"}]`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
+	})
+	bp := newBlockPinner(upstream)
+
+	doRequest := func(target, blockNumber, body string) *httptest.ResponseRecorder {
+		upstreamReq = nil
+		req := httptest.NewRequest(http.MethodPost, target, bytes.NewBufferString(body))
+		if len(blockNumber) > 0 {
+			req.Header.Set(BlockNumberHeader, blockNumber)
+		}
+		recorder := httptest.NewRecorder()
+		bp.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	// the requests without a pinned block are not changed
+	body := `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0xabcd","latest"]}`
+	doRequest("http://localhost:8545", "", body)
+	r.Equal(body, upstreamBody)
+
+	// the pinned block is sent in hex or decimal and does not reach upstream
+	doRequest("http://localhost:8545", "16", body)
+	r.Contains(upstreamBody, `"0x10"`)
+	r.Empty(upstreamReq.Header.Get(BlockNumberHeader))
+	doRequest("http://localhost:8545?blockNumber=0x20", "", body)
+	r.Contains(upstreamBody, `"0x20"`)
+	r.Empty(upstreamReq.URL.Query().Get(blockNumberQueryParam))
+
+	// the block number is answered from the pinned block
+	recorder := doRequest("http://localhost:8545", "0x10", `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`)
+	r.Nil(upstreamReq)
+	r.JSONEq(`{"jsonrpc":"2.0","id":1,"result":"0x10"}`, recorder.Body.String())
+
+	// the batch responses include the pinned block number
+	recorder = doRequest("http://localhost:8545", "0x10", `[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"},{"jsonrpc":"2.0","id":2,"method":"eth_getCode","params":["0xabcd","latest"]}]`)
+	r.JSONEq(`[{"jsonrpc":"2.0","id":2,"method":"eth_getCode","params":["0xabcd","0x10"]}]`, upstreamBody)
+	r.JSONEq(`[{"jsonrpc":"2.0","id":2,"result":"0x1"},{"jsonrpc":"2.0","id":1,"result":"0x10"}]`, recorder.Body.String())
+
+	recorder = doRequest("http://localhost:8545", "abcd", body)
+	r.Equal(http.StatusBadRequest, recorder.Code)
+	r.Nil(upstreamReq)
+
+	health := bp.Health()
+	r.Equal("4", health[0].Details)
+	r.Equal("3", health[1].Details)
+}
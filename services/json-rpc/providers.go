@@ -0,0 +1,439 @@
+package json_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultProviderLatency is assumed until the latency of a provider is observed.
+	defaultProviderLatency = time.Millisecond * 100
+	// minProviderLatency limits the weight of the fastest providers so that the rest still
+	// receive some of the requests.
+	minProviderLatency = time.Millisecond * 10
+	// latencySmoothing is the weight of the latest observation in the average latency.
+	latencySmoothing = 0.3
+	// maxProviderAttempts is the max amount of providers to try for a request.
+	maxProviderAttempts = 2
+)
+
+// circuit states of the providers
+const (
+	circuitClosed   = "closed"
+	circuitOpen     = "open"
+	circuitHalfOpen = "half-open"
+)
+
+var errProvidersUnavailable = errors.New("all json-rpc providers are unavailable")
+
+var healthCheckBody = []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`)
+
+// provider is an upstream JSON-RPC API.
+type provider struct {
+	name    string
+	cfg     config.JsonRpcConfig
+	proxy   *httputil.ReverseProxy
+	latency time.Duration
+	// consecutive failures
+	failures int
+	ejected  bool
+	lastErr  error
+	// the circuit is half-open while a probe request is sent to the ejected provider
+	ejectedAt time.Time
+	probing   bool
+	// called with the new circuit state after the state changes
+	onCircuitChange func(p *provider, state string)
+	mu              sync.RWMutex
+}
+
+type proxyErrKey struct{}
+
+func newProvider(name string, cfg config.JsonRpcConfig) (*provider, error) {
+	rpcUrl, err := url.Parse(cfg.Url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid provider url: %v", err)
+	}
+	rp := httputil.NewSingleHostReverseProxy(rpcUrl)
+	d := rp.Director
+	rp.Director = func(r *http.Request) {
+		d(r)
+		r.Host = rpcUrl.Host
+		r.URL = rpcUrl
+		for h, v := range cfg.Headers {
+			r.Header.Set(h, v)
+		}
+	}
+	rp.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		if errPtr, ok := r.Context().Value(proxyErrKey{}).(*error); ok {
+			*errPtr = err
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	return &provider{
+		name:    name,
+		cfg:     cfg,
+		proxy:   rp,
+		latency: defaultProviderLatency,
+	}, nil
+}
+
+func (p *provider) observeSuccess(latency time.Duration) {
+	p.mu.Lock()
+	p.latency = time.Duration(latencySmoothing*float64(latency) + (1-latencySmoothing)*float64(p.latency))
+	p.failures = 0
+	wasEjected := p.ejected
+	p.ejected = false
+	p.probing = false
+	p.lastErr = nil
+	p.mu.Unlock()
+
+	if wasEjected {
+		log.WithField("provider", p.name).Info("the json-rpc provider is healthy again")
+		p.circuitChanged(circuitClosed)
+	}
+}
+
+func (p *provider) observeFailure(err error, ejectAfter int) {
+	// the provider urls can contain api keys
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+
+	p.mu.Lock()
+	p.failures++
+	p.lastErr = err
+	var opened bool
+	switch {
+	case !p.ejected && p.failures >= ejectAfter:
+		p.ejected = true
+		p.ejectedAt = time.Now()
+		opened = true
+		log.WithError(err).WithField("provider", p.name).Warn("ejected the failing json-rpc provider")
+	case p.probing:
+		// the probe failed so the next probe waits for another interval
+		p.probing = false
+		p.ejectedAt = time.Now()
+		opened = true
+	}
+	p.mu.Unlock()
+
+	if opened {
+		p.circuitChanged(circuitOpen)
+	}
+}
+
+// tryProbe makes the circuit half-open if the ejected provider was not probed in the interval,
+// so that one request can be sent to the provider as a probe.
+func (p *provider) tryProbe(interval time.Duration) bool {
+	p.mu.Lock()
+	if !p.ejected || p.probing || time.Since(p.ejectedAt) < interval {
+		p.mu.Unlock()
+		return false
+	}
+	p.probing = true
+	p.mu.Unlock()
+
+	p.circuitChanged(circuitHalfOpen)
+	return true
+}
+
+func (p *provider) circuitChanged(state string) {
+	if p.onCircuitChange != nil {
+		p.onCircuitChange(p, state)
+	}
+}
+
+func (p *provider) weight() float64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	latency := p.latency
+	if latency < minProviderLatency {
+		latency = minProviderLatency
+	}
+	return 1 / latency.Seconds()
+}
+
+func (p *provider) isEjected() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.ejected
+}
+
+// providerPool balances the requests between the providers by their latency and ejects the
+// providers which keep failing until they pass the health checks again. While all providers are
+// ejected, the circuit breaker rejects the requests right away instead of waiting for the timeouts
+// and lets one request through to an ejected provider as a probe in each probe interval.
+type providerPool struct {
+	providers []*provider
+	cfg       config.ProviderHealthCheckConfig
+	client    *http.Client
+	rand      *rand.Rand
+	randMu    sync.Mutex
+
+	rejected uint64 // accessed atomically
+}
+
+func newProviderPool(providerCfgs []config.JsonRpcConfig, cfg config.ProviderHealthCheckConfig) (*providerPool, error) {
+	if len(providerCfgs) == 0 {
+		return nil, fmt.Errorf("no json-rpc providers")
+	}
+	pool := &providerPool{
+		cfg:    cfg,
+		client: &http.Client{Timeout: time.Duration(cfg.TimeoutSeconds) * time.Second},
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for i, providerCfg := range providerCfgs {
+		provider, err := newProvider(fmt.Sprintf("provider-%d", i), providerCfg)
+		if err != nil {
+			return nil, err
+		}
+		pool.providers = append(pool.providers, provider)
+	}
+	return pool, nil
+}
+
+// OnCircuitChange sets the func which is called after the circuit state of a provider changes.
+func (pool *providerPool) OnCircuitChange(onCircuitChange func(p *provider, state string)) {
+	for _, p := range pool.providers {
+		p.onCircuitChange = onCircuitChange
+	}
+}
+
+// pick selects a provider randomly by weight, skipping the excluded and the ejected providers.
+// If all of the providers are ejected, the circuit breaker picks an ejected provider only to probe it.
+func (pool *providerPool) pick(exclude *provider) *provider {
+	var candidates []*provider
+	for _, p := range pool.providers {
+		if p != exclude && !p.isEjected() {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) == 0 && !pool.cfg.DisableCircuitBreaker {
+		probeInterval := time.Duration(pool.cfg.ProbeIntervalSeconds) * time.Second
+		for _, p := range pool.providers {
+			if p != exclude && p.tryProbe(probeInterval) {
+				return p
+			}
+		}
+		return nil
+	}
+	if len(candidates) == 0 {
+		for _, p := range pool.providers {
+			if p != exclude {
+				candidates = append(candidates, p)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	weights := make([]float64, len(candidates))
+	var total float64
+	for i, p := range candidates {
+		weights[i] = p.weight()
+		total += weights[i]
+	}
+	pool.randMu.Lock()
+	target := pool.rand.Float64() * total
+	pool.randMu.Unlock()
+	for i, w := range weights {
+		if target < w {
+			return candidates[i]
+		}
+		target -= w
+	}
+	return candidates[len(candidates)-1]
+}
+
+func (pool *providerPool) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			log.WithError(err).Error("failed to read jsonrpc request body")
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	var (
+		tried   *provider
+		respBuf *responseBuffer
+	)
+	for attempt := 0; attempt < maxProviderAttempts; attempt++ {
+		p := pool.pick(tried)
+		if p == nil {
+			break
+		}
+		tried = p
+
+		var proxyErr error
+		attemptReq := req.Clone(context.WithValue(req.Context(), proxyErrKey{}, &proxyErr))
+		attemptReq.Body = io.NopCloser(bytes.NewReader(body))
+		attemptReq.ContentLength = int64(len(body))
+
+		respBuf = newResponseBuffer()
+		start := time.Now()
+		p.proxy.ServeHTTP(respBuf, attemptReq)
+
+		if proxyErr == nil && respBuf.code < http.StatusInternalServerError {
+			p.observeSuccess(time.Since(start))
+			break
+		}
+		if proxyErr == nil {
+			proxyErr = fmt.Errorf("status code %d", respBuf.code)
+		}
+		p.observeFailure(proxyErr, pool.cfg.EjectAfterFailures)
+		if req.Context().Err() != nil {
+			break
+		}
+	}
+
+	// the circuit is open for all providers
+	if respBuf == nil {
+		atomic.AddUint64(&pool.rejected, 1)
+		http.Error(w, errProvidersUnavailable.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	for k, v := range respBuf.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(respBuf.code)
+	if _, err := w.Write(respBuf.body.Bytes()); err != nil {
+		log.WithError(err).Debug("failed to write jsonrpc response")
+	}
+}
+
+// hasHealthy tells if any of the providers is not ejected.
+func (pool *providerPool) hasHealthy() bool {
+	for _, p := range pool.providers {
+		if !p.isEjected() {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHealth checks all providers periodically.
+func (pool *providerPool) checkHealth(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(pool.cfg.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		var wg sync.WaitGroup
+		for _, p := range pool.providers {
+			wg.Add(1)
+			go func(p *provider) {
+				defer wg.Done()
+				pool.checkProvider(ctx, p)
+			}(p)
+		}
+		wg.Wait()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (pool *providerPool) checkProvider(ctx context.Context, p *provider) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.Url, bytes.NewReader(healthCheckBody))
+	if err != nil {
+		p.observeFailure(err, pool.cfg.EjectAfterFailures)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for h, v := range p.cfg.Headers {
+		req.Header.Set(h, v)
+	}
+
+	start := time.Now()
+	resp, err := pool.client.Do(req)
+	if err != nil {
+		p.observeFailure(err, pool.cfg.EjectAfterFailures)
+		return
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Result string        `json:"result"`
+		Error  *jsonRpcError `json:"error"`
+	}
+	switch err := json.NewDecoder(resp.Body).Decode(&result); {
+	case err != nil:
+		p.observeFailure(fmt.Errorf("failed to decode response (status %d): %v", resp.StatusCode, err), pool.cfg.EjectAfterFailures)
+	case result.Error != nil:
+		p.observeFailure(fmt.Errorf("error response: %s", result.Error.Message), pool.cfg.EjectAfterFailures)
+	case len(result.Result) == 0:
+		p.observeFailure(fmt.Errorf("empty result (status %d)", resp.StatusCode), pool.cfg.EjectAfterFailures)
+	default:
+		p.observeSuccess(time.Since(start))
+	}
+}
+
+// Health implements health.Reporter interface.
+func (pool *providerPool) Health() health.Reports {
+	var healthy int
+	reports := health.Reports{}
+	for _, p := range pool.providers {
+		p.mu.RLock()
+		status := health.StatusOK
+		details := fmt.Sprintf("latency=%dms", p.latency.Milliseconds())
+		if p.ejected {
+			status = health.StatusFailing
+			details += " ejected"
+			if p.probing {
+				details += " probing"
+			}
+		} else {
+			healthy++
+		}
+		if p.lastErr != nil {
+			details += fmt.Sprintf(" error=%v", p.lastErr)
+		}
+		p.mu.RUnlock()
+		reports = append(reports, &health.Report{
+			Name:    fmt.Sprintf("providers.%s", p.name),
+			Status:  status,
+			Details: details,
+		})
+	}
+	healthyStatus := health.StatusOK
+	if healthy == 0 {
+		healthyStatus = health.StatusFailing
+	}
+	return append(health.Reports{
+		&health.Report{
+			Name:    "providers.healthy",
+			Status:  healthyStatus,
+			Details: strconv.Itoa(healthy),
+		},
+		&health.Report{
+			Name:    "providers.circuit.rejected",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&pool.rejected), 10),
+		},
+	}, reports...)
+}
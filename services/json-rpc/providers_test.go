@@ -0,0 +1,184 @@
+package json_rpc
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+var testProviderHealthCfg = config.ProviderHealthCheckConfig{
+	IntervalSeconds:    1,
+	TimeoutSeconds:     1,
+	EjectAfterFailures: 2,
+}
+
+type testUpstream struct {
+	*httptest.Server
+	calls   int32
+	failing int32
+}
+
+func newTestUpstream(t *testing.T) *testUpstream {
+	upstream := &testUpstream{}
+	upstream.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&upstream.calls, 1)
+		if atomic.LoadInt32(&upstream.failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream
+}
+
+func testProviderRequest(pool *providerPool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"}`))
+	rec := httptest.NewRecorder()
+	pool.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestProviderPool_Failover(t *testing.T) {
+	r := require.New(t)
+
+	upstream1 := newTestUpstream(t)
+	upstream2 := newTestUpstream(t)
+	atomic.StoreInt32(&upstream1.failing, 1)
+
+	pool, err := newProviderPool([]config.JsonRpcConfig{{Url: upstream1.URL}, {Url: upstream2.URL}}, testProviderHealthCfg)
+	r.NoError(err)
+
+	// the requests do not fail while the failing provider is tried and ejected
+	for i := 0; i < 1000 && !pool.providers[0].isEjected(); i++ {
+		rec := testProviderRequest(pool)
+		r.Equal(http.StatusOK, rec.Code)
+		r.Contains(rec.Body.String(), `"result":"0x1"`)
+	}
+	r.True(pool.providers[0].isEjected())
+	r.False(pool.providers[1].isEjected())
+	r.Equal(int32(2), atomic.LoadInt32(&upstream1.calls))
+
+	// the ejected provider is not called again
+	for i := 0; i < 20; i++ {
+		r.Equal(http.StatusOK, testProviderRequest(pool).Code)
+	}
+	r.Equal(int32(2), atomic.LoadInt32(&upstream1.calls))
+
+	reports := pool.Health()
+	healthy, ok := reports.NameContains("providers.healthy")
+	r.True(ok)
+	r.Equal("1", healthy.Details)
+}
+
+func TestProviderPool_AllEjected(t *testing.T) {
+	r := require.New(t)
+
+	upstream := newTestUpstream(t)
+	atomic.StoreInt32(&upstream.failing, 1)
+
+	pool, err := newProviderPool([]config.JsonRpcConfig{{Url: upstream.URL}}, testProviderHealthCfg)
+	r.NoError(err)
+
+	for i := 0; i < 3; i++ {
+		r.Equal(http.StatusServiceUnavailable, testProviderRequest(pool).Code)
+	}
+	// still tries the only provider
+	r.Equal(int32(3), atomic.LoadInt32(&upstream.calls))
+	r.True(pool.providers[0].isEjected())
+}
+
+func TestProviderPool_CircuitBreaker(t *testing.T) {
+	r := require.New(t)
+
+	upstream := newTestUpstream(t)
+	atomic.StoreInt32(&upstream.failing, 1)
+
+	cfg := testProviderHealthCfg
+	cfg.ProbeIntervalSeconds = 60
+	pool, err := newProviderPool([]config.JsonRpcConfig{{Url: upstream.URL}}, cfg)
+	r.NoError(err)
+	var states []string
+	pool.OnCircuitChange(func(p *provider, state string) {
+		states = append(states, state)
+	})
+
+	for i := 0; i < 2; i++ {
+		r.Equal(http.StatusServiceUnavailable, testProviderRequest(pool).Code)
+	}
+	r.True(pool.providers[0].isEjected())
+	r.Equal([]string{circuitOpen}, states)
+
+	// fails right away without calling the provider
+	for i := 0; i < 5; i++ {
+		rec := testProviderRequest(pool)
+		r.Equal(http.StatusServiceUnavailable, rec.Code)
+		r.Contains(rec.Body.String(), errProvidersUnavailable.Error())
+	}
+	r.Equal(int32(2), atomic.LoadInt32(&upstream.calls))
+	rejected, ok := pool.Health().NameContains("providers.circuit.rejected")
+	r.True(ok)
+	r.Equal("5", rejected.Details)
+
+	// a failed probe opens the circuit again
+	pool.providers[0].ejectedAt = time.Now().Add(-time.Minute)
+	r.Equal(http.StatusServiceUnavailable, testProviderRequest(pool).Code)
+	r.Equal(int32(3), atomic.LoadInt32(&upstream.calls))
+	r.Equal([]string{circuitOpen, circuitHalfOpen, circuitOpen}, states)
+	testProviderRequest(pool)
+	r.Equal(int32(3), atomic.LoadInt32(&upstream.calls))
+
+	// a successful probe closes the circuit
+	atomic.StoreInt32(&upstream.failing, 0)
+	pool.providers[0].ejectedAt = time.Now().Add(-time.Minute)
+	r.Equal(http.StatusOK, testProviderRequest(pool).Code)
+	r.False(pool.providers[0].isEjected())
+	r.Equal([]string{circuitOpen, circuitHalfOpen, circuitOpen, circuitHalfOpen, circuitClosed}, states)
+}
+
+func TestProviderPool_HealthCheckRecovers(t *testing.T) {
+	r := require.New(t)
+
+	upstream := newTestUpstream(t)
+	atomic.StoreInt32(&upstream.failing, 1)
+
+	pool, err := newProviderPool([]config.JsonRpcConfig{{Url: upstream.URL}}, testProviderHealthCfg)
+	r.NoError(err)
+
+	pool.checkProvider(context.Background(), pool.providers[0])
+	pool.checkProvider(context.Background(), pool.providers[0])
+	r.True(pool.providers[0].isEjected())
+
+	atomic.StoreInt32(&upstream.failing, 0)
+	pool.checkProvider(context.Background(), pool.providers[0])
+	r.False(pool.providers[0].isEjected())
+}
+
+func TestProviderPool_LatencyWeighting(t *testing.T) {
+	r := require.New(t)
+
+	pool, err := newProviderPool([]config.JsonRpcConfig{{Url: "http://fast"}, {Url: "http://slow"}}, testProviderHealthCfg)
+	r.NoError(err)
+
+	fast, slow := pool.providers[0], pool.providers[1]
+	for i := 0; i < 20; i++ {
+		fast.observeSuccess(time.Millisecond * 10)
+		slow.observeSuccess(time.Millisecond * 1000)
+	}
+
+	var fastPicks int
+	for i := 0; i < 1000; i++ {
+		if pool.pick(nil) == fast {
+			fastPicks++
+		}
+	}
+	r.Greater(fastPicks, 900)
+	r.Equal(slow, pool.pick(fast))
+}
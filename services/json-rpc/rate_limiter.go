@@ -35,6 +35,22 @@ func NewRateLimiter(rateN float64, burst int) *RateLimiter {
 	return rl
 }
 
+// SetLimits updates the rate limits for all clients.
+func (rl *RateLimiter) SetLimits(rateN float64, burst int) {
+	if rateN <= 0 {
+		log.Warn("ignoring non-positive rate limiter arg")
+		return
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.rate = rateN
+	rl.burst = burst
+	for _, limiter := range rl.clientLimiters {
+		limiter.SetLimit(rate.Limit(rateN))
+		limiter.SetBurst(burst)
+	}
+}
+
 // ExceedsLimit tries adding a request to the limiting channel and returns boolean to signal
 // if we hit the rate limit.
 func (rl *RateLimiter) ExceedsLimit(clientID string) bool {
@@ -0,0 +1,243 @@
+package json_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMethodWeight = 1
+	usageSaveInterval   = time.Minute
+	// the method name which the weight of the queries proxied to the upstream GraphQL endpoint is configured with
+	graphQLMethod = "graphql"
+)
+
+// defaultMethodWeights are the compute units of the methods which are heavier than a simple read.
+var defaultMethodWeights = map[string]int{
+	"eth_call":                  5,
+	"eth_estimateGas":           5,
+	"eth_getLogs":               10,
+	"eth_getBlockByNumber":      2,
+	"eth_getBlockByHash":        2,
+	"eth_getTransactionReceipt": 2,
+	"debug_traceTransaction":    20,
+	"debug_traceCall":           20,
+	"trace_block":               20,
+	"trace_call":                20,
+	"trace_transaction":         20,
+	graphQLMethod:               10,
+}
+
+// BotUsage is the JSON-RPC usage of a bot in an hour.
+type BotUsage struct {
+	Hour         time.Time `json:"hour"`
+	BotID        string    `json:"botId"`
+	Requests     uint64    `json:"requests"`
+	ComputeUnits uint64    `json:"computeUnits"`
+}
+
+type usageKey struct {
+	hour  int64
+	botID string
+}
+
+// usageTracker accounts the requests and the compute units per bot per hour and persists
+// them to a file in the Forta dir.
+type usageTracker struct {
+	filePath  string
+	weights   map[string]int
+	retention time.Duration
+	usage     map[usageKey]*BotUsage
+	mu        sync.Mutex
+
+	lastSave health.ErrorTracker
+}
+
+func newUsageTracker(filePath string, cfg config.RPCUsageConfig) *usageTracker {
+	weights := make(map[string]int)
+	for method, weight := range defaultMethodWeights {
+		weights[method] = weight
+	}
+	for method, weight := range cfg.MethodWeights {
+		weights[method] = weight
+	}
+	ut := &usageTracker{
+		filePath:  filePath,
+		weights:   weights,
+		retention: time.Duration(cfg.RetentionHours) * time.Hour,
+		usage:     make(map[usageKey]*BotUsage),
+	}
+	list, err := LoadUsage(filePath)
+	if err != nil {
+		log.WithError(err).Warn("failed to load the rpc usage - starting from scratch")
+	}
+	for _, botUsage := range list {
+		ut.usage[usageKey{hour: botUsage.Hour.Unix(), botID: botUsage.BotID}] = botUsage
+	}
+	return ut
+}
+
+// LoadUsage loads the persisted usage. The usage is empty if the file does not exist.
+func LoadUsage(filePath string) ([]*BotUsage, error) {
+	b, err := ioutil.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*BotUsage
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode the rpc usage: %v", err)
+	}
+	return list, nil
+}
+
+// Track accounts the JSON-RPC request body of a bot and returns the compute units.
+func (ut *usageTracker) Track(botID string, body []byte) int {
+	requests, computeUnits := ut.computeUnits(body)
+	if requests == 0 {
+		return 0
+	}
+	ut.add(botID, requests, computeUnits)
+	return computeUnits
+}
+
+// TrackGraphQL accounts a query of a bot which is proxied to the upstream GraphQL endpoint and
+// returns the compute units. The translated queries are accounted per JSON-RPC request instead.
+func (ut *usageTracker) TrackGraphQL(botID string, body []byte) int {
+	computeUnits := ut.weights[graphQLMethod]
+	ut.add(botID, 1, computeUnits)
+	return computeUnits
+}
+
+func (ut *usageTracker) add(botID string, requests, computeUnits int) {
+	hour := time.Now().UTC().Truncate(time.Hour)
+
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+	key := usageKey{hour: hour.Unix(), botID: botID}
+	botUsage, ok := ut.usage[key]
+	if !ok {
+		botUsage = &BotUsage{Hour: hour, BotID: botID}
+		ut.usage[key] = botUsage
+	}
+	botUsage.Requests += uint64(requests)
+	botUsage.ComputeUnits += uint64(computeUnits)
+}
+
+// computeUnits calculates the compute units of a single or a batch request.
+func (ut *usageTracker) computeUnits(body []byte) (requests, computeUnits int) {
+	type jsonRpcMethod struct {
+		Method string `json:"method"`
+	}
+	var calls []*jsonRpcMethod
+	if isBatch(body) {
+		if err := json.Unmarshal(body, &calls); err != nil {
+			return 0, 0
+		}
+	} else {
+		var call jsonRpcMethod
+		if err := json.Unmarshal(body, &call); err != nil {
+			return 0, 0
+		}
+		calls = append(calls, &call)
+	}
+	for _, call := range calls {
+		if call == nil {
+			continue
+		}
+		weight, ok := ut.weights[call.Method]
+		if !ok {
+			weight = defaultMethodWeight
+		}
+		requests++
+		computeUnits += weight
+	}
+	return
+}
+
+// List returns the usage ordered by the hour and the bot ID.
+func (ut *usageTracker) List() []*BotUsage {
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+	return ut.listUnsafe()
+}
+
+func (ut *usageTracker) listUnsafe() []*BotUsage {
+	var list []*BotUsage
+	for _, botUsage := range ut.usage {
+		usageCopy := *botUsage
+		list = append(list, &usageCopy)
+	}
+	SortUsage(list)
+	return list
+}
+
+// SortUsage sorts the usage by the hour and the bot ID.
+func SortUsage(list []*BotUsage) {
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Hour.Equal(list[j].Hour) {
+			return list[i].Hour.Before(list[j].Hour)
+		}
+		return list[i].BotID < list[j].BotID
+	})
+}
+
+// Save drops the usage older than the retention and writes the rest to the file.
+func (ut *usageTracker) Save() error {
+	ut.mu.Lock()
+	minHour := time.Now().UTC().Add(-ut.retention).Unix()
+	for key := range ut.usage {
+		if key.hour < minHour {
+			delete(ut.usage, key)
+		}
+	}
+	list := ut.listUnsafe()
+	ut.mu.Unlock()
+
+	b, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	tmpPath := ut.filePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, ut.filePath)
+}
+
+func (ut *usageTracker) saveLoop(ctx context.Context) {
+	ticker := time.NewTicker(usageSaveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := ut.Save()
+		ut.lastSave.Set(err)
+		if err != nil {
+			log.WithError(err).Warn("failed to save the rpc usage")
+		}
+	}
+}
+
+// Health implements health.Reporter interface.
+func (ut *usageTracker) Health() health.Reports {
+	return health.Reports{
+		ut.lastSave.GetReport("usage.save"),
+	}
+}
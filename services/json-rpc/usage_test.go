@@ -0,0 +1,53 @@
+package json_rpc
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageTracker(t *testing.T) {
+	r := require.New(t)
+
+	filePath := path.Join(t.TempDir(), config.DefaultRPCUsageFileName)
+	ut := newUsageTracker(filePath, config.RPCUsageConfig{
+		MethodWeights:  map[string]int{"eth_chainId": 0},
+		RetentionHours: 24,
+	})
+
+	r.Equal(10, ut.Track("0x01", []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_getLogs","params":[]}`)))
+	r.Equal(6, ut.Track("0x01", []byte(`[{"method":"eth_blockNumber"},{"method":"eth_call"},{"method":"eth_chainId"}]`)))
+	r.Equal(1, ut.Track("0x02", []byte(`{"method":"eth_blockNumber"}`)))
+	r.Equal(0, ut.Track("0x02", []byte(`not json`)))
+
+	list := ut.List()
+	r.Len(list, 2)
+	r.Equal("0x01", list[0].BotID)
+	r.Equal(uint64(4), list[0].Requests)
+	r.Equal(uint64(16), list[0].ComputeUnits)
+	r.Equal("0x02", list[1].BotID)
+	r.Equal(uint64(1), list[1].Requests)
+
+	// should continue from the saved usage
+	r.NoError(ut.Save())
+	ut = newUsageTracker(filePath, config.RPCUsageConfig{RetentionHours: 24})
+	ut.Track("0x02", []byte(`{"method":"eth_blockNumber"}`))
+	list = ut.List()
+	r.Len(list, 2)
+	r.Equal(uint64(2), list[1].Requests)
+
+	// should drop the usage older than the retention
+	oldHour := time.Now().UTC().Truncate(time.Hour).Add(-time.Hour * 48)
+	ut.usage[usageKey{hour: oldHour.Unix(), botID: "0x03"}] = &BotUsage{Hour: oldHour, BotID: "0x03", Requests: 1}
+	r.NoError(ut.Save())
+	loaded, err := LoadUsage(filePath)
+	r.NoError(err)
+	r.Len(loaded, 2)
+
+	// the proxied graphql queries cost the graphql weight
+	r.Equal(10, ut.TrackGraphQL("0x02", []byte(`{"query":"{ block { number } }"}`)))
+	r.Equal(uint64(3), ut.List()[1].Requests)
+}
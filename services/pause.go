@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+// Pauser is implemented by the services which can stop dispatching and publishing while
+// the node is paused. The containers keep running while paused.
+type Pauser interface {
+	SetPaused(paused bool)
+}
+
+// BotDisabler is implemented by the services which stop running the bots which are disabled
+// locally by the node operator.
+type BotDisabler interface {
+	SetDisabledBots(botIDs []string)
+}
+
+var pausec = make(chan struct{}, 1)
+
+// TriggerPauseStateCheck makes the services check the pause mark again.
+func TriggerPauseStateCheck() {
+	select {
+	case pausec <- struct{}{}:
+	default:
+	}
+}
+
+// ApplyPauseState reads the pause mark from the Forta dir and lets the services know.
+func ApplyPauseState(logger *log.Entry, fortaDir string, services []Service) {
+	paused := config.IsPaused(fortaDir)
+	for _, service := range services {
+		if pauser, ok := service.(Pauser); ok {
+			pauser.SetPaused(paused)
+		}
+	}
+	logger.WithField("paused", paused).Info("applied pause state")
+}
+
+// ApplyDisabledBots reads the disabled bots from the Forta dir and lets the services know.
+func ApplyDisabledBots(logger *log.Entry, fortaDir string, services []Service) {
+	botIDs, err := config.GetDisabledBots(fortaDir)
+	if err != nil {
+		logger.WithError(err).Error("failed to read the disabled bots")
+		return
+	}
+	for _, service := range services {
+		if disabler, ok := service.(BotDisabler); ok {
+			disabler.SetDisabledBots(botIDs)
+		}
+	}
+	logger.WithField("disabledBots", len(botIDs)).Info("applied disabled bots")
+}
+
+// HandlePauseState applies the pause state and the disabled bots whenever a check is triggered.
+func HandlePauseState(ctx context.Context, logger *log.Entry, fortaDir string, services []Service) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-pausec:
+		}
+		ApplyPauseState(logger, fortaDir, services)
+		ApplyDisabledBots(logger, fortaDir, services)
+	}
+}
+
+// PauseState keeps the pause state of a service. The zero value is not paused.
+type PauseState struct {
+	paused  bool
+	resumed chan struct{}
+	mu      sync.RWMutex
+}
+
+// SetPaused sets the pause state.
+func (ps *PauseState) SetPaused(paused bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if paused == ps.paused {
+		return
+	}
+	ps.paused = paused
+	if paused {
+		ps.resumed = make(chan struct{})
+	} else {
+		close(ps.resumed)
+	}
+}
+
+// IsPaused tells if paused.
+func (ps *PauseState) IsPaused() bool {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return ps.paused
+}
+
+// WaitResumed blocks while paused.
+func (ps *PauseState) WaitResumed(ctx context.Context) error {
+	ps.mu.RLock()
+	paused, resumed := ps.paused, ps.resumed
+	ps.mu.RUnlock()
+
+	if !paused {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-resumed:
+		return nil
+	}
+}
+
+// GetReport returns the pause state report.
+func (ps *PauseState) GetReport(name string) *health.Report {
+	return &health.Report{
+		Name:    name,
+		Status:  health.StatusInfo,
+		Details: strconv.FormatBool(ps.IsPaused()),
+	}
+}
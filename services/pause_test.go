@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type testPauser struct {
+	TestService
+	states chan bool
+}
+
+func (tp *testPauser) SetPaused(paused bool) {
+	tp.states <- paused
+}
+
+func TestPauseState(t *testing.T) {
+	r := require.New(t)
+
+	var ps PauseState
+	r.False(ps.IsPaused())
+	r.NoError(ps.WaitResumed(context.Background()))
+
+	ps.SetPaused(true)
+	ps.SetPaused(true) // no-op
+	r.True(ps.IsPaused())
+	r.Equal(health.StatusInfo, ps.GetReport("paused").Status)
+	r.Equal("true", ps.GetReport("paused").Details)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	r.ErrorIs(ps.WaitResumed(ctx), context.DeadlineExceeded)
+
+	resumed := make(chan error)
+	go func() {
+		resumed <- ps.WaitResumed(context.Background())
+	}()
+	ps.SetPaused(false)
+	r.NoError(<-resumed)
+	r.False(ps.IsPaused())
+}
+
+func TestSigUsr1AppliesPauseState(t *testing.T) {
+	r := require.New(t)
+
+	sigc = make(chan os.Signal, 1)
+	pausec = make(chan struct{}, 1)
+	ctx, cancel := InitMainContext()
+	defer cancel()
+
+	fortaDir := t.TempDir()
+	pauser := &testPauser{states: make(chan bool, 1)}
+	logger := logrus.NewEntry(logrus.StandardLogger())
+
+	ApplyPauseState(logger, fortaDir, []Service{pauser})
+	r.False(<-pauser.states)
+
+	go HandlePauseState(ctx, logger, fortaDir, []Service{pauser})
+
+	r.NoError(config.SetPaused(fortaDir, true))
+	sigc <- PauseStateSignal
+	r.True(<-pauser.states)
+	r.NoError(ctx.Err())
+
+	r.NoError(config.SetPaused(fortaDir, false))
+	r.NoError(config.SetPaused(fortaDir, false)) // not an error if not paused
+	sigc <- syscall.SIGUSR1
+	r.False(<-pauser.states)
+}
+
+type testDisabler struct {
+	TestService
+	botIDs chan []string
+}
+
+func (td *testDisabler) SetDisabledBots(botIDs []string) {
+	td.botIDs <- botIDs
+}
+
+func TestApplyDisabledBots(t *testing.T) {
+	r := require.New(t)
+
+	fortaDir := t.TempDir()
+	disabler := &testDisabler{botIDs: make(chan []string, 1)}
+	logger := logrus.NewEntry(logrus.StandardLogger())
+
+	ApplyDisabledBots(logger, fortaDir, []Service{disabler})
+	r.Empty(<-disabler.botIDs)
+
+	r.NoError(config.SetBotDisabled(fortaDir, "0xBBBB", true))
+	r.NoError(config.SetBotDisabled(fortaDir, "0xaaaa", true))
+	r.NoError(config.SetBotDisabled(fortaDir, "0xbbbb", true)) // no duplicates
+	ApplyDisabledBots(logger, fortaDir, []Service{disabler})
+	r.Equal([]string{"0xaaaa", "0xbbbb"}, <-disabler.botIDs)
+
+	r.NoError(config.SetBotDisabled(fortaDir, "0xaaaa", false))
+	r.NoError(config.SetBotDisabled(fortaDir, "0xbbbb", false))
+	r.NoError(config.SetBotDisabled(fortaDir, "0xbbbb", false)) // not an error if not disabled
+	ApplyDisabledBots(logger, fortaDir, []Service{disabler})
+	r.Empty(<-disabler.botIDs)
+}
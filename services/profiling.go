@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// the max duration of the CPU profiles and the traces
+const profilingWriteTimeout = 5 * time.Minute
+
+// NewProfilingHandler returns a handler which serves the pprof and the runtime trace endpoints.
+func NewProfilingHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// StartProfilingServer serves the profiling endpoints until the context is done, if the profiling is enabled.
+// The server listens only on the loopback interface of the container so that the bots which share a network
+// with the service cannot reach it. The profiles are collected from inside the container with docker exec.
+func StartProfilingServer(ctx context.Context, logger *log.Entry, cfg config.ProfilingConfig) {
+	if !cfg.Enable {
+		return
+	}
+	runtime.SetBlockProfileRate(cfg.BlockProfileRate)
+	runtime.SetMutexProfileFraction(cfg.MutexProfileFraction)
+
+	server := &http.Server{
+		Addr:         fmt.Sprintf("127.0.0.1:%s", config.DefaultProfilingPort),
+		Handler:      NewProfilingHandler(),
+		ReadTimeout:  time.Minute,
+		WriteTimeout: profilingWriteTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	go func() {
+		logger.WithField("port", config.DefaultProfilingPort).Warn("profiling is enabled")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.WithError(err).Error("profiling server failed")
+		}
+	}()
+}
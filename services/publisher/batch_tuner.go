@@ -0,0 +1,150 @@
+package publisher
+
+import (
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	batchTunerGrowFactor   = 1.5
+	batchTunerShrinkFactor = 0.5
+)
+
+// batchTuner adjusts the batch interval and the max batch size by looking at the observed
+// alert rate and publish latency. Low-traffic chains get shorter intervals so that the alerts
+// are published promptly and busy chains get bigger and less frequent batches.
+type batchTuner struct {
+	enabled bool
+
+	minInterval time.Duration
+	maxInterval time.Duration
+	minSize     int
+	maxSize     int
+
+	interval time.Duration
+	size     int
+	mu       sync.RWMutex
+}
+
+func newBatchTuner(cfg config.BatchAutoTuneConfig, interval time.Duration, size int) *batchTuner {
+	bt := &batchTuner{}
+	bt.Reset(cfg, interval, size)
+	return bt
+}
+
+// Reset sets the config and the initial values.
+func (bt *batchTuner) Reset(cfg config.BatchAutoTuneConfig, interval time.Duration, size int) {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	bt.enabled = cfg.Enable
+	bt.interval = interval
+	bt.size = size
+	if !bt.enabled {
+		return
+	}
+	bt.minInterval = time.Duration(cfg.MinIntervalSeconds) * time.Second
+	bt.maxInterval = time.Duration(cfg.MaxIntervalSeconds) * time.Second
+	bt.minSize = cfg.MinAlerts
+	bt.maxSize = cfg.MaxAlerts
+	bt.interval = bt.clampInterval(interval)
+	bt.size = bt.clampSize(size)
+}
+
+// Enabled tells if the auto-tuning is enabled.
+func (bt *batchTuner) Enabled() bool {
+	bt.mu.RLock()
+	defer bt.mu.RUnlock()
+	return bt.enabled
+}
+
+// Interval returns the current batch interval.
+func (bt *batchTuner) Interval() time.Duration {
+	bt.mu.RLock()
+	defer bt.mu.RUnlock()
+	return bt.interval
+}
+
+// Size returns the current max batch size.
+func (bt *batchTuner) Size() int {
+	bt.mu.RLock()
+	defer bt.mu.RUnlock()
+	return bt.size
+}
+
+// ObserveBatch adjusts the values after a batch is prepared, by looking at how many alerts
+// were collected and if the batch was filled up before the interval elapsed.
+func (bt *batchTuner) ObserveBatch(alertCount int, full bool) {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	if !bt.enabled {
+		return
+	}
+
+	switch {
+	case full:
+		// busy: collect more alerts in fewer batches
+		bt.size = bt.clampSize(int(float64(bt.size) * batchTunerGrowFactor))
+		bt.interval = bt.clampInterval(time.Duration(float64(bt.interval) * batchTunerGrowFactor))
+
+	case alertCount == 0:
+		// idle: keep the values so that the next alert is still published promptly
+		return
+
+	case alertCount < bt.size/4:
+		// low traffic: publish the few alerts promptly
+		bt.interval = bt.clampInterval(time.Duration(float64(bt.interval) * batchTunerShrinkFactor))
+		bt.size = bt.clampSize(int(float64(bt.size) * batchTunerShrinkFactor))
+
+	default:
+		return
+	}
+	bt.logValues()
+}
+
+// ObservePublish makes sure that the interval does not get shorter than what the publishing
+// takes so the batches do not pile up.
+func (bt *batchTuner) ObservePublish(latency time.Duration) {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	if !bt.enabled {
+		return
+	}
+
+	if latency*2 <= bt.interval {
+		return
+	}
+	bt.interval = bt.clampInterval(latency * 2)
+	bt.size = bt.clampSize(int(float64(bt.size) * batchTunerGrowFactor))
+	bt.logValues()
+}
+
+func (bt *batchTuner) clampInterval(interval time.Duration) time.Duration {
+	if interval < bt.minInterval {
+		return bt.minInterval
+	}
+	if interval > bt.maxInterval {
+		return bt.maxInterval
+	}
+	return interval
+}
+
+func (bt *batchTuner) clampSize(size int) int {
+	if size < bt.minSize {
+		return bt.minSize
+	}
+	if size > bt.maxSize {
+		return bt.maxSize
+	}
+	return size
+}
+
+func (bt *batchTuner) logValues() {
+	log.WithFields(log.Fields{
+		"interval": bt.interval.String(),
+		"maxSize":  bt.size,
+	}).Debug("tuned batch values")
+}
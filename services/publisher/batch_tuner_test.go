@@ -0,0 +1,67 @@
+package publisher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+var testAutoTuneConfig = config.BatchAutoTuneConfig{
+	Enable:             true,
+	MinIntervalSeconds: 5,
+	MaxIntervalSeconds: 60,
+	MinAlerts:          50,
+	MaxAlerts:          5000,
+}
+
+func TestBatchTuner_Disabled(t *testing.T) {
+	r := require.New(t)
+
+	bt := newBatchTuner(config.BatchAutoTuneConfig{}, time.Second*15, 1000)
+	bt.ObserveBatch(1000, true)
+	bt.ObservePublish(time.Minute)
+	r.Equal(time.Second*15, bt.Interval())
+	r.Equal(1000, bt.Size())
+}
+
+func TestBatchTuner_Busy(t *testing.T) {
+	r := require.New(t)
+
+	bt := newBatchTuner(testAutoTuneConfig, time.Second*15, 1000)
+	for i := 0; i < 10; i++ {
+		bt.ObserveBatch(bt.Size(), true)
+	}
+	r.Equal(time.Second*60, bt.Interval())
+	r.Equal(5000, bt.Size())
+}
+
+func TestBatchTuner_LowTraffic(t *testing.T) {
+	r := require.New(t)
+
+	bt := newBatchTuner(testAutoTuneConfig, time.Second*15, 1000)
+	for i := 0; i < 10; i++ {
+		bt.ObserveBatch(1, false)
+	}
+	r.Equal(time.Second*5, bt.Interval())
+	r.Equal(50, bt.Size())
+
+	// idle periods should not delay the next alerts
+	bt.ObserveBatch(0, false)
+	r.Equal(time.Second*5, bt.Interval())
+	r.Equal(50, bt.Size())
+}
+
+func TestBatchTuner_SlowPublish(t *testing.T) {
+	r := require.New(t)
+
+	bt := newBatchTuner(testAutoTuneConfig, time.Second*5, 100)
+	bt.ObservePublish(time.Second * 10)
+	r.Equal(time.Second*20, bt.Interval())
+	r.Equal(150, bt.Size())
+
+	// fast publishing does not change anything
+	bt.ObservePublish(time.Second)
+	r.Equal(time.Second*20, bt.Interval())
+}
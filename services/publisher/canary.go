@@ -0,0 +1,94 @@
+package publisher
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// canaryTracker measures the end-to-end latency of the canary findings which the scanner sends
+// through the pipeline. The health fails if the canary findings stop arriving, which means that
+// something is stuck between the event dispatch and the publisher.
+type canaryTracker struct {
+	timeout     time.Duration
+	started     time.Time
+	lastArrival time.Time
+	lastLatency time.Duration
+	received    uint64
+	mu          sync.Mutex
+}
+
+func newCanaryTracker(cfg config.CanaryConfig, now time.Time) *canaryTracker {
+	return &canaryTracker{
+		timeout: time.Duration(cfg.TimeoutSeconds) * time.Second,
+		started: now,
+	}
+}
+
+// isCanaryNotif tells if the notification is from the built-in canary bot.
+func isCanaryNotif(notif *protocol.NotifyRequest) bool {
+	return notif.AgentInfo != nil && notif.AgentInfo.Id == config.CanaryBotID
+}
+
+// Observe records the arrival of a canary finding.
+func (ct *canaryTracker) Observe(notif *protocol.NotifyRequest, now time.Time) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	ct.received++
+	ct.lastArrival = now
+	if notif.Timestamps == nil {
+		return
+	}
+	sentAt, err := time.Parse(domain.TimeTrackingTimestampFormat, notif.Timestamps.Feed)
+	if err != nil {
+		log.WithError(err).Warn("failed to parse the canary feed time")
+		return
+	}
+	ct.lastLatency = now.Sub(sentAt)
+}
+
+// Health implements health.Reporter interface.
+func (ct *canaryTracker) Health() health.Reports {
+	return ct.reports(time.Now())
+}
+
+func (ct *canaryTracker) reports(now time.Time) health.Reports {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	lastReport := &health.Report{
+		Name:    "canary.last",
+		Status:  health.StatusOK,
+		Details: ct.lastArrival.Format(time.RFC3339),
+	}
+	// there is no canary finding before the first interval so the wait starts with the publisher
+	since := ct.started
+	if !ct.lastArrival.IsZero() {
+		since = ct.lastArrival
+	} else {
+		lastReport.Details = "none"
+	}
+	if now.Sub(since) > ct.timeout {
+		lastReport.Status = health.StatusFailing
+	}
+	return health.Reports{
+		lastReport,
+		{
+			Name:    "canary.latency",
+			Status:  health.StatusInfo,
+			Details: fmt.Sprintf("%dms", ct.lastLatency.Milliseconds()),
+		},
+		{
+			Name:    "canary.received",
+			Status:  health.StatusInfo,
+			Details: fmt.Sprintf("%d", ct.received),
+		},
+	}
+}
@@ -0,0 +1,49 @@
+package publisher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanaryTracker(t *testing.T) {
+	r := require.New(t)
+
+	start := time.Now()
+	ct := newCanaryTracker(config.CanaryConfig{TimeoutSeconds: 300}, start)
+
+	// waits for the first canary finding
+	last, ok := ct.reports(start.Add(time.Minute)).NameContains("canary.last")
+	r.True(ok)
+	r.Equal(health.StatusOK, last.Status)
+	r.Equal("none", last.Details)
+	last, _ = ct.reports(start.Add(time.Minute * 6)).NameContains("canary.last")
+	r.Equal(health.StatusFailing, last.Status)
+
+	notif := &protocol.NotifyRequest{
+		AgentInfo: &protocol.AgentInfo{Id: config.CanaryBotID},
+		Timestamps: &protocol.TrackingTimestamps{
+			Feed: start.Add(time.Minute * 7).Format(domain.TimeTrackingTimestampFormat),
+		},
+	}
+	r.True(isCanaryNotif(notif))
+	r.False(isCanaryNotif(&protocol.NotifyRequest{AgentInfo: &protocol.AgentInfo{Id: "bot1"}}))
+	ct.Observe(notif, start.Add(time.Minute*7+time.Millisecond*250))
+
+	reports := ct.reports(start.Add(time.Minute * 8))
+	last, _ = reports.NameContains("canary.last")
+	r.Equal(health.StatusOK, last.Status)
+	latency, _ := reports.NameContains("canary.latency")
+	r.Equal("250ms", latency.Details)
+	received, _ := reports.NameContains("canary.received")
+	r.Equal("1", received.Details)
+
+	// fails again when the canary findings stop
+	last, _ = ct.reports(start.Add(time.Minute * 13)).NameContains("canary.last")
+	r.Equal(health.StatusFailing, last.Status)
+}
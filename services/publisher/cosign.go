@@ -0,0 +1,251 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-core-go/security"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// cosignature is a signature of the batch digest by a cosigner.
+type cosignature struct {
+	Name      string `json:"name"`
+	Signer    string `json:"signer"`
+	Signature string `json:"signature"`
+	Algorithm string `json:"algorithm"`
+}
+
+// cosignRequest is sent to the remote cosigners.
+type cosignRequest struct {
+	Digest     string `json:"digest"`
+	Scanner    string `json:"scanner"`
+	ChainID    uint64 `json:"chainId"`
+	BlockStart uint64 `json:"blockStart"`
+	BlockEnd   uint64 `json:"blockEnd"`
+	AlertCount uint32 `json:"alertCount"`
+}
+
+// cosignResponse is received from the remote cosigners.
+type cosignResponse struct {
+	Signer    string `json:"signer"`
+	Signature string `json:"signature"`
+}
+
+type cosigner interface {
+	Name() string
+	Cosign(ctx context.Context, req *cosignRequest) (*cosignature, error)
+}
+
+// localCosigner signs with a key from the disk.
+type localCosigner struct {
+	name string
+	key  *keystore.Key
+}
+
+func (lc *localCosigner) Name() string {
+	return lc.name
+}
+
+func (lc *localCosigner) Cosign(ctx context.Context, req *cosignRequest) (*cosignature, error) {
+	sig, err := security.SignString(lc.key, req.Digest)
+	if err != nil {
+		return nil, err
+	}
+	return &cosignature{
+		Name:      lc.name,
+		Signer:    sig.Signer,
+		Signature: sig.Signature,
+		Algorithm: sig.Algorithm,
+	}, nil
+}
+
+// remoteCosigner asks a cosigner service to sign the digest and verifies the signature.
+type remoteCosigner struct {
+	cfg    config.CosignerConfig
+	client *http.Client
+}
+
+func (rc *remoteCosigner) Name() string {
+	return rc.cfg.Name
+}
+
+func (rc *remoteCosigner) Cosign(ctx context.Context, req *cosignRequest) (*cosignature, error) {
+	b, _ := json.Marshal(req)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, rc.cfg.URL, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for k, v := range rc.cfg.Headers {
+		httpReq.Header.Set(k, v)
+	}
+	resp, err := rc.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cosigner responded with status %d", resp.StatusCode)
+	}
+	var cosignResp cosignResponse
+	if err := json.NewDecoder(resp.Body).Decode(&cosignResp); err != nil {
+		return nil, fmt.Errorf("failed to decode the cosigner response: %v", err)
+	}
+	if !strings.EqualFold(cosignResp.Signer, rc.cfg.Address) {
+		return nil, fmt.Errorf("unexpected signer %s", cosignResp.Signer)
+	}
+	if err := security.VerifySignature([]byte(req.Digest), rc.cfg.Address, cosignResp.Signature); err != nil {
+		return nil, fmt.Errorf("invalid cosignature: %v", err)
+	}
+	return &cosignature{
+		Name:      rc.cfg.Name,
+		Signer:    common.HexToAddress(rc.cfg.Address).Hex(),
+		Signature: cosignResp.Signature,
+		Algorithm: "ECDSA",
+	}, nil
+}
+
+// newCosigner creates the cosigner. The key dir of a local cosigner is mounted to the container
+// separately from the Forta dir.
+func newCosigner(cfg config.CosignerConfig, keyDir string) (cosigner, error) {
+	if len(cfg.URL) > 0 {
+		return &remoteCosigner{
+			cfg:    cfg,
+			client: &http.Client{Timeout: time.Duration(cfg.TimeoutSeconds) * time.Second},
+		}, nil
+	}
+	passphrase, err := os.ReadFile(path.Join(keyDir, cfg.PassphraseFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the passphrase file: %v", err)
+	}
+	key, err := security.LoadKeyWithPassphrase(keyDir, strings.TrimSpace(string(passphrase)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the key: %v", err)
+	}
+	return &localCosigner{name: cfg.Name, key: key}, nil
+}
+
+// batchCosigner collects the cosignatures of the alert batches.
+type batchCosigner struct {
+	cosigners []cosigner
+	threshold int
+
+	lastCosign    health.TimeTracker
+	lastCosignErr health.ErrorTracker
+}
+
+func newBatchCosigner(cfg config.BatchSigningConfig) (*batchCosigner, error) {
+	if len(cfg.Cosigners) == 0 {
+		return nil, nil
+	}
+	threshold := cfg.Threshold
+	if threshold == 0 {
+		threshold = len(cfg.Cosigners)
+	}
+	if threshold > len(cfg.Cosigners) {
+		return nil, fmt.Errorf("the threshold %d is greater than the cosigner count %d", threshold, len(cfg.Cosigners))
+	}
+	bc := &batchCosigner{threshold: threshold}
+	for i, cosignerCfg := range cfg.Cosigners {
+		c, err := newCosigner(cosignerCfg, config.CosignerContainerKeyDir(i))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cosigner %s: %v", cosignerCfg.Name, err)
+		}
+		bc.cosigners = append(bc.cosigners, c)
+	}
+	return bc, nil
+}
+
+// cosignedBatch is the published batch file which carries the cosignatures of the signed batch and
+// the invalidations of the reorged out blocks, so that the batch reference which the scanner signs
+// in the batch summary covers them too.
+type cosignedBatch struct {
+	*protocol.SignedPayload
+	Cosignatures  []*cosignature       `json:"cosignatures,omitempty"`
+	Invalidations []*blockInvalidation `json:"invalidations,omitempty"`
+}
+
+// batchDigest is the hash of the encoded batch which is signed by the scanner key.
+func batchDigest(signedBatch *protocol.SignedPayload) string {
+	return hexutil.Encode(crypto.Keccak256([]byte(signedBatch.Encoded)))
+}
+
+// Cosign collects the cosignatures from all cosigners and fails if they are less than the threshold.
+func (bc *batchCosigner) Cosign(ctx context.Context, scanner string, batch *protocol.AlertBatch, signedBatch *protocol.SignedPayload) (string, []*cosignature, error) {
+	req := &cosignRequest{
+		Digest:     batchDigest(signedBatch),
+		Scanner:    scanner,
+		ChainID:    batch.ChainId,
+		BlockStart: batch.BlockStart,
+		BlockEnd:   batch.BlockEnd,
+		AlertCount: batch.AlertCount,
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		sigs   []*cosignature
+		errMsg []string
+	)
+	results := make([]*cosignature, len(bc.cosigners))
+	for i, c := range bc.cosigners {
+		wg.Add(1)
+		go func(i int, c cosigner) {
+			defer wg.Done()
+			sig, err := c.Cosign(ctx, req)
+			if err != nil {
+				log.WithError(err).WithField("cosigner", c.Name()).Warn("failed to cosign the batch")
+				mu.Lock()
+				errMsg = append(errMsg, fmt.Sprintf("%s: %v", c.Name(), err))
+				mu.Unlock()
+				return
+			}
+			results[i] = sig
+		}(i, c)
+	}
+	wg.Wait()
+
+	// keep the config order
+	for _, sig := range results {
+		if sig != nil {
+			sigs = append(sigs, sig)
+		}
+	}
+	if len(sigs) < bc.threshold {
+		err := fmt.Errorf("collected %d of %d required cosignatures (%s)", len(sigs), bc.threshold, strings.Join(errMsg, ", "))
+		bc.lastCosignErr.Set(err)
+		return "", nil, err
+	}
+	var err error
+	if len(errMsg) > 0 {
+		err = errors.New(strings.Join(errMsg, ", "))
+	}
+	bc.lastCosignErr.Set(err)
+	bc.lastCosign.Set()
+	return req.Digest, sigs, nil
+}
+
+// Health implements the health.Reporter interface.
+func (bc *batchCosigner) Health() health.Reports {
+	return health.Reports{
+		bc.lastCosign.GetReport("event.batch-cosign.time"),
+		bc.lastCosignErr.GetReport("event.batch-cosign.error"),
+	}
+}
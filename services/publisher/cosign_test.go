@@ -0,0 +1,125 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-core-go/security"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func testCosignKey(r *require.Assertions) *keystore.Key {
+	privKey, err := crypto.GenerateKey()
+	r.NoError(err)
+	return &keystore.Key{PrivateKey: privKey, Address: crypto.PubkeyToAddress(privKey.PublicKey)}
+}
+
+func testCosignServer(r *require.Assertions, key *keystore.Key) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.Equal("secret", req.Header.Get("Authorization"))
+		var cosignReq cosignRequest
+		r.NoError(json.NewDecoder(req.Body).Decode(&cosignReq))
+		sig, err := security.SignString(key, cosignReq.Digest)
+		r.NoError(err)
+		json.NewEncoder(w).Encode(&cosignResponse{Signer: sig.Signer, Signature: sig.Signature})
+	}))
+}
+
+func TestBatchCosigner(t *testing.T) {
+	r := require.New(t)
+
+	scannerKey := testCosignKey(r)
+	orgKey := testCosignKey(r)
+	remoteKey := testCosignKey(r)
+	server := testCosignServer(r, remoteKey)
+	defer server.Close()
+
+	remoteCfg := config.CosignerConfig{
+		Name:           "remote",
+		URL:            server.URL,
+		Address:        remoteKey.Address.Hex(),
+		Headers:        map[string]string{"Authorization": "secret"},
+		TimeoutSeconds: 1,
+	}
+	remote, err := newCosigner(remoteCfg, "")
+	r.NoError(err)
+	bc := &batchCosigner{
+		cosigners: []cosigner{&localCosigner{name: "org", key: orgKey}, remote},
+		threshold: 2,
+	}
+
+	batch := &protocol.AlertBatch{ChainId: 1, BlockStart: 10, BlockEnd: 20}
+	signedBatch, err := security.SignBatch(scannerKey, batch)
+	r.NoError(err)
+
+	digest, sigs, err := bc.Cosign(context.Background(), scannerKey.Address.Hex(), batch, signedBatch)
+	r.NoError(err)
+	r.Equal(batchDigest(signedBatch), digest)
+	r.Len(sigs, 2)
+	r.Equal(orgKey.Address.Hex(), sigs[0].Signer)
+	r.Equal(remoteKey.Address.Hex(), sigs[1].Signer)
+	for _, sig := range sigs {
+		r.NoError(security.VerifySignature([]byte(digest), sig.Signer, sig.Signature))
+	}
+
+	// a remote cosigner with an unexpected key fails and the threshold is not met
+	remoteCfg.Address = orgKey.Address.Hex()
+	remote, err = newCosigner(remoteCfg, "")
+	r.NoError(err)
+	bc.cosigners[1] = remote
+	_, _, err = bc.Cosign(context.Background(), scannerKey.Address.Hex(), batch, signedBatch)
+	r.Error(err)
+	report, ok := bc.Health().GetByName("event.batch-cosign.error")
+	r.True(ok)
+	r.Contains(report.Details, "remote")
+
+	// one of two is enough with the lower threshold
+	bc.threshold = 1
+	_, sigs, err = bc.Cosign(context.Background(), scannerKey.Address.Hex(), batch, signedBatch)
+	r.NoError(err)
+	r.Len(sigs, 1)
+}
+
+func TestNewBatchCosigner(t *testing.T) {
+	r := require.New(t)
+
+	bc, err := newBatchCosigner(config.BatchSigningConfig{})
+	r.NoError(err)
+	r.Nil(bc)
+
+	_, err = newBatchCosigner(config.BatchSigningConfig{
+		Cosigners: []config.CosignerConfig{{Name: "remote", URL: "http://localhost:1234", TimeoutSeconds: 1}},
+		Threshold: 2,
+	})
+	r.Error(err)
+}
+
+func TestCosignedBatchEncoding(t *testing.T) {
+	r := require.New(t)
+
+	scannerKey := testCosignKey(r)
+	orgKey := testCosignKey(r)
+	signedBatch, err := security.SignBatch(scannerKey, &protocol.AlertBatch{ChainId: 1, BlockStart: 10, BlockEnd: 20})
+	r.NoError(err)
+	sig, err := (&localCosigner{name: "org", key: orgKey}).Cosign(context.Background(), &cosignRequest{Digest: batchDigest(signedBatch)})
+	r.NoError(err)
+
+	b, err := json.Marshal(&cosignedBatch{SignedPayload: signedBatch, Cosignatures: []*cosignature{sig}})
+	r.NoError(err)
+
+	// the batch file is still a valid signed payload and carries the cosignatures
+	var decoded protocol.SignedPayload
+	r.NoError(json.Unmarshal(b, &decoded))
+	r.NoError(security.VerifySignedPayload(&decoded))
+	var decodedCosigned cosignedBatch
+	r.NoError(json.Unmarshal(b, &decodedCosigned))
+	r.Len(decodedCosigned.Cosignatures, 1)
+	r.NoError(security.VerifySignature([]byte(batchDigest(&decoded)), orgKey.Address.Hex(), decodedCosigned.Cosignatures[0].Signature))
+}
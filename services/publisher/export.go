@@ -0,0 +1,266 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	s3Service        = "s3"
+	s3SigningAlgo    = "AWS4-HMAC-SHA256"
+	s3DateTimeFormat = "20060102T150405Z"
+	s3DateFormat     = "20060102"
+
+	exportQueueSize = 100
+)
+
+// batchExporter uploads the published batches and their receipts to an S3-compatible bucket
+// so that the operators can keep their own copy of the published alerts.
+type batchExporter struct {
+	cfg    config.BatchExportConfig
+	client *http.Client
+	now    func() time.Time
+	queue  chan *exportObject
+
+	batches  uint64
+	receipts uint64
+	failures uint64
+	dropped  uint64
+	lastErr  error
+	mu       sync.Mutex
+}
+
+// exportObject is an upload which is waiting in the export queue.
+type exportObject struct {
+	key     string
+	b       []byte
+	counter *uint64
+}
+
+func newBatchExporter(cfg config.BatchExportConfig) *batchExporter {
+	return &batchExporter{
+		cfg:    cfg,
+		client: &http.Client{Timeout: time.Duration(cfg.TimeoutSeconds) * time.Second},
+		now:    time.Now,
+		queue:  make(chan *exportObject, exportQueueSize),
+	}
+}
+
+// QueueBatch queues the signed batch to be uploaded. It never blocks the publishing: the batch
+// is dropped and counted if the bucket falls behind.
+func (be *batchExporter) QueueBatch(chainID uint64, ref string, b []byte) {
+	be.enqueue(&exportObject{key: be.objectKey(chainID, ref+".json"), b: b, counter: &be.batches})
+}
+
+// QueueReceipt queues the signed receipt of the batch to be uploaded.
+func (be *batchExporter) QueueReceipt(chainID uint64, ref string, b []byte) {
+	be.enqueue(&exportObject{key: be.objectKey(chainID, ref+".receipt.json"), b: b, counter: &be.receipts})
+}
+
+func (be *batchExporter) enqueue(obj *exportObject) {
+	select {
+	case be.queue <- obj:
+	default:
+		be.mu.Lock()
+		be.dropped++
+		be.mu.Unlock()
+		log.WithField("key", obj.key).Warn("export queue is full - dropping the object")
+	}
+}
+
+// exportLoop uploads the queued objects one by one.
+func (be *batchExporter) exportLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case obj := <-be.queue:
+			err := be.put(ctx, obj.key, obj.b)
+			be.observe(err, obj.counter)
+			if err != nil {
+				log.WithError(err).Warn("failed to export")
+			}
+		}
+	}
+}
+
+// ExportBatch uploads the signed batch.
+func (be *batchExporter) ExportBatch(ctx context.Context, chainID uint64, ref string, b []byte) error {
+	err := be.put(ctx, be.objectKey(chainID, ref+".json"), b)
+	be.observe(err, &be.batches)
+	return err
+}
+
+// ExportReceipt uploads the signed receipt of the batch.
+func (be *batchExporter) ExportReceipt(ctx context.Context, chainID uint64, ref string, b []byte) error {
+	err := be.put(ctx, be.objectKey(chainID, ref+".receipt.json"), b)
+	be.observe(err, &be.receipts)
+	return err
+}
+
+func (be *batchExporter) observe(err error, counter *uint64) {
+	be.mu.Lock()
+	defer be.mu.Unlock()
+
+	if err != nil {
+		be.failures++
+		be.lastErr = err
+		return
+	}
+	*counter++
+	be.lastErr = nil
+}
+
+// objectKey returns the key of the object under the chain and the date partitions.
+func (be *batchExporter) objectKey(chainID uint64, name string) string {
+	date := be.now().UTC()
+	return path.Join(
+		be.cfg.Prefix,
+		"chain="+strconv.FormatUint(chainID, 10),
+		date.Format("year=2006"), date.Format("month=01"), date.Format("day=02"),
+		name,
+	)
+}
+
+func (be *batchExporter) put(ctx context.Context, key string, b []byte) error {
+	canonicalURI := "/" + s3URIEncode(be.cfg.Bucket+"/"+key)
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPut, strings.TrimSuffix(be.cfg.Endpoint, "/")+canonicalURI, bytes.NewReader(b),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create the export request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(be.cfg.AccessKeyID) > 0 {
+		signS3Request(req, canonicalURI, b, be.cfg, be.now().UTC())
+	}
+
+	resp, err := be.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export %s: %v", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to export %s: status code %d: %s", key, resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// signS3Request signs the request with the AWS signature version 4.
+func signS3Request(req *http.Request, canonicalURI string, payload []byte, cfg config.BatchExportConfig, now time.Time) {
+	payloadHash := sha256Hex(payload)
+	amzDate := now.Format(s3DateTimeFormat)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		"", // no query
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{now.Format(s3DateFormat), cfg.Region, s3Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{s3SigningAlgo, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+	signingKey := s3SigningKey(cfg.SecretAccessKey, now.Format(s3DateFormat), cfg.Region, s3Service)
+	signature := hex.EncodeToString(hmacSHA256(signingKey, []byte(stringToSign)))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3SigningAlgo, cfg.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func s3SigningKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), []byte(date))
+	key = hmacSHA256(key, []byte(region))
+	key = hmacSHA256(key, []byte(service))
+	return hmacSHA256(key, []byte("aws4_request"))
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// s3URIEncode encodes the object path as S3 expects in the canonical request: everything other than
+// the unreserved characters and the slashes is percent-encoded.
+func s3URIEncode(s string) string {
+	var sb strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			sb.WriteByte(c)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+// Health implements health.Reporter interface.
+func (be *batchExporter) Health() health.Reports {
+	be.mu.Lock()
+	defer be.mu.Unlock()
+
+	errReport := &health.Report{
+		Name:   "export.error",
+		Status: health.StatusOK,
+	}
+	if be.lastErr != nil {
+		errReport.Status = health.StatusFailing
+		errReport.Details = be.lastErr.Error()
+	}
+	return health.Reports{
+		{
+			Name:    "export.batches",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(be.batches, 10),
+		},
+		{
+			Name:    "export.receipts",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(be.receipts, 10),
+		},
+		{
+			Name:    "export.failures",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(be.failures, 10),
+		},
+		{
+			Name:    "export.dropped",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(be.dropped, 10),
+		},
+		errReport,
+	}
+}
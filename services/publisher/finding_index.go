@@ -0,0 +1,214 @@
+package publisher
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/protocol"
+)
+
+// PublishedFinding is a finding in a batch which was published by the node.
+type PublishedFinding struct {
+	Time        time.Time `json:"time"`
+	Hash        string    `json:"hash"`
+	BotID       string    `json:"botId"`
+	AlertID     string    `json:"alertId"`
+	Name        string    `json:"name"`
+	Severity    string    `json:"severity"`
+	ChainID     uint64    `json:"chainId"`
+	BlockNumber uint64    `json:"blockNumber,omitempty"`
+	TxHash      string    `json:"txHash,omitempty"`
+	Batch       string    `json:"batch,omitempty"`
+}
+
+// findingIndex appends the published findings to a JSON lines file and keeps only the last ones.
+// The file is compacted after it grows to twice the max size, so that it is not rewritten with
+// every batch.
+type findingIndex struct {
+	filePath    string
+	maxFindings int
+
+	lines   int // -1 until the file is read
+	indexed uint64
+	lastErr health.ErrorTracker
+	mu      sync.Mutex
+}
+
+func newFindingIndex(filePath string, maxFindings int) *findingIndex {
+	return &findingIndex{
+		filePath:    filePath,
+		maxFindings: maxFindings,
+		lines:       -1,
+	}
+}
+
+// LoadPublishedFindings loads the published findings in the publish order. The index is empty if
+// the file does not exist.
+func LoadPublishedFindings(filePath string) ([]*PublishedFinding, error) {
+	f, err := os.Open(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var findings []*PublishedFinding
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var finding PublishedFinding
+		// skip the partially written lines
+		if err := json.Unmarshal(scanner.Bytes(), &finding); err != nil {
+			continue
+		}
+		findings = append(findings, &finding)
+	}
+	return findings, scanner.Err()
+}
+
+// Record appends the findings of the published batch to the index.
+func (fi *findingIndex) Record(batch *protocol.AlertBatch, ref string, t time.Time) error {
+	findings := batchFindings(batch, ref, t)
+	if len(findings) == 0 {
+		return nil
+	}
+
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	err := fi.record(findings)
+	fi.lastErr.Set(err)
+	return err
+}
+
+func (fi *findingIndex) record(findings []*PublishedFinding) error {
+	if fi.lines < 0 {
+		existing, err := LoadPublishedFindings(fi.filePath)
+		if err != nil {
+			return err
+		}
+		fi.lines = len(existing)
+	}
+
+	f, err := os.OpenFile(fi.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if err := writeFindings(f, findings); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fi.lines += len(findings)
+	fi.indexed += uint64(len(findings))
+
+	if fi.lines > fi.maxFindings*2 {
+		return fi.compact()
+	}
+	return nil
+}
+
+// compact rewrites the file with only the last findings.
+func (fi *findingIndex) compact() error {
+	findings, err := LoadPublishedFindings(fi.filePath)
+	if err != nil {
+		return err
+	}
+	if len(findings) > fi.maxFindings {
+		findings = findings[len(findings)-fi.maxFindings:]
+	}
+	tmpPath := fi.filePath + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if err := writeFindings(f, findings); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, fi.filePath); err != nil {
+		return err
+	}
+	fi.lines = len(findings)
+	return nil
+}
+
+func writeFindings(w io.Writer, findings []*PublishedFinding) error {
+	bw := bufio.NewWriter(w)
+	for _, finding := range findings {
+		b, err := json.Marshal(finding)
+		if err != nil {
+			return err
+		}
+		bw.Write(append(b, '\n'))
+	}
+	return bw.Flush()
+}
+
+// batchFindings returns the findings in the block, the transaction and the combination results of
+// the batch.
+func batchFindings(batch *protocol.AlertBatch, ref string, t time.Time) []*PublishedFinding {
+	var findings []*PublishedFinding
+	add := func(agentAlerts []*protocol.AgentAlerts, blockNumber uint64, txHash string) {
+		for _, aa := range agentAlerts {
+			for _, signedAlert := range aa.Alerts {
+				alert := signedAlert.GetAlert()
+				if alert == nil {
+					continue
+				}
+				findings = append(findings, &PublishedFinding{
+					Time:        t.UTC(),
+					Hash:        alert.Id,
+					BotID:       alert.GetAgent().GetId(),
+					AlertID:     alert.GetFinding().GetAlertId(),
+					Name:        alert.GetFinding().GetName(),
+					Severity:    alert.GetFinding().GetSeverity().String(),
+					ChainID:     batch.ChainId,
+					BlockNumber: blockNumber,
+					TxHash:      txHash,
+					Batch:       ref,
+				})
+			}
+		}
+	}
+	for _, blockRes := range batch.Results {
+		blockNumber := blockRes.GetBlock().GetBlockNumber()
+		add(blockRes.Results, blockNumber, "")
+		for _, txRes := range blockRes.Transactions {
+			add(txRes.Results, blockNumber, txRes.GetTransaction().GetTransaction().GetHash())
+		}
+	}
+	for _, combinationRes := range batch.CombinationAlerts {
+		add(combinationRes.Results, 0, "")
+	}
+	return findings
+}
+
+// Health implements health.Reporter interface.
+func (fi *findingIndex) Health() health.Reports {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	return health.Reports{
+		{
+			Name:    "findings.indexed",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(fi.indexed, 10),
+		},
+		fi.lastErr.GetReport("findings.index.error"),
+	}
+}
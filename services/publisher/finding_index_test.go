@@ -0,0 +1,106 @@
+package publisher
+
+import (
+	"fmt"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func testIndexAlert(hash, botID string, severity protocol.Finding_Severity) *protocol.SignedAlert {
+	return &protocol.SignedAlert{
+		Alert: &protocol.Alert{
+			Id:      hash,
+			Agent:   &protocol.AgentInfo{Id: botID},
+			Finding: &protocol.Finding{AlertId: "ALERT-1", Name: "alert", Severity: severity},
+		},
+	}
+}
+
+func TestBatchFindings(t *testing.T) {
+	r := require.New(t)
+
+	now := time.Date(2023, 3, 20, 10, 30, 0, 0, time.UTC)
+	batch := &protocol.AlertBatch{
+		ChainId: 1,
+		Results: []*protocol.BlockResults{
+			{
+				Block: &protocol.Block{BlockNumber: 100},
+				Results: []*protocol.AgentAlerts{
+					{Alerts: []*protocol.SignedAlert{testIndexAlert("0x01", "0xbot1", protocol.Finding_HIGH)}},
+				},
+				Transactions: []*protocol.TransactionResults{
+					{
+						Transaction: &protocol.TransactionEvent{Transaction: &protocol.TransactionEvent_EthTransaction{Hash: "0xtx"}},
+						Results: []*protocol.AgentAlerts{
+							{Alerts: []*protocol.SignedAlert{testIndexAlert("0x02", "0xbot2", protocol.Finding_LOW)}},
+						},
+					},
+				},
+			},
+		},
+		CombinationAlerts: []*protocol.CombinationAlertResults{
+			{
+				Results: []*protocol.AgentAlerts{
+					{Alerts: []*protocol.SignedAlert{testIndexAlert("0x03", "0xbot3", protocol.Finding_CRITICAL)}},
+				},
+			},
+		},
+	}
+
+	findings := batchFindings(batch, "Qm1234", now)
+	r.Len(findings, 3)
+	r.Equal(&PublishedFinding{
+		Time: now, Hash: "0x01", BotID: "0xbot1", AlertID: "ALERT-1", Name: "alert", Severity: "HIGH",
+		ChainID: 1, BlockNumber: 100, Batch: "Qm1234",
+	}, findings[0])
+	r.Equal("0xtx", findings[1].TxHash)
+	r.Equal(uint64(100), findings[1].BlockNumber)
+	r.Equal("CRITICAL", findings[2].Severity)
+	r.Zero(findings[2].BlockNumber)
+}
+
+func TestFindingIndex(t *testing.T) {
+	r := require.New(t)
+
+	filePath := path.Join(t.TempDir(), "findings.jsonl")
+	index := newFindingIndex(filePath, 3)
+
+	findings, err := LoadPublishedFindings(filePath)
+	r.NoError(err)
+	r.Empty(findings)
+
+	for i := 0; i < 7; i++ {
+		batch := &protocol.AlertBatch{
+			Results: []*protocol.BlockResults{
+				{
+					Block: &protocol.Block{BlockNumber: uint64(i)},
+					Results: []*protocol.AgentAlerts{
+						{Alerts: []*protocol.SignedAlert{testIndexAlert(fmt.Sprintf("0x%02d", i), "0xbot", protocol.Finding_INFO)}},
+					},
+				},
+			},
+		}
+		r.NoError(index.Record(batch, "", time.Now()))
+	}
+
+	// compacted to the last 3 findings after the 7th one and kept in order
+	findings, err = LoadPublishedFindings(filePath)
+	r.NoError(err)
+	r.Len(findings, 3)
+	r.Equal("0x04", findings[0].Hash)
+	r.Equal("0x06", findings[2].Hash)
+
+	// a new index continues from the existing file
+	index = newFindingIndex(filePath, 3)
+	r.NoError(index.Record(&protocol.AlertBatch{
+		CombinationAlerts: []*protocol.CombinationAlertResults{
+			{Results: []*protocol.AgentAlerts{{Alerts: []*protocol.SignedAlert{testIndexAlert("0x07", "0xbot", protocol.Finding_INFO)}}}},
+		},
+	}, "", time.Now()))
+	r.Equal(4, index.lines)
+	r.Equal("1", index.Health()[0].Details)
+}
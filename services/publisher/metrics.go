@@ -1,6 +1,7 @@
 package publisher
 
 import (
+	"math/rand"
 	"sort"
 	"sync"
 	"time"
@@ -10,20 +11,58 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// default limits of the aggregated metrics per bot
+const (
+	defaultMaxBucketsPerBot = 10
+	defaultMaxMetricSamples = 500
+)
+
 // AgentMetricsAggregator aggregates agents' metrics and produces a list of summary of them when flushed.
+// The buckets are flushed incrementally as they are closed and the memory per bot is bounded by
+// the number of open buckets and the number of samples kept for the percentiles.
 type AgentMetricsAggregator struct {
-	buckets        []*metricsBucket
-	bucketInterval time.Duration
-	lastFlush      time.Time
-	mu             sync.RWMutex
+	bots             map[string]*botBuckets
+	closed           []*metricsBucket
+	bucketInterval   time.Duration
+	maxBucketsPerBot int
+	maxSamples       int
+	mu               sync.RWMutex
+}
+
+type botBuckets struct {
+	buckets map[int64]*metricsBucket
 }
 
 type metricsBucket struct {
-	Time           time.Time
-	MetricCounters map[string][]uint32
+	Time       time.Time
+	Aggregates map[string]*metricAggregate
 	protocol.AgentMetrics
 }
 
+// metricAggregate keeps the summary values and a sample of the data points for the percentiles.
+type metricAggregate struct {
+	count   uint32
+	sum     float64
+	max     float64
+	samples []uint32
+}
+
+func (ma *metricAggregate) add(dataPoint uint32, maxSamples int) {
+	ma.count++
+	ma.sum += float64(dataPoint)
+	if float64(dataPoint) > ma.max {
+		ma.max = float64(dataPoint)
+	}
+	if len(ma.samples) < maxSamples {
+		ma.samples = append(ma.samples, dataPoint)
+		return
+	}
+	// reservoir sampling: every data point has an equal chance of being in the sample
+	if i := rand.Int63n(int64(ma.count)); i < int64(maxSamples) {
+		ma.samples[i] = dataPoint
+	}
+}
+
 func (mb *metricsBucket) CreateAndGetSummary(name string) *protocol.MetricSummary {
 	for _, summary := range mb.Metrics {
 		if summary.Name == name {
@@ -38,30 +77,49 @@ func (mb *metricsBucket) CreateAndGetSummary(name string) *protocol.MetricSummar
 // NewAgentMetricsAggregator creates a new agent metrics aggregator.
 func NewMetricsAggregator(bucketInterval time.Duration) *AgentMetricsAggregator {
 	return &AgentMetricsAggregator{
-		mu:             sync.RWMutex{},
-		bucketInterval: bucketInterval,
-		lastFlush:      time.Now(), // avoid flushing immediately
+		bots:             make(map[string]*botBuckets),
+		bucketInterval:   bucketInterval,
+		maxBucketsPerBot: defaultMaxBucketsPerBot,
+		maxSamples:       defaultMaxMetricSamples,
 	}
 }
 
+// WithLimits sets the max number of open buckets per bot and the max number of samples
+// kept per metric in each bucket.
+func (ama *AgentMetricsAggregator) WithLimits(maxBucketsPerBot, maxSamples int) *AgentMetricsAggregator {
+	ama.maxBucketsPerBot = maxBucketsPerBot
+	ama.maxSamples = maxSamples
+	return ama
+}
+
 func (ama *AgentMetricsAggregator) findBucket(agentID string, t time.Time) *metricsBucket {
 	bucketTime := ama.FindClosestBucketTime(t)
-	for _, bucket := range ama.buckets {
-		if bucket.AgentId != agentID {
-			continue
-		}
-		if !bucket.Time.Equal(bucketTime) {
-			continue
-		}
+	bot, ok := ama.bots[agentID]
+	if !ok {
+		bot = &botBuckets{buckets: make(map[int64]*metricsBucket)}
+		ama.bots[agentID] = bot
+	}
+	if bucket, ok := bot.buckets[bucketTime.UnixNano()]; ok {
 		return bucket
 	}
+	// close the oldest bucket early if the bot has too many open buckets
+	if len(bot.buckets) >= ama.maxBucketsPerBot {
+		var oldest *metricsBucket
+		for _, bucket := range bot.buckets {
+			if oldest == nil || bucket.Time.Before(oldest.Time) {
+				oldest = bucket
+			}
+		}
+		delete(bot.buckets, oldest.Time.UnixNano())
+		ama.closed = append(ama.closed, oldest)
+	}
 	bucket := &metricsBucket{
-		Time:           bucketTime,
-		MetricCounters: make(map[string][]uint32),
+		Time:       bucketTime,
+		Aggregates: make(map[string]*metricAggregate),
 	}
 	bucket.AgentId = agentID
 	bucket.Timestamp = utils.FormatTime(bucketTime)
-	ama.buckets = append(ama.buckets, bucket)
+	bot.buckets[bucketTime.UnixNano()] = bucket
 	return bucket
 }
 
@@ -82,7 +140,12 @@ func (ama *AgentMetricsAggregator) AddAgentMetrics(ms *protocol.AgentMetricList)
 	for _, m := range ms.Metrics {
 		t, _ := time.Parse(time.RFC3339, m.Timestamp)
 		bucket := ama.findBucket(m.AgentId, t)
-		bucket.MetricCounters[m.Name] = append(bucket.MetricCounters[m.Name], uint32(m.Value))
+		aggregate, ok := bucket.Aggregates[m.Name]
+		if !ok {
+			aggregate = &metricAggregate{}
+			bucket.Aggregates[m.Name] = aggregate
+		}
+		aggregate.add(uint32(m.Value), ama.maxSamples)
 	}
 	return nil
 }
@@ -92,91 +155,103 @@ func (ama *AgentMetricsAggregator) ForceFlush() []*protocol.AgentMetrics {
 	ama.mu.Lock()
 	defer ama.mu.Unlock()
 
-	now := time.Now()
-
-	ama.lastFlush = now
-	buckets := ama.buckets
-	ama.buckets = nil
-
-	(allAgentMetrics)(buckets).Fix()
-
-	var allMetrics []*protocol.AgentMetrics
-	for _, bucket := range buckets {
-		allMetrics = append(allMetrics, &bucket.AgentMetrics)
+	buckets := ama.closed
+	for _, bot := range ama.bots {
+		for _, bucket := range bot.buckets {
+			buckets = append(buckets, bucket)
+		}
 	}
+	ama.closed = nil
+	ama.bots = make(map[string]*botBuckets)
 
-	return allMetrics
+	return (allAgentMetrics)(buckets).Fix()
 }
 
-// TryFlush checks the flushing condition(s) an returns metrics accordingly.
+// TryFlush flushes the buckets which are closed and returns false if there are none.
 func (ama *AgentMetricsAggregator) TryFlush() ([]*protocol.AgentMetrics, bool) {
 	ama.mu.Lock()
 	defer ama.mu.Unlock()
 
 	now := time.Now()
-	if now.Sub(ama.lastFlush) < ama.bucketInterval {
+	buckets := ama.closed
+	for agentID, bot := range ama.bots {
+		for key, bucket := range bot.buckets {
+			if bucket.Time.Add(ama.bucketInterval).After(now) {
+				continue
+			}
+			buckets = append(buckets, bucket)
+			delete(bot.buckets, key)
+		}
+		if len(bot.buckets) == 0 {
+			delete(ama.bots, agentID)
+		}
+	}
+	ama.closed = nil
+	if len(buckets) == 0 {
 		return nil, false
 	}
 
-	ama.lastFlush = now
-	buckets := ama.buckets
-	ama.buckets = nil
+	return (allAgentMetrics)(buckets).Fix(), true
+}
 
-	(allAgentMetrics)(buckets).Fix()
+// OpenBuckets returns the number of buckets which are not flushed yet.
+func (ama *AgentMetricsAggregator) OpenBuckets() int {
+	ama.mu.RLock()
+	defer ama.mu.RUnlock()
 
-	var allMetrics []*protocol.AgentMetrics
-	for _, bucket := range buckets {
-		allMetrics = append(allMetrics, &bucket.AgentMetrics)
+	count := len(ama.closed)
+	for _, bot := range ama.bots {
+		count += len(bot.buckets)
 	}
-
-	return allMetrics, true
+	return count
 }
 
 // allAgentMetrics is an alias type for post-processing aggregated in-memory metrics
 // before we publish them.
 type allAgentMetrics []*metricsBucket
 
-func (allMetrics allAgentMetrics) Fix() {
+func (allMetrics allAgentMetrics) Fix() []*protocol.AgentMetrics {
 	sort.Slice(allMetrics, func(i, j int) bool {
+		if allMetrics[i].Time.Equal(allMetrics[j].Time) {
+			return allMetrics[i].AgentId < allMetrics[j].AgentId
+		}
 		return allMetrics[i].Time.Before(allMetrics[j].Time)
 	})
 	allMetrics.PrepareMetrics()
+
+	var result []*protocol.AgentMetrics
+	for _, bucket := range allMetrics {
+		result = append(result, &bucket.AgentMetrics)
+	}
+	return result
 }
 
 func (allMetrics allAgentMetrics) PrepareMetrics() {
 	for _, agentMetrics := range allMetrics {
-		for metricName, list := range agentMetrics.MetricCounters {
-			if len(list) > 0 {
+		names := make([]string, 0, len(agentMetrics.Aggregates))
+		for metricName := range agentMetrics.Aggregates {
+			names = append(names, metricName)
+		}
+		sort.Strings(names)
+		for _, metricName := range names {
+			aggregate := agentMetrics.Aggregates[metricName]
+			if aggregate.count > 0 {
 				summary := agentMetrics.CreateAndGetSummary(metricName)
-				summary.Count = uint32(len(list))
-				summary.Average = avgMetricArray(list)
-				summary.Max = maxDataPoint(list)
-				summary.P95 = calcP95(list)
-				summary.Sum = sumNums(list)
+				summary.Count = aggregate.count
+				summary.Average = avgMetric(aggregate.sum, aggregate.count)
+				summary.Max = aggregate.max
+				summary.P95 = calcP95(aggregate.samples)
+				summary.Sum = aggregate.sum
 			}
 		}
 	}
 }
 
-func avgMetricArray(data []uint32) float64 {
-	sum := decimal.NewFromInt(0)
-	for _, dataPoint := range data {
-		sum = sum.Add(decimal.NewFromInt32(int32(dataPoint)))
-	}
-	f, _ := sum.Div(decimal.NewFromInt32(int32(len(data)))).Round(2).Float64()
+func avgMetric(sum float64, count uint32) float64 {
+	f, _ := decimal.NewFromFloat(sum).Div(decimal.NewFromInt(int64(count))).Round(2).Float64()
 	return f
 }
 
-func maxDataPoint(data []uint32) float64 {
-	var max float64
-	for _, dataPoint := range data {
-		if float64(dataPoint) > max {
-			max = float64(dataPoint)
-		}
-	}
-	return max
-}
-
 func calcP95(data []uint32) float64 {
 	switch len(data) {
 	case 0:
@@ -192,10 +267,3 @@ func calcP95(data []uint32) float64 {
 	})
 	return float64(data[k95-1])
 }
-
-func sumNums(data []uint32) (n float64) {
-	for _, d := range data {
-		n += float64(d)
-	}
-	return
-}
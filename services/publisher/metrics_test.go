@@ -86,3 +86,66 @@ func TestAgentMetricsAggregator_math(t *testing.T) {
 	}
 
 }
+
+func testMetric(t time.Time, value float64) *protocol.AgentMetric {
+	return &protocol.AgentMetric{
+		AgentId:   "agentID",
+		Timestamp: utils.FormatTime(t),
+		Name:      "test.metric",
+		Value:     value,
+	}
+}
+
+func TestAgentMetricsAggregator_incrementalFlush(t *testing.T) {
+	aggregator := publisher.NewMetricsAggregator(10 * time.Second)
+	closedTime := time.Now().Add(-time.Minute)
+	openTime := time.Now().Add(time.Minute)
+	assert.NoError(t, aggregator.AddAgentMetrics(&protocol.AgentMetricList{
+		Metrics: []*protocol.AgentMetric{testMetric(closedTime, 1), testMetric(openTime, 2)},
+	}))
+	assert.Equal(t, 2, aggregator.OpenBuckets())
+
+	res, flushed := aggregator.TryFlush()
+	assert.True(t, flushed)
+	assert.Len(t, res, 1)
+	assert.Equal(t, utils.FormatTime(aggregator.FindClosestBucketTime(closedTime)), res[0].Timestamp)
+	assert.Equal(t, 1, aggregator.OpenBuckets())
+
+	_, flushed = aggregator.TryFlush()
+	assert.False(t, flushed)
+
+	res = aggregator.ForceFlush()
+	assert.Len(t, res, 1)
+	assert.Equal(t, float64(2), res[0].Metrics[0].Sum)
+	assert.Equal(t, 0, aggregator.OpenBuckets())
+}
+
+func TestAgentMetricsAggregator_limits(t *testing.T) {
+	aggregator := publisher.NewMetricsAggregator(10*time.Second).WithLimits(2, 3)
+
+	// the oldest of the future buckets is closed early
+	var metrics []*protocol.AgentMetric
+	for i := 1; i <= 3; i++ {
+		metrics = append(metrics, testMetric(testNow.Add(time.Duration(i)*time.Hour), float64(i)))
+	}
+	// the percentiles are calculated from the samples but the other values are exact
+	for i := 1; i <= 100; i++ {
+		metrics = append(metrics, testMetric(testNow.Add(3*time.Hour), float64(i)))
+	}
+	assert.NoError(t, aggregator.AddAgentMetrics(&protocol.AgentMetricList{Metrics: metrics}))
+	assert.Equal(t, 3, aggregator.OpenBuckets())
+
+	res, flushed := aggregator.TryFlush()
+	assert.True(t, flushed)
+	assert.Len(t, res, 1)
+	assert.Equal(t, float64(1), res[0].Metrics[0].Sum)
+
+	res = aggregator.ForceFlush()
+	assert.Len(t, res, 2)
+	summary := res[1].Metrics[0]
+	assert.Equal(t, uint32(101), summary.Count)
+	assert.Equal(t, float64(5053), summary.Sum)
+	assert.Equal(t, float64(100), summary.Max)
+	assert.Equal(t, 50.03, summary.Average)
+	assert.LessOrEqual(t, summary.P95, float64(100))
+}
@@ -0,0 +1,194 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/clients/webhook/client/models"
+	"github.com/forta-network/forta-core-go/clients/webhook/client/operations"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-core-go/protocol/transform"
+	"github.com/forta-network/forta-core-go/security"
+	"github.com/forta-network/forta-core-go/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	privateAlertsBufferSize   = 1000
+	privateAlertsSendInterval = time.Second * 5
+	privateAlertsSendLimit    = 100
+)
+
+// isPrivateAlert tells if the finding is private or the bot made the whole response private.
+func isPrivateAlert(notif *protocol.NotifyRequest) bool {
+	if notif.SignedAlert == nil || notif.SignedAlert.Alert == nil || notif.SignedAlert.Alert.Finding == nil {
+		return false
+	}
+	// default at per-finding level
+	if notif.SignedAlert.Alert.Finding.Private {
+		return true
+	}
+	// if public, let a private override at response-level win
+	switch {
+	case notif.EvalBlockResponse != nil:
+		return notif.EvalBlockResponse.Private
+	case notif.EvalTxResponse != nil:
+		return notif.EvalTxResponse.Private
+	case notif.EvalAlertResponse != nil:
+		return notif.EvalAlertResponse.Private
+	}
+	return false
+}
+
+// privateAlertRouter sends the private findings only to the operator endpoint.
+type privateAlertRouter struct {
+	client  LocalAlertClient
+	key     *keystore.Key
+	chainID uint64
+	alertCh chan *models.Alert
+
+	routed  uint64 // accessed atomically
+	sent    uint64 // accessed atomically
+	dropped uint64 // accessed atomically
+
+	lastSend    health.TimeTracker
+	lastSendErr health.ErrorTracker
+}
+
+func newPrivateAlertRouter(client LocalAlertClient, key *keystore.Key, chainID int) *privateAlertRouter {
+	return &privateAlertRouter{
+		client:  client,
+		key:     key,
+		chainID: uint64(chainID),
+		alertCh: make(chan *models.Alert, privateAlertsBufferSize),
+	}
+}
+
+// Route queues the private finding of the notification to be sent to the operator endpoint. It never
+// blocks the batching: the finding is dropped and counted if the operator endpoint falls behind.
+func (router *privateAlertRouter) Route(notif *protocol.NotifyRequest) error {
+	alert := notif.SignedAlert.Alert
+	var webhookAlert *models.Alert
+	switch {
+	case notif.EvalBlockRequest != nil:
+		event := notif.EvalBlockRequest.Event
+		blockNumber, err := hexutil.DecodeUint64(event.BlockNumber)
+		if err != nil {
+			return fmt.Errorf("invalid block number '%s': %v", event.BlockNumber, err)
+		}
+		webhookAlert = transform.ToWebhookAlert(alert, router.chainID, &protocol.Block{
+			BlockHash:      event.BlockHash,
+			BlockNumber:    blockNumber,
+			BlockTimestamp: event.Block.Timestamp,
+		}, nil, nil)
+	case isPendingTx(notif):
+		webhookAlert = transform.ToWebhookAlert(alert, router.chainID, nil, notif.EvalTxRequest.Event, nil)
+	case notif.EvalTxRequest != nil:
+		event := notif.EvalTxRequest.Event
+		blockNumber, err := hexutil.DecodeUint64(event.Block.BlockNumber)
+		if err != nil {
+			return fmt.Errorf("invalid block number '%s': %v", event.Block.BlockNumber, err)
+		}
+		webhookAlert = transform.ToWebhookAlert(alert, router.chainID, &protocol.Block{
+			BlockHash:      event.Block.BlockHash,
+			BlockNumber:    blockNumber,
+			BlockTimestamp: event.Block.BlockTimestamp,
+		}, event, nil)
+	case notif.EvalAlertRequest != nil:
+		webhookAlert = transform.ToWebhookAlert(alert, router.chainID, nil, nil, notif.EvalAlertRequest.Event)
+	default:
+		return nil
+	}
+	select {
+	case router.alertCh <- webhookAlert:
+		atomic.AddUint64(&router.routed, 1)
+	default:
+		atomic.AddUint64(&router.dropped, 1)
+		log.WithField("alert", alert.Id).Warn("private alert buffer is full - dropping the private alert")
+	}
+	return nil
+}
+
+func (router *privateAlertRouter) sendLoop(ctx context.Context) {
+	ticker := time.NewTicker(privateAlertsSendInterval)
+	defer ticker.Stop()
+	var pending models.AlertList
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	drain:
+		for len(pending) < privateAlertsSendLimit {
+			select {
+			case alert := <-router.alertCh:
+				pending = append(pending, alert)
+			default:
+				break drain
+			}
+		}
+		if len(pending) == 0 {
+			continue
+		}
+		// keep the pending alerts to retry at the next tick if sending fails
+		err := router.send(ctx, pending)
+		router.lastSendErr.Set(err)
+		if err != nil {
+			log.WithError(err).WithField("alertCount", len(pending)).Error("failed to send the private alerts")
+			continue
+		}
+		router.lastSend.Set()
+		atomic.AddUint64(&router.sent, uint64(len(pending)))
+		log.WithField("alertCount", len(pending)).Info("sent the private alerts to the operator endpoint")
+		pending = nil
+	}
+}
+
+func (router *privateAlertRouter) send(ctx context.Context, alerts models.AlertList) error {
+	scannerJwt, err := security.CreateScannerJWT(
+		router.key, map[string]interface{}{
+			"private": "true",
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create the scanner jwt: %v", err)
+	}
+	_, err = router.client.SendAlerts(
+		&operations.SendAlertsParams{
+			Context:       ctx,
+			Payload:       &models.AlertBatch{Alerts: alerts},
+			Authorization: utils.StringPtr(fmt.Sprintf("Bearer %s", scannerJwt)),
+		},
+	)
+	return err
+}
+
+// Health implements the health.Reporter interface.
+func (router *privateAlertRouter) Health() health.Reports {
+	return health.Reports{
+		{
+			Name:    "findings.private.routed",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&router.routed), 10),
+		},
+		{
+			Name:    "findings.private.sent",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&router.sent), 10),
+		},
+		{
+			Name:    "findings.private.dropped",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&router.dropped), 10),
+		},
+		router.lastSend.GetReport("event.private-send.time"),
+		router.lastSendErr.GetReport("event.private-send.error"),
+	}
+}
@@ -0,0 +1,133 @@
+package publisher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/forta-network/forta-core-go/clients/webhook/client/models"
+	"github.com/forta-network/forta-core-go/clients/webhook/client/operations"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+type testPrivateAlertClient struct {
+	batches []*models.AlertBatch
+}
+
+func (client *testPrivateAlertClient) SendAlerts(params *operations.SendAlertsParams, opts ...operations.ClientOption) (*operations.SendAlertsOK, error) {
+	client.batches = append(client.batches, params.Payload)
+	return &operations.SendAlertsOK{}, nil
+}
+
+func testPrivateNotif(private bool) *protocol.NotifyRequest {
+	return &protocol.NotifyRequest{
+		SignedAlert: &protocol.SignedAlert{
+			Alert: &protocol.Alert{
+				Id:      "alertId",
+				Finding: &protocol.Finding{Private: private},
+				Agent:   &protocol.AgentInfo{Id: "0x1234"},
+			},
+		},
+		EvalBlockRequest: &protocol.EvaluateBlockRequest{
+			Event: &protocol.BlockEvent{
+				BlockHash:   "0xabcd",
+				BlockNumber: "0x1",
+				Block:       &protocol.BlockEvent_EthBlock{Timestamp: "0x1"},
+			},
+		},
+		EvalBlockResponse: &protocol.EvaluateBlockResponse{},
+		AgentInfo:         &protocol.AgentInfo{Id: "0x1234", Manifest: "agentInfo"},
+	}
+}
+
+func TestIsPrivateAlert(t *testing.T) {
+	r := require.New(t)
+
+	r.False(isPrivateAlert(&protocol.NotifyRequest{}))
+	r.False(isPrivateAlert(testPrivateNotif(false)))
+	r.True(isPrivateAlert(testPrivateNotif(true)))
+
+	notif := testPrivateNotif(false)
+	notif.EvalBlockResponse.Private = true
+	r.True(isPrivateAlert(notif))
+}
+
+func TestPrivateAlertRouting(t *testing.T) {
+	r := require.New(t)
+
+	privateKey, err := crypto.GenerateKey()
+	r.NoError(err)
+	key := &keystore.Key{PrivateKey: privateKey, Address: crypto.PubkeyToAddress(privateKey.PublicKey)}
+
+	client := &testPrivateAlertClient{}
+	router := newPrivateAlertRouter(client, key, 1)
+
+	pub := &Publisher{
+		batchTuner:        newBatchTuner(config.BatchAutoTuneConfig{}, time.Millisecond*100, defaultBatchLimit),
+		batchTicker:       time.NewTicker(time.Millisecond * 100),
+		quota:             newFindingQuota(config.FindingQuotaConfig{}),
+		sampler:           newFindingSampler(config.FindingSamplingConfig{}),
+		severityFloor:     newSeverityFloor(config.FindingSeverityConfig{}),
+		orphaned:          newOrphanedBlocks(),
+		metricsAggregator: NewMetricsAggregator(time.Minute),
+		notifCh:           make(chan *protocol.NotifyRequest, 2),
+		batchCh:           make(chan *protocol.AlertBatch, 1),
+		privateRouter:     router,
+	}
+	defer pub.batchTicker.Stop()
+
+	pub.notifCh <- testPrivateNotif(true)
+	pub.notifCh <- testPrivateNotif(false)
+	pub.prepareLatestBatch()
+	batch := <-pub.batchCh
+
+	// only the public finding is in the batch but the bot is still included
+	r.Equal(uint32(1), batch.AlertCount)
+	r.Empty(batch.PrivateAlerts)
+	r.Len(batch.Agents, 1)
+
+	r.Len(router.alertCh, 1)
+	r.NoError(router.send(context.Background(), models.AlertList{<-router.alertCh}))
+	r.Len(client.batches, 1)
+	r.Len(client.batches[0].Alerts, 1)
+	r.Equal("alertId", client.batches[0].Alerts[0].Hash)
+
+	reports := router.Health()
+	routed, ok := reports.GetByName("findings.private.routed")
+	r.True(ok)
+	r.Equal("1", routed.Details)
+}
+
+func TestPrivateAlertRouting_BufferFull(t *testing.T) {
+	r := require.New(t)
+
+	router := newPrivateAlertRouter(&testPrivateAlertClient{}, nil, 1)
+	router.alertCh = make(chan *models.Alert, 1)
+
+	// the second finding should be dropped without blocking
+	r.NoError(router.Route(testPrivateNotif(true)))
+	r.NoError(router.Route(testPrivateNotif(true)))
+	r.Len(router.alertCh, 1)
+
+	reports := router.Health()
+	routed, ok := reports.GetByName("findings.private.routed")
+	r.True(ok)
+	r.Equal("1", routed.Details)
+	dropped, ok := reports.GetByName("findings.private.dropped")
+	r.True(ok)
+	r.Equal("1", dropped.Details)
+}
+
+func TestPrivateAlertRouting_InvalidBlockNumber(t *testing.T) {
+	r := require.New(t)
+
+	router := newPrivateAlertRouter(&testPrivateAlertClient{}, nil, 1)
+	notif := testPrivateNotif(true)
+	notif.EvalBlockRequest.Event.BlockNumber = "1"
+	r.Error(router.Route(notif))
+	r.Len(router.alertCh, 0)
+}
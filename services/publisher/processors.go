@@ -0,0 +1,180 @@
+package publisher
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-core-go/protocol/settings"
+	"github.com/forta-network/forta-node/config"
+	"gopkg.in/yaml.v3"
+)
+
+const redactedValue = "REDACTED"
+
+// findingProcessor modifies a finding before it is batched. The processors must not modify
+// the signed parts of the alert.
+type findingProcessor interface {
+	Name() string
+	Process(alert *protocol.Alert)
+}
+
+// processorChain runs the processors in order.
+type processorChain []findingProcessor
+
+// Process runs all processors on the alert finding.
+func (chain processorChain) Process(alert *protocol.Alert) {
+	if alert == nil || alert.Finding == nil {
+		return
+	}
+	for _, processor := range chain {
+		processor.Process(alert)
+	}
+}
+
+// String returns the processor names in order.
+func (chain processorChain) String() string {
+	var names []string
+	for _, processor := range chain {
+		names = append(names, processor.Name())
+	}
+	return strings.Join(names, ", ")
+}
+
+// newProcessorChain creates the processors which are enabled in the config.
+func newProcessorChain(cfg config.FindingProcessorsConfig, chainID int, fortaDir string) (processorChain, error) {
+	var chain processorChain
+	for _, name := range cfg.Enable {
+		switch name {
+		case config.FindingProcessorAddressLabels:
+			labelsFile := cfg.AddressLabelsFile
+			if len(labelsFile) == 0 {
+				return nil, fmt.Errorf("%s processor requires publish.processors.addressLabelsFile", name)
+			}
+			if !path.IsAbs(labelsFile) {
+				labelsFile = path.Join(fortaDir, labelsFile)
+			}
+			processor, err := newAddressLabelsProcessor(labelsFile)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, processor)
+
+		case config.FindingProcessorChainMetadata:
+			chain = append(chain, newChainMetadataProcessor(chainID))
+
+		case config.FindingProcessorRedact:
+			chain = append(chain, &redactProcessor{fields: cfg.RedactFields})
+
+		default:
+			return nil, fmt.Errorf("unknown finding processor: %s", name)
+		}
+	}
+	return chain, nil
+}
+
+// addressLabelsProcessor labels the finding addresses which are in the local list.
+type addressLabelsProcessor struct {
+	labels map[string]string
+}
+
+func newAddressLabelsProcessor(labelsFile string) (*addressLabelsProcessor, error) {
+	b, err := ioutil.ReadFile(labelsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the address labels file: %v", err)
+	}
+	var labels map[string]string
+	if err := yaml.Unmarshal(b, &labels); err != nil {
+		return nil, fmt.Errorf("failed to decode the address labels file: %v", err)
+	}
+	processor := &addressLabelsProcessor{labels: make(map[string]string)}
+	for address, label := range labels {
+		processor.labels[strings.ToLower(address)] = label
+	}
+	return processor, nil
+}
+
+func (processor *addressLabelsProcessor) Name() string {
+	return config.FindingProcessorAddressLabels
+}
+
+func (processor *addressLabelsProcessor) Process(alert *protocol.Alert) {
+	for _, address := range alert.Finding.Addresses {
+		label, ok := processor.labels[strings.ToLower(address)]
+		if !ok {
+			continue
+		}
+		alert.Finding.Labels = append(alert.Finding.Labels, &protocol.Label{
+			EntityType: protocol.Label_ADDRESS,
+			Entity:     address,
+			Label:      label,
+			Confidence: 1,
+		})
+	}
+}
+
+// chainMetadataProcessor adds the chain info to the finding metadata.
+type chainMetadataProcessor struct {
+	chainID   string
+	chainName string
+}
+
+func newChainMetadataProcessor(chainID int) *chainMetadataProcessor {
+	return &chainMetadataProcessor{
+		chainID:   strconv.Itoa(chainID),
+		chainName: settings.GetChainSettings(chainID).Name,
+	}
+}
+
+func (processor *chainMetadataProcessor) Name() string {
+	return config.FindingProcessorChainMetadata
+}
+
+func (processor *chainMetadataProcessor) Process(alert *protocol.Alert) {
+	if alert.Finding.Metadata == nil {
+		alert.Finding.Metadata = make(map[string]string)
+	}
+	alert.Finding.Metadata["forta.chainId"] = processor.chainID
+	alert.Finding.Metadata["forta.chainName"] = processor.chainName
+}
+
+// redactProcessor replaces the configured finding fields.
+type redactProcessor struct {
+	fields []string
+}
+
+func (processor *redactProcessor) Name() string {
+	return config.FindingProcessorRedact
+}
+
+func (processor *redactProcessor) Process(alert *protocol.Alert) {
+	for _, field := range processor.fields {
+		switch {
+		case field == "description":
+			alert.Finding.Description = redactedValue
+		case field == "addresses":
+			alert.Finding.Addresses = nil
+			// the bloom filter can tell if an address was in the finding
+			alert.AddressBloomFilter = nil
+		case strings.HasPrefix(field, "metadata."):
+			key := strings.TrimPrefix(field, "metadata.")
+			if _, ok := alert.Finding.Metadata[key]; ok {
+				alert.Finding.Metadata[key] = redactedValue
+			}
+		}
+	}
+}
+
+func (pub *Publisher) processorsReport() *health.Report {
+	pub.processorsMu.RLock()
+	defer pub.processorsMu.RUnlock()
+	return &health.Report{
+		Name:    "findings.processors",
+		Status:  health.StatusInfo,
+		Details: pub.processors.String(),
+	}
+}
@@ -0,0 +1,83 @@
+package publisher
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessorChain(t *testing.T) {
+	r := require.New(t)
+
+	dir := t.TempDir()
+	r.NoError(ioutil.WriteFile(path.Join(dir, "labels.yml"), []byte("0xABCD: exchange\n0x1234: bridge\n"), 0600))
+
+	chain, err := newProcessorChain(config.FindingProcessorsConfig{
+		Enable: []string{
+			config.FindingProcessorAddressLabels,
+			config.FindingProcessorChainMetadata,
+			config.FindingProcessorRedact,
+		},
+		AddressLabelsFile: "labels.yml",
+		RedactFields:      []string{"description", "metadata.secret", "metadata.missing"},
+	}, 1, dir)
+	r.NoError(err)
+	r.Equal("address-labels, chain-metadata, redact", chain.String())
+
+	alert := &protocol.Alert{
+		Id:       "0x1",
+		Metadata: map[string]string{"signed": "value"},
+		Finding: &protocol.Finding{
+			Description: "some description",
+			Addresses:   []string{"0xabcd", "0x5678"},
+			Metadata:    map[string]string{"secret": "value", "other": "value"},
+		},
+	}
+	chain.Process(alert)
+
+	r.Len(alert.Finding.Labels, 1)
+	r.Equal("0xabcd", alert.Finding.Labels[0].Entity)
+	r.Equal("exchange", alert.Finding.Labels[0].Label)
+	r.Equal(protocol.Label_ADDRESS, alert.Finding.Labels[0].EntityType)
+
+	r.Equal("1", alert.Finding.Metadata["forta.chainId"])
+	r.NotEmpty(alert.Finding.Metadata["forta.chainName"])
+
+	r.Equal(redactedValue, alert.Finding.Description)
+	r.Equal(redactedValue, alert.Finding.Metadata["secret"])
+	r.Equal("value", alert.Finding.Metadata["other"])
+	r.NotContains(alert.Finding.Metadata, "missing")
+
+	// the signed alert fields are not modified
+	r.Equal(map[string]string{"signed": "value"}, alert.Metadata)
+	r.Equal("0x1", alert.Id)
+
+	// no panics without a finding
+	chain.Process(&protocol.Alert{})
+}
+
+func TestProcessorChain_Errors(t *testing.T) {
+	r := require.New(t)
+
+	_, err := newProcessorChain(config.FindingProcessorsConfig{
+		Enable: []string{config.FindingProcessorAddressLabels},
+	}, 1, t.TempDir())
+	r.Error(err)
+
+	_, err = newProcessorChain(config.FindingProcessorsConfig{
+		Enable:            []string{config.FindingProcessorAddressLabels},
+		AddressLabelsFile: "missing.yml",
+	}, 1, t.TempDir())
+	r.Error(err)
+
+	_, err = newProcessorChain(config.FindingProcessorsConfig{Enable: []string{"unknown"}}, 1, "")
+	r.Error(err)
+
+	chain, err := newProcessorChain(config.FindingProcessorsConfig{}, 1, "")
+	r.NoError(err)
+	r.Empty(chain)
+}
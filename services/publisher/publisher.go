@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
 	"os"
 	"path"
+	"strconv"
 	"sync"
 	"time"
 
@@ -30,6 +32,8 @@ import (
 	"github.com/forta-network/forta-node/clients/messaging"
 	"github.com/forta-network/forta-node/clients/storagegrpc"
 	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/metrics"
+	"github.com/forta-network/forta-node/services"
 	"github.com/forta-network/forta-node/services/publisher/webhooklog"
 	"github.com/forta-network/forta-node/services/storage"
 	"github.com/forta-network/forta-node/store"
@@ -59,6 +63,10 @@ type Publisher struct {
 	messageClient     clients.MessageClient
 	alertClient       clients.AlertAPIClient
 	localAlertClient  LocalAlertClient
+	privateRouter     *privateAlertRouter
+	cosigner          *batchCosigner
+	exporter          *batchExporter
+	findingIndex      *findingIndex
 
 	batchRefStore    store.StringStore
 	lastReceiptStore store.StringStore
@@ -69,7 +77,14 @@ type Publisher struct {
 	skipEmpty     bool
 	skipPublish   bool
 	batchInterval time.Duration
-	batchLimit    int
+	batchTuner    *batchTuner
+	quota         *findingQuota
+	sampler       *findingSampler
+	severityFloor *severityFloor
+	orphaned      *orphanedBlocks
+	canary        *canaryTracker
+	processors    processorChain
+	processorsMu  sync.RWMutex
 	latestChainID uint64
 	notifCh       chan *protocol.NotifyRequest
 	batchCh       chan *protocol.AlertBatch
@@ -95,6 +110,8 @@ type Publisher struct {
 
 	latestInspectionResults   *protocol.InspectionResults
 	latestInspectionResultsMu sync.RWMutex
+
+	pause services.PauseState
 }
 
 // LocalAlertClient sends the local alerts.
@@ -176,12 +193,6 @@ func (pub *Publisher) publishNextBatch(batch *protocol.AlertBatch) (published bo
 		return false, fmt.Errorf("failed to build envelope: %v", err)
 	}
 
-	var buf bytes.Buffer
-	if err = json.NewEncoder(&buf).Encode(signedBatch); err != nil {
-		return false, fmt.Errorf("failed to encode the signed alert: %v", err)
-	}
-	log.Tracef("alert payload: %s", string(buf.Bytes()))
-
 	if pub.skipPublish {
 		const reason = "skipping batch, because skipPublish is enabled"
 		log.WithFields(
@@ -204,15 +215,38 @@ func (pub *Publisher) publishNextBatch(batch *protocol.AlertBatch) (published bo
 		return false, nil
 	}
 
+	cosignClaims, cosignatures, err := pub.cosignBatch(batch, signedBatch)
+	if err != nil {
+		return false, fmt.Errorf("failed to cosign the batch: %v", err)
+	}
+
+	// the invalidations are published with the next batch if this one fails
+	invalidations := pub.orphaned.TakeInvalidations()
+	defer func() {
+		if !published {
+			pub.orphaned.RestoreInvalidations(invalidations)
+		}
+	}()
+
+	var buf bytes.Buffer
+	if err = json.NewEncoder(&buf).Encode(&cosignedBatch{
+		SignedPayload: signedBatch,
+		Cosignatures:  cosignatures,
+		Invalidations: invalidations,
+	}); err != nil {
+		return false, fmt.Errorf("failed to encode the signed alert: %v", err)
+	}
+	log.Tracef("alert payload: %s", string(buf.Bytes()))
+
 	pub.lastBatchReadyMu.RLock()
 	pub.lastBatchSendAttempt = pub.lastBatchReady
 	pub.lastBatchReadyMu.RUnlock()
 
 	if pub.cfg.Config.LocalModeConfig.Enable {
 		scannerJwt, err := security.CreateScannerJWT(
-			pub.cfg.Key, map[string]interface{}{
+			pub.cfg.Key, withClaims(map[string]interface{}{
 				"localMode": "true",
-			},
+			}, cosignClaims),
 		)
 		alertBatch := transform.ToWebhookAlertBatch(batch)
 		if !pub.cfg.Config.LocalModeConfig.IncludeMetrics {
@@ -238,6 +272,7 @@ func (pub *Publisher) publishNextBatch(batch *protocol.AlertBatch) (published bo
 				},
 			).Info("successfully sent local mode alerts")
 		}
+		pub.indexFindings(batch, "")
 		return true, nil
 	}
 
@@ -285,9 +320,9 @@ func (pub *Publisher) publishNextBatch(batch *protocol.AlertBatch) (published bo
 	}
 
 	scannerJwt, err := security.CreateScannerJWT(
-		pub.cfg.Key, map[string]interface{}{
+		pub.cfg.Key, withClaims(map[string]interface{}{
 			"batch": cid,
-		},
+		}, cosignClaims),
 	)
 
 	if err != nil {
@@ -313,6 +348,10 @@ func (pub *Publisher) publishNextBatch(batch *protocol.AlertBatch) (published bo
 		return false, fmt.Errorf("failed to send the alert tx: %v", err)
 	}
 
+	if pub.exporter != nil {
+		pub.exporter.QueueBatch(batch.ChainId, cid, buf.Bytes())
+	}
+
 	if resp.SignedReceipt != nil {
 		// store off receipt id
 		if err := pub.lastReceiptStore.Put(resp.ReceiptID); err != nil {
@@ -350,13 +389,27 @@ func (pub *Publisher) publishNextBatch(batch *protocol.AlertBatch) (published bo
 				"storedReceiptPath": putResp.ContentPath,
 			})
 		}
+
+		if pub.exporter != nil {
+			pub.exporter.QueueReceipt(batch.ChainId, cid, b)
+		}
 	}
 
 	logger.Info("alert batch")
+	pub.indexFindings(batch, cid)
 
 	return true, nil
 }
 
+func (pub *Publisher) indexFindings(batch *protocol.AlertBatch, ref string) {
+	if pub.findingIndex == nil {
+		return
+	}
+	if err := pub.findingIndex.Record(batch, ref, time.Now()); err != nil {
+		log.WithError(err).Warn("failed to index the published findings")
+	}
+}
+
 func (pub *Publisher) shouldSkipPublishing(batch *protocol.AlertBatch) (string, bool) {
 	if pub.cfg.PublisherConfig.AlwaysPublish {
 		return "", false
@@ -411,8 +464,26 @@ func (pub *Publisher) registerMessageHandlers() {
 	pub.messageClient.Subscribe(messaging.SubjectMetricAgent, messaging.AgentMetricHandler(pub.metricsAggregator.AddAgentMetrics))
 	pub.messageClient.Subscribe(messaging.SubjectScannerBlock, messaging.ScannerHandler(pub.handleScannerBlock))
 	pub.messageClient.Subscribe(messaging.SubjectScannerAlert, messaging.ScannerHandler(pub.handleScannerAlert))
+	pub.messageClient.Subscribe(messaging.SubjectScannerReorg, messaging.ReorgHandler(pub.orphaned.Add))
 	pub.messageClient.Subscribe(messaging.SubjectInspectionDone, messaging.InspectionResultsHandler(pub.handleInspectionResults))
 	pub.messageClient.Subscribe(messaging.SubjectAgentsVersionsLatest, messaging.AgentsHandler(pub.handleAgentVersionsUpdate))
+	pub.messageClient.Respond(messaging.SubjectPublisherStatusRequest, messaging.PublisherStatusHandler(pub.handleStatusRequest))
+	pub.messageClient.Respond(messaging.SubjectPublisherReadyRequest, messaging.ReadinessHandler(pub.handleReadyRequest))
+}
+
+func (pub *Publisher) handleStatusRequest() (*messaging.PublisherStatus, error) {
+	return &messaging.PublisherStatus{
+		PendingNotifications: len(pub.notifCh),
+		PendingBatches:       len(pub.batchCh),
+	}, nil
+}
+
+// handleReadyRequest responds after the publisher starts, since the handlers are registered at the start.
+func (pub *Publisher) handleReadyRequest() (*messaging.Readiness, error) {
+	if len(pub.batchCh) == cap(pub.batchCh) {
+		return &messaging.Readiness{Reason: "batch queue is full"}, nil
+	}
+	return &messaging.Readiness{Ready: true}, nil
 }
 
 func (pub *Publisher) handleAgentVersionsUpdate(payload messaging.AgentPayload) error {
@@ -455,10 +526,16 @@ func (pub *Publisher) handleInspectionResults(payload *protocol.InspectionResult
 
 func (pub *Publisher) publishBatches() {
 	for batch := range pub.batchCh {
+		// hold the batches until resumed
+		if err := pub.pause.WaitResumed(pub.ctx); err != nil {
+			return
+		}
 		pub.lastBatchPublishAttempt.Set()
+		publishStart := time.Now()
 		published, err := pub.publishNextBatch(batch)
 		if published {
 			pub.lastBatchPublish.Set()
+			pub.batchTuner.ObservePublish(time.Since(publishStart))
 		}
 		pub.lastBatchPublishErr.Set(err)
 		if err != nil {
@@ -499,30 +576,19 @@ func (bd *BatchData) GetPrivateAlerts(notif *protocol.NotifyRequest) *protocol.A
 	return res
 }
 
+// isPendingTx tells if the notification is about a transaction from the mempool. The scanner sends
+// the pending transactions without a block.
+func isPendingTx(notif *protocol.NotifyRequest) bool {
+	return notif.EvalTxRequest != nil && len(notif.EvalTxRequest.Event.GetBlock().GetBlockNumber()) == 0
+}
+
 // AppendAlert adds the alert to the relevant list.
 func (bd *BatchData) AppendAlert(notif *protocol.NotifyRequest) {
 	isBlockAlert := notif.EvalBlockRequest != nil
 	isTxAlert := notif.EvalTxRequest != nil
 	isCombinationAlert := notif.EvalAlertRequest != nil
 
-	var isPrivate bool
-
-	if notif.SignedAlert != nil && notif.SignedAlert.Alert != nil && notif.SignedAlert.Alert.Finding != nil {
-		// default at per-finding level
-		isPrivate = notif.SignedAlert.Alert.Finding.Private
-
-		// if public, let a private override at response-level win
-		if !isPrivate {
-			if notif.EvalBlockResponse != nil {
-				isPrivate = notif.EvalBlockResponse.Private
-			} else if notif.EvalTxResponse != nil {
-				isPrivate = notif.EvalTxResponse.Private
-			} else if notif.EvalAlertResponse.Private {
-				isPrivate = notif.EvalAlertResponse.Private
-			}
-		}
-	}
-
+	isPrivate := isPrivateAlert(notif)
 	hasAlert := notif.SignedAlert != nil
 
 	var agentAlerts *protocol.AgentAlerts
@@ -537,6 +603,14 @@ func (bd *BatchData) AppendAlert(notif *protocol.NotifyRequest) {
 		if hasAlert {
 			agentAlerts = (*BlockResults)(blockRes).GetAgentAlerts(notif.AgentInfo)
 		}
+	} else if isPendingTx(notif) {
+		// the pending transactions are grouped under the zero block and their alerts are tagged as pending
+		bd.AddBatchAgent(notif.AgentInfo, 0, notif.EvalTxRequest.Event.Transaction.Hash, "")
+		blockRes := bd.GetBlockResults("", 0, "")
+		if hasAlert {
+			txRes := (*BlockResults)(blockRes).GetTransactionResults(notif.EvalTxRequest.Event)
+			agentAlerts = (*TransactionResults)(txRes).GetAgentAlerts(notif.AgentInfo)
+		}
 	} else if isTxAlert {
 		blockNum := hexutil.MustDecodeUint64(notif.EvalTxRequest.Event.Block.BlockNumber)
 		bd.AddBatchAgent(notif.AgentInfo, blockNum, notif.EvalTxRequest.Event.Receipt.TransactionHash, "")
@@ -594,6 +668,12 @@ func (bd *BatchData) AddBatchAgent(agent *protocol.AgentInfo, blockNumber uint64
 		return
 	}
 
+	// a pending transaction
+	if len(txHash) > 0 {
+		batchAgent.Transactions = append(batchAgent.Transactions, txHash)
+		return
+	}
+
 	if subscription != "" {
 		var alreadyAddedCombinationAlert bool
 		for _, addedAlertSubscription := range batchAgent.Combinations {
@@ -712,13 +792,65 @@ func (pub *Publisher) prepareLatestBatch() {
 		batchTime time.Time
 		i         int
 	)
-	for i < pub.batchLimit {
+	batchLimit := pub.batchTuner.Size()
+	for i < batchLimit {
 		select {
 		case notif := <-pub.notifCh:
+			// the canary findings only measure the pipeline and they are never published
+			if isCanaryNotif(notif) {
+				if pub.canary != nil {
+					pub.canary.Observe(notif, time.Now())
+				}
+				continue
+			}
+
 			alert := notif.SignedAlert
 			hasAlert := alert != nil
+			if hasAlert && !pub.severityFloor.Keep(notif.AgentInfo.Id, alert.Alert.Finding.Severity) {
+				pub.metricsAggregator.AddAgentMetrics(&protocol.AgentMetricList{
+					Metrics: []*protocol.AgentMetric{
+						metrics.CreateAgentMetric(notif.AgentInfo.Id, metrics.MetricFindingsBelowSev, 1),
+					},
+				})
+				// still include the bot in the batch without the finding
+				notif.SignedAlert = nil
+				alert = nil
+				hasAlert = false
+			}
+			if hasAlert && !pub.sampler.Keep(notif.AgentInfo.Id, alert.Alert.Finding.Severity) {
+				pub.metricsAggregator.AddAgentMetrics(&protocol.AgentMetricList{
+					Metrics: []*protocol.AgentMetric{
+						metrics.CreateAgentMetric(notif.AgentInfo.Id, metrics.MetricFindingsSampled, 1),
+					},
+				})
+				// still include the bot in the batch without the finding
+				notif.SignedAlert = nil
+				alert = nil
+				hasAlert = false
+			}
+			if hasAlert && !pub.quota.Allow(notif.AgentInfo.Id, time.Now()) {
+				pub.metricsAggregator.AddAgentMetrics(&protocol.AgentMetricList{
+					Metrics: []*protocol.AgentMetric{
+						metrics.CreateAgentMetric(notif.AgentInfo.Id, metrics.MetricFindingsQuota, 1),
+					},
+				})
+				// still include the bot in the batch without the finding
+				notif.SignedAlert = nil
+				alert = nil
+				hasAlert = false
+			}
 			if hasAlert {
 				log.WithField("alertId", alert.Alert.Id).Debug("publisher received alert")
+				pub.processFinding(alert.Alert)
+			}
+			if hasAlert && pub.privateRouter != nil && isPrivateAlert(notif) {
+				if err := pub.privateRouter.Route(notif); err != nil {
+					log.WithError(err).WithField("alertId", alert.Alert.Id).Warn("failed to route the private alert")
+				}
+				// the private finding never goes to the public batch
+				notif.SignedAlert = nil
+				alert = nil
+				hasAlert = false
 			}
 
 			// Notifications with empty alerts shouldn't be taken into account while limiting the batch.
@@ -727,25 +859,28 @@ func (pub *Publisher) prepareLatestBatch() {
 				i++
 			}
 
-			var blockNum string
-			if notif.EvalBlockRequest != nil {
-				blockNum = notif.EvalBlockRequest.Event.BlockNumber
-			} else if notif.EvalTxRequest != nil {
-				blockNum = notif.EvalTxRequest.Event.Block.BlockNumber
-			} else if notif.EvalAlertRequest != nil {
-				blockNum = hexutil.EncodeUint64(notif.EvalAlertRequest.Event.Alert.Source.Block.Number)
-			}
-
-			notifBlockNum, err := hexutil.DecodeUint64(blockNum)
-			if err != nil {
-				log.Errorf("failed to parse alert notif block number: %v", err)
-				continue
-			}
-			if batch.BlockStart == 0 || (batch.BlockStart > 0 && notifBlockNum < batch.BlockStart) {
-				batch.BlockStart = notifBlockNum
-			}
-			if batch.BlockEnd == 0 || (batch.BlockEnd > 0 && notifBlockNum > batch.BlockEnd) {
-				batch.BlockEnd = notifBlockNum
+			// the pending transactions are not in the block range of the batch
+			if !isPendingTx(notif) {
+				var blockNum string
+				if notif.EvalBlockRequest != nil {
+					blockNum = notif.EvalBlockRequest.Event.BlockNumber
+				} else if notif.EvalTxRequest != nil {
+					blockNum = notif.EvalTxRequest.Event.Block.BlockNumber
+				} else if notif.EvalAlertRequest != nil {
+					blockNum = hexutil.EncodeUint64(notif.EvalAlertRequest.Event.Alert.Source.Block.Number)
+				}
+
+				notifBlockNum, err := hexutil.DecodeUint64(blockNum)
+				if err != nil {
+					log.Errorf("failed to parse alert notif block number: %v", err)
+					continue
+				}
+				if batch.BlockStart == 0 || (batch.BlockStart > 0 && notifBlockNum < batch.BlockStart) {
+					batch.BlockStart = notifBlockNum
+				}
+				if batch.BlockEnd == 0 || (batch.BlockEnd > 0 && notifBlockNum > batch.BlockEnd) {
+					batch.BlockEnd = notifBlockNum
+				}
 			}
 
 			if hasAlert && alert.Alert.Finding.Severity > batch.MaxSeverity {
@@ -762,20 +897,41 @@ func (pub *Publisher) prepareLatestBatch() {
 		}
 	}
 
+	pub.batchTuner.ObserveBatch(i, !timedOut)
 	if !timedOut {
 		batchTime = time.Now()
-		pub.batchTicker.Reset(defaultInterval)
+	}
+	if !timedOut || pub.batchTuner.Enabled() {
+		pub.batchTicker.Reset(pub.batchTuner.Interval())
 	}
 	pub.lastBatchReadyMu.Lock()
 	pub.lastBatchReady = batchTime
 	pub.lastBatchReadyMu.Unlock()
 
+	// the findings about the blocks which were reorged out since they were evaluated
+	if reorgMetrics := pub.orphaned.CountBatch((*protocol.AlertBatch)(batch)); len(reorgMetrics) > 0 {
+		pub.metricsAggregator.AddAgentMetrics(&protocol.AgentMetricList{Metrics: reorgMetrics})
+	}
+
 	pub.batchCh <- (*protocol.AlertBatch)(batch)
 }
 
+// processFinding runs the enabled finding processors.
+func (pub *Publisher) processFinding(alert *protocol.Alert) {
+	pub.processorsMu.RLock()
+	defer pub.processorsMu.RUnlock()
+	pub.processors.Process(alert)
+}
+
 func (pub *Publisher) Start() error {
 	go pub.prepareBatches()
 	go pub.publishBatches()
+	if pub.privateRouter != nil {
+		go pub.privateRouter.sendLoop(pub.ctx)
+	}
+	if pub.exporter != nil {
+		go pub.exporter.exportLoop(pub.ctx)
+	}
 	pub.registerMessageHandlers()
 	return nil
 }
@@ -793,7 +949,7 @@ func (pub *Publisher) Name() string {
 
 // Health implements the health.Reporter interface.
 func (pub *Publisher) Health() health.Reports {
-	return health.Reports{
+	reports := health.Reports{
 		pub.lastBatchPublish.GetReport("event.batch-publish.time"),
 		pub.lastBatchPublishAttempt.GetReport("event.batch-publish-attempt.time"),
 		pub.lastBatchPublishErr.GetReport("event.batch-publish.error"),
@@ -804,11 +960,116 @@ func (pub *Publisher) Health() health.Reports {
 		},
 		pub.lastBatchSkipReason.GetReport("event.batch-skip.reason"),
 		pub.lastMetricsFlush.GetReport("event.metrics-flush.time"),
+		&health.Report{
+			Name:    "metrics.open-buckets",
+			Status:  health.StatusInfo,
+			Details: strconv.Itoa(pub.metricsAggregator.OpenBuckets()),
+		},
+		pub.pause.GetReport("paused"),
+		&health.Report{
+			Name:    "findings.over-quota",
+			Status:  health.StatusInfo,
+			Details: pub.quota.String(),
+		},
+		&health.Report{
+			Name:    "findings.sampled",
+			Status:  health.StatusInfo,
+			Details: pub.sampler.String(),
+		},
+		&health.Report{
+			Name:    "findings.below-severity",
+			Status:  health.StatusInfo,
+			Details: pub.severityFloor.String(),
+		},
+		pub.processorsReport(),
+	}
+	reports = append(reports, pub.orphaned.Health()...)
+	if pub.canary != nil {
+		reports = append(reports, pub.canary.Health()...)
+	}
+	if pub.privateRouter != nil {
+		reports = append(reports, pub.privateRouter.Health()...)
+	}
+	if pub.cosigner != nil {
+		reports = append(reports, pub.cosigner.Health()...)
+	}
+	if pub.exporter != nil {
+		reports = append(reports, pub.exporter.Health()...)
+	}
+	if pub.findingIndex != nil {
+		reports = append(reports, pub.findingIndex.Health()...)
+	}
+	if reporter, ok := pub.messageClient.(health.Reporter); ok {
+		reports = append(reports, reporter.Health()...)
+	}
+	return reports
+}
+
+// SetPaused implements services.Pauser. The batches are held and published after resuming.
+func (pub *Publisher) SetPaused(paused bool) {
+	pub.pause.SetPaused(paused)
+}
+
+// ReloadConfig implements services.ConfigReloader.
+func (pub *Publisher) ReloadConfig(cfg config.Config, report *config.ReloadReport) {
+	batchInterval, batchLimit := getBatchValues(cfg.Publish.Batch)
+	pub.batchTuner.Reset(cfg.Publish.Batch.AutoTune, batchInterval, batchLimit)
+	// apply the new interval to the current batch as well
+	pub.batchTicker.Reset(pub.batchTuner.Interval())
+	pub.quota.SetConfig(cfg.Publish.Quota)
+	pub.sampler.SetConfig(cfg.Publish.Sampling)
+	pub.severityFloor.SetConfig(cfg.Publish.Severity)
+
+	processors, err := newProcessorChain(cfg.Publish.Processors, pub.cfg.ChainID, pub.cfg.Config.FortaDir)
+	if err != nil {
+		log.WithError(err).Error("failed to reload the finding processors - keeping the current ones")
+		return
+	}
+	pub.processorsMu.Lock()
+	pub.processors = processors
+	pub.processorsMu.Unlock()
+}
+
+// cosignBatch collects the cosignatures of the signed batch. The cosignatures are published in the batch
+// file and also returned as the scanner JWT claims so that the batch is accepted only with the signatures
+// of all required keys.
+func (pub *Publisher) cosignBatch(batch *protocol.AlertBatch, signedBatch *protocol.SignedPayload) (map[string]interface{}, []*cosignature, error) {
+	if pub.cosigner == nil {
+		return nil, nil, nil
 	}
+	digest, cosignatures, err := pub.cosigner.Cosign(pub.ctx, pub.cfg.Key.Address.Hex(), batch, signedBatch)
+	if err != nil {
+		return nil, nil, err
+	}
+	return map[string]interface{}{
+		"batchDigest":  digest,
+		"cosignatures": cosignatures,
+	}, cosignatures, nil
+}
+
+func withClaims(claims, extra map[string]interface{}) map[string]interface{} {
+	for k, v := range extra {
+		claims[k] = v
+	}
+	return claims
+}
+
+func getBatchValues(cfg config.BatchConfig) (time.Duration, int) {
+	batchInterval := defaultInterval
+	if cfg.IntervalSeconds != nil {
+		batchInterval = (time.Duration)(*cfg.IntervalSeconds) * time.Second
+	}
+
+	batchLimit := defaultBatchLimit
+	if cfg.MaxAlerts != nil {
+		batchLimit = *cfg.MaxAlerts
+	}
+	return batchInterval, batchLimit
 }
 
 func NewPublisher(ctx context.Context, cfg config.Config) (*Publisher, error) {
 	msgClient := messaging.NewClient("metrics", fmt.Sprintf("%s:%s", config.DockerNatsContainerName, config.DefaultNatsPort))
+	msgClient.SetTracing(cfg.Messaging.Tracing)
 
 	key, err := config.LoadKeyInContainer(cfg)
 	if err != nil {
@@ -847,14 +1108,11 @@ func initPublisher(ctx context.Context, mc clients.MessageClient, alertClient cl
 		return nil, err
 	}
 
-	batchInterval := defaultInterval
-	if cfg.PublisherConfig.Batch.IntervalSeconds != nil {
-		batchInterval = (time.Duration)(*cfg.PublisherConfig.Batch.IntervalSeconds) * time.Second
-	}
+	batchInterval, batchLimit := getBatchValues(cfg.PublisherConfig.Batch)
 
-	batchLimit := defaultBatchLimit
-	if cfg.PublisherConfig.Batch.MaxAlerts != nil {
-		batchLimit = *cfg.PublisherConfig.Batch.MaxAlerts
+	processors, err := newProcessorChain(cfg.PublisherConfig.Processors, cfg.ChainID, cfg.Config.FortaDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the finding processors: %v", err)
 	}
 
 	var localAlertClient LocalAlertClient
@@ -878,25 +1136,75 @@ func initPublisher(ctx context.Context, mc clients.MessageClient, alertClient cl
 		}
 	}
 
+	var privateRouter *privateAlertRouter
+	if cfg.PublisherConfig.Private.Enable {
+		privateAlertDest := cfg.PublisherConfig.Private.WebhookURL
+		if len(privateAlertDest) == 0 {
+			return nil, errors.New("publish.private.webhookUrl is required when the private alert routing is enabled")
+		}
+		privateAlertClient, err := webhook.NewAlertWebhookClient(privateAlertDest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create private alert webhook client: %s", privateAlertDest)
+		}
+		privateRouter = newPrivateAlertRouter(privateAlertClient, cfg.Key, cfg.ChainID)
+	}
+
+	cosigner, err := newBatchCosigner(cfg.PublisherConfig.Signing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the batch cosigners: %v", err)
+	}
+
+	var exporter *batchExporter
+	if cfg.PublisherConfig.Export.Enable {
+		exporter = newBatchExporter(cfg.PublisherConfig.Export)
+	}
+
+	var index *findingIndex
+	if !cfg.PublisherConfig.FindingIndex.Disable {
+		index = newFindingIndex(
+			path.Join(cfg.Config.StateDir(), config.DefaultPublishedFindingsFileName),
+			cfg.PublisherConfig.FindingIndex.MaxFindings,
+		)
+	}
+
+	var canary *canaryTracker
+	if cfg.Config.Scan.Canary.Enable {
+		canary = newCanaryTracker(cfg.Config.Scan.Canary, time.Now())
+	}
+
+	batchCfg := cfg.PublisherConfig.Batch
+	metricsAggregator := NewMetricsAggregator(time.Duration(*batchCfg.MetricsBucketIntervalSeconds)*time.Second).
+		WithLimits(batchCfg.MetricsMaxBucketsPerBot, batchCfg.MetricsMaxSamples)
+
 	return &Publisher{
 		ctx:               ctx,
 		cfg:               cfg,
 		ipfs:              ipfsClient,
 		storage:           storageClient,
-		metricsAggregator: NewMetricsAggregator(time.Duration(*cfg.PublisherConfig.Batch.MetricsBucketIntervalSeconds) * time.Second),
+		metricsAggregator: metricsAggregator,
 		messageClient:     mc,
 		alertClient:       alertClient,
 		localAlertClient:  localAlertClient,
-		batchRefStore:     store.NewFileStringStore(path.Join(cfg.Config.FortaDir, ".last-batch")),
-		lastReceiptStore:  store.NewFileStringStore(path.Join(cfg.Config.FortaDir, ".last-receipt")),
+		privateRouter:     privateRouter,
+		cosigner:          cosigner,
+		exporter:          exporter,
+		findingIndex:      index,
+		canary:            canary,
+		batchRefStore:     store.NewFileStringStore(path.Join(cfg.Config.StateDir(), ".last-batch")),
+		lastReceiptStore:  store.NewFileStringStore(path.Join(cfg.Config.StateDir(), ".last-receipt")),
 
 		skipEmpty:     cfg.PublisherConfig.Batch.SkipEmpty,
 		skipPublish:   cfg.PublisherConfig.SkipPublish,
 		batchInterval: batchInterval,
-		batchLimit:    batchLimit,
+		batchTuner:    newBatchTuner(cfg.PublisherConfig.Batch.AutoTune, batchInterval, batchLimit),
+		quota:         newFindingQuota(cfg.PublisherConfig.Quota),
+		sampler:       newFindingSampler(cfg.PublisherConfig.Sampling),
+		severityFloor: newSeverityFloor(cfg.PublisherConfig.Severity),
+		orphaned:      newOrphanedBlocks(),
+		processors:    processors,
 		notifCh:       make(chan *protocol.NotifyRequest, defaultBatchLimit),
 		batchCh:       make(chan *protocol.AlertBatch, defaultBatchBufferSize),
 
-		batchTicker: time.NewTicker(defaultInterval),
+		batchTicker: time.NewTicker(batchInterval),
 	}, nil
 }
@@ -106,6 +106,38 @@ func TestBatchData_AppendPrivateAlert_Combination(t *testing.T) {
 	assert.EqualValues(t, alert, bd.PrivateAlerts[0].Alerts[0])
 }
 
+func TestBatchData_AppendPendingTxAlert(t *testing.T) {
+	r := require.New(t)
+
+	bd := BatchData{}
+	alert := &protocol.SignedAlert{
+		Alert: &protocol.Alert{Id: "alertId", Finding: &protocol.Finding{}, Tags: map[string]string{"pending": "true"}},
+	}
+	nr := &protocol.NotifyRequest{
+		SignedAlert: alert,
+		EvalTxRequest: &protocol.EvaluateTxRequest{
+			Event: &protocol.TransactionEvent{
+				Block:       &protocol.TransactionEvent_EthBlock{},
+				Transaction: &protocol.TransactionEvent_EthTransaction{Hash: "0x1"},
+			},
+		},
+		EvalTxResponse: &protocol.EvaluateTxResponse{},
+		AgentInfo: &protocol.AgentInfo{
+			Manifest: "agentInfo",
+		},
+	}
+	r.True(isPendingTx(nr))
+
+	bd.AppendAlert(nr)
+	r.Len(bd.Results, 1)
+	r.Zero(bd.Results[0].Block.BlockNumber)
+	r.Len(bd.Results[0].Transactions, 1)
+	r.Equal(alert, bd.Results[0].Transactions[0].Results[0].Alerts[0])
+	r.Len(bd.Agents, 1)
+	r.Empty(bd.Agents[0].Blocks)
+	r.Equal([]string{"0x1"}, bd.Agents[0].Transactions)
+}
+
 func TestShouldSkipPublishing(t *testing.T) {
 	veryRecently := time.Now().Add(-time.Second * 2)
 
@@ -185,3 +217,51 @@ func TestShouldSkipPublishing(t *testing.T) {
 		})
 	}
 }
+
+func TestReloadConfig_BatchInterval(t *testing.T) {
+	r := require.New(t)
+
+	pub := &Publisher{
+		batchTuner:    newBatchTuner(config.BatchAutoTuneConfig{}, time.Hour, defaultBatchLimit),
+		batchTicker:   time.NewTicker(time.Hour),
+		quota:         newFindingQuota(config.FindingQuotaConfig{}),
+		sampler:       newFindingSampler(config.FindingSamplingConfig{}),
+		severityFloor: newSeverityFloor(config.FindingSeverityConfig{}),
+	}
+	defer pub.batchTicker.Stop()
+
+	intervalSeconds := 1
+	var cfg config.Config
+	cfg.Publish.Batch.IntervalSeconds = &intervalSeconds
+	cfg.Publish.Quota.FindingsPerMinute = 10
+	pub.ReloadConfig(cfg, &config.ReloadReport{})
+	r.Equal(time.Second, pub.batchTuner.Interval())
+	r.Equal(10, pub.quota.cfg.FindingsPerMinute)
+
+	// the new interval should apply without waiting for the old one
+	select {
+	case <-pub.batchTicker.C:
+	case <-time.After(time.Second * 3):
+		r.FailNow("batch ticker was not reset")
+	}
+}
+
+func TestReloadConfig_SeverityFloor(t *testing.T) {
+	r := require.New(t)
+
+	pub := &Publisher{
+		batchTuner:    newBatchTuner(config.BatchAutoTuneConfig{}, time.Hour, defaultBatchLimit),
+		batchTicker:   time.NewTicker(time.Hour),
+		quota:         newFindingQuota(config.FindingQuotaConfig{}),
+		sampler:       newFindingSampler(config.FindingSamplingConfig{}),
+		severityFloor: newSeverityFloor(config.FindingSeverityConfig{}),
+	}
+	defer pub.batchTicker.Stop()
+	r.True(pub.severityFloor.Keep("bot-id", protocol.Finding_LOW))
+
+	var cfg config.Config
+	cfg.Publish.Severity.MinSeverity = "HIGH"
+	pub.ReloadConfig(cfg, &config.ReloadReport{})
+	r.False(pub.severityFloor.Keep("bot-id", protocol.Finding_LOW))
+	r.True(pub.severityFloor.Keep("bot-id", protocol.Finding_HIGH))
+}
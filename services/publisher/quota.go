@@ -0,0 +1,105 @@
+package publisher
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const quotaWindow = time.Minute
+
+type botQuota struct {
+	windowStart time.Time
+	count       int
+	dropped     int
+}
+
+// findingQuota counts the findings of each bot in one minute windows and tells which findings
+// are over the quota of the bot.
+type findingQuota struct {
+	cfg  config.FindingQuotaConfig
+	bots map[string]*botQuota
+	rand *rand.Rand
+	mu   sync.Mutex
+}
+
+func newFindingQuota(cfg config.FindingQuotaConfig) *findingQuota {
+	return &findingQuota{
+		cfg:  cfg,
+		bots: make(map[string]*botQuota),
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// SetConfig sets the new config.
+func (fq *findingQuota) SetConfig(cfg config.FindingQuotaConfig) {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	fq.cfg = cfg
+}
+
+// Allow counts the finding and tells if it should be published.
+func (fq *findingQuota) Allow(botID string, now time.Time) bool {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+
+	limit := fq.cfg.GetFindingsPerMinute(botID)
+	if limit == 0 {
+		return true
+	}
+
+	bq, ok := fq.bots[botID]
+	if !ok {
+		bq = &botQuota{}
+		fq.bots[botID] = bq
+	}
+	if now.Sub(bq.windowStart) >= quotaWindow {
+		bq.windowStart = now
+		bq.count = 0
+	}
+	bq.count++
+	if bq.count <= limit {
+		return true
+	}
+	if bq.count == limit+1 {
+		log.WithFields(log.Fields{
+			"botId": botID,
+			"quota": limit,
+		}).Warn("bot exceeded the findings quota - dropping the excess findings")
+	}
+	if fq.cfg.SampleRate > 0 && fq.rand.Float64() < fq.cfg.SampleRate {
+		return true
+	}
+	bq.dropped++
+	return false
+}
+
+// Dropped returns the total amount of dropped findings by bot.
+func (fq *findingQuota) Dropped() map[string]int {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+
+	dropped := make(map[string]int)
+	for botID, bq := range fq.bots {
+		if bq.dropped > 0 {
+			dropped[botID] = bq.dropped
+		}
+	}
+	return dropped
+}
+
+// String lists the bots which had findings dropped.
+func (fq *findingQuota) String() string {
+	var entries []string
+	for botID, dropped := range fq.Dropped() {
+		entries = append(entries, fmt.Sprintf("%s=%d", botID, dropped))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
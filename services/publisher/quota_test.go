@@ -0,0 +1,52 @@
+package publisher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindingQuota(t *testing.T) {
+	r := require.New(t)
+
+	fq := newFindingQuota(config.FindingQuotaConfig{
+		FindingsPerMinute: 2,
+		Bots: map[string]int{
+			"0xUNLIMITED": 0,
+		},
+	})
+
+	now := time.Now()
+	r.True(fq.Allow("0xbot", now))
+	r.True(fq.Allow("0xbot", now))
+	r.False(fq.Allow("0xbot", now))
+	r.False(fq.Allow("0xbot", now.Add(time.Second*30)))
+	r.Equal(map[string]int{"0xbot": 2}, fq.Dropped())
+	r.Equal("0xbot=2", fq.String())
+
+	// the next window has a new quota
+	r.True(fq.Allow("0xbot", now.Add(time.Minute)))
+
+	for i := 0; i < 10; i++ {
+		r.True(fq.Allow("0xunlimited", now))
+	}
+}
+
+func TestFindingQuota_Sampling(t *testing.T) {
+	r := require.New(t)
+
+	fq := newFindingQuota(config.FindingQuotaConfig{FindingsPerMinute: 1, SampleRate: 0.5})
+
+	now := time.Now()
+	var allowed int
+	for i := 0; i < 1000; i++ {
+		if fq.Allow("0xbot", now) {
+			allowed++
+		}
+	}
+	r.Greater(allowed, 300)
+	r.Less(allowed, 700)
+	r.Equal(1000-allowed, fq.Dropped()["0xbot"])
+}
@@ -0,0 +1,160 @@
+package publisher
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients/messaging"
+	"github.com/forta-network/forta-node/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxOrphanedBlocks is how many of the latest reorged out blocks are remembered.
+const maxOrphanedBlocks = 256
+
+// blockInvalidation is published with the next batch to invalidate the findings about a block
+// which was reorged out, including the findings in the batches which were already published.
+type blockInvalidation struct {
+	BlockNumber   uint64 `json:"blockNumber"`
+	OrphanedHash  string `json:"orphanedHash"`
+	CanonicalHash string `json:"canonicalHash"`
+}
+
+// orphanedBlocks remembers the evaluated blocks which were reorged out, so that they can be
+// invalidated in the next batch.
+type orphanedBlocks struct {
+	seen    map[string]bool // orphaned hash
+	order   []string
+	pending []*blockInvalidation
+	blocks  uint64
+	found   uint64
+	mu      sync.Mutex
+}
+
+func newOrphanedBlocks() *orphanedBlocks {
+	return &orphanedBlocks{
+		seen: make(map[string]bool),
+	}
+}
+
+// Add remembers the reorged out block.
+func (ob *orphanedBlocks) Add(payload messaging.ReorgPayload) error {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	orphanedHash := strings.ToLower(payload.OrphanedHash)
+	if ob.seen[orphanedHash] {
+		return nil
+	}
+	log.WithFields(log.Fields{
+		"block":         payload.BlockNumber,
+		"orphanedHash":  payload.OrphanedHash,
+		"canonicalHash": payload.CanonicalHash,
+	}).Warn("invalidating the findings of the reorged out block")
+
+	ob.blocks++
+	ob.seen[orphanedHash] = true
+	ob.order = append(ob.order, orphanedHash)
+	if len(ob.order) > maxOrphanedBlocks {
+		delete(ob.seen, ob.order[0])
+		ob.order = ob.order[1:]
+	}
+	ob.addPending([]*blockInvalidation{{
+		BlockNumber:   payload.BlockNumber,
+		OrphanedHash:  orphanedHash,
+		CanonicalHash: strings.ToLower(payload.CanonicalHash),
+	}})
+	return nil
+}
+
+// TakeInvalidations returns the invalidations which were not published yet.
+func (ob *orphanedBlocks) TakeInvalidations() []*blockInvalidation {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	invalidations := ob.pending
+	ob.pending = nil
+	return invalidations
+}
+
+// RestoreInvalidations puts back the invalidations of a batch which was not published.
+func (ob *orphanedBlocks) RestoreInvalidations(invalidations []*blockInvalidation) {
+	if len(invalidations) == 0 {
+		return
+	}
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	ob.pending = append(invalidations, ob.pending...)
+	ob.addPending(nil)
+}
+
+// addPending expects the lock to be held.
+func (ob *orphanedBlocks) addPending(invalidations []*blockInvalidation) {
+	ob.pending = append(ob.pending, invalidations...)
+	if len(ob.pending) > maxOrphanedBlocks {
+		ob.pending = ob.pending[len(ob.pending)-maxOrphanedBlocks:]
+	}
+}
+
+// CountBatch counts the findings about the reorged out blocks in the batch and returns the metrics
+// of the bots which had them.
+func (ob *orphanedBlocks) CountBatch(batch *protocol.AlertBatch) []*protocol.AgentMetric {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	if len(ob.seen) == 0 {
+		return nil
+	}
+	var agentMetrics []*protocol.AgentMetric
+	for _, blockRes := range batch.Results {
+		if blockRes.Block == nil || !ob.seen[strings.ToLower(blockRes.Block.BlockHash)] {
+			continue
+		}
+		agentAlertsList := blockRes.Results
+		for _, txRes := range blockRes.Transactions {
+			agentAlertsList = append(agentAlertsList, txRes.Results...)
+		}
+		for _, agentAlerts := range agentAlertsList {
+			for _, signedAlert := range agentAlerts.Alerts {
+				if signedAlert == nil || signedAlert.Alert == nil {
+					continue
+				}
+				ob.found++
+				if signedAlert.Alert.Agent != nil {
+					agentMetrics = append(agentMetrics, metrics.CreateAgentMetric(
+						signedAlert.Alert.Agent.Id, metrics.MetricFindingsReorged, 1,
+					))
+				}
+			}
+		}
+	}
+	return agentMetrics
+}
+
+// Health implements health.Reporter interface.
+func (ob *orphanedBlocks) Health() health.Reports {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	return health.Reports{
+		{
+			Name:    "reorg.orphaned-blocks",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(ob.blocks, 10),
+		},
+		{
+			Name:    "reorg.orphaned-findings",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(ob.found, 10),
+		},
+		{
+			Name:    "reorg.pending-invalidations",
+			Status:  health.StatusInfo,
+			Details: strconv.Itoa(len(ob.pending)),
+		},
+	}
+}
@@ -0,0 +1,92 @@
+package publisher
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients/messaging"
+	"github.com/forta-network/forta-node/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func testReorgBlockResults(blockHash string, alert *protocol.SignedAlert) *protocol.BlockResults {
+	return &protocol.BlockResults{
+		Block: &protocol.Block{BlockHash: blockHash},
+		Transactions: []*protocol.TransactionResults{
+			{
+				Results: []*protocol.AgentAlerts{{Alerts: []*protocol.SignedAlert{alert}}},
+			},
+		},
+	}
+}
+
+func testReorgAlert() *protocol.SignedAlert {
+	return &protocol.SignedAlert{
+		Alert: &protocol.Alert{
+			Agent:   &protocol.AgentInfo{Id: "bot1"},
+			Finding: &protocol.Finding{},
+		},
+	}
+}
+
+func TestOrphanedBlocks_Invalidations(t *testing.T) {
+	r := require.New(t)
+
+	ob := newOrphanedBlocks()
+	r.Empty(ob.TakeInvalidations())
+
+	r.NoError(ob.Add(messaging.ReorgPayload{BlockNumber: 2, OrphanedHash: "0xA2", CanonicalHash: "0xB2"}))
+	r.NoError(ob.Add(messaging.ReorgPayload{BlockNumber: 2, OrphanedHash: "0xa2", CanonicalHash: "0xb2"}))
+	invalidations := ob.TakeInvalidations()
+	r.Equal([]*blockInvalidation{{BlockNumber: 2, OrphanedHash: "0xa2", CanonicalHash: "0xb2"}}, invalidations)
+	r.Empty(ob.TakeInvalidations())
+
+	// the invalidations of a failed batch are published with the next one, before the newer ones
+	r.NoError(ob.Add(messaging.ReorgPayload{BlockNumber: 3, OrphanedHash: "0xa3", CanonicalHash: "0xb3"}))
+	ob.RestoreInvalidations(invalidations)
+	invalidations = ob.TakeInvalidations()
+	r.Len(invalidations, 2)
+	r.Equal("0xa2", invalidations[0].OrphanedHash)
+	r.Equal("0xa3", invalidations[1].OrphanedHash)
+}
+
+func TestOrphanedBlocks_CountBatch(t *testing.T) {
+	r := require.New(t)
+
+	ob := newOrphanedBlocks()
+	r.Nil(ob.CountBatch(&protocol.AlertBatch{}))
+
+	r.NoError(ob.Add(messaging.ReorgPayload{BlockNumber: 2, OrphanedHash: "0xA2", CanonicalHash: "0xb2"}))
+
+	orphanedAlert := testReorgAlert()
+	canonicalAlert := testReorgAlert()
+	agentMetrics := ob.CountBatch(&protocol.AlertBatch{
+		Results: []*protocol.BlockResults{
+			testReorgBlockResults("0xa2", orphanedAlert),
+			testReorgBlockResults("0xa3", canonicalAlert),
+		},
+	})
+	r.Len(agentMetrics, 1)
+	r.Equal("bot1", agentMetrics[0].AgentId)
+	r.Equal(metrics.MetricFindingsReorged, agentMetrics[0].Name)
+	// the signed findings are not changed
+	r.Empty(orphanedAlert.Alert.Finding.Metadata)
+
+	reports := ob.Health()
+	r.Equal("1", reports[0].Details)
+	r.Equal("1", reports[1].Details)
+	r.Equal("1", reports[2].Details)
+}
+
+func TestOrphanedBlocks_Limit(t *testing.T) {
+	r := require.New(t)
+
+	ob := newOrphanedBlocks()
+	for i := 0; i < maxOrphanedBlocks+10; i++ {
+		r.NoError(ob.Add(messaging.ReorgPayload{BlockNumber: uint64(i), OrphanedHash: hexutil.EncodeUint64(uint64(i))}))
+	}
+	r.Len(ob.seen, maxOrphanedBlocks)
+	r.Len(ob.order, maxOrphanedBlocks)
+	r.Len(ob.pending, maxOrphanedBlocks)
+}
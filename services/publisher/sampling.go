@@ -0,0 +1,94 @@
+package publisher
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+)
+
+const defaultAlwaysKeepSeverity = protocol.Finding_HIGH
+
+type botSampling struct {
+	count   int
+	sampled int
+}
+
+// findingSampler keeps one in every N findings of the configured bots, unless the findings
+// are severe enough to be always kept.
+type findingSampler struct {
+	cfg  config.FindingSamplingConfig
+	bots map[string]*botSampling
+	mu   sync.Mutex
+}
+
+func newFindingSampler(cfg config.FindingSamplingConfig) *findingSampler {
+	return &findingSampler{
+		cfg:  cfg,
+		bots: make(map[string]*botSampling),
+	}
+}
+
+// SetConfig sets the new config.
+func (fs *findingSampler) SetConfig(cfg config.FindingSamplingConfig) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.cfg = cfg
+}
+
+// Keep counts the finding and tells if it should be published.
+func (fs *findingSampler) Keep(botID string, severity protocol.Finding_Severity) bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	botCfg, ok := fs.cfg.GetBotSampling(botID)
+	if !ok || botCfg.KeepOneIn <= 1 {
+		return true
+	}
+	alwaysKeep := defaultAlwaysKeepSeverity
+	if len(botCfg.AlwaysKeepSeverity) > 0 {
+		alwaysKeep = protocol.Finding_Severity(protocol.Finding_Severity_value[botCfg.AlwaysKeepSeverity])
+	}
+	if severity >= alwaysKeep {
+		return true
+	}
+
+	bs, ok := fs.bots[botID]
+	if !ok {
+		bs = &botSampling{}
+		fs.bots[botID] = bs
+	}
+	keep := bs.count%botCfg.KeepOneIn == 0
+	bs.count++
+	if !keep {
+		bs.sampled++
+	}
+	return keep
+}
+
+// Sampled returns the total amount of skipped findings by bot.
+func (fs *findingSampler) Sampled() map[string]int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	sampled := make(map[string]int)
+	for botID, bs := range fs.bots {
+		if bs.sampled > 0 {
+			sampled[botID] = bs.sampled
+		}
+	}
+	return sampled
+}
+
+// String lists the bots which had findings skipped.
+func (fs *findingSampler) String() string {
+	var entries []string
+	for botID, sampled := range fs.Sampled() {
+		entries = append(entries, fmt.Sprintf("%s=%d", botID, sampled))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,44 @@
+package publisher
+
+import (
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindingSampler(t *testing.T) {
+	r := require.New(t)
+
+	fs := newFindingSampler(config.FindingSamplingConfig{
+		Bots: map[string]config.BotSamplingConfig{
+			"0xNOISY":  {KeepOneIn: 3},
+			"0xmedium": {KeepOneIn: 2, AlwaysKeepSeverity: "MEDIUM"},
+		},
+	})
+
+	// one in three info findings are kept
+	var kept int
+	for i := 0; i < 9; i++ {
+		if fs.Keep("0xnoisy", protocol.Finding_INFO) {
+			kept++
+		}
+	}
+	r.Equal(3, kept)
+
+	// the severe findings are always kept
+	r.True(fs.Keep("0xnoisy", protocol.Finding_HIGH))
+	r.True(fs.Keep("0xnoisy", protocol.Finding_CRITICAL))
+	r.True(fs.Keep("0xmedium", protocol.Finding_MEDIUM))
+	r.True(fs.Keep("0xmedium", protocol.Finding_LOW))
+	r.False(fs.Keep("0xmedium", protocol.Finding_LOW))
+
+	// the other bots are not sampled
+	for i := 0; i < 5; i++ {
+		r.True(fs.Keep("0xother", protocol.Finding_INFO))
+	}
+
+	r.Equal(map[string]int{"0xnoisy": 6, "0xmedium": 1}, fs.Sampled())
+	r.Equal("0xmedium=1,0xnoisy=6", fs.String())
+}
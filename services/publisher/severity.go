@@ -0,0 +1,61 @@
+package publisher
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+)
+
+// severityFloor skips the findings which are below the configured severity of the bot.
+type severityFloor struct {
+	cfg     config.FindingSeverityConfig
+	skipped map[string]int
+	mu      sync.Mutex
+}
+
+func newSeverityFloor(cfg config.FindingSeverityConfig) *severityFloor {
+	return &severityFloor{
+		cfg:     cfg,
+		skipped: make(map[string]int),
+	}
+}
+
+// SetConfig sets the new config.
+func (sf *severityFloor) SetConfig(cfg config.FindingSeverityConfig) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	sf.cfg = cfg
+}
+
+// Keep counts the finding and tells if it should be published.
+func (sf *severityFloor) Keep(botID string, severity protocol.Finding_Severity) bool {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	minSeverity := sf.cfg.GetMinSeverity(botID)
+	if len(minSeverity) == 0 {
+		return true
+	}
+	if severity >= protocol.Finding_Severity(protocol.Finding_Severity_value[minSeverity]) {
+		return true
+	}
+	sf.skipped[botID]++
+	return false
+}
+
+// String lists the bots which had findings skipped.
+func (sf *severityFloor) String() string {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	var entries []string
+	for botID, skipped := range sf.skipped {
+		entries = append(entries, fmt.Sprintf("%s=%d", botID, skipped))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,38 @@
+package publisher
+
+import (
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeverityFloor(t *testing.T) {
+	r := require.New(t)
+
+	sf := newSeverityFloor(config.FindingSeverityConfig{
+		MinSeverity: "HIGH",
+		Bots: map[string]string{
+			"0xVERBOSE": "UNKNOWN",
+			"0xmedium":  "MEDIUM",
+		},
+	})
+
+	// the global floor
+	r.False(sf.Keep("0xother", protocol.Finding_INFO))
+	r.False(sf.Keep("0xother", protocol.Finding_MEDIUM))
+	r.True(sf.Keep("0xother", protocol.Finding_HIGH))
+	r.True(sf.Keep("0xother", protocol.Finding_CRITICAL))
+
+	// the bot overrides
+	r.True(sf.Keep("0xverbose", protocol.Finding_INFO))
+	r.False(sf.Keep("0xmedium", protocol.Finding_LOW))
+	r.True(sf.Keep("0xmedium", protocol.Finding_MEDIUM))
+
+	r.Equal("0xmedium=1,0xother=2", sf.String())
+
+	// nothing is skipped without a floor
+	sf.SetConfig(config.FindingSeverityConfig{})
+	r.True(sf.Keep("0xother", protocol.Finding_INFO))
+}
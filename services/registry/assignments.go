@@ -0,0 +1,39 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+// LoadAssignments loads the last published bot assignments. The list is empty if the file does not exist.
+func LoadAssignments(filePath string) ([]*config.AgentConfig, error) {
+	b, err := ioutil.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var agentConfigs []*config.AgentConfig
+	if err := json.Unmarshal(b, &agentConfigs); err != nil {
+		return nil, fmt.Errorf("failed to decode the assignments: %v", err)
+	}
+	return agentConfigs, nil
+}
+
+func saveAssignments(filePath string, agentConfigs []*config.AgentConfig) error {
+	b, err := json.Marshal(agentConfigs)
+	if err != nil {
+		return err
+	}
+	tmpPath := filePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filePath)
+}
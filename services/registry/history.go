@@ -0,0 +1,134 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+// maxAssignmentChanges is the number of changes kept in the history file.
+const maxAssignmentChanges = 1000
+
+// AssignmentChange is a change in the bots assigned to the scanner.
+type AssignmentChange struct {
+	Time       time.Time `json:"time"`
+	Bots       []string  `json:"bots"`
+	Assigned   []string  `json:"assigned"`
+	Unassigned []string  `json:"unassigned"`
+}
+
+// assignmentHistory persists the assignment changes to a file in the Forta dir.
+type assignmentHistory struct {
+	filePath string
+}
+
+func newAssignmentHistory(filePath string) *assignmentHistory {
+	return &assignmentHistory{filePath: filePath}
+}
+
+// LoadAssignmentHistory loads the assignment changes, ordered by time. The history is empty
+// if the file does not exist.
+func LoadAssignmentHistory(filePath string) ([]*AssignmentChange, error) {
+	b, err := ioutil.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var changes []*AssignmentChange
+	if err := json.Unmarshal(b, &changes); err != nil {
+		return nil, fmt.Errorf("failed to decode the assignment history: %v", err)
+	}
+	return changes, nil
+}
+
+// Record appends a change to the history if the bots are different than the last recorded ones.
+func (ah *assignmentHistory) Record(agentConfigs []*config.AgentConfig, t time.Time) (*AssignmentChange, error) {
+	bots := make([]string, 0, len(agentConfigs))
+	for _, agentConfig := range agentConfigs {
+		bots = append(bots, strings.ToLower(agentConfig.ID))
+	}
+	sort.Strings(bots)
+
+	// the changes are rare so the file is read every time instead of keeping the state,
+	// and a restart with the same bots is not recorded as a change
+	changes, err := LoadAssignmentHistory(ah.filePath)
+	if err != nil {
+		return nil, err
+	}
+	var lastBots []string
+	if len(changes) > 0 {
+		lastBots = changes[len(changes)-1].Bots
+	}
+	assigned, unassigned := DiffAssignments(lastBots, bots)
+	if len(changes) > 0 && len(assigned) == 0 && len(unassigned) == 0 {
+		return nil, nil
+	}
+	change := &AssignmentChange{
+		Time:       t.UTC(),
+		Bots:       bots,
+		Assigned:   assigned,
+		Unassigned: unassigned,
+	}
+	changes = append(changes, change)
+	if len(changes) > maxAssignmentChanges {
+		changes = changes[len(changes)-maxAssignmentChanges:]
+	}
+
+	b, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+	tmpPath := ah.filePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, b, 0644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, ah.filePath); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// DiffAssignments returns the bots which exist only in the new list and the ones
+// which exist only in the old list.
+func DiffAssignments(oldBots, newBots []string) (assigned, unassigned []string) {
+	oldSet := make(map[string]bool)
+	for _, bot := range oldBots {
+		oldSet[bot] = true
+	}
+	newSet := make(map[string]bool)
+	for _, bot := range newBots {
+		newSet[bot] = true
+		if !oldSet[bot] {
+			assigned = append(assigned, bot)
+		}
+	}
+	for _, bot := range oldBots {
+		if !newSet[bot] {
+			unassigned = append(unassigned, bot)
+		}
+	}
+	sort.Strings(assigned)
+	sort.Strings(unassigned)
+	return
+}
+
+// AssignmentsAt returns the bots which were assigned at the given time.
+func AssignmentsAt(changes []*AssignmentChange, t time.Time) []string {
+	var bots []string
+	for _, change := range changes {
+		if change.Time.After(t) {
+			break
+		}
+		bots = change.Bots
+	}
+	return bots
+}
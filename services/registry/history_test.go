@@ -0,0 +1,40 @@
+package registry
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssignmentHistory(t *testing.T) {
+	r := require.New(t)
+
+	history := newAssignmentHistory(path.Join(t.TempDir(), config.DefaultAssignmentHistoryFileName))
+	t1 := time.Date(2023, 3, 20, 10, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+
+	change, err := history.Record([]*config.AgentConfig{{ID: "0x02"}, {ID: "0x01"}}, t1)
+	r.NoError(err)
+	r.Equal([]string{"0x01", "0x02"}, change.Assigned)
+
+	// the same bots are not recorded again
+	change, err = history.Record([]*config.AgentConfig{{ID: "0x01"}, {ID: "0x02"}}, t1.Add(time.Minute))
+	r.NoError(err)
+	r.Nil(change)
+
+	change, err = history.Record([]*config.AgentConfig{{ID: "0x02"}, {ID: "0x03"}}, t2)
+	r.NoError(err)
+	r.Equal([]string{"0x03"}, change.Assigned)
+	r.Equal([]string{"0x01"}, change.Unassigned)
+
+	changes, err := LoadAssignmentHistory(history.filePath)
+	r.NoError(err)
+	r.Len(changes, 2)
+
+	r.Nil(AssignmentsAt(changes, t1.Add(-time.Minute)))
+	r.Equal([]string{"0x01", "0x02"}, AssignmentsAt(changes, t1.Add(time.Minute)))
+	r.Equal([]string{"0x02", "0x03"}, AssignmentsAt(changes, t2))
+}
@@ -3,6 +3,8 @@ package registry
 import (
 	"context"
 	"fmt"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/forta-network/forta-node/store"
@@ -30,6 +32,7 @@ type RegistryService struct {
 
 	rpcClient     *rpc.Client
 	registryStore store.RegistryStore
+	history       *assignmentHistory
 
 	agentsConfigs []*config.AgentConfig
 	done          chan struct{}
@@ -39,6 +42,7 @@ type RegistryService struct {
 	lastChecked        health.TimeTracker
 	lastChangeDetected health.TimeTracker
 	lastErr            health.ErrorTracker
+	lastHistoryErr     health.ErrorTracker
 }
 
 // IPFSClient interacts with an IPFS Gateway.
@@ -60,6 +64,7 @@ func New(cfg config.Config, scannerAddress common.Address, msgClient clients.Mes
 		ethClient:      ethClient,
 		done:           make(chan struct{}),
 		blockFeed:      blockFeed,
+		history:        newAssignmentHistory(path.Join(cfg.StateDir(), config.DefaultAssignmentHistoryFileName)),
 	}
 }
 
@@ -91,6 +96,10 @@ func (rs *RegistryService) Start() error {
 }
 
 func (rs *RegistryService) start() error {
+	if rs.cfg.AutoStart.Enable {
+		rs.publishSavedAgents()
+	}
+
 	go func() {
 		ticker := time.NewTicker(time.Duration(rs.cfg.Registry.CheckIntervalSeconds) * time.Second)
 		for {
@@ -120,6 +129,10 @@ func (rs *RegistryService) publishLatestAgents() error {
 			rs.lastChangeDetected.Set()
 			log.WithField("count", len(agts)).Infof("publishing list of agents")
 			rs.agentsConfigs = agts
+			rs.recordAssignments(agts)
+			if err := saveAssignments(rs.assignmentsPath(), agts); err != nil {
+				log.WithError(err).Warn("failed to save the assignments")
+			}
 			rs.msgClient.Publish(messaging.SubjectAgentsVersionsLatest, agts)
 		} else {
 			log.Info("registry: no agent changes detected")
@@ -128,6 +141,43 @@ func (rs *RegistryService) publishLatestAgents() error {
 	return nil
 }
 
+// publishSavedAgents publishes the last assignments so that the bots can resume after a restart
+// before the registry responds.
+func (rs *RegistryService) publishSavedAgents() {
+	agts, err := LoadAssignments(rs.assignmentsPath())
+	if err != nil {
+		log.WithError(err).Warn("failed to load the saved assignments")
+		return
+	}
+	if len(agts) == 0 {
+		return
+	}
+	log.WithField("count", len(agts)).Info("publishing the saved list of agents")
+	rs.agentsConfigs = agts
+	rs.msgClient.Publish(messaging.SubjectAgentsVersionsLatest, agts)
+}
+
+func (rs *RegistryService) assignmentsPath() string {
+	return path.Join(rs.cfg.StateDir(), config.DefaultAssignmentsFileName)
+}
+
+// recordAssignments persists the assignment change so that the operators can see later
+// when a bot was assigned or unassigned.
+func (rs *RegistryService) recordAssignments(agts []*config.AgentConfig) {
+	change, err := rs.history.Record(agts, time.Now())
+	rs.lastHistoryErr.Set(err)
+	if err != nil {
+		log.WithError(err).Warn("failed to record the assignment change")
+		return
+	}
+	if change != nil {
+		log.WithFields(log.Fields{
+			"assigned":   len(change.Assigned),
+			"unassigned": len(change.Unassigned),
+		}).Info("recorded the assignment change")
+	}
+}
+
 // Stop stops the registry service.
 func (rs *RegistryService) Stop() error {
 	return nil
@@ -142,6 +192,7 @@ func (rs *RegistryService) Name() string {
 func (rs *RegistryService) Health() health.Reports {
 	return health.Reports{
 		rs.lastErr.GetReport("event.checked.error"),
+		rs.lastHistoryErr.GetReport("event.history-record.error"),
 		&health.Report{
 			Name:    "event.checked.time",
 			Status:  health.StatusInfo,
@@ -152,5 +203,27 @@ func (rs *RegistryService) Health() health.Reports {
 			Status:  health.StatusInfo,
 			Details: rs.lastChangeDetected.String(),
 		},
+		rs.rejectedBotsReport(),
+	}
+}
+
+func (rs *RegistryService) rejectedBotsReport() *health.Report {
+	report := &health.Report{
+		Name:    "bots.rejected",
+		Status:  health.StatusInfo,
+		Details: "0",
+	}
+	if rs.registryStore == nil {
+		return report
+	}
+	rejectedBots := rs.registryStore.RejectedBots()
+	if len(rejectedBots) == 0 {
+		return report
+	}
+	var reasons []string
+	for _, rejectedBot := range rejectedBots {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", rejectedBot.ID, rejectedBot.Reason))
 	}
+	report.Details = fmt.Sprintf("%d (%s)", len(rejectedBots), strings.Join(reasons, "; "))
+	return report
 }
@@ -2,6 +2,7 @@ package registry
 
 import (
 	"fmt"
+	"path"
 	"testing"
 
 	"golang.org/x/sync/semaphore"
@@ -62,8 +63,10 @@ func (s *Suite) SetupTest() {
 		registryStore:  s.registryStore,
 		done:           make(chan struct{}),
 		sem:            semaphore.NewWeighted(1),
+		history:        newAssignmentHistory(path.Join(s.T().TempDir(), config.DefaultAssignmentHistoryFileName)),
 	}
 	s.service.cfg.Registry.ContainerRegistry = testContainerRegistry
+	s.service.cfg.FortaDir = s.T().TempDir()
 }
 
 type agentConfigs []*config.AgentConfig
@@ -103,6 +106,15 @@ func (s *Suite) TestPublishChanges() {
 	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsVersionsLatest, configs)
 
 	s.NoError(s.service.publishLatestAgents())
+
+	changes, err := LoadAssignmentHistory(s.service.history.filePath)
+	s.r.NoError(err)
+	s.r.Len(changes, 1)
+	s.r.Equal([]string{testAgentIDStr}, changes[0].Assigned)
+
+	// the saved assignments are published first after a restart
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsVersionsLatest, configs)
+	s.service.publishSavedAgents()
 }
 
 func (s *Suite) TestDoNotPublishChanges() {
@@ -25,6 +25,7 @@ func (runner *Runner) checkHealth() (allReports health.Reports) {
 		Status:  health.StatusInfo,
 		Details: config.GetBuildReleaseInfo().Manifest.Release.Version,
 	})
+	allReports = append(allReports, runner.runStateReports()...)
 
 	for _, container := range containers {
 		name := fmt.Sprintf("forta.container.%s", container.Names[0][1:])
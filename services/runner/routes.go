@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"net"
+	"os"
+	"strings"
+)
+
+const hostRouteFile = "/proc/net/route"
+
+// readHostRoutes returns the subnets of the routes in the route table file, except the default route.
+// The route table is not available on the non-Linux hosts.
+func readHostRoutes(routeFile string) ([]string, error) {
+	f, err := os.Open(routeFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var subnets []string
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // skip the header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+		dest, err1 := parseRouteHex(fields[1])
+		mask, err2 := parseRouteHex(fields[7])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		ipMask := net.IPMask(mask)
+		if ones, _ := ipMask.Size(); ones == 0 {
+			continue
+		}
+		subnet := &net.IPNet{IP: net.IP(dest).Mask(ipMask), Mask: ipMask}
+		subnets = append(subnets, subnet.String())
+	}
+	return subnets, scanner.Err()
+}
+
+// parseRouteHex parses the little-endian hex address in the route table.
+func parseRouteHex(s string) ([]byte, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != net.IPv4len {
+		return nil, errors.New("invalid address length")
+	}
+	addr := make([]byte, net.IPv4len)
+	binary.BigEndian.PutUint32(addr, binary.LittleEndian.Uint32(b))
+	return addr, nil
+}
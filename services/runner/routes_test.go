@@ -0,0 +1,30 @@
+package runner
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testRouteTable = `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	00000000	0101A8C0	0003	0	0	0	00000000	0	0	0
+eth0	0001A8C0	00000000	0001	0	0	0	00FFFFFF	0	0	0
+tun0	0000080A	00000000	0001	0	0	0	0000FFFF	0	0	0
+`
+
+func TestReadHostRoutes(t *testing.T) {
+	r := require.New(t)
+
+	routeFile := path.Join(t.TempDir(), "route")
+	r.NoError(os.WriteFile(routeFile, []byte(testRouteTable), 0644))
+
+	subnets, err := readHostRoutes(routeFile)
+	r.NoError(err)
+	r.Equal([]string{"192.168.1.0/24", "10.8.0.0/16"}, subnets)
+
+	subnets, err = readHostRoutes(path.Join(t.TempDir(), "missing"))
+	r.NoError(err)
+	r.Empty(subnets)
+}
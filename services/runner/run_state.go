@@ -0,0 +1,115 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/sdnotify"
+	log "github.com/sirupsen/logrus"
+)
+
+// RunState tells if the runner was stopped cleanly. A state which is still running at the start
+// means that the host was rebooted or the runner was killed.
+type RunState struct {
+	Running   bool      `json:"running"`
+	StartedAt time.Time `json:"startedAt"`
+	StoppedAt time.Time `json:"stoppedAt"`
+}
+
+// LoadRunState loads the run state. The state is nil if the file does not exist.
+func LoadRunState(filePath string) (*RunState, error) {
+	b, err := ioutil.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state RunState
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode the run state: %v", err)
+	}
+	return &state, nil
+}
+
+func saveRunState(filePath string, state *RunState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmpPath := filePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filePath)
+}
+
+// recordStart detects the unplanned shutdown of the previous run and marks the node as running.
+func (runner *Runner) recordStart(now time.Time) {
+	prevState, err := LoadRunState(runner.runStatePath())
+	if err != nil {
+		log.WithError(err).Warn("failed to load the run state")
+	}
+	if prevState != nil && prevState.Running {
+		runner.resumedFrom = prevState
+		log.WithField("startedAt", prevState.StartedAt.Format(time.RFC3339)).
+			Warn("the node was not stopped cleanly (host reboot?) - resuming")
+	}
+	runner.lastRunStateErr.Set(saveRunState(runner.runStatePath(), &RunState{
+		Running:   true,
+		StartedAt: now,
+	}))
+}
+
+// recordStop marks the node as stopped cleanly.
+func (runner *Runner) recordStop(now time.Time) {
+	prevState, _ := LoadRunState(runner.runStatePath())
+	state := &RunState{StoppedAt: now}
+	if prevState != nil {
+		state.StartedAt = prevState.StartedAt
+	}
+	if err := saveRunState(runner.runStatePath(), state); err != nil {
+		log.WithError(err).Warn("failed to save the run state")
+	}
+}
+
+// containerRestartPolicy returns the Docker restart policy of the supervisor and the updater containers.
+// The runner interrupts these containers when it stops, which Docker treats as a manual stop, so
+// with the default policy they are started with the Docker daemon only after an unplanned shutdown.
+//
+// There is no restart policy when the runner is a systemd service: systemd starts the runner at boot
+// and the runner removes the leftover containers at start, so Docker starting them would only make
+// the supervisor start twice.
+func (runner *Runner) containerRestartPolicy() string {
+	if !runner.cfg.AutoStart.Enable || sdnotify.Enabled() {
+		return ""
+	}
+	return runner.cfg.AutoStart.RestartPolicy
+}
+
+func (runner *Runner) runStatePath() string {
+	return path.Join(runner.cfg.FortaDir, config.DefaultRunStateFileName)
+}
+
+func (runner *Runner) runStateReports() health.Reports {
+	resumedReport := &health.Report{
+		Name:   "runner.resumed",
+		Status: health.StatusInfo,
+	}
+	if runner.resumedFrom != nil {
+		resumedReport.Details = fmt.Sprintf(
+			"after an unplanned shutdown of the run started at %s", runner.resumedFrom.StartedAt.Format(time.RFC3339),
+		)
+	}
+	return health.Reports{
+		resumedReport,
+		runner.lastRunStateErr.GetReport("runner.run-state.error"),
+	}
+}
@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunState(t *testing.T) {
+	r := require.New(t)
+
+	runner := &Runner{cfg: config.Config{FortaDir: t.TempDir()}}
+	start := time.Now().Add(-time.Hour).UTC().Round(time.Second)
+
+	// first run
+	runner.recordStart(start)
+	r.Nil(runner.resumedFrom)
+	state, err := LoadRunState(runner.runStatePath())
+	r.NoError(err)
+	r.True(state.Running)
+
+	// clean stop and start
+	runner.recordStop(start.Add(time.Minute))
+	state, err = LoadRunState(runner.runStatePath())
+	r.NoError(err)
+	r.False(state.Running)
+	r.Equal(start, state.StartedAt)
+	runner.recordStart(start.Add(time.Minute * 2))
+	r.Nil(runner.resumedFrom)
+	r.Empty(runner.runStateReports()[0].Details)
+
+	// start without a clean stop
+	runner.recordStart(start.Add(time.Minute * 3))
+	r.NotNil(runner.resumedFrom)
+	r.Equal(start.Add(time.Minute*2), runner.resumedFrom.StartedAt)
+	r.Contains(runner.runStateReports()[0].Details, "unplanned shutdown")
+	r.Empty(runner.runStateReports()[1].Details)
+
+	// the restart policy is set only if auto-start is enabled
+	t.Setenv("NOTIFY_SOCKET", "")
+	r.Empty(runner.containerRestartPolicy())
+	runner.cfg.AutoStart = config.AutoStartConfig{Enable: true, RestartPolicy: "unless-stopped"}
+	r.Equal("unless-stopped", runner.containerRestartPolicy())
+
+	// systemd starts the runner instead
+	t.Setenv("NOTIFY_SOCKET", "/run/systemd/notify")
+	r.Empty(runner.containerRestartPolicy())
+}
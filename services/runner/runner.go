@@ -3,6 +3,7 @@ package runner
 import (
 	"context"
 	"fmt"
+	"path"
 	"strconv"
 	"strings"
 	"sync"
@@ -10,10 +11,12 @@ import (
 
 	"github.com/forta-network/forta-core-go/clients/health"
 	"github.com/forta-network/forta-core-go/ethereum"
+	"github.com/forta-network/forta-core-go/release"
 	"github.com/forta-network/forta-core-go/utils"
 	"github.com/forta-network/forta-node/clients"
 	"github.com/forta-network/forta-node/config"
 	"github.com/forta-network/forta-node/healthutils"
+	"github.com/forta-network/forta-node/sdnotify"
 	"github.com/forta-network/forta-node/services"
 	"github.com/forta-network/forta-node/store"
 	log "github.com/sirupsen/logrus"
@@ -39,6 +42,9 @@ type Runner struct {
 	containerMu          sync.RWMutex // protects above refs and containers
 
 	healthClient health.HealthClient
+
+	resumedFrom     *RunState
+	lastRunStateErr health.ErrorTracker
 }
 
 // EthereumClient is useful for checking the JSON-RPC API.
@@ -68,6 +74,8 @@ func (runner *Runner) Start() error {
 	}
 	log.Info("start-up check successful")
 
+	runner.recordStart(time.Now())
+
 	if err := runner.globalClient.Nuke(context.Background()); err != nil {
 		return fmt.Errorf("failed to nuke leftover containers at start: %v", err)
 	}
@@ -83,6 +91,10 @@ func (runner *Runner) Start() error {
 
 	go runner.keepContainersAlive()
 
+	if sdnotify.Enabled() {
+		go runner.notifySystemd()
+	}
+
 	return nil
 }
 
@@ -91,8 +103,26 @@ func (runner *Runner) Name() string {
 	return "runner"
 }
 
+// ReloadConfig implements services.ConfigReloader and passes the reload signal to the supervisor.
+func (runner *Runner) ReloadConfig(cfg config.Config, report *config.ReloadReport) {
+	runner.containerMu.RLock()
+	defer runner.containerMu.RUnlock()
+
+	if runner.supervisorContainer == nil {
+		return
+	}
+	if err := runner.dockerClient.ReloadContainer(runner.ctx, runner.supervisorContainer.ID); err != nil {
+		log.WithError(err).Error("failed to send reload signal to supervisor")
+	}
+}
+
 // Stop stops the service
 func (runner *Runner) Stop() error {
+	if _, err := sdnotify.Notify(sdnotify.StateStopping); err != nil {
+		log.WithError(err).Warn("failed to notify systemd")
+	}
+	runner.recordStop(time.Now())
+
 	runner.containerMu.RLock()
 	defer runner.containerMu.RUnlock()
 
@@ -163,7 +193,7 @@ func (runner *Runner) removeContainerWithProps(name, id string) error {
 }
 
 func (runner *Runner) startEmbeddedUpdater() {
-	builtInRefs := runner.imgStore.EmbeddedImageRefs()
+	builtInRefs := runner.embeddedImageRefs()
 	logger := log.WithField("supervisor", builtInRefs.Supervisor).WithField("updater", builtInRefs.Updater)
 
 	if err := runner.replaceUpdater(logger, builtInRefs); err != nil {
@@ -174,7 +204,7 @@ func (runner *Runner) startEmbeddedUpdater() {
 }
 
 func (runner *Runner) startEmbeddedSupervisor() {
-	builtInRefs := runner.imgStore.EmbeddedImageRefs()
+	builtInRefs := runner.embeddedImageRefs()
 	logger := log.WithField("supervisor", builtInRefs.Supervisor).WithField("updater", builtInRefs.Updater)
 
 	if err := runner.replaceSupervisor(logger, builtInRefs); err != nil {
@@ -184,6 +214,25 @@ func (runner *Runner) startEmbeddedSupervisor() {
 	}
 }
 
+// embeddedImageRefs returns the images of the imported release for the offline nodes, since they can
+// not pull the images of the build.
+func (runner *Runner) embeddedImageRefs() store.ImageRefs {
+	if runner.cfg.AutoUpdate.Offline {
+		releaseInfo, err := store.ReadImportedRelease(runner.cfg.FortaDir)
+		if err != nil {
+			log.WithError(err).Warn("failed to read the imported release - using the build images")
+		}
+		if releaseInfo != nil {
+			return store.ImageRefs{
+				Supervisor:  releaseInfo.Manifest.Release.Services.Supervisor,
+				Updater:     releaseInfo.Manifest.Release.Services.Updater,
+				ReleaseInfo: releaseInfo,
+			}
+		}
+	}
+	return runner.imgStore.EmbeddedImageRefs()
+}
+
 func (runner *Runner) keepContainersUpToDate() {
 	defer func() {
 		if r := recover(); r != nil {
@@ -234,7 +283,8 @@ func (runner *Runner) ensureImage(logger *log.Entry, name string, imageRef strin
 	logger = logger.WithField("ref", imageRef).WithField("name", name)
 
 	// to make things easier, don't require image ref validation in dev mode
-	if !runner.cfg.Development {
+	// the images imported from the update bundles are referred by their IDs
+	if !runner.cfg.Development && !strings.HasPrefix(imageRef, "sha256:") {
 		fixedRef, err := utils.ValidateDiscoImageRef(runner.cfg.Registry.ContainerRegistry, imageRef)
 		if err != nil {
 			logger.WithError(err).WithField("imageRef", imageRef).Warn("not a disco ref")
@@ -243,7 +293,7 @@ func (runner *Runner) ensureImage(logger *log.Entry, name string, imageRef strin
 		}
 	}
 
-	if err := runner.dockerClient.EnsureLocalImage(runner.ctx, name, imageRef); err != nil {
+	if err := clients.EnsureImageWithPolicy(runner.ctx, runner.dockerClient, name, imageRef, runner.cfg.ImagePull.Services); err != nil {
 		logger.WithError(err).Warn("failed to ensure local image")
 		return "", err
 	}
@@ -251,6 +301,30 @@ func (runner *Runner) ensureImage(logger *log.Entry, name string, imageRef strin
 	return imageRef, nil
 }
 
+// withLocalBotSources mounts the local bot source directories outside of the Forta dir to the same paths
+// so the supervisor can build the bot images from them.
+func (runner *Runner) withLocalBotSources(volumes map[string]string) map[string]string {
+	if !runner.cfg.LocalModeConfig.Enable {
+		return volumes
+	}
+	for _, localBot := range runner.cfg.LocalModeConfig.LocalBots {
+		if path.IsAbs(localBot.Source) {
+			volumes[localBot.Source] = localBot.Source
+		}
+	}
+	return volumes
+}
+
+// releaseLabels returns the labels which show the version of the node containers.
+func releaseLabels(releaseInfo *release.ReleaseInfo) map[string]string {
+	if releaseInfo == nil || len(releaseInfo.Manifest.Release.Version) == 0 {
+		return nil
+	}
+	return map[string]string{
+		clients.DockerLabelFortaVersion: releaseInfo.Manifest.Release.Version,
+	}
+}
+
 func (runner *Runner) replaceUpdater(logger *log.Entry, imageRefs store.ImageRefs) error {
 	logger.Info("replacing updater")
 	err := runner.removeContainer(runner.updaterContainer)
@@ -281,8 +355,9 @@ func (runner *Runner) startUpdater(logger *log.Entry, latestRefs store.ImageRefs
 		Image: updaterRef,
 		Cmd:   []string{config.DefaultFortaNodeBinaryPath, "updater"},
 		Env: map[string]string{
-			config.EnvDevelopment: strconv.FormatBool(runner.cfg.Development),
-			config.EnvReleaseInfo: latestRefs.ReleaseInfo.String(),
+			config.EnvDevelopment:    strconv.FormatBool(runner.cfg.Development),
+			config.EnvReleaseInfo:    latestRefs.ReleaseInfo.String(),
+			config.EnvReleaseChannel: runner.cfg.AutoUpdate.GetReleaseChannel(),
 		},
 		Volumes: map[string]string{
 			runner.cfg.FortaDir: config.DefaultContainerFortaDirPath,
@@ -291,9 +366,11 @@ func (runner *Runner) startUpdater(logger *log.Entry, latestRefs store.ImageRefs
 			config.DefaultContainerPort: config.DefaultContainerPort,
 			"":                          config.DefaultHealthPort, // random host port
 		},
-		DialHost:    true,
-		MaxLogSize:  runner.cfg.Log.MaxLogSize,
-		MaxLogFiles: runner.cfg.Log.MaxLogFiles,
+		Labels:        releaseLabels(latestRefs.ReleaseInfo),
+		DialHost:      true,
+		MaxLogSize:    runner.cfg.Log.MaxLogSize,
+		MaxLogFiles:   runner.cfg.Log.MaxLogFiles,
+		RestartPolicy: runner.containerRestartPolicy(),
 	})
 	if err != nil {
 		logger.WithError(err).Errorf("failed to start the updater")
@@ -314,6 +391,11 @@ func (runner *Runner) startSupervisor(logger *log.Entry, latestRefs store.ImageR
 	if err != nil {
 		return err
 	}
+	// the supervisor avoids the host routes when allocating the agent network subnets
+	hostRoutes, err := readHostRoutes(hostRouteFile)
+	if err != nil {
+		logger.WithError(err).Warn("failed to read the host routes")
+	}
 	sc, err := runner.dockerClient.StartContainer(runner.ctx, clients.DockerContainerConfig{
 		Name:  config.DockerSupervisorContainerName,
 		Image: supervisorRef,
@@ -322,21 +404,24 @@ func (runner *Runner) startSupervisor(logger *log.Entry, latestRefs store.ImageR
 			// supervisor needs to know and mount the forta dir on the host os
 			config.EnvHostFortaDir: runner.cfg.FortaDir,
 			config.EnvReleaseInfo:  latestRefs.ReleaseInfo.String(),
+			config.EnvHostRoutes:   strings.Join(hostRoutes, ","),
 		},
-		Volumes: map[string]string{
+		Volumes: runner.withLocalBotSources(map[string]string{
 			// give access to host docker
 			"/var/run/docker.sock": "/var/run/docker.sock",
 			runner.cfg.FortaDir:    config.DefaultContainerFortaDirPath,
-		},
+		}),
 		Ports: map[string]string{
 			"": config.DefaultHealthPort, // random host port
 		},
 		Files: map[string][]byte{
 			"passphrase": []byte(runner.cfg.Passphrase),
 		},
-		DialHost:    true,
-		MaxLogSize:  runner.cfg.Log.MaxLogSize,
-		MaxLogFiles: runner.cfg.Log.MaxLogFiles,
+		Labels:        releaseLabels(latestRefs.ReleaseInfo),
+		DialHost:      true,
+		MaxLogSize:    runner.cfg.Log.MaxLogSize,
+		MaxLogFiles:   runner.cfg.Log.MaxLogFiles,
+		RestartPolicy: runner.containerRestartPolicy(),
 	})
 	if err != nil {
 		logger.WithError(err).Errorf("failed to start the supervisor")
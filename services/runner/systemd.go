@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/sdnotify"
+	log "github.com/sirupsen/logrus"
+)
+
+const systemdNotifyInterval = time.Second * 5
+
+// the containers which need to be running before the node is ready
+var readinessContainerNames = []string{
+	config.DockerSupervisorContainerName,
+	config.DockerScannerContainerName,
+}
+
+// notifySystemd tells systemd when the node becomes healthy and keeps notifying the watchdog
+// only while the node stays healthy, so that systemd can restart a node which is stuck.
+func (runner *Runner) notifySystemd() {
+	watchdogInterval := sdnotify.WatchdogInterval()
+	interval := systemdNotifyInterval
+	if watchdogInterval > 0 && watchdogInterval/2 < interval {
+		interval = watchdogInterval / 2
+	}
+	logger := log.WithField("component", "systemd-notify")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var (
+		ready      bool
+		lastStatus string
+	)
+	for {
+		select {
+		case <-runner.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		healthy, status := getNodeStatus(runner.checkHealth())
+		var states []string
+		if healthy && !ready {
+			ready = true
+			states = append(states, sdnotify.StateReady)
+			logger.Info("node is ready")
+		}
+		if healthy && watchdogInterval > 0 {
+			states = append(states, sdnotify.StateWatchdog)
+		}
+		if status != lastStatus {
+			lastStatus = status
+			states = append(states, sdnotify.Status(status))
+		}
+		if len(states) == 0 {
+			continue
+		}
+		if _, err := sdnotify.Notify(states...); err != nil {
+			logger.WithError(err).Warn("failed to notify systemd")
+		}
+	}
+}
+
+// getNodeStatus tells if the node is healthy by using the runner health reports.
+func getNodeStatus(reports health.Reports) (bool, string) {
+	for _, report := range reports {
+		if report.Status == health.StatusDown {
+			return false, fmt.Sprintf("%s is down", report.Name)
+		}
+	}
+	for _, containerName := range readinessContainerNames {
+		report, ok := reports.GetByName(fmt.Sprintf("forta.container.%s", containerName))
+		if !ok || report.Status != health.StatusOK {
+			return false, fmt.Sprintf("waiting for %s", containerName)
+		}
+	}
+	return true, "healthy"
+}
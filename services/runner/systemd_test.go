@@ -0,0 +1,30 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetNodeStatus(t *testing.T) {
+	r := require.New(t)
+
+	reports := health.Reports{
+		{Name: "forta.version", Status: health.StatusInfo},
+		{Name: "forta.container.forta-supervisor", Status: health.StatusOK},
+	}
+	healthy, status := getNodeStatus(reports)
+	r.False(healthy)
+	r.Equal("waiting for forta-scanner", status)
+
+	reports = append(reports, &health.Report{Name: "forta.container.forta-scanner", Status: health.StatusOK})
+	healthy, status = getNodeStatus(reports)
+	r.True(healthy)
+	r.Equal("healthy", status)
+
+	reports = append(reports, &health.Report{Name: "forta.container.forta-json-rpc", Status: health.StatusDown})
+	healthy, status = getNodeStatus(reports)
+	r.False(healthy)
+	r.Equal("forta.container.forta-json-rpc is down", status)
+}
@@ -2,8 +2,13 @@ package agentpool
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -17,7 +22,11 @@ import (
 	"github.com/forta-network/forta-node/config"
 	"github.com/forta-network/forta-node/services/scanner"
 	"github.com/forta-network/forta-node/services/scanner/agentpool/poolagent"
+	"github.com/forta-network/forta-node/tlsutils"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 )
 
 // AgentPool maintains the pool of agents that the scanner should
@@ -33,6 +42,32 @@ type AgentPool struct {
 	dialer                  func(config.AgentConfig) (clients.AgentClient, error)
 	mu                      sync.RWMutex
 	botWaitGroup            *sync.WaitGroup
+	waitedBots              map[string]bool
+	latestBlockInput        uint64
+	latestBlockTimestamp    int64
+	warmingUp               map[string]bool
+	txDeadlines             resultDeadlines
+	ticks                   tickSchedule
+
+	// the built-in canary bot and the number of the canary transactions sent to it
+	canary       *poolagent.Agent
+	canariesSent uint64 // accessed atomically
+
+	// the latest bot list and the bots which are disabled locally
+	latestVersions messaging.AgentPayload
+	disabledBots   map[string]bool
+	// the bots which the supervisor refused to start and the bots which failed to start
+	refusedBots map[string]config.AgentConfig
+	failedBots  map[string]bool
+
+	// the number of the replicas of the bots which are auto-scaled
+	scaledReplicas map[string]int
+
+	// sequence numbers of the event streams
+	txSequence        uint64
+	pendingTxSequence uint64
+	blockSequence     uint64
+	alertSequence     uint64
 }
 
 // NewAgentPool creates a new agent pool.
@@ -44,8 +79,31 @@ func NewAgentPool(ctx context.Context, cfg config.Config, msgClient clients.Mess
 		blockResults:            make(chan *scanner.BlockResult),
 		combinationAlertResults: make(chan *scanner.CombinationAlertResult),
 		msgClient:               msgClient,
+		warmingUp:               make(map[string]bool),
+		waitedBots:              make(map[string]bool),
+		disabledBots:            make(map[string]bool),
+		refusedBots:             make(map[string]config.AgentConfig),
+		failedBots:              make(map[string]bool),
+		scaledReplicas:          make(map[string]int),
+		txDeadlines: resultDeadlines{
+			timeout: time.Duration(cfg.Scan.ResultDeadlineSeconds) * time.Second,
+		},
+		ticks: tickSchedule{
+			minInterval: time.Duration(cfg.Scan.MinTickIntervalSeconds) * time.Second,
+		},
 		dialer: func(ac config.AgentConfig) (clients.AgentClient, error) {
-			client := agentgrpc.NewClient()
+			client := agentgrpc.NewClient().WithConnConfig(cfg.AgentGrpc).OnReconnect(func() {
+				metrics.SendAgentMetrics(msgClient, []*protocol.AgentMetric{
+					metrics.CreateAgentMetric(ac.ID, metrics.MetricGrpcReconnect, 1),
+				})
+			})
+			if cfg.AgentTLS.Enable {
+				tlsConfig, err := tlsutils.ClientConfig(tlsutils.AllowPeers(config.AgentContainerNamePrefix + "*"))
+				if err != nil {
+					return nil, fmt.Errorf("failed to load the agent tls config: %v", err)
+				}
+				client.WithTLS(tlsConfig)
+			}
 			if err := client.Dial(ac); err != nil {
 				return nil, err
 			}
@@ -60,6 +118,13 @@ func NewAgentPool(ctx context.Context, cfg config.Config, msgClient clients.Mess
 
 	agentPool.registerMessageHandlers()
 	go agentPool.logAgentChanBuffersLoop()
+	go agentPool.tickLoop()
+	if cfg.Scan.Canary.Enable {
+		agentPool.startCanary()
+	}
+	if cfg.Scan.Replicas.AutoScale.Enable {
+		go agentPool.autoScaleLoop()
+	}
 	return agentPool
 }
 
@@ -69,17 +134,12 @@ func (ap *AgentPool) Health() health.Reports {
 	defer ap.mu.RUnlock()
 
 	agentCount := len(ap.agents)
-	var fullCount int
-	for _, agent := range ap.agents {
-		if agent.TxBufferIsFull() {
-			fullCount++
-		}
-	}
+	fullCount := ap.laggingAgentCount()
 	status := health.StatusOK
 	if agentCount == 0 {
 		status = health.StatusFailing
 	}
-	return health.Reports{
+	reports := health.Reports{
 		&health.Report{
 			Name:    "agents.total",
 			Status:  status,
@@ -90,7 +150,68 @@ func (ap *AgentPool) Health() health.Reports {
 			Status:  health.StatusInfo,
 			Details: strconv.Itoa(fullCount),
 		},
+		&health.Report{
+			Name:    "agents.disabled",
+			Status:  health.StatusInfo,
+			Details: strings.Join(ap.disabledBotIDs(), ", "),
+		},
+		&health.Report{
+			Name:    "agents.refused",
+			Status:  health.StatusInfo,
+			Details: strings.Join(ap.refusedBotIDs(), ", "),
+		},
+		&health.Report{
+			Name:    "agents.failed",
+			Status:  health.StatusInfo,
+			Details: strings.Join(ap.failedBotIDs(), ", "),
+		},
+	}
+	if replicated := ap.replicatedBots(); len(replicated) > 0 {
+		reports = append(reports, &health.Report{
+			Name:    "agents.replicas",
+			Status:  health.StatusInfo,
+			Details: strings.Join(replicated, ", "),
+		})
+	}
+	if ap.canary != nil {
+		reports = append(reports, &health.Report{
+			Name:    "canary.sent",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&ap.canariesSent), 10),
+		})
 	}
+	for _, agent := range ap.agents {
+		agentStatus := health.StatusInfo
+		if agent.TxBufferIsFull() {
+			agentStatus = health.StatusLagging
+		}
+		details := fmt.Sprintf("latency=%dms", agent.LatencyMs())
+		if late := agent.LateResults(); late > 0 {
+			details = fmt.Sprintf("%s, late=%d", details, late)
+		}
+		if invalid := agent.InvalidFindings(); invalid > 0 {
+			details = fmt.Sprintf("%s, invalid=%d", details, invalid)
+		}
+		if cancelled := agent.CancelledEvaluations(); cancelled > 0 {
+			details = fmt.Sprintf("%s, cancelled=%d", details, cancelled)
+		}
+		if tickInterval := agent.TickInterval(); tickInterval > 0 {
+			details = fmt.Sprintf("%s, tick=%s", details, tickInterval)
+		}
+		if stats, ok := agent.ConnStats(); ok {
+			details = fmt.Sprintf("%s, conn=%s, reconnects=%d", details, stats.State, stats.Reconnects)
+		}
+		name := fmt.Sprintf("agent.%s", agent.Config().ID)
+		if replica := agent.Config().ReplicaIndex(); replica > 0 {
+			name = fmt.Sprintf("%s.replica-%d", name, replica)
+		}
+		reports = append(reports, &health.Report{
+			Name:    name,
+			Status:  agentStatus,
+			Details: details,
+		})
+	}
+	return reports
 }
 
 // Name implements health.Reporter interface.
@@ -98,6 +219,71 @@ func (ap *AgentPool) Name() string {
 	return "agent-pool"
 }
 
+// Start implements services.Service interface. The pool is in the service list only to get
+// the pause state and the disabled bots, and it starts working with the bot list updates.
+func (ap *AgentPool) Start() error {
+	return nil
+}
+
+// Stop implements services.Service interface.
+func (ap *AgentPool) Stop() error {
+	return nil
+}
+
+// replicatedBots returns the bots which run in multiple replicas with the number of the replicas. It expects
+// the lock to be held.
+func (ap *AgentPool) replicatedBots() []string {
+	replicas := make(map[string]uint)
+	for _, agent := range ap.agents {
+		if replicaCfg := agent.Config().ReplicaConfig; replicaCfg != nil {
+			replicas[agent.Config().ID] = replicaCfg.Replicas
+		}
+	}
+	var bots []string
+	for botID, count := range replicas {
+		bots = append(bots, fmt.Sprintf("%s=%d", botID, count))
+	}
+	sort.Strings(bots)
+	return bots
+}
+
+// laggingAgentCount counts the agents with a full buffer. It expects the lock to be held.
+func (ap *AgentPool) laggingAgentCount() (count int) {
+	for _, agent := range ap.agents {
+		if agent.TxBufferIsFull() {
+			count++
+		}
+	}
+	return
+}
+
+func (ap *AgentPool) handleStatusRequest() (*messaging.ScannerStatus, error) {
+	ap.mu.RLock()
+	defer ap.mu.RUnlock()
+
+	return &messaging.ScannerStatus{
+		LatestBlockInput:     atomic.LoadUint64(&ap.latestBlockInput),
+		LatestBlockTimestamp: atomic.LoadInt64(&ap.latestBlockTimestamp),
+		LaggingAgents:        ap.laggingAgentCount(),
+	}, nil
+}
+
+// CheckReady tells if at least one bot is ready to evaluate, for the startup barrier of the scanner.
+func (ap *AgentPool) CheckReady() error {
+	ap.mu.RLock()
+	defer ap.mu.RUnlock()
+
+	for _, agent := range ap.agents {
+		if agent.IsReady() {
+			return nil
+		}
+	}
+	if len(ap.agents) == 0 {
+		return errors.New("no bots assigned yet")
+	}
+	return fmt.Errorf("%d bot(s) warming up", len(ap.agents))
+}
+
 func (ap *AgentPool) logBotWait() {
 	if ap.botWaitGroup != nil {
 		ap.botWaitGroup.Wait()
@@ -109,6 +295,8 @@ func (ap *AgentPool) logBotWait() {
 // request channels to be deallocated.
 func (ap *AgentPool) discardAgent(discarded *poolagent.Agent) {
 	ap.mu.Lock()
+	defer ap.mu.Unlock()
+
 	var newAgents []*poolagent.Agent
 	for _, agent := range ap.agents {
 		if agent != discarded {
@@ -118,12 +306,29 @@ func (ap *AgentPool) discardAgent(discarded *poolagent.Agent) {
 		}
 	}
 	ap.agents = newAgents
-	ap.mu.Unlock()
 }
 
 // SendEvaluateTxRequest sends the request to all of the active agents which
-// should be processing the block.
+// should be processing the block. The request is sent to each agent without waiting
+// for the other agents and the results which arrive after the result deadline of the
+// block are tagged as late.
 func (ap *AgentPool) SendEvaluateTxRequest(req *protocol.EvaluateTxRequest) {
+	deadline := ap.txDeadlines.For(req.Event.Block.BlockNumber, time.Now())
+	ap.sendEvaluateTxRequest(req, deadline, false, func(agent *poolagent.Agent) bool {
+		return agent.ShouldProcessBlock(req.Event.Block.BlockNumber)
+	})
+}
+
+// SendEvaluatePendingTxRequest sends the pending tx request to the active agents which
+// opted in to the mempool scanning and should be processing the latest block. The request
+// has no block and is marked as pending with the event extension.
+func (ap *AgentPool) SendEvaluatePendingTxRequest(req *protocol.EvaluateTxRequest, latestBlockNumber string) {
+	ap.sendEvaluateTxRequest(req, time.Time{}, true, func(agent *poolagent.Agent) bool {
+		return ap.cfg.Scan.Mempool.IsBotEnabled(agent.Config().ID) && agent.ShouldProcessBlock(latestBlockNumber)
+	})
+}
+
+func (ap *AgentPool) sendEvaluateTxRequest(req *protocol.EvaluateTxRequest, deadline time.Time, pending bool, shouldProcess func(*poolagent.Agent) bool) {
 	startTime := time.Now()
 	lg := log.WithFields(log.Fields{
 		"tx":        req.Event.Transaction.Hash,
@@ -139,14 +344,40 @@ func (ap *AgentPool) SendEvaluateTxRequest(req *protocol.EvaluateTxRequest) {
 	agents := ap.agents
 	ap.mu.RUnlock()
 
-	encoded, err := agentgrpc.EncodeMessage(req)
+	// each transaction goes to only one of the replicas of a bot
+	replicas := txReplicas(agents)
+	shouldProcessTx := func(agent *poolagent.Agent) bool {
+		return shouldProcess(agent) && isTxReplica(agent, req.Event.Transaction.Hash, replicas)
+	}
+
+	limitedReq, trimmed, ok := limitTxRequest(req, ap.cfg.Scan.PayloadLimits.MaxTxBytes)
+	if !ok {
+		lg.WithField("size", proto.Size(req)).Warn("request is too large even after trimming - skipping")
+		metrics.SendAgentMetrics(ap.msgClient, eligibleAgentMetrics(agents, shouldProcessTx, metrics.MetricTxTooLarge))
+		return
+	}
+	if len(trimmed) > 0 {
+		lg.WithField("trimmed", trimmed).Warn("trimmed the large request")
+	}
+
+	// the pending transactions have their own stream
+	sequence := &ap.txSequence
+	if pending {
+		sequence = &ap.pendingTxSequence
+	}
+	encoded, err := agentgrpc.EncodeRequest(limitedReq, agentgrpc.EventExtension{
+		Sequence: atomic.AddUint64(sequence, 1),
+		Trimmed:  trimmed,
+		Pending:  pending,
+	}, ap.cfg.AgentGrpc.GetCompression())
 	if err != nil {
 		lg.WithError(err).Error("failed to encode message")
 		return
 	}
+	size, wireSize := agentgrpc.MessageSize(encoded)
 	var metricsList []*protocol.AgentMetric
 	for _, agent := range agents {
-		if !agent.IsReady() || !agent.ShouldProcessBlock(req.Event.Block.BlockNumber) {
+		if !agent.IsReady() || !shouldProcessTx(agent) {
 			continue
 		}
 		lg.WithFields(log.Fields{
@@ -162,7 +393,13 @@ func (ap *AgentPool) SendEvaluateTxRequest(req *protocol.EvaluateTxRequest) {
 		case agent.TxRequestCh() <- &poolagent.TxRequest{
 			Original: req,
 			Encoded:  encoded,
+			Deadline: deadline,
+			Pending:  pending,
 		}:
+			if len(trimmed) > 0 {
+				metricsList = append(metricsList, metrics.CreateAgentMetric(agent.Config().ID, metrics.MetricTxTrimmed, 1))
+			}
+			metricsList = append(metricsList, ap.requestSizeMetrics(agent.Config().ID, size, wireSize)...)
 		default: // do not try to send if the buffer is full
 			lg.WithField("agent", agent.Config().ID).Debug("agent tx request buffer is full - skipping")
 			metricsList = append(metricsList, metrics.CreateAgentMetric(agent.Config().ID, metrics.MetricTxDrop, 1))
@@ -179,6 +416,26 @@ func (ap *AgentPool) SendEvaluateTxRequest(req *protocol.EvaluateTxRequest) {
 	}).Debug("Finished SendEvaluateTxRequest")
 }
 
+// requestSizeMetrics creates the payload size metrics of a request which is sent to the agent. The size
+// on the wire is different only if the requests are compressed.
+func (ap *AgentPool) requestSizeMetrics(agentID string, size, wireSize int) []*protocol.AgentMetric {
+	sizeMetrics := []*protocol.AgentMetric{metrics.CreateAgentMetric(agentID, metrics.MetricAgentRequestBytes, float64(size))}
+	if len(ap.cfg.AgentGrpc.GetCompression()) > 0 {
+		sizeMetrics = append(sizeMetrics, metrics.CreateAgentMetric(agentID, metrics.MetricAgentRequestWire, float64(wireSize)))
+	}
+	return sizeMetrics
+}
+
+// eligibleAgentMetrics creates a metric for each ready agent which should process the request.
+func eligibleAgentMetrics(agents []*poolagent.Agent, shouldProcess func(*poolagent.Agent) bool, metricName string) (metricsList []*protocol.AgentMetric) {
+	for _, agent := range agents {
+		if agent.IsReady() && shouldProcess(agent) {
+			metricsList = append(metricsList, metrics.CreateAgentMetric(agent.Config().ID, metricName, 1))
+		}
+	}
+	return
+}
+
 // TxResults returns the receive-only tx results channel.
 func (ap *AgentPool) TxResults() <-chan *scanner.TxResult {
 	return ap.txResults
@@ -202,15 +459,33 @@ func (ap *AgentPool) SendEvaluateBlockRequest(req *protocol.EvaluateBlockRequest
 	agents := ap.agents
 	ap.mu.RUnlock()
 
-	encoded, err := agentgrpc.EncodeMessage(req)
+	limitedReq, trimmed, ok := limitBlockRequest(req, ap.cfg.Scan.PayloadLimits.MaxBlockBytes)
+	if !ok {
+		lg.WithField("size", proto.Size(req)).Warn("request is too large even after trimming - skipping")
+		metrics.SendAgentMetrics(ap.msgClient, eligibleAgentMetrics(agents, func(agent *poolagent.Agent) bool {
+			return agent.IsPrimaryReplica() && agent.ShouldProcessBlock(req.Event.BlockNumber)
+		}, metrics.MetricBlockTooLarge))
+		return
+	}
+	if len(trimmed) > 0 {
+		lg.WithField("trimmed", trimmed).Warn("trimmed the large request")
+	}
+	ap.ticks.SetLatest(req)
+
+	encoded, err := agentgrpc.EncodeRequest(limitedReq, agentgrpc.EventExtension{
+		Sequence: atomic.AddUint64(&ap.blockSequence, 1),
+		Trimmed:  trimmed,
+	}, ap.cfg.AgentGrpc.GetCompression())
 	if err != nil {
 		lg.WithError(err).Error("failed to encode message")
 		return
 	}
+	size, wireSize := agentgrpc.MessageSize(encoded)
 
 	var metricsList []*protocol.AgentMetric
 	for _, agent := range agents {
-		if !agent.IsReady() || !agent.ShouldProcessBlock(req.Event.BlockNumber) {
+		// the blocks are evaluated only by the first replica of a bot
+		if !agent.IsReady() || !agent.IsPrimaryReplica() || !agent.ShouldProcessBlock(req.Event.BlockNumber) {
 			continue
 		}
 
@@ -227,6 +502,10 @@ func (ap *AgentPool) SendEvaluateBlockRequest(req *protocol.EvaluateBlockRequest
 			Original: req,
 			Encoded:  encoded,
 		}:
+			if len(trimmed) > 0 {
+				metricsList = append(metricsList, metrics.CreateAgentMetric(agent.Config().ID, metrics.MetricBlockTrimmed, 1))
+			}
+			metricsList = append(metricsList, ap.requestSizeMetrics(agent.Config().ID, size, wireSize)...)
 		default: // do not try to send if the buffer is full
 			lg.WithField("agent", agent.Config().ID).Warn("agent block request buffer is full - skipping")
 			metricsList = append(metricsList, metrics.CreateAgentMetric(agent.Config().ID, metrics.MetricBlockDrop, 1))
@@ -240,6 +519,11 @@ func (ap *AgentPool) SendEvaluateBlockRequest(req *protocol.EvaluateBlockRequest
 	}
 
 	blockNumber, _ := hexutil.DecodeUint64(req.Event.BlockNumber)
+	atomic.StoreUint64(&ap.latestBlockInput, blockNumber)
+	if req.Event.Block != nil {
+		blockTimestamp, _ := hexutil.DecodeUint64(req.Event.Block.Timestamp)
+		atomic.StoreInt64(&ap.latestBlockTimestamp, int64(blockTimestamp))
+	}
 	ap.msgClient.Publish(messaging.SubjectScannerBlock, &messaging.ScannerPayload{
 		LatestBlockInput: blockNumber,
 	})
@@ -250,6 +534,92 @@ func (ap *AgentPool) SendEvaluateBlockRequest(req *protocol.EvaluateBlockRequest
 	}).Debug("Finished SendEvaluateBlockRequest")
 }
 
+func (ap *AgentPool) tickLoop() {
+	ticker := time.NewTicker(tickCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ap.ctx.Done():
+			return
+		case now := <-ticker.C:
+			ap.sendTicks(now)
+		}
+	}
+}
+
+// sendTicks sends the latest block to the agents which requested to be evaluated periodically
+// and are due. The ticks do not advance the block sequence.
+func (ap *AgentPool) sendTicks(now time.Time) {
+	latestReq := ap.ticks.Latest()
+	if latestReq == nil {
+		return
+	}
+
+	ap.mu.RLock()
+	agents := ap.agents
+	ap.mu.RUnlock()
+
+	var (
+		encoded     *grpc.PreparedMsg
+		tickReq     *protocol.EvaluateBlockRequest
+		metricsList []*protocol.AgentMetric
+	)
+	activeBots := make(map[string]bool)
+	for _, agent := range agents {
+		if !agent.IsReady() || !agent.IsPrimaryReplica() {
+			continue
+		}
+		botID := agent.Config().ID
+		activeBots[botID] = true
+		if !agent.ShouldProcessBlock(latestReq.Event.BlockNumber) || !ap.ticks.Due(botID, agent.TickInterval(), now) {
+			continue
+		}
+		if encoded == nil {
+			var err error
+			tickReq, encoded, err = ap.encodeTick(latestReq)
+			if err != nil {
+				log.WithError(err).WithField("block", latestReq.Event.BlockNumber).Error("failed to encode the tick")
+				return
+			}
+		}
+
+		select {
+		case <-agent.Closed():
+			ap.discardAgent(agent)
+		case agent.BlockRequestCh() <- &poolagent.BlockRequest{
+			Original: tickReq,
+			Encoded:  encoded,
+		}:
+			metricsList = append(metricsList, metrics.CreateAgentMetric(botID, metrics.MetricAgentTick, 1))
+		default: // do not try to send if the buffer is full
+			log.WithField("agent", botID).Warn("agent block request buffer is full - skipping the tick")
+			metricsList = append(metricsList, metrics.CreateAgentMetric(botID, metrics.MetricAgentTickDrop, 1))
+		}
+	}
+	ap.ticks.Retain(activeBots)
+	metrics.SendAgentMetrics(ap.msgClient, metricsList)
+}
+
+// encodeTick creates a new request for the latest block and encodes it with the tick extension.
+func (ap *AgentPool) encodeTick(latestReq *protocol.EvaluateBlockRequest) (*protocol.EvaluateBlockRequest, *grpc.PreparedMsg, error) {
+	tickReq := &protocol.EvaluateBlockRequest{
+		RequestId: uuid.Must(uuid.NewUUID()).String(),
+		Event:     latestReq.Event,
+	}
+	limitedReq, trimmed, ok := limitBlockRequest(tickReq, ap.cfg.Scan.PayloadLimits.MaxBlockBytes)
+	if !ok {
+		return nil, nil, fmt.Errorf("request is too large even after trimming")
+	}
+	encoded, err := agentgrpc.EncodeRequest(limitedReq, agentgrpc.EventExtension{
+		Trimmed: trimmed,
+		Tick:    true,
+	}, ap.cfg.AgentGrpc.GetCompression())
+	if err != nil {
+		return nil, nil, err
+	}
+	return tickReq, encoded, nil
+}
+
 // SendEvaluateAlertRequest sends the request to all the active agents which
 // should be processing the alert.
 func (ap *AgentPool) SendEvaluateAlertRequest(req *protocol.EvaluateAlertRequest) {
@@ -272,15 +642,32 @@ func (ap *AgentPool) SendEvaluateAlertRequest(req *protocol.EvaluateAlertRequest
 	agents := ap.agents
 	ap.mu.RUnlock()
 
-	encoded, err := agentgrpc.EncodeMessage(req)
+	limitedReq, trimmed, ok := limitAlertRequest(req, ap.cfg.Scan.PayloadLimits.MaxAlertBytes)
+	if !ok {
+		lg.WithField("size", proto.Size(req)).Warn("request is too large even after trimming - skipping")
+		metrics.SendAgentMetrics(ap.msgClient, eligibleAgentMetrics(agents, func(agent *poolagent.Agent) bool {
+			return agent.IsPrimaryReplica() && agent.ShouldProcessAlert(req.Event)
+		}, metrics.MetricCombinerTooLarge))
+		return
+	}
+	if len(trimmed) > 0 {
+		lg.WithField("trimmed", trimmed).Warn("trimmed the large request")
+	}
+
+	encoded, err := agentgrpc.EncodeRequest(limitedReq, agentgrpc.EventExtension{
+		Sequence: atomic.AddUint64(&ap.alertSequence, 1),
+		Trimmed:  trimmed,
+	}, ap.cfg.AgentGrpc.GetCompression())
 	if err != nil {
 		lg.WithError(err).Error("failed to encode message")
 		return
 	}
+	size, wireSize := agentgrpc.MessageSize(encoded)
 
 	var metricsList []*protocol.AgentMetric
 	for _, agent := range agents {
-		if !agent.IsReady() || !agent.ShouldProcessAlert(req.Event) {
+		// the alerts are evaluated only by the first replica of a bot
+		if !agent.IsReady() || !agent.IsPrimaryReplica() || !agent.ShouldProcessAlert(req.Event) {
 			continue
 		}
 
@@ -297,6 +684,10 @@ func (ap *AgentPool) SendEvaluateAlertRequest(req *protocol.EvaluateAlertRequest
 			Original: req,
 			Encoded:  encoded,
 		}:
+			if len(trimmed) > 0 {
+				metricsList = append(metricsList, metrics.CreateAgentMetric(agent.Config().ID, metrics.MetricCombinerTrimmed, 1))
+			}
+			metricsList = append(metricsList, ap.requestSizeMetrics(agent.Config().ID, size, wireSize)...)
 		default: // do not try to send if the buffer is full
 			lg.WithField("agent", agent.Config().ID).Warn("agent alert request buffer is full - skipping")
 			metricsList = append(metricsList, metrics.CreateAgentMetric(agent.Config().ID, metrics.MetricCombinerDrop, 1))
@@ -341,11 +732,114 @@ func (ap *AgentPool) BlockResults() <-chan *scanner.BlockResult {
 	return ap.blockResults
 }
 
+// SetDisabledBots implements services.BotDisabler. The disabled bots are stopped and the
+// enabled bots are run again.
+func (ap *AgentPool) SetDisabledBots(botIDs []string) {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+
+	ap.disabledBots = make(map[string]bool)
+	for _, botID := range botIDs {
+		ap.disabledBots[strings.ToLower(botID)] = true
+	}
+
+	if ap.latestVersions != nil {
+		ap.applyLatestVersions()
+	}
+}
+
+// SetPaused implements services.Pauser. The evaluations in progress are cancelled when paused
+// because their results are not published.
+func (ap *AgentPool) SetPaused(paused bool) {
+	if !paused {
+		return
+	}
+	ap.cancelEvaluations(log.WithField("reason", agentgrpc.CancelReasonPaused), func(agent *poolagent.Agent) int {
+		return agent.CancelAllEvaluations(agentgrpc.CancelReasonPaused)
+	})
+}
+
+// handleReorg cancels the evaluations of the block which was reorged out.
+func (ap *AgentPool) handleReorg(payload messaging.ReorgPayload) error {
+	lg := log.WithFields(log.Fields{
+		"reason":       agentgrpc.CancelReasonReorg,
+		"block":        payload.BlockNumber,
+		"orphanedHash": payload.OrphanedHash,
+	})
+	ap.cancelEvaluations(lg, func(agent *poolagent.Agent) int {
+		return agent.CancelBlockEvaluations(payload.OrphanedHash, agentgrpc.CancelReasonReorg)
+	})
+	return nil
+}
+
+func (ap *AgentPool) cancelEvaluations(lg *log.Entry, cancel func(*poolagent.Agent) int) {
+	ap.mu.RLock()
+	agents := ap.agents
+	ap.mu.RUnlock()
+
+	var count int
+	for _, agent := range agents {
+		count += cancel(agent)
+	}
+	if count > 0 {
+		lg.WithField("count", count).Info("cancelled the superseded evaluations")
+	}
+}
+
+// refusedBotIDs expects the lock to be held.
+func (ap *AgentPool) refusedBotIDs() []string {
+	var botIDs []string
+	seen := make(map[string]bool)
+	for _, agentCfg := range ap.refusedBots {
+		if !seen[agentCfg.ID] {
+			seen[agentCfg.ID] = true
+			botIDs = append(botIDs, agentCfg.ID)
+		}
+	}
+	sort.Strings(botIDs)
+	return botIDs
+}
+
+// failedBotIDs expects the lock to be held.
+func (ap *AgentPool) failedBotIDs() []string {
+	botIDs := make([]string, 0, len(ap.failedBots))
+	for botID := range ap.failedBots {
+		botIDs = append(botIDs, botID)
+	}
+	sort.Strings(botIDs)
+	return botIDs
+}
+
+// disabledBotIDs expects the lock to be held.
+func (ap *AgentPool) disabledBotIDs() []string {
+	botIDs := make([]string, 0, len(ap.disabledBots))
+	for botID := range ap.disabledBots {
+		botIDs = append(botIDs, botID)
+	}
+	sort.Strings(botIDs)
+	return botIDs
+}
+
 func (ap *AgentPool) handleAgentVersionsUpdate(payload messaging.AgentPayload) error {
 	ap.mu.Lock()
 	defer ap.mu.Unlock()
 
-	latestVersions := payload
+	ap.latestVersions = payload
+	ap.applyLatestVersions()
+	return nil
+}
+
+// applyLatestVersions runs and stops the bots by the latest bot list. It expects the lock to be held.
+func (ap *AgentPool) applyLatestVersions() {
+	// skip the bots which are disabled locally so that they are stopped or not run, and run
+	// each replica of the replicated bots
+	var latestVersions messaging.AgentPayload
+	for _, agentCfg := range ap.latestVersions {
+		if ap.disabledBots[strings.ToLower(agentCfg.ID)] {
+			continue
+		}
+		latestVersions = append(latestVersions, ap.replicaConfigs(agentCfg)...)
+	}
 
 	// The agents list which we completely replace with the old ones.
 	var newAgents []*poolagent.Agent
@@ -359,6 +853,7 @@ func (ap *AgentPool) handleAgentVersionsUpdate(payload messaging.AgentPayload) e
 			if agent.Config().ContainerName() == agentCfg.ContainerName() {
 				found = true
 				agent.SetShardConfig(agentCfg)
+				agent.SetReplicaConfig(agentCfg)
 				break
 			}
 		}
@@ -390,6 +885,22 @@ func (ap *AgentPool) handleAgentVersionsUpdate(payload messaging.AgentPayload) e
 		}
 	}
 
+	// The supervisor runs the refused bots later if they are still assigned, so it should
+	// forget the bots which are not assigned anymore.
+	for containerName, agentCfg := range ap.refusedBots {
+		var found bool
+		for _, latestCfg := range latestVersions {
+			if latestCfg.ContainerName() == containerName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			delete(ap.refusedBots, containerName)
+			agentsToStop = append(agentsToStop, agentCfg)
+		}
+	}
+
 	ap.agents = newAgents
 	if len(agentsToRun) > 0 {
 		ap.msgClient.Publish(messaging.SubjectAgentsActionRun, agentsToRun)
@@ -402,61 +913,133 @@ func (ap *AgentPool) handleAgentVersionsUpdate(payload messaging.AgentPayload) e
 	if len(agentsToRun) > 0 && ap.cfg.LocalModeConfig.IsStandalone() {
 		ap.msgClient.Publish(messaging.SubjectAgentsStatusRunning, agentsToRun)
 	}
+}
 
-	return nil
+// agentsToAttach finds the agents which were added before and just started to run, and marks them
+// as warming up. The refused agents are added back when the supervisor runs them later.
+func (ap *AgentPool) agentsToAttach(payload messaging.AgentPayload) []*poolagent.Agent {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+
+	var agentsToAttach []*poolagent.Agent
+	for _, agentCfg := range payload {
+		var found bool
+		for _, agent := range ap.agents {
+			if agent.Config().ContainerName() == agentCfg.ContainerName() {
+				found = true
+				if agent.IsReady() || ap.warmingUp[agentCfg.ContainerName()] {
+					continue
+				}
+				ap.warmingUp[agentCfg.ContainerName()] = true
+				agentsToAttach = append(agentsToAttach, agent)
+			}
+		}
+		refusedCfg, refused := ap.refusedBots[agentCfg.ContainerName()]
+		if found || !refused {
+			continue
+		}
+		agent := poolagent.New(ap.ctx, refusedCfg, ap.msgClient, ap.txResults, ap.blockResults, ap.combinationAlertResults)
+		ap.agents = append(ap.agents, agent)
+		ap.warmingUp[agentCfg.ContainerName()] = true
+		agentsToAttach = append(agentsToAttach, agent)
+		log.WithField("agent", agentCfg.ID).Info("refused bot was started later")
+	}
+	return agentsToAttach
 }
 
 func (ap *AgentPool) handleStatusRunning(payload messaging.AgentPayload) error {
+	agentsToAttach := ap.agentsToAttach(payload)
+
+	// Dial and warm up the agents in parallel and without holding the lock,
+	// so a slow bot does not block the other bots and the pool.
+	warmUpErrs := make([]error, len(agentsToAttach))
+	dialErrs := make([]error, len(agentsToAttach))
+	var wg sync.WaitGroup
+	for i, agent := range agentsToAttach {
+		wg.Add(1)
+		go func(i int, agent *poolagent.Agent) {
+			defer wg.Done()
+			c, err := ap.dialer(agent.Config())
+			if err != nil {
+				dialErrs[i] = err
+				return
+			}
+			agent.SetClient(c)
+			warmUpErrs[i] = agent.WarmUp(time.Duration(ap.cfg.Scan.BotWarmUpTimeoutSeconds) * time.Second)
+		}(i, agent)
+	}
+	wg.Wait()
+
 	ap.mu.Lock()
 	defer ap.mu.Unlock()
 
-	// If an agent was added before and just started to run, we should mark as ready.
 	var agentsToStop []config.AgentConfig
 	var agentsReady []config.AgentConfig
+	var agentsFailed []config.AgentConfig
+	var agentsToRemove []*poolagent.Agent
 	var newSubscriptions []messaging.CombinerBotSubscription
 	var removedSubscriptions []messaging.CombinerBotSubscription
 
-	for _, agentCfg := range payload {
-		for _, agent := range ap.agents {
-			if agent.Config().ContainerName() == agentCfg.ContainerName() {
-				logger := log.WithField("agent", agent.Config().ID)
-				if agent.IsReady() {
-					continue
-				}
+	for i, agent := range agentsToAttach {
+		delete(ap.warmingUp, agent.Config().ContainerName())
+		logger := log.WithField("agent", agent.Config().ID)
 
-				c, err := ap.dialer(agent.Config())
-				if err != nil {
-					log.WithField("agent", agent.Config().ID).WithError(err).Error("handleStatusRunning: error while dialing")
-					agentsToStop = append(agentsToStop, agent.Config())
-					if agent.IsCombinerBot() {
-						for _, subscription := range agent.AlertConfig().Subscriptions {
-							removedSubscriptions = append(removedSubscriptions, messaging.CombinerBotSubscription{Subscription: subscription})
-						}
-					}
-					continue
-				}
-
-				agent.SetClient(c)
-				agent.SetReady()
-				agent.StartProcessing()
-				agent.WaitInitialization()
+		// the agent might have been removed from the pool during the warm-up
+		if !ap.hasAgent(agent) {
+			logger.Info("handleStatusRunning: bot was removed during warm-up")
+			agent.Close()
+			continue
+		}
 
-				if agent.IsCombinerBot() {
-					for _, subscription := range agent.AlertConfig().Subscriptions {
-						newSubscriptions = append(newSubscriptions, messaging.CombinerBotSubscription{Subscription: subscription})
-					}
+		if err := dialErrs[i]; err != nil {
+			logger.WithError(err).Error("handleStatusRunning: error while dialing")
+			agent.Close()
+			agentsToRemove = append(agentsToRemove, agent)
+			agentsToStop = append(agentsToStop, agent.Config())
+			if agent.IsCombinerBot() {
+				for _, subscription := range agent.AlertConfig().Subscriptions {
+					removedSubscriptions = append(removedSubscriptions, messaging.CombinerBotSubscription{Subscription: subscription})
 				}
+			}
+			continue
+		}
+
+		if err := warmUpErrs[i]; err != nil {
+			logger.WithError(err).Error("handleStatusRunning: bot failed to initialize")
+			agent.Close()
+			agentsToRemove = append(agentsToRemove, agent)
+			agentsFailed = append(agentsFailed, agent.Config())
+			agentsToStop = append(agentsToStop, agent.Config())
+			continue
+		}
+		agent.SetReady()
+		agent.StartProcessing()
+		delete(ap.refusedBots, agent.Config().ContainerName())
+		delete(ap.failedBots, agent.Config().ID)
 
-				logger.WithField("image", agent.Config().Image).Info("attached")
-				agentsReady = append(agentsReady, agent.Config())
+		if agent.IsCombinerBot() {
+			for _, subscription := range agent.AlertConfig().Subscriptions {
+				newSubscriptions = append(newSubscriptions, messaging.CombinerBotSubscription{Subscription: subscription})
 			}
 		}
+
+		logger.WithField("image", agent.Config().Image).Info("attached")
+		agentsReady = append(agentsReady, agent.Config())
+	}
+	// the agents which failed to start are removed from the pool and tried again with the next bot list
+	ap.removeAgents(agentsToRemove)
+	for _, agent := range agentsToRemove {
+		ap.failedBots[agent.Config().ID] = true
+		ap.botDone(agent.Config())
+	}
+	for _, agentCfg := range agentsReady {
+		ap.botDone(agentCfg)
 	}
 	if len(agentsReady) > 0 {
 		ap.msgClient.Publish(messaging.SubjectAgentsStatusAttached, agentsReady)
-		if ap.botWaitGroup != nil {
-			ap.botWaitGroup.Add(-len(agentsReady))
-		}
+	}
+	if len(agentsFailed) > 0 {
+		ap.msgClient.Publish(messaging.SubjectAgentsStatusFailedToInitialize, agentsFailed)
 	}
 	if len(agentsToStop) > 0 {
 		ap.msgClient.Publish(messaging.SubjectAgentsActionStop, agentsToStop)
@@ -471,6 +1054,47 @@ func (ap *AgentPool) handleStatusRunning(payload messaging.AgentPayload) error {
 	return nil
 }
 
+// removeAgents removes the given agents from the pool. It expects the lock to be held.
+func (ap *AgentPool) removeAgents(removed []*poolagent.Agent) {
+	if len(removed) == 0 {
+		return
+	}
+	var newAgents []*poolagent.Agent
+	for _, agent := range ap.agents {
+		var found bool
+		for _, removedAgent := range removed {
+			if agent == removedAgent {
+				found = true
+				break
+			}
+		}
+		if !found {
+			newAgents = append(newAgents, agent)
+		}
+	}
+	ap.agents = newAgents
+}
+
+// botDone marks the bot as started or failed for the bot wait. The bots which are tried again
+// are counted once. It expects the lock to be held.
+func (ap *AgentPool) botDone(agentCfg config.AgentConfig) {
+	if ap.botWaitGroup == nil || ap.waitedBots[agentCfg.ContainerName()] {
+		return
+	}
+	ap.waitedBots[agentCfg.ContainerName()] = true
+	ap.botWaitGroup.Done()
+}
+
+// hasAgent checks if the agent is still in the pool. It expects the lock to be held.
+func (ap *AgentPool) hasAgent(agent *poolagent.Agent) bool {
+	for _, poolAgent := range ap.agents {
+		if poolAgent == agent {
+			return true
+		}
+	}
+	return false
+}
+
 func (ap *AgentPool) handleStatusStopped(payload messaging.AgentPayload) error {
 	ap.mu.Lock()
 	defer ap.mu.Unlock()
@@ -495,8 +1119,74 @@ func (ap *AgentPool) handleStatusStopped(payload messaging.AgentPayload) error {
 	return nil
 }
 
+// handleStatusRefused removes the agents which the supervisor refused to start, so that they are
+// not waited for, and reports them in the pool health. They are tried again with the next bot list.
+func (ap *AgentPool) handleStatusRefused(payload messaging.AgentPayload) error {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+
+	var newAgents []*poolagent.Agent
+	for _, agent := range ap.agents {
+		var found bool
+		for _, agentCfg := range payload {
+			if agent.Config().ContainerName() == agentCfg.ContainerName() {
+				found = true
+				break
+			}
+		}
+		if !found || agent.IsReady() {
+			newAgents = append(newAgents, agent)
+			continue
+		}
+		agent.Close()
+		log.WithField("agent", agent.Config().ID).WithField("image", agent.Config().Image).Warn("bot was refused")
+		ap.refusedBots[agent.Config().ContainerName()] = agent.Config()
+		ap.botDone(agent.Config())
+	}
+	ap.agents = newAgents
+	return nil
+}
+
+// handleStatusRecreated replaces the agents of the recreated containers with new agents and attaches
+// them, so that the new containers are initialized and their alert subscriptions are renewed.
+func (ap *AgentPool) handleStatusRecreated(payload messaging.AgentPayload) error {
+	ap.mu.Lock()
+	var removedSubscriptions []messaging.CombinerBotSubscription
+	for i, agent := range ap.agents {
+		var found bool
+		for _, agentCfg := range payload {
+			if agent.Config().ContainerName() == agentCfg.ContainerName() {
+				found = true
+				break
+			}
+		}
+		// the agents which are warming up are attached to the new container already
+		if !found || ap.warmingUp[agent.Config().ContainerName()] {
+			continue
+		}
+		if agent.IsReady() && agent.IsCombinerBot() {
+			for _, subscription := range agent.AlertConfig().Subscriptions {
+				removedSubscriptions = append(removedSubscriptions, messaging.CombinerBotSubscription{Subscription: subscription})
+			}
+		}
+		agent.Close()
+		ap.agents[i] = poolagent.New(ap.ctx, agent.Config(), ap.msgClient, ap.txResults, ap.blockResults, ap.combinationAlertResults)
+		log.WithField("agent", agent.Config().ID).Info("bot container was recreated - attaching again")
+	}
+	ap.mu.Unlock()
+
+	if len(removedSubscriptions) > 0 {
+		ap.msgClient.Publish(messaging.SubjectAgentsAlertUnsubscribe, removedSubscriptions)
+	}
+	return ap.handleStatusRunning(payload)
+}
+
 func (ap *AgentPool) registerMessageHandlers() {
 	ap.msgClient.Subscribe(messaging.SubjectAgentsVersionsLatest, messaging.AgentsHandler(ap.handleAgentVersionsUpdate))
 	ap.msgClient.Subscribe(messaging.SubjectAgentsStatusRunning, messaging.AgentsHandler(ap.handleStatusRunning))
 	ap.msgClient.Subscribe(messaging.SubjectAgentsStatusStopped, messaging.AgentsHandler(ap.handleStatusStopped))
+	ap.msgClient.Subscribe(messaging.SubjectAgentsStatusRefused, messaging.AgentsHandler(ap.handleStatusRefused))
+	ap.msgClient.Subscribe(messaging.SubjectAgentsStatusRecreated, messaging.AgentsHandler(ap.handleStatusRecreated))
+	ap.msgClient.Subscribe(messaging.SubjectScannerReorg, messaging.ReorgHandler(ap.handleReorg))
+	ap.msgClient.Respond(messaging.SubjectScannerStatusRequest, messaging.ScannerStatusHandler(ap.handleStatusRequest))
 }
@@ -2,6 +2,9 @@ package agentpool
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,10 +14,14 @@ import (
 	"github.com/forta-network/forta-node/clients/messaging"
 	mock_clients "github.com/forta-network/forta-node/clients/mocks"
 	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services"
 	"github.com/forta-network/forta-node/services/scanner"
+	"github.com/golang/protobuf/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
 
 	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -54,6 +61,9 @@ func (s *Suite) SetupTest() {
 		blockResults:            make(chan *scanner.BlockResult),
 		combinationAlertResults: make(chan *scanner.CombinationAlertResult),
 		msgClient:               s.msgClient,
+		warmingUp:               make(map[string]bool),
+		refusedBots:             make(map[string]config.AgentConfig),
+		failedBots:              make(map[string]bool),
 		dialer: func(agentCfg config.AgentConfig) (clients.AgentClient, error) {
 			return s.agentClient, nil
 		},
@@ -79,6 +89,7 @@ func (s *Suite) TestStartProcessStop() {
 	emptyPayload := messaging.AgentPayload{}
 
 	s.agentClient.EXPECT().Initialize(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	s.agentClient.EXPECT().EvaluateBlock(gomock.Any(), gomock.Any()).Return(&protocol.EvaluateBlockResponse{}, nil)
 
 	// Given that there are no agents running
 	// When the latest list is received,
@@ -123,6 +134,9 @@ func (s *Suite) TestStartProcessStop() {
 	// save combiner subscription
 	combinerResp := &protocol.EvaluateAlertResponse{Metadata: map[string]string{"imageHash": ""}}
 
+	// the request sizes are sent as metrics
+	s.msgClient.EXPECT().PublishProto(messaging.SubjectMetricAgent, gomock.Any()).Times(3)
+
 	// test tx handling
 	s.agentClient.EXPECT().Invoke(
 		gomock.Any(), agentgrpc.MethodEvaluateTx,
@@ -167,3 +181,281 @@ func (s *Suite) TestStartProcessStop() {
 	s.agentClient.EXPECT().Close()
 	s.r.NoError(s.ap.handleAgentVersionsUpdate(emptyPayload))
 }
+
+// TestFailedToInitialize tests that an agent which cannot warm up is not attached.
+func (s *Suite) TestFailedToInitialize() {
+	agentConfig := config.AgentConfig{
+		ID: testAgentID,
+	}
+	agentPayload := messaging.AgentPayload{
+		agentConfig,
+	}
+
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionRun, gomock.Any())
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(agentPayload))
+
+	// Given that the bot fails to respond to the warm-up evaluation
+	s.agentClient.EXPECT().Initialize(gomock.Any(), gomock.Any()).Return(nil, nil)
+	s.agentClient.EXPECT().EvaluateBlock(gomock.Any(), gomock.Any()).Return(nil, errors.New("failed"))
+	s.agentClient.EXPECT().Close()
+	// When the agent pool receives a message saying that the agent started to run
+	// Then the bot should be reported as failed and stopped
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusFailedToInitialize, agentPayload)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionStop, agentPayload)
+	s.r.NoError(s.ap.handleStatusRunning(agentPayload))
+
+	// And it should be removed from the pool and reported
+	s.r.Len(s.ap.agents, 0)
+	s.r.Error(s.ap.CheckReady())
+	failed, ok := s.ap.Health().GetByName("agents.failed")
+	s.r.True(ok)
+	s.r.Equal(testAgentID, failed.Details)
+
+	// And it should be tried again with the next list
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionRun, agentPayload)
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(agentPayload))
+	s.r.Len(s.ap.agents, 1)
+}
+
+// TestFailedToDial tests that an agent which cannot be dialed is removed from the pool.
+func (s *Suite) TestFailedToDial() {
+	agentPayload := messaging.AgentPayload{
+		config.AgentConfig{ID: testAgentID},
+	}
+	s.ap.botWaitGroup = &sync.WaitGroup{}
+	s.ap.botWaitGroup.Add(1)
+	s.ap.waitedBots = make(map[string]bool)
+	s.ap.dialer = func(agentCfg config.AgentConfig) (clients.AgentClient, error) {
+		return nil, errors.New("failed")
+	}
+
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionRun, agentPayload).Times(2)
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(agentPayload))
+
+	// When the agent pool cannot dial the bot after it starts running
+	// Then the bot should be stopped and removed from the pool
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionStop, agentPayload).Times(2)
+	s.r.NoError(s.ap.handleStatusRunning(agentPayload))
+	s.r.Len(s.ap.agents, 0)
+	failed, ok := s.ap.Health().GetByName("agents.failed")
+	s.r.True(ok)
+	s.r.Equal(testAgentID, failed.Details)
+
+	// And the bot wait should be done only once when it fails again
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(agentPayload))
+	s.r.NoError(s.ap.handleStatusRunning(agentPayload))
+	s.ap.botWaitGroup.Wait()
+}
+
+// TestApplyDisabledBots tests that the pool gets the disabled bots from the Forta dir as a service.
+func (s *Suite) TestApplyDisabledBots() {
+	agentPayload := messaging.AgentPayload{
+		config.AgentConfig{ID: testAgentID},
+	}
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionRun, agentPayload)
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(agentPayload))
+
+	fortaDir := s.T().TempDir()
+	s.r.NoError(config.SetBotDisabled(fortaDir, testAgentID, true))
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionStop, agentPayload)
+	services.ApplyDisabledBots(log.NewEntry(log.StandardLogger()), fortaDir, []services.Service{s.ap})
+	s.r.Len(s.ap.agents, 0)
+}
+
+// TestDisabledBots tests that the locally disabled bots are stopped and run again when enabled.
+func (s *Suite) TestDisabledBots() {
+	agentPayload := messaging.AgentPayload{
+		config.AgentConfig{ID: testAgentID},
+	}
+
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionRun, agentPayload)
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(agentPayload))
+	s.r.Len(s.ap.agents, 1)
+
+	// When the bot is disabled
+	// Then a "stop" action should be published
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionStop, agentPayload)
+	s.ap.SetDisabledBots([]string{strings.ToUpper(testAgentID)})
+	s.r.Len(s.ap.agents, 0)
+	disabled, ok := s.ap.Health().GetByName("agents.disabled")
+	s.r.True(ok)
+	s.r.Equal(strings.ToLower(testAgentID), disabled.Details)
+
+	// And the bot should not be run with the next list
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(agentPayload))
+	s.r.Len(s.ap.agents, 0)
+
+	// When the bot is enabled again
+	// Then a "run" action should be published
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionRun, agentPayload)
+	s.ap.SetDisabledBots(nil)
+	s.r.Len(s.ap.agents, 1)
+}
+
+// TestRefusedBots tests that the bots which the supervisor refuses to start are removed from the pool.
+func (s *Suite) TestRefusedBots() {
+	agentPayload := messaging.AgentPayload{
+		config.AgentConfig{ID: testAgentID},
+	}
+
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionRun, agentPayload)
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(agentPayload))
+	s.r.Len(s.ap.agents, 1)
+
+	// When the supervisor refuses to start the bot
+	// Then the bot should be removed from the pool and reported
+	s.r.NoError(s.ap.handleStatusRefused(agentPayload))
+	s.r.Len(s.ap.agents, 0)
+	refused, ok := s.ap.Health().GetByName("agents.refused")
+	s.r.True(ok)
+	s.r.Equal(testAgentID, refused.Details)
+
+	// And the bot should be tried again with the next list
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionRun, agentPayload)
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(agentPayload))
+	s.r.Len(s.ap.agents, 1)
+}
+
+// TestRefusedBotsStartedLater tests that the refused bots are attached when the supervisor runs them later.
+func (s *Suite) TestRefusedBotsStartedLater() {
+	agentPayload := messaging.AgentPayload{
+		config.AgentConfig{ID: testAgentID},
+	}
+
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionRun, agentPayload)
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(agentPayload))
+	s.r.NoError(s.ap.handleStatusRefused(agentPayload))
+	s.r.Len(s.ap.agents, 0)
+
+	// When the supervisor runs the bot after the capacity frees up
+	// Then the bot should be attached
+	s.agentClient.EXPECT().Initialize(gomock.Any(), gomock.Any()).Return(nil, nil)
+	s.agentClient.EXPECT().EvaluateBlock(gomock.Any(), gomock.Any()).Return(&protocol.EvaluateBlockResponse{}, nil)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusAttached, agentPayload)
+	s.r.NoError(s.ap.handleStatusRunning(agentPayload))
+	s.r.Len(s.ap.agents, 1)
+	s.r.True(s.ap.agents[0].IsReady())
+	refused, _ := s.ap.Health().GetByName("agents.refused")
+	s.r.Empty(refused.Details)
+}
+
+// TestRefusedBotsUnassigned tests that the supervisor is told to forget the refused bots which are unassigned.
+func (s *Suite) TestRefusedBotsUnassigned() {
+	agentPayload := messaging.AgentPayload{
+		config.AgentConfig{ID: testAgentID},
+	}
+
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionRun, agentPayload)
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(agentPayload))
+	s.r.NoError(s.ap.handleStatusRefused(agentPayload))
+
+	// When the bot is unassigned
+	// Then a "stop" action should be published for it
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionStop, agentPayload)
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(messaging.AgentPayload{}))
+	refused, _ := s.ap.Health().GetByName("agents.refused")
+	s.r.Empty(refused.Details)
+
+	// And the bot should not be attached if it runs later
+	s.r.NoError(s.ap.handleStatusRunning(agentPayload))
+	s.r.Len(s.ap.agents, 0)
+}
+
+// TestRecreatedBots tests that the bots are initialized again when their containers are recreated.
+func (s *Suite) TestRecreatedBots() {
+	agentPayload := messaging.AgentPayload{
+		config.AgentConfig{ID: testAgentID},
+	}
+
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionRun, agentPayload)
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(agentPayload))
+
+	s.agentClient.EXPECT().Initialize(gomock.Any(), gomock.Any()).Return(nil, nil)
+	s.agentClient.EXPECT().EvaluateBlock(gomock.Any(), gomock.Any()).Return(&protocol.EvaluateBlockResponse{}, nil)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusAttached, agentPayload)
+	s.r.NoError(s.ap.handleStatusRunning(agentPayload))
+	oldAgent := s.ap.agents[0]
+	s.r.True(oldAgent.IsReady())
+
+	// a duplicate "running" message does not initialize the bot again
+	s.r.NoError(s.ap.handleStatusRunning(agentPayload))
+
+	// When the bot container is recreated
+	// Then the bot should be initialized and attached again
+	s.agentClient.EXPECT().Close()
+	s.agentClient.EXPECT().Initialize(gomock.Any(), gomock.Any()).Return(nil, nil)
+	s.agentClient.EXPECT().EvaluateBlock(gomock.Any(), gomock.Any()).Return(&protocol.EvaluateBlockResponse{}, nil)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusAttached, agentPayload)
+	s.r.NoError(s.ap.handleStatusRecreated(agentPayload))
+	s.r.Len(s.ap.agents, 1)
+	s.r.True(oldAgent.IsClosed())
+	s.r.NotSame(oldAgent, s.ap.agents[0])
+	s.r.True(s.ap.agents[0].IsReady())
+}
+
+// TestCheckReady tests that the pool is ready after a bot starts running.
+func (s *Suite) TestCheckReady() {
+	agentPayload := messaging.AgentPayload{
+		config.AgentConfig{ID: testAgentID},
+	}
+
+	// Given that there are no bots
+	// Then the pool should not be ready
+	s.r.Error(s.ap.CheckReady())
+
+	// Given that the bot is assigned but it is not running yet
+	// Then the pool should not be ready
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionRun, agentPayload)
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(agentPayload))
+	s.r.Error(s.ap.CheckReady())
+
+	// When the bot starts running
+	// Then the pool should be ready
+	s.agentClient.EXPECT().Initialize(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	s.agentClient.EXPECT().EvaluateBlock(gomock.Any(), gomock.Any()).Return(&protocol.EvaluateBlockResponse{}, nil)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusAttached, gomock.Any()).AnyTimes()
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsAlertSubscribe, gomock.Any()).AnyTimes()
+	s.r.NoError(s.ap.handleStatusRunning(agentPayload))
+	s.r.NoError(s.ap.CheckReady())
+}
+
+// TestScheduledTicks tests that the latest block is sent to the bots which request the scheduled evaluations.
+func (s *Suite) TestScheduledTicks() {
+	agentPayload := messaging.AgentPayload{
+		config.AgentConfig{ID: testAgentID},
+	}
+	s.ap.ticks.minInterval = time.Minute
+
+	// Given that the bot requests to be evaluated every ten minutes
+	initB := protowire.AppendTag(nil, agentgrpc.InitializeFieldTickInterval, protowire.VarintType)
+	initB = protowire.AppendVarint(initB, 600)
+	var initResp protocol.InitializeResponse
+	s.r.NoError(proto.Unmarshal(initB, &initResp))
+	s.agentClient.EXPECT().Initialize(gomock.Any(), gomock.Any()).Return(&initResp, nil)
+	s.agentClient.EXPECT().EvaluateBlock(gomock.Any(), gomock.Any()).Return(&protocol.EvaluateBlockResponse{}, nil)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsActionRun, gomock.Any())
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusAttached, gomock.Any())
+	s.r.NoError(s.ap.handleAgentVersionsUpdate(agentPayload))
+	s.r.NoError(s.ap.handleStatusRunning(agentPayload))
+	s.r.Equal(time.Minute*10, s.ap.agents[0].TickInterval())
+
+	// When the interval has not passed yet
+	// Then the bot should not be evaluated
+	start := time.Now()
+	blockReq := &protocol.EvaluateBlockRequest{RequestId: testRequestID, Event: &protocol.BlockEvent{BlockNumber: "0x10"}}
+	s.ap.ticks.SetLatest(blockReq)
+	s.ap.sendTicks(start)
+	s.ap.sendTicks(start.Add(time.Minute * 5))
+
+	// When the interval passes
+	// Then the latest block should be evaluated with a new request
+	s.agentClient.EXPECT().Invoke(
+		gomock.Any(), agentgrpc.MethodEvaluateBlock,
+		gomock.AssignableToTypeOf(&grpc.PreparedMsg{}), gomock.AssignableToTypeOf(&protocol.EvaluateBlockResponse{}),
+	).Return(nil)
+	s.msgClient.EXPECT().PublishProto(messaging.SubjectMetricAgent, gomock.Any())
+	s.ap.sendTicks(start.Add(time.Minute * 10))
+	blockResult := <-s.ap.BlockResults()
+	s.r.NotEqual(testRequestID, blockResult.Request.RequestId)
+	s.r.Equal(blockReq.Event, blockResult.Request.Event)
+}
@@ -0,0 +1,131 @@
+package agentpool
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients/agentgrpc"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services/scanner/agentpool/poolagent"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// canaryAlertID is the alert ID of the canary findings.
+const canaryAlertID = "FORTA-CANARY"
+
+// canaryClient is the built-in canary bot. It responds to every transaction with a canary finding
+// and it only receives the canary transactions.
+type canaryClient struct{}
+
+func (cc *canaryClient) Dial(config.AgentConfig) error {
+	return nil
+}
+
+func (cc *canaryClient) Invoke(ctx context.Context, method agentgrpc.Method, in, out interface{}, opts ...grpc.CallOption) error {
+	resp, ok := out.(*protocol.EvaluateTxResponse)
+	if !ok {
+		return fmt.Errorf("canary bot does not support %s", method)
+	}
+	resp.Status = protocol.ResponseStatus_SUCCESS
+	resp.Findings = []*protocol.Finding{
+		{
+			Protocol:    "forta",
+			Severity:    protocol.Finding_INFO,
+			Type:        protocol.Finding_INFORMATION,
+			AlertId:     canaryAlertID,
+			Name:        "Canary",
+			Description: "Synthetic end-to-end evaluation",
+		},
+	}
+	return nil
+}
+
+func (cc *canaryClient) Initialize(context.Context, *protocol.InitializeRequest, ...grpc.CallOption) (*protocol.InitializeResponse, error) {
+	return &protocol.InitializeResponse{Status: protocol.ResponseStatus_SUCCESS}, nil
+}
+
+func (cc *canaryClient) EvaluateTx(context.Context, *protocol.EvaluateTxRequest, ...grpc.CallOption) (*protocol.EvaluateTxResponse, error) {
+	return nil, fmt.Errorf("canary bot only supports the prepared requests")
+}
+
+func (cc *canaryClient) EvaluateBlock(context.Context, *protocol.EvaluateBlockRequest, ...grpc.CallOption) (*protocol.EvaluateBlockResponse, error) {
+	return nil, fmt.Errorf("canary bot does not evaluate blocks")
+}
+
+func (cc *canaryClient) EvaluateAlert(context.Context, *protocol.EvaluateAlertRequest, ...grpc.CallOption) (*protocol.EvaluateAlertResponse, error) {
+	return nil, fmt.Errorf("canary bot does not evaluate alerts")
+}
+
+func (cc *canaryClient) Close() error {
+	return nil
+}
+
+// startCanary starts the built-in canary bot which is not a part of the agent list.
+func (ap *AgentPool) startCanary() {
+	ap.canary = poolagent.New(
+		ap.ctx, config.AgentConfig{ID: config.CanaryBotID, ChainID: ap.cfg.ChainID}, ap.msgClient,
+		ap.txResults, ap.blockResults, ap.combinationAlertResults,
+	)
+	ap.canary.SetClient(&canaryClient{})
+	ap.canary.SetReady()
+	ap.canary.StartProcessing()
+	go ap.canaryLoop(time.Duration(ap.cfg.Scan.Canary.IntervalSeconds) * time.Second)
+}
+
+func (ap *AgentPool) canaryLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ap.ctx.Done():
+			ap.canary.Close()
+			return
+		case now := <-ticker.C:
+			ap.sendCanary(now)
+		}
+	}
+}
+
+// sendCanary sends a marked transaction to the canary bot. The feed time of the transaction is the
+// time that the publisher measures the latency from.
+func (ap *AgentPool) sendCanary(now time.Time) {
+	requestID := uuid.Must(uuid.NewUUID()).String()
+	req := &protocol.EvaluateTxRequest{
+		RequestId: requestID,
+		Event: &protocol.TransactionEvent{
+			Type: protocol.TransactionEvent_BLOCK,
+			Transaction: &protocol.TransactionEvent_EthTransaction{
+				// unique so that the canary findings are not deduplicated
+				Hash: crypto.Keccak256Hash([]byte(requestID)).Hex(),
+			},
+			Block: &protocol.TransactionEvent_EthBlock{
+				BlockNumber: hexutil.EncodeUint64(atomic.LoadUint64(&ap.latestBlockInput)),
+			},
+			Network: &protocol.TransactionEvent_Network{
+				ChainId: hexutil.EncodeUint64(uint64(ap.cfg.ChainID)),
+			},
+			Timestamps: &protocol.TrackingTimestamps{
+				Feed: now.UTC().Format(domain.TimeTrackingTimestampFormat),
+			},
+		},
+	}
+	encoded, err := agentgrpc.EncodeRequest(req, agentgrpc.EventExtension{}, "")
+	if err != nil {
+		log.WithError(err).Error("failed to encode the canary request")
+		return
+	}
+	select {
+	case ap.canary.TxRequestCh() <- &poolagent.TxRequest{Original: req, Encoded: encoded}:
+		atomic.AddUint64(&ap.canariesSent, 1)
+	default:
+		log.Warn("canary request buffer is full - skipping")
+	}
+}
@@ -0,0 +1,41 @@
+package agentpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services/scanner"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanary(t *testing.T) {
+	r := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ap := &AgentPool{
+		ctx:       ctx,
+		cfg:       config.Config{ChainID: 1, Scan: config.ScannerConfig{Canary: config.CanaryConfig{Enable: true, IntervalSeconds: 60}}},
+		txResults: make(chan *scanner.TxResult),
+	}
+	ap.startCanary()
+
+	now := time.Now()
+	ap.sendCanary(now)
+	result := <-ap.TxResults()
+	r.Equal(config.CanaryBotID, result.AgentConfig.ID)
+	r.Len(result.Response.Findings, 1)
+	r.Equal(canaryAlertID, result.Response.Findings[0].AlertId)
+	r.Equal(now.UTC().Format(domain.TimeTrackingTimestampFormat), result.Request.Event.Timestamps.Feed)
+	r.Equal("0x1", result.Request.Event.Network.ChainId)
+
+	// the canary bot is not a part of the agent list
+	r.Empty(ap.agents)
+	reports := ap.Health()
+	sent, ok := reports.NameContains("canary.sent")
+	r.True(ok)
+	r.Equal("1", sent.Details)
+}
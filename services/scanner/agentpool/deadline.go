@@ -0,0 +1,30 @@
+package agentpool
+
+import (
+	"sync"
+	"time"
+)
+
+// resultDeadlines keeps the result deadline of the latest block which the tx requests are dispatched for.
+// The deadline starts with the first tx of the block so all bots get the same time to respond to the
+// block, regardless of how long the other bots take.
+type resultDeadlines struct {
+	timeout     time.Duration
+	blockNumber string
+	deadline    time.Time
+	mu          sync.Mutex
+}
+
+// For returns the result deadline of the block. The zero time is returned if the deadline is disabled.
+func (rd *resultDeadlines) For(blockNumber string, now time.Time) time.Time {
+	if rd.timeout <= 0 {
+		return time.Time{}
+	}
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+	if blockNumber != rd.blockNumber {
+		rd.blockNumber = blockNumber
+		rd.deadline = now.Add(rd.timeout)
+	}
+	return rd.deadline
+}
@@ -0,0 +1,26 @@
+package agentpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultDeadlines(t *testing.T) {
+	r := require.New(t)
+
+	var disabled resultDeadlines
+	r.True(disabled.For("0x1", time.Now()).IsZero())
+
+	start := time.Now()
+	rd := &resultDeadlines{timeout: time.Second * 15}
+	deadline := rd.For("0x1", start)
+	r.Equal(start.Add(time.Second*15), deadline)
+
+	// the later txs of the same block get the same deadline
+	r.Equal(deadline, rd.For("0x1", start.Add(time.Second*10)))
+
+	// the next block starts a new deadline
+	r.Equal(start.Add(time.Second*25), rd.For("0x2", start.Add(time.Second*10)))
+}
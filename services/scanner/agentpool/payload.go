@@ -0,0 +1,93 @@
+package agentpool
+
+import (
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/golang/protobuf/proto"
+)
+
+// Trimmed fields are reported to the bots in the event extension. The trimmed data can still
+// be fetched from the JSON-RPC API by using the tx hash or the block hash.
+const (
+	trimmedTraces         = "traces"
+	trimmedLogs           = "logs"
+	trimmedInput          = "transaction.input"
+	trimmedBlockTxs       = "block.transactions"
+	trimmedAlertMeta      = "alert.metadata"
+	trimmedAlertAddrs     = "alert.addresses"
+	trimmedAlertContracts = "alert.contracts"
+)
+
+// selectorLength is the length of "0x" + 4-byte function selector.
+const selectorLength = 10
+
+// limitTxRequest trims the tx request until it fits into the max size. The original request is
+// not modified. It returns false if the request cannot fit into the max size even after trimming.
+func limitTxRequest(req *protocol.EvaluateTxRequest, maxSize int) (*protocol.EvaluateTxRequest, []string, bool) {
+	if maxSize <= 0 || proto.Size(req) <= maxSize {
+		return req, nil, true
+	}
+	req = proto.Clone(req).(*protocol.EvaluateTxRequest)
+	var trimmed []string
+
+	req.Event.Traces = nil
+	trimmed = append(trimmed, trimmedTraces)
+	if proto.Size(req) <= maxSize {
+		return req, trimmed, true
+	}
+
+	if tx := req.Event.Transaction; tx != nil && len(tx.Input) > selectorLength {
+		tx.Input = tx.Input[:selectorLength] // keep the function selector
+		trimmed = append(trimmed, trimmedInput)
+		if proto.Size(req) <= maxSize {
+			return req, trimmed, true
+		}
+	}
+
+	req.Event.Logs = nil
+	trimmed = append(trimmed, trimmedLogs)
+	return req, trimmed, proto.Size(req) <= maxSize
+}
+
+// limitBlockRequest trims the block request until it fits into the max size. The original request
+// is not modified. It returns false if the request cannot fit into the max size even after trimming.
+func limitBlockRequest(req *protocol.EvaluateBlockRequest, maxSize int) (*protocol.EvaluateBlockRequest, []string, bool) {
+	if maxSize <= 0 || proto.Size(req) <= maxSize {
+		return req, nil, true
+	}
+	req = proto.Clone(req).(*protocol.EvaluateBlockRequest)
+	if req.Event.Block != nil {
+		req.Event.Block.Transactions = nil
+	}
+	return req, []string{trimmedBlockTxs}, proto.Size(req) <= maxSize
+}
+
+// limitAlertRequest trims the alert request until it fits into the max size. The original request
+// is not modified. It returns false if the request cannot fit into the max size even after trimming.
+func limitAlertRequest(req *protocol.EvaluateAlertRequest, maxSize int) (*protocol.EvaluateAlertRequest, []string, bool) {
+	if maxSize <= 0 || proto.Size(req) <= maxSize {
+		return req, nil, true
+	}
+	// there is nothing to trim without the alert
+	if req.Event == nil || req.Event.Alert == nil {
+		return req, nil, false
+	}
+	req = proto.Clone(req).(*protocol.EvaluateAlertRequest)
+	alert := req.Event.Alert
+	var trimmed []string
+
+	alert.Metadata = nil
+	trimmed = append(trimmed, trimmedAlertMeta)
+	if proto.Size(req) <= maxSize {
+		return req, trimmed, true
+	}
+
+	alert.Addresses = nil
+	trimmed = append(trimmed, trimmedAlertAddrs)
+	if proto.Size(req) <= maxSize {
+		return req, trimmed, true
+	}
+
+	alert.Contracts = nil
+	trimmed = append(trimmed, trimmedAlertContracts)
+	return req, trimmed, proto.Size(req) <= maxSize
+}
@@ -0,0 +1,74 @@
+package agentpool
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitTxRequest(t *testing.T) {
+	r := require.New(t)
+
+	req := &protocol.EvaluateTxRequest{
+		Event: &protocol.TransactionEvent{
+			Transaction: &protocol.TransactionEvent_EthTransaction{
+				Input: "0xa9059cbb" + strings.Repeat("00", 2000),
+			},
+			Traces: []*protocol.TransactionEvent_Trace{
+				{Action: &protocol.TransactionEvent_TraceAction{Input: "0x" + strings.Repeat("11", 2000)}},
+			},
+			Logs: []*protocol.TransactionEvent_Log{{Data: "0x1234"}},
+		},
+	}
+	originalSize := proto.Size(req)
+
+	limited, trimmed, ok := limitTxRequest(req, originalSize)
+	r.True(ok)
+	r.Empty(trimmed)
+	r.Equal(req, limited)
+
+	limited, trimmed, ok = limitTxRequest(req, 1024)
+	r.True(ok)
+	r.Equal([]string{trimmedTraces, trimmedInput}, trimmed)
+	r.Equal("0xa9059cbb", limited.Event.Transaction.Input)
+	r.Len(limited.Event.Logs, 1)
+
+	// the original request should stay the same
+	r.Len(req.Event.Traces, 1)
+	r.Equal(originalSize, proto.Size(req))
+
+	_, _, ok = limitTxRequest(req, 1)
+	r.False(ok)
+}
+
+func TestLimitAlertRequest(t *testing.T) {
+	r := require.New(t)
+
+	req := &protocol.EvaluateAlertRequest{
+		Event: &protocol.AlertEvent{
+			Alert: &protocol.AlertEvent_Alert{
+				Metadata:  map[string]string{"data": strings.Repeat("a", 2000)},
+				Addresses: []string{"0x1"},
+			},
+		},
+	}
+
+	limited, trimmed, ok := limitAlertRequest(req, 1024)
+	r.True(ok)
+	r.Equal([]string{trimmedAlertMeta}, trimmed)
+	r.Nil(limited.Event.Alert.Metadata)
+	r.Len(limited.Event.Alert.Addresses, 1)
+	r.NotNil(req.Event.Alert.Metadata)
+
+	noAlertReq := &protocol.EvaluateAlertRequest{
+		RequestId: strings.Repeat("a", 2000),
+		Event:     &protocol.AlertEvent{},
+	}
+	limited, trimmed, ok = limitAlertRequest(noAlertReq, 1024)
+	r.False(ok)
+	r.Nil(trimmed)
+	r.True(limited == noAlertReq)
+}
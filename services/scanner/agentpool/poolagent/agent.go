@@ -2,12 +2,12 @@ package poolagent
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"regexp"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/forta-network/forta-core-go/domain"
 	"github.com/forta-network/forta-core-go/utils"
 
@@ -17,6 +17,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/forta-network/forta-core-go/protocol"
 	"github.com/forta-network/forta-node/clients"
@@ -56,7 +57,17 @@ type Agent struct {
 	readyOnce sync.Once
 	closed    chan struct{}
 	closeOnce sync.Once
-	initWait  sync.WaitGroup
+
+	latencyMs    uint32 // accessed atomically
+	lateResults  uint64 // accessed atomically
+	tickInterval int64  // accessed atomically
+	feedback     uint32 // accessed atomically
+
+	invalidFindings uint64 // accessed atomically
+	cancelled       uint64 // accessed atomically
+
+	evals  map[*evaluation]struct{}
+	evalMu sync.Mutex // protects evals
 
 	mu sync.RWMutex
 }
@@ -89,6 +100,10 @@ func (agent *Agent) IsCombinerBot() bool {
 type TxRequest struct {
 	Original *protocol.EvaluateTxRequest
 	Encoded  *grpc.PreparedMsg
+	// the result is tagged as late after this, if not zero
+	Deadline time.Time
+	// the transaction is from the mempool
+	Pending bool
 }
 
 // BlockRequest contains the original request data and the encoded message.
@@ -121,6 +136,18 @@ func New(ctx context.Context, agentCfg config.AgentConfig, msgClient clients.Mes
 	}
 }
 
+// reportTimeout sends a metric if the agent did not respond before the request timeout and lets
+// the bot know that the node stopped waiting.
+func (agent *Agent) reportTimeout(lg *log.Entry, requestID string, err error) {
+	if status.Code(err) != codes.DeadlineExceeded && !errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	metrics.SendAgentMetrics(agent.msgClient, []*protocol.AgentMetric{
+		metrics.CreateAgentMetric(agent.config.ID, metrics.MetricAgentTimeout, 1),
+	})
+	agent.sendCancelFeedback(lg, requestID, agentgrpc.CancelReasonTimeout)
+}
+
 func isCriticalErr(err error) bool {
 	return false
 	// errStr := err.Error()
@@ -144,6 +171,40 @@ func (agent *Agent) TxBufferIsFull() bool {
 	return len(agent.txRequests) == DefaultBufferSize
 }
 
+// TxBufferUsage returns the fullness of the agent tx input buffer between zero and one.
+func (agent *Agent) TxBufferUsage() float64 {
+	return float64(len(agent.txRequests)) / DefaultBufferSize
+}
+
+// LatencyMs returns the latency of the last successful request.
+func (agent *Agent) LatencyMs() uint32 {
+	return atomic.LoadUint32(&agent.latencyMs)
+}
+
+// LateResults returns the number of the tx results which arrived after the result deadline.
+func (agent *Agent) LateResults() uint64 {
+	return atomic.LoadUint64(&agent.lateResults)
+}
+
+// TickInterval returns the interval which the bot requested to evaluate the latest block at. Zero
+// means that the bot does not need the scheduled evaluations.
+func (agent *Agent) TickInterval() time.Duration {
+	return time.Duration(atomic.LoadInt64(&agent.tickInterval))
+}
+
+// ConnStats returns the stats of the agent connections if the agent is ready and the client reports them.
+func (agent *Agent) ConnStats() (agentgrpc.ConnStats, bool) {
+	// the client is set before the agent is ready
+	if !agent.IsReady() {
+		return agentgrpc.ConnStats{}, false
+	}
+	statsClient, ok := agent.client.(interface{ Stats() agentgrpc.ConnStats })
+	if !ok {
+		return agentgrpc.ConnStats{}, false
+	}
+	return statsClient.Stats(), true
+}
+
 // Config returns the agent config.
 func (agent *Agent) Config() config.AgentConfig {
 	agent.mu.RLock()
@@ -222,23 +283,31 @@ func (agent *Agent) SetClient(agentClient clients.AgentClient) {
 // StartProcessing launches the goroutines to concurrently process incoming requests
 // from request channels.
 func (agent *Agent) StartProcessing() {
-	agent.initWait.Add(1)
-	go agent.initialize()
-
 	go agent.processTransactions()
 	go agent.processBlocks()
 	go agent.processCombinationAlerts()
 }
 
-func (agent *Agent) initialize() {
-	defer agent.initWait.Done()
+// WarmUp initializes the bot and sends a synthetic no-op evaluation request to make sure
+// that the bot can respond before it starts to receive the real traffic.
+func (agent *Agent) WarmUp(timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = DefaultAgentInitializeTimeout
+	}
+	ctx, cancel := context.WithTimeout(agent.ctx, timeout)
+	defer cancel()
 
+	if err := agent.initialize(ctx); err != nil {
+		return err
+	}
+	return agent.evaluateNoOp(ctx)
+}
+
+func (agent *Agent) initialize(ctx context.Context) error {
 	logger := log.WithFields(log.Fields{
 		"agent": agent.config.ID,
 	})
 
-	ctx, cancel := context.WithTimeout(agent.ctx, DefaultAgentInitializeTimeout)
-	defer cancel()
 	initializeResponse, err := agent.client.Initialize(ctx, &protocol.InitializeRequest{
 		AgentId:   agent.config.ID,
 		ProxyHost: config.DockerJSONRPCProxyContainerName,
@@ -246,16 +315,14 @@ func (agent *Agent) initialize() {
 
 	if status.Code(err) == codes.Unimplemented {
 		logger.WithError(err).Info("initialize() method not implemented in bot - safe to ignore")
-		return
+		return nil
 	}
 	if err != nil {
-		logger.WithError(err).Warn("bot initialization failed")
-		return
+		return fmt.Errorf("bot initialization failed: %v", err)
 	}
 
-	if err := validateInitializeResponse(initializeResponse); err != nil {
-		logger.WithError(err).Warn("bot initialization validation failed")
-		return
+	if err := ValidateInitializeResponse(initializeResponse); err != nil {
+		return fmt.Errorf("bot initialization validation failed: %v", err)
 	}
 
 	// pass new alert subscriptions to pool
@@ -263,10 +330,51 @@ func (agent *Agent) initialize() {
 		agent.SetAlertConfig(initializeResponse.AlertConfig)
 	}
 
+	caps, err := agentgrpc.ReadCapabilities(initializeResponse)
+	if err != nil {
+		return fmt.Errorf("bot initialization validation failed: invalid capabilities: %v", err)
+	}
+	if caps.TickInterval > 0 {
+		logger.WithField("tickInterval", caps.TickInterval).Info("bot requested scheduled evaluations")
+	}
+	atomic.StoreInt64(&agent.tickInterval, int64(caps.TickInterval))
+	var feedback uint32
+	if caps.Feedback {
+		feedback = 1
+	}
+	atomic.StoreUint32(&agent.feedback, feedback)
+
 	logger.Info("bot initialization succeeded")
+	return nil
 }
 
-func validateInitializeResponse(response *protocol.InitializeResponse) error {
+// evaluateNoOp sends an empty block to the bot. The response content is ignored
+// because we only care about the bot being able to respond.
+func (agent *Agent) evaluateNoOp(ctx context.Context) error {
+	_, err := agent.client.EvaluateBlock(ctx, &protocol.EvaluateBlockRequest{
+		RequestId: "warm-up",
+		Event: &protocol.BlockEvent{
+			Type:        protocol.BlockEvent_BLOCK,
+			BlockNumber: "0x0",
+			Network: &protocol.BlockEvent_Network{
+				ChainId: hexutil.EncodeUint64(uint64(agent.config.ChainID)),
+			},
+			Block: &protocol.BlockEvent_EthBlock{
+				Number: "0x0",
+			},
+		},
+	})
+	if status.Code(err) == codes.Unimplemented {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("bot warm-up evaluation failed: %v", err)
+	}
+	return nil
+}
+
+// ValidateInitializeResponse validates the alert subscriptions of a bot.
+func ValidateInitializeResponse(response *protocol.InitializeResponse) error {
 	if response == nil || response.AlertConfig == nil {
 		return nil
 	}
@@ -280,10 +388,6 @@ func validateInitializeResponse(response *protocol.InitializeResponse) error {
 	return nil
 }
 
-func (agent *Agent) WaitInitialization() {
-	agent.initWait.Wait()
-}
-
 func (agent *Agent) processTransactions() {
 	lg := log.WithFields(
 		log.Fields{
@@ -293,8 +397,6 @@ func (agent *Agent) processTransactions() {
 		},
 	)
 
-	agent.initWait.Wait()
-
 	for request := range agent.txRequests {
 		if exit := agent.processTransaction(lg, request); exit {
 			return
@@ -311,15 +413,21 @@ func (agent *Agent) processTransaction(lg *log.Entry, request *TxRequest) (exit
 		return true
 	}
 
-	ctx, cancel := context.WithTimeout(agent.ctx, AgentTimeout)
+	ctx, eval := agent.startEvaluation(request.Original.RequestId, request.Original.Event.Block.BlockHash)
 	lg.WithField("duration", time.Since(startTime)).Debugf("sending request")
 	resp := new(protocol.EvaluateTxResponse)
 
 	requestTime := time.Now().UTC()
 	err := agent.client.Invoke(ctx, agentgrpc.MethodEvaluateTx, request.Encoded, resp)
 	responseTime := time.Now().UTC()
-	cancel()
+	cancelReason := agent.finishEvaluation(eval)
+	if err != nil && len(cancelReason) > 0 {
+		agent.handleCancelled(lg, request.Original.RequestId, cancelReason)
+		return false
+	}
 	if err == nil {
+		resp.Findings = agent.rejectInvalidFindings(lg, request.Original.RequestId, resp.Findings)
+
 		// truncate findings
 		if len(resp.Findings) > MaxFindings {
 			dropped := len(resp.Findings) - MaxFindings
@@ -332,6 +440,7 @@ func (agent *Agent) processTransaction(lg *log.Entry, request *TxRequest) (exit
 		}
 		var duration time.Duration
 		resp.Timestamp, resp.LatencyMs, duration = calculateResponseTime(&startTime)
+		atomic.StoreUint32(&agent.latencyMs, resp.LatencyMs)
 		lg.WithField("duration", duration).Debugf("request successful")
 
 		if resp.Metadata == nil {
@@ -343,17 +452,26 @@ func (agent *Agent) processTransaction(lg *log.Entry, request *TxRequest) (exit
 		ts.BotRequest = requestTime
 		ts.BotResponse = responseTime
 
+		late := !request.Deadline.IsZero() && responseTime.After(request.Deadline)
+		if late {
+			atomic.AddUint64(&agent.lateResults, 1)
+			lg.WithField("lateBy", responseTime.Sub(request.Deadline)).Debug("result arrived after the deadline")
+		}
+
 		agent.txResults <- &scanner.TxResult{
 			AgentConfig: agent.config,
 			Request:     request.Original,
 			Response:    resp,
 			Timestamps:  ts,
+			Late:        late,
+			Pending:     request.Pending,
 		}
 		lg.WithField("duration", time.Since(startTime)).Debugf("sent results")
 
 		return false
 	}
 	lg.WithField("duration", time.Since(startTime)).WithError(err).Error("error invoking agent")
+	agent.reportTimeout(lg, request.Original.RequestId, err)
 	if agent.errCounter.TooManyErrs(err) {
 		lg.WithField("duration", time.Since(startTime)).Error("too many errors - shutting down agent")
 		agent.Close()
@@ -385,8 +503,6 @@ func (agent *Agent) processBlocks() {
 		},
 	)
 
-	agent.initWait.Wait()
-
 	for request := range agent.blockRequests {
 		if exit := agent.processBlock(lg, request); exit {
 			return
@@ -403,14 +519,20 @@ func (agent *Agent) processBlock(lg *log.Entry, request *BlockRequest) (exit boo
 		return true
 	}
 
-	ctx, cancel := context.WithTimeout(agent.ctx, AgentTimeout)
+	ctx, eval := agent.startEvaluation(request.Original.RequestId, request.Original.Event.BlockHash)
 	lg.WithField("duration", time.Since(startTime)).Debugf("sending request")
 	resp := new(protocol.EvaluateBlockResponse)
 	requestTime := time.Now().UTC()
 	err := agent.client.Invoke(ctx, agentgrpc.MethodEvaluateBlock, request.Encoded, resp)
 	responseTime := time.Now().UTC()
-	cancel()
+	cancelReason := agent.finishEvaluation(eval)
+	if err != nil && len(cancelReason) > 0 {
+		agent.handleCancelled(lg, request.Original.RequestId, cancelReason)
+		return false
+	}
 	if err == nil {
+		resp.Findings = agent.rejectInvalidFindings(lg, request.Original.RequestId, resp.Findings)
+
 		// truncate findings
 		if len(resp.Findings) > MaxFindings {
 			dropped := len(resp.Findings) - MaxFindings
@@ -425,6 +547,7 @@ func (agent *Agent) processBlock(lg *log.Entry, request *BlockRequest) (exit boo
 		}
 		var duration time.Duration
 		resp.Timestamp, resp.LatencyMs, duration = calculateResponseTime(&startTime)
+		atomic.StoreUint32(&agent.latencyMs, resp.LatencyMs)
 		lg.WithField("duration", duration).Debugf("request successful")
 
 		if resp.Metadata == nil {
@@ -448,6 +571,7 @@ func (agent *Agent) processBlock(lg *log.Entry, request *BlockRequest) (exit boo
 	}
 
 	lg.WithField("duration", time.Since(startTime)).WithError(err).Error("error invoking agent")
+	agent.reportTimeout(lg, request.Original.RequestId, err)
 	if agent.errCounter.TooManyErrs(err) {
 		lg.WithField("duration", time.Since(startTime)).Error("too many errors - shutting down agent")
 		agent.Close()
@@ -468,8 +592,6 @@ func (agent *Agent) processCombinationAlerts() {
 		},
 	)
 
-	agent.initWait.Wait()
-
 	for request := range agent.combinationRequests {
 		if exit := agent.processCombinationAlert(lg, request); exit {
 			return
@@ -486,16 +608,21 @@ func (agent *Agent) processCombinationAlert(lg *log.Entry, request *CombinationR
 		agent.mu.RUnlock()
 	}
 
-	ctx, cancel := context.WithTimeout(agent.ctx, AgentTimeout)
+	ctx, eval := agent.startEvaluation(request.Original.RequestId, "")
 	lg.WithField("duration", time.Since(startTime)).Debugf("sending request")
 	resp := new(protocol.EvaluateAlertResponse)
 	requestTime := time.Now().UTC()
 	err := agent.client.Invoke(ctx, agentgrpc.MethodEvaluateAlert, request.Encoded, resp)
 	responseTime := time.Now().UTC()
-	cancel()
+	cancelReason := agent.finishEvaluation(eval)
+	if err != nil && len(cancelReason) > 0 {
+		agent.handleCancelled(lg, request.Original.RequestId, cancelReason)
+		return false
+	}
 
 	if err != nil {
 		lg.WithField("duration", time.Since(startTime)).WithError(err).Error("error invoking agent")
+		agent.reportTimeout(lg, request.Original.RequestId, err)
 		if agent.errCounter.TooManyErrs(err) {
 			lg.WithField("duration", time.Since(startTime)).Error("too many errors - shutting down agent")
 			agent.Close()
@@ -506,13 +633,11 @@ func (agent *Agent) processCombinationAlert(lg *log.Entry, request *CombinationR
 	}
 
 	// validate response
-	if vErr := validateEvaluateAlertResponse(resp); vErr != nil {
-		lg.WithField(
-			"request", request.Original.RequestId,
-		).WithError(vErr).Error("evaluate combination response validation failed")
-
+	if resp == nil {
+		lg.WithField("request", request.Original.RequestId).Error("evaluate combination response validation failed: nil response")
 		return false
 	}
+	resp.Findings = agent.rejectInvalidFindings(lg, request.Original.RequestId, resp.Findings)
 
 	// truncate findings
 	if len(resp.Findings) > MaxFindings {
@@ -526,6 +651,7 @@ func (agent *Agent) processCombinationAlert(lg *log.Entry, request *CombinationR
 
 	var duration time.Duration
 	resp.Timestamp, resp.LatencyMs, duration = calculateResponseTime(&startTime)
+	atomic.StoreUint32(&agent.latencyMs, resp.LatencyMs)
 	lg.WithField("duration", duration).Debugf("request successful")
 
 	if resp.Metadata == nil {
@@ -549,42 +675,42 @@ func (agent *Agent) processCombinationAlert(lg *log.Entry, request *CombinationR
 	return false
 }
 
-func validateEvaluateAlertResponse(resp *protocol.EvaluateAlertResponse) (err error) {
-	if resp == nil {
-		return fmt.Errorf("nil response")
-	}
-
-	for _, finding := range resp.Findings {
-		if err = validateFinding(finding); err != nil {
-			return err
-		}
+// rejectInvalidFindings removes the findings which do not match the protocol schema, counts them and
+// sends the reasons to the bot if the bot implements the feedback method.
+func (agent *Agent) rejectInvalidFindings(lg *log.Entry, requestID string, findings []*protocol.Finding) []*protocol.Finding {
+	valid, findingErrs := filterInvalidFindings(findings)
+	if len(findingErrs) == 0 {
+		return valid
+	}
+	atomic.AddUint64(&agent.invalidFindings, uint64(len(findingErrs)))
+	lg.WithFields(log.Fields{
+		"request": requestID,
+		"invalid": len(findingErrs),
+		"reason":  findingErrs[0].Message,
+	}).Warn("rejected invalid findings")
+	invalidMetric := metrics.CreateAgentMetric(agent.config.ID, metrics.MetricFindingsInvalid, float64(len(findingErrs)))
+	agent.msgClient.PublishProto(
+		messaging.SubjectMetricAgent,
+		&protocol.AgentMetricList{Metrics: []*protocol.AgentMetric{invalidMetric}},
+	)
+	if atomic.LoadUint32(&agent.feedback) == 1 {
+		go agent.sendFeedback(lg, agent.client, &agentgrpc.Feedback{RequestID: requestID, Errors: findingErrs})
 	}
-
-	return nil
+	return valid
 }
 
-func validateFinding(finding *protocol.Finding) error {
-	if finding == nil {
-		return fmt.Errorf("nil finding")
-	}
-	for _, alert := range finding.RelatedAlerts {
-		if !checkValidKeccak256(alert) {
-			return fmt.Errorf("bad related alert string: %s", alert)
-		}
-	}
-	for _, address := range finding.Addresses {
-		if !common.IsHexAddress(address) {
-			return fmt.Errorf("bad address string: %s", address)
-		}
+func (agent *Agent) sendFeedback(lg *log.Entry, client clients.AgentClient, feedback *agentgrpc.Feedback) {
+	ctx, cancel := context.WithTimeout(agent.ctx, AgentTimeout)
+	defer cancel()
+	err := client.Invoke(ctx, agentgrpc.MethodFeedback, agentgrpc.EncodeFeedback(feedback), &emptypb.Empty{})
+	if err != nil {
+		lg.WithField("request", feedback.RequestID).WithError(err).Warn("failed to send the feedback")
 	}
-
-	return nil
 }
 
-var _regexKeccak256 = regexp.MustCompile("^0x[a-f0-9]{64}$")
-
-func checkValidKeccak256(hash string) bool {
-	return _regexKeccak256.Match([]byte(hash))
+// InvalidFindings returns how many findings were rejected because they did not match the protocol schema.
+func (agent *Agent) InvalidFindings() uint64 {
+	return atomic.LoadUint64(&agent.invalidFindings)
 }
 
 func calculateResponseTime(startTime *time.Time) (timestamp string, latencyMs uint32, duration time.Duration) {
@@ -677,6 +803,19 @@ func (agent *Agent) SetShardConfig(cfg config.AgentConfig) {
 	agent.config.ShardConfig = cfg.ShardConfig
 }
 
+// SetReplicaConfig updates the number of the replicas of the bot.
+func (agent *Agent) SetReplicaConfig(cfg config.AgentConfig) {
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+
+	agent.config.ReplicaConfig = cfg.ReplicaConfig
+}
+
+// IsPrimaryReplica tells if the agent is the first replica of the bot, or the bot is not replicated.
+func (agent *Agent) IsPrimaryReplica() bool {
+	return agent.Config().ReplicaIndex() == 0
+}
+
 func (agent *Agent) IsSharded() bool {
 	return agent.config.ShardConfig != nil && agent.config.ShardConfig.Shards > 1
 }
@@ -0,0 +1,109 @@
+package poolagent
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients/agentgrpc"
+	"github.com/forta-network/forta-node/metrics"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// evaluation is a request which is waiting for the bot response. The node cancels it before the
+// timeout if the result becomes irrelevant, so that the bot can stop working on it.
+type evaluation struct {
+	requestID string
+	blockHash string // empty if the request is not about a block
+	cancel    context.CancelFunc
+	reason    string // set when cancelled by the node
+}
+
+// startEvaluation creates the context of a request which is cancelled on timeout or when the
+// evaluation is superseded.
+func (agent *Agent) startEvaluation(requestID, blockHash string) (context.Context, *evaluation) {
+	ctx, cancel := context.WithTimeout(agent.ctx, AgentTimeout)
+	eval := &evaluation{requestID: requestID, blockHash: strings.ToLower(blockHash), cancel: cancel}
+
+	agent.evalMu.Lock()
+	defer agent.evalMu.Unlock()
+	if agent.evals == nil {
+		agent.evals = make(map[*evaluation]struct{})
+	}
+	agent.evals[eval] = struct{}{}
+	return ctx, eval
+}
+
+// finishEvaluation releases the request context and returns the cancellation reason if the
+// evaluation was cancelled by the node.
+func (agent *Agent) finishEvaluation(eval *evaluation) string {
+	agent.evalMu.Lock()
+	delete(agent.evals, eval)
+	reason := eval.reason
+	agent.evalMu.Unlock()
+
+	eval.cancel()
+	return reason
+}
+
+// CancelBlockEvaluations cancels the evaluations of a block and returns how many were cancelled.
+// The block is matched by hash so that the evaluations of the canonical block at the same height
+// are left alone.
+func (agent *Agent) CancelBlockEvaluations(blockHash string, reason string) int {
+	if len(blockHash) == 0 {
+		return 0
+	}
+	blockHash = strings.ToLower(blockHash)
+	return agent.cancelEvaluations(reason, func(eval *evaluation) bool {
+		return eval.blockHash == blockHash
+	})
+}
+
+// CancelAllEvaluations cancels all evaluations in progress and returns how many were cancelled.
+func (agent *Agent) CancelAllEvaluations(reason string) int {
+	return agent.cancelEvaluations(reason, func(eval *evaluation) bool {
+		return true
+	})
+}
+
+func (agent *Agent) cancelEvaluations(reason string, match func(*evaluation) bool) (count int) {
+	agent.evalMu.Lock()
+	defer agent.evalMu.Unlock()
+
+	for eval := range agent.evals {
+		if len(eval.reason) > 0 || !match(eval) {
+			continue
+		}
+		eval.reason = reason
+		eval.cancel()
+		count++
+	}
+	return
+}
+
+// CancelledEvaluations returns how many evaluations were cancelled by the node.
+func (agent *Agent) CancelledEvaluations() uint64 {
+	return atomic.LoadUint64(&agent.cancelled)
+}
+
+// handleCancelled counts the evaluation which was cancelled by the node and lets the bot know
+// why, if the bot implements the feedback method.
+func (agent *Agent) handleCancelled(lg *log.Entry, requestID, reason string) {
+	atomic.AddUint64(&agent.cancelled, 1)
+	lg.WithFields(log.Fields{
+		"request": requestID,
+		"reason":  reason,
+	}).Debug("cancelled the evaluation")
+	metrics.SendAgentMetrics(agent.msgClient, []*protocol.AgentMetric{
+		metrics.CreateAgentMetric(agent.config.ID, metrics.MetricAgentCancelled, 1),
+	})
+	agent.sendCancelFeedback(lg, requestID, reason)
+}
+
+func (agent *Agent) sendCancelFeedback(lg *log.Entry, requestID, reason string) {
+	if atomic.LoadUint32(&agent.feedback) == 1 {
+		go agent.sendFeedback(lg, agent.client, &agentgrpc.Feedback{RequestID: requestID, CancelReason: reason})
+	}
+}
@@ -0,0 +1,41 @@
+package poolagent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/forta-network/forta-node/clients/agentgrpc"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelEvaluations(t *testing.T) {
+	r := require.New(t)
+
+	agent := New(context.Background(), config.AgentConfig{ID: "test-agent"}, nil, nil, nil, nil)
+
+	ctx1, eval1 := agent.startEvaluation("request-1", "0xA1")
+	ctx2, eval2 := agent.startEvaluation("request-2", "0xb1")
+	ctx3, eval3 := agent.startEvaluation("request-3", "")
+
+	// only the evaluations of the orphaned block are cancelled, not the canonical one
+	r.Zero(agent.CancelBlockEvaluations("", agentgrpc.CancelReasonReorg))
+	r.Equal(1, agent.CancelBlockEvaluations("0xa1", agentgrpc.CancelReasonReorg))
+	r.Error(ctx1.Err())
+	r.NoError(ctx2.Err())
+	r.NoError(ctx3.Err())
+	r.Equal(agentgrpc.CancelReasonReorg, agent.finishEvaluation(eval1))
+
+	// the rest are cancelled when paused
+	r.Equal(2, agent.CancelAllEvaluations(agentgrpc.CancelReasonPaused))
+	r.Error(ctx2.Err())
+	r.Error(ctx3.Err())
+	r.Equal(agentgrpc.CancelReasonPaused, agent.finishEvaluation(eval2))
+	r.Equal(agentgrpc.CancelReasonPaused, agent.finishEvaluation(eval3))
+
+	// the finished evaluations are not cancelled again
+	r.Zero(agent.CancelAllEvaluations(agentgrpc.CancelReasonPaused))
+	_, eval4 := agent.startEvaluation("request-4", "0xc1")
+	r.Empty(agent.finishEvaluation(eval4))
+	r.Zero(agent.CancelAllEvaluations(agentgrpc.CancelReasonPaused))
+}
@@ -0,0 +1,155 @@
+package poolagent
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients/agentgrpc"
+)
+
+// Finding size limits
+const (
+	MaxFindingFieldSize       = 256   // alert id, name, protocol and everest id
+	MaxFindingDescriptionSize = 10000 // 10K
+	MaxFindingMetadataSize    = 50000 // 50K of keys and values
+	MaxFindingAddresses       = 1000
+	MaxFindingLabels          = 1000
+	MaxFindingRelatedAlerts   = 100
+)
+
+// FindingValidationError tells which field of a finding is invalid.
+type FindingValidationError struct {
+	Field   string
+	Message string
+}
+
+// Error implements the error interface.
+func (err *FindingValidationError) Error() string {
+	return err.Message
+}
+
+func invalidField(field, format string, args ...interface{}) *FindingValidationError {
+	return &FindingValidationError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// ValidateFinding validates a finding against the protocol schema: the required fields, the field
+// sizes, the enums and the address and alert hash formats. The returned error is a
+// *FindingValidationError if the finding is not nil.
+func ValidateFinding(finding *protocol.Finding) error {
+	if finding == nil {
+		return fmt.Errorf("nil finding")
+	}
+	if err := validateFinding(finding); err != nil {
+		return err
+	}
+	return nil
+}
+
+func validateFinding(finding *protocol.Finding) *FindingValidationError {
+	switch {
+	case len(finding.AlertId) == 0:
+		return invalidField("alertId", "empty alert id")
+	case len(finding.Name) == 0:
+		return invalidField("name", "empty name")
+	}
+	for _, field := range []struct{ name, value string }{
+		{"alertId", finding.AlertId},
+		{"name", finding.Name},
+		{"protocol", finding.Protocol},
+		{"everestId", finding.EverestId},
+	} {
+		if len(field.value) > MaxFindingFieldSize {
+			return invalidField(field.name, "%s is longer than %d bytes", field.name, MaxFindingFieldSize)
+		}
+	}
+	if len(finding.Description) > MaxFindingDescriptionSize {
+		return invalidField("description", "description is longer than %d bytes", MaxFindingDescriptionSize)
+	}
+	var metadataSize int
+	for key, value := range finding.Metadata {
+		metadataSize += len(key) + len(value)
+	}
+	if metadataSize > MaxFindingMetadataSize {
+		return invalidField("metadata", "metadata is larger than %d bytes", MaxFindingMetadataSize)
+	}
+
+	if _, ok := protocol.Finding_Severity_name[int32(finding.Severity)]; !ok {
+		return invalidField("severity", "invalid severity: %d", finding.Severity)
+	}
+	if _, ok := protocol.Finding_FindingType_name[int32(finding.Type)]; !ok {
+		return invalidField("type", "invalid type: %d", finding.Type)
+	}
+
+	if len(finding.RelatedAlerts) > MaxFindingRelatedAlerts {
+		return invalidField("relatedAlerts", "more than %d related alerts", MaxFindingRelatedAlerts)
+	}
+	for _, alert := range finding.RelatedAlerts {
+		if !checkValidKeccak256(alert) {
+			return invalidField("relatedAlerts", "bad related alert string: %s", alert)
+		}
+	}
+	if len(finding.Addresses) > MaxFindingAddresses {
+		return invalidField("addresses", "more than %d addresses", MaxFindingAddresses)
+	}
+	for _, address := range finding.Addresses {
+		if !common.IsHexAddress(address) {
+			return invalidField("addresses", "bad address string: %s", address)
+		}
+	}
+
+	if len(finding.Labels) > MaxFindingLabels {
+		return invalidField("labels", "more than %d labels", MaxFindingLabels)
+	}
+	for i, label := range finding.Labels {
+		switch {
+		case label == nil:
+			return invalidField("labels", "nil label at %d", i)
+		case len(label.Entity) == 0:
+			return invalidField("labels", "empty entity in label %d", i)
+		case len(label.Label) == 0:
+			return invalidField("labels", "empty label name in label %d", i)
+		case label.Confidence < 0 || label.Confidence > 1:
+			return invalidField("labels", "confidence of label %d is not between 0 and 1: %v", i, label.Confidence)
+		}
+		if _, ok := protocol.Label_EntityType_name[int32(label.EntityType)]; !ok {
+			return invalidField("labels", "invalid entity type in label %d: %d", i, label.EntityType)
+		}
+		if label.EntityType == protocol.Label_ADDRESS && !common.IsHexAddress(label.Entity) {
+			return invalidField("labels", "bad address entity in label %d: %s", i, label.Entity)
+		}
+	}
+
+	return nil
+}
+
+// filterInvalidFindings removes the invalid findings and returns the reasons of the removals.
+func filterInvalidFindings(findings []*protocol.Finding) ([]*protocol.Finding, []agentgrpc.FindingError) {
+	var findingErrs []agentgrpc.FindingError
+	valid := findings[:0]
+	for i, finding := range findings {
+		var vErr *FindingValidationError
+		if finding == nil {
+			vErr = invalidField("", "nil finding")
+		} else {
+			vErr = validateFinding(finding)
+		}
+		if vErr == nil {
+			valid = append(valid, finding)
+			continue
+		}
+		findingErr := agentgrpc.FindingError{Index: i, Field: vErr.Field, Message: vErr.Message}
+		if finding != nil {
+			findingErr.AlertID = finding.AlertId
+		}
+		findingErrs = append(findingErrs, findingErr)
+	}
+	return valid, findingErrs
+}
+
+var _regexKeccak256 = regexp.MustCompile("^0x[a-f0-9]{64}$")
+
+func checkValidKeccak256(hash string) bool {
+	return _regexKeccak256.Match([]byte(hash))
+}
@@ -0,0 +1,69 @@
+package poolagent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func testValidFinding() *protocol.Finding {
+	return &protocol.Finding{
+		AlertId:     "ALERT-1",
+		Name:        "Finding",
+		Description: "Finding description",
+		Severity:    protocol.Finding_HIGH,
+		Type:        protocol.Finding_EXPLOIT,
+		Addresses:   []string{"0x5A9d1C5bB7B0Ca1e8eB5e5e5F7fC2D6B6f1f8C3c"},
+		Labels: []*protocol.Label{{
+			EntityType: protocol.Label_ADDRESS,
+			Entity:     "0x5A9d1C5bB7B0Ca1e8eB5e5e5F7fC2D6B6f1f8C3c",
+			Label:      "attacker",
+			Confidence: 0.9,
+		}},
+	}
+}
+
+func TestValidateFinding(t *testing.T) {
+	r := require.New(t)
+
+	r.NoError(ValidateFinding(testValidFinding()))
+	r.Error(ValidateFinding(nil))
+
+	for field, modify := range map[string]func(finding *protocol.Finding){
+		"alertId": func(finding *protocol.Finding) { finding.AlertId = "" },
+		"name":    func(finding *protocol.Finding) { finding.Name = strings.Repeat("a", MaxFindingFieldSize+1) },
+		"description": func(finding *protocol.Finding) {
+			finding.Description = strings.Repeat("a", MaxFindingDescriptionSize+1)
+		},
+		"severity":  func(finding *protocol.Finding) { finding.Severity = 10 },
+		"type":      func(finding *protocol.Finding) { finding.Type = 10 },
+		"addresses": func(finding *protocol.Finding) { finding.Addresses = []string{"0x1234"} },
+		"relatedAlerts": func(finding *protocol.Finding) {
+			finding.RelatedAlerts = []string{"not-a-hash"}
+		},
+		"labels": func(finding *protocol.Finding) { finding.Labels[0].Confidence = 2 },
+	} {
+		finding := testValidFinding()
+		modify(finding)
+		err := ValidateFinding(finding)
+		r.Error(err, field)
+		r.Equal(field, err.(*FindingValidationError).Field)
+	}
+}
+
+func TestFilterInvalidFindings(t *testing.T) {
+	r := require.New(t)
+
+	invalid := testValidFinding()
+	invalid.AlertId = "ALERT-2"
+	invalid.Severity = 10
+	findings, findingErrs := filterInvalidFindings([]*protocol.Finding{testValidFinding(), invalid, nil, testValidFinding()})
+	r.Len(findings, 2)
+	r.Len(findingErrs, 2)
+	r.Equal(1, findingErrs[0].Index)
+	r.Equal("ALERT-2", findingErrs[0].AlertID)
+	r.Equal("severity", findingErrs[0].Field)
+	r.Equal(2, findingErrs[1].Index)
+}
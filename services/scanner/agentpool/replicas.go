@@ -0,0 +1,153 @@
+package agentpool
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services/scanner/agentpool/poolagent"
+	log "github.com/sirupsen/logrus"
+)
+
+// replicaConfigs returns the configs of the replicas which should run for the bot. It expects the
+// lock to be held.
+func (ap *AgentPool) replicaConfigs(agentCfg config.AgentConfig) []config.AgentConfig {
+	replicas := ap.replicaCount(agentCfg)
+	if replicas <= 1 {
+		return []config.AgentConfig{agentCfg}
+	}
+	configs := make([]config.AgentConfig, 0, replicas)
+	for i := 0; i < replicas; i++ {
+		configs = append(configs, agentCfg.WithReplica(uint(i), uint(replicas)))
+	}
+	return configs
+}
+
+// replicaCount returns the configured or the auto-scaled number of the replicas of the bot. It expects
+// the lock to be held.
+func (ap *AgentPool) replicaCount(agentCfg config.AgentConfig) int {
+	// the standalone bots are already running
+	if agentCfg.IsStandalone {
+		return 1
+	}
+	replicas := ap.cfg.Scan.Replicas.GetReplicas(agentCfg.ID)
+	if scaled := ap.scaledReplicas[strings.ToLower(agentCfg.ID)]; scaled > replicas {
+		replicas = scaled
+	}
+	return replicas
+}
+
+func (ap *AgentPool) autoScaleLoop() {
+	ticker := time.NewTicker(time.Duration(ap.cfg.Scan.Replicas.AutoScale.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ap.ctx.Done():
+			return
+		case <-ticker.C:
+			ap.autoScale()
+		}
+	}
+}
+
+// autoScale adds a replica to the bots which can not keep up with the transactions and removes a replica
+// from the bots which are mostly idle. The bots are scaled by one replica at a time and only after all
+// of their replicas are ready.
+func (ap *AgentPool) autoScale() {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+
+	scaleCfg := ap.cfg.Scan.Replicas.AutoScale
+	bots := make(map[string]config.AgentConfig)
+	usage := make(map[string][]float64)
+	for _, agent := range ap.agents {
+		agentCfg := agent.Config()
+		if agentCfg.IsStandalone || !agent.IsReady() {
+			continue
+		}
+		botID := strings.ToLower(agentCfg.ID)
+		bots[botID] = agentCfg
+		usage[botID] = append(usage[botID], agent.TxBufferUsage())
+	}
+
+	var changed bool
+	for botID, agentCfg := range bots {
+		current := ap.replicaCount(agentCfg)
+		if len(usage[botID]) != current {
+			continue
+		}
+		var total float64
+		for _, bufferUsage := range usage[botID] {
+			total += bufferUsage
+		}
+		avgPercent := total / float64(current) * 100
+
+		replicas := current
+		switch {
+		case avgPercent >= float64(scaleCfg.ScaleUpPercent) && current < scaleCfg.MaxReplicas:
+			replicas++
+		case avgPercent <= float64(scaleCfg.ScaleDownPercent) && current > ap.cfg.Scan.Replicas.GetReplicas(botID):
+			replicas--
+		default:
+			continue
+		}
+		log.WithFields(log.Fields{
+			"agent":       agentCfg.ID,
+			"queuePct":    fmt.Sprintf("%.1f", avgPercent),
+			"oldReplicas": current,
+			"newReplicas": replicas,
+		}).Info("scaling the bot replicas")
+		ap.scaledReplicas[botID] = replicas
+		changed = true
+	}
+
+	// forget the bots which are not running anymore
+	for botID := range ap.scaledReplicas {
+		if _, ok := bots[botID]; !ok {
+			delete(ap.scaledReplicas, botID)
+		}
+	}
+
+	if changed && ap.latestVersions != nil {
+		ap.applyLatestVersions()
+	}
+}
+
+// txReplicas groups the ready replicas of the replicated bots by the bot ID, in the replica order.
+func txReplicas(agents []*poolagent.Agent) map[string][]*poolagent.Agent {
+	replicas := make(map[string][]*poolagent.Agent)
+	for _, agent := range agents {
+		agentCfg := agent.Config()
+		if agentCfg.ReplicaConfig == nil || !agent.IsReady() {
+			continue
+		}
+		botID := strings.ToLower(agentCfg.ID)
+		replicas[botID] = append(replicas[botID], agent)
+	}
+	for _, botReplicas := range replicas {
+		sort.Slice(botReplicas, func(i, j int) bool {
+			return botReplicas[i].Config().ReplicaIndex() < botReplicas[j].Config().ReplicaIndex()
+		})
+	}
+	return replicas
+}
+
+// isTxReplica tells if the transaction is on the partition of the replica. The transactions are
+// partitioned between the ready replicas, so the partitions of the replicas which are not ready yet
+// are not skipped.
+func isTxReplica(agent *poolagent.Agent, txHash string, replicas map[string][]*poolagent.Agent) bool {
+	botReplicas := replicas[strings.ToLower(agent.Config().ID)]
+	if len(botReplicas) <= 1 {
+		return true
+	}
+	return botReplicas[txPartition(txHash, len(botReplicas))] == agent
+}
+
+func txPartition(txHash string, partitions int) int {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(txHash)))
+	return int(h.Sum32() % uint32(partitions))
+}
@@ -0,0 +1,113 @@
+package agentpool
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/forta-network/forta-node/clients/messaging"
+	mock_clients "github.com/forta-network/forta-node/clients/mocks"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services/scanner"
+	"github.com/forta-network/forta-node/services/scanner/agentpool/poolagent"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaConfigs(t *testing.T) {
+	r := require.New(t)
+
+	ap := &AgentPool{
+		cfg: config.Config{
+			Scan: config.ScannerConfig{Replicas: config.BotReplicasConfig{Bots: map[string]int{"0xABCD": 3}}},
+		},
+		scaledReplicas: map[string]int{"0x1234": 2},
+	}
+
+	configs := ap.replicaConfigs(config.AgentConfig{ID: "0xabcd"})
+	r.Len(configs, 3)
+	for i, agentCfg := range configs {
+		r.Equal(uint(i), agentCfg.ReplicaIndex())
+		r.Equal(uint(3), agentCfg.ReplicaConfig.Replicas)
+	}
+	r.Len(ap.replicaConfigs(config.AgentConfig{ID: "0x1234"}), 2)
+	r.Nil(ap.replicaConfigs(config.AgentConfig{ID: "0x5678"})[0].ReplicaConfig)
+	r.Len(ap.replicaConfigs(config.AgentConfig{ID: "0xabcd", IsStandalone: true}), 1)
+}
+
+func TestTxReplicas(t *testing.T) {
+	r := require.New(t)
+
+	var agents []*poolagent.Agent
+	for i := 0; i < 3; i++ {
+		agent := poolagent.New(context.Background(), config.AgentConfig{ID: "0xabcd"}.WithReplica(uint(i), 3), nil, nil, nil, nil)
+		agent.SetReady()
+		agents = append(agents, agent)
+	}
+	// the last replica is not ready yet
+	agents = append(agents, poolagent.New(context.Background(), config.AgentConfig{ID: "0xabcd"}.WithReplica(3, 4), nil, nil, nil, nil))
+	single := poolagent.New(context.Background(), config.AgentConfig{ID: "0x1234"}, nil, nil, nil, nil)
+	single.SetReady()
+	agents = append(agents, single)
+
+	replicas := txReplicas(agents)
+	r.Len(replicas["0xabcd"], 3)
+
+	processed := make(map[*poolagent.Agent]int)
+	for i := 0; i < 300; i++ {
+		txHash := fmt.Sprintf("0x%064x", i)
+		var receivers int
+		for _, agent := range agents[:3] {
+			if isTxReplica(agent, txHash, replicas) {
+				processed[agent]++
+				receivers++
+			}
+		}
+		r.Equal(1, receivers, txHash)
+		r.True(isTxReplica(single, txHash, replicas))
+	}
+	for _, agent := range agents[:3] {
+		r.NotZero(processed[agent])
+	}
+}
+
+func TestAutoScale(t *testing.T) {
+	r := require.New(t)
+
+	msgClient := mock_clients.NewMockMessageClient(gomock.NewController(t))
+	agentCfg := config.AgentConfig{ID: "0xabcd", Image: "bot@sha256:1234"}
+	ap := &AgentPool{
+		ctx: context.Background(),
+		cfg: config.Config{
+			Scan: config.ScannerConfig{Replicas: config.BotReplicasConfig{
+				AutoScale: config.ReplicaAutoScaleConfig{Enable: true, MaxReplicas: 2, ScaleUpPercent: 50, ScaleDownPercent: 5},
+			}},
+		},
+		txResults:               make(chan *scanner.TxResult),
+		blockResults:            make(chan *scanner.BlockResult),
+		combinationAlertResults: make(chan *scanner.CombinationAlertResult),
+		msgClient:               msgClient,
+		scaledReplicas:          make(map[string]int),
+		latestVersions:          messaging.AgentPayload{agentCfg},
+	}
+	agent := poolagent.New(ap.ctx, agentCfg, msgClient, ap.txResults, ap.blockResults, ap.combinationAlertResults)
+	agent.SetReady()
+	ap.agents = []*poolagent.Agent{agent}
+
+	// the mostly empty queue does not need more replicas
+	ap.autoScale()
+	r.Empty(ap.scaledReplicas)
+
+	for i := 0; i < poolagent.DefaultBufferSize*3/4; i++ {
+		agent.TxRequestCh() <- &poolagent.TxRequest{}
+	}
+	msgClient.EXPECT().Publish(messaging.SubjectAgentsActionRun, messaging.AgentPayload{agentCfg.WithReplica(1, 2)})
+	ap.autoScale()
+	r.Equal(2, ap.scaledReplicas["0xabcd"])
+	r.Len(ap.agents, 2)
+	r.True(agent.IsPrimaryReplica())
+
+	// the new replica is not ready yet
+	ap.autoScale()
+	r.Equal(2, ap.scaledReplicas["0xabcd"])
+}
@@ -0,0 +1,68 @@
+package agentpool
+
+import (
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/protocol"
+)
+
+// tickCheckInterval is how often the pool checks for the scheduled bot evaluations.
+const tickCheckInterval = 10 * time.Second
+
+// tickSchedule keeps the latest block request and the last scheduled evaluation times of the bots
+// which requested to evaluate the latest block periodically.
+type tickSchedule struct {
+	minInterval time.Duration
+	latestReq   *protocol.EvaluateBlockRequest
+	lastTicks   map[string]time.Time
+	mu          sync.Mutex
+}
+
+// SetLatest sets the latest block request which the scheduled evaluations use.
+func (ts *tickSchedule) SetLatest(req *protocol.EvaluateBlockRequest) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.latestReq = req
+}
+
+// Latest returns the latest block request.
+func (ts *tickSchedule) Latest() *protocol.EvaluateBlockRequest {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.latestReq
+}
+
+// Due tells if the bot should be evaluated now and records the evaluation if so. The interval of
+// a bot is counted from the first time that the bot is seen so the new bots are not evaluated
+// immediately.
+func (ts *tickSchedule) Due(botID string, interval time.Duration, now time.Time) bool {
+	if interval <= 0 {
+		return false
+	}
+	if interval < ts.minInterval {
+		interval = ts.minInterval
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.lastTicks == nil {
+		ts.lastTicks = make(map[string]time.Time)
+	}
+	lastTick, ok := ts.lastTicks[botID]
+	if ok && now.Sub(lastTick) < interval {
+		return false
+	}
+	ts.lastTicks[botID] = now
+	return ok
+}
+
+// Retain forgets the bots which are not in the given set.
+func (ts *tickSchedule) Retain(botIDs map[string]bool) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for botID := range ts.lastTicks {
+		if !botIDs[botID] {
+			delete(ts.lastTicks, botID)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package agentpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTickSchedule(t *testing.T) {
+	r := require.New(t)
+
+	start := time.Now()
+	ts := &tickSchedule{minInterval: time.Minute}
+
+	// the event-driven bots are never due
+	r.False(ts.Due("bot1", 0, start))
+
+	// the interval starts when the bot is seen first
+	r.False(ts.Due("bot1", time.Minute*10, start))
+	r.False(ts.Due("bot1", time.Minute*10, start.Add(time.Minute*9)))
+	r.True(ts.Due("bot1", time.Minute*10, start.Add(time.Minute*10)))
+	r.False(ts.Due("bot1", time.Minute*10, start.Add(time.Minute*11)))
+
+	// the intervals shorter than the minimum are raised to the minimum
+	r.False(ts.Due("bot2", time.Second, start))
+	r.False(ts.Due("bot2", time.Second, start.Add(time.Second*30)))
+	r.True(ts.Due("bot2", time.Second, start.Add(time.Minute)))
+
+	// the forgotten bots start over
+	ts.Retain(map[string]bool{"bot2": true})
+	r.False(ts.Due("bot1", time.Minute*10, start.Add(time.Minute*30)))
+	r.True(ts.Due("bot2", time.Second, start.Add(time.Minute*2)))
+}
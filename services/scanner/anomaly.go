@@ -0,0 +1,166 @@
+package scanner
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-core-go/feeds"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-core-go/utils"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/metrics"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// anomalyMinAvgTxs is the minimum recent average of the tx counts for detecting the drops, so that
+// the quiet chains are not flagged.
+const anomalyMinAvgTxs = 10
+
+// AnomalyDetector follows the block stream and flags the abnormal drops in the tx counts, the streaks
+// of the blocks without logs and the skipped blocks. These usually mean that the provider is degraded
+// and otherwise show up only as fewer findings.
+type AnomalyDetector struct {
+	cfg       config.AnomalyDetectionConfig
+	metricsID string
+	msgClient clients.MessageClient
+
+	txCounts  []int
+	txSum     int
+	lastBlock uint64
+	logStreak int
+	mu        sync.Mutex
+
+	txDropCount    uint64
+	emptyLogsCount uint64
+	dataGapCount   uint64
+	lastAnomaly    time.Time
+	lastAnomalyMsg string
+}
+
+// NewAnomalyDetector creates a new anomaly detector which follows the block feed. The anomalies are
+// sent as metrics under the given ID.
+func NewAnomalyDetector(blockFeed feeds.BlockFeed, cfg config.AnomalyDetectionConfig, metricsID string, msgClient clients.MessageClient) *AnomalyDetector {
+	ad := newAnomalyDetector(cfg)
+	ad.metricsID = metricsID
+	ad.msgClient = msgClient
+	blockFeed.Subscribe(ad.handleBlock)
+	return ad
+}
+
+func newAnomalyDetector(cfg config.AnomalyDetectionConfig) *AnomalyDetector {
+	return &AnomalyDetector{cfg: cfg}
+}
+
+func (ad *AnomalyDetector) handleBlock(evt *domain.BlockEvent) error {
+	blockNum, err := utils.HexToBigInt(evt.Block.Number)
+	if err != nil {
+		log.WithError(err).WithField("block", evt.Block.Number).Warn("failed to parse the block number for anomaly detection")
+		return nil
+	}
+	number := blockNum.Uint64()
+	txCount := len(evt.Block.Transactions)
+
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+
+	var agentMetrics []*protocol.AgentMetric
+
+	// the feed can go back after a reorg, so only the skipped blocks are gaps
+	if ad.lastBlock > 0 && number > ad.lastBlock+1 {
+		missed := number - ad.lastBlock - 1
+		ad.dataGapCount++
+		ad.flag(number, fmt.Sprintf("skipped %d blocks before block %d", missed, number))
+		agentMetrics = append(agentMetrics, metrics.CreateAgentMetric(ad.metricsID, metrics.MetricAnomalyDataGap, float64(missed)))
+	}
+	ad.lastBlock = number
+
+	// compare to the average of a full window only
+	if len(ad.txCounts) == ad.cfg.WindowBlocks {
+		avg := float64(ad.txSum) / float64(len(ad.txCounts))
+		if avg >= anomalyMinAvgTxs && float64(txCount) < avg*float64(ad.cfg.TxDropPercent)/100 {
+			ad.txDropCount++
+			ad.flag(number, fmt.Sprintf("block %d has %d txs while the recent average is %.1f", number, txCount, avg))
+			agentMetrics = append(agentMetrics, metrics.CreateAgentMetric(ad.metricsID, metrics.MetricAnomalyTxDrop, 1))
+		}
+		ad.txSum -= ad.txCounts[0]
+		ad.txCounts = ad.txCounts[1:]
+	}
+	ad.txCounts = append(ad.txCounts, txCount)
+	ad.txSum += txCount
+
+	switch {
+	case len(evt.Logs) > 0:
+		ad.logStreak = 0
+	case txCount > 0:
+		ad.logStreak++
+		if ad.logStreak == ad.cfg.EmptyLogStreak {
+			ad.emptyLogsCount++
+			ad.flag(number, fmt.Sprintf("%d consecutive blocks until block %d have txs but no logs", ad.logStreak, number))
+			agentMetrics = append(agentMetrics, metrics.CreateAgentMetric(ad.metricsID, metrics.MetricAnomalyEmptyLogs, float64(ad.logStreak)))
+		}
+	}
+
+	if ad.msgClient != nil {
+		metrics.SendAgentMetrics(ad.msgClient, agentMetrics)
+	}
+	return nil
+}
+
+func (ad *AnomalyDetector) flag(number uint64, msg string) {
+	ad.lastAnomaly = time.Now()
+	ad.lastAnomalyMsg = msg
+	log.WithField("block", number).Warnf("block stream anomaly: %s - the json-rpc provider may be degraded", msg)
+}
+
+// Name returns the name of the detector.
+func (ad *AnomalyDetector) Name() string {
+	return "anomaly-detector"
+}
+
+// Health implements health.Reporter interface.
+func (ad *AnomalyDetector) Health() health.Reports {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+
+	warningReport := &health.Report{
+		Name:   "anomaly.warning",
+		Status: health.StatusOK,
+	}
+	var lastAnomaly string
+	if !ad.lastAnomaly.IsZero() {
+		lastAnomaly = ad.lastAnomaly.Format(time.RFC3339)
+		if time.Since(ad.lastAnomaly) < time.Duration(ad.cfg.WarningSeconds)*time.Second {
+			warningReport.Status = health.StatusLagging
+			warningReport.Details = ad.lastAnomalyMsg
+		}
+	}
+	return health.Reports{
+		warningReport,
+		&health.Report{
+			Name:    "anomaly.tx-drop.count",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(ad.txDropCount, 10),
+		},
+		&health.Report{
+			Name:    "anomaly.empty-logs.count",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(ad.emptyLogsCount, 10),
+		},
+		&health.Report{
+			Name:    "anomaly.data-gap.count",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(ad.dataGapCount, 10),
+		},
+		&health.Report{
+			Name:    "anomaly.time",
+			Status:  health.StatusInfo,
+			Details: lastAnomaly,
+		},
+	}
+}
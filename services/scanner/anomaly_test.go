@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-node/clients/messaging"
+	mock_clients "github.com/forta-network/forta-node/clients/mocks"
+	"github.com/forta-network/forta-node/config"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func testAnomalyBlock(number uint64, txCount, logCount int) *domain.BlockEvent {
+	return &domain.BlockEvent{
+		Block: &domain.Block{
+			Number:       fmt.Sprintf("0x%x", number),
+			Transactions: make([]domain.Transaction, txCount),
+		},
+		Logs: make([]domain.LogEntry, logCount),
+	}
+}
+
+func TestAnomalyDetector(t *testing.T) {
+	r := require.New(t)
+
+	msgClient := mock_clients.NewMockMessageClient(gomock.NewController(t))
+	ad := newAnomalyDetector(config.AnomalyDetectionConfig{
+		WindowBlocks:   10,
+		TxDropPercent:  20,
+		EmptyLogStreak: 3,
+		WarningSeconds: 60,
+	})
+	ad.metricsID = "0xscanner"
+	ad.msgClient = msgClient
+
+	for i := uint64(1); i <= 10; i++ {
+		r.NoError(ad.handleBlock(testAnomalyBlock(i, 100, 10)))
+	}
+	r.Equal(health.StatusOK, ad.Health()[0].Status)
+
+	// a block with much fewer txs than the recent average
+	msgClient.EXPECT().PublishProto(messaging.SubjectMetricAgent, gomock.Any())
+	r.NoError(ad.handleBlock(testAnomalyBlock(11, 5, 10)))
+	r.Equal(uint64(1), ad.txDropCount)
+
+	// skipped blocks
+	msgClient.EXPECT().PublishProto(messaging.SubjectMetricAgent, gomock.Any())
+	r.NoError(ad.handleBlock(testAnomalyBlock(14, 100, 10)))
+	r.Equal(uint64(1), ad.dataGapCount)
+
+	// going back after a reorg is not a gap
+	r.NoError(ad.handleBlock(testAnomalyBlock(13, 100, 10)))
+	r.NoError(ad.handleBlock(testAnomalyBlock(14, 100, 10)))
+	r.Equal(uint64(1), ad.dataGapCount)
+
+	// the blocks with txs but without logs are flagged once per streak
+	r.NoError(ad.handleBlock(testAnomalyBlock(15, 100, 0)))
+	r.NoError(ad.handleBlock(testAnomalyBlock(16, 100, 0)))
+	msgClient.EXPECT().PublishProto(messaging.SubjectMetricAgent, gomock.Any())
+	r.NoError(ad.handleBlock(testAnomalyBlock(17, 100, 0)))
+	r.NoError(ad.handleBlock(testAnomalyBlock(18, 100, 0)))
+	r.Equal(uint64(1), ad.emptyLogsCount)
+
+	reports := ad.Health()
+	r.Equal(health.StatusLagging, reports[0].Status)
+	r.Contains(reports[0].Details, "no logs")
+	r.Equal("1", reports[1].Details)
+	r.Equal("1", reports[2].Details)
+	r.Equal("1", reports[3].Details)
+	r.NotEmpty(reports[4].Details)
+}
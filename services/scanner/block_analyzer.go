@@ -34,10 +34,16 @@ type BlockAnalyzerServiceConfig struct {
 	AlertSender  clients.AlertSender
 	AgentPool    AgentPool
 	MsgClient    clients.MessageClient
+	Checkpoints  *EvalCheckpoints
+	CrossCheck   *CrossCheckedClient
+	Clock        *ClockSkewMonitor
 }
 
 func (t *BlockAnalyzerService) publishMetrics(result *BlockResult) {
-	m := metrics.GetBlockMetrics(result.AgentConfig, result.Response, result.Timestamps)
+	m := metrics.GetBlockMetrics(result.AgentConfig, result.Response, t.cfg.Clock.Compensate(result.Timestamps))
+	if t.cfg.CrossCheck.Diverged(result.Request.Event.BlockHash) {
+		m = append(m, metrics.CreateAgentMetric(result.AgentConfig.ID, metrics.MetricDataDivergence, 1))
+	}
 	t.cfg.MsgClient.PublishProto(messaging.SubjectMetricAgent, &protocol.AgentMetricList{Metrics: m})
 }
 
@@ -68,6 +74,10 @@ func (t *BlockAnalyzerService) findingToAlert(result *BlockResult, ts time.Time,
 		"agentId":    result.AgentConfig.ID,
 		"chainId":    chainId.String(),
 	}
+	// the providers disagreed on the block data and the data of the majority was evaluated
+	if t.cfg.CrossCheck.Diverged(result.Request.Event.BlockHash) {
+		tags["dataDivergence"] = "true"
+	}
 
 	alertType := protocol.AlertType_PRIVATE
 	if !f.Private && !result.Response.Private {
@@ -119,6 +129,7 @@ func (t *BlockAnalyzerService) Start() error {
 				EvalBlockRequest:  result.Request,
 				EvalBlockResponse: result.Response,
 			}
+			t.cfg.Checkpoints.Set(result.AgentConfig.ID, result.Request.GetEvent().GetBlockNumber())
 
 			if len(result.Response.Findings) == 0 {
 				if err := t.cfg.AlertSender.NotifyWithoutAlert(
@@ -0,0 +1,200 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/forta-network/forta-core-go/clients/health"
+	log "github.com/sirupsen/logrus"
+)
+
+// EvalCheckpoint is the last block which a bot evaluated.
+type EvalCheckpoint struct {
+	BotID       string    `json:"botId"`
+	BlockNumber uint64    `json:"blockNumber"`
+	Time        time.Time `json:"time"`
+}
+
+// EvalCheckpoints keeps the last block evaluated by each bot and persists them to a file
+// in the Forta dir, so the scanning can resume from there after a restart. The checkpoints
+// are moved by the block results since every bot evaluates every block.
+type EvalCheckpoints struct {
+	ctx          context.Context
+	filePath     string
+	saveInterval time.Duration
+	checkpoints  map[string]*EvalCheckpoint
+	mu           sync.Mutex
+
+	lastSave health.ErrorTracker
+}
+
+// NewEvalCheckpoints creates the checkpoints from the file if it exists.
+func NewEvalCheckpoints(ctx context.Context, filePath string, saveInterval time.Duration) *EvalCheckpoints {
+	ec := &EvalCheckpoints{
+		ctx:          ctx,
+		filePath:     filePath,
+		saveInterval: saveInterval,
+		checkpoints:  make(map[string]*EvalCheckpoint),
+	}
+	list, err := LoadEvalCheckpoints(filePath)
+	if err != nil {
+		log.WithError(err).Warn("failed to load the evaluation checkpoints - starting from scratch")
+	}
+	for _, checkpoint := range list {
+		ec.checkpoints[checkpoint.BotID] = checkpoint
+	}
+	return ec
+}
+
+// LoadEvalCheckpoints loads the persisted checkpoints. The list is empty if the file does not exist.
+func LoadEvalCheckpoints(filePath string) ([]*EvalCheckpoint, error) {
+	b, err := ioutil.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*EvalCheckpoint
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode the evaluation checkpoints: %v", err)
+	}
+	return list, nil
+}
+
+// Set moves the checkpoint of the bot forward to the evaluated block.
+func (ec *EvalCheckpoints) Set(botID, blockNumberHex string) {
+	if ec == nil {
+		return
+	}
+	blockNumber, err := hexutil.DecodeUint64(blockNumberHex)
+	if err != nil {
+		return
+	}
+
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	checkpoint, ok := ec.checkpoints[botID]
+	if !ok {
+		checkpoint = &EvalCheckpoint{BotID: botID}
+		ec.checkpoints[botID] = checkpoint
+	}
+	if blockNumber >= checkpoint.BlockNumber {
+		checkpoint.BlockNumber = blockNumber
+		checkpoint.Time = time.Now().UTC()
+	}
+}
+
+// ResumeFrom returns the oldest checkpoint within the max number of blocks from the latest block.
+// The checkpoints of the bots which are behind the window are dropped and the window start is used
+// instead. The checkpoint block is included in the resumed blocks since it may be partially evaluated.
+func (ec *EvalCheckpoints) ResumeFrom(latestBlock uint64, maxResumeBlocks int) (*EvalCheckpoint, bool) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+
+	var windowStart uint64
+	if latestBlock > uint64(maxResumeBlocks) {
+		windowStart = latestBlock - uint64(maxResumeBlocks)
+	}
+	var oldest *EvalCheckpoint
+	for botID, checkpoint := range ec.checkpoints {
+		resumeFrom := *checkpoint
+		if resumeFrom.BlockNumber < windowStart {
+			delete(ec.checkpoints, botID)
+			resumeFrom.BlockNumber = windowStart
+		}
+		if oldest == nil || resumeFrom.BlockNumber < oldest.BlockNumber {
+			oldest = &resumeFrom
+		}
+	}
+	if oldest == nil || oldest.BlockNumber >= latestBlock {
+		return nil, false
+	}
+	return oldest, true
+}
+
+// Reset drops the checkpoints when the scanning is started from another block on request, so that
+// a restart does not resume from the checkpoints which were overridden.
+func (ec *EvalCheckpoints) Reset() {
+	ec.mu.Lock()
+	ec.checkpoints = make(map[string]*EvalCheckpoint)
+	ec.mu.Unlock()
+	ec.save()
+}
+
+// List returns the checkpoints ordered by the bot ID.
+func (ec *EvalCheckpoints) List() []*EvalCheckpoint {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+
+	var list []*EvalCheckpoint
+	for _, checkpoint := range ec.checkpoints {
+		checkpointCopy := *checkpoint
+		list = append(list, &checkpointCopy)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].BotID < list[j].BotID
+	})
+	return list
+}
+
+// Save writes the checkpoints to the file.
+func (ec *EvalCheckpoints) Save() error {
+	b, err := json.Marshal(ec.List())
+	if err != nil {
+		return err
+	}
+	tmpPath := ec.filePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, ec.filePath)
+}
+
+func (ec *EvalCheckpoints) save() {
+	err := ec.Save()
+	ec.lastSave.Set(err)
+	if err != nil {
+		log.WithError(err).Warn("failed to save the evaluation checkpoints")
+	}
+}
+
+func (ec *EvalCheckpoints) Start() error {
+	go func() {
+		ticker := time.NewTicker(ec.saveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ec.ctx.Done():
+				return
+			case <-ticker.C:
+				ec.save()
+			}
+		}
+	}()
+	return nil
+}
+
+func (ec *EvalCheckpoints) Stop() error {
+	ec.save()
+	return nil
+}
+
+func (ec *EvalCheckpoints) Name() string {
+	return "eval-checkpoints"
+}
+
+// Health implements health.Reporter interface.
+func (ec *EvalCheckpoints) Health() health.Reports {
+	return health.Reports{
+		ec.lastSave.GetReport("checkpoints.save"),
+	}
+}
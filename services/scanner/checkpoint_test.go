@@ -0,0 +1,68 @@
+package scanner
+
+import (
+	"context"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvalCheckpoints(t *testing.T) {
+	r := require.New(t)
+
+	filePath := path.Join(t.TempDir(), "checkpoints.json")
+	ec := NewEvalCheckpoints(context.Background(), filePath, time.Second)
+	ec.Set("bot1", "0x64")
+	ec.Set("bot2", "0x5a")
+	ec.Set("bot3", "0x14")
+	// invalid blocks and older blocks are ignored
+	ec.Set("bot1", "invalid")
+	ec.Set("bot1", "0x63")
+
+	r.NoError(ec.Save())
+	list, err := LoadEvalCheckpoints(filePath)
+	r.NoError(err)
+	r.Len(list, 3)
+	r.Equal(uint64(100), list[0].BlockNumber)
+
+	// bot3 is behind the window so the window start is used
+	ec = NewEvalCheckpoints(context.Background(), filePath, time.Second)
+	resume, ok := ec.ResumeFrom(110, 50)
+	r.True(ok)
+	r.Equal(uint64(60), resume.BlockNumber)
+	r.Len(ec.List(), 2)
+
+	resume, ok = ec.ResumeFrom(110, 50)
+	r.True(ok)
+	r.Equal("bot2", resume.BotID)
+	r.Equal(uint64(90), resume.BlockNumber)
+
+	_, ok = ec.ResumeFrom(90, 50)
+	r.False(ok)
+}
+
+func TestEvalCheckpoints_NoFile(t *testing.T) {
+	r := require.New(t)
+
+	list, err := LoadEvalCheckpoints(path.Join(t.TempDir(), "checkpoints.json"))
+	r.NoError(err)
+	r.Empty(list)
+
+	var ec *EvalCheckpoints
+	ec.Set("bot1", "0x1")
+}
+
+func TestEvalCheckpoints_Reset(t *testing.T) {
+	r := require.New(t)
+
+	filePath := path.Join(t.TempDir(), "checkpoints.json")
+	ec := NewEvalCheckpoints(context.Background(), filePath, time.Second)
+	ec.Set("bot1", "0x64")
+	ec.Reset()
+
+	list, err := LoadEvalCheckpoints(filePath)
+	r.NoError(err)
+	r.Empty(list)
+}
@@ -0,0 +1,251 @@
+package scanner
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ntpPort       = "123"
+	ntpPacketSize = 48
+	// the seconds between the NTP epoch (1900) and the Unix epoch (1970)
+	ntpEpochOffset = 2208988800
+	// leap indicator 0, version 3, client mode
+	ntpClientHeader = 0x1B
+	ntpModeServer   = 4
+)
+
+// ClockSkewMonitor measures the offset of the node clock from the NTP servers periodically. The
+// node-local timestamps of the events are shifted by the offset before the latencies are calculated,
+// so that the latencies stay correct on the hosts with a bad clock.
+type ClockSkewMonitor struct {
+	ctx       context.Context
+	cfg       config.ClockSkewConfig
+	metricsID string
+	msgClient clients.MessageClient
+
+	offset    time.Duration
+	measured  bool
+	lastCheck time.Time
+	server    string
+	lastErr   health.ErrorTracker
+	mu        sync.RWMutex
+
+	queryFunc func(ctx context.Context, server string) (time.Duration, error)
+}
+
+// NewClockSkewMonitor creates a new clock skew monitor. The skew is sent as a metric under the given ID.
+func NewClockSkewMonitor(ctx context.Context, cfg config.ClockSkewConfig, metricsID string, msgClient clients.MessageClient) *ClockSkewMonitor {
+	return &ClockSkewMonitor{
+		ctx:       ctx,
+		cfg:       cfg,
+		metricsID: metricsID,
+		msgClient: msgClient,
+		queryFunc: queryNTP,
+	}
+}
+
+// Start implements the services.Service interface.
+func (csm *ClockSkewMonitor) Start() error {
+	go func() {
+		ticker := time.NewTicker(time.Duration(csm.cfg.IntervalSeconds) * time.Second)
+		defer ticker.Stop()
+		for {
+			csm.measure()
+			select {
+			case <-csm.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop implements the services.Service interface.
+func (csm *ClockSkewMonitor) Stop() error {
+	return nil
+}
+
+// Name returns the name of the monitor.
+func (csm *ClockSkewMonitor) Name() string {
+	return "clock-skew-monitor"
+}
+
+// measure queries the servers in order and keeps the offset from the first one which responds.
+func (csm *ClockSkewMonitor) measure() {
+	var errs []error
+	for _, server := range csm.cfg.Servers {
+		ctx, cancel := context.WithTimeout(csm.ctx, time.Duration(csm.cfg.TimeoutSeconds)*time.Second)
+		offset, err := csm.queryFunc(ctx, server)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", server, err))
+			continue
+		}
+		csm.setOffset(server, offset)
+		return
+	}
+	if len(errs) == 0 {
+		return
+	}
+	err := fmt.Errorf("failed to query the ntp servers: %v", errs)
+	log.WithError(err).Warn("failed to measure the clock skew")
+	csm.lastErr.Set(err)
+}
+
+func (csm *ClockSkewMonitor) setOffset(server string, offset time.Duration) {
+	csm.mu.Lock()
+	csm.offset = offset
+	csm.measured = true
+	csm.lastCheck = time.Now()
+	csm.server = server
+	csm.mu.Unlock()
+	csm.lastErr.Set(nil)
+
+	logger := log.WithFields(log.Fields{
+		"server":   server,
+		"offsetMs": offset.Milliseconds(),
+	})
+	if csm.isSkewed(offset) {
+		logger.Warn("node clock is skewed - please sync the host clock with ntp")
+	} else {
+		logger.Debug("measured the clock skew")
+	}
+	if csm.msgClient != nil {
+		metrics.SendAgentMetrics(csm.msgClient, []*protocol.AgentMetric{
+			metrics.CreateAgentMetric(csm.metricsID, metrics.MetricClockSkew, float64(offset.Milliseconds())),
+		})
+	}
+}
+
+func (csm *ClockSkewMonitor) isSkewed(offset time.Duration) bool {
+	if offset < 0 {
+		offset = -offset
+	}
+	return offset > time.Duration(csm.cfg.MaxSkewMs)*time.Millisecond
+}
+
+// Offset returns how much the node clock is behind the NTP time. It is zero until the first
+// measurement and when the monitor is disabled.
+func (csm *ClockSkewMonitor) Offset() time.Duration {
+	if csm == nil {
+		return 0
+	}
+	csm.mu.RLock()
+	defer csm.mu.RUnlock()
+	return csm.offset
+}
+
+// Compensate returns a copy of the timestamps in which the node-local timestamps are shifted by
+// the clock offset. The chain and the source alert timestamps are not set by the node clock and
+// are kept as they are.
+func (csm *ClockSkewMonitor) Compensate(ts *domain.TrackingTimestamps) *domain.TrackingTimestamps {
+	offset := csm.Offset()
+	if ts == nil || offset == 0 {
+		return ts
+	}
+	compensated := *ts
+	for _, t := range []*time.Time{&compensated.Feed, &compensated.BotRequest, &compensated.BotResponse} {
+		if !t.IsZero() {
+			*t = t.Add(offset)
+		}
+	}
+	return &compensated
+}
+
+// Health implements the health.Reporter interface.
+func (csm *ClockSkewMonitor) Health() health.Reports {
+	csm.mu.RLock()
+	defer csm.mu.RUnlock()
+
+	skewReport := &health.Report{
+		Name:   "clock.skew",
+		Status: health.StatusOK,
+	}
+	var lastCheck string
+	if csm.measured {
+		skewReport.Details = fmt.Sprintf("%dms (%s)", csm.offset.Milliseconds(), csm.server)
+		if csm.isSkewed(csm.offset) {
+			skewReport.Status = health.StatusLagging
+		}
+		lastCheck = csm.lastCheck.Format(time.RFC3339)
+	}
+	return health.Reports{
+		skewReport,
+		&health.Report{
+			Name:    "clock.time",
+			Status:  health.StatusInfo,
+			Details: lastCheck,
+		},
+		csm.lastErr.GetReport("clock.ntp.error"),
+	}
+}
+
+// queryNTP sends an SNTP request to the server and returns the offset of the local clock from the
+// server clock, calculated from the request and the response timestamps as in RFC 4330.
+func queryNTP(ctx context.Context, server string) (time.Duration, error) {
+	addr := server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		addr = net.JoinHostPort(server, ntpPort)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	req := make([]byte, ntpPacketSize)
+	req[0] = ntpClientHeader
+	originTime := time.Now()
+	if _, err := conn.Write(req); err != nil {
+		return 0, err
+	}
+	resp := make([]byte, ntpPacketSize)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return 0, err
+	}
+	destinationTime := time.Now()
+	return ntpOffset(resp[:n], originTime, destinationTime)
+}
+
+// ntpOffset calculates the clock offset from an NTP response and the local send and receive times.
+func ntpOffset(resp []byte, originTime, destinationTime time.Time) (time.Duration, error) {
+	if len(resp) < ntpPacketSize {
+		return 0, fmt.Errorf("short ntp response: %d bytes", len(resp))
+	}
+	if mode := resp[0] & 0x07; mode != ntpModeServer {
+		return 0, fmt.Errorf("unexpected ntp mode: %d", mode)
+	}
+	// zero stratum is a "kiss-o'-death" message
+	if resp[1] == 0 {
+		return 0, errors.New("ntp server refused the request")
+	}
+	receiveTime := ntpTime(resp[32:40])
+	transmitTime := ntpTime(resp[40:48])
+	return (receiveTime.Sub(originTime) + transmitTime.Sub(destinationTime)) / 2, nil
+}
+
+func ntpTime(b []byte) time.Time {
+	seconds := int64(binary.BigEndian.Uint32(b[0:4])) - ntpEpochOffset
+	fraction := uint64(binary.BigEndian.Uint32(b[4:8]))
+	return time.Unix(seconds, int64((fraction*uint64(time.Second))>>32))
+}
@@ -0,0 +1,96 @@
+package scanner
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func putNTPTime(b []byte, t time.Time) {
+	binary.BigEndian.PutUint32(b[0:4], uint32(t.Unix()+ntpEpochOffset))
+	binary.BigEndian.PutUint32(b[4:8], uint32((uint64(t.Nanosecond())<<32)/uint64(time.Second)))
+}
+
+// runNTPServer responds to the NTP requests with a clock which is ahead of the local clock.
+func runNTPServer(t *testing.T, ahead time.Duration) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		req := make([]byte, ntpPacketSize)
+		for {
+			_, addr, err := conn.ReadFrom(req)
+			if err != nil {
+				return
+			}
+			resp := make([]byte, ntpPacketSize)
+			resp[0] = 0x1C // version 3, server mode
+			resp[1] = 1
+			putNTPTime(resp[32:40], time.Now().Add(ahead))
+			putNTPTime(resp[40:48], time.Now().Add(ahead))
+			conn.WriteTo(resp, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestQueryNTP(t *testing.T) {
+	r := require.New(t)
+
+	addr := runNTPServer(t, time.Second*2)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	offset, err := queryNTP(ctx, addr)
+	r.NoError(err)
+	r.InDelta(float64(time.Second*2), float64(offset), float64(time.Millisecond*100))
+
+	_, err = ntpOffset(make([]byte, ntpPacketSize), time.Now(), time.Now())
+	r.Error(err)
+}
+
+func TestClockSkewMonitor(t *testing.T) {
+	r := require.New(t)
+
+	csm := NewClockSkewMonitor(context.Background(), config.ClockSkewConfig{
+		Servers:        []string{"bad.server", "good.server"},
+		TimeoutSeconds: 1,
+		MaxSkewMs:      1000,
+	}, "0xscanner", nil)
+	csm.queryFunc = func(ctx context.Context, server string) (time.Duration, error) {
+		if server == "bad.server" {
+			return 0, errors.New("timeout")
+		}
+		return time.Second * 3, nil
+	}
+	csm.measure()
+	r.Equal(time.Second*3, csm.Offset())
+
+	reports := csm.Health()
+	r.Equal(health.StatusLagging, reports[0].Status)
+	r.Equal("3000ms (good.server)", reports[0].Details)
+	r.NotEmpty(reports[1].Details)
+	r.Empty(reports[2].Details)
+
+	// the node-local timestamps are shifted and the chain timestamp is kept
+	now := time.Now()
+	ts := &domain.TrackingTimestamps{Block: now.Add(-time.Second * 5), Feed: now.Add(-time.Second), BotRequest: now}
+	compensated := csm.Compensate(ts)
+	r.Equal(ts.Block, compensated.Block)
+	r.Equal(now.Add(time.Second*2), compensated.Feed)
+	r.Equal(now.Add(time.Second*3), compensated.BotRequest)
+	r.True(compensated.BotResponse.IsZero())
+	r.Equal(now, ts.BotRequest)
+
+	// nothing is compensated without a monitor
+	var disabled *ClockSkewMonitor
+	r.Equal(ts, disabled.Compensate(ts))
+}
@@ -2,15 +2,27 @@ package scanner
 
 import (
 	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/forta-network/forta-core-go/clients/health"
 	"github.com/forta-network/forta-core-go/domain"
 	"github.com/forta-network/forta-core-go/feeds"
+	"github.com/forta-network/forta-core-go/protocol"
 	"github.com/forta-network/forta-node/clients"
 	"github.com/forta-network/forta-node/clients/messaging"
+	"github.com/forta-network/forta-node/services"
+	"github.com/patrickmn/go-cache"
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	localAlertBufferSize = 1000
+	// the public copy of a locally delivered alert is skipped if it arrives within this period
+	localAlertDedupPeriod = time.Hour
+)
+
 // CombinerAlertStreamService pulls alert info from providers and emits to channel
 type CombinerAlertStreamService struct {
 	cfg         CombinerAlertStreamServiceConfig
@@ -22,11 +34,20 @@ type CombinerAlertStreamService struct {
 	subscribeChan     chan string
 	unSubscribeChan   chan string
 	lastAlertActivity health.TimeTracker
+
+	localAlertCh      chan *domain.AlertEvent
+	localAlerts       *cache.Cache
+	localDelivered    uint64 // accessed atomically
+	localDeduplicated uint64 // accessed atomically
+
+	pause services.PauseState
 }
 
 type CombinerAlertStreamServiceConfig struct {
 	Start uint64
 	End   uint64
+	// disables delivering the alerts of the local bots to the local subscribers before the alert API
+	DisableLocalDelivery bool
 }
 
 func (t *CombinerAlertStreamService) registerMessageHandlers() {
@@ -44,6 +65,13 @@ func (t *CombinerAlertStreamService) handleAlert(evt *domain.AlertEvent) error {
 		return nil
 	default:
 	}
+	if t.pause.IsPaused() {
+		return nil
+	}
+	if _, ok := t.localAlerts.Get(evt.Event.Alert.Hash); ok {
+		atomic.AddUint64(&t.localDeduplicated, 1)
+		return nil
+	}
 
 	log.WithFields(
 		log.Fields{
@@ -57,8 +85,72 @@ func (t *CombinerAlertStreamService) handleAlert(evt *domain.AlertEvent) error {
 	return nil
 }
 
+// HandleLocalAlert delivers an alert of a bot on this node to the local subscribers without waiting
+// for the alert API. The public copy of the alert is skipped later.
+func (t *CombinerAlertStreamService) HandleLocalAlert(alert *protocol.AlertEvent) {
+	if t.cfg.DisableLocalDelivery || t.pause.IsPaused() {
+		return
+	}
+	if !isSubscribedToAlert(t.alertFeed.Subscriptions(), alert.Alert) {
+		return
+	}
+	if err := t.localAlerts.Add(alert.Alert.Hash, struct{}{}, cache.DefaultExpiration); err != nil {
+		return // already delivered
+	}
+	evt := &domain.AlertEvent{
+		Event: alert,
+		Timestamps: &domain.TrackingTimestamps{
+			Feed:        time.Now().UTC(),
+			SourceAlert: alertTime(alert.Alert),
+		},
+	}
+	select {
+	case t.localAlertCh <- evt:
+	default:
+		// let the public copy through since this one is dropped
+		t.localAlerts.Delete(alert.Alert.Hash)
+		log.WithField("alert", alert.Alert.Hash).Warn("local alert buffer is full - waiting for the alert api")
+	}
+}
+
+// alertTime returns the creation time of the alert like the alert feed does, or the current time
+// if it cannot be parsed.
+func alertTime(alert *protocol.AlertEvent_Alert) time.Time {
+	createdAt, err := time.Parse(time.RFC3339, alert.CreatedAt)
+	if err != nil {
+		return time.Now().UTC()
+	}
+	return createdAt
+}
+
+func (t *CombinerAlertStreamService) deliverLocalAlerts() {
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case evt := <-t.localAlertCh:
+			log.WithFields(
+				log.Fields{
+					"subscribee": evt.Event.Alert.Source.Bot.Id,
+					"alert":      evt.Event.Alert.Hash,
+				},
+			).Debug("streaming new local alert")
+			select {
+			case <-t.ctx.Done():
+				return
+			case t.alertOutput <- evt:
+			}
+			atomic.AddUint64(&t.localDelivered, 1)
+			t.lastAlertActivity.Set()
+		}
+	}
+}
+
 func (t *CombinerAlertStreamService) Start() error {
 	t.registerMessageHandlers()
+	if !t.cfg.DisableLocalDelivery {
+		go t.deliverLocalAlerts()
+	}
 	go func() {
 		t.alertFeed.RegisterHandler(t.handleAlert)
 		t.alertFeed.Start()
@@ -84,6 +176,11 @@ func (t *CombinerAlertStreamService) Stop() error {
 	return nil
 }
 
+// SetPaused implements services.Pauser. The alerts are skipped while paused.
+func (t *CombinerAlertStreamService) SetPaused(paused bool) {
+	t.pause.SetPaused(paused)
+}
+
 func (t *CombinerAlertStreamService) Name() string {
 	return "combiner-alert-stream"
 }
@@ -92,6 +189,17 @@ func (t *CombinerAlertStreamService) Name() string {
 func (t *CombinerAlertStreamService) Health() health.Reports {
 	return health.Reports{
 		t.lastAlertActivity.GetReport("event.alert.time"),
+		t.pause.GetReport("paused"),
+		&health.Report{
+			Name:    "local.delivered",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&t.localDelivered), 10),
+		},
+		&health.Report{
+			Name:    "local.deduplicated",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&t.localDeduplicated), 10),
+		},
 	}
 }
 
@@ -122,10 +230,12 @@ func NewCombinerAlertStreamService(ctx context.Context, alertFeed feeds.AlertFee
 	alertOutput := make(chan *domain.AlertEvent)
 
 	return &CombinerAlertStreamService{
-		cfg:         cfg,
-		ctx:         ctx,
-		msgClient:   msgClient,
-		alertOutput: alertOutput,
-		alertFeed:   alertFeed,
+		cfg:          cfg,
+		ctx:          ctx,
+		msgClient:    msgClient,
+		alertOutput:  alertOutput,
+		alertFeed:    alertFeed,
+		localAlertCh: make(chan *domain.AlertEvent, localAlertBufferSize),
+		localAlerts:  cache.New(localAlertDedupPeriod, localAlertDedupPeriod),
 	}, nil
 }
@@ -0,0 +1,280 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/domain"
+	fortaethereum "github.com/forta-network/forta-core-go/ethereum"
+	"github.com/forta-network/forta-node/config"
+	lru "github.com/hashicorp/golang-lru"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxDivergedBlocks is how many of the latest diverged block hashes are remembered for annotating the alerts.
+const maxDivergedBlocks = 1000
+
+// CrossCheckedClient fetches the blocks (and optionally the receipts) also from the secondary providers and
+// prefers the data which the majority of the providers agree on. The primary provider wins the ties.
+// When a secondary provider wins the block, the logs and the receipts of the block are fetched from
+// that provider as well, so that they belong to the selected block.
+type CrossCheckedClient struct {
+	fortaethereum.Client
+	secondaries     []fortaethereum.Client
+	compareReceipts bool
+	timeout         time.Duration
+	selected        *lru.Cache // block number -> the secondary provider which won the block
+
+	diverged      map[string]bool
+	divergedOrder []string
+	mu            sync.RWMutex
+
+	divergenceCount     uint64 // accessed atomically
+	secondaryErrCount   uint64 // accessed atomically
+	lastDivergence      health.TimeTracker
+	lastDivergenceBlock health.MessageTracker
+}
+
+// NewCrossCheckedClient creates a new cross-checked client.
+func NewCrossCheckedClient(
+	primary fortaethereum.Client, secondaries []fortaethereum.Client, cfg config.CrossValidationConfig,
+) *CrossCheckedClient {
+	selected, _ := lru.New(maxDivergedBlocks)
+	return &CrossCheckedClient{
+		Client:          primary,
+		secondaries:     secondaries,
+		compareReceipts: cfg.CompareReceipts,
+		timeout:         time.Duration(cfg.TimeoutSeconds) * time.Second,
+		selected:        selected,
+		diverged:        make(map[string]bool),
+	}
+}
+
+// providerData is the data from one of the providers and the digest to compare it with.
+// The client is nil for the primary provider.
+type providerData struct {
+	value  interface{}
+	digest string
+	client fortaethereum.Client
+}
+
+// BlockByNumber implements ethereum.Client interface.
+func (cc *CrossCheckedClient) BlockByNumber(ctx context.Context, number *big.Int) (*domain.Block, error) {
+	// the providers can be at different heights
+	if number == nil {
+		return cc.Client.BlockByNumber(ctx, number)
+	}
+	secondaryData := cc.fetchSecondaries(ctx, func(ctx context.Context, client fortaethereum.Client) (*providerData, error) {
+		block, err := client.BlockByNumber(ctx, number)
+		if err != nil {
+			return nil, err
+		}
+		return &providerData{value: block, digest: block.Hash}, nil
+	})
+	block, err := cc.Client.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	chosen, diverged := chooseMajority(&providerData{value: block, digest: block.Hash}, <-secondaryData)
+	if chosen.client != nil {
+		cc.selected.Add(number.Uint64(), chosen.client)
+	} else {
+		cc.selected.Remove(number.Uint64())
+	}
+	if diverged {
+		chosenBlock := chosen.value.(*domain.Block)
+		cc.markDiverged(chosenBlock.Hash, chosenBlock.Number)
+		log.WithFields(log.Fields{
+			"blockNumber":  chosenBlock.Number,
+			"primaryHash":  block.Hash,
+			"selectedHash": chosenBlock.Hash,
+		}).Warn("block data diverged between the json-rpc providers")
+		return chosenBlock, nil
+	}
+	return block, nil
+}
+
+// GetLogs implements ethereum.Client interface. The logs of a block which a secondary provider won are
+// fetched only from that provider.
+func (cc *CrossCheckedClient) GetLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	if q.FromBlock != nil && q.ToBlock != nil && q.FromBlock.Cmp(q.ToBlock) == 0 && q.FromBlock.IsUint64() {
+		if secondary, ok := cc.selectedClient(q.FromBlock.Uint64()); ok {
+			return secondary.GetLogs(ctx, q)
+		}
+	}
+	return cc.Client.GetLogs(ctx, q)
+}
+
+// selectedClient returns the secondary provider which won the block.
+func (cc *CrossCheckedClient) selectedClient(blockNumber uint64) (fortaethereum.Client, bool) {
+	client, ok := cc.selected.Get(blockNumber)
+	if !ok {
+		return nil, false
+	}
+	return client.(fortaethereum.Client), true
+}
+
+// TransactionReceipt implements ethereum.Client interface.
+func (cc *CrossCheckedClient) TransactionReceipt(ctx context.Context, txHash string) (*domain.TransactionReceipt, error) {
+	if !cc.compareReceipts {
+		receipt, err := cc.Client.TransactionReceipt(ctx, txHash)
+		if err != nil {
+			return nil, err
+		}
+		// the receipt is fetched again from the provider which won the block
+		blockNumber, err := strconv.ParseUint(deref(receipt.BlockNumber), 0, 64)
+		if err != nil {
+			return receipt, nil
+		}
+		if secondary, ok := cc.selectedClient(blockNumber); ok {
+			return secondary.TransactionReceipt(ctx, txHash)
+		}
+		return receipt, nil
+	}
+	secondaryData := cc.fetchSecondaries(ctx, func(ctx context.Context, client fortaethereum.Client) (*providerData, error) {
+		receipt, err := client.TransactionReceipt(ctx, txHash)
+		if err != nil {
+			return nil, err
+		}
+		return &providerData{value: receipt, digest: receiptDigest(receipt)}, nil
+	})
+	receipt, err := cc.Client.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	chosen, diverged := chooseMajority(&providerData{value: receipt, digest: receiptDigest(receipt)}, <-secondaryData)
+	if diverged {
+		chosenReceipt := chosen.value.(*domain.TransactionReceipt)
+		cc.markDiverged(deref(chosenReceipt.BlockHash), deref(chosenReceipt.BlockNumber))
+		log.WithField("txHash", txHash).Warn("receipt data diverged between the json-rpc providers")
+		return chosenReceipt, nil
+	}
+	return receipt, nil
+}
+
+// fetchSecondaries fetches the data from the secondary providers concurrently. The secondary errors are
+// counted and the failed providers do not vote.
+func (cc *CrossCheckedClient) fetchSecondaries(
+	ctx context.Context, fetch func(context.Context, fortaethereum.Client) (*providerData, error),
+) <-chan []*providerData {
+	resultCh := make(chan []*providerData, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, cc.timeout)
+		defer cancel()
+
+		var (
+			results []*providerData
+			mu      sync.Mutex
+			wg      sync.WaitGroup
+		)
+		for i, secondary := range cc.secondaries {
+			wg.Add(1)
+			go func(i int, secondary fortaethereum.Client) {
+				defer wg.Done()
+				data, err := fetch(ctx, secondary)
+				if err != nil {
+					atomic.AddUint64(&cc.secondaryErrCount, 1)
+					log.WithError(err).WithField("provider", i).Debug("failed to fetch from the secondary json-rpc provider")
+					return
+				}
+				data.client = secondary
+				mu.Lock()
+				results = append(results, data)
+				mu.Unlock()
+			}(i, secondary)
+		}
+		wg.Wait()
+		resultCh <- results
+	}()
+	return resultCh
+}
+
+// chooseMajority returns the data with the most votes and tells if any of the providers disagreed.
+// The primary data wins the ties.
+func chooseMajority(primary *providerData, secondaries []*providerData) (*providerData, bool) {
+	votes := map[string]int{primary.digest: 1}
+	var diverged bool
+	for _, data := range secondaries {
+		votes[data.digest]++
+		if data.digest != primary.digest {
+			diverged = true
+		}
+	}
+	chosen := primary
+	for _, data := range secondaries {
+		if votes[data.digest] > votes[chosen.digest] {
+			chosen = data
+		}
+	}
+	return chosen, diverged
+}
+
+func receiptDigest(receipt *domain.TransactionReceipt) string {
+	return strings.Join([]string{
+		deref(receipt.BlockHash), deref(receipt.Status), deref(receipt.GasUsed), deref(receipt.CumulativeGasUsed),
+		strconv.Itoa(len(receipt.Logs)),
+	}, "|")
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func (cc *CrossCheckedClient) markDiverged(blockHash, blockNumber string) {
+	atomic.AddUint64(&cc.divergenceCount, 1)
+	cc.lastDivergence.Set()
+	cc.lastDivergenceBlock.Set(fmt.Sprintf("%s (%s)", blockNumber, blockHash))
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.diverged[blockHash] {
+		return
+	}
+	cc.diverged[blockHash] = true
+	cc.divergedOrder = append(cc.divergedOrder, blockHash)
+	if len(cc.divergedOrder) > maxDivergedBlocks {
+		delete(cc.diverged, cc.divergedOrder[0])
+		cc.divergedOrder = cc.divergedOrder[1:]
+	}
+}
+
+// Diverged tells if the providers disagreed on the data of the block. It is safe to call
+// on a nil client.
+func (cc *CrossCheckedClient) Diverged(blockHash string) bool {
+	if cc == nil {
+		return false
+	}
+	cc.mu.RLock()
+	defer cc.mu.RUnlock()
+	return cc.diverged[blockHash]
+}
+
+// Health implements health.Reporter interface.
+func (cc *CrossCheckedClient) Health() health.Reports {
+	return append(cc.Client.Health(),
+		&health.Report{
+			Name:    "data-integrity.divergences",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&cc.divergenceCount), 10),
+		},
+		&health.Report{
+			Name:    "data-integrity.secondary-errors",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&cc.secondaryErrCount), 10),
+		},
+		cc.lastDivergence.GetReport("data-integrity.time"),
+		cc.lastDivergenceBlock.GetReport("data-integrity.block"),
+	)
+}
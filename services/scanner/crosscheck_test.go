@@ -0,0 +1,152 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/forta-network/forta-core-go/domain"
+	fortaethereum "github.com/forta-network/forta-core-go/ethereum"
+	mock_ethereum "github.com/forta-network/forta-core-go/ethereum/mocks"
+	"github.com/forta-network/forta-core-go/utils"
+	"github.com/forta-network/forta-node/config"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrossCheckedClient_BlockByNumber(t *testing.T) {
+	r := require.New(t)
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	primary := mock_ethereum.NewMockClient(ctrl)
+	secondary1 := mock_ethereum.NewMockClient(ctrl)
+	secondary2 := mock_ethereum.NewMockClient(ctrl)
+
+	cc := NewCrossCheckedClient(primary, []fortaethereum.Client{secondary1, secondary2}, config.CrossValidationConfig{TimeoutSeconds: 1})
+
+	// all providers agree
+	block1 := &domain.Block{Number: "0x1", Hash: "0xaaa"}
+	primary.EXPECT().BlockByNumber(gomock.Any(), big.NewInt(1)).Return(block1, nil)
+	secondary1.EXPECT().BlockByNumber(gomock.Any(), big.NewInt(1)).Return(&domain.Block{Number: "0x1", Hash: "0xaaa"}, nil)
+	secondary2.EXPECT().BlockByNumber(gomock.Any(), big.NewInt(1)).Return(&domain.Block{Number: "0x1", Hash: "0xaaa"}, nil)
+	block, err := cc.BlockByNumber(ctx, big.NewInt(1))
+	r.NoError(err)
+	r.Equal(block1, block)
+	r.False(cc.Diverged("0xaaa"))
+
+	// the majority is preferred over the primary
+	majorityBlock := &domain.Block{Number: "0x2", Hash: "0xccc"}
+	primary.EXPECT().BlockByNumber(gomock.Any(), big.NewInt(2)).Return(&domain.Block{Number: "0x2", Hash: "0xbbb"}, nil)
+	secondary1.EXPECT().BlockByNumber(gomock.Any(), big.NewInt(2)).Return(majorityBlock, nil)
+	secondary2.EXPECT().BlockByNumber(gomock.Any(), big.NewInt(2)).Return(&domain.Block{Number: "0x2", Hash: "0xccc"}, nil)
+	block, err = cc.BlockByNumber(ctx, big.NewInt(2))
+	r.NoError(err)
+	r.Equal("0xccc", block.Hash)
+	r.True(cc.Diverged("0xccc"))
+
+	// the primary wins the ties and the failing providers do not vote
+	block3 := &domain.Block{Number: "0x3", Hash: "0xddd"}
+	primary.EXPECT().BlockByNumber(gomock.Any(), big.NewInt(3)).Return(block3, nil)
+	secondary1.EXPECT().BlockByNumber(gomock.Any(), big.NewInt(3)).Return(&domain.Block{Number: "0x3", Hash: "0xeee"}, nil)
+	secondary2.EXPECT().BlockByNumber(gomock.Any(), big.NewInt(3)).Return(nil, errors.New("failed"))
+	block, err = cc.BlockByNumber(ctx, big.NewInt(3))
+	r.NoError(err)
+	r.Equal(block3, block)
+	r.True(cc.Diverged("0xddd"))
+	r.Equal(uint64(2), cc.divergenceCount)
+	r.Equal(uint64(1), cc.secondaryErrCount)
+
+	// the latest block is not cross-checked
+	primary.EXPECT().BlockByNumber(gomock.Any(), nil).Return(block3, nil)
+	block, err = cc.BlockByNumber(ctx, nil)
+	r.NoError(err)
+	r.Equal(block3, block)
+
+	// the nil client never reports divergence
+	var nilClient *CrossCheckedClient
+	r.False(nilClient.Diverged("0xddd"))
+}
+
+func TestCrossCheckedClient_TransactionReceipt(t *testing.T) {
+	r := require.New(t)
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	primary := mock_ethereum.NewMockClient(ctrl)
+	secondary := mock_ethereum.NewMockClient(ctrl)
+
+	// the receipts are not compared by default
+	cc := NewCrossCheckedClient(primary, []fortaethereum.Client{secondary}, config.CrossValidationConfig{TimeoutSeconds: 1})
+	receipt := &domain.TransactionReceipt{BlockHash: utils.StringPtr("0xaaa"), Status: utils.StringPtr("0x1")}
+	primary.EXPECT().TransactionReceipt(gomock.Any(), "0x1").Return(receipt, nil)
+	result, err := cc.TransactionReceipt(ctx, "0x1")
+	r.NoError(err)
+	r.Equal(receipt, result)
+
+	cc = NewCrossCheckedClient(primary, []fortaethereum.Client{secondary}, config.CrossValidationConfig{
+		CompareReceipts: true, TimeoutSeconds: 1,
+	})
+	primary.EXPECT().TransactionReceipt(gomock.Any(), "0x1").Return(receipt, nil)
+	secondary.EXPECT().TransactionReceipt(gomock.Any(), "0x1").Return(&domain.TransactionReceipt{
+		BlockHash: utils.StringPtr("0xaaa"), Status: utils.StringPtr("0x0"),
+	}, nil)
+	result, err = cc.TransactionReceipt(ctx, "0x1")
+	r.NoError(err)
+	r.Equal(receipt, result)
+	r.True(cc.Diverged("0xaaa"))
+}
+
+func TestCrossCheckedClient_SelectedProvider(t *testing.T) {
+	r := require.New(t)
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	primary := mock_ethereum.NewMockClient(ctrl)
+	secondary1 := mock_ethereum.NewMockClient(ctrl)
+	secondary2 := mock_ethereum.NewMockClient(ctrl)
+
+	cc := NewCrossCheckedClient(primary, []fortaethereum.Client{secondary1, secondary2}, config.CrossValidationConfig{TimeoutSeconds: 1})
+
+	// the secondaries win the block
+	primary.EXPECT().BlockByNumber(gomock.Any(), big.NewInt(2)).Return(&domain.Block{Number: "0x2", Hash: "0xbbb"}, nil)
+	secondary1.EXPECT().BlockByNumber(gomock.Any(), big.NewInt(2)).Return(&domain.Block{Number: "0x2", Hash: "0xccc"}, nil)
+	secondary2.EXPECT().BlockByNumber(gomock.Any(), big.NewInt(2)).Return(&domain.Block{Number: "0x2", Hash: "0xccc"}, nil)
+	block, err := cc.BlockByNumber(ctx, big.NewInt(2))
+	r.NoError(err)
+	r.Equal("0xccc", block.Hash)
+	selected, ok := cc.selectedClient(2)
+	r.True(ok)
+
+	// the logs and the receipts of the block are fetched from the winner
+	q := ethereum.FilterQuery{FromBlock: big.NewInt(2), ToBlock: big.NewInt(2)}
+	logs := []types.Log{{BlockHash: common.HexToHash("0xccc")}}
+	selected.(*mock_ethereum.MockClient).EXPECT().GetLogs(gomock.Any(), q).Return(logs, nil)
+	result, err := cc.GetLogs(ctx, q)
+	r.NoError(err)
+	r.Equal(logs, result)
+
+	receipt := &domain.TransactionReceipt{BlockNumber: utils.StringPtr("0x2"), BlockHash: utils.StringPtr("0xccc")}
+	primary.EXPECT().TransactionReceipt(gomock.Any(), "0x1").Return(&domain.TransactionReceipt{
+		BlockNumber: utils.StringPtr("0x2"), BlockHash: utils.StringPtr("0xbbb"),
+	}, nil)
+	selected.(*mock_ethereum.MockClient).EXPECT().TransactionReceipt(gomock.Any(), "0x1").Return(receipt, nil)
+	receiptResult, err := cc.TransactionReceipt(ctx, "0x1")
+	r.NoError(err)
+	r.Equal(receipt, receiptResult)
+
+	// the block fails instead of mixing the data of the providers
+	selected.(*mock_ethereum.MockClient).EXPECT().GetLogs(gomock.Any(), q).Return(nil, errors.New("failed"))
+	_, err = cc.GetLogs(ctx, q)
+	r.Error(err)
+
+	// the other blocks are fetched from the primary
+	otherQ := ethereum.FilterQuery{FromBlock: big.NewInt(3), ToBlock: big.NewInt(3)}
+	primary.EXPECT().GetLogs(gomock.Any(), otherQ).Return(nil, nil)
+	_, err = cc.GetLogs(ctx, otherQ)
+	r.NoError(err)
+}
@@ -0,0 +1,84 @@
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/forta-network/forta-core-go/ethereum"
+	"github.com/forta-network/forta-core-go/feeds"
+	"github.com/forta-network/forta-node/config"
+)
+
+// ChainDataSource provides the blocks and the transactions which the scanner sends to the bots.
+// The data sources for the chains which are not served by the standard EVM JSON-RPC API, like
+// the sequencer feeds of the L2 chains, implement this and register themselves as plugins.
+type ChainDataSource interface {
+	// BlockFeed is the feed which drives the scanning. The scanner starts it after the rest of
+	// the services are ready.
+	BlockFeed() feeds.BlockFeed
+	// TransactionFeed emits each block and then its transactions in the index order.
+	TransactionFeed() feeds.TransactionFeed
+}
+
+// Params contains what the data sources need for creating the feeds.
+type Params struct {
+	Config      config.Config
+	ChainID     *big.Int
+	EthClient   ethereum.Client
+	TraceClient ethereum.Client
+	// the block range, the offset and the rate limit of the feed
+	Feed feeds.BlockFeedConfig
+}
+
+// Factory creates a data source.
+type Factory func(ctx context.Context, params Params) (ChainDataSource, error)
+
+var (
+	factories   = make(map[string]Factory)
+	factoriesMu sync.RWMutex
+)
+
+// Register makes a data source available by the name. The plugins call this from init() and
+// they are compiled in by importing them in the scanner command. It panics if the name is
+// registered twice.
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory == nil {
+		panic("datasource: nil factory for " + name)
+	}
+	if _, ok := factories[name]; ok {
+		panic("datasource: registered twice: " + name)
+	}
+	factories[name] = factory
+}
+
+// Names returns the names of the registered data sources.
+func Names() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	var names []string
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates the data source which was registered by the name.
+func New(ctx context.Context, name string, params Params) (ChainDataSource, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[name]
+	factoriesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown chain data source '%s' (available: %s)", name, strings.Join(Names(), ", "))
+	}
+	return factory(ctx, params)
+}
@@ -0,0 +1,43 @@
+package datasource
+
+import (
+	"context"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/feeds"
+	"github.com/stretchr/testify/require"
+)
+
+type testDataSource struct {
+	params Params
+}
+
+func (ds *testDataSource) BlockFeed() feeds.BlockFeed {
+	return nil
+}
+
+func (ds *testDataSource) TransactionFeed() feeds.TransactionFeed {
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	r := require.New(t)
+
+	Register("test-chain", func(ctx context.Context, params Params) (ChainDataSource, error) {
+		return &testDataSource{params: params}, nil
+	})
+	r.Contains(Names(), "test-chain")
+	r.Panics(func() {
+		Register("test-chain", func(ctx context.Context, params Params) (ChainDataSource, error) {
+			return nil, nil
+		})
+	})
+
+	ds, err := New(context.Background(), "test-chain", Params{Feed: feeds.BlockFeedConfig{Offset: 3}})
+	r.NoError(err)
+	r.Equal(3, ds.(*testDataSource).params.Feed.Offset)
+
+	_, err = New(context.Background(), "unknown", Params{})
+	r.Error(err)
+	r.Contains(err.Error(), "test-chain")
+}
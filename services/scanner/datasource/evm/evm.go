@@ -0,0 +1,50 @@
+package evm
+
+import (
+	"context"
+
+	"github.com/forta-network/forta-core-go/feeds"
+	"github.com/forta-network/forta-node/services/scanner/datasource"
+)
+
+// Name is the name of the default data source which reads the chain from the EVM JSON-RPC API.
+const Name = "evm"
+
+// txFeedWorkers is the amount of workers which handle the transactions of the feed. It must be 1
+// so the transactions of a block are always emitted in the transaction index order.
+const txFeedWorkers = 1
+
+func init() {
+	datasource.Register(Name, New)
+}
+
+type dataSource struct {
+	blockFeed feeds.BlockFeed
+	txFeed    feeds.TransactionFeed
+}
+
+// New creates the block and the transaction feeds which poll the JSON-RPC API.
+func New(ctx context.Context, params datasource.Params) (datasource.ChainDataSource, error) {
+	feedCfg := params.Feed
+	feedCfg.ChainID = params.ChainID
+	blockFeed, err := feeds.NewBlockFeed(ctx, params.EthClient, params.TraceClient, feedCfg)
+	if err != nil {
+		return nil, err
+	}
+	txFeed, err := feeds.NewTransactionFeed(ctx, params.EthClient, blockFeed, feedCfg.SkipBlocksOlderThan, txFeedWorkers)
+	if err != nil {
+		return nil, err
+	}
+	return &dataSource{
+		blockFeed: blockFeed,
+		txFeed:    txFeed,
+	}, nil
+}
+
+func (ds *dataSource) BlockFeed() feeds.BlockFeed {
+	return ds.blockFeed
+}
+
+func (ds *dataSource) TransactionFeed() feeds.TransactionFeed {
+	return ds.txFeed
+}
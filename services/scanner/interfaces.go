@@ -15,6 +15,10 @@ type TxResult struct {
 	Request     *protocol.EvaluateTxRequest
 	Response    *protocol.EvaluateTxResponse
 	Timestamps  *domain.TrackingTimestamps
+	// the result arrived after the result deadline of the block
+	Late bool
+	// the transaction was evaluated from the mempool before it was mined
+	Pending bool
 }
 
 // BlockResult contains request and response data.
@@ -37,6 +41,7 @@ type CombinationAlertResult struct {
 // to and receive the results from.
 type AgentPool interface {
 	SendEvaluateTxRequest(req *protocol.EvaluateTxRequest)
+	SendEvaluatePendingTxRequest(req *protocol.EvaluateTxRequest, latestBlockNumber string)
 	TxResults() <-chan *TxResult
 	SendEvaluateBlockRequest(req *protocol.EvaluateBlockRequest)
 	BlockResults() <-chan *BlockResult
@@ -0,0 +1,148 @@
+package scanner
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients"
+	log "github.com/sirupsen/logrus"
+)
+
+// LocalAlertSender delivers the alerts of the bots on this node to the local subscriber bots immediately,
+// in addition to sending them to the publisher, so that they do not need to round-trip through the alert API.
+type LocalAlertSender struct {
+	clients.AlertSender
+	stream *CombinerAlertStreamService
+}
+
+// NewLocalAlertSender creates a new local alert sender.
+func NewLocalAlertSender(alertSender clients.AlertSender, stream *CombinerAlertStreamService) *LocalAlertSender {
+	return &LocalAlertSender{AlertSender: alertSender, stream: stream}
+}
+
+// SignAlertAndNotify implements clients.AlertSender interface.
+func (las *LocalAlertSender) SignAlertAndNotify(
+	rt *clients.AgentRoundTrip, alert *protocol.Alert, chainID, blockNumber string, ts *domain.TrackingTimestamps,
+) error {
+	if err := las.AlertSender.SignAlertAndNotify(rt, alert, chainID, blockNumber, ts); err != nil {
+		return err
+	}
+	// the private alerts never show up in the alert API
+	if alert.Type == protocol.AlertType_PRIVATE {
+		return nil
+	}
+	las.stream.HandleLocalAlert(toLocalAlertEvent(rt, alert))
+	return nil
+}
+
+// toLocalAlertEvent converts the alert of a local bot to the form the alert API serves.
+func toLocalAlertEvent(rt *clients.AgentRoundTrip, alert *protocol.Alert) *protocol.AlertEvent {
+	finding := alert.Finding
+	chainID := uint64(rt.AgentConfig.ChainID)
+	evtAlert := &protocol.AlertEvent_Alert{
+		AlertId:            finding.AlertId,
+		Addresses:          finding.Addresses,
+		CreatedAt:          alert.Timestamp,
+		Description:        finding.Description,
+		Hash:               alert.Id,
+		Metadata:           finding.Metadata,
+		Name:               finding.Name,
+		Severity:           finding.Severity.String(),
+		FindingType:        finding.Type.String(),
+		RelatedAlerts:      finding.RelatedAlerts,
+		ChainId:            chainID,
+		Truncated:          alert.Truncated,
+		AddressBloomFilter: alert.AddressBloomFilter,
+		Source: &protocol.AlertEvent_Alert_Source{
+			Bot: &protocol.AlertEvent_Alert_Bot{
+				Id:    alert.Agent.Id,
+				Image: alert.Agent.Image,
+			},
+		},
+	}
+	for _, label := range finding.Labels {
+		evtAlert.Labels = append(evtAlert.Labels, &protocol.AlertEvent_Alert_Label{
+			Label:      label.Label,
+			Confidence: label.Confidence,
+			Entity:     label.Entity,
+			EntityType: label.EntityType.String(),
+			Remove:     label.Remove,
+			Metadata:   label.Metadata,
+		})
+	}
+
+	switch {
+	case rt.EvalBlockRequest != nil:
+		event := rt.EvalBlockRequest.Event
+		evtAlert.Source.Block = toLocalAlertBlock(chainID, event.BlockHash, event.BlockNumber, event.Block.Timestamp)
+	case rt.EvalTxRequest != nil:
+		event := rt.EvalTxRequest.Event
+		evtAlert.Source.TransactionHash = event.Transaction.Hash
+		// the pending transactions are not in a block yet
+		if IsPendingTxEvent(event) {
+			evtAlert.Source.Block = &protocol.AlertEvent_Alert_Block{ChainId: chainID}
+			break
+		}
+		evtAlert.Source.Block = toLocalAlertBlock(chainID, event.Block.BlockHash, event.Block.BlockNumber, event.Block.BlockTimestamp)
+	case rt.EvalAlertRequest != nil:
+		sourceAlert := rt.EvalAlertRequest.Event.Alert
+		evtAlert.Source.Block = sourceAlert.Source.Block
+		evtAlert.Source.SourceEvent = &protocol.AlertEvent_Alert_SourceAlertEvent{
+			BotId:     sourceAlert.Source.Bot.Id,
+			AlertHash: sourceAlert.Hash,
+			Timestamp: sourceAlert.CreatedAt,
+		}
+	}
+
+	return &protocol.AlertEvent{Alert: evtAlert}
+}
+
+func toLocalAlertBlock(chainID uint64, blockHash, blockNumber, blockTimestamp string) *protocol.AlertEvent_Alert_Block {
+	block := &protocol.AlertEvent_Alert_Block{
+		Hash:    blockHash,
+		ChainId: chainID,
+	}
+	number, err := hexutil.DecodeUint64(blockNumber)
+	if err != nil {
+		log.WithError(err).WithField("blockNumber", blockNumber).Warn("failed to decode the block number of the local alert")
+	}
+	block.Number = number
+	if ts, err := hexutil.DecodeUint64(blockTimestamp); err == nil {
+		block.Timestamp = time.Unix(int64(ts), 0).UTC().Format(time.RFC3339)
+	}
+	return block
+}
+
+// isSubscribedToAlert checks the alert against the subscriptions the same way the agents do.
+func isSubscribedToAlert(subscriptions []*protocol.CombinerBotSubscription, alert *protocol.AlertEvent_Alert) bool {
+	for _, subscription := range subscriptions {
+		if subscription == nil {
+			continue
+		}
+		if subscription.BotId != "" && subscription.BotId != alert.Source.Bot.Id {
+			continue
+		}
+		if subscription.ChainId != 0 && subscription.ChainId != alert.ChainId {
+			continue
+		}
+		if subscription.AlertId != "" && subscription.AlertId != alert.AlertId {
+			continue
+		}
+		if len(subscription.AlertIds) > 0 && !containsString(subscription.AlertIds, alert.AlertId) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
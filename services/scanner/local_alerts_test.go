@@ -0,0 +1,101 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/domain"
+	mock_feeds "github.com/forta-network/forta-core-go/feeds/mocks"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/config"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testLocalBotID   = "0xbot"
+	testLocalAlertID = "ALERT-1"
+)
+
+func testLocalRoundTrip() (*clients.AgentRoundTrip, *protocol.Alert) {
+	rt := &clients.AgentRoundTrip{
+		AgentConfig: config.AgentConfig{ID: testLocalBotID, ChainID: 1},
+		EvalBlockRequest: &protocol.EvaluateBlockRequest{
+			Event: &protocol.BlockEvent{
+				BlockHash:   "0xabcd",
+				BlockNumber: "0x10",
+				Block:       &protocol.BlockEvent_EthBlock{Timestamp: "0x1"},
+			},
+		},
+	}
+	alert := &protocol.Alert{
+		Id:    "0xalerthash",
+		Agent: &protocol.AgentInfo{Id: testLocalBotID, Image: "image"},
+		Finding: &protocol.Finding{
+			AlertId: testLocalAlertID,
+			Labels:  []*protocol.Label{{Label: "label", Entity: "0x1"}},
+		},
+	}
+	return rt, alert
+}
+
+func TestToLocalAlertEvent(t *testing.T) {
+	r := require.New(t)
+
+	rt, alert := testLocalRoundTrip()
+	evt := toLocalAlertEvent(rt, alert)
+
+	r.Equal("0xalerthash", evt.Alert.Hash)
+	r.Equal(testLocalAlertID, evt.Alert.AlertId)
+	r.Equal(uint64(1), evt.Alert.ChainId)
+	r.Equal(testLocalBotID, evt.Alert.Source.Bot.Id)
+	r.Equal("0xabcd", evt.Alert.Source.Block.Hash)
+	r.Equal(uint64(16), evt.Alert.Source.Block.Number)
+	r.Equal("1970-01-01T00:00:01Z", evt.Alert.Source.Block.Timestamp)
+	r.Len(evt.Alert.Labels, 1)
+}
+
+func TestIsSubscribedToAlert(t *testing.T) {
+	r := require.New(t)
+
+	rt, alert := testLocalRoundTrip()
+	evtAlert := toLocalAlertEvent(rt, alert).Alert
+
+	r.True(isSubscribedToAlert([]*protocol.CombinerBotSubscription{{BotId: testLocalBotID}}, evtAlert))
+	r.True(isSubscribedToAlert([]*protocol.CombinerBotSubscription{{BotId: testLocalBotID, AlertIds: []string{testLocalAlertID}}}, evtAlert))
+	r.False(isSubscribedToAlert([]*protocol.CombinerBotSubscription{{BotId: "0xother"}}, evtAlert))
+	r.False(isSubscribedToAlert([]*protocol.CombinerBotSubscription{{BotId: testLocalBotID, ChainId: 137}}, evtAlert))
+	r.False(isSubscribedToAlert([]*protocol.CombinerBotSubscription{{BotId: testLocalBotID, AlertId: "ALERT-2"}}, evtAlert))
+	r.False(isSubscribedToAlert(nil, evtAlert))
+}
+
+func TestHandleLocalAlert(t *testing.T) {
+	r := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	alertFeed := mock_feeds.NewMockAlertFeed(ctrl)
+	alertFeed.EXPECT().Subscriptions().Return([]*protocol.CombinerBotSubscription{{BotId: testLocalBotID}}).AnyTimes()
+
+	alertOutput := make(chan *domain.AlertEvent, 2)
+	stream, err := NewCombinerAlertStreamService(context.Background(), alertFeed, nil, CombinerAlertStreamServiceConfig{})
+	r.NoError(err)
+	stream.alertOutput = alertOutput
+
+	rt, alert := testLocalRoundTrip()
+	evt := toLocalAlertEvent(rt, alert)
+
+	// delivered only once even if the bot alert is handled twice
+	stream.HandleLocalAlert(evt)
+	stream.HandleLocalAlert(evt)
+	r.Len(stream.localAlertCh, 1)
+
+	// the public copy is skipped
+	r.NoError(stream.handleAlert(&domain.AlertEvent{Event: toLocalAlertEvent(rt, alert)}))
+	r.Len(alertOutput, 0)
+
+	reports := stream.Health()
+	deduplicated, ok := reports.GetByName("local.deduplicated")
+	r.True(ok)
+	r.Equal("1", deduplicated.Details)
+}
@@ -0,0 +1,302 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-core-go/feeds"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// pendingTxRetention is how long the seen and the mined transaction hashes are remembered
+// for deduplication.
+const pendingTxRetention = time.Minute * 10
+
+// pendingTxClient is the JSON-RPC client used for reading the pending transactions.
+type pendingTxClient interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+	EthSubscribe(ctx context.Context, channel interface{}, args ...interface{}) (*rpc.ClientSubscription, error)
+}
+
+// PendingTxStreamService reads the pending transactions from the mempool and emits the ones
+// which were not seen or mined before.
+type PendingTxStreamService struct {
+	cfg    PendingTxStreamServiceConfig
+	ctx    context.Context
+	client pendingTxClient
+	output chan *domain.TransactionEvent
+
+	latestBlock *domain.BlockEvent
+	seen        map[string]time.Time
+	mined       map[string]time.Time
+	mu          sync.Mutex
+
+	lastPendingTxActivity health.TimeTracker
+	lastErr               health.ErrorTracker
+
+	pause services.PauseState
+}
+
+// PendingTxStreamServiceConfig contains the pending tx stream config.
+type PendingTxStreamServiceConfig struct {
+	JsonRpcConfig config.JsonRpcConfig
+	MempoolConfig config.MempoolConfig
+}
+
+// ReadOnlyPendingTxStream returns the receive-only pending tx channel.
+func (t *PendingTxStreamService) ReadOnlyPendingTxStream() <-chan *domain.TransactionEvent {
+	return t.output
+}
+
+// handleBlock keeps the latest block for the pending tx events and remembers the mined
+// transactions so they are never sent as pending.
+func (t *PendingTxStreamService) handleBlock(evt *domain.BlockEvent) error {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	block := *evt.Block
+	block.Transactions = nil
+	t.latestBlock = &domain.BlockEvent{
+		EventType: evt.EventType,
+		ChainID:   evt.ChainID,
+		Block:     &block,
+	}
+	for _, tx := range evt.Block.Transactions {
+		t.mined[strings.ToLower(tx.Hash)] = now
+	}
+	for hash, ts := range t.seen {
+		if now.Sub(ts) > pendingTxRetention {
+			delete(t.seen, hash)
+		}
+	}
+	for hash, ts := range t.mined {
+		if now.Sub(ts) > pendingTxRetention {
+			delete(t.mined, hash)
+		}
+	}
+	return nil
+}
+
+// markSeen marks the hash as seen and returns the latest block if the pending tx should be processed.
+// The hash is forgotten again if the transaction cannot be fetched, so that it is retried when it is
+// announced again.
+func (t *PendingTxStreamService) markSeen(hash string) (*domain.BlockEvent, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.latestBlock == nil {
+		return nil, false
+	}
+	if _, ok := t.mined[hash]; ok {
+		return nil, false
+	}
+	if _, ok := t.seen[hash]; ok {
+		return nil, false
+	}
+	t.seen[hash] = time.Now()
+	return t.latestBlock, true
+}
+
+func (t *PendingTxStreamService) forgetSeen(hash string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.seen, hash)
+}
+
+func (t *PendingTxStreamService) markMined(hash string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.mined[hash] = time.Now()
+}
+
+func (t *PendingTxStreamService) handlePendingTx(hash string) {
+	if t.pause.IsPaused() {
+		return
+	}
+	hash = strings.ToLower(hash)
+	latestBlock, ok := t.markSeen(hash)
+	if !ok {
+		return
+	}
+
+	var tx *domain.Transaction
+	if err := t.client.CallContext(t.ctx, &tx, "eth_getTransactionByHash", hash); err != nil {
+		log.WithError(err).WithField("tx", hash).Debug("failed to get the pending transaction")
+		t.forgetSeen(hash)
+		return
+	}
+	// dropped from the mempool, already mined or not propagated to the node yet
+	if tx == nil {
+		t.forgetSeen(hash)
+		return
+	}
+	if len(tx.BlockNumber) > 0 {
+		t.markMined(hash)
+		return
+	}
+
+	select {
+	case <-t.ctx.Done():
+	case t.output <- &domain.TransactionEvent{
+		BlockEvt:    latestBlock,
+		Transaction: tx,
+		Timestamps:  &domain.TrackingTimestamps{Block: blockTime(latestBlock), Feed: time.Now().UTC()},
+	}:
+		t.lastPendingTxActivity.Set()
+	}
+}
+
+func (t *PendingTxStreamService) pollPendingTxs() error {
+	ticker := time.NewTicker(time.Duration(t.cfg.MempoolConfig.PollIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	var filterID string
+	for {
+		select {
+		case <-t.ctx.Done():
+			return t.ctx.Err()
+		case <-ticker.C:
+		}
+
+		if len(filterID) == 0 {
+			if err := t.client.CallContext(t.ctx, &filterID, "eth_newPendingTransactionFilter"); err != nil {
+				t.lastErr.Set(fmt.Errorf("failed to create the pending tx filter: %v", err))
+				continue
+			}
+		}
+		var hashes []string
+		if err := t.client.CallContext(t.ctx, &hashes, "eth_getFilterChanges", filterID); err != nil {
+			// the filter expires if it is not polled for a while or the node restarts
+			t.lastErr.Set(fmt.Errorf("failed to get the pending tx filter changes: %v", err))
+			filterID = ""
+			continue
+		}
+		t.lastErr.Set(nil)
+		for _, hash := range hashes {
+			t.handlePendingTx(hash)
+		}
+	}
+}
+
+func (t *PendingTxStreamService) subscribePendingTxs() error {
+	for {
+		hashCh := make(chan string)
+		sub, err := t.client.EthSubscribe(t.ctx, hashCh, "newPendingTransactions")
+		if err == nil {
+			t.lastErr.Set(nil)
+			err = t.handleSubscription(sub, hashCh)
+		}
+		if t.ctx.Err() != nil {
+			return t.ctx.Err()
+		}
+		t.lastErr.Set(fmt.Errorf("pending tx subscription failed: %v", err))
+		select {
+		case <-t.ctx.Done():
+			return t.ctx.Err()
+		case <-time.After(time.Duration(t.cfg.MempoolConfig.PollIntervalSeconds) * time.Second):
+		}
+	}
+}
+
+func (t *PendingTxStreamService) handleSubscription(sub *rpc.ClientSubscription, hashCh chan string) error {
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case <-t.ctx.Done():
+			return t.ctx.Err()
+		case err := <-sub.Err():
+			return err
+		case hash := <-hashCh:
+			t.handlePendingTx(hash)
+		}
+	}
+}
+
+func (t *PendingTxStreamService) Start() error {
+	go func() {
+		var err error
+		if t.cfg.MempoolConfig.Subscribe {
+			err = t.subscribePendingTxs()
+		} else {
+			err = t.pollPendingTxs()
+		}
+		log.WithError(err).Info("pending tx stream stopped")
+	}()
+	return nil
+}
+
+func (t *PendingTxStreamService) Stop() error {
+	return nil
+}
+
+// SetPaused implements services.Pauser. The pending transactions are skipped while paused.
+func (t *PendingTxStreamService) SetPaused(paused bool) {
+	t.pause.SetPaused(paused)
+}
+
+func (t *PendingTxStreamService) Name() string {
+	return "pending-tx-stream"
+}
+
+// Health implements health.Reporter interface.
+func (t *PendingTxStreamService) Health() health.Reports {
+	return health.Reports{
+		t.lastPendingTxActivity.GetReport("event.pending-transaction.time"),
+		t.lastErr.GetReport("event.pending-transaction.error"),
+		t.pause.GetReport("paused"),
+	}
+}
+
+// NewPendingTxStreamService creates a new pending tx stream which follows the block feed
+// to deduplicate the mined transactions.
+func NewPendingTxStreamService(ctx context.Context, blockFeed feeds.BlockFeed, cfg PendingTxStreamServiceConfig) (*PendingTxStreamService, error) {
+	if cfg.MempoolConfig.Subscribe && !strings.HasPrefix(cfg.JsonRpcConfig.Url, "ws") {
+		return nil, fmt.Errorf("pending tx subscription requires a websocket url")
+	}
+	client, err := rpc.DialContext(ctx, cfg.JsonRpcConfig.Url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial the json-rpc api: %v", err)
+	}
+	t := newPendingTxStreamService(ctx, client, cfg)
+	blockFeed.Subscribe(t.handleBlock)
+	return t, nil
+}
+
+func newPendingTxStreamService(ctx context.Context, client pendingTxClient, cfg PendingTxStreamServiceConfig) *PendingTxStreamService {
+	return &PendingTxStreamService{
+		cfg:    cfg,
+		ctx:    ctx,
+		client: client,
+		output: make(chan *domain.TransactionEvent),
+		seen:   make(map[string]time.Time),
+		mined:  make(map[string]time.Time),
+	}
+}
+
+// IsPendingTxEvent tells if the transaction event is from the mempool. The pending transactions
+// are sent to the bots without a block.
+func IsPendingTxEvent(event *protocol.TransactionEvent) bool {
+	return len(event.GetBlock().GetBlockNumber()) == 0
+}
+
+// blockTime returns the chain timestamp of the block which the pending tx is sent with. The timestamp
+// is zero if it cannot be parsed.
+func blockTime(evt *domain.BlockEvent) time.Time {
+	t, err := evt.Block.GetTimestamp()
+	if err != nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
@@ -0,0 +1,125 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+type testPendingTxClient struct {
+	txs  map[string]*domain.Transaction
+	errs map[string]error
+}
+
+func (c *testPendingTxClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if err := c.errs[args[0].(string)]; err != nil {
+		return err
+	}
+	b, _ := json.Marshal(c.txs[args[0].(string)])
+	return json.Unmarshal(b, result)
+}
+
+func (c *testPendingTxClient) EthSubscribe(ctx context.Context, channel interface{}, args ...interface{}) (*rpc.ClientSubscription, error) {
+	return nil, rpc.ErrNotificationsUnsupported
+}
+
+func TestPendingTxStream_Dedup(t *testing.T) {
+	r := require.New(t)
+
+	client := &testPendingTxClient{
+		txs: map[string]*domain.Transaction{
+			"0x1": {Hash: "0x1"},
+			"0x2": {Hash: "0x2"},
+			"0x3": {Hash: "0x3", BlockNumber: "0x5"},
+		},
+	}
+	stream := newPendingTxStreamService(context.Background(), client, PendingTxStreamServiceConfig{
+		MempoolConfig: config.MempoolConfig{Enable: true, PollIntervalSeconds: 1},
+	})
+	stream.output = make(chan *domain.TransactionEvent, 10)
+
+	// no pending txs before the first block
+	stream.handlePendingTx("0x1")
+	r.Len(stream.output, 0)
+
+	r.NoError(stream.handleBlock(&domain.BlockEvent{
+		Block: &domain.Block{
+			Number:       "0x4",
+			Transactions: []domain.Transaction{{Hash: "0x2"}},
+		},
+	}))
+
+	stream.handlePendingTx("0x1")
+	stream.handlePendingTx("0x1") // seen
+	stream.handlePendingTx("0x2") // mined in the latest block
+	stream.handlePendingTx("0x3") // mined after it was seen as pending
+	stream.handlePendingTx("0x4") // not found
+	r.Len(stream.output, 1)
+
+	evt := <-stream.output
+	r.Equal("0x1", evt.Transaction.Hash)
+	r.Equal("0x4", evt.BlockEvt.Block.Number)
+	r.Empty(evt.BlockEvt.Block.Transactions)
+
+	msg, err := evt.ToMessage()
+	r.NoError(err)
+	r.Equal("0x4", msg.Block.BlockNumber)
+
+	_, ok := stream.mined["0x3"]
+	r.True(ok)
+}
+
+func TestPendingTxStream_Retry(t *testing.T) {
+	r := require.New(t)
+
+	client := &testPendingTxClient{
+		txs:  map[string]*domain.Transaction{"0x1": {Hash: "0x1"}},
+		errs: map[string]error{"0x1": errors.New("connection reset")},
+	}
+	stream := newPendingTxStreamService(context.Background(), client, PendingTxStreamServiceConfig{})
+	stream.output = make(chan *domain.TransactionEvent, 10)
+	r.NoError(stream.handleBlock(&domain.BlockEvent{Block: &domain.Block{Number: "0x4"}}))
+
+	// the failed fetch does not mark the transaction as seen
+	stream.handlePendingTx("0x1")
+	r.Len(stream.output, 0)
+	r.Empty(stream.seen)
+
+	delete(client.errs, "0x1")
+	stream.handlePendingTx("0x1")
+	r.Len(stream.output, 1)
+	r.Len(stream.seen, 1)
+}
+
+func TestPendingTxStream_Paused(t *testing.T) {
+	r := require.New(t)
+
+	client := &testPendingTxClient{txs: map[string]*domain.Transaction{"0x1": {Hash: "0x1"}}}
+	stream := newPendingTxStreamService(context.Background(), client, PendingTxStreamServiceConfig{})
+	stream.output = make(chan *domain.TransactionEvent, 10)
+	r.NoError(stream.handleBlock(&domain.BlockEvent{Block: &domain.Block{Number: "0x4"}}))
+
+	stream.SetPaused(true)
+	stream.handlePendingTx("0x1")
+	r.Len(stream.output, 0)
+}
+
+func TestPendingTxStream_Retention(t *testing.T) {
+	r := require.New(t)
+
+	stream := newPendingTxStreamService(context.Background(), &testPendingTxClient{}, PendingTxStreamServiceConfig{})
+	stream.seen["0x1"] = time.Now().Add(-pendingTxRetention * 2)
+	stream.mined["0x2"] = time.Now().Add(-pendingTxRetention * 2)
+	stream.seen["0x3"] = time.Now()
+
+	r.NoError(stream.handleBlock(&domain.BlockEvent{Block: &domain.Block{Number: "0x4"}}))
+	r.Len(stream.seen, 1)
+	r.Len(stream.mined, 0)
+}
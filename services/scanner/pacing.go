@@ -0,0 +1,247 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	fortaethereum "github.com/forta-network/forta-core-go/ethereum"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// errCodeLimitExceeded is the JSON-RPC error code most providers use when the request limit is exceeded.
+const errCodeLimitExceeded = -32005
+
+type jsonRpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// isRateLimitResponse tells if the provider rejected a request because of rate limiting.
+func isRateLimitResponse(statusCode int, rpcErr *jsonRpcError) bool {
+	if statusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if rpcErr == nil {
+		return false
+	}
+	if rpcErr.Code == errCodeLimitExceeded {
+		return true
+	}
+	msg := strings.ToLower(rpcErr.Message)
+	return strings.Contains(msg, "too many requests") || strings.Contains(msg, "rate limit")
+}
+
+// pacer increases the delay between the requests multiplicatively while the provider is rate limiting
+// and decays it back on successful requests.
+type pacer struct {
+	minDelay time.Duration
+	maxDelay time.Duration
+
+	delay time.Duration
+	mu    sync.Mutex
+
+	rateLimitCount uint64 // accessed atomically
+	lastRateLimit  health.TimeTracker
+}
+
+func newPacer(minDelay, maxDelay time.Duration) *pacer {
+	return &pacer{minDelay: minDelay, maxDelay: maxDelay}
+}
+
+// Delay returns the current delay.
+func (p *pacer) Delay() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.delay
+}
+
+// Wait blocks for the current delay with jitter so that the concurrent callers do not retry at once.
+func (p *pacer) Wait(ctx context.Context) error {
+	delay := p.Delay()
+	if delay == 0 {
+		return nil
+	}
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay)))
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+// Observe adjusts the delay by the result of a request.
+func (p *pacer) Observe(rateLimited bool) {
+	if rateLimited {
+		atomic.AddUint64(&p.rateLimitCount, 1)
+		p.lastRateLimit.Set()
+		p.mu.Lock()
+		p.delay *= 2
+		if p.delay < p.minDelay {
+			p.delay = p.minDelay
+		}
+		if p.delay > p.maxDelay {
+			p.delay = p.maxDelay
+		}
+		delay := p.delay
+		p.mu.Unlock()
+		log.WithField("delay", delay).Warn("rate limited by the json-rpc provider - slowing down")
+		return
+	}
+	p.mu.Lock()
+	p.delay /= 2
+	if p.delay < p.minDelay {
+		p.delay = 0
+	}
+	p.mu.Unlock()
+}
+
+// Health implements health.Reporter interface.
+func (p *pacer) Health() health.Reports {
+	delayReport := &health.Report{
+		Name:    "rate-limit.delay",
+		Status:  health.StatusOK,
+		Details: p.Delay().String(),
+	}
+	if p.Delay() > 0 {
+		delayReport.Status = health.StatusLagging
+	}
+	return health.Reports{
+		delayReport,
+		{
+			Name:    "rate-limit.count",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&p.rateLimitCount), 10),
+		},
+		p.lastRateLimit.GetReport("rate-limit.time"),
+	}
+}
+
+// pacedTransport paces the requests to the provider and detects the rate limiting in the responses.
+type pacedTransport struct {
+	base  http.RoundTripper
+	pacer *pacer
+}
+
+// RoundTrip implements http.RoundTripper interface.
+func (pt *pacedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := pt.pacer.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	resp, err := pt.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		pt.pacer.Observe(isRateLimitResponse(resp.StatusCode, nil))
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	pt.pacer.Observe(isRateLimitResponse(resp.StatusCode, responseError(body)))
+	return resp, nil
+}
+
+// responseError decodes the error of a JSON-RPC response, if there is any.
+func responseError(body []byte) *jsonRpcError {
+	if !bytes.Contains(body, []byte(`"error"`)) {
+		return nil
+	}
+	var resp struct {
+		Error *jsonRpcError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil
+	}
+	return resp.Error
+}
+
+// PacedClient is an Ethereum client which paces the requests when the provider is rate limiting.
+// The client retries the rate limited requests internally, so the requests are sent through a local
+// proxy which detects the rate limiting in the provider responses.
+type PacedClient struct {
+	fortaethereum.Client
+	pacer  *pacer
+	server *http.Server
+}
+
+// NewPacedClient creates a new paced client. A client without pacing is returned if the pacing
+// is disabled or the provider is used over websocket.
+func NewPacedClient(ctx context.Context, apiName, apiURL string, cfg config.RateLimitPacingConfig) (fortaethereum.Client, error) {
+	target, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid json-rpc url: %v", err)
+	}
+	if cfg.Disable || (target.Scheme != "http" && target.Scheme != "https") {
+		return fortaethereum.NewStreamEthClient(ctx, apiName, apiURL)
+	}
+
+	p := newPacer(
+		time.Duration(cfg.MinDelayMs)*time.Millisecond,
+		time.Duration(cfg.MaxDelaySeconds)*time.Second,
+	)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the paced requests: %v", err)
+	}
+	server := &http.Server{
+		Handler: &httputil.ReverseProxy{
+			Director: func(req *http.Request) {
+				req.URL.Scheme = target.Scheme
+				req.URL.Host = target.Host
+				req.URL.Path = target.Path
+				req.URL.RawPath = target.RawPath
+				req.URL.RawQuery = target.RawQuery
+				req.Host = target.Host
+				if target.User != nil {
+					password, _ := target.User.Password()
+					req.SetBasicAuth(target.User.Username(), password)
+				}
+			},
+			Transport: &pacedTransport{base: http.DefaultTransport, pacer: p},
+		},
+	}
+	go server.Serve(listener)
+
+	client, err := fortaethereum.NewStreamEthClient(ctx, apiName, fmt.Sprintf("http://%s", listener.Addr()))
+	if err != nil {
+		server.Close()
+		return nil, err
+	}
+	pc := &PacedClient{Client: client, pacer: p, server: server}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	return pc, nil
+}
+
+// Close implements ethereum.Client interface.
+func (pc *PacedClient) Close() {
+	pc.Client.Close()
+	pc.server.Close()
+}
+
+// Health implements health.Reporter interface.
+func (pc *PacedClient) Health() health.Reports {
+	return append(pc.Client.Health(), pc.pacer.Health()...)
+}
@@ -0,0 +1,128 @@
+package scanner
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRateLimitResponse(t *testing.T) {
+	r := require.New(t)
+
+	r.False(isRateLimitResponse(http.StatusOK, nil))
+	r.False(isRateLimitResponse(http.StatusInternalServerError, nil))
+	r.True(isRateLimitResponse(http.StatusTooManyRequests, nil))
+	r.True(isRateLimitResponse(http.StatusOK, &jsonRpcError{Code: errCodeLimitExceeded}))
+	r.False(isRateLimitResponse(http.StatusOK, &jsonRpcError{Code: -32000, Message: "not found"}))
+	r.True(isRateLimitResponse(http.StatusOK, &jsonRpcError{Code: -32000, Message: "daily request count exceeded, request rate limited"}))
+}
+
+func TestPacer(t *testing.T) {
+	r := require.New(t)
+
+	p := newPacer(100*time.Millisecond, 300*time.Millisecond)
+	r.Zero(p.Delay())
+	r.NoError(p.Wait(context.Background()))
+
+	p.Observe(true)
+	r.Equal(100*time.Millisecond, p.Delay())
+	p.Observe(true)
+	r.Equal(200*time.Millisecond, p.Delay())
+	p.Observe(true)
+	r.Equal(300*time.Millisecond, p.Delay())
+
+	reports := p.Health()
+	delayReport, ok := reports.GetByName("rate-limit.delay")
+	r.True(ok)
+	r.Equal(health.StatusLagging, delayReport.Status)
+	countReport, ok := reports.GetByName("rate-limit.count")
+	r.True(ok)
+	r.Equal("3", countReport.Details)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.ErrorIs(p.Wait(ctx), context.Canceled)
+
+	// decays back to zero on success
+	p.Observe(false)
+	r.Equal(150*time.Millisecond, p.Delay())
+	p.Observe(false)
+	r.Zero(p.Delay())
+}
+
+func TestPacedTransport(t *testing.T) {
+	r := require.New(t)
+
+	const limitExceeded = `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"limit exceeded"}}`
+	const result = `{"jsonrpc":"2.0","id":1,"result":{"error":"reverted"}}`
+	responses := []string{limitExceeded, result}
+	var served int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(responses[served]))
+		served++
+	}))
+	defer server.Close()
+
+	p := newPacer(time.Millisecond, time.Second)
+	client := &http.Client{Transport: &pacedTransport{base: http.DefaultTransport, pacer: p}}
+
+	// the json-rpc error is detected and the body is still readable
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader("{}"))
+	r.NoError(err)
+	body, err := io.ReadAll(resp.Body)
+	r.NoError(err)
+	r.Equal(limitExceeded, string(body))
+	r.Equal(time.Millisecond, p.Delay())
+
+	// the errors in the results are not rate limiting
+	resp, err = client.Post(server.URL, "application/json", strings.NewReader("{}"))
+	r.NoError(err)
+	resp.Body.Close()
+	r.Zero(p.Delay())
+}
+
+func TestPacedClient(t *testing.T) {
+	r := require.New(t)
+
+	// the provider rate limits the first request
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt64(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x10"}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client, err := NewPacedClient(ctx, "chain", server.URL, config.RateLimitPacingConfig{MinDelayMs: 1, MaxDelaySeconds: 1})
+	r.NoError(err)
+	r.IsType(&PacedClient{}, client)
+
+	// the client retries the request internally and the rate limiting is still counted
+	number, err := client.BlockNumber(ctx)
+	r.NoError(err)
+	r.Equal(int64(16), number.Int64())
+	r.Equal(int64(2), atomic.LoadInt64(&requests))
+
+	countReport, ok := client.Health().GetByName("rate-limit.count")
+	r.True(ok)
+	r.Equal("1", countReport.Details)
+
+	// the pacing is skipped if disabled
+	client, err = NewPacedClient(ctx, "chain", server.URL, config.RateLimitPacingConfig{Disable: true})
+	r.NoError(err)
+	_, ok = client.(*PacedClient)
+	r.False(ok)
+}
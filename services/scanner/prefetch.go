@@ -0,0 +1,405 @@
+package scanner
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/domain"
+	fortaethereum "github.com/forta-network/forta-core-go/ethereum"
+	"github.com/forta-network/forta-node/config"
+	lru "github.com/hashicorp/golang-lru"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// the prefetched data is not used after this long because the chain could have changed
+	prefetchMaxAge = time.Minute
+	// the number of the block hashes which the prefetched blocks are checked against
+	prefetchChainSize = 256
+)
+
+// BlockPrefetcher fetches the block, the logs and the traces of the next block while the current
+// block is being evaluated, so that the block feed does not wait for the JSON-RPC provider.
+type BlockPrefetcher struct {
+	ctx         context.Context
+	chainClient fortaethereum.Client
+	traceClient fortaethereum.Client
+	tracing     bool
+	offset      int
+
+	blocks      *lru.Cache // block number -> *prefetchedBlock
+	latestBlock uint64     // accessed atomically
+	// the hashes of the blocks which the feed got and their parents: block number -> hash
+	chain *lru.Cache
+
+	hits   uint64 // accessed atomically
+	misses uint64 // accessed atomically
+	reorgs uint64 // accessed atomically
+}
+
+type prefetchedBlock struct {
+	block     *prefetchResult
+	logs      *prefetchResult
+	traces    *prefetchResult
+	fetchedAt time.Time
+}
+
+type prefetchResult struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+func newPrefetchResult(fetch func() (interface{}, error)) *prefetchResult {
+	result := &prefetchResult{done: make(chan struct{})}
+	go func() {
+		defer close(result.done)
+		result.value, result.err = fetch()
+	}()
+	return result
+}
+
+// wait returns the prefetched value if the prefetch succeeded.
+func (result *prefetchResult) wait(ctx context.Context) (interface{}, bool) {
+	if result == nil {
+		return nil, false
+	}
+	select {
+	case <-ctx.Done():
+		return nil, false
+	case <-result.done:
+		return result.value, result.err == nil
+	}
+}
+
+// NewBlockPrefetcher creates a new block prefetcher. The offset is the distance between the latest block
+// and the block which is evaluated.
+func NewBlockPrefetcher(
+	ctx context.Context, chainClient, traceClient fortaethereum.Client, tracing bool, offset int,
+	cfg config.BlockPrefetchConfig,
+) (*BlockPrefetcher, error) {
+	blocks, err := lru.New(cfg.CacheSize)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := lru.New(prefetchChainSize)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockPrefetcher{
+		ctx:         ctx,
+		chainClient: chainClient,
+		traceClient: traceClient,
+		tracing:     tracing,
+		offset:      offset,
+		blocks:      blocks,
+		chain:       chain,
+	}, nil
+}
+
+// ChainClient returns the chain client which serves the prefetched blocks and logs.
+func (bp *BlockPrefetcher) ChainClient() fortaethereum.Client {
+	return &prefetchChainClient{Client: bp.chainClient, prefetcher: bp}
+}
+
+// TraceClient returns the trace client which serves the prefetched traces.
+func (bp *BlockPrefetcher) TraceClient() fortaethereum.Client {
+	return &prefetchTraceClient{Client: bp.traceClient, prefetcher: bp}
+}
+
+func (bp *BlockPrefetcher) setLatestBlock(number uint64) {
+	for {
+		latest := atomic.LoadUint64(&bp.latestBlock)
+		if number <= latest || atomic.CompareAndSwapUint64(&bp.latestBlock, latest, number) {
+			return
+		}
+	}
+}
+
+// prefetch starts fetching the data of the block if the block is known to be available.
+func (bp *BlockPrefetcher) prefetch(number uint64) {
+	if number+uint64(bp.offset) > atomic.LoadUint64(&bp.latestBlock) {
+		return
+	}
+	if bp.blocks.Contains(number) {
+		return
+	}
+	blockNum := new(big.Int).SetUint64(number)
+	prefetched := &prefetchedBlock{
+		block: newPrefetchResult(func() (interface{}, error) {
+			return bp.chainClient.BlockByNumber(bp.ctx, blockNum)
+		}),
+		logs: newPrefetchResult(func() (interface{}, error) {
+			return bp.chainClient.GetLogs(bp.ctx, ethereum.FilterQuery{FromBlock: blockNum, ToBlock: blockNum})
+		}),
+		fetchedAt: time.Now(),
+	}
+	if bp.tracing {
+		prefetched.traces = newPrefetchResult(func() (interface{}, error) {
+			return bp.traceClient.TraceBlock(bp.ctx, blockNum)
+		})
+	}
+	bp.blocks.Add(number, prefetched)
+	log.WithField("block", number).Debug("prefetching block")
+
+	// the feed also gets the latest block before the evaluated block
+	if bp.offset > 0 {
+		bp.prefetchBlockOnly(number + uint64(bp.offset))
+	}
+}
+
+func (bp *BlockPrefetcher) prefetchBlockOnly(number uint64) {
+	if bp.blocks.Contains(number) {
+		return
+	}
+	blockNum := new(big.Int).SetUint64(number)
+	bp.blocks.Add(number, &prefetchedBlock{
+		block: newPrefetchResult(func() (interface{}, error) {
+			return bp.chainClient.BlockByNumber(bp.ctx, blockNum)
+		}),
+		fetchedAt: time.Now(),
+	})
+}
+
+// get waits for and returns the prefetched value. The caller counts the hit after checking the value.
+func (bp *BlockPrefetcher) get(ctx context.Context, number *big.Int, getResult func(*prefetchedBlock) *prefetchResult) (interface{}, bool) {
+	if number == nil || !number.IsUint64() {
+		return nil, false
+	}
+	cached, ok := bp.blocks.Get(number.Uint64())
+	if !ok {
+		atomic.AddUint64(&bp.misses, 1)
+		return nil, false
+	}
+	prefetched := cached.(*prefetchedBlock)
+	if time.Since(prefetched.fetchedAt) > prefetchMaxAge {
+		atomic.AddUint64(&bp.misses, 1)
+		return nil, false
+	}
+	value, ok := getResult(prefetched).wait(ctx)
+	if !ok {
+		atomic.AddUint64(&bp.misses, 1)
+		return nil, false
+	}
+	return value, true
+}
+
+// getBlock returns the prefetched block if its hash and its parent hash match the blocks which the feed got
+// from the chain. The prefetched blocks are discarded otherwise because the chain reorganized.
+func (bp *BlockPrefetcher) getBlock(ctx context.Context, number *big.Int) (*domain.Block, bool) {
+	value, ok := bp.get(ctx, number, func(prefetched *prefetchedBlock) *prefetchResult {
+		return prefetched.block
+	})
+	if !ok {
+		return nil, false
+	}
+	block := value.(*domain.Block)
+	if !bp.matchesChain(block) {
+		bp.handleReorg(block)
+		atomic.AddUint64(&bp.misses, 1)
+		return nil, false
+	}
+	bp.recordBlock(block)
+	atomic.AddUint64(&bp.hits, 1)
+	return block, true
+}
+
+// getLogs returns the prefetched logs if they belong to the block which the feed got for the number.
+func (bp *BlockPrefetcher) getLogs(ctx context.Context, number *big.Int) ([]types.Log, bool) {
+	value, ok := bp.get(ctx, number, func(prefetched *prefetchedBlock) *prefetchResult {
+		return prefetched.logs
+	})
+	if !ok {
+		return nil, false
+	}
+	logs := value.([]types.Log)
+	for _, l := range logs {
+		if !bp.isChainBlockHash(number.Uint64(), l.BlockHash.Hex()) {
+			bp.discard(number.Uint64(), l.BlockHash.Hex())
+			return nil, false
+		}
+	}
+	atomic.AddUint64(&bp.hits, 1)
+	return logs, true
+}
+
+// getTraces returns the prefetched traces if they belong to the block which the feed got for the number.
+func (bp *BlockPrefetcher) getTraces(ctx context.Context, number *big.Int) ([]domain.Trace, bool) {
+	value, ok := bp.get(ctx, number, func(prefetched *prefetchedBlock) *prefetchResult {
+		return prefetched.traces
+	})
+	if !ok {
+		return nil, false
+	}
+	traces := value.([]domain.Trace)
+	for _, trace := range traces {
+		if trace.BlockHash != nil && !bp.isChainBlockHash(number.Uint64(), *trace.BlockHash) {
+			bp.discard(number.Uint64(), *trace.BlockHash)
+			return nil, false
+		}
+	}
+	atomic.AddUint64(&bp.hits, 1)
+	return traces, true
+}
+
+// matchesChain checks the hash and the parent hash of the block against the blocks which the feed got.
+func (bp *BlockPrefetcher) matchesChain(block *domain.Block) bool {
+	number, err := strconv.ParseUint(block.Number, 0, 64)
+	if err != nil {
+		return false
+	}
+	if hash, ok := bp.chain.Get(number); ok && !strings.EqualFold(hash.(string), block.Hash) {
+		return false
+	}
+	if number == 0 {
+		return true
+	}
+	if parentHash, ok := bp.chain.Get(number - 1); ok && !strings.EqualFold(parentHash.(string), block.ParentHash) {
+		return false
+	}
+	return true
+}
+
+// isChainBlockHash tells if the hash is the hash of the block which the feed got for the number.
+func (bp *BlockPrefetcher) isChainBlockHash(number uint64, hash string) bool {
+	chainHash, ok := bp.chain.Get(number)
+	return ok && strings.EqualFold(chainHash.(string), hash)
+}
+
+func (bp *BlockPrefetcher) recordBlock(block *domain.Block) {
+	number, err := strconv.ParseUint(block.Number, 0, 64)
+	if err != nil {
+		return
+	}
+	bp.chain.Add(number, block.Hash)
+	if number > 0 {
+		bp.chain.Add(number-1, block.ParentHash)
+	}
+}
+
+// observeBlock checks a block which is fetched from the chain and discards the prefetched blocks if
+// the chain reorganized.
+func (bp *BlockPrefetcher) observeBlock(block *domain.Block) {
+	if !bp.matchesChain(block) {
+		bp.handleReorg(block)
+	}
+	bp.recordBlock(block)
+}
+
+// handleReorg discards the prefetched blocks and the known hashes since they can be from the old chain.
+func (bp *BlockPrefetcher) handleReorg(block *domain.Block) {
+	log.WithFields(log.Fields{
+		"block": block.Number,
+		"hash":  block.Hash,
+	}).Warn("detected reorg - discarding the prefetched blocks")
+	atomic.AddUint64(&bp.reorgs, 1)
+	bp.blocks.Purge()
+	bp.chain.Purge()
+}
+
+// discard removes the prefetched data of a block which does not belong to the block the feed got.
+func (bp *BlockPrefetcher) discard(number uint64, hash string) {
+	log.WithFields(log.Fields{
+		"block": number,
+		"hash":  hash,
+	}).Warn("prefetched data does not match the block - discarding")
+	atomic.AddUint64(&bp.misses, 1)
+	bp.blocks.Remove(number)
+}
+
+// Name implements health.Reporter interface.
+func (bp *BlockPrefetcher) Name() string {
+	return "block-prefetcher"
+}
+
+// Health implements health.Reporter interface.
+func (bp *BlockPrefetcher) Health() health.Reports {
+	return health.Reports{
+		{
+			Name:    "hits",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&bp.hits), 10),
+		},
+		{
+			Name:    "misses",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&bp.misses), 10),
+		},
+		{
+			Name:    "reorgs",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(atomic.LoadUint64(&bp.reorgs), 10),
+		},
+	}
+}
+
+type prefetchChainClient struct {
+	fortaethereum.Client
+	prefetcher *BlockPrefetcher
+}
+
+// BlockByNumber implements ethereum.Client interface.
+func (pc *prefetchChainClient) BlockByNumber(ctx context.Context, number *big.Int) (*domain.Block, error) {
+	if block, ok := pc.prefetcher.getBlock(ctx, number); ok {
+		return block, nil
+	}
+	block, err := pc.Client.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	pc.prefetcher.observeBlock(block)
+	if number == nil {
+		if latest, err := strconv.ParseUint(block.Number, 0, 64); err == nil {
+			pc.prefetcher.setLatestBlock(latest)
+		}
+	}
+	return block, nil
+}
+
+// BlockNumber implements ethereum.Client interface.
+func (pc *prefetchChainClient) BlockNumber(ctx context.Context) (*big.Int, error) {
+	number, err := pc.Client.BlockNumber(ctx)
+	if err == nil && number.IsUint64() {
+		pc.prefetcher.setLatestBlock(number.Uint64())
+	}
+	return number, err
+}
+
+// GetLogs implements ethereum.Client interface. The logs are the last data the block feed gets
+// for a block so the next block is prefetched after that.
+func (pc *prefetchChainClient) GetLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	isBlockQuery := q.BlockHash == nil && q.FromBlock != nil && q.ToBlock != nil && q.FromBlock.Cmp(q.ToBlock) == 0 &&
+		len(q.Addresses) == 0 && len(q.Topics) == 0
+	if !isBlockQuery {
+		return pc.Client.GetLogs(ctx, q)
+	}
+	defer func() {
+		if q.FromBlock.IsUint64() {
+			pc.prefetcher.prefetch(q.FromBlock.Uint64() + 1)
+		}
+	}()
+	if logs, ok := pc.prefetcher.getLogs(ctx, q.FromBlock); ok {
+		return logs, nil
+	}
+	return pc.Client.GetLogs(ctx, q)
+}
+
+type prefetchTraceClient struct {
+	fortaethereum.Client
+	prefetcher *BlockPrefetcher
+}
+
+// TraceBlock implements ethereum.Client interface.
+func (pc *prefetchTraceClient) TraceBlock(ctx context.Context, number *big.Int) ([]domain.Trace, error) {
+	if traces, ok := pc.prefetcher.getTraces(ctx, number); ok {
+		return traces, nil
+	}
+	return pc.Client.TraceBlock(ctx, number)
+}
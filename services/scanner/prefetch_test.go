@@ -0,0 +1,126 @@
+package scanner
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/forta-network/forta-core-go/domain"
+	mock_ethereum "github.com/forta-network/forta-core-go/ethereum/mocks"
+	"github.com/forta-network/forta-node/config"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func blockQuery(number int64) ethereum.FilterQuery {
+	return ethereum.FilterQuery{FromBlock: big.NewInt(number), ToBlock: big.NewInt(number)}
+}
+
+const (
+	testPrefetchHash1 = "0x0000000000000000000000000000000000000000000000000000000000000001"
+	testPrefetchHash2 = "0x0000000000000000000000000000000000000000000000000000000000000002"
+	testPrefetchHash3 = "0x0000000000000000000000000000000000000000000000000000000000000003"
+)
+
+func TestBlockPrefetcher(t *testing.T) {
+	r := require.New(t)
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	chainClient := mock_ethereum.NewMockClient(ctrl)
+	traceClient := mock_ethereum.NewMockClient(ctrl)
+
+	prefetcher, err := NewBlockPrefetcher(ctx, chainClient, traceClient, true, 0, config.BlockPrefetchConfig{CacheSize: 8})
+	r.NoError(err)
+	feedChainClient := prefetcher.ChainClient()
+	feedTraceClient := prefetcher.TraceClient()
+
+	block2 := &domain.Block{Number: "0x2", Hash: testPrefetchHash2, ParentHash: testPrefetchHash1}
+	logs2 := []types.Log{{Index: 1, BlockHash: common.HexToHash(testPrefetchHash2)}}
+	blockHash2 := testPrefetchHash2
+	traces2 := []domain.Trace{{Type: "call", BlockHash: &blockHash2}}
+
+	// the latest block is known from the availability check
+	chainClient.EXPECT().BlockByNumber(gomock.Any(), nil).Return(block2, nil)
+	_, err = feedChainClient.BlockByNumber(ctx, nil)
+	r.NoError(err)
+
+	// getting the logs of block 1 triggers the prefetch of block 2
+	chainClient.EXPECT().GetLogs(gomock.Any(), blockQuery(1)).Return(nil, nil)
+	chainClient.EXPECT().BlockByNumber(gomock.Any(), big.NewInt(2)).Return(block2, nil).Times(1)
+	chainClient.EXPECT().GetLogs(gomock.Any(), blockQuery(2)).Return(logs2, nil).Times(1)
+	traceClient.EXPECT().TraceBlock(gomock.Any(), big.NewInt(2)).Return(traces2, nil).Times(1)
+	_, err = feedChainClient.GetLogs(ctx, blockQuery(1))
+	r.NoError(err)
+
+	// block 2 is served from the prefetched data and block 3 is not prefetched before it is available
+	block, err := feedChainClient.BlockByNumber(ctx, big.NewInt(2))
+	r.NoError(err)
+	r.Equal(block2, block)
+	traces, err := feedTraceClient.TraceBlock(ctx, big.NewInt(2))
+	r.NoError(err)
+	r.Equal(traces2, traces)
+	logs, err := feedChainClient.GetLogs(ctx, blockQuery(2))
+	r.NoError(err)
+	r.Equal(logs2, logs)
+
+	reports := prefetcher.Health()
+	hits, ok := reports.GetByName("hits")
+	r.True(ok)
+	r.Equal("3", hits.Details)
+	misses, ok := reports.GetByName("misses")
+	r.True(ok)
+	r.Equal("1", misses.Details)
+}
+
+func TestBlockPrefetcher_Reorg(t *testing.T) {
+	r := require.New(t)
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	chainClient := mock_ethereum.NewMockClient(ctrl)
+
+	prefetcher, err := NewBlockPrefetcher(ctx, chainClient, nil, false, 0, config.BlockPrefetchConfig{CacheSize: 8})
+	r.NoError(err)
+	feedChainClient := prefetcher.ChainClient()
+
+	block2 := &domain.Block{Number: "0x2", Hash: testPrefetchHash2, ParentHash: testPrefetchHash1}
+	reorgedBlock2 := &domain.Block{Number: "0x2", Hash: testPrefetchHash3, ParentHash: testPrefetchHash1}
+
+	// the prefetched block is from a different chain than the latest block
+	chainClient.EXPECT().BlockByNumber(gomock.Any(), nil).Return(block2, nil)
+	_, err = feedChainClient.BlockByNumber(ctx, nil)
+	r.NoError(err)
+
+	chainClient.EXPECT().GetLogs(gomock.Any(), blockQuery(1)).Return(nil, nil)
+	chainClient.EXPECT().BlockByNumber(gomock.Any(), big.NewInt(2)).Return(reorgedBlock2, nil)
+	chainClient.EXPECT().GetLogs(gomock.Any(), blockQuery(2)).Return([]types.Log{{BlockHash: common.HexToHash(testPrefetchHash3)}}, nil)
+	_, err = feedChainClient.GetLogs(ctx, blockQuery(1))
+	r.NoError(err)
+
+	// the cache is discarded and the block is fetched again
+	chainClient.EXPECT().BlockByNumber(gomock.Any(), big.NewInt(2)).Return(block2, nil)
+	block, err := feedChainClient.BlockByNumber(ctx, big.NewInt(2))
+	r.NoError(err)
+	r.Equal(block2, block)
+
+	// the logs are not from the prefetched block anymore
+	logs2 := []types.Log{{BlockHash: common.HexToHash(testPrefetchHash2)}}
+	chainClient.EXPECT().GetLogs(gomock.Any(), blockQuery(2)).Return(logs2, nil)
+	chainClient.EXPECT().BlockByNumber(gomock.Any(), big.NewInt(3)).Return(nil, ethereum.NotFound).AnyTimes()
+	chainClient.EXPECT().GetLogs(gomock.Any(), blockQuery(3)).Return(nil, ethereum.NotFound).AnyTimes()
+	logs, err := feedChainClient.GetLogs(ctx, blockQuery(2))
+	r.NoError(err)
+	r.Equal(logs2, logs)
+
+	reports := prefetcher.Health()
+	hits, ok := reports.GetByName("hits")
+	r.True(ok)
+	r.Equal("0", hits.Details)
+	reorgs, ok := reports.GetByName("reorgs")
+	r.True(ok)
+	r.Equal("1", reorgs.Details)
+}
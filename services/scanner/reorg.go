@@ -0,0 +1,120 @@
+package scanner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-core-go/feeds"
+	"github.com/forta-network/forta-core-go/utils"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/clients/messaging"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// reorgTrackerSize is how many block hashes are remembered for detecting the reorgs.
+const reorgTrackerSize = 256
+
+// ReorgTracker follows the evaluated blocks and detects the reorgs which happened deeper than
+// the block offset, i.e. the evaluated blocks which were later reorged out.
+type ReorgTracker struct {
+	offset    int
+	msgClient clients.MessageClient
+	hashes    map[uint64]string
+	mu        sync.Mutex
+
+	reorgCount     uint64
+	lastReorg      health.TimeTracker
+	lastReorgBlock health.MessageTracker
+}
+
+// NewReorgTracker creates a new reorg tracker which follows the block feed. The reorged out blocks
+// are published so that the findings about them can be marked.
+func NewReorgTracker(blockFeed feeds.BlockFeed, offset int, msgClient clients.MessageClient) *ReorgTracker {
+	rt := newReorgTracker(offset)
+	rt.msgClient = msgClient
+	blockFeed.Subscribe(rt.handleBlock)
+	return rt
+}
+
+func newReorgTracker(offset int) *ReorgTracker {
+	return &ReorgTracker{
+		offset: offset,
+		hashes: make(map[uint64]string),
+	}
+}
+
+func (rt *ReorgTracker) handleBlock(evt *domain.BlockEvent) error {
+	blockNum, err := utils.HexToBigInt(evt.Block.Number)
+	if err != nil {
+		log.WithError(err).WithField("block", evt.Block.Number).Warn("failed to parse the block number for reorg tracking")
+		return nil
+	}
+	number := blockNum.Uint64()
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	parentHash, ok := rt.hashes[number-1]
+	if ok && !strings.EqualFold(parentHash, evt.Block.ParentHash) {
+		rt.reorgCount++
+		rt.lastReorg.Set()
+		rt.lastReorgBlock.Set(strconv.FormatUint(number-1, 10))
+		log.WithFields(log.Fields{
+			"block":          number - 1,
+			"evaluatedHash":  parentHash,
+			"canonicalHash":  evt.Block.ParentHash,
+			"offset":         rt.offset,
+			"totalReorgsNow": rt.reorgCount,
+		}).Warn("evaluated block was reorged out - consider increasing scan.confirmationDepth")
+		if rt.msgClient != nil {
+			rt.msgClient.Publish(messaging.SubjectScannerReorg, &messaging.ReorgPayload{
+				BlockNumber:   number - 1,
+				OrphanedHash:  parentHash,
+				CanonicalHash: evt.Block.ParentHash,
+			})
+		}
+	}
+
+	rt.hashes[number] = evt.Block.Hash
+	for blockNum := range rt.hashes {
+		if blockNum+reorgTrackerSize < number {
+			delete(rt.hashes, blockNum)
+		}
+	}
+	return nil
+}
+
+// Name returns the name of the tracker.
+func (rt *ReorgTracker) Name() string {
+	return "reorg-tracker"
+}
+
+// Health implements health.Reporter interface.
+func (rt *ReorgTracker) Health() health.Reports {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	return health.Reports{
+		&health.Report{
+			Name:    "block.offset",
+			Status:  health.StatusInfo,
+			Details: strconv.Itoa(rt.offset),
+		},
+		&health.Report{
+			Name:    "reorg.count",
+			Status:  health.StatusInfo,
+			Details: fmt.Sprintf("%d", rt.reorgCount),
+		},
+		&health.Report{
+			Name:    "reorg.time",
+			Status:  health.StatusInfo,
+			Details: rt.lastReorg.String(),
+		},
+		rt.lastReorgBlock.GetReport("reorg.block"),
+	}
+}
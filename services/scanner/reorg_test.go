@@ -0,0 +1,51 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-node/clients/messaging"
+	mock_clients "github.com/forta-network/forta-node/clients/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func testReorgBlock(number, hash, parentHash string) *domain.BlockEvent {
+	return &domain.BlockEvent{Block: &domain.Block{Number: number, Hash: hash, ParentHash: parentHash}}
+}
+
+func TestReorgTracker(t *testing.T) {
+	r := require.New(t)
+
+	msgClient := mock_clients.NewMockMessageClient(gomock.NewController(t))
+	rt := newReorgTracker(3)
+	rt.msgClient = msgClient
+	r.NoError(rt.handleBlock(testReorgBlock("0x1", "0xa1", "0xa0")))
+	r.NoError(rt.handleBlock(testReorgBlock("0x2", "0xa2", "0xA1")))
+	r.Zero(rt.reorgCount)
+
+	// block 2 was reorged out after it was evaluated
+	msgClient.EXPECT().Publish(messaging.SubjectScannerReorg, &messaging.ReorgPayload{
+		BlockNumber: 2, OrphanedHash: "0xa2", CanonicalHash: "0xb2",
+	})
+	r.NoError(rt.handleBlock(testReorgBlock("0x3", "0xa3", "0xb2")))
+	r.Equal(uint64(1), rt.reorgCount)
+
+	// blocks which were skipped are not checked
+	r.NoError(rt.handleBlock(testReorgBlock("0x5", "0xa5", "0xb4")))
+	r.Equal(uint64(1), rt.reorgCount)
+
+	reports := rt.Health()
+	r.Equal("3", reports[0].Details)
+	r.Equal("1", reports[1].Details)
+	r.Equal("2", reports[3].Details)
+}
+
+func TestReorgTracker_Prune(t *testing.T) {
+	r := require.New(t)
+
+	rt := newReorgTracker(0)
+	r.NoError(rt.handleBlock(testReorgBlock("0x1", "0xa1", "0xa0")))
+	r.NoError(rt.handleBlock(testReorgBlock("0x1000", "0xb1", "0xb0")))
+	r.Len(rt.hashes, 1)
+}
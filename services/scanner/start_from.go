@@ -0,0 +1,136 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-core-go/domain"
+	"github.com/forta-network/forta-core-go/ethereum"
+)
+
+// StartFromLatest is the start-from value which starts the scanning from the latest block.
+const StartFromLatest = "latest"
+
+// StartFrom is where the block feed starts from: the latest block, a block number or the first block
+// which was mined at or after a time.
+type StartFrom struct {
+	Latest    bool       `json:"latest,omitempty"`
+	Block     *uint64    `json:"block,omitempty"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+}
+
+// ParseStartFrom parses "latest", a block number or an RFC3339 timestamp.
+func ParseStartFrom(value string) (*StartFrom, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, StartFromLatest) {
+		return &StartFrom{Latest: true}, nil
+	}
+	if blockNumber, err := strconv.ParseUint(value, 10, 64); err == nil {
+		return &StartFrom{Block: &blockNumber}, nil
+	}
+	ts, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start-from value '%s': expected 'latest', a block number or an RFC3339 timestamp", value)
+	}
+	ts = ts.UTC()
+	if ts.After(time.Now()) {
+		return nil, fmt.Errorf("start-from timestamp %s is in the future", value)
+	}
+	return &StartFrom{Timestamp: &ts}, nil
+}
+
+func (sf *StartFrom) String() string {
+	switch {
+	case sf.Block != nil:
+		return fmt.Sprintf("block %d", *sf.Block)
+	case sf.Timestamp != nil:
+		return fmt.Sprintf("the first block at %s", sf.Timestamp.Format(time.RFC3339))
+	default:
+		return "the latest block"
+	}
+}
+
+// LoadStartFrom loads the start-from override requested with the CLI. It is nil if the file does not exist.
+func LoadStartFrom(filePath string) (*StartFrom, error) {
+	b, err := ioutil.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sf StartFrom
+	if err := json.Unmarshal(b, &sf); err != nil {
+		return nil, fmt.Errorf("failed to decode the start-from override: %v", err)
+	}
+	return &sf, nil
+}
+
+// SaveStartFrom writes the start-from override which the scanner uses once at the next start.
+func SaveStartFrom(filePath string, sf *StartFrom) error {
+	b, err := json.Marshal(sf)
+	if err != nil {
+		return err
+	}
+	tmpPath := filePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filePath)
+}
+
+// ResolveBlock finds the block to start from. The block is nil if the scanning should start from the latest block.
+func (sf *StartFrom) ResolveBlock(ctx context.Context, ethClient ethereum.Client, latestBlock uint64) (*domain.Block, error) {
+	switch {
+	case sf.Block != nil:
+		if *sf.Block > latestBlock {
+			return nil, fmt.Errorf("start block %d is after the latest block %d", *sf.Block, latestBlock)
+		}
+		return ethClient.BlockByNumber(ctx, big.NewInt(0).SetUint64(*sf.Block))
+	case sf.Timestamp != nil:
+		return findBlockByTime(ctx, ethClient, latestBlock, *sf.Timestamp)
+	default:
+		return nil, nil
+	}
+}
+
+// findBlockByTime does a binary search for the first block which was mined at or after the given time.
+func findBlockByTime(ctx context.Context, ethClient ethereum.Client, latestBlock uint64, t time.Time) (*domain.Block, error) {
+	var (
+		low   uint64
+		high  = latestBlock
+		found *domain.Block
+	)
+	for low <= high {
+		mid := low + (high-low)/2
+		block, err := ethClient.BlockByNumber(ctx, big.NewInt(0).SetUint64(mid))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get block %d: %v", mid, err)
+		}
+		blockTime, err := block.GetTimestamp()
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp of block %d: %v", mid, err)
+		}
+		if blockTime.Before(t) {
+			low = mid + 1
+			continue
+		}
+		found = block
+		if mid == 0 {
+			break
+		}
+		high = mid - 1
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no block was mined at or after %s", t.Format(time.RFC3339))
+	}
+	return found, nil
+}
@@ -0,0 +1,86 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/domain"
+	mock_ethereum "github.com/forta-network/forta-core-go/ethereum/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStartFrom(t *testing.T) {
+	r := require.New(t)
+
+	sf, err := ParseStartFrom("latest")
+	r.NoError(err)
+	r.True(sf.Latest)
+
+	sf, err = ParseStartFrom("12345")
+	r.NoError(err)
+	r.Equal(uint64(12345), *sf.Block)
+	r.Equal("block 12345", sf.String())
+
+	sf, err = ParseStartFrom("2023-03-01T10:00:00+02:00")
+	r.NoError(err)
+	r.Equal(time.Date(2023, 3, 1, 8, 0, 0, 0, time.UTC), *sf.Timestamp)
+
+	_, err = ParseStartFrom("yesterday")
+	r.Error(err)
+	_, err = ParseStartFrom(time.Now().Add(time.Hour).Format(time.RFC3339))
+	r.Error(err)
+}
+
+func TestStartFrom_SaveLoad(t *testing.T) {
+	r := require.New(t)
+
+	filePath := path.Join(t.TempDir(), "start-from.json")
+	sf, err := LoadStartFrom(filePath)
+	r.NoError(err)
+	r.Nil(sf)
+
+	blockNumber := uint64(100)
+	r.NoError(SaveStartFrom(filePath, &StartFrom{Block: &blockNumber}))
+	sf, err = LoadStartFrom(filePath)
+	r.NoError(err)
+	r.Equal(blockNumber, *sf.Block)
+}
+
+func TestStartFrom_ResolveBlockByTime(t *testing.T) {
+	r := require.New(t)
+	ctrl := gomock.NewController(t)
+	ethClient := mock_ethereum.NewMockClient(ctrl)
+
+	// the blocks are mined every 10 seconds starting from the unix time 1000
+	ethClient.EXPECT().BlockByNumber(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, number *big.Int) (*domain.Block, error) {
+			return &domain.Block{
+				Number:    fmt.Sprintf("0x%x", number.Uint64()),
+				Timestamp: fmt.Sprintf("0x%x", 1000+number.Uint64()*10),
+			}, nil
+		},
+	).AnyTimes()
+
+	ts := time.Unix(1255, 0)
+	block, err := (&StartFrom{Timestamp: &ts}).ResolveBlock(context.Background(), ethClient, 100)
+	r.NoError(err)
+	r.Equal("0x1a", block.Number)
+
+	ts = time.Unix(500, 0)
+	block, err = (&StartFrom{Timestamp: &ts}).ResolveBlock(context.Background(), ethClient, 100)
+	r.NoError(err)
+	r.Equal("0x0", block.Number)
+
+	ts = time.Unix(5000, 0)
+	_, err = (&StartFrom{Timestamp: &ts}).ResolveBlock(context.Background(), ethClient, 100)
+	r.Error(err)
+
+	blockNumber := uint64(101)
+	_, err = (&StartFrom{Block: &blockNumber}).ResolveBlock(context.Background(), ethClient, 100)
+	r.Error(err)
+}
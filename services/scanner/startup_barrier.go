@@ -0,0 +1,180 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/clients/messaging"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultReadinessCheckInterval  = time.Second * 2
+	defaultReadinessRequestTimeout = time.Second * 5
+)
+
+// ReadinessCheck checks if a service which the evaluation depends on is ready.
+type ReadinessCheck struct {
+	Name  string
+	Check func() error
+}
+
+// MessagingReadinessCheck sends a readiness request to the service over the messaging. The service is not
+// ready until it starts responding.
+func MessagingReadinessCheck(name string, msgClient clients.MessageClient, subject string) ReadinessCheck {
+	return ReadinessCheck{
+		Name: name,
+		Check: func() error {
+			var readiness messaging.Readiness
+			if err := msgClient.Request(subject, nil, &readiness, defaultReadinessRequestTimeout); err != nil {
+				return err
+			}
+			if !readiness.Ready {
+				reason := readiness.Reason
+				if len(reason) == 0 {
+					reason = "not ready"
+				}
+				return errors.New(reason)
+			}
+			return nil
+		},
+	}
+}
+
+// StartupBarrier holds the block feed until the services which the evaluation depends on report ready, so
+// that the first blocks after a start are not dropped before the proxy, the publisher and the bots are up.
+type StartupBarrier struct {
+	ctx      context.Context
+	cfg      config.StartupBarrierConfig
+	checks   []ReadinessCheck
+	interval time.Duration
+
+	started  time.Time
+	passed   time.Time
+	timedOut bool
+	waiting  map[string]string
+	mu       sync.RWMutex
+}
+
+// NewStartupBarrier creates a new startup barrier.
+func NewStartupBarrier(ctx context.Context, cfg config.StartupBarrierConfig, checks ...ReadinessCheck) *StartupBarrier {
+	return &StartupBarrier{
+		ctx:      ctx,
+		cfg:      cfg,
+		checks:   checks,
+		interval: defaultReadinessCheckInterval,
+		waiting:  make(map[string]string),
+	}
+}
+
+// Wait blocks until all of the checks pass, the timeout is reached or the context is done.
+// It returns false if the checks did not pass.
+func (sb *StartupBarrier) Wait() bool {
+	sb.mu.Lock()
+	sb.started = time.Now()
+	sb.mu.Unlock()
+	if sb.cfg.Disable {
+		sb.pass()
+		return true
+	}
+
+	timeout := time.NewTimer(time.Duration(sb.cfg.TimeoutSeconds) * time.Second)
+	defer timeout.Stop()
+	ticker := time.NewTicker(sb.interval)
+	defer ticker.Stop()
+	for {
+		if sb.check() {
+			sb.pass()
+			log.WithField("duration", time.Since(sb.started).String()).Info("startup barrier passed - starting the block feed")
+			return true
+		}
+		select {
+		case <-sb.ctx.Done():
+			return false
+		case <-timeout.C:
+			sb.mu.Lock()
+			sb.timedOut = true
+			sb.mu.Unlock()
+			log.WithField("waitingFor", sb.waitingFor()).Warn("startup barrier timed out - starting the block feed anyway")
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// check runs the checks which have not passed yet and tells if all of them passed.
+func (sb *StartupBarrier) check() bool {
+	var remaining []ReadinessCheck
+	waiting := make(map[string]string)
+	for _, check := range sb.checks {
+		if err := check.Check(); err != nil {
+			waiting[check.Name] = err.Error()
+			remaining = append(remaining, check)
+			continue
+		}
+		log.WithField("service", check.Name).Info("ready")
+	}
+	// the services do not become unready later so the passed checks are not repeated
+	sb.checks = remaining
+
+	sb.mu.Lock()
+	sb.waiting = waiting
+	sb.mu.Unlock()
+	return len(remaining) == 0
+}
+
+func (sb *StartupBarrier) pass() {
+	sb.mu.Lock()
+	sb.passed = time.Now()
+	sb.mu.Unlock()
+}
+
+func (sb *StartupBarrier) waitingFor() string {
+	sb.mu.RLock()
+	defer sb.mu.RUnlock()
+
+	var list []string
+	for name, reason := range sb.waiting {
+		list = append(list, fmt.Sprintf("%s (%s)", name, reason))
+	}
+	sort.Strings(list)
+	return strings.Join(list, ", ")
+}
+
+// Name implements health.Reporter interface.
+func (sb *StartupBarrier) Name() string {
+	return "startup-barrier"
+}
+
+// Health implements health.Reporter interface.
+func (sb *StartupBarrier) Health() health.Reports {
+	sb.mu.RLock()
+	started, passed, timedOut := sb.started, sb.passed, sb.timedOut
+	sb.mu.RUnlock()
+
+	report := &health.Report{
+		Name:   "startup-barrier",
+		Status: health.StatusOK,
+	}
+	switch {
+	case !passed.IsZero():
+		report.Details = fmt.Sprintf("passed in %s", passed.Sub(started).Round(time.Millisecond))
+	case timedOut:
+		report.Status = health.StatusLagging
+		report.Details = "timed out waiting for: " + sb.waitingFor()
+	case started.IsZero():
+		report.Status = health.StatusUnknown
+	default:
+		report.Status = health.StatusLagging
+		report.Details = "waiting for: " + sb.waitingFor()
+	}
+	return health.Reports{report}
+}
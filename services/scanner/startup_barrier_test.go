@@ -0,0 +1,88 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/clients/messaging"
+	mock_clients "github.com/forta-network/forta-node/clients/mocks"
+	"github.com/forta-network/forta-node/config"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartupBarrier(t *testing.T) {
+	r := require.New(t)
+
+	var botsReady bool
+	var proxyChecks int
+	sb := NewStartupBarrier(context.Background(), config.StartupBarrierConfig{TimeoutSeconds: 10},
+		ReadinessCheck{Name: "proxy", Check: func() error {
+			proxyChecks++
+			return nil
+		}},
+		ReadinessCheck{Name: "bots", Check: func() error {
+			if !botsReady {
+				botsReady = true
+				return errors.New("warming up")
+			}
+			return nil
+		}},
+	)
+	sb.interval = time.Millisecond
+	r.Equal(health.StatusUnknown, sb.Health()[0].Status)
+
+	r.True(sb.Wait())
+	// the passed checks are not repeated
+	r.Equal(1, proxyChecks)
+	r.Equal(health.StatusOK, sb.Health()[0].Status)
+	r.Contains(sb.Health()[0].Details, "passed")
+}
+
+func TestStartupBarrier_Timeout(t *testing.T) {
+	r := require.New(t)
+
+	sb := NewStartupBarrier(context.Background(), config.StartupBarrierConfig{TimeoutSeconds: 1},
+		ReadinessCheck{Name: "bots", Check: func() error {
+			return errors.New("no bots assigned yet")
+		}},
+	)
+	sb.interval = time.Millisecond * 100
+
+	r.False(sb.Wait())
+	report := sb.Health()[0]
+	r.Equal(health.StatusLagging, report.Status)
+	r.Equal("timed out waiting for: bots (no bots assigned yet)", report.Details)
+
+	sb = NewStartupBarrier(context.Background(), config.StartupBarrierConfig{Disable: true}, ReadinessCheck{
+		Name: "bots", Check: func() error { return errors.New("no bots assigned yet") },
+	})
+	r.True(sb.Wait())
+}
+
+func TestMessagingReadinessCheck(t *testing.T) {
+	r := require.New(t)
+
+	msgClient := mock_clients.NewMockMessageClient(gomock.NewController(t))
+	check := MessagingReadinessCheck("publisher", msgClient, messaging.SubjectPublisherReadyRequest)
+
+	msgClient.EXPECT().Request(messaging.SubjectPublisherReadyRequest, nil, gomock.Any(), gomock.Any()).Return(errors.New("timeout"))
+	r.Error(check.Check())
+
+	msgClient.EXPECT().Request(messaging.SubjectPublisherReadyRequest, nil, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(subject string, payload, response interface{}, timeout time.Duration) error {
+			response.(*messaging.Readiness).Reason = "batch queue is full"
+			return nil
+		})
+	r.EqualError(check.Check(), "batch queue is full")
+
+	msgClient.EXPECT().Request(messaging.SubjectPublisherReadyRequest, nil, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(subject string, payload, response interface{}, timeout time.Duration) error {
+			response.(*messaging.Readiness).Ready = true
+			return nil
+		})
+	r.NoError(check.Check())
+}
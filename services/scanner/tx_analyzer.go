@@ -2,6 +2,7 @@ package scanner
 
 import (
 	"context"
+	"math/big"
 	"time"
 
 	"github.com/forta-network/forta-core-go/clients/health"
@@ -13,6 +14,7 @@ import (
 	"github.com/forta-network/forta-core-go/protocol"
 	"github.com/forta-network/forta-core-go/utils"
 	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/config"
 
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
@@ -24,19 +26,30 @@ type TxAnalyzerService struct {
 	ctx context.Context
 	cfg TxAnalyzerServiceConfig
 
+	txOrder txOrderChecker
+
 	lastInputActivity  health.TimeTracker
 	lastOutputActivity health.TimeTracker
 }
 
 type TxAnalyzerServiceConfig struct {
-	TxChannel   <-chan *domain.TransactionEvent
-	AlertSender clients.AlertSender
-	AgentPool   AgentPool
-	MsgClient   clients.MessageClient
+	TxChannel        <-chan *domain.TransactionEvent
+	PendingTxChannel <-chan *domain.TransactionEvent
+	AlertSender      clients.AlertSender
+	AgentPool        AgentPool
+	MsgClient        clients.MessageClient
+	CrossCheck       *CrossCheckedClient
+	Clock            *ClockSkewMonitor
 }
 
 func (t *TxAnalyzerService) publishMetrics(result *TxResult) {
-	m := metrics.GetTxMetrics(result.AgentConfig, result.Response, result.Timestamps)
+	m := metrics.GetTxMetrics(result.AgentConfig, result.Response, t.cfg.Clock.Compensate(result.Timestamps))
+	if result.Late {
+		m = append(m, metrics.CreateAgentMetric(result.AgentConfig.ID, metrics.MetricTxLate, 1))
+	}
+	if t.cfg.CrossCheck.Diverged(result.Request.Event.Block.BlockHash) {
+		m = append(m, metrics.CreateAgentMetric(result.AgentConfig.ID, metrics.MetricDataDivergence, 1))
+	}
 	t.cfg.MsgClient.PublishProto(messaging.SubjectMetricAgent, &protocol.AgentMetricList{Metrics: m})
 }
 
@@ -52,9 +65,13 @@ func (t *TxAnalyzerService) findingToAlert(result *TxResult, ts time.Time, f *pr
 		},
 	)
 
-	blockNumber, err := utils.HexToBigInt(result.Request.Event.Block.BlockNumber)
-	if err != nil {
-		return nil, err
+	var blockNumber *big.Int
+	if !result.Pending {
+		var err error
+		blockNumber, err = utils.HexToBigInt(result.Request.Event.Block.BlockNumber)
+		if err != nil {
+			return nil, err
+		}
 	}
 	chainId, err := utils.HexToBigInt(result.Request.Event.Network.ChainId)
 	if err != nil {
@@ -66,13 +83,28 @@ func (t *TxAnalyzerService) findingToAlert(result *TxResult, ts time.Time, f *pr
 		"agentId":    result.AgentConfig.ID,
 		"chainId":    chainId.String(),
 	}
+	// the findings which arrive after the result deadline of the block are still published
+	if result.Late {
+		tags["late"] = "true"
+	}
+	// the providers disagreed on the block data and the data of the majority was evaluated
+	if t.cfg.CrossCheck.Diverged(result.Request.Event.Block.BlockHash) {
+		tags["dataDivergence"] = "true"
+	}
+
+	// the pending transactions are not in a block yet
+	if result.Pending {
+		tags["pending"] = "true"
+	}
 
 	alertType := protocol.AlertType_PRIVATE
 	if !f.Private && !result.Response.Private {
 		alertType = protocol.AlertType_TRANSACTION
 		tags["txHash"] = result.Request.Event.Transaction.Hash
-		tags["blockHash"] = result.Request.Event.Block.BlockHash
-		tags["blockNumber"] = blockNumber.String()
+		if !result.Pending {
+			tags["blockHash"] = result.Request.Event.Block.BlockHash
+			tags["blockNumber"] = blockNumber.String()
+		}
 	}
 
 	addressBloomFilter, err := t.createBloomFilter(f, result.Request.Event)
@@ -117,7 +149,9 @@ func (t *TxAnalyzerService) Start() error {
 				EvalTxResponse: result.Response,
 			}
 
-			if len(result.Response.Findings) == 0 {
+			// the batches record which bots processed which blocks and the pending
+			// transactions are not in a block yet
+			if len(result.Response.Findings) == 0 && !result.Pending {
 				if err := t.cfg.AlertSender.NotifyWithoutAlert(
 					rt, result.Timestamps,
 				); err != nil {
@@ -138,7 +172,10 @@ func (t *TxAnalyzerService) Start() error {
 					log.WithError(err).Panic("failed to sign alert and notify")
 				}
 			}
-			t.publishMetrics(result)
+			// the canary bot is not a real bot
+			if result.AgentConfig.ID != config.CanaryBotID {
+				t.publishMetrics(result)
+			}
 
 			t.lastOutputActivity.Set()
 		}
@@ -148,6 +185,12 @@ func (t *TxAnalyzerService) Start() error {
 	go func() {
 		// for each transaction
 		for tx := range t.cfg.TxChannel {
+			// bots should never see tx N+1 of a block before tx N
+			if err := t.txOrder.Check(tx.Transaction.BlockNumber, tx.Transaction.BlockHash, tx.Transaction.TransactionIndex); err != nil {
+				log.WithError(err).WithField("tx", tx.Transaction.Hash).Error("transaction is out of order (skipping)")
+				continue
+			}
+
 			// convert to message
 			msg, err := tx.ToMessage()
 			if err != nil {
@@ -166,6 +209,31 @@ func (t *TxAnalyzerService) Start() error {
 		}
 	}()
 
+	if t.cfg.PendingTxChannel == nil {
+		return nil
+	}
+
+	// distributes the pending transactions to the bots which opted in
+	go func() {
+		for tx := range t.cfg.PendingTxChannel {
+			msg, err := tx.ToMessage()
+			if err != nil {
+				log.WithError(err).Error("error converting pending tx event to message (skipping)")
+				continue
+			}
+			// the latest block only selects the bots and the pending tx is not in it
+			latestBlockNumber := msg.Block.BlockNumber
+			msg.Block = &protocol.TransactionEvent_EthBlock{}
+
+			requestId := uuid.Must(uuid.NewUUID())
+			request := &protocol.EvaluateTxRequest{RequestId: requestId.String(), Event: msg}
+
+			t.cfg.AgentPool.SendEvaluatePendingTxRequest(request, latestBlockNumber)
+
+			t.lastInputActivity.Set()
+		}
+	}()
+
 	return nil
 }
 
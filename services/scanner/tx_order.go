@@ -0,0 +1,43 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// txOrderChecker checks that the transactions of a block are dispatched in the transaction
+// index order. The transactions are sent to the bots through FIFO channels and each bot
+// processes them one by one so the dispatch order is the order which the bots see.
+// The index starts over when the block hash changes because the same block number is
+// dispatched again for the new block after a reorg.
+type txOrderChecker struct {
+	started   bool
+	lastBlock uint64
+	lastHash  string
+	lastIndex uint64
+}
+
+// Check checks the next transaction and remembers it if it is in order. The transactions which
+// are out of order are not remembered so they do not affect the next checks.
+func (c *txOrderChecker) Check(blockNumberHex, blockHash, txIndexHex string) error {
+	blockNumber, err := hexutil.DecodeUint64(blockNumberHex)
+	if err != nil {
+		return fmt.Errorf("invalid block number '%s': %v", blockNumberHex, err)
+	}
+	txIndex, err := hexutil.DecodeUint64(txIndexHex)
+	if err != nil {
+		return fmt.Errorf("invalid transaction index '%s': %v", txIndexHex, err)
+	}
+
+	sameBlock := blockNumber == c.lastBlock && strings.EqualFold(blockHash, c.lastHash)
+	if c.started && sameBlock && txIndex <= c.lastIndex {
+		return fmt.Errorf("block %d: tx index %d dispatched after %d", blockNumber, txIndex, c.lastIndex)
+	}
+	c.started = true
+	c.lastBlock = blockNumber
+	c.lastHash = blockHash
+	c.lastIndex = txIndex
+	return nil
+}
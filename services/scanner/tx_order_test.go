@@ -0,0 +1,24 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxOrderChecker(t *testing.T) {
+	r := require.New(t)
+
+	var checker txOrderChecker
+	r.NoError(checker.Check("0x1", "0xa", "0x0"))
+	r.NoError(checker.Check("0x1", "0xa", "0x1"))
+	r.NoError(checker.Check("0x1", "0xa", "0x3")) // gaps are fine - duplicates are skipped by the feed
+	r.Error(checker.Check("0x1", "0xa", "0x2"))
+	r.NoError(checker.Check("0x1", "0xa", "0x4")) // the rejected tx is not remembered
+	r.NoError(checker.Check("0x2", "0xb", "0x0"))
+	r.Error(checker.Check("0x2", "0xb", "0x0"))
+	r.NoError(checker.Check("0x2", "0xc", "0x0")) // the reorged block starts over
+	r.NoError(checker.Check("0x2", "0xc", "0x1"))
+	r.Error(checker.Check("0x2", "0xC", "0x1"))
+	r.Error(checker.Check("0x2", "0xc", "bad"))
+}
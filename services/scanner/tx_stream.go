@@ -6,9 +6,9 @@ import (
 
 	"github.com/forta-network/forta-core-go/clients/health"
 	"github.com/forta-network/forta-core-go/domain"
-	"github.com/forta-network/forta-core-go/ethereum"
 	"github.com/forta-network/forta-core-go/feeds"
 	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -23,6 +23,8 @@ type TxStreamService struct {
 
 	lastBlockActivity health.TimeTracker
 	lastTxActivity    health.TimeTracker
+
+	pause services.PauseState
 }
 
 type TxStreamServiceConfig struct {
@@ -45,6 +47,10 @@ func (t *TxStreamService) handleBlock(evt *domain.BlockEvent) error {
 		return nil
 	default:
 	}
+	if t.pause.IsPaused() {
+		log.WithField("block", evt.Block.Number).Debug("paused - skipping block")
+		return nil
+	}
 	t.blockOutput <- evt
 	t.lastBlockActivity.Set()
 	return nil
@@ -56,6 +62,9 @@ func (t *TxStreamService) handleTx(evt *domain.TransactionEvent) error {
 		return nil
 	default:
 	}
+	if t.pause.IsPaused() {
+		return nil
+	}
 	t.txOutput <- evt
 	t.lastTxActivity.Set()
 	return nil
@@ -106,6 +115,11 @@ func (t *TxStreamService) Stop() error {
 	return nil
 }
 
+// SetPaused implements services.Pauser. The blocks and the transactions are skipped while paused.
+func (t *TxStreamService) SetPaused(paused bool) {
+	t.pause.SetPaused(paused)
+}
+
 func (t *TxStreamService) Name() string {
 	return "tx-stream"
 }
@@ -115,18 +129,14 @@ func (t *TxStreamService) Health() health.Reports {
 	return health.Reports{
 		t.lastBlockActivity.GetReport("event.block.time"),
 		t.lastTxActivity.GetReport("event.transaction.time"),
+		t.pause.GetReport("paused"),
 	}
 }
 
-func NewTxStreamService(ctx context.Context, ethClient ethereum.Client, blockFeed feeds.BlockFeed, cfg TxStreamServiceConfig) (*TxStreamService, error) {
+func NewTxStreamService(ctx context.Context, txFeed feeds.TransactionFeed, cfg TxStreamServiceConfig) (*TxStreamService, error) {
 	txOutput := make(chan *domain.TransactionEvent)
 	blockOutput := make(chan *domain.BlockEvent)
 
-	txFeed, err := feeds.NewTransactionFeed(ctx, ethClient, blockFeed, cfg.SkipBlocksOlderThan, 10)
-	if err != nil {
-		return nil, err
-	}
-
 	return &TxStreamService{
 		cfg:         cfg,
 		ctx:         ctx,
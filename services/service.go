@@ -21,6 +21,11 @@ const (
 
 const (
 	GracefulShutdownSignal = syscall.SIGTERM
+	ConfigReloadSignal     = syscall.SIGHUP
+	PauseStateSignal       = syscall.SIGUSR1
+
+	// PauseStateSignalName is for sending the pause state signal to the containers.
+	PauseStateSignalName = "SIGUSR1"
 
 	ExitCodeTriggered = 77
 )
@@ -37,8 +42,16 @@ type Service interface {
 	Name() string
 }
 
+// ConfigReloader is implemented by the services which can apply the reloadable config
+// values at runtime.
+type ConfigReloader interface {
+	ReloadConfig(cfg config.Config, report *config.ReloadReport)
+}
+
 var sigc = make(chan os.Signal)
 
+var reloadc = make(chan struct{}, 1)
+
 var execIDKey = struct{}{}
 
 func ExecID(ctx context.Context) string {
@@ -79,12 +92,21 @@ func ContainerMain(name string, getServices func(ctx context.Context, cfg config
 	ctx, cancel := InitMainContext()
 	defer cancel()
 
+	StartProfilingServer(ctx, logger, cfg.Profiling)
+
 	serviceList, err := getServices(ctx, cfg)
 	if err != nil {
 		logger.WithError(err).Error("could not initialize services")
 		return
 	}
 
+	go HandleConfigReloads(ctx, logger, cfg, config.GetConfigForContainer, serviceList)
+
+	// apply before starting so that a paused node does not dispatch anything
+	ApplyPauseState(logger, cfg.FortaDir, serviceList)
+	ApplyDisabledBots(logger, cfg.FortaDir, serviceList)
+	go HandlePauseState(ctx, logger, cfg.FortaDir, serviceList)
+
 	err = StartServices(ctx, cancel, logger, serviceList)
 	if err == ErrExitTriggered {
 		logger.Info("exiting due to internal trigger")
@@ -110,18 +132,71 @@ func InitMainContext() (context.Context, context.CancelFunc) {
 	ctx, cancel := context.WithCancel(execIDCtx)
 	signal.Notify(sigc,
 		syscall.SIGHUP,
+		syscall.SIGUSR1,
 		syscall.SIGINT,
 		syscall.SIGTERM,
 		syscall.SIGQUIT)
 	go func() {
-		sig := <-sigc
-		log.Infof("received signal: %s", sig.String())
-		gracefulShutdown = sig == GracefulShutdownSignal
-		cancel()
+		for sig := range sigc {
+			log.Infof("received signal: %s", sig.String())
+			if sig == ConfigReloadSignal {
+				TriggerConfigReload()
+				continue
+			}
+			if sig == PauseStateSignal {
+				TriggerPauseStateCheck()
+				continue
+			}
+			gracefulShutdown = sig == GracefulShutdownSignal
+			cancel()
+			return
+		}
 	}()
 	return ctx, cancel
 }
 
+// TriggerConfigReload triggers reloading the config internally.
+func TriggerConfigReload() {
+	select {
+	case reloadc <- struct{}{}:
+	default:
+	}
+}
+
+// HandleConfigReloads reloads the config whenever a reload is triggered, applies the reloadable
+// values and lets the services know about the new config.
+func HandleConfigReloads(
+	ctx context.Context, logger *log.Entry, cfg config.Config,
+	getConfig func() (config.Config, error), services []Service,
+) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reloadc:
+		}
+
+		updatedCfg, err := getConfig()
+		if err != nil {
+			logger.WithError(err).Error("failed to reload config - keeping the current config")
+			continue
+		}
+		report := config.ApplyReloadable(&cfg, updatedCfg)
+		if lvl, err := log.ParseLevel(cfg.Log.Level); err == nil {
+			log.SetLevel(lvl)
+		}
+		for _, service := range services {
+			if reloader, ok := service.(ConfigReloader); ok {
+				reloader.ReloadConfig(cfg, report)
+			}
+		}
+		logger.WithFields(log.Fields{
+			"applied":         report.Applied,
+			"requiresRestart": report.RequiresRestart,
+		}).Info("reloaded config")
+	}
+}
+
 // InterruptMainContext interrupts by sending a fake interrup signal from within runtime.
 func InterruptMainContext() {
 	select {
@@ -2,13 +2,16 @@ package services
 
 import (
 	"context"
+	"errors"
 	"os"
 	"syscall"
 	"testing"
 	"time"
 
+	"github.com/forta-network/forta-node/config"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -51,3 +54,76 @@ func TestSigIntSignalCancelsService(t *testing.T) {
 	assert.Error(t, err, context.Canceled)
 	assert.True(t, svc.cancelled)
 }
+
+type testReloader struct {
+	TestService
+	reloads chan *config.ReloadReport
+	cfg     config.Config
+}
+
+func (tr *testReloader) ReloadConfig(cfg config.Config, report *config.ReloadReport) {
+	tr.cfg = cfg
+	tr.reloads <- report
+}
+
+func TestSigHupReloadsConfig(t *testing.T) {
+	r := require.New(t)
+
+	sigc = make(chan os.Signal, 1)
+	reloadc = make(chan struct{}, 1)
+	ctx, cancel := InitMainContext()
+	defer cancel()
+
+	logLevel := logrus.GetLevel()
+	defer logrus.SetLevel(logLevel)
+
+	var cfg config.Config
+	cfg.ChainID = 1
+	cfg.Log.Level = "info"
+	updatedCfg := cfg
+	updatedCfg.Log.Level = "debug"
+	updatedCfg.ChainID = 137
+
+	reloader := &testReloader{reloads: make(chan *config.ReloadReport, 1)}
+	go HandleConfigReloads(ctx, logrus.NewEntry(logrus.StandardLogger()), cfg, func() (config.Config, error) {
+		return updatedCfg, nil
+	}, []Service{&TestService{ctx: ctx}, reloader})
+
+	sigc <- syscall.SIGHUP
+
+	select {
+	case report := <-reloader.reloads:
+		r.Equal([]string{"log.level"}, report.Applied)
+		r.Equal([]string{"chainId"}, report.RequiresRestart)
+		r.Equal("debug", reloader.cfg.Log.Level)
+		r.Equal(1, reloader.cfg.ChainID)
+		r.Equal(logrus.DebugLevel, logrus.GetLevel())
+	case <-time.After(time.Second * 5):
+		r.FailNow("config was not reloaded")
+	}
+
+	// the reload signal should not stop the services
+	r.NoError(ctx.Err())
+}
+
+func TestHandleConfigReloads_Error(t *testing.T) {
+	reloadc = make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	reloader := &testReloader{reloads: make(chan *config.ReloadReport, 1)}
+	done := make(chan struct{})
+	go func() {
+		HandleConfigReloads(ctx, logrus.NewEntry(logrus.StandardLogger()), config.Config{}, func() (config.Config, error) {
+			return config.Config{}, errors.New("invalid config")
+		}, []Service{reloader})
+		close(done)
+	}()
+
+	TriggerConfigReload()
+	time.Sleep(time.Millisecond * 100)
+	cancel()
+	<-done
+
+	// the services are not reloaded with a bad config
+	assert.Len(t, reloader.reloads, 0)
+}
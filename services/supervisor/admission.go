@@ -0,0 +1,156 @@
+package supervisor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients/messaging"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+// initAdmission sets the max bot count by the host resources if the admission control is enabled.
+func (sup *SupervisorService) initAdmission() {
+	resourcesCfg := sup.config.Config.ResourcesConfig
+	if !resourcesCfg.Admission.Enable {
+		return
+	}
+	var (
+		hostMemory int64
+		hostCPUs   int
+	)
+	hostResources, err := sup.client.GetHostResources(sup.ctx)
+	if err != nil {
+		log.WithError(err).Warn("failed to get the host resources - only the configured max bot count applies")
+	} else {
+		hostMemory, hostCPUs = hostResources.Memory, hostResources.CPUs
+	}
+	sup.maxBots = config.GetMaxBots(resourcesCfg, hostMemory, hostCPUs)
+	log.WithFields(log.Fields{
+		"maxBots":    sup.maxBots,
+		"hostMemory": hostMemory,
+		"hostCpus":   hostCPUs,
+	}).Info("bot admission control is enabled")
+}
+
+// admitAgents admits the agents which fit the remaining capacity by priority and returns the rest
+// as rejected. The running agents do not need any capacity. It expects the lock to be held.
+func (sup *SupervisorService) admitAgents(payload messaging.AgentPayload) (admitted, rejected messaging.AgentPayload) {
+	if sup.maxBots == 0 {
+		return payload, nil
+	}
+
+	var (
+		running int
+		newBots messaging.AgentPayload
+	)
+	for _, container := range sup.containers {
+		if container.IsAgent {
+			running++
+		}
+	}
+	for _, agent := range payload {
+		if _, ok := sup.getContainerUnsafe(agent.ContainerName()); ok {
+			admitted = append(admitted, agent)
+			continue
+		}
+		newBots = append(newBots, agent)
+	}
+
+	admission := sup.config.Config.ResourcesConfig.Admission
+	sort.SliceStable(newBots, func(i, j int) bool {
+		return admission.GetBotPriority(newBots[i].ID) > admission.GetBotPriority(newBots[j].ID)
+	})
+	for _, agent := range newBots {
+		if running < sup.maxBots {
+			running++
+			admitted = append(admitted, agent)
+			delete(sup.rejectedBots, agent.ContainerName())
+			continue
+		}
+		rejected = append(rejected, agent)
+		sup.rejectedBots[agent.ContainerName()] = agent
+	}
+	return admitted, rejected
+}
+
+// reportRejectedAgents logs and sends metrics for the bots which did not fit the capacity. The agent pool
+// is notified about the rejected agents so that it does not wait for them.
+func (sup *SupervisorService) reportRejectedAgents(rejected messaging.AgentPayload) {
+	if len(rejected) == 0 {
+		return
+	}
+	var agentMetrics []*protocol.AgentMetric
+	for _, agent := range rejected {
+		agentLogger(agent).WithField("maxBots", sup.maxBots).Warn("not enough capacity to run the bot - rejected")
+		agentMetrics = append(agentMetrics, metrics.CreateAgentMetric(agent.ID, metrics.MetricAgentRejected, 1))
+	}
+	sup.getMsgClient().Publish(messaging.SubjectAgentsStatusRefused, rejected)
+	metrics.SendAgentMetrics(sup.getMsgClient(), agentMetrics)
+}
+
+// readmitRejectedAgents runs the rejected bots again after some bots stop, so that they start
+// as soon as there is capacity for them.
+func (sup *SupervisorService) readmitRejectedAgents() {
+	sup.mu.RLock()
+	var pending messaging.AgentPayload
+	for _, agent := range sup.rejectedBots {
+		pending = append(pending, agent)
+	}
+	sup.mu.RUnlock()
+	if len(pending) == 0 {
+		return
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].ContainerName() < pending[j].ContainerName()
+	})
+	log.WithField("bots", len(pending)).Info("trying to admit the rejected bots again")
+	if err := sup.handleAgentRun(pending); err != nil {
+		log.WithError(err).Error("failed to run the rejected bots")
+	}
+}
+
+// admissionReports expects the lock to be held.
+func (sup *SupervisorService) admissionReports() health.Reports {
+	if sup.maxBots == 0 {
+		return nil
+	}
+	var (
+		running int
+		botIDs  []string
+	)
+	for _, container := range sup.containers {
+		if container.IsAgent {
+			running++
+		}
+	}
+	seen := make(map[string]bool)
+	for _, agent := range sup.rejectedBots {
+		if !seen[agent.ID] {
+			seen[agent.ID] = true
+			botIDs = append(botIDs, agent.ID)
+		}
+	}
+	sort.Strings(botIDs)
+
+	rejectedReport := &health.Report{
+		Name:    "agents.rejected",
+		Status:  health.StatusOK,
+		Details: strings.Join(botIDs, ", "),
+	}
+	if len(botIDs) > 0 {
+		rejectedReport.Status = health.StatusFailing
+	}
+	return health.Reports{
+		{
+			Name:    "agents.capacity",
+			Status:  health.StatusInfo,
+			Details: fmt.Sprintf("%d/%d", running, sup.maxBots),
+		},
+		rejectedReport,
+	}
+}
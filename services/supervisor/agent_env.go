@@ -0,0 +1,45 @@
+package supervisor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+// agentSecrets reads the secrets of the bot from the secrets dir by the env var names.
+func (sup *SupervisorService) agentSecrets(agent config.AgentConfig) (map[string]string, error) {
+	botEnv := sup.config.Config.AgentEnv.GetBotEnv(agent.ID)
+	if len(botEnv.Secrets) == 0 {
+		return nil, nil
+	}
+	secrets := make(map[string]string)
+	for name, fileName := range botEnv.Secrets {
+		// the secrets can only be read from the secrets dir
+		if filepath.Base(fileName) != fileName {
+			return nil, fmt.Errorf("invalid secret file name '%s' for env var %s", fileName, name)
+		}
+		b, err := ioutil.ReadFile(path.Join(sup.config.Config.SecretsDir(), fileName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the secret of env var %s: %v", name, err)
+		}
+		secrets[name] = strings.TrimRight(string(b), "\r\n")
+	}
+	return secrets, nil
+}
+
+// withBotEnv adds the extra env vars and the secrets of the bot to the env vars of the node. The env vars
+// of the node are kept if the bot config uses the same names.
+func (sup *SupervisorService) withBotEnv(env map[string]string, agent config.AgentConfig, secrets map[string]string) map[string]string {
+	for _, extra := range []map[string]string{sup.config.Config.AgentEnv.GetBotEnv(agent.ID).Vars, secrets} {
+		for name, value := range extra {
+			if _, ok := env[name]; !ok {
+				env[name] = value
+			}
+		}
+	}
+	return env
+}
@@ -0,0 +1,101 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+var errNoFreeSubnet = errors.New("no free subnets left in the address pools")
+
+// createAgentNetwork creates the network of the agent, or the network shared by all agents in the shared mode.
+// The network is reused if it exists.
+func (sup *SupervisorService) createAgentNetwork(ctx context.Context, agent config.AgentConfig) (string, error) {
+	nwCfg := sup.config.Config.AgentNetwork
+	name := agent.ContainerName()
+	if nwCfg.Shared {
+		name = config.DockerAgentsNetworkName
+	}
+	if len(nwCfg.AddressPools) == 0 {
+		return sup.client.CreatePublicNetwork(ctx, name)
+	}
+
+	usedSubnets, err := sup.client.GetNetworkSubnets(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the network subnets: %v", err)
+	}
+	usedSubnets = append(usedSubnets, nwCfg.ExcludedSubnets...)
+	usedSubnets = append(usedSubnets, hostRouteSubnets()...)
+	subnet, err := allocateSubnet(nwCfg.AddressPools, parseSubnets(usedSubnets))
+	if err != nil {
+		return "", fmt.Errorf("failed to allocate a subnet for the agent network: %v", err)
+	}
+	log.WithFields(log.Fields{
+		"network": name,
+		"subnet":  subnet,
+	}).Debug("allocated a subnet for the agent network")
+	return sup.client.CreatePublicNetworkWithSubnet(ctx, name, subnet)
+}
+
+// hostRouteSubnets returns the subnets of the host routes which the runner detected.
+func hostRouteSubnets() []string {
+	hostRoutes := os.Getenv(config.EnvHostRoutes)
+	if len(hostRoutes) == 0 {
+		return nil
+	}
+	return strings.Split(hostRoutes, ",")
+}
+
+func parseSubnets(subnets []string) []*net.IPNet {
+	var parsed []*net.IPNet
+	for _, subnet := range subnets {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(subnet))
+		if err != nil {
+			log.WithError(err).WithField("subnet", subnet).Warn("ignoring invalid subnet")
+			continue
+		}
+		parsed = append(parsed, ipNet)
+	}
+	return parsed
+}
+
+// allocateSubnet returns the first subnet from the pools which does not overlap with the used subnets.
+func allocateSubnet(pools []config.AgentAddressPoolConfig, used []*net.IPNet) (string, error) {
+	for _, pool := range pools {
+		_, base, err := net.ParseCIDR(pool.Base)
+		if err != nil || base.IP.To4() == nil {
+			return "", fmt.Errorf("invalid address pool: %s", pool.Base)
+		}
+		baseSize, _ := base.Mask.Size()
+		if pool.Size < baseSize {
+			return "", fmt.Errorf("the subnet size /%d is larger than the address pool %s", pool.Size, pool.Base)
+		}
+		start := binary.BigEndian.Uint32(base.IP.To4())
+		count := uint32(1) << (pool.Size - baseSize)
+		for i := uint32(0); i < count; i++ {
+			ip := make(net.IP, net.IPv4len)
+			binary.BigEndian.PutUint32(ip, start+i<<(32-pool.Size))
+			candidate := &net.IPNet{IP: ip, Mask: net.CIDRMask(pool.Size, 32)}
+			if !overlapsAny(candidate, used) {
+				return candidate.String(), nil
+			}
+		}
+	}
+	return "", errNoFreeSubnet
+}
+
+func overlapsAny(subnet *net.IPNet, others []*net.IPNet) bool {
+	for _, other := range others {
+		if subnet.Contains(other.IP) || other.Contains(subnet.IP) {
+			return true
+		}
+	}
+	return false
+}
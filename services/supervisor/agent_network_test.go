@@ -0,0 +1,40 @@
+package supervisor
+
+import (
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocateSubnet(t *testing.T) {
+	r := require.New(t)
+
+	pools := []config.AgentAddressPoolConfig{
+		{Base: "10.200.0.0/26", Size: 28},
+		{Base: "10.201.0.0/28", Size: 28},
+	}
+
+	subnet, err := allocateSubnet(pools, nil)
+	r.NoError(err)
+	r.Equal("10.200.0.0/28", subnet)
+
+	// a larger route which covers a part of the pool
+	used := parseSubnets([]string{"10.200.0.0/27", "10.200.0.48/28", "invalid"})
+	subnet, err = allocateSubnet(pools, used)
+	r.NoError(err)
+	r.Equal("10.200.0.32/28", subnet)
+
+	// a route which covers the whole pool
+	used = parseSubnets([]string{"10.200.0.0/16"})
+	subnet, err = allocateSubnet(pools, used)
+	r.NoError(err)
+	r.Equal("10.201.0.0/28", subnet)
+
+	used = parseSubnets([]string{"10.0.0.0/8"})
+	_, err = allocateSubnet(pools, used)
+	r.ErrorIs(err, errNoFreeSubnet)
+
+	_, err = allocateSubnet([]config.AgentAddressPoolConfig{{Base: "10.200.0.0/28", Size: 24}}, nil)
+	r.Error(err)
+}
@@ -0,0 +1,49 @@
+package supervisor
+
+import (
+	"fmt"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/tlsutils"
+)
+
+// initAgentTLS creates the authority which issues the container certificates if mutual TLS
+// is enabled between the node and the agents.
+func (sup *SupervisorService) initAgentTLS() error {
+	if !sup.config.Config.AgentTLS.Enable {
+		return nil
+	}
+	authority, err := tlsutils.NewAuthority()
+	if err != nil {
+		return fmt.Errorf("failed to initialize agent tls: %v", err)
+	}
+	sup.agentTLS = authority
+	return nil
+}
+
+// withTLSFiles issues a certificate for the container in the given role and adds the TLS files to the
+// container files.
+func (sup *SupervisorService) withTLSFiles(files map[string][]byte, role tlsutils.Role, name string, dnsNames ...string) (map[string][]byte, error) {
+	if sup.agentTLS == nil {
+		return files, nil
+	}
+	tlsFiles, err := sup.agentTLS.ContainerFiles(role, name, dnsNames...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue the certificate for %s: %v", name, err)
+	}
+	for fileName, b := range files {
+		tlsFiles[fileName] = b
+	}
+	return tlsFiles, nil
+}
+
+// withTLSEnv adds the TLS file paths to the agent env vars.
+func (sup *SupervisorService) withTLSEnv(env map[string]string) map[string]string {
+	if sup.agentTLS == nil {
+		return env
+	}
+	env[config.EnvFortaTLSCert] = config.DefaultContainerTLSCertPath
+	env[config.EnvFortaTLSKey] = config.DefaultContainerTLSKeyPath
+	env[config.EnvFortaTLSCA] = config.DefaultContainerTLSCAPath
+	return env
+}
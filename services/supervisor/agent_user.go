@@ -0,0 +1,58 @@
+package supervisor
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// rootRequiredErrors are the log messages which show that an agent image probably requires root.
+var rootRequiredErrors = []string{"permission denied", "operation not permitted", "eacces", "eperm"}
+
+// checkUsernsRemap checks if the Docker daemon remaps the user namespaces of the containers.
+func (sup *SupervisorService) checkUsernsRemap() error {
+	enabled, err := sup.client.IsUsernsRemapEnabled(sup.ctx)
+	if err != nil {
+		log.WithError(err).Warn("failed to check the user namespace remapping")
+	}
+	sup.usernsRemap = enabled
+	if enabled {
+		return nil
+	}
+	if sup.config.Config.AgentUser.RequireUsernsRemap {
+		return errors.New("agentUser.requireUsernsRemap is enabled but the docker daemon does not remap the user namespaces (see userns-remap in the docker daemon config)")
+	}
+	log.Warn("the docker daemon does not remap the user namespaces - the agents run as a non-root user only")
+	return nil
+}
+
+// checkAgentRequiresRoot logs a clear error if the agent container which was not running as root
+// exited with an error that looks like a permission problem.
+func (sup *SupervisorService) checkAgentRequiresRoot(knownContainer *Container, exitCode int) {
+	if exitCode == 0 || knownContainer.AgentConfig == nil || config.IsRootUser(knownContainer.Config.User) {
+		return
+	}
+	logs, err := sup.client.GetContainerLogs(sup.ctx, knownContainer.ID, "20", 2000)
+	if err != nil || !requiresRoot(logs) {
+		return
+	}
+	log.WithFields(log.Fields{
+		"agent": knownContainer.AgentConfig.ID,
+		"user":  knownContainer.Config.User,
+	}).Errorf(
+		"agent exited with a permission error and the image probably requires root - it can be allowed with 'agentUser.bots.%s: root' at your own risk",
+		knownContainer.AgentConfig.ID,
+	)
+}
+
+func requiresRoot(logs string) bool {
+	logs = strings.ToLower(logs)
+	for _, msg := range rootRequiredErrors {
+		if strings.Contains(logs, msg) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,272 @@
+package supervisor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/clients/messaging"
+	"github.com/forta-network/forta-node/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	containerEventsRetryInterval = time.Second * 10
+	// an OOM kill makes the report fail for this long
+	recentOOMKillPeriod = time.Minute * 10
+)
+
+// containerEventTracker keeps the OOM kill, exit and restart history of the containers by name
+// so that the history survives the containers being replaced.
+type containerEventTracker struct {
+	containers map[string]*containerEvents
+	mu         sync.RWMutex
+}
+
+type containerEvents struct {
+	oomKills     int
+	lastOOMKill  time.Time
+	restarts     int
+	exited       bool
+	lastExitCode int
+	lastExit     time.Time
+	healthStatus string
+}
+
+func newContainerEventTracker() *containerEventTracker {
+	return &containerEventTracker{containers: make(map[string]*containerEvents)}
+}
+
+// Track records the container event and tells if the container was restarted.
+func (cet *containerEventTracker) Track(evt *clients.DockerContainerEvent) (restarted bool) {
+	cet.mu.Lock()
+	defer cet.mu.Unlock()
+
+	events, ok := cet.containers[evt.ContainerName]
+	if !ok {
+		events = &containerEvents{}
+		cet.containers[evt.ContainerName] = events
+	}
+	switch evt.Action {
+	case clients.DockerEventOOM:
+		events.oomKills++
+		events.lastOOMKill = evt.Time
+	case clients.DockerEventDie:
+		events.exited = true
+		events.lastExitCode = evt.ExitCode()
+		events.lastExit = evt.Time
+	case clients.DockerEventStart:
+		// starting again after an exit is a restart by docker or the supervisor
+		// and the restart event which comes after this is not counted again
+		if events.exited {
+			events.restarts++
+			restarted = true
+		}
+	case clients.DockerEventHealthStatus:
+		events.healthStatus = evt.HealthStatus()
+	}
+	return
+}
+
+// Health implements the health.Reporter interface.
+func (cet *containerEventTracker) Health() health.Reports {
+	cet.mu.RLock()
+	defer cet.mu.RUnlock()
+
+	names := make([]string, 0, len(cet.containers))
+	for name := range cet.containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	oomStatus := health.StatusOK
+	var oomKills, restarts, exits, unhealthy []string
+	for _, name := range names {
+		events := cet.containers[name]
+		if events.oomKills > 0 {
+			oomKills = append(oomKills, fmt.Sprintf("%s: %d", name, events.oomKills))
+			if time.Since(events.lastOOMKill) < recentOOMKillPeriod {
+				oomStatus = health.StatusFailing
+			}
+		}
+		if events.restarts > 0 {
+			restarts = append(restarts, fmt.Sprintf("%s: %d", name, events.restarts))
+		}
+		if events.exited {
+			exits = append(exits, fmt.Sprintf(
+				"%s: exit code %d at %s", name, events.lastExitCode, events.lastExit.UTC().Format(time.RFC3339),
+			))
+		}
+		if events.healthStatus == "unhealthy" {
+			unhealthy = append(unhealthy, name)
+		}
+	}
+	unhealthyStatus := health.StatusOK
+	if len(unhealthy) > 0 {
+		unhealthyStatus = health.StatusFailing
+	}
+
+	return health.Reports{
+		&health.Report{
+			Name:    "containers.oom-kills",
+			Status:  oomStatus,
+			Details: strings.Join(oomKills, ", "),
+		},
+		&health.Report{
+			Name:    "containers.restarts",
+			Status:  health.StatusInfo,
+			Details: strings.Join(restarts, ", "),
+		},
+		&health.Report{
+			Name:    "containers.last-exits",
+			Status:  health.StatusInfo,
+			Details: strings.Join(exits, ", "),
+		},
+		&health.Report{
+			Name:    "containers.unhealthy",
+			Status:  unhealthyStatus,
+			Details: strings.Join(unhealthy, ", "),
+		},
+	}
+}
+
+// watchContainerEvents tracks the container events and keeps reconnecting to the event stream.
+func (sup *SupervisorService) watchContainerEvents() {
+	for {
+		eventCh, errCh := sup.client.ContainerEvents(sup.ctx)
+		err := sup.handleContainerEvents(eventCh, errCh)
+		if sup.ctx.Err() != nil {
+			return
+		}
+		log.WithError(err).Warn("container event stream failed - retrying")
+		select {
+		case <-sup.ctx.Done():
+			return
+		case <-time.After(containerEventsRetryInterval):
+		}
+	}
+}
+
+func (sup *SupervisorService) handleContainerEvents(eventCh <-chan *clients.DockerContainerEvent, errCh <-chan error) error {
+	for {
+		select {
+		case <-sup.ctx.Done():
+			return sup.ctx.Err()
+		case err := <-errCh:
+			return err
+		case evt := <-eventCh:
+			sup.handleContainerEvent(evt)
+		}
+	}
+}
+
+func (sup *SupervisorService) handleContainerEvent(evt *clients.DockerContainerEvent) {
+	restarted := sup.containerEvents.Track(evt)
+	var serviceMetrics []*protocol.AgentMetric
+	if sup.serviceHistory != nil {
+		serviceMetrics = sup.serviceHistory.Track(evt, restarted)
+	}
+
+	// the message client is not available until the supervisor starts nats
+	msgClient := sup.getMsgClient()
+	if msgClient != nil {
+		publishContainerEvent(msgClient, evt)
+		metrics.SendAgentMetrics(msgClient, serviceMetrics)
+	}
+
+	logger := log.WithFields(log.Fields{
+		"container": evt.ContainerName,
+		"event":     evt.Action,
+	})
+	botID := evt.Attributes[clients.DockerLabelFortaBotID]
+	var agentMetric *protocol.AgentMetric
+	switch evt.Action {
+	case clients.DockerEventOOM:
+		logger.Warn("container was killed because it ran out of memory")
+		agentMetric = metrics.CreateAgentMetric(botID, metrics.MetricContainerOOMKill, 1)
+	case clients.DockerEventDie:
+		logger.WithField("exitCode", evt.ExitCode()).Info("container exited")
+		agentMetric = metrics.CreateAgentMetric(botID, metrics.MetricContainerExit, float64(evt.ExitCode()))
+		go sup.checkExitedContainer(evt.ContainerID)
+	case clients.DockerEventStart:
+		if !restarted {
+			return
+		}
+		logger.Info("container was restarted")
+		agentMetric = metrics.CreateAgentMetric(botID, metrics.MetricContainerRestart, 1)
+		if len(botID) > 0 {
+			go sup.relimitAgentBandwidth(evt.ContainerID)
+		}
+	case clients.DockerEventHealthStatus:
+		if evt.HealthStatus() == "unhealthy" {
+			logger.Warn("container is unhealthy")
+		}
+		return
+	default:
+		return
+	}
+
+	// only the bot containers have metrics
+	if len(botID) == 0 || msgClient == nil {
+		return
+	}
+	metrics.SendAgentMetrics(msgClient, []*protocol.AgentMetric{agentMetric})
+}
+
+// publishContainerEvent lets the other services follow the container lifecycle without polling docker.
+func publishContainerEvent(msgClient clients.MessageClient, evt *clients.DockerContainerEvent) {
+	var subject string
+	switch evt.Action {
+	case clients.DockerEventStart:
+		subject = messaging.SubjectContainerStart
+	case clients.DockerEventDie:
+		subject = messaging.SubjectContainerDie
+	case clients.DockerEventOOM:
+		subject = messaging.SubjectContainerOOM
+	case clients.DockerEventHealthStatus:
+		subject = messaging.SubjectContainerHealthStatus
+	default:
+		return
+	}
+	payload := &messaging.ContainerEventPayload{
+		ContainerID:   evt.ContainerID,
+		ContainerName: evt.ContainerName,
+		BotID:         evt.Attributes[clients.DockerLabelFortaBotID],
+		HealthStatus:  evt.HealthStatus(),
+		Time:          evt.Time,
+	}
+	if evt.Action == clients.DockerEventDie {
+		payload.ExitCode = evt.ExitCode()
+	}
+	msgClient.Publish(subject, payload)
+}
+
+// checkExitedContainer handles the exited container right away instead of waiting for the next health check.
+func (sup *SupervisorService) checkExitedContainer(containerID string) {
+	// the containers exit while shutting down
+	if sup.ctx.Err() != nil {
+		return
+	}
+	sup.mu.RLock()
+	defer sup.mu.RUnlock()
+
+	for _, knownContainer := range sup.containers {
+		if knownContainer.ID != containerID {
+			continue
+		}
+		foundContainer, err := sup.client.GetContainerByID(sup.ctx, containerID)
+		if err != nil {
+			log.WithError(err).WithField("name", knownContainer.Name).Warn("failed to get the exited container")
+			return
+		}
+		if err := sup.ensureUp(knownContainer, foundContainer); err != nil {
+			log.WithError(err).WithField("name", knownContainer.Name).Error("failed to handle the exited container")
+		}
+		return
+	}
+}
@@ -0,0 +1,139 @@
+package supervisor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/clients/messaging"
+	mock_clients "github.com/forta-network/forta-node/clients/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+const testEventContainerName = "forta-agent-0x1234"
+
+func testContainerEvent(action string, attributes map[string]string) *clients.DockerContainerEvent {
+	if attributes == nil {
+		attributes = make(map[string]string)
+	}
+	attributes[clients.DockerLabelFortaBotID] = "0x1234"
+	return &clients.DockerContainerEvent{
+		Action:        action,
+		ContainerName: testEventContainerName,
+		Attributes:    attributes,
+		Time:          time.Now(),
+	}
+}
+
+func TestContainerEventTracker(t *testing.T) {
+	r := require.New(t)
+
+	cet := newContainerEventTracker()
+	r.False(cet.Track(testContainerEvent(clients.DockerEventStart, nil)))
+	r.False(cet.Track(testContainerEvent(clients.DockerEventOOM, nil)))
+	r.False(cet.Track(testContainerEvent(clients.DockerEventDie, map[string]string{"exitCode": "137"})))
+	r.True(cet.Track(testContainerEvent(clients.DockerEventStart, nil)))
+	r.False(cet.Track(testContainerEvent(clients.DockerEventRestart, nil)))
+
+	reports := cet.Health()
+	oomKills, ok := reports.GetByName("containers.oom-kills")
+	r.True(ok)
+	r.Equal("failing", string(oomKills.Status))
+	r.Equal(testEventContainerName+": 1", oomKills.Details)
+
+	restarts, ok := reports.GetByName("containers.restarts")
+	r.True(ok)
+	r.Equal(testEventContainerName+": 1", restarts.Details)
+
+	exits, ok := reports.GetByName("containers.last-exits")
+	r.True(ok)
+	r.Contains(exits.Details, testEventContainerName+": exit code 137")
+}
+
+func TestHandleContainerEvents(t *testing.T) {
+	r := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	msgClient := mock_clients.NewMockMessageClient(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	sup := &SupervisorService{
+		ctx:             ctx,
+		msgClient:       msgClient,
+		containerEvents: newContainerEventTracker(),
+	}
+
+	eventCh := make(chan *clients.DockerContainerEvent)
+	errCh := make(chan error, 1)
+	done := make(chan error)
+	go func() {
+		done <- sup.handleContainerEvents(eventCh, errCh)
+	}()
+
+	// the bot metrics are sent for the oom kill and the exit but not for the first start
+	msgClient.EXPECT().PublishProto(gomock.Any(), gomock.Any()).Times(2)
+	// all of the events are published for the other services
+	var diePayload *messaging.ContainerEventPayload
+	msgClient.EXPECT().Publish(messaging.SubjectContainerStart, gomock.Any())
+	msgClient.EXPECT().Publish(messaging.SubjectContainerOOM, gomock.Any())
+	msgClient.EXPECT().Publish(messaging.SubjectContainerDie, gomock.Any()).Do(func(subject string, payload interface{}) {
+		diePayload = payload.(*messaging.ContainerEventPayload)
+	})
+	msgClient.EXPECT().Publish(messaging.SubjectContainerHealthStatus, gomock.Any())
+	eventCh <- testContainerEvent(clients.DockerEventStart, nil)
+	eventCh <- testContainerEvent(clients.DockerEventOOM, nil)
+	eventCh <- testContainerEvent(clients.DockerEventDie, map[string]string{"exitCode": "137"})
+	eventCh <- testContainerEvent(clients.DockerEventHealthStatus, map[string]string{"healthStatus": "unhealthy"})
+	cancel()
+	r.ErrorIs(<-done, context.Canceled)
+
+	r.Equal(1, sup.containerEvents.containers[testEventContainerName].oomKills)
+	r.Equal(testEventContainerName, diePayload.ContainerName)
+	r.Equal("0x1234", diePayload.BotID)
+	r.Equal(137, diePayload.ExitCode)
+	unhealthy, ok := sup.containerEvents.Health().GetByName("containers.unhealthy")
+	r.True(ok)
+	r.Equal(testEventContainerName, unhealthy.Details)
+}
+
+func TestCheckExitedContainerWithHealthCheck(t *testing.T) {
+	r := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	dockerClient := mock_clients.NewMockDockerClient(ctrl)
+	knownContainer := &Container{
+		DockerContainer: clients.DockerContainer{Name: "forta-scanner", ID: "scanner-id"},
+	}
+	sup := &SupervisorService{
+		ctx:        context.Background(),
+		client:     dockerClient,
+		containers: []*Container{knownContainer},
+	}
+
+	// the container is started only once when it is handled by the event and the health check together
+	exited := &types.Container{ID: "scanner-id", State: "exited"}
+	dockerClient.EXPECT().GetContainerByID(gomock.Any(), "scanner-id").Return(exited, nil)
+	gomock.InOrder(
+		dockerClient.EXPECT().InspectContainer(gomock.Any(), "scanner-id").Return(testContainerDetails("exited", 1), nil),
+		dockerClient.EXPECT().InspectContainer(gomock.Any(), "scanner-id").Return(testContainerDetails("running", 0), nil),
+	)
+	dockerClient.EXPECT().StartContainer(gomock.Any(), gomock.Any()).Return(&knownContainer.DockerContainer, nil).Times(1)
+
+	done := make(chan struct{})
+	go func() {
+		sup.checkExitedContainer("scanner-id")
+		close(done)
+	}()
+	r.NoError(sup.ensureUp(knownContainer, exited))
+	<-done
+}
+
+func testContainerDetails(status string, exitCode int) *types.ContainerJSON {
+	return &types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			State: &types.ContainerState{Status: status, ExitCode: exitCode},
+		},
+	}
+}
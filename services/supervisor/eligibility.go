@@ -0,0 +1,118 @@
+package supervisor
+
+import (
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/registry"
+	"github.com/forta-network/forta-node/store"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultEligibilityCheckInterval = time.Minute * 10
+
+type eligibilityState struct {
+	problems   []string
+	membership string
+	err        error
+	checked    bool
+}
+
+func (sup *SupervisorService) checkEligibility() {
+	var regClient registry.Client
+	ticker := time.NewTicker(defaultEligibilityCheckInterval)
+	defer ticker.Stop()
+	for {
+		if regClient == nil {
+			regClient = sup.createEligibilityRegistryClient()
+		}
+		if regClient != nil {
+			sup.doEligibilityCheck(regClient)
+		}
+		select {
+		case <-sup.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// createEligibilityRegistryClient creates the registry client or returns nil so the next tick can retry.
+func (sup *SupervisorService) createEligibilityRegistryClient() registry.Client {
+	regClient, err := store.GetRegistryClient(sup.ctx, sup.config.Config, registry.ClientConfig{
+		JsonRpcUrl: sup.config.Config.Registry.JsonRpc.Url,
+		ENSAddress: sup.config.Config.ENSConfig.ContractAddress,
+		Name:       "supervisor-registry-client",
+	})
+	if err != nil {
+		log.WithError(err).Error("failed to create the registry client for eligibility checks - will retry")
+		sup.setEligibility(nil, err)
+		return nil
+	}
+	return regClient
+}
+
+func (sup *SupervisorService) doEligibilityCheck(regClient registry.Client) {
+	scannerAddr := sup.config.Key.Address.Hex()
+	eligibility, err := store.CheckScannerEligibility(
+		regClient, scannerAddr, int64(sup.config.Config.ChainID), sup.config.Config.ScannerPool,
+	)
+	if err != nil {
+		log.WithError(err).Warn("failed to check scanner eligibility")
+		sup.setEligibility(nil, err)
+		return
+	}
+	problems := eligibility.Problems()
+	for _, problem := range problems {
+		log.WithField("scanner", scannerAddr).Warnf("scanner is not eligible: %s", problem)
+	}
+	sup.setEligibility(eligibility, nil)
+}
+
+func (sup *SupervisorService) setEligibility(eligibility *store.ScannerEligibility, err error) {
+	sup.eligibilityMu.Lock()
+	defer sup.eligibilityMu.Unlock()
+	state := eligibilityState{err: err, checked: true}
+	if eligibility != nil {
+		state.problems = eligibility.Problems()
+		state.membership = eligibility.Membership()
+	}
+	sup.eligibility = state
+}
+
+func (sup *SupervisorService) eligibilityReport() *health.Report {
+	sup.eligibilityMu.RLock()
+	defer sup.eligibilityMu.RUnlock()
+
+	report := &health.Report{Name: "scanner.eligibility"}
+	switch {
+	case !sup.eligibility.checked:
+		report.Status = health.StatusUnknown
+		report.Details = "not checked"
+	case sup.eligibility.err != nil:
+		report.Status = health.StatusUnknown
+		report.Details = sup.eligibility.err.Error()
+	case len(sup.eligibility.problems) > 0:
+		report.Status = health.StatusFailing
+		report.Details = strings.Join(sup.eligibility.problems, "; ")
+	default:
+		report.Status = health.StatusOK
+		report.Details = "eligible"
+	}
+	return report
+}
+
+// poolReport shows the pool membership and the stake allocation of the scanner.
+func (sup *SupervisorService) poolReport() *health.Report {
+	sup.eligibilityMu.RLock()
+	defer sup.eligibilityMu.RUnlock()
+
+	report := &health.Report{Name: "scanner.pool", Status: health.StatusInfo}
+	if len(sup.eligibility.membership) == 0 {
+		report.Details = "unknown"
+		return report
+	}
+	report.Details = sup.eligibility.membership
+	return report
+}
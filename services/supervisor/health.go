@@ -2,9 +2,12 @@ package supervisor
 
 import (
 	"errors"
+	"strconv"
 
+	"github.com/forta-network/forta-core-go/clients/health"
 	"github.com/forta-network/forta-core-go/utils"
 	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/clients/messaging"
 	"github.com/forta-network/forta-node/services"
 
 	"fmt"
@@ -14,8 +17,10 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-const defaultHealthCheckInterval = time.Second * 5
+// the container events trigger the checks of the exited containers so this is only a fallback
+const defaultHealthCheckInterval = time.Second * 30
 const maxAttempts = 10
+const defaultStatusRequestTimeout = time.Second * 2
 
 func (sup *SupervisorService) healthCheck() {
 	ticker := time.NewTicker(defaultHealthCheckInterval)
@@ -76,16 +81,35 @@ func (sup *SupervisorService) ensureUp(knownContainer *Container, foundContainer
 	case "exited":
 		logger := log.WithField("name", knownContainer.Name)
 
+		knownContainer.upMu.Lock()
+		defer knownContainer.upMu.Unlock()
+
 		containerDetails, err := sup.client.InspectContainer(sup.ctx, foundContainer.ID)
 		if err != nil {
 			return err
 		}
+		// the container could be started by the other check while waiting for the lock
+		if containerDetails.State != nil && containerDetails.State.Status != "exited" {
+			return nil
+		}
 		if !knownContainer.IsAgent && containerDetails.State.ExitCode == services.ExitCodeTriggered {
 			logger.Info("detected internal exit trigger - exiting")
 			services.TriggerExit(0)
 			return nil
 		}
 
+		if knownContainer.IsAgent {
+			sup.checkAgentRequiresRoot(knownContainer, containerDetails.State.ExitCode)
+		}
+
+		if !knownContainer.IsAgent && sup.restarts != nil {
+			restart, reason := sup.restarts.ShouldRestart(serviceName(knownContainer.Name), containerDetails.State.ExitCode, time.Now())
+			if !restart {
+				logger.WithField("reason", reason).Error("not restarting exited service container - node is degraded")
+				return nil
+			}
+		}
+
 		logger.Warn("starting exited container")
 		_, err = sup.client.StartContainer(sup.ctx, knownContainer.Config)
 		if err != nil {
@@ -97,3 +121,67 @@ func (sup *SupervisorService) ensureUp(knownContainer *Container, foundContainer
 	}
 	return nil
 }
+
+// serviceStatusReports queries the other services and converts their status to reports.
+func (sup *SupervisorService) serviceStatusReports() health.Reports {
+	var (
+		scannerStatus   messaging.ScannerStatus
+		publisherStatus messaging.PublisherStatus
+		reports         health.Reports
+	)
+
+	// the message client is not available until the supervisor starts nats
+	msgClient := sup.getMsgClient()
+	if msgClient == nil {
+		return health.Reports{
+			&health.Report{
+				Name:    "scanner.status",
+				Status:  health.StatusUnknown,
+				Details: "supervisor is starting",
+			},
+			&health.Report{
+				Name:    "publisher.status",
+				Status:  health.StatusUnknown,
+				Details: "supervisor is starting",
+			},
+		}
+	}
+
+	if err := msgClient.Request(messaging.SubjectScannerStatusRequest, nil, &scannerStatus, defaultStatusRequestTimeout); err != nil {
+		reports = append(reports, &health.Report{
+			Name:    "scanner.status",
+			Status:  health.StatusUnknown,
+			Details: err.Error(),
+		})
+	} else {
+		reports = append(reports, &health.Report{
+			Name:    "scanner.latest-block-input",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatUint(scannerStatus.LatestBlockInput, 10),
+		}, &health.Report{
+			Name:    "scanner.lagging-agents",
+			Status:  health.StatusInfo,
+			Details: strconv.Itoa(scannerStatus.LaggingAgents),
+		})
+	}
+
+	if err := msgClient.Request(messaging.SubjectPublisherStatusRequest, nil, &publisherStatus, defaultStatusRequestTimeout); err != nil {
+		reports = append(reports, &health.Report{
+			Name:    "publisher.status",
+			Status:  health.StatusUnknown,
+			Details: err.Error(),
+		})
+	} else {
+		reports = append(reports, &health.Report{
+			Name:    "publisher.pending-notifications",
+			Status:  health.StatusInfo,
+			Details: strconv.Itoa(publisherStatus.PendingNotifications),
+		}, &health.Report{
+			Name:    "publisher.pending-batches",
+			Status:  health.StatusInfo,
+			Details: strconv.Itoa(publisherStatus.PendingBatches),
+		})
+	}
+
+	return reports
+}
@@ -0,0 +1,106 @@
+package supervisor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/forta-network/forta-node/clients/messaging"
+	log "github.com/sirupsen/logrus"
+)
+
+const heartbeatRequestTimeout = time.Second * 10
+
+// heartbeat is the summary of the node state which is sent to the operator endpoint.
+type heartbeat struct {
+	NodeID          string `json:"nodeId,omitempty"`
+	Version         string `json:"version,omitempty"`
+	ChainID         int    `json:"chainId"`
+	LatestBlock     uint64 `json:"latestBlock"`
+	BlockLagSeconds *int64 `json:"blockLagSeconds,omitempty"`
+	BotCount        int    `json:"botCount"`
+	Timestamp       string `json:"timestamp"`
+}
+
+func (sup *SupervisorService) sendHeartbeats() {
+	cfg := sup.config.Config.Heartbeat
+	ticker := time.NewTicker(time.Duration(cfg.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	httpClient := &http.Client{Timeout: heartbeatRequestTimeout}
+	for {
+		select {
+		case <-sup.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := sup.sendHeartbeat(httpClient)
+		if err != nil {
+			log.WithError(err).Warn("failed to send heartbeat")
+		}
+		sup.lastHeartbeat.Set()
+		sup.lastHeartbeatError.Set(err)
+	}
+}
+
+func (sup *SupervisorService) sendHeartbeat(httpClient *http.Client) error {
+	cfg := sup.config.Config.Heartbeat
+	b, err := json.Marshal(sup.makeHeartbeat(time.Now()))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(sup.ctx, http.MethodPost, cfg.URL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for h, v := range cfg.Headers {
+		req.Header.Set(h, v)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("heartbeat endpoint responded with status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (sup *SupervisorService) makeHeartbeat(now time.Time) *heartbeat {
+	hb := &heartbeat{
+		ChainID:   sup.config.Config.ChainID,
+		Timestamp: now.UTC().Format(time.RFC3339),
+	}
+	if sup.config.Key != nil {
+		hb.NodeID = sup.config.Key.Address.Hex()
+	}
+
+	sup.mu.RLock()
+	hb.Version = sup.releaseVersion
+	for _, container := range sup.containers {
+		if container.IsAgent {
+			hb.BotCount++
+		}
+	}
+	sup.mu.RUnlock()
+
+	msgClient := sup.getMsgClient()
+	if msgClient == nil {
+		return hb
+	}
+	var scannerStatus messaging.ScannerStatus
+	if err := msgClient.Request(messaging.SubjectScannerStatusRequest, nil, &scannerStatus, defaultStatusRequestTimeout); err != nil {
+		log.WithError(err).Debug("failed to get the scanner status for the heartbeat")
+		return hb
+	}
+	hb.LatestBlock = scannerStatus.LatestBlockInput
+	if scannerStatus.LatestBlockTimestamp > 0 {
+		lag := now.Unix() - scannerStatus.LatestBlockTimestamp
+		hb.BlockLagSeconds = &lag
+	}
+	return hb
+}
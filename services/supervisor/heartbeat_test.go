@@ -0,0 +1,69 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/clients/messaging"
+	mock_clients "github.com/forta-network/forta-node/clients/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendHeartbeat(t *testing.T) {
+	r := require.New(t)
+
+	var received heartbeat
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.Equal("secret", req.Header.Get("X-Api-Key"))
+		r.NoError(json.NewDecoder(req.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	msgClient := mock_clients.NewMockMessageClient(gomock.NewController(t))
+	msgClient.EXPECT().Request(messaging.SubjectScannerStatusRequest, nil, gomock.Any(), defaultStatusRequestTimeout).
+		DoAndReturn(func(subject string, payload, response interface{}, timeout time.Duration) error {
+			status := response.(*messaging.ScannerStatus)
+			status.LatestBlockInput = 100
+			status.LatestBlockTimestamp = now.Add(-time.Second * 30).Unix()
+			return nil
+		})
+
+	sup := &SupervisorService{
+		ctx:            context.Background(),
+		msgClient:      msgClient,
+		releaseVersion: "v1.2.3",
+		containers: []*Container{
+			{DockerContainer: clients.DockerContainer{Name: "scanner"}},
+			{DockerContainer: clients.DockerContainer{Name: "bot"}, IsAgent: true},
+		},
+	}
+	sup.config.Config.ChainID = 137
+	sup.config.Config.Heartbeat.URL = server.URL
+	sup.config.Config.Heartbeat.Headers = map[string]string{"X-Api-Key": "secret"}
+
+	r.NoError(sup.sendHeartbeat(server.Client()))
+	r.Equal("v1.2.3", received.Version)
+	r.Equal(137, received.ChainID)
+	r.Equal(uint64(100), received.LatestBlock)
+	r.NotNil(received.BlockLagSeconds)
+	r.InDelta(30, *received.BlockLagSeconds, 2)
+	r.Equal(1, received.BotCount)
+}
+
+func TestSendHeartbeat_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sup := &SupervisorService{ctx: context.Background()}
+	sup.config.Config.Heartbeat.URL = server.URL
+	require.Error(t, sup.sendHeartbeat(server.Client()))
+}
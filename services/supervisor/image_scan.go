@@ -0,0 +1,290 @@
+package supervisor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+)
+
+const (
+	maxImageScanReportSize = 50 * 1024 * 1024
+	// the failed scans are not retried for a while so that a broken scanner does not slow down the bot starts
+	imageScanErrorTTL = time.Minute * 10
+)
+
+var errImageRefused = errors.New("image has vulnerabilities")
+
+var severityLevels = map[string]int{
+	"LOW":      1,
+	"MEDIUM":   2,
+	"HIGH":     3,
+	"CRITICAL": 4,
+}
+
+// trivyReport is the part of the Trivy JSON report which the findings are read from.
+type trivyReport struct {
+	Results []struct {
+		Vulnerabilities []struct {
+			VulnerabilityID string `json:"VulnerabilityID"`
+			Severity        string `json:"Severity"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+type imageScanResult struct {
+	findings  []string
+	err       error
+	scannedAt time.Time
+}
+
+// imageScan is a scan in progress. The result is set before done is closed.
+type imageScan struct {
+	done   chan struct{}
+	result *imageScanResult
+}
+
+// imageScanner scans the bot images with the external scanner hook and keeps the results
+// of the bots to report them in the bot status.
+type imageScanner struct {
+	ctx     context.Context
+	cfg     config.AgentImageScanConfig
+	results map[string]*imageScanResult // by image reference
+	scans   map[string]*imageScan       // by image reference
+	bots    map[string]*imageScanResult // by bot ID
+	mu      sync.RWMutex
+
+	// receives the findings of the scans which the bot starts do not wait for
+	onFindings func(agent config.AgentConfig, findings []string)
+}
+
+func newImageScanner(ctx context.Context, cfg config.AgentImageScanConfig) *imageScanner {
+	return &imageScanner{
+		ctx:     ctx,
+		cfg:     cfg,
+		results: make(map[string]*imageScanResult),
+		scans:   make(map[string]*imageScan),
+		bots:    make(map[string]*imageScanResult),
+	}
+}
+
+// Check scans the image of the bot, unless it was scanned before, and returns an error if the bot
+// should not be started according to the policy. The built images are scanned every time.
+// The scans run in the background with their own timeout and only the refuse policy waits for them.
+func (is *imageScanner) Check(ctx context.Context, agent config.AgentConfig) ([]string, error) {
+	result, ok := is.cachedResult(agent)
+	if ok {
+		return is.evaluate(agent, result)
+	}
+
+	scan := is.startScan(agent.Image)
+	if is.cfg.Policy != config.ImageScanPolicyRefuse {
+		go func() {
+			<-scan.done
+			findings, _ := is.evaluate(agent, scan.result)
+			if len(findings) > 0 && is.onFindings != nil {
+				is.onFindings(agent, findings)
+			}
+		}()
+		return nil, nil
+	}
+	select {
+	case <-scan.done:
+		return is.evaluate(agent, scan.result)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to wait for the image scan of agent %s: %v", agent.ID, ctx.Err())
+	}
+}
+
+// cachedResult returns the result of the previous scan of the image. The failures are retried after a while.
+func (is *imageScanner) cachedResult(agent config.AgentConfig) (*imageScanResult, bool) {
+	if agent.Build != nil {
+		return nil, false
+	}
+	is.mu.RLock()
+	defer is.mu.RUnlock()
+	result, ok := is.results[agent.Image]
+	if !ok || (result.err != nil && time.Since(result.scannedAt) > imageScanErrorTTL) {
+		return nil, false
+	}
+	return result, true
+}
+
+// startScan starts scanning the image or returns the scan which is already in progress.
+func (is *imageScanner) startScan(image string) *imageScan {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	if scan, ok := is.scans[image]; ok {
+		return scan
+	}
+	scan := &imageScan{done: make(chan struct{})}
+	is.scans[image] = scan
+	go func() {
+		result := is.scan(is.ctx, image)
+		is.mu.Lock()
+		is.results[image] = result
+		delete(is.scans, image)
+		is.mu.Unlock()
+		scan.result = result
+		close(scan.done)
+	}()
+	return scan
+}
+
+// evaluate reports the scan result in the bot status and applies the policy.
+func (is *imageScanner) evaluate(agent config.AgentConfig, result *imageScanResult) ([]string, error) {
+	is.mu.Lock()
+	is.bots[agent.ID] = result
+	is.mu.Unlock()
+
+	logger := agentLogger(agent)
+	refuse := is.cfg.Policy == config.ImageScanPolicyRefuse
+	if result.err != nil {
+		logger.WithError(result.err).Warn("failed to scan the agent image")
+		if refuse && is.cfg.RefuseOnError {
+			return nil, fmt.Errorf("failed to scan the image of agent %s: %v", agent.ID, result.err)
+		}
+		return nil, nil
+	}
+	if len(result.findings) == 0 {
+		return nil, nil
+	}
+	logger.WithField("vulnerabilities", strings.Join(result.findings, ", ")).Warn("agent image has vulnerabilities")
+	if refuse {
+		return result.findings, fmt.Errorf("%w: %d with %s severity or higher", errImageRefused, len(result.findings), is.cfg.Severity)
+	}
+	return result.findings, nil
+}
+
+// Remove clears the status of the stopped bot.
+func (is *imageScanner) Remove(botID string) {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	delete(is.bots, botID)
+}
+
+func (is *imageScanner) scan(ctx context.Context, image string) *imageScanResult {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(is.cfg.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	var (
+		b   []byte
+		err error
+	)
+	switch {
+	case len(is.cfg.URL) > 0:
+		b, err = scanImageWithURL(ctx, is.cfg.URL, image)
+	case len(is.cfg.Command) > 0:
+		b, err = scanImageWithCommand(ctx, is.cfg.Command, image)
+	default:
+		err = errors.New("image scan needs either a url or a command")
+	}
+	if err != nil {
+		return &imageScanResult{err: err, scannedAt: time.Now()}
+	}
+	findings, err := parseImageScanReport(b, is.cfg.Severity)
+	return &imageScanResult{findings: findings, err: err, scannedAt: time.Now()}
+}
+
+func scanImageWithURL(ctx context.Context, url, image string) ([]byte, error) {
+	reqBody, _ := json.Marshal(map[string]string{"image": image})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxImageScanReportSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scanner responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	return b, nil
+}
+
+func scanImageWithCommand(ctx context.Context, command []string, image string) ([]byte, error) {
+	args := append(append([]string{}, command[1:]...), image)
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("scanner command failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// parseImageScanReport returns the unique IDs of the vulnerabilities with the given severity or higher.
+func parseImageScanReport(b []byte, severity string) ([]string, error) {
+	var report trivyReport
+	if err := json.Unmarshal(b, &report); err != nil {
+		return nil, fmt.Errorf("failed to decode the scan report: %v", err)
+	}
+	minLevel := severityLevels[strings.ToUpper(severity)]
+	found := make(map[string]bool)
+	var findings []string
+	for _, result := range report.Results {
+		for _, vuln := range result.Vulnerabilities {
+			if severityLevels[strings.ToUpper(vuln.Severity)] < minLevel || found[vuln.VulnerabilityID] {
+				continue
+			}
+			found[vuln.VulnerabilityID] = true
+			findings = append(findings, vuln.VulnerabilityID)
+		}
+	}
+	sort.Strings(findings)
+	return findings, nil
+}
+
+// Health returns the image scan status of the bots.
+func (is *imageScanner) Health() health.Reports {
+	is.mu.RLock()
+	defer is.mu.RUnlock()
+
+	var flagged, failed []string
+	for botID, result := range is.bots {
+		if result.err != nil {
+			failed = append(failed, botID)
+			continue
+		}
+		if len(result.findings) > 0 {
+			flagged = append(flagged, fmt.Sprintf("%s (%s)", botID, strings.Join(result.findings, " ")))
+		}
+	}
+	sort.Strings(flagged)
+	sort.Strings(failed)
+
+	flaggedStatus := health.StatusOK
+	if len(flagged) > 0 {
+		flaggedStatus = health.StatusFailing
+	}
+	return health.Reports{
+		&health.Report{
+			Name:    "agents.image-scan.vulnerable",
+			Status:  flaggedStatus,
+			Details: strings.Join(flagged, ", "),
+		},
+		&health.Report{
+			Name:    "agents.image-scan.failed",
+			Status:  health.StatusInfo,
+			Details: strings.Join(failed, ", "),
+		},
+	}
+}
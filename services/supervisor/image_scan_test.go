@@ -0,0 +1,141 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+const testTrivyReport = `{
+	"Results": [
+		{"Vulnerabilities": [
+			{"VulnerabilityID": "CVE-2", "Severity": "CRITICAL"},
+			{"VulnerabilityID": "CVE-1", "Severity": "HIGH"},
+			{"VulnerabilityID": "CVE-3", "Severity": "LOW"}
+		]},
+		{"Vulnerabilities": [
+			{"VulnerabilityID": "CVE-2", "Severity": "CRITICAL"}
+		]}
+	]
+}`
+
+func TestParseImageScanReport(t *testing.T) {
+	r := require.New(t)
+
+	findings, err := parseImageScanReport([]byte(testTrivyReport), "CRITICAL")
+	r.NoError(err)
+	r.Equal([]string{"CVE-2"}, findings)
+
+	findings, err = parseImageScanReport([]byte(testTrivyReport), "HIGH")
+	r.NoError(err)
+	r.Equal([]string{"CVE-1", "CVE-2"}, findings)
+
+	_, err = parseImageScanReport([]byte("not json"), "HIGH")
+	r.Error(err)
+}
+
+func TestImageScanner(t *testing.T) {
+	r := require.New(t)
+
+	var (
+		scanned []string
+		mu      sync.Mutex
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]string
+		r.NoError(json.NewDecoder(req.Body).Decode(&body))
+		mu.Lock()
+		scanned = append(scanned, body["image"])
+		mu.Unlock()
+		if body["image"] == "bad-image" {
+			w.Write([]byte(testTrivyReport))
+			return
+		}
+		w.Write([]byte(`{"Results": []}`))
+	}))
+	defer server.Close()
+
+	is := newImageScanner(context.Background(), config.AgentImageScanConfig{
+		Enable:         true,
+		URL:            server.URL,
+		Severity:       "CRITICAL",
+		Policy:         config.ImageScanPolicyFlag,
+		TimeoutSeconds: 10,
+	})
+	flaggedCh := make(chan []string, 1)
+	is.onFindings = func(agent config.AgentConfig, findings []string) {
+		flaggedCh <- findings
+	}
+
+	// flagged bots are started without waiting for the scan
+	findings, err := is.Check(context.Background(), config.AgentConfig{ID: "bot1", Image: "bad-image"})
+	r.NoError(err)
+	r.Empty(findings)
+	r.Equal([]string{"CVE-2"}, <-flaggedCh)
+
+	// the results are reused for the same image
+	findings, err = is.Check(context.Background(), config.AgentConfig{ID: "bot1", Image: "bad-image"})
+	r.NoError(err)
+	r.Equal([]string{"CVE-2"}, findings)
+
+	is.cfg.Policy = config.ImageScanPolicyRefuse
+	findings, err = is.Check(context.Background(), config.AgentConfig{ID: "bot2", Image: "good-image"})
+	r.NoError(err)
+	r.Empty(findings)
+
+	reports := is.Health()
+	r.Equal(health.StatusFailing, reports[0].Status)
+	r.Equal("bot1 (CVE-2)", reports[0].Details)
+
+	// the flagged images are refused with the refuse policy
+	_, err = is.Check(context.Background(), config.AgentConfig{ID: "bot3", Image: "bad-image"})
+	r.True(errors.Is(err, errImageRefused))
+	r.Equal([]string{"bad-image", "good-image"}, scanned)
+
+	is.Remove("bot1")
+	is.Remove("bot3")
+	r.Equal(health.StatusOK, is.Health()[0].Status)
+}
+
+func TestImageScanner_Error(t *testing.T) {
+	r := require.New(t)
+
+	var scans int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&scans, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	is := newImageScanner(context.Background(), config.AgentImageScanConfig{
+		Enable:         true,
+		URL:            server.URL,
+		Severity:       "CRITICAL",
+		Policy:         config.ImageScanPolicyRefuse,
+		TimeoutSeconds: 10,
+	})
+	_, err := is.Check(context.Background(), config.AgentConfig{ID: "bot1", Image: "image"})
+	r.NoError(err)
+	r.Equal("bot1", is.Health()[1].Details)
+
+	// the failure is cached for a while
+	is.cfg.RefuseOnError = true
+	_, err = is.Check(context.Background(), config.AgentConfig{ID: "bot1", Image: "image"})
+	r.Error(err)
+	r.Equal(int32(1), atomic.LoadInt32(&scans))
+
+	is.results["image"].scannedAt = time.Now().Add(-imageScanErrorTTL * 2)
+	_, err = is.Check(context.Background(), config.AgentConfig{ID: "bot1", Image: "image"})
+	r.Error(err)
+	r.Equal(int32(2), atomic.LoadInt32(&scans))
+}
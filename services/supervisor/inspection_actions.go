@@ -0,0 +1,245 @@
+package supervisor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/inspect"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// the scanner is not restarted by the inspection actions more often than this
+const inspectionRestartCooldown = time.Minute * 30
+
+// inspectionActionTracker evaluates the inspection action rules against the inspection results.
+// The inspector publishes the latest results repeatedly so the actions are triggered only when
+// an indicator starts failing.
+type inspectionActionTracker struct {
+	rules       []config.InspectionActionConfig
+	failing     map[int]float64 // rule index -> indicator value
+	lastRestart time.Time
+	mu          sync.RWMutex
+}
+
+type triggeredInspectionAction struct {
+	Rule  config.InspectionActionConfig
+	Value float64
+}
+
+func newInspectionActionTracker(rules []config.InspectionActionConfig) *inspectionActionTracker {
+	return &inspectionActionTracker{
+		rules:   rules,
+		failing: make(map[int]float64),
+	}
+}
+
+// HasRules tells if any actions are configured.
+func (iat *inspectionActionTracker) HasRules() bool {
+	return len(iat.rules) > 0
+}
+
+// Evaluate updates the failing rules. It returns the actions which started failing and tells
+// if the node should be paused.
+func (iat *inspectionActionTracker) Evaluate(results *protocol.InspectionResults) (started []*triggeredInspectionAction, pause bool) {
+	iat.mu.Lock()
+	defer iat.mu.Unlock()
+
+	for i, rule := range iat.rules {
+		value, failing := isIndicatorFailing(rule, results)
+		if !failing {
+			delete(iat.failing, i)
+			continue
+		}
+		if rule.Action == config.InspectionActionPause {
+			pause = true
+		}
+		if _, wasFailing := iat.failing[i]; !wasFailing {
+			started = append(started, &triggeredInspectionAction{Rule: rule, Value: value})
+		}
+		iat.failing[i] = value
+	}
+	return
+}
+
+// ShouldRestart tells if the scanner can be restarted now and starts the cool-down.
+func (iat *inspectionActionTracker) ShouldRestart(now time.Time) bool {
+	iat.mu.Lock()
+	defer iat.mu.Unlock()
+
+	if !iat.lastRestart.IsZero() && now.Sub(iat.lastRestart) < inspectionRestartCooldown {
+		return false
+	}
+	iat.lastRestart = now
+	return true
+}
+
+func isIndicatorFailing(rule config.InspectionActionConfig, results *protocol.InspectionResults) (float64, bool) {
+	if results == nil {
+		return 0, false
+	}
+	value, ok := results.Indicators[rule.Indicator]
+	if !ok {
+		return 0, false
+	}
+	if rule.Min == nil && rule.Max == nil {
+		return value, value == inspect.ResultFailure
+	}
+	if rule.Min != nil && value < *rule.Min {
+		return value, true
+	}
+	if rule.Max != nil && value > *rule.Max {
+		return value, true
+	}
+	return value, false
+}
+
+// Health implements the health.Reporter interface.
+func (iat *inspectionActionTracker) Health() health.Reports {
+	if !iat.HasRules() {
+		return nil
+	}
+	iat.mu.RLock()
+	defer iat.mu.RUnlock()
+
+	status := health.StatusOK
+	var failing []string
+	for i, value := range iat.failing {
+		rule := iat.rules[i]
+		failing = append(failing, fmt.Sprintf("%s=%v (%s)", rule.Indicator, value, rule.Action))
+	}
+	if len(failing) > 0 {
+		status = health.StatusFailing
+		sort.Strings(failing)
+	}
+	return health.Reports{
+		{
+			Name:    "inspection.actions.failing",
+			Status:  status,
+			Details: strings.Join(failing, ", "),
+		},
+	}
+}
+
+// handleInspectionActions runs the actions of the failed inspection indicators.
+func (sup *SupervisorService) handleInspectionActions(results *protocol.InspectionResults) {
+	if !sup.inspectionActions.HasRules() {
+		return
+	}
+	started, pause := sup.inspectionActions.Evaluate(results)
+	sup.setPausedByInspection(pause)
+
+	for _, action := range started {
+		logger := log.WithFields(log.Fields{
+			"indicator": action.Rule.Indicator,
+			"value":     action.Value,
+			"action":    action.Rule.Action,
+		})
+		logger.Warn("inspection indicator failed")
+
+		switch action.Rule.Action {
+		case config.InspectionActionNotify:
+			go func(action *triggeredInspectionAction) {
+				if err := sup.sendInspectionNotification(results, action); err != nil {
+					logger.WithError(err).Error("failed to send the inspection notification")
+				}
+			}(action)
+
+		case config.InspectionActionRestart:
+			if !sup.inspectionActions.ShouldRestart(time.Now()) {
+				logger.Warn("not restarting the scanner again during the cool-down")
+				continue
+			}
+			go func() {
+				if err := sup.restartScanner(); err != nil {
+					logger.WithError(err).Error("failed to restart the scanner")
+				}
+			}()
+		}
+	}
+}
+
+// setPausedByInspection marks the node as paused by the inspection actions, separately from the operator,
+// and lets the scanner know if the mark changed.
+func (sup *SupervisorService) setPausedByInspection(paused bool) {
+	fortaDir := sup.config.Config.FortaDir
+	if config.IsPausedByInspection(fortaDir) == paused {
+		return
+	}
+	if err := config.SetPausedByInspection(fortaDir, paused); err != nil {
+		log.WithError(err).Error("failed to update the inspection pause state")
+		return
+	}
+	log.WithField("paused", paused).Warn("updated the pause state by the inspection actions")
+	sup.SetPaused(config.IsPaused(fortaDir))
+}
+
+func (sup *SupervisorService) restartScanner() error {
+	sup.mu.RLock()
+	scannerContainer := sup.scannerContainer
+	sup.mu.RUnlock()
+	if scannerContainer == nil {
+		return nil
+	}
+	log.Warn("restarting the scanner because of the inspection actions")
+	if err := sup.client.StopContainer(sup.ctx, scannerContainer.ID); err != nil {
+		return err
+	}
+	_, err := sup.client.StartContainer(sup.ctx, scannerContainer.Config)
+	return err
+}
+
+// inspectionNotification is sent to the action webhook when an inspection indicator fails.
+type inspectionNotification struct {
+	NodeID      string  `json:"nodeId,omitempty"`
+	ChainID     int     `json:"chainId"`
+	BlockNumber uint64  `json:"blockNumber"`
+	Indicator   string  `json:"indicator"`
+	Value       float64 `json:"value"`
+	Timestamp   string  `json:"timestamp"`
+}
+
+func (sup *SupervisorService) sendInspectionNotification(results *protocol.InspectionResults, action *triggeredInspectionAction) error {
+	webhookURL := sup.config.Config.InspectionConfig.ActionWebhookURL
+	if len(webhookURL) == 0 {
+		return fmt.Errorf("inspection.actionWebhookUrl is not configured")
+	}
+	notification := &inspectionNotification{
+		ChainID:   sup.config.Config.ChainID,
+		Indicator: action.Rule.Indicator,
+		Value:     action.Value,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+	if sup.config.Key != nil {
+		notification.NodeID = sup.config.Key.Address.Hex()
+	}
+	if results.Inputs != nil {
+		notification.BlockNumber = results.Inputs.BlockNumber
+	}
+	b, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(sup.ctx, http.MethodPost, webhookURL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := (&http.Client{Timeout: heartbeatRequestTimeout}).Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("action webhook responded with status code %d", resp.StatusCode)
+	}
+	return nil
+}
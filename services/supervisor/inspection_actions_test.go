@@ -0,0 +1,84 @@
+package supervisor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/inspect"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func testInspectionResults(chainID, offset float64) *protocol.InspectionResults {
+	return &protocol.InspectionResults{
+		Indicators: map[string]float64{
+			inspect.IndicatorProxyAPIChainID:        chainID,
+			inspect.IndicatorProxyAPIOffsetScanMean: offset,
+		},
+	}
+}
+
+func TestInspectionActionTracker(t *testing.T) {
+	r := require.New(t)
+
+	maxOffset := float64(10)
+	tracker := newInspectionActionTracker([]config.InspectionActionConfig{
+		{Indicator: inspect.IndicatorProxyAPIChainID, Action: config.InspectionActionPause},
+		{Indicator: inspect.IndicatorProxyAPIOffsetScanMean, Action: config.InspectionActionNotify, Max: &maxOffset},
+		{Indicator: "unknown", Action: config.InspectionActionRestart},
+	})
+
+	started, pause := tracker.Evaluate(testInspectionResults(inspect.ResultSuccess, 5))
+	r.Empty(started)
+	r.False(pause)
+
+	started, pause = tracker.Evaluate(testInspectionResults(inspect.ResultFailure, 20))
+	r.Len(started, 2)
+	r.True(pause)
+	report, ok := tracker.Health().GetByName("inspection.actions.failing")
+	r.True(ok)
+	r.Equal("proxy-api.chain-id=-1 (pause), proxy-api.offset.scan.mean=20 (notify)", report.Details)
+
+	// the same results do not trigger the actions again but keep the node paused
+	started, pause = tracker.Evaluate(testInspectionResults(inspect.ResultFailure, 20))
+	r.Empty(started)
+	r.True(pause)
+
+	started, pause = tracker.Evaluate(testInspectionResults(inspect.ResultSuccess, 20))
+	r.Empty(started)
+	r.False(pause)
+}
+
+func TestInspectionActionTracker_ShouldRestart(t *testing.T) {
+	r := require.New(t)
+
+	tracker := newInspectionActionTracker(nil)
+	now := time.Now()
+	r.True(tracker.ShouldRestart(now))
+	r.False(tracker.ShouldRestart(now.Add(time.Minute)))
+	r.True(tracker.ShouldRestart(now.Add(inspectionRestartCooldown)))
+}
+
+func TestSetPausedByInspection(t *testing.T) {
+	r := require.New(t)
+
+	sup := &SupervisorService{
+		ctx: context.Background(),
+		inspectionActions: newInspectionActionTracker([]config.InspectionActionConfig{
+			{Indicator: inspect.IndicatorProxyAPIChainID, Action: config.InspectionActionPause},
+		}),
+	}
+	sup.config.Config.FortaDir = t.TempDir()
+
+	sup.handleInspectionActions(testInspectionResults(inspect.ResultFailure, 0))
+	r.True(config.IsPausedByInspection(sup.config.Config.FortaDir))
+	r.True(sup.pause.IsPaused())
+
+	// the operator pause is kept after the indicator recovers
+	r.NoError(config.SetPaused(sup.config.Config.FortaDir, true))
+	sup.handleInspectionActions(testInspectionResults(inspect.ResultSuccess, 0))
+	r.False(config.IsPausedByInspection(sup.config.Config.FortaDir))
+	r.True(sup.pause.IsPaused())
+}
@@ -0,0 +1,35 @@
+package supervisor
+
+import (
+	"strings"
+
+	"github.com/forta-network/forta-node/clients"
+	log "github.com/sirupsen/logrus"
+)
+
+// containerLabels adds the custom labels from the config to the node labels of a container. The custom
+// labels cannot override the node labels or use the reserved prefix.
+func (sup *SupervisorService) containerLabels(labels map[string]string, extraLabels ...map[string]string) map[string]string {
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	for _, customLabels := range append([]map[string]string{sup.config.Config.ContainerLabels.Labels}, extraLabels...) {
+		for name, value := range customLabels {
+			if strings.HasPrefix(name, clients.DockerLabelForta) {
+				log.WithField("label", name).Warn("ignoring the custom container label with the reserved prefix")
+				continue
+			}
+			labels[name] = value
+		}
+	}
+	return labels
+}
+
+// nodeContainerLabels returns the labels of the containers which run the node image.
+func (sup *SupervisorService) nodeContainerLabels() map[string]string {
+	labels := make(map[string]string)
+	if len(sup.releaseVersion) > 0 {
+		labels[clients.DockerLabelFortaVersion] = sup.releaseVersion
+	}
+	return sup.containerLabels(labels)
+}
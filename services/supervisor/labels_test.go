@@ -0,0 +1,31 @@
+package supervisor
+
+import (
+	"testing"
+
+	"github.com/forta-network/forta-node/clients"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerLabels(t *testing.T) {
+	r := require.New(t)
+
+	sup := &SupervisorService{}
+	sup.config.Config.ContainerLabels.Labels = map[string]string{
+		"team":                        "security",
+		clients.DockerLabelForta:      "false",
+		clients.DockerLabelFortaBotID: "0x02",
+	}
+
+	r.Equal(map[string]string{"team": "security"}, sup.containerLabels(nil))
+
+	labels := sup.containerLabels(
+		map[string]string{clients.DockerLabelFortaBotID: "0x01"},
+		map[string]string{"log-route": "bots", "team": "bots"},
+	)
+	r.Equal(map[string]string{
+		clients.DockerLabelFortaBotID: "0x01",
+		"log-route":                   "bots",
+		"team":                        "bots",
+	}, labels)
+}
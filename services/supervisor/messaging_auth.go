@@ -0,0 +1,110 @@
+package supervisor
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/tlsutils"
+	"github.com/nats-io/nats.go"
+)
+
+// messagingAuth contains the message bus credentials which the supervisor generates at start. The
+// authority is separate from the agent TLS authority so that the agent certificates can not be used
+// for connecting to the message bus.
+type messagingAuth struct {
+	token     string
+	authority *tlsutils.Authority
+}
+
+// initMessagingAuth generates the message bus credentials if the message bus should be secured.
+func (sup *SupervisorService) initMessagingAuth() error {
+	if !sup.config.Config.Messaging.Secure {
+		return nil
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("failed to generate the message bus token: %v", err)
+	}
+	authority, err := tlsutils.NewAuthority()
+	if err != nil {
+		return fmt.Errorf("failed to initialize the message bus tls: %v", err)
+	}
+	sup.messagingAuth = &messagingAuth{
+		token:     hex.EncodeToString(b),
+		authority: authority,
+	}
+	return nil
+}
+
+// natsContainerFiles returns the server config and the TLS files of the NATS container.
+func (sup *SupervisorService) natsContainerFiles() (map[string][]byte, error) {
+	files, err := sup.messagingAuth.authority.NatsContainerFiles(tlsutils.RoleServer, config.DockerNatsContainerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue the message bus certificate for nats: %v", err)
+	}
+	files[config.DefaultContainerNatsConfigPath] = natsServerConfig(sup.messagingAuth.token)
+	return files, nil
+}
+
+// natsServerConfig makes the NATS server config which requires the token and a client certificate
+// issued by the supervisor.
+func natsServerConfig(token string) []byte {
+	return []byte(fmt.Sprintf(`port: %s
+
+authorization {
+  token: "%s"
+}
+
+tls {
+  cert_file: "%s"
+  key_file: "%s"
+  ca_file: "%s"
+  verify: true
+}
+`, config.DefaultNatsPort, token,
+		config.DefaultContainerNatsCertPath, config.DefaultContainerNatsKeyPath, config.DefaultContainerNatsCAPath))
+}
+
+// withMessagingFiles issues a message bus certificate for the node container and adds the TLS files
+// to the container files.
+func (sup *SupervisorService) withMessagingFiles(files map[string][]byte, name string) (map[string][]byte, error) {
+	if sup.messagingAuth == nil {
+		return files, nil
+	}
+	natsFiles, err := sup.messagingAuth.authority.NatsContainerFiles(tlsutils.RoleClient, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue the message bus certificate for %s: %v", name, err)
+	}
+	for fileName, b := range files {
+		natsFiles[fileName] = b
+	}
+	return natsFiles, nil
+}
+
+// withMessagingEnv adds the message bus token to the node container env vars.
+func (sup *SupervisorService) withMessagingEnv(env map[string]string) map[string]string {
+	if sup.messagingAuth == nil {
+		return env
+	}
+	if env == nil {
+		env = make(map[string]string)
+	}
+	env[config.EnvNatsToken] = sup.messagingAuth.token
+	return env
+}
+
+// messagingClientOptions returns the options which authenticate the supervisor to the message bus.
+func (sup *SupervisorService) messagingClientOptions() ([]nats.Option, error) {
+	if sup.messagingAuth == nil {
+		return nil, nil
+	}
+	tlsConfig, err := sup.messagingAuth.authority.ClientConfig(
+		config.DockerSupervisorContainerName, tlsutils.AllowPeers(config.DockerNatsContainerName),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue the message bus certificate for the supervisor: %v", err)
+	}
+	return []nats.Option{nats.Token(sup.messagingAuth.token), nats.Secure(tlsConfig)}, nil
+}
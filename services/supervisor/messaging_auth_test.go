@@ -0,0 +1,38 @@
+package supervisor
+
+import (
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessagingAuth(t *testing.T) {
+	r := require.New(t)
+
+	sup := &SupervisorService{}
+	r.NoError(sup.initMessagingAuth())
+	r.Nil(sup.messagingAuth)
+	r.Nil(sup.withMessagingEnv(nil))
+
+	sup.config.Config.Messaging.Secure = true
+	r.NoError(sup.initMessagingAuth())
+	r.Len(sup.messagingAuth.token, 64)
+
+	env := sup.withMessagingEnv(nil)
+	r.Equal(sup.messagingAuth.token, env[config.EnvNatsToken])
+
+	files, err := sup.withMessagingFiles(map[string][]byte{"passphrase": []byte("1234")}, config.DockerScannerContainerName)
+	r.NoError(err)
+	r.Len(files, 4)
+	r.Contains(files, config.DefaultContainerNatsCertPath)
+
+	natsFiles, err := sup.natsContainerFiles()
+	r.NoError(err)
+	r.Contains(string(natsFiles[config.DefaultContainerNatsConfigPath]), sup.messagingAuth.token)
+	r.Contains(string(natsFiles[config.DefaultContainerNatsConfigPath]), "verify: true")
+
+	opts, err := sup.messagingClientOptions()
+	r.NoError(err)
+	r.Len(opts, 2)
+}
@@ -0,0 +1,158 @@
+package supervisor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/services"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	remoteConfigRequestTimeout = time.Second * 30
+	maxRemoteConfigSize        = 1024 * 1024
+)
+
+// pullRemoteConfigs pulls the config overrides from the remote config URL periodically.
+func (sup *SupervisorService) pullRemoteConfigs() {
+	cfg := sup.config.Config.RemoteConfig
+	ticker := time.NewTicker(time.Duration(cfg.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	httpClient := &http.Client{Timeout: remoteConfigRequestTimeout}
+	for {
+		err := sup.pullRemoteConfig(httpClient)
+		if err != nil {
+			log.WithError(err).Warn("failed to pull the remote config")
+		}
+		sup.lastRemoteConfigPull.Set()
+		sup.lastRemoteConfigPullError.Set(err)
+
+		select {
+		case <-sup.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pullRemoteConfig writes the verified overrides to the Forta dir and triggers a config reload if they changed.
+func (sup *SupervisorService) pullRemoteConfig(httpClient *http.Client) error {
+	cfg := sup.config.Config.RemoteConfig
+	doc, err := fetchRemoteConfig(sup.ctx, httpClient, cfg)
+	if err != nil {
+		return err
+	}
+	if err := doc.Verify(cfg.Signers); err != nil {
+		return err
+	}
+
+	fortaDir := sup.config.Config.FortaDir
+	currentVersion, err := config.GetRemoteConfigVersion(fortaDir)
+	if err != nil {
+		return fmt.Errorf("failed to read the current remote config version: %v", err)
+	}
+	if doc.Version < currentVersion {
+		return fmt.Errorf("remote config version %d is older than the applied version %d", doc.Version, currentVersion)
+	}
+
+	filtered, applied, ignored, err := config.FilterReloadableOverrides([]byte(doc.Config))
+	if err != nil {
+		return fmt.Errorf("failed to decode the remote config: %v", err)
+	}
+	sup.setRemoteConfigState(doc.Version, ignored)
+	if len(ignored) > 0 {
+		log.WithField("fields", strings.Join(ignored, ", ")).Warn("ignoring the remote config fields which are not reloadable")
+	}
+
+	overridesFile := path.Join(fortaDir, config.DefaultRemoteConfigFileName)
+	updated := config.MakeRemoteOverridesFile(doc.Version, filtered)
+	current, err := os.ReadFile(overridesFile)
+	if err == nil && bytes.Equal(current, updated) {
+		return nil
+	}
+	if err := os.WriteFile(overridesFile, updated, 0644); err != nil {
+		return fmt.Errorf("failed to write the remote config overrides: %v", err)
+	}
+	log.WithFields(log.Fields{
+		"version": doc.Version,
+		"fields":  strings.Join(applied, ", "),
+	}).Info("applying the remote config")
+	services.TriggerConfigReload()
+	return nil
+}
+
+func fetchRemoteConfig(
+	ctx context.Context, httpClient *http.Client, cfg config.RemoteConfigConfig,
+) (*config.RemoteConfigDocument, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, remoteConfigURL(cfg.URL), nil)
+	if err != nil {
+		return nil, err
+	}
+	for h, v := range cfg.Headers {
+		req.Header.Set(h, v)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote config responded with status %d", resp.StatusCode)
+	}
+	var doc config.RemoteConfigDocument
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxRemoteConfigSize)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode the remote config document: %v", err)
+	}
+	return &doc, nil
+}
+
+// remoteConfigURL converts the s3://bucket/key references to the S3 object URLs.
+func remoteConfigURL(url string) string {
+	if !strings.HasPrefix(url, "s3://") {
+		return url
+	}
+	bucketAndKey := strings.SplitN(strings.TrimPrefix(url, "s3://"), "/", 2)
+	if len(bucketAndKey) < 2 {
+		return fmt.Sprintf("https://%s.s3.amazonaws.com/", bucketAndKey[0])
+	}
+	return fmt.Sprintf("https://%s.s3.amazonaws.com/%s", bucketAndKey[0], bucketAndKey[1])
+}
+
+func (sup *SupervisorService) setRemoteConfigState(version int64, ignored []string) {
+	sup.mu.Lock()
+	defer sup.mu.Unlock()
+	sup.remoteConfigVersion = version
+	sup.remoteConfigIgnored = ignored
+}
+
+// remoteConfigReports expects the lock to be held.
+func (sup *SupervisorService) remoteConfigReports() health.Reports {
+	if len(sup.config.Config.RemoteConfig.URL) == 0 {
+		return nil
+	}
+	return health.Reports{
+		sup.lastRemoteConfigPull.GetReport("event.remote-config.time"),
+		sup.lastRemoteConfigPullError.GetReport("event.remote-config.error"),
+		&health.Report{
+			Name:    "remote-config.version",
+			Status:  health.StatusInfo,
+			Details: fmt.Sprintf("%d", sup.remoteConfigVersion),
+		},
+		&health.Report{
+			Name:    "remote-config.ignored",
+			Status:  health.StatusInfo,
+			Details: strings.Join(sup.remoteConfigIgnored, ", "),
+		},
+	}
+}
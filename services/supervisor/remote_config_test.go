@@ -0,0 +1,74 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullRemoteConfig(t *testing.T) {
+	r := require.New(t)
+
+	privateKey, err := crypto.GenerateKey()
+	r.NoError(err)
+	key := &keystore.Key{PrivateKey: privateKey, Address: crypto.PubkeyToAddress(privateKey.PublicKey)}
+
+	var doc *config.RemoteConfigDocument
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.Equal("secret", req.Header.Get("X-Api-Key"))
+		json.NewEncoder(w).Encode(doc)
+	}))
+	defer server.Close()
+
+	sup := &SupervisorService{ctx: context.Background()}
+	sup.config.Config.FortaDir = t.TempDir()
+	sup.config.Config.RemoteConfig = config.RemoteConfigConfig{
+		URL:     server.URL,
+		Headers: map[string]string{"X-Api-Key": "secret"},
+		Signers: []string{key.Address.Hex()},
+	}
+
+	doc, err = config.SignRemoteConfig(key, 2, "log:\n  level: debug\nchainId: 137\n")
+	r.NoError(err)
+	r.NoError(sup.pullRemoteConfig(server.Client()))
+
+	b, err := os.ReadFile(path.Join(sup.config.Config.FortaDir, config.DefaultRemoteConfigFileName))
+	r.NoError(err)
+	r.Equal("# remote config version: 2 - do not edit\nlog:\n  level: debug\n", string(b))
+	r.Equal(int64(2), sup.remoteConfigVersion)
+	r.Equal([]string{"chainId"}, sup.remoteConfigIgnored)
+
+	// the older documents are not applied
+	doc, err = config.SignRemoteConfig(key, 1, "log:\n  level: info\n")
+	r.NoError(err)
+	r.Error(sup.pullRemoteConfig(server.Client()))
+
+	// the documents of the other signers are not applied
+	otherKey, err := crypto.GenerateKey()
+	r.NoError(err)
+	doc, err = config.SignRemoteConfig(&keystore.Key{
+		PrivateKey: otherKey, Address: crypto.PubkeyToAddress(otherKey.PublicKey),
+	}, 3, "log:\n  level: info\n")
+	r.NoError(err)
+	r.Error(sup.pullRemoteConfig(server.Client()))
+
+	b, err = os.ReadFile(path.Join(sup.config.Config.FortaDir, config.DefaultRemoteConfigFileName))
+	r.NoError(err)
+	r.Contains(string(b), "level: debug")
+}
+
+func TestRemoteConfigURL(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("https://example.com/config.json", remoteConfigURL("https://example.com/config.json"))
+	r.Equal("https://fleet.s3.amazonaws.com/nodes/config.json", remoteConfigURL("s3://fleet/nodes/config.json"))
+}
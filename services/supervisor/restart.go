@@ -0,0 +1,149 @@
+package supervisor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+// restartTracker keeps the restart history of the service containers and decides if
+// an exited service container should be restarted.
+type restartTracker struct {
+	cfg      config.RestartConfig
+	services map[string]*serviceRestarts
+	mu       sync.Mutex
+}
+
+type serviceRestarts struct {
+	count   int
+	recent  []time.Time
+	stopped bool
+	reason  string
+
+	// circuit breaker state: the service is not restarted until the cool-down ends
+	// and then it is restarted once to see if it recovered
+	openUntil time.Time
+	halfOpen  bool
+}
+
+func newRestartTracker(cfg config.RestartConfig) *restartTracker {
+	return &restartTracker{
+		cfg:      cfg,
+		services: make(map[string]*serviceRestarts),
+	}
+}
+
+// SetConfig updates the restart config.
+func (rt *restartTracker) SetConfig(cfg config.RestartConfig) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.cfg = cfg
+}
+
+// serviceName converts a container name like "forta-scanner" to a service name like "scanner".
+func serviceName(containerName string) string {
+	return strings.TrimPrefix(containerName, config.ContainerNamePrefix+"-")
+}
+
+// ShouldRestart checks the restart policy and the circuit breaker and records the restart
+// if the service should be restarted. Otherwise, it returns the reason.
+func (rt *restartTracker) ShouldRestart(service string, exitCode int, now time.Time) (bool, string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	restarts, ok := rt.services[service]
+	if !ok {
+		restarts = &serviceRestarts{}
+		rt.services[service] = restarts
+	}
+	if restarts.stopped {
+		return false, restarts.reason
+	}
+	if !restarts.openUntil.IsZero() {
+		if now.Before(restarts.openUntil) {
+			return false, restarts.reason
+		}
+		// cool-down is over: try once more and forget the history
+		restarts.openUntil = time.Time{}
+		restarts.reason = ""
+		restarts.halfOpen = true
+		restarts.recent = nil
+		restarts.count++
+		restarts.recent = append(restarts.recent, now)
+		return true, ""
+	}
+
+	policy := rt.cfg.GetPolicy(service)
+	switch {
+	case policy.Policy == config.RestartPolicyNever:
+		return rt.stop(restarts, "restart policy is 'never'")
+
+	case policy.Policy == config.RestartPolicyOnFailure && exitCode == 0:
+		return rt.stop(restarts, "exited successfully and restart policy is 'on-failure'")
+
+	case policy.Policy == config.RestartPolicyOnFailure && policy.MaxRetries > 0 && restarts.count >= policy.MaxRetries:
+		return rt.stop(restarts, fmt.Sprintf("reached max retries (%d)", policy.MaxRetries))
+	}
+
+	if rt.cfg.CircuitBreakerMaxRestarts > 0 {
+		windowStart := now.Add(-time.Duration(rt.cfg.CircuitBreakerWindowSeconds) * time.Second)
+		var recent []time.Time
+		for _, restartTime := range restarts.recent {
+			if restartTime.After(windowStart) {
+				recent = append(recent, restartTime)
+			}
+		}
+		restarts.recent = recent
+		switch {
+		case restarts.halfOpen && len(restarts.recent) > 0:
+			// crashed again soon after the retry
+			return rt.open(restarts, now, "crash loop: crashed again after the cool-down")
+
+		case len(restarts.recent) >= rt.cfg.CircuitBreakerMaxRestarts:
+			return rt.open(restarts, now, fmt.Sprintf(
+				"crash loop: restarted %d times in %d seconds", len(restarts.recent), rt.cfg.CircuitBreakerWindowSeconds,
+			))
+		}
+		restarts.halfOpen = false
+	}
+
+	restarts.count++
+	restarts.recent = append(restarts.recent, now)
+	return true, ""
+}
+
+func (rt *restartTracker) stop(restarts *serviceRestarts, reason string) (bool, string) {
+	restarts.stopped = true
+	restarts.reason = reason
+	return false, reason
+}
+
+// open opens the circuit breaker. The service is retried after the cool-down if there is one.
+func (rt *restartTracker) open(restarts *serviceRestarts, now time.Time, reason string) (bool, string) {
+	restarts.halfOpen = false
+	if rt.cfg.CircuitBreakerCooldownSeconds <= 0 {
+		return rt.stop(restarts, reason)
+	}
+	restarts.openUntil = now.Add(time.Duration(rt.cfg.CircuitBreakerCooldownSeconds) * time.Second)
+	restarts.reason = fmt.Sprintf("%s - retrying after %s", reason, restarts.openUntil.UTC().Format(time.RFC3339))
+	return false, restarts.reason
+}
+
+// Stopped returns the services which are not restarted at the moment, with the reasons.
+func (rt *restartTracker) Stopped() []string {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	var stopped []string
+	for service, restarts := range rt.services {
+		if restarts.stopped || !restarts.openUntil.IsZero() {
+			stopped = append(stopped, fmt.Sprintf("%s (%s)", service, restarts.reason))
+		}
+	}
+	sort.Strings(stopped)
+	return stopped
+}
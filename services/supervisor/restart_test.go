@@ -0,0 +1,109 @@
+package supervisor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartTracker_Policies(t *testing.T) {
+	r := require.New(t)
+
+	rt := newRestartTracker(config.RestartConfig{
+		Default: config.RestartPolicyConfig{Policy: config.RestartPolicyAlways},
+		Services: map[string]config.RestartPolicyConfig{
+			"json-rpc":  {Policy: config.RestartPolicyNever},
+			"inspector": {Policy: config.RestartPolicyOnFailure, MaxRetries: 2},
+		},
+	})
+	now := time.Now()
+
+	restart, _ := rt.ShouldRestart("scanner", 0, now)
+	r.True(restart)
+
+	restart, reason := rt.ShouldRestart("json-rpc", 1, now)
+	r.False(restart)
+	r.Contains(reason, "never")
+
+	restart, _ = rt.ShouldRestart("inspector", 1, now)
+	r.True(restart)
+	restart, _ = rt.ShouldRestart("inspector", 1, now)
+	r.True(restart)
+	restart, reason = rt.ShouldRestart("inspector", 1, now)
+	r.False(restart)
+	r.Contains(reason, "max retries")
+
+	r.Len(rt.Stopped(), 2)
+}
+
+func TestRestartTracker_CircuitBreaker(t *testing.T) {
+	r := require.New(t)
+
+	rt := newRestartTracker(config.RestartConfig{
+		Default:                     config.RestartPolicyConfig{Policy: config.RestartPolicyAlways},
+		CircuitBreakerMaxRestarts:   2,
+		CircuitBreakerWindowSeconds: 60,
+	})
+	now := time.Now()
+
+	// restarts outside of the window are not counted
+	restart, _ := rt.ShouldRestart("scanner", 1, now.Add(-time.Hour))
+	r.True(restart)
+	restart, _ = rt.ShouldRestart("scanner", 1, now)
+	r.True(restart)
+	restart, _ = rt.ShouldRestart("scanner", 1, now.Add(time.Second))
+	r.True(restart)
+
+	restart, reason := rt.ShouldRestart("scanner", 1, now.Add(time.Second*2))
+	r.False(restart)
+	r.Contains(reason, "crash loop")
+
+	// stays stopped
+	restart, _ = rt.ShouldRestart("scanner", 1, now.Add(time.Hour))
+	r.False(restart)
+	r.Len(rt.Stopped(), 1)
+}
+
+func TestRestartTracker_CircuitBreakerCooldown(t *testing.T) {
+	r := require.New(t)
+
+	rt := newRestartTracker(config.RestartConfig{
+		Default:                       config.RestartPolicyConfig{Policy: config.RestartPolicyAlways},
+		CircuitBreakerMaxRestarts:     2,
+		CircuitBreakerWindowSeconds:   60,
+		CircuitBreakerCooldownSeconds: 300,
+	})
+	now := time.Now()
+
+	restart, _ := rt.ShouldRestart("scanner", 1, now)
+	r.True(restart)
+	restart, _ = rt.ShouldRestart("scanner", 1, now.Add(time.Second))
+	r.True(restart)
+	restart, reason := rt.ShouldRestart("scanner", 1, now.Add(time.Second*2))
+	r.False(restart)
+	r.Contains(reason, "retrying after")
+	r.Len(rt.Stopped(), 1)
+
+	// not restarted during the cool-down
+	restart, _ = rt.ShouldRestart("scanner", 1, now.Add(time.Minute))
+	r.False(restart)
+
+	// retried once after the cool-down
+	restart, _ = rt.ShouldRestart("scanner", 1, now.Add(time.Minute*6))
+	r.True(restart)
+	r.Empty(rt.Stopped())
+
+	// crashing again soon opens the breaker again
+	restart, _ = rt.ShouldRestart("scanner", 1, now.Add(time.Minute*6+time.Second))
+	r.False(restart)
+	r.Len(rt.Stopped(), 1)
+
+	// recovers if it runs longer than the window after the retry
+	restart, _ = rt.ShouldRestart("scanner", 1, now.Add(time.Minute*12))
+	r.True(restart)
+	restart, _ = rt.ShouldRestart("scanner", 1, now.Add(time.Minute*14))
+	r.True(restart)
+	r.Empty(rt.Stopped())
+}
@@ -0,0 +1,223 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/protocol"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+// recentRestartPeriod is how long the restarts of a service make the report lagging, so that
+// the restarts which happen overnight are still visible the next morning.
+const recentRestartPeriod = time.Hour * 24
+
+// ServiceHistory is the lifecycle history of a service container, kept across the node restarts.
+type ServiceHistory struct {
+	Restarts       int         `json:"restarts"`
+	RecentRestarts []time.Time `json:"recentRestarts,omitempty"`
+	LastStart      time.Time   `json:"lastStart"`
+	LastExit       time.Time   `json:"lastExit"`
+	// the total uptime of the previous runs
+	UptimeSeconds   float64   `json:"uptimeSeconds"`
+	LastFailure     string    `json:"lastFailure,omitempty"`
+	LastFailureTime time.Time `json:"lastFailureTime"`
+}
+
+// IsRunning tells if the service was started after the last exit.
+func (sh *ServiceHistory) IsRunning() bool {
+	return !sh.LastStart.IsZero() && sh.LastStart.After(sh.LastExit)
+}
+
+// serviceHistoryTracker persists the uptime, the restarts and the failures of the service containers
+// to a file in the Forta dir.
+type serviceHistoryTracker struct {
+	filePath string
+	services map[string]*ServiceHistory
+	lastErr  health.ErrorTracker
+	mu       sync.Mutex
+}
+
+func newServiceHistoryTracker(filePath string) *serviceHistoryTracker {
+	services, err := LoadServiceHistory(filePath)
+	if err != nil {
+		log.WithError(err).Warn("failed to load the service history - starting over")
+	}
+	if services == nil {
+		services = make(map[string]*ServiceHistory)
+	}
+	return &serviceHistoryTracker{
+		filePath: filePath,
+		services: services,
+	}
+}
+
+// LoadServiceHistory loads the service histories by the service name. The history is empty if the file
+// does not exist.
+func LoadServiceHistory(filePath string) (map[string]*ServiceHistory, error) {
+	b, err := ioutil.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var services map[string]*ServiceHistory
+	if err := json.Unmarshal(b, &services); err != nil {
+		return nil, fmt.Errorf("failed to decode the service history: %v", err)
+	}
+	return services, nil
+}
+
+// isServiceContainer tells if the event is about a node service container rather than a bot container.
+func isServiceContainer(evt *clients.DockerContainerEvent) bool {
+	return len(evt.Attributes[clients.DockerLabelFortaBotID]) == 0 &&
+		strings.HasPrefix(evt.ContainerName, config.ContainerNamePrefix+"-")
+}
+
+// Track records the lifecycle event of a service container, saves the history and returns the metrics
+// of the event.
+func (sht *serviceHistoryTracker) Track(evt *clients.DockerContainerEvent, restarted bool) []*protocol.AgentMetric {
+	if !isServiceContainer(evt) {
+		return nil
+	}
+	service := serviceName(evt.ContainerName)
+
+	sht.mu.Lock()
+	defer sht.mu.Unlock()
+
+	history, ok := sht.services[service]
+	if !ok {
+		history = &ServiceHistory{}
+		sht.services[service] = history
+	}
+
+	var serviceMetrics []*protocol.AgentMetric
+	switch evt.Action {
+	case clients.DockerEventStart:
+		if restarted {
+			history.Restarts++
+			history.RecentRestarts = append(history.RecentRestarts, evt.Time)
+			serviceMetrics = append(serviceMetrics, metrics.CreateAgentMetric(evt.ContainerName, metrics.MetricServiceRestart, 1))
+		}
+		history.LastStart = evt.Time
+
+	case clients.DockerEventDie:
+		if history.IsRunning() {
+			uptime := evt.Time.Sub(history.LastStart)
+			history.UptimeSeconds += uptime.Seconds()
+			serviceMetrics = append(serviceMetrics, metrics.CreateAgentMetric(evt.ContainerName, metrics.MetricServiceUptime, uptime.Seconds()))
+		}
+		history.LastExit = evt.Time
+		if exitCode := evt.ExitCode(); exitCode != 0 {
+			history.LastFailure = fmt.Sprintf("exit code %d", exitCode)
+			history.LastFailureTime = evt.Time
+		}
+
+	case clients.DockerEventOOM:
+		history.LastFailure = "out of memory"
+		history.LastFailureTime = evt.Time
+
+	default:
+		return nil
+	}
+
+	var recent []time.Time
+	for _, restartTime := range history.RecentRestarts {
+		if evt.Time.Sub(restartTime) < recentRestartPeriod {
+			recent = append(recent, restartTime)
+		}
+	}
+	history.RecentRestarts = recent
+
+	sht.lastErr.Set(sht.save())
+	return serviceMetrics
+}
+
+func (sht *serviceHistoryTracker) save() error {
+	b, err := json.Marshal(sht.services)
+	if err != nil {
+		return err
+	}
+	tmpPath := sht.filePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, sht.filePath)
+}
+
+// Health implements the health.Reporter interface.
+func (sht *serviceHistoryTracker) Health() health.Reports {
+	sht.mu.Lock()
+	defer sht.mu.Unlock()
+
+	names := make([]string, 0, len(sht.services))
+	for name := range sht.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := time.Now()
+	restartsStatus := health.StatusOK
+	var uptimes, restarts, failures []string
+	for _, name := range names {
+		history := sht.services[name]
+		totalUptime := time.Duration(history.UptimeSeconds * float64(time.Second))
+		if history.IsRunning() {
+			uptime := now.Sub(history.LastStart)
+			totalUptime += uptime
+			uptimes = append(uptimes, fmt.Sprintf("%s: %s (total %s)", name, uptime.Round(time.Second), totalUptime.Round(time.Second)))
+		} else {
+			uptimes = append(uptimes, fmt.Sprintf("%s: down (total %s)", name, totalUptime.Round(time.Second)))
+		}
+
+		var recentRestarts int
+		for _, restartTime := range history.RecentRestarts {
+			if now.Sub(restartTime) < recentRestartPeriod {
+				recentRestarts++
+			}
+		}
+		if recentRestarts > 0 {
+			restartsStatus = health.StatusLagging
+		}
+		if history.Restarts > 0 {
+			restarts = append(restarts, fmt.Sprintf("%s: %d (%d in 24h)", name, history.Restarts, recentRestarts))
+		}
+
+		if len(history.LastFailure) > 0 {
+			failures = append(failures, fmt.Sprintf(
+				"%s: %s at %s", name, history.LastFailure, history.LastFailureTime.UTC().Format(time.RFC3339),
+			))
+		}
+	}
+
+	return health.Reports{
+		&health.Report{
+			Name:    "services.uptime",
+			Status:  health.StatusInfo,
+			Details: strings.Join(uptimes, ", "),
+		},
+		&health.Report{
+			Name:    "services.restarts",
+			Status:  restartsStatus,
+			Details: strings.Join(restarts, ", "),
+		},
+		&health.Report{
+			Name:    "services.last-failures",
+			Status:  health.StatusInfo,
+			Details: strings.Join(failures, ", "),
+		},
+		sht.lastErr.GetReport("services.history.error"),
+	}
+}
@@ -0,0 +1,60 @@
+package supervisor
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func testServiceEvent(action string, t time.Time, exitCode string) *clients.DockerContainerEvent {
+	return &clients.DockerContainerEvent{
+		Action:        action,
+		ContainerName: "forta-scanner",
+		Attributes:    map[string]string{"exitCode": exitCode},
+		Time:          t,
+	}
+}
+
+func TestServiceHistoryTracker(t *testing.T) {
+	r := require.New(t)
+
+	filePath := path.Join(t.TempDir(), "service-history.json")
+	sht := newServiceHistoryTracker(filePath)
+
+	start := time.Now().Add(-time.Hour)
+	r.Empty(sht.Track(testServiceEvent(clients.DockerEventStart, start, ""), false))
+	serviceMetrics := sht.Track(testServiceEvent(clients.DockerEventDie, start.Add(time.Minute*10), "1"), false)
+	r.Len(serviceMetrics, 1)
+	r.Equal(metrics.MetricServiceUptime, serviceMetrics[0].Name)
+	r.Equal(float64(600), serviceMetrics[0].Value)
+	serviceMetrics = sht.Track(testServiceEvent(clients.DockerEventStart, start.Add(time.Minute*11), ""), true)
+	r.Len(serviceMetrics, 1)
+	r.Equal(metrics.MetricServiceRestart, serviceMetrics[0].Name)
+	r.Equal("forta-scanner", serviceMetrics[0].AgentId)
+
+	// the bot containers are not tracked
+	botEvent := testServiceEvent(clients.DockerEventStart, start, "")
+	botEvent.Attributes[clients.DockerLabelFortaBotID] = "0x1234"
+	r.Nil(sht.Track(botEvent, true))
+
+	// the history is kept after a restart
+	sht = newServiceHistoryTracker(filePath)
+	history := sht.services["scanner"]
+	r.NotNil(history)
+	r.Equal(1, history.Restarts)
+	r.Equal(float64(600), history.UptimeSeconds)
+	r.Equal("exit code 1", history.LastFailure)
+	r.True(history.IsRunning())
+
+	reports := sht.Health()
+	r.Contains(reports[0].Details, "scanner: 49m0s (total 59m0s)")
+	r.Equal(health.StatusLagging, reports[1].Status)
+	r.Equal("scanner: 1 (1 in 24h)", reports[1].Details)
+	r.Contains(reports[2].Details, "scanner: exit code 1 at ")
+	r.Empty(reports[3].Details)
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/forta-network/forta-node/clients/messaging"
 	"github.com/forta-network/forta-node/config"
 	"github.com/forta-network/forta-node/services"
+	"github.com/forta-network/forta-node/tlsutils"
 )
 
 const (
@@ -45,9 +46,16 @@ type SupervisorService struct {
 	releaseClient  release.Client
 
 	msgClient   clients.MessageClient
+	msgClientMu sync.RWMutex
 	config      SupervisorServiceConfig
 	maxLogSize  string
 	maxLogFiles int
+	nodeImage   string
+	// the version of the running release
+	releaseVersion string
+	usernsRemap    bool
+	agentTLS       *tlsutils.Authority
+	messagingAuth  *messagingAuth
 
 	scannerContainer     *clients.DockerContainer
 	inspectorContainer   *clients.DockerContainer
@@ -65,12 +73,39 @@ type SupervisorService struct {
 	lastCustomTelemetryRequestError health.ErrorTracker
 	lastAgentLogsRequest            health.TimeTracker
 	lastAgentLogsRequestError       health.ErrorTracker
+	lastHeartbeat                   health.TimeTracker
+	lastHeartbeatError              health.ErrorTracker
 
 	healthClient health.HealthClient
 
 	agentLogsClient agentlogs.Client
 	prevAgentLogs   agentlogs.Agents
 	inspectionCh    chan *protocol.InspectionResults
+
+	restarts          *restartTracker
+	containerEvents   *containerEventTracker
+	serviceHistory    *serviceHistoryTracker
+	inspectionActions *inspectionActionTracker
+	imageScanner      *imageScanner
+
+	lastConfigReload       health.TimeTracker
+	lastConfigReloadReport *config.ReloadReport
+
+	lastRemoteConfigPull      health.TimeTracker
+	lastRemoteConfigPullError health.ErrorTracker
+	remoteConfigVersion       int64
+	remoteConfigIgnored       []string
+
+	eligibility   eligibilityState
+	eligibilityMu sync.RWMutex
+
+	failedToInitialize map[string]bool
+	// the max number of bot containers, zero means no limit
+	maxBots int
+	// the bots which are waiting for capacity by container name
+	rejectedBots map[string]config.AgentConfig
+
+	pause services.PauseState
 }
 
 type SupervisorServiceConfig struct {
@@ -84,6 +119,9 @@ type Container struct {
 	clients.DockerContainer
 	IsAgent     bool
 	AgentConfig *config.AgentConfig
+
+	// the health check and the container events can handle the same exited container at the same time
+	upMu sync.Mutex
 }
 
 func (sup *SupervisorService) Start() error {
@@ -92,11 +130,19 @@ func (sup *SupervisorService) Start() error {
 	}
 
 	go sup.healthCheck()
+	go sup.watchContainerEvents()
 
 	return nil
 }
 
 func (sup *SupervisorService) start() error {
+	sup.restarts = newRestartTracker(sup.config.Config.RestartConfig)
+
+	// the inspection actions pause the node again if the indicators are still failing
+	if err := config.SetPausedByInspection(sup.config.Config.FortaDir, false); err != nil {
+		return fmt.Errorf("failed to clear the inspection pause state: %v", err)
+	}
+
 	// in addition to the feature disable flags, check local mode flags to disable agent logging and telemetry
 
 	shouldDisableTelemetry := sup.config.Config.TelemetryConfig.Disable
@@ -104,11 +150,24 @@ func (sup *SupervisorService) start() error {
 		go sup.syncTelemetryData()
 	}
 
+	if len(sup.config.Config.Heartbeat.URL) > 0 {
+		go sup.sendHeartbeats()
+	}
+
+	if len(sup.config.Config.RemoteConfig.URL) > 0 {
+		go sup.pullRemoteConfigs()
+	}
+
 	shouldDisableAgentLogs := sup.config.Config.AgentLogsConfig.Disable || sup.config.Config.LocalModeConfig.Enable
 	if !shouldDisableAgentLogs {
 		go sup.syncAgentLogs()
 	}
 
+	shouldCheckEligibility := !sup.config.Config.LocalModeConfig.Enable && sup.config.Key != nil
+	if shouldCheckEligibility {
+		go sup.checkEligibility()
+	}
+
 	sup.mu.Lock()
 	defer sup.mu.Unlock()
 
@@ -129,11 +188,26 @@ func (sup *SupervisorService) start() error {
 	}
 	if releaseInfo != nil {
 		release.LogReleaseInfo(releaseInfo)
+		sup.releaseVersion = releaseInfo.Manifest.Release.Version
 	}
 
 	sup.maxLogSize = sup.config.Config.Log.MaxLogSize
 	sup.maxLogFiles = sup.config.Config.Log.MaxLogFiles
 
+	if err := sup.checkUsernsRemap(); err != nil {
+		return err
+	}
+	sup.initAdmission()
+
+	sup.removeUndeclaredAgentVolumes()
+
+	if err := sup.initAgentTLS(); err != nil {
+		return err
+	}
+	if err := sup.initMessagingAuth(); err != nil {
+		return err
+	}
+
 	if err := sup.removeOldContainers(); err != nil {
 		return err
 	}
@@ -147,6 +221,7 @@ func (sup *SupervisorService) start() error {
 		return fmt.Errorf("failed to get the supervisor container: %v", err)
 	}
 	commonNodeImage := supervisorContainer.Image
+	sup.nodeImage = commonNodeImage
 
 	nodeNetworkID, err := sup.client.CreatePublicNetwork(sup.ctx, config.DockerNetworkName)
 	if err != nil {
@@ -166,8 +241,9 @@ func (sup *SupervisorService) start() error {
 
 	prepareIpfsDir()
 	ipfsContainer, err := sup.client.StartContainer(sup.ctx, clients.DockerContainerConfig{
-		Name:  config.DockerIpfsContainerName,
-		Image: "ipfs/kubo:v0.16.0",
+		Name:   config.DockerIpfsContainerName,
+		Labels: sup.containerLabels(nil),
+		Image:  config.DockerIpfsImage,
 		Ports: map[string]string{
 			"5001": "5001",
 		},
@@ -204,9 +280,10 @@ ipfs config Datastore.StorageMax '1GB'
 	sup.addContainerUnsafe(ipfsContainer)
 
 	// start nats, wait for it and connect from the supervisor
-	natsContainer, err := sup.client.StartContainer(sup.ctx, clients.DockerContainerConfig{
-		Name:  config.DockerNatsContainerName,
-		Image: "nats:2.3.2",
+	natsContainerCfg := clients.DockerContainerConfig{
+		Name:   config.DockerNatsContainerName,
+		Labels: sup.containerLabels(nil),
+		Image:  config.DockerNatsImage,
 		Ports: map[string]string{
 			"4222": "4222",
 			"6222": "6222",
@@ -215,7 +292,17 @@ ipfs config Datastore.StorageMax '1GB'
 		NetworkID:   natsNetworkID,
 		MaxLogFiles: sup.maxLogFiles,
 		MaxLogSize:  sup.maxLogSize,
-	})
+	}
+	if sup.messagingAuth != nil {
+		natsContainerCfg.Files, err = sup.natsContainerFiles()
+		if err != nil {
+			return err
+		}
+		natsContainerCfg.Cmd = []string{"--config", config.DefaultContainerNatsConfigPath}
+		// only the node containers in the nats network can reach the secured message bus
+		natsContainerCfg.Ports = nil
+	}
+	natsContainer, err := sup.client.StartContainer(sup.ctx, natsContainerCfg)
 	if err != nil {
 		return err
 	}
@@ -225,16 +312,26 @@ ipfs config Datastore.StorageMax '1GB'
 		return fmt.Errorf("failed while waiting for nats to start: %v", err)
 	}
 	// in tests, this is already set to a mock client
+	sup.msgClientMu.Lock()
 	if sup.msgClient == nil {
-		sup.msgClient = messaging.NewClient("supervisor", fmt.Sprintf("%s:%s", config.DockerNatsContainerName, config.DefaultNatsPort))
+		msgOpts, err := sup.messagingClientOptions()
+		if err != nil {
+			sup.msgClientMu.Unlock()
+			return err
+		}
+		msgClient := messaging.NewClient("supervisor", fmt.Sprintf("%s:%s", config.DockerNatsContainerName, config.DefaultNatsPort), msgOpts...)
+		msgClient.SetTracing(sup.config.Config.Messaging.Tracing)
+		sup.msgClient = msgClient
 	}
+	sup.msgClientMu.Unlock()
 	sup.registerMessageHandlers()
 
 	sup.storageContainer, err = sup.client.StartContainer(
 		sup.ctx, clients.DockerContainerConfig{
-			Name:  config.DockerStorageContainerName,
-			Image: commonNodeImage,
-			Cmd:   []string{config.DefaultFortaNodeBinaryPath, "storage"},
+			Name:   config.DockerStorageContainerName,
+			Labels: sup.nodeContainerLabels(),
+			Image:  commonNodeImage,
+			Cmd:    []string{config.DefaultFortaNodeBinaryPath, "storage"},
 			Env: map[string]string{
 				config.EnvReleaseInfo: releaseInfo.String(),
 			},
@@ -264,11 +361,22 @@ ipfs config Datastore.StorageMax '1GB'
 		return fmt.Errorf("failed while waiting for the storage container to start: %v", err)
 	}
 
+	// the proxy tests itself through localhost
+	jsonRpcFiles, err := sup.withTLSFiles(nil, tlsutils.RoleServer|tlsutils.RoleClient, config.DockerJSONRPCProxyContainerName, "localhost")
+	if err != nil {
+		return err
+	}
+	jsonRpcFiles, err = sup.withMessagingFiles(jsonRpcFiles, config.DockerJSONRPCProxyContainerName)
+	if err != nil {
+		return err
+	}
 	sup.jsonRpcContainer, err = sup.client.StartContainer(
 		sup.ctx, clients.DockerContainerConfig{
-			Name:  config.DockerJSONRPCProxyContainerName,
-			Image: commonNodeImage,
-			Cmd:   []string{config.DefaultFortaNodeBinaryPath, "json-rpc"},
+			Name:   config.DockerJSONRPCProxyContainerName,
+			Labels: sup.nodeContainerLabels(),
+			Image:  commonNodeImage,
+			Cmd:    []string{config.DefaultFortaNodeBinaryPath, "json-rpc"},
+			Env:    sup.withMessagingEnv(nil),
 			Volumes: map[string]string{
 				// give access to host docker
 				"/var/run/docker.sock": "/var/run/docker.sock",
@@ -277,6 +385,7 @@ ipfs config Datastore.StorageMax '1GB'
 			Ports: map[string]string{
 				"": config.DefaultHealthPort, // random host port
 			},
+			Files:          jsonRpcFiles,
 			DialHost:       true,
 			NetworkID:      nodeNetworkID,
 			LinkNetworkIDs: []string{natsNetworkID},
@@ -300,20 +409,30 @@ ipfs config Datastore.StorageMax '1GB'
 		}
 	}
 
+	inspectorFiles, err := sup.withTLSFiles(map[string][]byte{
+		"passphrase": []byte(sup.config.Passphrase),
+	}, tlsutils.RoleClient, config.DockerInspectorContainerName)
+	if err != nil {
+		return err
+	}
+	inspectorFiles, err = sup.withMessagingFiles(inspectorFiles, config.DockerInspectorContainerName)
+	if err != nil {
+		return err
+	}
 	sup.inspectorContainer, err = sup.client.StartContainer(
 		sup.ctx, clients.DockerContainerConfig{
-			Name:  config.DockerInspectorContainerName,
-			Image: commonNodeImage,
-			Cmd:   []string{config.DefaultFortaNodeBinaryPath, "inspector"},
+			Name:   config.DockerInspectorContainerName,
+			Labels: sup.nodeContainerLabels(),
+			Image:  commonNodeImage,
+			Cmd:    []string{config.DefaultFortaNodeBinaryPath, "inspector"},
+			Env:    sup.withMessagingEnv(nil),
 			Volumes: map[string]string{
 				hostFortaDir: config.DefaultContainerFortaDirPath,
 			},
 			Ports: map[string]string{
 				"": config.DefaultHealthPort, // random host port
 			},
-			Files: map[string][]byte{
-				"passphrase": []byte(sup.config.Passphrase),
-			},
+			Files:          inspectorFiles,
 			DialHost:       true,
 			NetworkID:      nodeNetworkID,
 			LinkNetworkIDs: []string{natsNetworkID},
@@ -336,23 +455,42 @@ ipfs config Datastore.StorageMax '1GB'
 		<-sup.inspectionCh
 	}
 
+	signingCfg := sup.config.Config.Publish.Signing
+	if err := signingCfg.CheckKeyDirs(hostFortaDir); err != nil {
+		return err
+	}
+	scannerVolumes := map[string]string{
+		hostFortaDir: config.DefaultContainerFortaDirPath,
+	}
+	for i, cosigner := range signingCfg.Cosigners {
+		if len(cosigner.KeyDir) > 0 {
+			scannerVolumes[cosigner.KeyDir] = config.CosignerContainerKeyDir(i)
+		}
+	}
+	scannerFiles, err := sup.withTLSFiles(map[string][]byte{
+		"passphrase": []byte(sup.config.Passphrase),
+	}, tlsutils.RoleClient, config.DockerScannerContainerName)
+	if err != nil {
+		return err
+	}
+	scannerFiles, err = sup.withMessagingFiles(scannerFiles, config.DockerScannerContainerName)
+	if err != nil {
+		return err
+	}
 	sup.scannerContainer, err = sup.client.StartContainer(
 		sup.ctx, clients.DockerContainerConfig{
-			Name:  config.DockerScannerContainerName,
-			Image: commonNodeImage,
-			Cmd:   []string{config.DefaultFortaNodeBinaryPath, "scanner"},
-			Env: map[string]string{
+			Name:   config.DockerScannerContainerName,
+			Labels: sup.nodeContainerLabels(),
+			Image:  commonNodeImage,
+			Cmd:    []string{config.DefaultFortaNodeBinaryPath, "scanner"},
+			Env: sup.withMessagingEnv(map[string]string{
 				config.EnvReleaseInfo: releaseInfo.String(),
-			},
-			Volumes: map[string]string{
-				hostFortaDir: config.DefaultContainerFortaDirPath,
-			},
+			}),
+			Volumes: scannerVolumes,
 			Ports: map[string]string{
 				"": config.DefaultHealthPort, // random host port
 			},
-			Files: map[string][]byte{
-				"passphrase": []byte(sup.config.Passphrase),
-			},
+			Files:          scannerFiles,
 			DialHost:       true,
 			NetworkID:      nodeNetworkID,
 			LinkNetworkIDs: []string{natsNetworkID},
@@ -367,9 +505,10 @@ ipfs config Datastore.StorageMax '1GB'
 
 	sup.jwtProviderContainer, err = sup.client.StartContainer(
 		sup.ctx, clients.DockerContainerConfig{
-			Name:  config.DockerJWTProviderContainerName,
-			Image: commonNodeImage,
-			Cmd:   []string{config.DefaultFortaNodeBinaryPath, "jwt-provider"},
+			Name:   config.DockerJWTProviderContainerName,
+			Labels: sup.nodeContainerLabels(),
+			Image:  commonNodeImage,
+			Cmd:    []string{config.DefaultFortaNodeBinaryPath, "jwt-provider"},
 			Env: map[string]string{
 				config.EnvReleaseInfo: releaseInfo.String(),
 			},
@@ -424,14 +563,14 @@ func (sup *SupervisorService) ensureNodeImages() error {
 	}{
 		{
 			Name: "nats",
-			Ref:  "nats:2.3.2",
+			Ref:  config.DockerNatsImage,
 		},
 		{
 			Name: "ipfs/kubo",
-			Ref:  "ipfs/kubo:v0.16.0",
+			Ref:  config.DockerIpfsImage,
 		},
 	} {
-		if err := sup.client.EnsureLocalImage(sup.ctx, image.Name, image.Ref); err != nil {
+		if err := clients.EnsureImageWithPolicy(sup.ctx, sup.client, image.Name, image.Ref, sup.config.Config.ImagePull.Services); err != nil {
 			return err
 		}
 	}
@@ -583,7 +722,8 @@ func (sup *SupervisorService) getFullReleaseInfo(releaseInfo *release.ReleaseInf
 	if releaseInfo == nil {
 		return nil, nil
 	}
-	if len(releaseInfo.IPFS) == 0 {
+	// the imported releases are already full and the offline nodes can not fetch them
+	if len(releaseInfo.IPFS) == 0 || len(releaseInfo.Manifest.Release.Commit) > 0 {
 		return releaseInfo, nil
 	}
 	if _, err := cid.Parse(releaseInfo.IPFS); err != nil {
@@ -632,26 +772,129 @@ func (sup *SupervisorService) Name() string {
 	return "supervisor"
 }
 
+// ReloadConfig implements services.ConfigReloader. The new resource limits and agent network
+// settings apply to the agent containers which are started afterwards. The reload signal is
+// passed to the service containers.
+func (sup *SupervisorService) ReloadConfig(cfg config.Config, report *config.ReloadReport) {
+	sup.mu.Lock()
+	defer sup.mu.Unlock()
+
+	sup.config.Config.Log.Level = cfg.Log.Level
+	sup.config.Config.ResourcesConfig = cfg.ResourcesConfig
+	sup.config.Config.RestartConfig = cfg.RestartConfig
+	sup.config.Config.AgentNetwork = cfg.AgentNetwork
+	sup.config.Config.AgentUser.User = cfg.AgentUser.User
+	sup.config.Config.AgentUser.Bots = cfg.AgentUser.Bots
+	if sup.restarts != nil {
+		sup.restarts.SetConfig(cfg.RestartConfig)
+	}
+	sup.lastConfigReload.Set()
+	sup.lastConfigReloadReport = report
+
+	if stale := sup.staleAgentsUnsafe(); len(stale) > 0 {
+		go sup.recreateAgents(stale)
+	}
+
+	for _, serviceContainer := range []*clients.DockerContainer{
+		sup.scannerContainer, sup.inspectorContainer, sup.jsonRpcContainer,
+		sup.jwtProviderContainer, sup.storageContainer,
+	} {
+		if serviceContainer == nil {
+			continue
+		}
+		if err := sup.client.ReloadContainer(sup.ctx, serviceContainer.ID); err != nil {
+			log.WithError(err).WithField("name", serviceContainer.Name).Error("failed to send reload signal")
+		}
+	}
+}
+
+// SetPaused implements services.Pauser. The containers keep running while paused and the
+// scanner is signaled to check the pause state.
+func (sup *SupervisorService) SetPaused(paused bool) {
+	sup.pause.SetPaused(paused)
+
+	sup.mu.RLock()
+	defer sup.mu.RUnlock()
+	if sup.scannerContainer == nil {
+		return // reads the pause state when started
+	}
+	if err := sup.client.SignalContainer(sup.ctx, sup.scannerContainer.ID, services.PauseStateSignalName); err != nil {
+		log.WithError(err).Error("failed to send the pause state signal to the scanner")
+	}
+}
+
+func (sup *SupervisorService) configReloadReports() health.Reports {
+	if sup.lastConfigReloadReport == nil {
+		return nil
+	}
+	restartStatus := health.StatusInfo
+	if len(sup.lastConfigReloadReport.RequiresRestart) > 0 {
+		restartStatus = health.StatusLagging
+	}
+	return health.Reports{
+		&health.Report{
+			Name:    "config-reload.applied",
+			Status:  health.StatusInfo,
+			Details: strings.Join(sup.lastConfigReloadReport.Applied, ", "),
+		},
+		&health.Report{
+			Name:    "config-reload.requires-restart",
+			Status:  restartStatus,
+			Details: strings.Join(sup.lastConfigReloadReport.RequiresRestart, ", "),
+		},
+	}
+}
+
 // Health implements the health.Reporter interface.
 func (sup *SupervisorService) Health() health.Reports {
+	// query before locking because the requests can take a while
+	statusReports := append(sup.serviceStatusReports(), sup.messagingReports()...)
+	if sup.imageScanner != nil {
+		statusReports = append(statusReports, sup.imageScanner.Health()...)
+	}
+	if sup.serviceHistory != nil {
+		statusReports = append(statusReports, sup.serviceHistory.Health()...)
+	}
+
 	sup.mu.RLock()
 	defer sup.mu.RUnlock()
 
+	degradedStatus := health.StatusOK
+	var degraded []string
+	if sup.restarts != nil {
+		degraded = sup.restarts.Stopped()
+	}
+	if len(degraded) > 0 {
+		degradedStatus = health.StatusFailing
+	}
+
+	// the service containers which are not restarted are not counted as managed
+	managedCount := len(sup.containers) - len(degraded)
 	containersStatus := health.StatusOK
-	if len(sup.containers) < config.DockerSupervisorManagedContainers {
+	if managedCount < config.DockerSupervisorManagedContainers {
 		containersStatus = health.StatusFailing
 	}
 
-	return health.Reports{
+	return append(health.Reports{
 		&health.Report{
 			Name:    "local-mode",
 			Status:  health.StatusInfo,
 			Details: strconv.FormatBool(sup.config.Config.LocalModeConfig.Enable),
 		},
+		&health.Report{
+			Name:    "agents.userns-remap",
+			Status:  health.StatusInfo,
+			Details: strconv.FormatBool(sup.usernsRemap),
+		},
 		&health.Report{
 			Name:    "containers.managed",
 			Status:  containersStatus,
-			Details: strconv.Itoa(len(sup.containers)),
+			Details: strconv.Itoa(managedCount),
+		},
+		&health.Report{
+			Name:    "containers.degraded",
+			Status:  degradedStatus,
+			Details: strings.Join(degraded, ", "),
 		},
 		&health.Report{
 			Name:    "event.run-agent.time",
@@ -669,11 +912,36 @@ func (sup *SupervisorService) Health() health.Reports {
 		sup.lastCustomTelemetryRequestError.GetReport("event.custom-telemetry-sync.error"),
 		sup.lastAgentLogsRequest.GetReport("event.agent-logs-sync.time"),
 		sup.lastAgentLogsRequestError.GetReport("event.agent-logs-sync.error"),
+		sup.lastHeartbeat.GetReport("event.heartbeat.time"),
+		sup.lastHeartbeatError.GetReport("event.heartbeat.error"),
+		sup.lastConfigReload.GetReport("event.config-reload.time"),
+		sup.eligibilityReport(),
+		sup.poolReport(),
+		sup.failedToInitializeReport(),
+		sup.pause.GetReport("paused"),
+	}, append(append(append(append(append(sup.configReloadReports(), sup.remoteConfigReports()...), sup.admissionReports()...), sup.containerEvents.Health()...), sup.inspectionActions.Health()...), statusReports...)...)
+}
+
+// messagingReports returns the health reports of the messaging client, e.g. the dead-letter depth.
+func (sup *SupervisorService) messagingReports() health.Reports {
+	reporter, ok := sup.getMsgClient().(health.Reporter)
+	if !ok {
+		return nil
 	}
+	return reporter.Health()
+}
+
+// getMsgClient returns the message client, which is nil until the supervisor starts nats.
+func (sup *SupervisorService) getMsgClient() clients.MessageClient {
+	sup.msgClientMu.RLock()
+	defer sup.msgClientMu.RUnlock()
+	return sup.msgClient
 }
 
 // handleInspectionResults listen for inspections.
 func (sup *SupervisorService) handleInspectionResults(payload *protocol.InspectionResults) error {
+	sup.handleInspectionActions(payload)
+
 	// do a non-blocking write because messages are consumed only at startup
 	select {
 	case sup.inspectionCh <- payload:
@@ -713,7 +981,7 @@ func NewSupervisorService(ctx context.Context, cfg SupervisorServiceConfig) (*Su
 		return nil, fmt.Errorf("failed to create the private docker client: %v", err)
 	}
 
-	return &SupervisorService{
+	sup := &SupervisorService{
 		ctx:              ctx,
 		client:           dockerClient,
 		globalClient:     globalClient,
@@ -723,5 +991,16 @@ func NewSupervisorService(ctx context.Context, cfg SupervisorServiceConfig) (*Su
 		healthClient:     health.NewClient(),
 		agentLogsClient:  agentlogs.NewClient(cfg.Config.AgentLogsConfig.URL),
 		inspectionCh:     make(chan *protocol.InspectionResults),
-	}, nil
+
+		failedToInitialize: make(map[string]bool),
+		rejectedBots:       make(map[string]config.AgentConfig),
+		containerEvents:    newContainerEventTracker(),
+		serviceHistory:     newServiceHistoryTracker(path.Join(cfg.Config.StateDir(), config.DefaultServiceHistoryFileName)),
+		inspectionActions:  newInspectionActionTracker(cfg.Config.InspectionConfig.Actions),
+	}
+	if cfg.Config.AgentImageScan.Enable {
+		sup.imageScanner = newImageScanner(ctx, cfg.Config.AgentImageScan)
+		sup.imageScanner.onFindings = sup.sendImageFindings
+	}
+	return sup, nil
 }
@@ -4,12 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/forta-network/forta-core-go/clients/health"
+	"github.com/forta-network/forta-core-go/protocol"
 	"github.com/forta-network/forta-node/clients"
 	"github.com/forta-network/forta-node/clients/messaging"
 	"github.com/forta-network/forta-node/config"
+	"github.com/forta-network/forta-node/metrics"
+	"github.com/forta-network/forta-node/tlsutils"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -22,8 +28,53 @@ const (
 	agentStartTimeout = time.Minute * 5
 )
 
+// ensureAgentImage builds the image of the local bot from the source or makes sure that
+// the local image exists, and pulls the image for the other bots.
+func (sup *SupervisorService) ensureAgentImage(ctx context.Context, agent config.AgentConfig) error {
+	switch {
+	case agent.Build != nil:
+		if err := sup.client.BuildImage(ctx, agent.Build.Source, agent.Build.Dockerfile, agent.Image); err != nil {
+			return fmt.Errorf("failed to build the image for agent %s: %v", agent.ID, err)
+		}
+		return nil
+	case agent.LocalImage:
+		if !sup.client.HasLocalImage(ctx, agent.Image) {
+			return fmt.Errorf("image %s of agent %s not found in the local docker daemon", agent.Image, agent.ID)
+		}
+		return nil
+	default:
+		return clients.EnsureImageWithPolicy(ctx, sup.agentImageClient, fmt.Sprintf("agent %s", agent.ID), agent.Image, sup.config.Config.ImagePull.Bots)
+	}
+}
+
+// checkAgentImage scans the image of the agent if the image scan is enabled and reports the vulnerabilities.
+// The agent pool is notified about the refused agents so that it does not wait for them.
+func (sup *SupervisorService) checkAgentImage(ctx context.Context, agent config.AgentConfig) error {
+	if sup.imageScanner == nil {
+		return nil
+	}
+	findings, err := sup.imageScanner.Check(ctx, agent)
+	sup.sendImageFindings(agent, findings)
+	if err != nil {
+		sup.getMsgClient().Publish(messaging.SubjectAgentsStatusRefused, messaging.AgentPayload{agent})
+	}
+	return err
+}
+
+func (sup *SupervisorService) sendImageFindings(agent config.AgentConfig, findings []string) {
+	if len(findings) == 0 {
+		return
+	}
+	metrics.SendAgentMetrics(sup.getMsgClient(), []*protocol.AgentMetric{
+		metrics.CreateAgentMetric(agent.ID, metrics.MetricImageFindings, float64(len(findings))),
+	})
+}
+
 func (sup *SupervisorService) startAgent(ctx context.Context, agent config.AgentConfig) error {
-	if err := sup.agentImageClient.EnsureLocalImage(ctx, fmt.Sprintf("agent %s", agent.ID), agent.Image); err != nil {
+	if err := sup.ensureAgentImage(ctx, agent); err != nil {
+		return err
+	}
+	if err := sup.checkAgentImage(ctx, agent); err != nil {
 		return err
 	}
 
@@ -35,42 +86,45 @@ func (sup *SupervisorService) startAgent(ctx context.Context, agent config.Agent
 		return errAgentAlreadyRunning
 	}
 
-	nwID, err := sup.client.CreatePublicNetwork(ctx, agent.ContainerName())
+	nwID, err := sup.createAgentNetwork(ctx, agent)
 	if err != nil {
 		return err
 	}
 
-	limits := config.GetAgentResourceLimits(sup.config.Config.ResourcesConfig)
+	if config.IsRootUser(sup.config.Config.AgentUser.GetUser(agent.ID)) {
+		log.WithField("agent", agent.ID).Warn("agent is configured to run as root")
+	}
 
-	agentContainer, err := sup.client.StartContainer(
-		ctx, clients.DockerContainerConfig{
-			Name:           agent.ContainerName(),
-			Image:          agent.Image,
-			NetworkID:      nwID,
-			LinkNetworkIDs: []string{},
-			Env: map[string]string{
-				config.EnvJsonRpcHost:     config.DockerJSONRPCProxyContainerName,
-				config.EnvJsonRpcPort:     config.DefaultJSONRPCProxyPort,
-				config.EnvJWTProviderHost: config.DockerJWTProviderContainerName,
-				config.EnvJWTProviderPort: config.DefaultJWTProviderPort,
-				config.EnvAgentGrpcPort:   agent.GrpcPort(),
-				config.EnvFortaBotID:      agent.ID,
-				config.EnvFortaBotOwner:   agent.Owner,
-				config.EnvFortaChainID:    fmt.Sprintf("%d", agent.ChainID),
-			},
-			MaxLogFiles: sup.maxLogFiles,
-			MaxLogSize:  sup.maxLogSize,
-			CPUQuota:    limits.CPUQuota,
-			Memory:      limits.Memory,
-			Labels: map[string]string{
-				clients.DockerLabelFortaSupervisorStrategyVersion: SupervisorStrategyVersion,
-			},
-		},
-	)
+	// the agents serve the scanner and send requests to the json-rpc proxy
+	files, err := sup.withTLSFiles(nil, tlsutils.RoleServer|tlsutils.RoleClient, agent.ContainerName())
+	if err != nil {
+		return err
+	}
+
+	volumes, err := sup.ensureAgentVolumes(ctx, agent)
 	if err != nil {
 		return err
 	}
 
+	secrets, err := sup.agentSecrets(agent)
+	if err != nil {
+		return err
+	}
+
+	// the existing container of the agent is recreated if the config is different
+	agentContainer, err := sup.client.StartContainer(ctx, sup.agentContainerConfig(agent, nwID, files, volumes, secrets))
+	if err != nil {
+		return err
+	}
+
+	// the bot does not run without the limits
+	if err := sup.limitAgentBandwidth(ctx, agentContainer.ID); err != nil {
+		if err := sup.client.RemoveContainer(ctx, agentContainer.ID); err != nil {
+			log.WithError(err).WithField("agent", agent.ID).Warn("failed to remove the agent container")
+		}
+		return err
+	}
+
 	// Attach the scanner, JWT Provider and the JSON-RPC proxy to the agent's network.
 	for _, containerID := range []string{
 		sup.scannerContainer.ID, sup.jsonRpcContainer.ID,
@@ -87,6 +141,144 @@ func (sup *SupervisorService) startAgent(ctx context.Context, agent config.Agent
 	return nil
 }
 
+// limitAgentBandwidth applies the bandwidth limits to the agent container if the limits are configured.
+func (sup *SupervisorService) limitAgentBandwidth(ctx context.Context, containerID string) error {
+	limits := config.GetAgentResourceLimits(sup.config.Config.ResourcesConfig)
+	bandwidth := clients.BandwidthLimits{EgressKbit: limits.EgressKbit, IngressKbit: limits.IngressKbit}
+	if bandwidth.IsZero() {
+		return nil
+	}
+	if err := sup.client.LimitContainerBandwidth(ctx, containerID, sup.nodeImage, bandwidth); err != nil {
+		return fmt.Errorf("failed to limit the agent bandwidth: %v", err)
+	}
+	return nil
+}
+
+// relimitAgentBandwidth applies the bandwidth limits again after the agent container restarts because
+// the restarted container gets a new network interface without the limits. The container is stopped
+// if the limits can not be applied.
+func (sup *SupervisorService) relimitAgentBandwidth(containerID string) {
+	ctx, cancel := context.WithTimeout(sup.ctx, agentStartTimeout)
+	defer cancel()
+	err := sup.limitAgentBandwidth(ctx, containerID)
+	if err == nil || sup.ctx.Err() != nil {
+		return
+	}
+	log.WithError(err).WithField("container", containerID).Error("stopping the restarted agent container")
+	if err := sup.client.StopContainer(ctx, containerID); err != nil {
+		log.WithError(err).WithField("container", containerID).Error("failed to stop the agent container")
+	}
+}
+
+// agentContainerConfig returns the container config of the agent by the current config.
+func (sup *SupervisorService) agentContainerConfig(
+	agent config.AgentConfig, nwID string, files map[string][]byte, volumes map[string]string, secrets map[string]string,
+) clients.DockerContainerConfig {
+	limits := config.GetAgentResourceLimits(sup.config.Config.ResourcesConfig)
+	containerResources := sup.config.Config.ResourcesConfig.GetAgentContainerResources(agent.ID)
+	dnsCfg := sup.config.Config.AgentNetwork.GetDNSConfig(agent.ID)
+	env := map[string]string{
+		config.EnvJsonRpcHost:     config.DockerJSONRPCProxyContainerName,
+		config.EnvJsonRpcPort:     config.DefaultJSONRPCProxyPort,
+		config.EnvJWTProviderHost: config.DockerJWTProviderContainerName,
+		config.EnvJWTProviderPort: config.DefaultJWTProviderPort,
+		config.EnvAgentGrpcPort:   agent.GrpcPort(),
+		config.EnvFortaBotID:      agent.ID,
+		config.EnvFortaBotOwner:   agent.Owner,
+		config.EnvFortaChainID:    fmt.Sprintf("%d", agent.ChainID),
+	}
+	var runtime string
+	if gpuCfg := sup.config.Config.ResourcesConfig.GPU; gpuCfg.IsBotEnabled(agent) {
+		runtime = gpuCfg.Runtime
+		env[config.EnvNvidiaVisibleDevices] = gpuCfg.VisibleDevices()
+		env[config.EnvNvidiaDriverCapabilities] = "compute,utility"
+	}
+	return clients.DockerContainerConfig{
+		Name:           agent.ContainerName(),
+		Image:          agent.Image,
+		NetworkID:      nwID,
+		LinkNetworkIDs: []string{},
+		Env:            sup.withBotEnv(sup.withTLSEnv(env), agent, secrets),
+		Files:          files,
+		Volumes:        volumes,
+		MaxLogFiles:    sup.maxLogFiles,
+		MaxLogSize:     sup.maxLogSize,
+		CPUQuota:       limits.CPUQuota,
+		Memory:         limits.Memory,
+		ShmSize:        int64(containerResources.ShmSizeMiB) * 1024 * 1024,
+		Tmpfs:          containerResources.Tmpfs,
+		Ulimits:        agentUlimits(containerResources.Ulimits),
+		Runtime:        runtime,
+		DNS:            dnsCfg.Servers,
+		DNSSearch:      dnsCfg.Search,
+		ExtraHosts:     dnsCfg.ExtraHosts,
+		User:           sup.config.Config.AgentUser.GetUser(agent.ID),
+		// agents cannot gain more privileges than the configured user
+		NoNewPrivileges: true,
+		Labels: sup.containerLabels(map[string]string{
+			clients.DockerLabelFortaSupervisorStrategyVersion: SupervisorStrategyVersion,
+			clients.DockerLabelFortaBotID:                     agent.ID,
+			clients.DockerLabelFortaBotImageHash:              agent.ImageHash(),
+			clients.DockerLabelFortaBotChainID:                fmt.Sprintf("%d", agent.ChainID),
+		}, sup.config.Config.ContainerLabels.AgentLabels),
+	}
+}
+
+// staleAgentsUnsafe returns the running agents which have a different container config than the current
+// config. It expects the lock to be held.
+func (sup *SupervisorService) staleAgentsUnsafe() []config.AgentConfig {
+	var stale []config.AgentConfig
+	for _, container := range sup.containers {
+		if !container.IsAgent || container.AgentConfig == nil {
+			continue
+		}
+		running := container.Config
+		secrets, err := sup.agentSecrets(*container.AgentConfig)
+		if err != nil {
+			agentLogger(*container.AgentConfig).WithError(err).Warn("failed to check the agent container config")
+			continue
+		}
+		current := sup.agentContainerConfig(*container.AgentConfig, running.NetworkID, running.Files, running.Volumes, secrets)
+		if current.ConfigHash() != running.ConfigHash() {
+			stale = append(stale, *container.AgentConfig)
+		}
+	}
+	return stale
+}
+
+// recreateAgents replaces the containers of the agents with the containers which have the current config.
+// The agent pool is notified so that it initializes the new containers.
+func (sup *SupervisorService) recreateAgents(agents []config.AgentConfig) {
+	var recreated messaging.AgentPayload
+	for _, agent := range agents {
+		logger := agentLogger(agent)
+		logger.Info("agent container config has changed - recreating")
+
+		// forget the container so that it is started again
+		sup.mu.Lock()
+		var remainingContainers []*Container
+		for _, container := range sup.containers {
+			if container.Name != agent.ContainerName() {
+				remainingContainers = append(remainingContainers, container)
+			}
+		}
+		sup.containers = remainingContainers
+		sup.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(sup.ctx, agentStartTimeout)
+		err := sup.startAgent(ctx, agent)
+		cancel()
+		if err != nil {
+			logger.WithError(err).Error("failed to recreate the agent container")
+			continue
+		}
+		recreated = append(recreated, agent)
+	}
+	if len(recreated) > 0 {
+		sup.getMsgClient().Publish(messaging.SubjectAgentsStatusRecreated, recreated)
+	}
+}
+
 func (sup *SupervisorService) getContainerUnsafe(name string) (*Container, bool) {
 	for _, container := range sup.containers {
 		if container.Name == name {
@@ -117,9 +309,24 @@ func (sup *SupervisorService) handleAgentRun(payload messaging.AgentPayload) err
 	return sup.handleAgentRunWithContext(startCtx, payload)
 }
 
+// admitAgentsToRun gives the failed bots another chance and admits the bots which can run.
+func (sup *SupervisorService) admitAgentsToRun(payload messaging.AgentPayload) (admitted, rejected messaging.AgentPayload) {
+	sup.mu.Lock()
+	defer sup.mu.Unlock()
+
+	// the bots get another chance when they are run again
+	for _, agent := range payload {
+		delete(sup.failedToInitialize, agent.ID)
+	}
+	return sup.admitAgents(payload)
+}
+
 func (sup *SupervisorService) handleAgentRunWithContext(ctx context.Context, payload messaging.AgentPayload) error {
 	sup.lastRun.Set()
 
+	payload, rejected := sup.admitAgentsToRun(payload)
+	sup.reportRejectedAgents(rejected)
+
 	log.WithFields(
 		log.Fields{
 			"payload": len(payload),
@@ -148,7 +355,7 @@ func (sup *SupervisorService) doStartAgent(ctx context.Context, agent config.Age
 	err := sup.startAgent(ctx, agent)
 	if err == errAgentAlreadyRunning {
 		logger.Infof("agent container is already running - skipped")
-		sup.msgClient.Publish(messaging.SubjectAgentsStatusRunning, messaging.AgentPayload{agent})
+		sup.getMsgClient().Publish(messaging.SubjectAgentsStatusRunning, messaging.AgentPayload{agent})
 		return
 	}
 	if err != nil {
@@ -157,7 +364,7 @@ func (sup *SupervisorService) doStartAgent(ctx context.Context, agent config.Age
 	}
 
 	// Broadcast the agent status.
-	sup.msgClient.Publish(messaging.SubjectAgentsStatusRunning, messaging.AgentPayload{agent})
+	sup.getMsgClient().Publish(messaging.SubjectAgentsStatusRunning, messaging.AgentPayload{agent})
 }
 
 func (sup *SupervisorService) handleAgentStop(payload messaging.AgentPayload) error {
@@ -169,6 +376,7 @@ func (sup *SupervisorService) handleAgentStop(payload messaging.AgentPayload) er
 	stopped := make(map[string]bool)
 	for _, agentCfg := range payload {
 		logger := agentLogger(agentCfg)
+		delete(sup.rejectedBots, agentCfg.ContainerName())
 
 		container, ok := sup.getContainerUnsafe(agentCfg.ContainerName())
 		if !ok {
@@ -180,6 +388,9 @@ func (sup *SupervisorService) handleAgentStop(payload messaging.AgentPayload) er
 		}
 		logger.Infof("successfully stopped the container")
 		stopped[container.ID] = true
+		if sup.imageScanner != nil {
+			sup.imageScanner.Remove(agentCfg.ID)
+		}
 	}
 
 	// Remove the stopped agents from the list.
@@ -193,16 +404,52 @@ func (sup *SupervisorService) handleAgentStop(payload messaging.AgentPayload) er
 
 	// Broadcast the agent statuses.
 	if len(payload) > 0 {
-		sup.msgClient.Publish(messaging.SubjectAgentsStatusStopped, payload)
+		sup.getMsgClient().Publish(messaging.SubjectAgentsStatusStopped, payload)
+	}
+	// the stopped bots leave capacity for the rejected bots
+	if len(stopped) > 0 && len(sup.rejectedBots) > 0 {
+		go sup.readmitRejectedAgents()
 	}
 	return nil
 }
 
+// handleAgentFailedToInitialize keeps track of the bots which failed the warm-up in the scanner
+// and reports them to the network as agent metrics.
+func (sup *SupervisorService) handleAgentFailedToInitialize(payload messaging.AgentPayload) error {
+	sup.mu.Lock()
+	defer sup.mu.Unlock()
+
+	var agentMetrics []*protocol.AgentMetric
+	for _, agentCfg := range payload {
+		agentLogger(agentCfg).Warn("bot failed to initialize")
+		sup.failedToInitialize[agentCfg.ID] = true
+		agentMetrics = append(agentMetrics, metrics.CreateAgentMetric(agentCfg.ID, metrics.MetricInitializeFailed, 1))
+	}
+	metrics.SendAgentMetrics(sup.getMsgClient(), agentMetrics)
+	return nil
+}
+
+// failedToInitializeReport expects the lock to be held.
+func (sup *SupervisorService) failedToInitializeReport() *health.Report {
+	var botIDs []string
+	for botID := range sup.failedToInitialize {
+		botIDs = append(botIDs, botID)
+	}
+	sort.Strings(botIDs)
+	return &health.Report{
+		Name:    "agents.failed-to-initialize",
+		Status:  health.StatusInfo,
+		Details: strings.Join(botIDs, ", "),
+	}
+}
+
 func (sup *SupervisorService) registerMessageHandlers() {
-	sup.msgClient.Subscribe(messaging.SubjectAgentsActionRun, messaging.AgentsHandler(sup.handleAgentRun))
-	sup.msgClient.Subscribe(messaging.SubjectAgentsActionStop, messaging.AgentsHandler(sup.handleAgentStop))
-	if sup.config.Config.InspectionConfig.InspectAtStartup {
-		sup.msgClient.Subscribe(messaging.SubjectInspectionDone, messaging.InspectionResultsHandler(sup.handleInspectionResults))
+	msgClient := sup.getMsgClient()
+	msgClient.Subscribe(messaging.SubjectAgentsActionRun, messaging.AgentsHandler(sup.handleAgentRun))
+	msgClient.Subscribe(messaging.SubjectAgentsActionStop, messaging.AgentsHandler(sup.handleAgentStop))
+	msgClient.Subscribe(messaging.SubjectAgentsStatusFailedToInitialize, messaging.AgentsHandler(sup.handleAgentFailedToInitialize))
+	if sup.config.Config.InspectionConfig.InspectAtStartup || sup.inspectionActions.HasRules() {
+		msgClient.Subscribe(messaging.SubjectInspectionDone, messaging.InspectionResultsHandler(sup.handleInspectionResults))
 	}
 }
 
@@ -213,3 +460,15 @@ func agentLogger(agent config.AgentConfig) *log.Entry {
 		},
 	)
 }
+
+// agentUlimits returns the configured ulimits of an agent container by the ulimit names.
+func agentUlimits(ulimitsCfg config.AgentUlimitsConfig) map[string]int64 {
+	ulimits := make(map[string]int64)
+	if ulimitsCfg.NoFile > 0 {
+		ulimits["nofile"] = ulimitsCfg.NoFile
+	}
+	if ulimitsCfg.NProc > 0 {
+		ulimits["nproc"] = ulimitsCfg.NProc
+	}
+	return ulimits
+}
@@ -2,10 +2,16 @@ package supervisor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/forta-network/forta-core-go/clients/health"
 	"github.com/forta-network/forta-core-go/release"
 
 	"github.com/docker/docker/api/types"
@@ -84,6 +90,10 @@ func (m configMatcher) Matches(x interface{}) bool {
 
 	}
 
+	if len(c2.User) > 0 && c1.User != c2.User {
+		return false
+	}
+
 	return c1.Name == c2.Name
 }
 
@@ -109,6 +119,11 @@ func (s *Suite) SetupTest() {
 		msgClient:        s.msgClient,
 		releaseClient:    s.releaseClient,
 		agentImageClient: s.agentImageClient,
+
+		failedToInitialize: make(map[string]bool),
+		rejectedBots:       make(map[string]config.AgentConfig),
+		containerEvents:    newContainerEventTracker(),
+		inspectionActions:  newInspectionActionTracker(nil),
 	}
 	service.config.Config.TelemetryConfig.Disable = true
 	service.config.Config.Log.Level = "debug"
@@ -170,6 +185,8 @@ func (s *Suite) SetupTest() {
 			},
 		),
 	).Return(&clients.DockerContainer{ID: testProxyContainerID}, nil)
+	s.dockerClient.EXPECT().IsUsernsRemapEnabled(service.ctx).Return(true, nil)
+	s.dockerClient.EXPECT().GetVolumes(service.ctx).Return(nil, nil)
 	s.dockerClient.EXPECT().HasLocalImage(service.ctx, gomock.Any()).Return(true).AnyTimes()
 	s.globalClient.EXPECT().GetContainerByName(service.ctx, config.DockerSupervisorContainerName).Return(&types.Container{ID: testSupervisorContainerID}, nil).AnyTimes()
 	s.dockerClient.EXPECT().AttachNetwork(service.ctx, testSupervisorContainerID, testNodeNetworkID)
@@ -185,6 +202,7 @@ func (s *Suite) SetupTest() {
 	s.dockerClient.EXPECT().WaitContainerStart(service.ctx, gomock.Any()).Return(nil).AnyTimes()
 	s.msgClient.EXPECT().Subscribe(messaging.SubjectAgentsActionRun, gomock.Any())
 	s.msgClient.EXPECT().Subscribe(messaging.SubjectAgentsActionStop, gomock.Any())
+	s.msgClient.EXPECT().Subscribe(messaging.SubjectAgentsStatusFailedToInitialize, gomock.Any())
 
 	s.r.NoError(service.start())
 }
@@ -271,6 +289,266 @@ func (s *Suite) TestAgentRun() {
 	s.r.NoError(s.service.handleAgentRunWithContext(ctx, agentPayload))
 }
 
+// TestAgentRunWithBandwidthLimits tests running the agent with the bandwidth limits.
+func (s *Suite) TestAgentRunWithBandwidthLimits() {
+	agentConfig, agentPayload := testAgentData()
+	s.service.nodeImage = "forta-node"
+	s.service.config.Config.ResourcesConfig.AgentMaxEgressMbps = 10
+	s.service.config.Config.ResourcesConfig.AgentMaxIngressMbps = 20.5
+
+	ctx, cancel := context.WithTimeout(s.service.ctx, agentStartTimeout)
+	defer cancel()
+	s.agentImageClient.EXPECT().EnsureLocalImage(ctx, "agent test-agent", agentConfig.Image).Return(nil)
+	s.dockerClient.EXPECT().CreatePublicNetwork(ctx, testAgentContainerName).Return(testAgentNetworkID, nil)
+	s.dockerClient.EXPECT().StartContainer(
+		ctx, (configMatcher)(clients.DockerContainerConfig{Name: agentConfig.ContainerName()}),
+	).Return(&clients.DockerContainer{Name: agentConfig.ContainerName(), ID: testAgentContainerID}, nil)
+	s.dockerClient.EXPECT().LimitContainerBandwidth(ctx, testAgentContainerID, "forta-node", clients.BandwidthLimits{
+		EgressKbit:  10000,
+		IngressKbit: 20500,
+	})
+
+	s.dockerClient.EXPECT().AttachNetwork(ctx, testScannerContainerID, testAgentNetworkID)
+	s.dockerClient.EXPECT().AttachNetwork(ctx, testProxyContainerID, testAgentNetworkID)
+	s.dockerClient.EXPECT().AttachNetwork(ctx, testJWTProviderContainerID, testAgentNetworkID)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusRunning, agentPayload)
+
+	s.r.NoError(s.service.handleAgentRunWithContext(ctx, agentPayload))
+}
+
+// TestAgentRunBandwidthLimitFailure tests that the agent does not run if the bandwidth limits can not be applied.
+func (s *Suite) TestAgentRunBandwidthLimitFailure() {
+	agentConfig, agentPayload := testAgentData()
+	s.service.nodeImage = "forta-node"
+	s.service.config.Config.ResourcesConfig.AgentMaxIngressMbps = 20
+
+	ctx, cancel := context.WithTimeout(s.service.ctx, agentStartTimeout)
+	defer cancel()
+	s.agentImageClient.EXPECT().EnsureLocalImage(ctx, "agent test-agent", agentConfig.Image).Return(nil)
+	s.dockerClient.EXPECT().CreatePublicNetwork(ctx, testAgentContainerName).Return(testAgentNetworkID, nil)
+	s.dockerClient.EXPECT().StartContainer(
+		ctx, (configMatcher)(clients.DockerContainerConfig{Name: agentConfig.ContainerName()}),
+	).Return(&clients.DockerContainer{Name: agentConfig.ContainerName(), ID: testAgentContainerID}, nil)
+	s.dockerClient.EXPECT().LimitContainerBandwidth(ctx, testAgentContainerID, "forta-node", clients.BandwidthLimits{
+		IngressKbit: 20000,
+	}).Return(errors.New("tc failed"))
+	s.dockerClient.EXPECT().RemoveContainer(ctx, testAgentContainerID).Return(nil)
+
+	s.r.NoError(s.service.handleAgentRunWithContext(ctx, agentPayload))
+	_, ok := s.service.getContainerUnsafe(agentConfig.ContainerName())
+	s.r.False(ok)
+}
+
+// TestRelimitAgentBandwidth tests that the restarted agent container is stopped if the bandwidth limits
+// can not be applied again.
+func (s *Suite) TestRelimitAgentBandwidth() {
+	s.service.nodeImage = "forta-node"
+	s.service.config.Config.ResourcesConfig.AgentMaxEgressMbps = 10
+
+	s.dockerClient.EXPECT().LimitContainerBandwidth(gomock.Any(), testAgentContainerID, "forta-node", clients.BandwidthLimits{
+		EgressKbit: 10000,
+	}).Return(errors.New("tc failed"))
+	s.dockerClient.EXPECT().StopContainer(gomock.Any(), testAgentContainerID).Return(nil)
+
+	s.service.relimitAgentBandwidth(testAgentContainerID)
+}
+
+// TestAgentRunRefusedImage tests that the agent pool is notified when the image scan refuses the agent.
+func (s *Suite) TestAgentRunRefusedImage() {
+	agentConfig, agentPayload := testAgentData()
+	s.service.imageScanner = newImageScanner(s.service.ctx, config.AgentImageScanConfig{
+		Enable:         true,
+		Command:        []string{"false"},
+		Policy:         config.ImageScanPolicyRefuse,
+		RefuseOnError:  true,
+		TimeoutSeconds: 10,
+	})
+
+	ctx, cancel := context.WithTimeout(s.service.ctx, agentStartTimeout)
+	defer cancel()
+	s.agentImageClient.EXPECT().EnsureLocalImage(ctx, "agent test-agent", agentConfig.Image).Return(nil)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusRefused, agentPayload)
+
+	s.r.NoError(s.service.handleAgentRunWithContext(ctx, agentPayload))
+}
+
+// TestAgentRunWithBuild tests building the image of a local bot before running it.
+func (s *Suite) TestAgentRunWithBuild() {
+	agentConfig, agentPayload := testAgentData()
+	agentConfig.LocalImage = true
+	agentConfig.Build = &config.AgentBuildConfig{Source: "/bots/test-agent", Dockerfile: "Dockerfile.dev"}
+	agentPayload[0] = agentConfig
+
+	ctx, cancel := context.WithTimeout(s.service.ctx, agentStartTimeout)
+	defer cancel()
+	s.dockerClient.EXPECT().BuildImage(ctx, "/bots/test-agent", "Dockerfile.dev", agentConfig.Image).Return(nil)
+	s.dockerClient.EXPECT().CreatePublicNetwork(ctx, testAgentContainerName).Return(testAgentNetworkID, nil)
+	s.dockerClient.EXPECT().StartContainer(
+		ctx, (configMatcher)(clients.DockerContainerConfig{Name: agentConfig.ContainerName()}),
+	).Return(&clients.DockerContainer{Name: agentConfig.ContainerName(), ID: testAgentContainerID}, nil)
+
+	s.dockerClient.EXPECT().AttachNetwork(ctx, testScannerContainerID, testAgentNetworkID)
+	s.dockerClient.EXPECT().AttachNetwork(ctx, testProxyContainerID, testAgentNetworkID)
+	s.dockerClient.EXPECT().AttachNetwork(ctx, testJWTProviderContainerID, testAgentNetworkID)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusRunning, agentPayload)
+
+	s.r.NoError(s.service.handleAgentRunWithContext(ctx, agentPayload))
+}
+
+// TestAgentRunWithMissingLocalImage tests that a local bot is not pulled if its image is missing.
+func (s *Suite) TestAgentRunWithMissingLocalImage() {
+	agentConfig, agentPayload := testAgentData()
+	agentConfig.LocalImage = true
+	agentPayload[0] = agentConfig
+
+	ctx, cancel := context.WithTimeout(s.service.ctx, agentStartTimeout)
+	defer cancel()
+	s.dockerClient.EXPECT().HasLocalImage(ctx, agentConfig.Image).Return(false)
+
+	s.r.Error(s.service.startAgent(ctx, agentConfig))
+}
+
+// TestAgentRunWithSharedNetwork tests running the agent in the shared network with a subnet from the address pools.
+func (s *Suite) TestAgentRunWithSharedNetwork() {
+	agentConfig, agentPayload := testAgentData()
+	s.service.config.Config.AgentNetwork.Shared = true
+	s.service.config.Config.AgentNetwork.AddressPools = []config.AgentAddressPoolConfig{{Base: "10.200.0.0/16", Size: 24}}
+	s.service.config.Config.AgentNetwork.ExcludedSubnets = []string{"10.200.1.0/24"}
+
+	ctx, cancel := context.WithTimeout(s.service.ctx, agentStartTimeout)
+	defer cancel()
+	s.agentImageClient.EXPECT().EnsureLocalImage(ctx, "agent test-agent", agentConfig.Image).Return(nil)
+	s.dockerClient.EXPECT().GetNetworkSubnets(ctx).Return([]string{"10.200.0.0/24"}, nil)
+	s.dockerClient.EXPECT().CreatePublicNetworkWithSubnet(ctx, config.DockerAgentsNetworkName, "10.200.2.0/24").Return(testAgentNetworkID, nil)
+	s.dockerClient.EXPECT().StartContainer(
+		ctx, (configMatcher)(clients.DockerContainerConfig{Name: agentConfig.ContainerName()}),
+	).Return(&clients.DockerContainer{Name: agentConfig.ContainerName(), ID: testAgentContainerID}, nil)
+
+	s.dockerClient.EXPECT().AttachNetwork(ctx, testScannerContainerID, testAgentNetworkID)
+	s.dockerClient.EXPECT().AttachNetwork(ctx, testProxyContainerID, testAgentNetworkID)
+	s.dockerClient.EXPECT().AttachNetwork(ctx, testJWTProviderContainerID, testAgentNetworkID)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusRunning, agentPayload)
+
+	s.r.NoError(s.service.handleAgentRunWithContext(ctx, agentPayload))
+}
+
+// TestAgentRunWithUser tests running the agent as the user configured for the bot.
+func (s *Suite) TestAgentRunWithUser() {
+	agentConfig, agentPayload := testAgentData()
+	s.service.config.Config.AgentUser.User = "65534:65534"
+	s.service.config.Config.AgentUser.Bots = map[string]string{testAgentID: "1000:1000"}
+
+	ctx, cancel := context.WithTimeout(s.service.ctx, agentStartTimeout)
+	defer cancel()
+	s.agentImageClient.EXPECT().EnsureLocalImage(ctx, "agent test-agent", agentConfig.Image).Return(nil)
+	s.dockerClient.EXPECT().CreatePublicNetwork(ctx, testAgentContainerName).Return(testAgentNetworkID, nil)
+	s.dockerClient.EXPECT().StartContainer(
+		ctx, (configMatcher)(clients.DockerContainerConfig{Name: agentConfig.ContainerName(), User: "1000:1000"}),
+	).Return(&clients.DockerContainer{Name: agentConfig.ContainerName(), ID: testAgentContainerID}, nil)
+
+	s.dockerClient.EXPECT().AttachNetwork(ctx, testScannerContainerID, testAgentNetworkID)
+	s.dockerClient.EXPECT().AttachNetwork(ctx, testProxyContainerID, testAgentNetworkID)
+	s.dockerClient.EXPECT().AttachNetwork(ctx, testJWTProviderContainerID, testAgentNetworkID)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusRunning, agentPayload)
+
+	s.r.NoError(s.service.handleAgentRunWithContext(ctx, agentPayload))
+}
+
+// TestAgentRunWithVolumes tests creating and mounting the volumes declared for the bot.
+func (s *Suite) TestAgentRunWithVolumes() {
+	agentConfig, agentPayload := testAgentData()
+	s.service.config.Config.AgentVolumes.Bots = map[string][]config.AgentVolumeConfig{
+		testAgentID: {{Name: "state", MountPath: "/data", SizeMB: 100, Driver: "pxd"}},
+	}
+	s.service.config.Config.AgentUser.User = "65534:65534"
+	s.service.nodeImage = "forta-node"
+	volumeName := agentVolumeName(testAgentID, "state", 0)
+
+	ctx, cancel := context.WithTimeout(s.service.ctx, agentStartTimeout)
+	defer cancel()
+	s.agentImageClient.EXPECT().EnsureLocalImage(ctx, "agent test-agent", agentConfig.Image).Return(nil)
+	s.dockerClient.EXPECT().CreatePublicNetwork(ctx, testAgentContainerName).Return(testAgentNetworkID, nil)
+	s.dockerClient.EXPECT().EnsureVolume(ctx, clients.DockerVolumeConfig{
+		Name:       volumeName,
+		Driver:     "pxd",
+		DriverOpts: map[string]string{"size": "100m"},
+		Labels:     map[string]string{clients.DockerLabelFortaBotID: testAgentID},
+		Owner:      "65534:65534",
+		InitImage:  "forta-node",
+	})
+	s.dockerClient.EXPECT().StartContainer(
+		ctx, (configMatcher)(clients.DockerContainerConfig{Name: agentConfig.ContainerName()}),
+	).DoAndReturn(func(ctx context.Context, containerConfig clients.DockerContainerConfig) (*clients.DockerContainer, error) {
+		s.r.Equal(map[string]string{volumeName: "/data"}, containerConfig.Volumes)
+		return &clients.DockerContainer{Name: agentConfig.ContainerName(), ID: testAgentContainerID}, nil
+	})
+
+	s.dockerClient.EXPECT().AttachNetwork(ctx, testScannerContainerID, testAgentNetworkID)
+	s.dockerClient.EXPECT().AttachNetwork(ctx, testProxyContainerID, testAgentNetworkID)
+	s.dockerClient.EXPECT().AttachNetwork(ctx, testJWTProviderContainerID, testAgentNetworkID)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusRunning, agentPayload)
+
+	s.r.NoError(s.service.handleAgentRunWithContext(ctx, agentPayload))
+}
+
+// TestVolumeSizeWithLocalDriver tests rejecting the volume size with the local driver.
+func (s *Suite) TestVolumeSizeWithLocalDriver() {
+	agentConfig, _ := testAgentData()
+	s.service.config.Config.AgentVolumes.Bots = map[string][]config.AgentVolumeConfig{
+		testAgentID: {{Name: "state", MountPath: "/data", SizeMB: 100}},
+	}
+
+	_, err := s.service.ensureAgentVolumes(s.service.ctx, agentConfig)
+	s.r.Error(err)
+	s.r.Contains(err.Error(), "'local' driver")
+}
+
+// TestReplicaVolumes tests that each replica of the bot gets its own volumes.
+func (s *Suite) TestReplicaVolumes() {
+	agentConfig, _ := testAgentData()
+	s.service.config.Config.AgentVolumes.Bots = map[string][]config.AgentVolumeConfig{
+		testAgentID: {{Name: "state", MountPath: "/data"}},
+	}
+
+	s.dockerClient.EXPECT().EnsureVolume(s.service.ctx, gomock.Any()).Times(2)
+	mounts, err := s.service.ensureAgentVolumes(s.service.ctx, agentConfig.WithReplica(0, 2))
+	s.r.NoError(err)
+	s.r.Equal(map[string]string{"forta-agent-" + testAgentID + "-state": "/data"}, mounts)
+	mounts, err = s.service.ensureAgentVolumes(s.service.ctx, agentConfig.WithReplica(1, 2))
+	s.r.NoError(err)
+	s.r.Equal(map[string]string{"forta-agent-" + testAgentID + "-state-replica-1": "/data"}, mounts)
+}
+
+// TestRemoveUndeclaredAgentVolumes tests removing the bot volumes which are no longer in the config.
+func (s *Suite) TestRemoveUndeclaredAgentVolumes() {
+	s.service.config.Config.AgentVolumes.Bots = map[string][]config.AgentVolumeConfig{
+		testAgentID: {{Name: "state", MountPath: "/data"}},
+	}
+	botLabels := map[string]string{clients.DockerLabelFortaBotID: testAgentID}
+	s.dockerClient.EXPECT().GetVolumes(s.service.ctx).Return([]*types.Volume{
+		{Name: agentVolumeName(testAgentID, "state", 0), Labels: botLabels},
+		{Name: agentVolumeName(testAgentID, "state", 1), Labels: botLabels},
+		{Name: agentVolumeName(testAgentID, "old", 0), Labels: botLabels},
+		{Name: agentVolumeName(testAgentID, "old", 2), Labels: botLabels},
+		{Name: "some-other-volume"},
+	}, nil)
+	s.dockerClient.EXPECT().RemoveVolume(s.service.ctx, agentVolumeName(testAgentID, "old", 0))
+	s.dockerClient.EXPECT().RemoveVolume(s.service.ctx, agentVolumeName(testAgentID, "old", 2))
+
+	s.service.removeUndeclaredAgentVolumes()
+}
+
+// TestRequireUsernsRemap tests failing when the user namespace remapping is required but not enabled.
+func (s *Suite) TestRequireUsernsRemap() {
+	s.service.config.Config.AgentUser.RequireUsernsRemap = true
+
+	s.dockerClient.EXPECT().IsUsernsRemapEnabled(s.service.ctx).Return(true, nil)
+	s.r.NoError(s.service.checkUsernsRemap())
+
+	s.dockerClient.EXPECT().IsUsernsRemapEnabled(s.service.ctx).Return(false, nil)
+	s.r.Error(s.service.checkUsernsRemap())
+}
+
 // TestAgentRunAgain tests running an agent twice.
 func (s *Suite) TestAgentRunAgain() {
 	s.TestAgentRun()
@@ -289,6 +567,95 @@ func (s *Suite) TestAgentRunAgain() {
 	s.r.NoError(s.service.handleAgentRunWithContext(startCtx, agentPayload))
 }
 
+// TestAgentRecreateOnConfigChange tests recreating the agent container when the container config changes.
+func (s *Suite) TestAgentRecreateOnConfigChange() {
+	agentConfig, agentPayload := testAgentData()
+	startContainer := func(ctx context.Context, cfg clients.DockerContainerConfig) (*clients.DockerContainer, error) {
+		return &clients.DockerContainer{Name: cfg.Name, ID: testAgentContainerID, Config: cfg}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(s.service.ctx, agentStartTimeout)
+	defer cancel()
+	s.agentImageClient.EXPECT().EnsureLocalImage(ctx, "agent test-agent", agentConfig.Image).Return(nil)
+	s.dockerClient.EXPECT().CreatePublicNetwork(ctx, testAgentContainerName).Return(testAgentNetworkID, nil)
+	s.dockerClient.EXPECT().StartContainer(ctx, gomock.Any()).DoAndReturn(startContainer)
+	s.dockerClient.EXPECT().AttachNetwork(ctx, gomock.Any(), testAgentNetworkID).Times(3)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusRunning, agentPayload)
+	s.r.NoError(s.service.handleAgentRunWithContext(ctx, agentPayload))
+
+	s.service.mu.Lock()
+	s.r.Empty(s.service.staleAgentsUnsafe())
+	s.service.config.Config.ResourcesConfig.AgentContainer.ShmSizeMiB = 256
+	stale := s.service.staleAgentsUnsafe()
+	s.service.mu.Unlock()
+	s.r.Equal([]config.AgentConfig{agentConfig}, stale)
+
+	s.agentImageClient.EXPECT().EnsureLocalImage(gomock.Any(), "agent test-agent", agentConfig.Image).Return(nil)
+	s.dockerClient.EXPECT().CreatePublicNetwork(gomock.Any(), testAgentContainerName).Return(testAgentNetworkID, nil)
+	s.dockerClient.EXPECT().StartContainer(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, cfg clients.DockerContainerConfig) (*clients.DockerContainer, error) {
+			s.r.Equal(int64(256*1024*1024), cfg.ShmSize)
+			return startContainer(ctx, cfg)
+		},
+	)
+	s.dockerClient.EXPECT().AttachNetwork(gomock.Any(), gomock.Any(), testAgentNetworkID).Times(3)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusRecreated, agentPayload)
+	s.service.recreateAgents(stale)
+
+	s.service.mu.Lock()
+	defer s.service.mu.Unlock()
+	s.r.Empty(s.service.staleAgentsUnsafe())
+	container, ok := s.service.getContainerUnsafe(agentConfig.ContainerName())
+	s.r.True(ok)
+	s.r.Equal(int64(256*1024*1024), container.Config.ShmSize)
+}
+
+// TestAgentContainerConfigGPU tests passing the GPUs to the bots which need them.
+func (s *Suite) TestAgentContainerConfigGPU() {
+	agentConfig, _ := testAgentData()
+	s.service.config.Config.ResourcesConfig.GPU = config.AgentGPUConfig{Enable: true, Runtime: "nvidia", Devices: []string{"1"}}
+
+	cfg := s.service.agentContainerConfig(agentConfig, testAgentNetworkID, nil, nil, nil)
+	s.r.Empty(cfg.Runtime)
+	s.r.NotContains(cfg.Env, config.EnvNvidiaVisibleDevices)
+
+	agentConfig.Capabilities = []string{config.AgentCapabilityGPU}
+	cfg = s.service.agentContainerConfig(agentConfig, testAgentNetworkID, nil, nil, nil)
+	s.r.Equal("nvidia", cfg.Runtime)
+	s.r.Equal("1", cfg.Env[config.EnvNvidiaVisibleDevices])
+}
+
+// TestAgentContainerConfigEnv tests injecting the extra env vars and the secrets into the bot containers.
+func (s *Suite) TestAgentContainerConfigEnv() {
+	agentConfig, _ := testAgentData()
+	s.service.config.Config.FortaDir = s.T().TempDir()
+	s.service.config.Config.AgentEnv.Bots = map[string]config.BotEnvConfig{
+		strings.ToUpper(agentConfig.ID): {
+			Vars:    map[string]string{"NETWORK": "testnet", config.EnvFortaBotID: "0x1234"},
+			Secrets: map[string]string{"API_KEY": "api-key"},
+		},
+	}
+
+	_, err := s.service.agentSecrets(agentConfig)
+	s.r.Error(err)
+
+	s.r.NoError(os.MkdirAll(s.service.config.Config.SecretsDir(), 0700))
+	s.r.NoError(ioutil.WriteFile(path.Join(s.service.config.Config.SecretsDir(), "api-key"), []byte("secret\n"), 0600))
+	secrets, err := s.service.agentSecrets(agentConfig)
+	s.r.NoError(err)
+
+	cfg := s.service.agentContainerConfig(agentConfig, testAgentNetworkID, nil, nil, secrets)
+	s.r.Equal("testnet", cfg.Env["NETWORK"])
+	s.r.Equal("secret", cfg.Env["API_KEY"])
+	// the node env vars are not overridden
+	s.r.Equal(agentConfig.ID, cfg.Env[config.EnvFortaBotID])
+
+	// the secrets are not read from outside of the secrets dir
+	s.service.config.Config.AgentEnv.Bots[strings.ToUpper(agentConfig.ID)].Secrets["API_KEY"] = "../config.yml"
+	_, err = s.service.agentSecrets(agentConfig)
+	s.r.Error(err)
+}
+
 // TestAgentStop tests stopping an agent.
 func (s *Suite) TestAgentStopOne() {
 	s.TestAgentRun()
@@ -309,3 +676,138 @@ func (s *Suite) TestAgentStopNone() {
 
 	s.r.NoError(s.service.handleAgentStop(agentPayload))
 }
+
+// TestAgentFailedToInitialize tests tracking the bots which failed to initialize.
+func (s *Suite) TestAgentFailedToInitialize() {
+	_, agentPayload := testAgentData()
+
+	s.msgClient.EXPECT().PublishProto(messaging.SubjectMetricAgent, gomock.Any())
+	s.r.NoError(s.service.handleAgentFailedToInitialize(agentPayload))
+
+	report := s.service.failedToInitializeReport()
+	s.r.Equal(testAgentID, report.Details)
+
+	// running the bot again should clear the state
+	s.TestAgentRun()
+	s.r.Empty(s.service.failedToInitializeReport().Details)
+}
+
+// TestAgentAdmission tests admitting the bots by priority when the capacity is exceeded.
+func (s *Suite) TestAgentAdmission() {
+	s.service.maxBots = 2
+	s.service.config.Config.ResourcesConfig.Admission.BotPriorities = map[string]int{"0xhigh": 10}
+	s.TestAgentRun()
+
+	agentConfig, _ := testAgentData()
+	payload := messaging.AgentPayload{
+		agentConfig,
+		{ID: "0xlow", Image: testImageRef},
+		{ID: "0xHIGH", Image: testImageRef},
+	}
+	s.service.mu.Lock()
+	admitted, rejected := s.service.admitAgents(payload)
+	s.service.mu.Unlock()
+
+	// the running bot does not need capacity and the bot with the higher priority gets the last slot
+	s.r.Equal(messaging.AgentPayload{agentConfig, payload[2]}, admitted)
+	s.r.Equal(messaging.AgentPayload{payload[1]}, rejected)
+
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusRefused, rejected)
+	s.msgClient.EXPECT().PublishProto(messaging.SubjectMetricAgent, gomock.Any())
+	s.service.reportRejectedAgents(rejected)
+
+	reports := s.service.admissionReports()
+	s.r.Equal("1/2", reports[0].Details)
+	s.r.Equal("0xlow", reports[1].Details)
+	s.r.Equal(health.StatusFailing, reports[1].Status)
+
+	// stopping the rejected bot clears the state
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusStopped, rejected)
+	s.r.NoError(s.service.handleAgentStop(rejected))
+	s.r.Empty(s.service.admissionReports()[1].Details)
+}
+
+// TestAgentReadmission tests that the rejected bots are run when a bot stops and leaves capacity.
+func (s *Suite) TestAgentReadmission() {
+	s.service.maxBots = 1
+	s.TestAgentRun()
+
+	// Given that a bot is rejected
+	lowPayload := messaging.AgentPayload{{ID: "0xlow", Image: testImageRef}}
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusRefused, lowPayload)
+	s.msgClient.EXPECT().PublishProto(messaging.SubjectMetricAgent, gomock.Any())
+	s.r.NoError(s.service.handleAgentRunWithContext(s.service.ctx, lowPayload))
+	s.r.Equal("0xlow", s.service.admissionReports()[1].Details)
+
+	// When the running bot stops
+	// Then the rejected bot should be run
+	_, agentPayload := testAgentData()
+	s.dockerClient.EXPECT().StopContainer(s.service.ctx, testAgentContainerID)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusStopped, agentPayload)
+
+	readmitted := make(chan struct{})
+	s.agentImageClient.EXPECT().EnsureLocalImage(gomock.Any(), gomock.Any(), testImageRef).Return(nil)
+	s.dockerClient.EXPECT().CreatePublicNetwork(gomock.Any(), lowPayload[0].ContainerName()).Return(testAgentNetworkID, nil)
+	s.dockerClient.EXPECT().StartContainer(gomock.Any(), gomock.Any()).
+		Return(&clients.DockerContainer{Name: lowPayload[0].ContainerName(), ID: "low-container-id"}, nil)
+	s.dockerClient.EXPECT().AttachNetwork(gomock.Any(), gomock.Any(), testAgentNetworkID).Times(3)
+	s.msgClient.EXPECT().Publish(messaging.SubjectAgentsStatusRunning, lowPayload).Do(func(string, interface{}) {
+		close(readmitted)
+	})
+	s.r.NoError(s.service.handleAgentStop(agentPayload))
+
+	select {
+	case <-readmitted:
+	case <-time.After(time.Second * 5):
+		s.r.FailNow("the rejected bot was not run")
+	}
+	s.service.mu.RLock()
+	defer s.service.mu.RUnlock()
+	s.r.Empty(s.service.admissionReports()[1].Details)
+}
+
+// TestServiceStatusReportsBeforeStart tests the health reports before the message client is ready.
+func (s *Suite) TestServiceStatusReportsBeforeStart() {
+	reports := (&SupervisorService{}).serviceStatusReports()
+	s.r.Len(reports, 2)
+	for _, report := range reports {
+		s.r.Equal(health.StatusUnknown, report.Status)
+	}
+}
+
+// TestConfigReloadReports tests the reload reports which are shown by "forta reload".
+func (s *Suite) TestConfigReloadReports() {
+	s.r.Nil(s.service.configReloadReports())
+
+	s.service.lastConfigReloadReport = &config.ReloadReport{
+		Applied:         []string{"log.level", "restart"},
+		RequiresRestart: []string{"chainId"},
+	}
+	reports := s.service.configReloadReports()
+	s.r.Len(reports, 2)
+	s.r.Equal("config-reload.applied", reports[0].Name)
+	s.r.Equal("log.level, restart", reports[0].Details)
+	s.r.Equal("config-reload.requires-restart", reports[1].Name)
+	s.r.Equal("chainId", reports[1].Details)
+	s.r.Equal(health.StatusLagging, reports[1].Status)
+}
+
+// TestAgentImagePullPolicy tests that the bot images are pulled according to the pull policy.
+func (s *Suite) TestAgentImagePullPolicy() {
+	ctx := context.Background()
+	agentConfig := config.AgentConfig{ID: "test-agent", Image: "test-image"}
+
+	s.service.config.Config.ImagePull.Bots = config.ImagePullNever
+	s.agentImageClient.EXPECT().HasLocalImage(ctx, agentConfig.Image).Return(false)
+	s.r.Error(s.service.ensureAgentImage(ctx, agentConfig))
+	s.agentImageClient.EXPECT().HasLocalImage(ctx, agentConfig.Image).Return(true)
+	s.r.NoError(s.service.ensureAgentImage(ctx, agentConfig))
+
+	// the local image is used if the pull fails
+	s.service.config.Config.ImagePull.Bots = config.ImagePullAlways
+	s.agentImageClient.EXPECT().PullImage(ctx, agentConfig.Image).Return(errors.New("registry unreachable"))
+	s.agentImageClient.EXPECT().EnsureLocalImage(ctx, "agent test-agent", agentConfig.Image).Return(nil)
+	s.r.NoError(s.service.ensureAgentImage(ctx, agentConfig))
+	s.agentImageClient.EXPECT().PullImage(ctx, agentConfig.Image).Return(nil)
+	s.r.NoError(s.service.ensureAgentImage(ctx, agentConfig))
+}
@@ -0,0 +1,107 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/forta-network/forta-node/clients"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultVolumeDriver = "local"
+	volumeSizeOpt       = "size"
+	replicaVolumeSuffix = "-replica-"
+)
+
+// agentVolumeName returns the Docker volume name of a bot volume. The name does not depend on the
+// image so that the state survives the bot updates. Each replica of the bot gets its own volume
+// so that the replicas do not write to the same files, and the first replica keeps the bot volume.
+func agentVolumeName(botID, volumeName string, replica uint) string {
+	name := fmt.Sprintf("forta-agent-%s-%s", strings.ToLower(botID), volumeName)
+	if replica > 0 {
+		name = fmt.Sprintf("%s%s%d", name, replicaVolumeSuffix, replica)
+	}
+	return name
+}
+
+// replicaVolumeBaseName returns the name of the bot volume which the replica volume belongs to.
+func replicaVolumeBaseName(name string) string {
+	if i := strings.LastIndex(name, replicaVolumeSuffix); i > 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// ensureAgentVolumes creates the volumes declared for the bot and returns the container mounts.
+func (sup *SupervisorService) ensureAgentVolumes(ctx context.Context, agent config.AgentConfig) (map[string]string, error) {
+	declared := sup.config.Config.AgentVolumes.GetVolumes(agent.ID)
+	if len(declared) == 0 {
+		return nil, nil
+	}
+	mounts := make(map[string]string)
+	for _, volume := range declared {
+		driver := volume.Driver
+		if len(driver) == 0 {
+			driver = defaultVolumeDriver
+		}
+		driverOpts := make(map[string]string)
+		for k, v := range volume.DriverOpts {
+			driverOpts[k] = v
+		}
+		if volume.SizeMB > 0 {
+			// the local driver rejects the size option
+			if driver == defaultVolumeDriver {
+				return nil, fmt.Errorf("volume '%s' has a size but the '%s' driver does not support it", volume.Name, driver)
+			}
+			driverOpts[volumeSizeOpt] = fmt.Sprintf("%dm", volume.SizeMB)
+		}
+		// the new volumes are owned by root and the bots usually run as a non-root user
+		var owner string
+		if user := sup.config.Config.AgentUser.GetUser(agent.ID); !config.IsRootUser(user) {
+			owner = user
+		}
+		name := agentVolumeName(agent.ID, volume.Name, agent.ReplicaIndex())
+		err := sup.client.EnsureVolume(ctx, clients.DockerVolumeConfig{
+			Name:       name,
+			Driver:     driver,
+			DriverOpts: driverOpts,
+			Labels: sup.containerLabels(map[string]string{
+				clients.DockerLabelFortaBotID: agent.ID,
+			}, sup.config.Config.ContainerLabels.AgentLabels),
+			Owner:     owner,
+			InitImage: sup.nodeImage,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create volume '%s': %v", name, err)
+		}
+		mounts[name] = volume.MountPath
+	}
+	return mounts, nil
+}
+
+// removeUndeclaredAgentVolumes removes the bot volumes which are no longer declared in the config.
+func (sup *SupervisorService) removeUndeclaredAgentVolumes() {
+	volumes, err := sup.client.GetVolumes(sup.ctx)
+	if err != nil {
+		log.WithError(err).Warn("failed to get the agent volumes")
+		return
+	}
+	declared := make(map[string]bool)
+	for botID, botVolumes := range sup.config.Config.AgentVolumes.Bots {
+		for _, volume := range botVolumes {
+			declared[agentVolumeName(botID, volume.Name, 0)] = true
+		}
+	}
+	for _, volume := range volumes {
+		// the volumes of the replicas are kept with the bot volume
+		if _, ok := volume.Labels[clients.DockerLabelFortaBotID]; !ok || declared[replicaVolumeBaseName(volume.Name)] {
+			continue
+		}
+		if err := sup.client.RemoveVolume(sup.ctx, volume.Name); err != nil {
+			log.WithError(err).WithField("volume", volume.Name).Warn("failed to remove the undeclared agent volume")
+		}
+	}
+}
@@ -9,9 +9,11 @@ import (
 	"net/http"
 	"path"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/blang/semver/v4"
 	"github.com/forta-network/forta-core-go/clients/health"
 	"github.com/forta-network/forta-core-go/registry"
 	"github.com/forta-network/forta-core-go/release"
@@ -19,6 +21,7 @@ import (
 
 	"github.com/forta-network/forta-node/config"
 	"github.com/forta-network/forta-node/nodeutils"
+	"github.com/forta-network/forta-node/store"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -39,8 +42,14 @@ type UpdaterService struct {
 	registryClient registry.Client
 	server         *http.Server
 
-	developmentMode  bool
-	trackPrereleases bool
+	developmentMode bool
+	// follows the releases imported from the update bundles
+	offline        bool
+	releaseChannel string
+	// release versions by reference, for resolving the release channel
+	releaseVersions map[string]string
+	// the newest release candidate which the rc channel keeps following while the prerelease is not one
+	latestRCRef string
 
 	latestReference string
 	latestRelease   *release.ReleaseManifest
@@ -58,25 +67,33 @@ type UpdaterService struct {
 
 // NewUpdaterService creates a new updater service.
 func NewUpdaterService(ctx context.Context, registryClient registry.Client, releaseClient release.Client,
-	port string, developmentMode bool, trackPrereleases bool, updateDelaySeconds, updateCheckIntervalSeconds int,
+	port string, developmentMode, offline bool, releaseChannel string, updateDelaySeconds, updateCheckIntervalSeconds int,
 ) *UpdaterService {
 	if updateCheckIntervalSeconds == 0 {
 		updateCheckIntervalSeconds = defaultUpdateCheckIntervalSeconds
 	}
 
-	return &UpdaterService{
+	updater := &UpdaterService{
 		ctx:                 ctx,
 		port:                port,
 		releaseClient:       releaseClient,
 		registryClient:      registryClient,
 		developmentMode:     developmentMode,
-		trackPrereleases:    trackPrereleases,
+		offline:             offline,
+		releaseChannel:      releaseChannel,
+		releaseVersions:     make(map[string]string),
 		updateDelay:         time.Duration(updateDelaySeconds) * time.Second,
 		updateCheckInterval: time.Duration(updateCheckIntervalSeconds) * time.Second,
 		errCounter: nodeutils.NewErrorCounter(uint(maxConsecutiveUpdateErrors), func(err error) bool {
 			return err != nil // all non-nil errors are critical errors
 		}),
 	}
+	// keeps following the running release candidate after a restart
+	if len(config.ReleaseCid) > 0 && isPrerelease(config.Version) && isReleaseCandidate(config.Version) {
+		updater.latestRCRef = config.ReleaseCid
+		updater.releaseVersions[config.ReleaseCid] = config.Version
+	}
+	return updater
 }
 
 func (updater *UpdaterService) handleGetVersion(w http.ResponseWriter, r *http.Request) {
@@ -155,9 +172,12 @@ func (updater *UpdaterService) updateLatestReleaseWithDelay(delay time.Duration)
 		releaseManifest *release.ReleaseManifest
 		err             error
 	)
-	if updater.developmentMode {
+	switch {
+	case updater.developmentMode:
 		releaseRef, releaseManifest, err = updater.readLocalRelease(latestReference)
-	} else {
+	case updater.offline:
+		releaseRef, releaseManifest, err = updater.readImportedRelease(latestReference)
+	default:
 		releaseRef, releaseManifest, err = updater.fetchNewerRelease(latestReference)
 	}
 	switch err {
@@ -175,7 +195,8 @@ func (updater *UpdaterService) updateLatestReleaseWithDelay(delay time.Duration)
 	}
 
 	// so that all scanners don't update simultaneously, this waits a period of time
-	if delay > 0 {
+	// the imported releases are applied right away since the operator decides when to import them
+	if delay > 0 && !updater.offline {
 		log.WithFields(log.Fields{
 			"release": releaseRef, "delay": delay,
 		}).Info("delaying update")
@@ -189,7 +210,7 @@ func (updater *UpdaterService) updateLatestReleaseWithDelay(delay time.Duration)
 	}
 
 	updater.latestVersion.Set(releaseManifest.Release.Version)
-	updater.latestIsPrerelease.Set(strconv.FormatBool(updater.trackPrereleases))
+	updater.latestIsPrerelease.Set(strconv.FormatBool(isPrerelease(releaseManifest.Release.Version)))
 
 	updater.mu.Lock()
 	defer updater.mu.Unlock()
@@ -221,12 +242,7 @@ func (updater *UpdaterService) compareScannerNodeVersion(previousRef string) (ne
 		return
 	}
 
-	var ref string
-	if updater.trackPrereleases {
-		ref, err = updater.registryClient.GetScannerNodePrereleaseVersion()
-	} else {
-		ref, err = updater.registryClient.GetScannerNodeVersion()
-	}
+	ref, err := updater.getReleaseChannelRef()
 	if err != nil {
 		log.WithError(err).Error("error getting the latest release manifest ref")
 		return "", fmt.Errorf("failed to get the latest release manifest ref: %v", err)
@@ -237,6 +253,96 @@ func (updater *UpdaterService) compareScannerNodeVersion(previousRef string) (ne
 	return ref, nil
 }
 
+// getReleaseChannelRef returns the latest release reference of the release channel. The rc channel
+// follows the newest release candidate until there is a newer stable release.
+func (updater *UpdaterService) getReleaseChannelRef() (string, error) {
+	switch updater.releaseChannel {
+	case config.ReleaseChannelCanary:
+		return updater.registryClient.GetScannerNodePrereleaseVersion()
+
+	case config.ReleaseChannelRC:
+		ref, err := updater.registryClient.GetScannerNodePrereleaseVersion()
+		if err != nil {
+			return "", err
+		}
+		version, err := updater.getReleaseVersion(ref)
+		if err != nil {
+			return "", err
+		}
+		updater.mu.Lock()
+		if isPrerelease(version) && isReleaseCandidate(version) {
+			updater.latestRCRef = ref
+		}
+		rcRef := updater.latestRCRef
+		updater.mu.Unlock()
+
+		stableRef, err := updater.registryClient.GetScannerNodeVersion()
+		if err != nil {
+			return "", err
+		}
+		if len(rcRef) == 0 || rcRef == stableRef {
+			return stableRef, nil
+		}
+		rcVersion, err := updater.getReleaseVersion(rcRef)
+		if err != nil {
+			return "", err
+		}
+		stableVersion, err := updater.getReleaseVersion(stableRef)
+		if err != nil {
+			return "", err
+		}
+		if isNewerVersion(rcVersion, stableVersion) {
+			return rcRef, nil
+		}
+		return stableRef, nil
+
+	default:
+		return updater.registryClient.GetScannerNodeVersion()
+	}
+}
+
+func (updater *UpdaterService) getReleaseVersion(ref string) (string, error) {
+	updater.mu.RLock()
+	version, ok := updater.releaseVersions[ref]
+	updater.mu.RUnlock()
+	if ok {
+		return version, nil
+	}
+	rm, err := updater.releaseClient.GetReleaseManifest(updater.ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the prerelease manifest: %v", err)
+	}
+	updater.mu.Lock()
+	updater.releaseVersions[ref] = rm.Release.Version
+	updater.mu.Unlock()
+	return rm.Release.Version, nil
+}
+
+// isPrerelease tells if the version has a prerelease suffix like "v1.2.3-rc.1".
+func isPrerelease(version string) bool {
+	return strings.Contains(version, "-")
+}
+
+// isNewerVersion tells if the version is newer than the other version. The release candidates are older
+// than the stable release of the same version.
+func isNewerVersion(version, other string) bool {
+	v, err := semver.ParseTolerant(version)
+	if err != nil {
+		return false
+	}
+	o, err := semver.ParseTolerant(other)
+	if err != nil {
+		return false
+	}
+	return v.GT(o)
+}
+
+// isReleaseCandidate tells if the version is a stable release or a release candidate.
+func isReleaseCandidate(version string) bool {
+	parts := strings.SplitN(version, "-", 2)
+	return len(parts) == 1 || strings.HasPrefix(parts[1], "rc")
+}
+
 func (updater *UpdaterService) checkNewerReleaseAndWait(previousRef string, delay time.Duration) (foundNew bool) {
 	detectedCh := make(chan struct{})
 
@@ -287,6 +393,23 @@ func (updater *UpdaterService) readLocalRelease(previousRef string) (string, *re
 	return currentRef, &release, nil
 }
 
+// readImportedRelease reads the release imported with 'forta update import'.
+func (updater *UpdaterService) readImportedRelease(previousRef string) (string, *release.ReleaseManifest, error) {
+	releaseInfo, err := store.ReadImportedRelease(config.DefaultContainerFortaDirPath)
+	if err != nil {
+		return "", nil, err
+	}
+	if releaseInfo == nil {
+		log.Info("no imported release yet")
+		return "", nil, errNotAvailable
+	}
+	currentRef := releaseInfo.IPFS
+	if currentRef == previousRef {
+		return currentRef, nil, errNotAvailable
+	}
+	return currentRef, &releaseInfo.Manifest, nil
+}
+
 // Name returns the name of the service.
 func (updater *UpdaterService) Name() string {
 	return "updater"
@@ -318,5 +441,10 @@ func (updater *UpdaterService) Health() health.Reports {
 		updater.lastErr.GetReport("event.checked.error"),
 		updater.latestVersion.GetReport("latest.version"),
 		updater.latestIsPrerelease.GetReport("latest.is-prerelease"),
+		&health.Report{
+			Name:    "release.channel",
+			Status:  health.StatusInfo,
+			Details: updater.releaseChannel,
+		},
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/forta-network/forta-core-go/release"
+	"github.com/forta-network/forta-node/config"
 	"github.com/stretchr/testify/require"
 
 	rm "github.com/forta-network/forta-core-go/registry/mocks"
@@ -23,7 +24,7 @@ func TestUpdaterService_UpdateLatestRelease(t *testing.T) {
 	registryClient := rm.NewMockClient(gomock.NewController(t))
 	releaseClient := im.NewMockClient(gomock.NewController(t))
 	updater := NewUpdaterService(
-		context.Background(), registryClient, releaseClient, "8080", false, false,
+		context.Background(), registryClient, releaseClient, "8080", false, false, config.ReleaseChannelStable,
 		testUpdateDelaySeconds, testUpdateCheckIntervalSeconds,
 	)
 
@@ -40,7 +41,7 @@ func TestUpdaterService_UpdateLatestReleaseCached(t *testing.T) {
 	registryClient := rm.NewMockClient(gomock.NewController(t))
 	releaseClient := im.NewMockClient(gomock.NewController(t))
 	updater := NewUpdaterService(
-		context.Background(), registryClient, releaseClient, "8080", false, false,
+		context.Background(), registryClient, releaseClient, "8080", false, false, config.ReleaseChannelStable,
 		testUpdateDelaySeconds, testUpdateCheckIntervalSeconds,
 	)
 
@@ -60,7 +61,7 @@ func TestUpdaterService_UpdateLatestReleaseNotCached(t *testing.T) {
 	registryClient := rm.NewMockClient(gomock.NewController(t))
 	releaseClient := im.NewMockClient(gomock.NewController(t))
 	updater := NewUpdaterService(
-		context.Background(), registryClient, releaseClient, "8080", false, false,
+		context.Background(), registryClient, releaseClient, "8080", false, false, config.ReleaseChannelStable,
 		testUpdateDelaySeconds, testUpdateCheckIntervalSeconds,
 	)
 
@@ -81,7 +82,7 @@ func TestUpdaterService_UpdateLatestReleaseAbort(t *testing.T) {
 	registryClient := rm.NewMockClient(gomock.NewController(t))
 	releaseClient := im.NewMockClient(gomock.NewController(t))
 	updater := NewUpdaterService(
-		context.Background(), registryClient, releaseClient, "8080", false, false,
+		context.Background(), registryClient, releaseClient, "8080", false, false, config.ReleaseChannelStable,
 		testUpdateDelaySeconds, testUpdateCheckIntervalSeconds,
 	)
 
@@ -98,3 +99,70 @@ func TestUpdaterService_UpdateLatestReleaseAbort(t *testing.T) {
 	// update should be ineffective and be aborted
 	r.Equal(initalLatestRef, updater.latestReference)
 }
+
+func TestUpdaterService_ReleaseChannels(t *testing.T) {
+	r := require.New(t)
+
+	registryClient := rm.NewMockClient(gomock.NewController(t))
+	releaseClient := im.NewMockClient(gomock.NewController(t))
+	updater := NewUpdaterService(
+		context.Background(), registryClient, releaseClient, "8080", false, false, config.ReleaseChannelCanary,
+		testUpdateDelaySeconds, testUpdateCheckIntervalSeconds,
+	)
+
+	// canary follows all prereleases
+	registryClient.EXPECT().GetScannerNodePrereleaseVersion().Return("canary", nil)
+	ref, err := updater.getReleaseChannelRef()
+	r.NoError(err)
+	r.Equal("canary", ref)
+
+	// rc follows the prereleases only if they are release candidates
+	updater.releaseChannel = config.ReleaseChannelRC
+	registryClient.EXPECT().GetScannerNodePrereleaseVersion().Return("canary", nil)
+	releaseClient.EXPECT().GetReleaseManifest(gomock.Any(), "canary").Return(&release.ReleaseManifest{
+		Release: release.Release{Version: "v0.8.0-canary.3"},
+	}, nil)
+	registryClient.EXPECT().GetScannerNodeVersion().Return("stable", nil)
+	ref, err = updater.getReleaseChannelRef()
+	r.NoError(err)
+	r.Equal("stable", ref)
+
+	registryClient.EXPECT().GetScannerNodePrereleaseVersion().Return("rc", nil)
+	releaseClient.EXPECT().GetReleaseManifest(gomock.Any(), "rc").Return(&release.ReleaseManifest{
+		Release: release.Release{Version: "v0.8.0-rc.1"},
+	}, nil).Times(1)
+	registryClient.EXPECT().GetScannerNodeVersion().Return("stable", nil)
+	releaseClient.EXPECT().GetReleaseManifest(gomock.Any(), "stable").Return(&release.ReleaseManifest{
+		Release: release.Release{Version: "v0.7.9"},
+	}, nil).Times(1)
+	ref, err = updater.getReleaseChannelRef()
+	r.NoError(err)
+	r.Equal("rc", ref)
+
+	// the version is cached by reference
+	registryClient.EXPECT().GetScannerNodePrereleaseVersion().Return("rc", nil)
+	registryClient.EXPECT().GetScannerNodeVersion().Return("stable", nil)
+	ref, err = updater.getReleaseChannelRef()
+	r.NoError(err)
+	r.Equal("rc", ref)
+
+	// a newer prerelease which is not a release candidate does not take the node back to stable
+	registryClient.EXPECT().GetScannerNodePrereleaseVersion().Return("canary-2", nil)
+	releaseClient.EXPECT().GetReleaseManifest(gomock.Any(), "canary-2").Return(&release.ReleaseManifest{
+		Release: release.Release{Version: "v0.8.1-canary.1"},
+	}, nil)
+	registryClient.EXPECT().GetScannerNodeVersion().Return("stable", nil)
+	ref, err = updater.getReleaseChannelRef()
+	r.NoError(err)
+	r.Equal("rc", ref)
+
+	// until the stable release of the release candidate
+	registryClient.EXPECT().GetScannerNodePrereleaseVersion().Return("canary-2", nil)
+	registryClient.EXPECT().GetScannerNodeVersion().Return("stable-2", nil)
+	releaseClient.EXPECT().GetReleaseManifest(gomock.Any(), "stable-2").Return(&release.ReleaseManifest{
+		Release: release.Release{Version: "v0.8.0"},
+	}, nil)
+	ref, err = updater.getReleaseChannelRef()
+	r.NoError(err)
+	r.Equal("stable-2", ref)
+}
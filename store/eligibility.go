@@ -0,0 +1,164 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/forta-network/forta-core-go/contracts/merged/contract_scanner_pool_registry"
+	"github.com/forta-network/forta-core-go/registry"
+	"github.com/forta-network/forta-node/config"
+)
+
+var errScannerContractsNotReady = errors.New("scanner pool registry contracts are not ready")
+
+// scannerPoolRegistry contains the scanner pool registry calls needed for the eligibility checks.
+type scannerPoolRegistry interface {
+	GetScannerState(opts *bind.CallOpts, scanner common.Address) (*contract_scanner_pool_registry.GetScannerStateOutput, error)
+	GetManagedStakeThreshold(opts *bind.CallOpts, managedId *big.Int) (*contract_scanner_pool_registry.GetManagedStakeThresholdOutput, error)
+	OwnerOf(opts *bind.CallOpts, subject *big.Int) (common.Address, error)
+}
+
+// ScannerEligibility contains the registration and the stake state of a scanner.
+type ScannerEligibility struct {
+	Registered        bool
+	Disabled          bool
+	Operational       bool
+	ChainID           int64
+	ExpectedChainID   int64
+	PoolID            string
+	PoolOwner         string
+	ExpectedPool      config.ScannerPoolConfig
+	AllocatedStake    *big.Int
+	MinStake          *big.Int
+	StakeThresholdSet bool
+}
+
+// CheckScannerEligibility queries the registry to see if the scanner is registered on the expected chain
+// and to the expected pool, and has enough stake allocated to receive bots.
+func CheckScannerEligibility(
+	regClient registry.Client, scannerAddr string, expectedChainID int64, expectedPool config.ScannerPoolConfig,
+) (*ScannerEligibility, error) {
+	contracts := regClient.Contracts()
+	if contracts == nil || contracts.ScannerPoolReg == nil {
+		return nil, errScannerContractsNotReady
+	}
+	return checkScannerEligibility(regClient, contracts.ScannerPoolReg, scannerAddr, expectedChainID, expectedPool)
+}
+
+func checkScannerEligibility(
+	regClient registry.Client, poolReg scannerPoolRegistry, scannerAddr string, expectedChainID int64,
+	expectedPool config.ScannerPoolConfig,
+) (*ScannerEligibility, error) {
+	state, err := poolReg.GetScannerState(nil, common.HexToAddress(scannerAddr))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scanner state: %v", err)
+	}
+	eligibility := &ScannerEligibility{
+		Registered:      state.Registered,
+		Disabled:        state.Disabled,
+		Operational:     state.Operational,
+		ExpectedChainID: expectedChainID,
+		ExpectedPool:    expectedPool,
+	}
+	if state.ChainId != nil {
+		eligibility.ChainID = state.ChainId.Int64()
+	}
+	if !eligibility.Registered {
+		return eligibility, nil
+	}
+
+	scanner, err := regClient.GetPoolScanner(scannerAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool scanner: %v", err)
+	}
+	if scanner == nil {
+		return eligibility, nil
+	}
+	eligibility.PoolID = scanner.PoolID
+
+	poolID, ok := new(big.Int).SetString(scanner.PoolID, 10)
+	if !ok {
+		return eligibility, nil
+	}
+	owner, err := poolReg.OwnerOf(nil, poolID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool owner: %v", err)
+	}
+	eligibility.PoolOwner = owner.Hex()
+	eligibility.AllocatedStake, err = regClient.GetAllocatedStakePerManaged(nil, poolID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allocated stake: %v", err)
+	}
+	threshold, err := poolReg.GetManagedStakeThreshold(nil, big.NewInt(eligibility.ChainID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stake threshold: %v", err)
+	}
+	eligibility.MinStake = threshold.Min
+	eligibility.StakeThresholdSet = threshold.Activated
+	return eligibility, nil
+}
+
+// Problems returns actionable messages about why the scanner is not eligible to scan.
+func (se *ScannerEligibility) Problems() (problems []string) {
+	if !se.Registered {
+		return []string{"not registered - please register this scanner to a pool with 'forta register-pool'"}
+	}
+	if expectedPoolID := se.ExpectedPool.PoolID; len(expectedPoolID) > 0 && se.PoolID != expectedPoolID {
+		problems = append(problems, fmt.Sprintf(
+			"registered to pool %s but configured to run under pool %s", se.PoolID, expectedPoolID,
+		))
+	}
+	if expectedOwner := se.ExpectedPool.OwnerAddress; len(expectedOwner) > 0 && len(se.PoolOwner) > 0 &&
+		!strings.EqualFold(se.PoolOwner, expectedOwner) {
+		problems = append(problems, fmt.Sprintf(
+			"pool %s is owned by %s but the configured owner is %s", se.PoolID, se.PoolOwner, expectedOwner,
+		))
+	}
+	if se.ExpectedChainID != 0 && se.ChainID != se.ExpectedChainID {
+		problems = append(problems, fmt.Sprintf(
+			"registered for chain %d but configured to scan chain %d", se.ChainID, se.ExpectedChainID,
+		))
+	}
+	if se.Disabled {
+		problems = append(problems, fmt.Sprintf("disabled in pool %s - please enable it from the pool", se.PoolID))
+	}
+	if se.StakeThresholdSet && se.AllocatedStake != nil && se.MinStake != nil && se.AllocatedStake.Cmp(se.MinStake) < 0 {
+		missing := new(big.Int).Sub(se.MinStake, se.AllocatedStake)
+		problems = append(problems, fmt.Sprintf(
+			"below min stake by %s FORT (allocated: %s FORT, min: %s FORT) - please stake more on pool %s",
+			formatFORT(missing), formatFORT(se.AllocatedStake), formatFORT(se.MinStake), se.PoolID,
+		))
+	}
+	if len(problems) == 0 && !se.Operational {
+		problems = append(problems, "not operational - please check the pool stake and the scanner status")
+	}
+	return
+}
+
+// Membership describes the pool membership and the stake allocation of the scanner.
+func (se *ScannerEligibility) Membership() string {
+	if !se.Registered || len(se.PoolID) == 0 {
+		return "not registered"
+	}
+	membership := fmt.Sprintf("pool %s", se.PoolID)
+	if len(se.PoolOwner) > 0 {
+		membership += fmt.Sprintf(", owner %s", se.PoolOwner)
+	}
+	if se.AllocatedStake != nil {
+		membership += fmt.Sprintf(", allocated stake %s FORT", formatFORT(se.AllocatedStake))
+	}
+	if se.StakeThresholdSet && se.MinStake != nil {
+		membership += fmt.Sprintf(" (min %s FORT)", formatFORT(se.MinStake))
+	}
+	return membership
+}
+
+// formatFORT formats the wei amount as FORT.
+func formatFORT(wei *big.Int) string {
+	f := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18))
+	return f.Text('f', 2)
+}
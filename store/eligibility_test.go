@@ -0,0 +1,180 @@
+package store
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/forta-network/forta-core-go/contracts/merged/contract_scanner_pool_registry"
+	"github.com/forta-network/forta-core-go/registry"
+	rm "github.com/forta-network/forta-core-go/registry/mocks"
+	"github.com/forta-network/forta-node/config"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func fort(amount int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(amount), big.NewInt(1e18))
+}
+
+func TestScannerEligibility_Problems(t *testing.T) {
+	r := require.New(t)
+
+	r.Contains((&ScannerEligibility{}).Problems()[0], "not registered")
+
+	eligible := &ScannerEligibility{
+		Registered:        true,
+		Operational:       true,
+		ChainID:           1,
+		ExpectedChainID:   1,
+		PoolID:            "5",
+		AllocatedStake:    fort(600),
+		MinStake:          fort(500),
+		StakeThresholdSet: true,
+	}
+	r.Empty(eligible.Problems())
+
+	belowStake := *eligible
+	belowStake.Operational = false
+	belowStake.AllocatedStake = fort(450)
+	problems := belowStake.Problems()
+	r.Len(problems, 1)
+	r.Contains(problems[0], "below min stake by 50.00 FORT")
+
+	wrongChain := *eligible
+	wrongChain.ChainID = 137
+	problems = wrongChain.Problems()
+	r.Len(problems, 1)
+	r.Equal("registered for chain 137 but configured to scan chain 1", problems[0])
+
+	wrongPool := *eligible
+	wrongPool.PoolOwner = "0x3DC45b47B7559Ca3b231E5384D825F9B461A0398"
+	wrongPool.ExpectedPool = config.ScannerPoolConfig{
+		PoolID:       "6",
+		OwnerAddress: "0x3dc45b47b7559ca3b231e5384d825f9b461a0398",
+	}
+	problems = wrongPool.Problems()
+	r.Len(problems, 1)
+	r.Equal("registered to pool 5 but configured to run under pool 6", problems[0])
+
+	wrongPool.ExpectedPool = config.ScannerPoolConfig{OwnerAddress: "0x56E0D5E5DfE7Ecd5Ba5c2Dcd2a1E9f6bF9C5A12c"}
+	problems = wrongPool.Problems()
+	r.Len(problems, 1)
+	r.Contains(problems[0], "but the configured owner is 0x56E0D5E5DfE7Ecd5Ba5c2Dcd2a1E9f6bF9C5A12c")
+}
+
+func TestScannerEligibility_Membership(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("not registered", (&ScannerEligibility{}).Membership())
+
+	eligibility := &ScannerEligibility{
+		Registered:        true,
+		PoolID:            "5",
+		PoolOwner:         "0x3DC45b47B7559Ca3b231E5384D825F9B461A0398",
+		AllocatedStake:    fort(600),
+		MinStake:          fort(500),
+		StakeThresholdSet: true,
+	}
+	r.Equal(
+		"pool 5, owner 0x3DC45b47B7559Ca3b231E5384D825F9B461A0398, allocated stake 600.00 FORT (min 500.00 FORT)",
+		eligibility.Membership(),
+	)
+}
+
+type testPoolRegistry struct {
+	state     contract_scanner_pool_registry.GetScannerStateOutput
+	threshold contract_scanner_pool_registry.GetManagedStakeThresholdOutput
+	owner     common.Address
+}
+
+func (reg *testPoolRegistry) GetScannerState(opts *bind.CallOpts, scanner common.Address) (*contract_scanner_pool_registry.GetScannerStateOutput, error) {
+	return &reg.state, nil
+}
+
+func (reg *testPoolRegistry) GetManagedStakeThreshold(opts *bind.CallOpts, managedId *big.Int) (*contract_scanner_pool_registry.GetManagedStakeThresholdOutput, error) {
+	return &reg.threshold, nil
+}
+
+func (reg *testPoolRegistry) OwnerOf(opts *bind.CallOpts, subject *big.Int) (common.Address, error) {
+	return reg.owner, nil
+}
+
+func TestCheckScannerEligibility(t *testing.T) {
+	const scannerAddr = "0x3DC45b47B7559Ca3b231E5384D825F9B461A0398"
+
+	tests := []struct {
+		name           string
+		state          contract_scanner_pool_registry.GetScannerStateOutput
+		allocatedStake *big.Int
+		expectedPool   config.ScannerPoolConfig
+		problem        string
+	}{
+		{
+			name:    "unregistered",
+			problem: "not registered",
+		},
+		{
+			name:           "wrong chain",
+			state:          contract_scanner_pool_registry.GetScannerStateOutput{Registered: true, Operational: true, ChainId: big.NewInt(137)},
+			allocatedStake: fort(600),
+			problem:        "registered for chain 137 but configured to scan chain 1",
+		},
+		{
+			name:           "below stake",
+			state:          contract_scanner_pool_registry.GetScannerStateOutput{Registered: true, ChainId: big.NewInt(1)},
+			allocatedStake: fort(450),
+			problem:        "below min stake by 50.00 FORT",
+		},
+		{
+			name:           "wrong pool",
+			state:          contract_scanner_pool_registry.GetScannerStateOutput{Registered: true, Operational: true, ChainId: big.NewInt(1)},
+			allocatedStake: fort(600),
+			expectedPool:   config.ScannerPoolConfig{PoolID: "6"},
+			problem:        "registered to pool 5 but configured to run under pool 6",
+		},
+		{
+			name:           "wrong owner",
+			state:          contract_scanner_pool_registry.GetScannerStateOutput{Registered: true, Operational: true, ChainId: big.NewInt(1)},
+			allocatedStake: fort(600),
+			expectedPool:   config.ScannerPoolConfig{PoolID: "5", OwnerAddress: "0x56E0D5E5DfE7Ecd5Ba5c2Dcd2a1E9f6bF9C5A12c"},
+			problem:        "pool 5 is owned by 0x3DC45b47B7559Ca3b231E5384D825F9B461A0398",
+		},
+		{
+			name:           "eligible",
+			state:          contract_scanner_pool_registry.GetScannerStateOutput{Registered: true, Operational: true, ChainId: big.NewInt(1)},
+			allocatedStake: fort(600),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+
+			regClient := rm.NewMockClient(gomock.NewController(t))
+			poolReg := &testPoolRegistry{
+				state: test.state,
+				threshold: contract_scanner_pool_registry.GetManagedStakeThresholdOutput{
+					Min: fort(500), Max: fort(10000), Activated: true,
+				},
+				owner: common.HexToAddress(scannerAddr),
+			}
+			if test.state.Registered {
+				regClient.EXPECT().GetPoolScanner(scannerAddr).Return(&registry.Scanner{PoolID: "5"}, nil)
+				regClient.EXPECT().GetAllocatedStakePerManaged(gomock.Nil(), gomock.Any()).Return(test.allocatedStake, nil)
+			}
+
+			eligibility, err := checkScannerEligibility(regClient, poolReg, scannerAddr, 1, test.expectedPool)
+			r.NoError(err)
+
+			problems := eligibility.Problems()
+			if test.problem == "" {
+				r.Empty(problems)
+				return
+			}
+			r.Len(problems, 1)
+			r.Contains(problems[0], test.problem)
+		})
+	}
+}
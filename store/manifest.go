@@ -0,0 +1,329 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/forta-network/forta-core-go/ipfs"
+	"github.com/forta-network/forta-core-go/manifest"
+	"github.com/forta-network/forta-node/config"
+	"github.com/ipfs/go-cid"
+	chunker "github.com/ipfs/go-ipfs-chunker"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/ipfs/go-unixfs/importer/balanced"
+	"github.com/ipfs/go-unixfs/importer/helpers"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultManifestCacheDirName = ".manifests"
+
+var errNoAllowedGateways = errors.New("no allowed ipfs gateways")
+
+// IPFSFileStore fetches the content-addressed files (bot manifests, documentation) from
+// multiple IPFS gateways and keeps them in a local cache.
+type IPFSFileStore interface {
+	manifest.Client
+	GetFile(ctx context.Context, reference string) ([]byte, error)
+}
+
+type ipfsGateway struct {
+	url    string
+	client ipfs.Client
+}
+
+type ipfsFileStore struct {
+	gateways []*ipfsGateway
+	cacheDir string
+
+	memCache map[string][]byte
+	mu       sync.RWMutex
+}
+
+// NewIPFSFileStore creates a new IPFS file store which tries the configured gateway first
+// and then the fallback gateways, if the operator has configured any. The gateways which
+// are not allowed are ignored.
+func NewIPFSFileStore(cfg config.Config) (*ipfsFileStore, error) {
+	gatewayURLs := append([]string{cfg.Registry.IPFS.GatewayURL}, cfg.Registry.Manifest.FallbackGatewayURLs...)
+
+	var gateways []*ipfsGateway
+	for _, gatewayURL := range gatewayURLs {
+		if len(gatewayURL) == 0 {
+			continue
+		}
+		if !isAllowedGateway(cfg.Registry.Manifest.AllowedGatewayHosts, gatewayURL) {
+			log.WithField("gateway", gatewayURL).Warn("ipfs gateway is not in the allowlist - ignoring")
+			continue
+		}
+		client, err := ipfs.NewClient(gatewayURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create ipfs client for gateway '%s': %v", gatewayURL, err)
+		}
+		gateways = append(gateways, &ipfsGateway{url: gatewayURL, client: client})
+	}
+	if len(gateways) == 0 {
+		return nil, errNoAllowedGateways
+	}
+
+	var cacheDir string
+	if !cfg.Registry.Manifest.DisableCache && len(cfg.FortaDir) > 0 {
+		cacheDir = path.Join(cfg.StateDir(), defaultManifestCacheDirName)
+		if err := os.MkdirAll(cacheDir, 0755); err != nil {
+			log.WithError(err).Warn("failed to create the manifest cache dir - using memory cache only")
+			cacheDir = ""
+		}
+	}
+
+	return &ipfsFileStore{
+		gateways: gateways,
+		cacheDir: cacheDir,
+		memCache: make(map[string][]byte),
+	}, nil
+}
+
+func isAllowedGateway(allowedHosts []string, gatewayURL string) bool {
+	if len(allowedHosts) == 0 {
+		return true
+	}
+	u, err := url.Parse(gatewayURL)
+	if err != nil {
+		return false
+	}
+	for _, host := range allowedHosts {
+		if u.Host == host || u.Hostname() == host {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAgentManifest implements manifest.Client.
+func (fs *ipfsFileStore) GetAgentManifest(ctx context.Context, reference string) (*manifest.SignedAgentManifest, error) {
+	var m manifest.SignedAgentManifest
+	_, err := fs.getFile(ctx, reference, func(b []byte) error {
+		if err := json.Unmarshal(b, &m); err != nil {
+			return fmt.Errorf("failed to decode manifest: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// manifestCapabilities is the part of the manifest which declares the node capabilities which the bot
+// needs, like "gpu". It is not a part of the manifest schema so it is decoded separately.
+type manifestCapabilities struct {
+	Manifest struct {
+		Capabilities []string `json:"capabilities"`
+	} `json:"manifest"`
+}
+
+// getManifestCapabilities returns the capabilities which the manifest declares. The manifest file is
+// already cached after the manifest is loaded.
+func getManifestCapabilities(ctx context.Context, mc manifest.Client, reference string) ([]string, error) {
+	fs, ok := mc.(IPFSFileStore)
+	if !ok {
+		return nil, nil
+	}
+	b, err := fs.GetFile(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	var m manifestCapabilities
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %v", err)
+	}
+	return m.Manifest.Capabilities, nil
+}
+
+// cacheDocumentation fetches the bot documentation into the cache so that the operators can read it
+// later with 'forta bots describe' without depending on the gateways.
+func cacheDocumentation(ctx context.Context, mc manifest.Client, agentID string, m *manifest.AgentManifest) {
+	fs, ok := mc.(IPFSFileStore)
+	if !ok || m.Documentation == nil || len(*m.Documentation) == 0 {
+		return
+	}
+	if _, err := fs.GetFile(ctx, *m.Documentation); err != nil {
+		log.WithError(err).WithField("agent", agentID).Warn("failed to cache the bot documentation")
+	}
+}
+
+// GetFile gets the file from the cache or from the first gateway which can serve it.
+func (fs *ipfsFileStore) GetFile(ctx context.Context, reference string) ([]byte, error) {
+	return fs.getFile(ctx, reference, nil)
+}
+
+// getFile gets the file and caches it only after it is verified against the cid
+// and accepted by the decode function.
+func (fs *ipfsFileStore) getFile(ctx context.Context, reference string, decode func([]byte) error) ([]byte, error) {
+	// only content-addressed references are safe to cache forever
+	c, err := cid.Parse(reference)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cid '%s': %v", reference, err)
+	}
+
+	validate := func(b []byte) error {
+		if err := verifyContent(c, b); err != nil {
+			return err
+		}
+		if decode != nil {
+			return decode(b)
+		}
+		return nil
+	}
+
+	if b, ok := fs.getCached(reference); ok {
+		err := validate(b)
+		if err == nil {
+			return b, nil
+		}
+		log.WithField("reference", reference).WithError(err).Warn("invalid file in manifest cache - fetching again")
+		fs.removeCached(reference)
+	}
+
+	var errs []error
+	for _, gateway := range fs.gateways {
+		logger := log.WithFields(log.Fields{
+			"gateway":   gateway.url,
+			"reference": reference,
+		})
+		b, err := gateway.client.GetBytes(ctx, reference)
+		if err == nil {
+			err = validate(b)
+		}
+		if err == nil {
+			fs.putCached(reference, b)
+			return b, nil
+		}
+		logger.WithError(err).Warn("failed to get file from ipfs gateway - trying next")
+		errs = append(errs, fmt.Errorf("%s: %v", gateway.url, err))
+	}
+	return nil, fmt.Errorf("failed to get file '%s' from all gateways: %v", reference, errs)
+}
+
+// verifyContent checks the content hash against the cid. The raw cids address the file content
+// directly. The dag-pb cids address the unixfs dag which the gateway has built the file from, so
+// the dag is built again from the content with the default importer settings. The files which
+// were added with other settings and the other cid types cannot be verified and are rejected.
+func verifyContent(c cid.Cid, b []byte) error {
+	var (
+		sum cid.Cid
+		err error
+	)
+	switch c.Type() {
+	case cid.Raw:
+		sum, err = c.Prefix().Sum(b)
+	case cid.DagProtobuf:
+		sum, err = unixfsCid(c.Prefix(), b)
+	default:
+		return fmt.Errorf("unsupported cid type: %d", c.Type())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to hash the content: %v", err)
+	}
+	if !sum.Equals(c) {
+		return fmt.Errorf("content does not match the cid: %s", sum.String())
+	}
+	return nil
+}
+
+// unixfsCid builds the unixfs dag of the content like 'ipfs add' does by default and returns
+// the root cid. The v1 cids are built with raw leaves as implied by '--cid-version=1'.
+func unixfsCid(prefix cid.Prefix, b []byte) (cid.Cid, error) {
+	params := helpers.DagBuilderParams{
+		Maxlinks:   helpers.DefaultLinksPerBlock,
+		RawLeaves:  prefix.Version > 0,
+		CidBuilder: prefix,
+		Dagserv:    discardDAGService{},
+	}
+	db, err := params.New(chunker.DefaultSplitter(bytes.NewReader(b)))
+	if err != nil {
+		return cid.Undef, err
+	}
+	root, err := balanced.Layout(db)
+	if err != nil {
+		return cid.Undef, err
+	}
+	return root.Cid(), nil
+}
+
+// discardDAGService drops the nodes since only the root cid is needed.
+type discardDAGService struct{}
+
+func (discardDAGService) Get(ctx context.Context, c cid.Cid) (ipld.Node, error) {
+	return nil, ipld.ErrNotFound{Cid: c}
+}
+
+func (discardDAGService) GetMany(ctx context.Context, cids []cid.Cid) <-chan *ipld.NodeOption {
+	ch := make(chan *ipld.NodeOption)
+	close(ch)
+	return ch
+}
+
+func (discardDAGService) Add(ctx context.Context, node ipld.Node) error {
+	return nil
+}
+
+func (discardDAGService) AddMany(ctx context.Context, nodes []ipld.Node) error {
+	return nil
+}
+
+func (discardDAGService) Remove(ctx context.Context, c cid.Cid) error {
+	return nil
+}
+
+func (discardDAGService) RemoveMany(ctx context.Context, cids []cid.Cid) error {
+	return nil
+}
+
+func (fs *ipfsFileStore) getCached(reference string) ([]byte, bool) {
+	fs.mu.RLock()
+	b, ok := fs.memCache[reference]
+	fs.mu.RUnlock()
+	if ok {
+		return b, true
+	}
+	if len(fs.cacheDir) == 0 {
+		return nil, false
+	}
+	b, err := os.ReadFile(path.Join(fs.cacheDir, reference))
+	if err != nil {
+		return nil, false
+	}
+	fs.mu.Lock()
+	fs.memCache[reference] = b
+	fs.mu.Unlock()
+	return b, true
+}
+
+func (fs *ipfsFileStore) putCached(reference string, b []byte) {
+	fs.mu.Lock()
+	fs.memCache[reference] = b
+	fs.mu.Unlock()
+	if len(fs.cacheDir) == 0 {
+		return
+	}
+	if err := os.WriteFile(path.Join(fs.cacheDir, reference), b, 0644); err != nil {
+		log.WithError(err).WithField("reference", reference).Warn("failed to write to manifest cache")
+	}
+}
+
+func (fs *ipfsFileStore) removeCached(reference string) {
+	fs.mu.Lock()
+	delete(fs.memCache, reference)
+	fs.mu.Unlock()
+	if len(fs.cacheDir) == 0 {
+		return
+	}
+	if err := os.Remove(path.Join(fs.cacheDir, reference)); err != nil && !os.IsNotExist(err) {
+		log.WithError(err).WithField("reference", reference).Warn("failed to remove from manifest cache")
+	}
+}
@@ -0,0 +1,161 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-core-go/manifest"
+	"github.com/forta-network/forta-core-go/utils"
+	"github.com/forta-network/forta-node/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const imageManifestTimeout = time.Second * 30
+
+// RejectedBot is a bot which was rejected because it does not conform to the manifest schema
+// or the local policy.
+type RejectedBot struct {
+	ID       string `json:"id"`
+	Manifest string `json:"manifest"`
+	Reason   string `json:"reason"`
+}
+
+// validateManifest checks the manifest against the schema and the local policy.
+func validateManifest(cfg config.Config, agentID string, agentData *manifest.SignedAgentManifest) error {
+	if err := agentData.Validate(); err != nil {
+		return err
+	}
+	m := agentData.Manifest
+	if m.AgentID != nil && !strings.EqualFold(*m.AgentID, agentID) {
+		return fmt.Errorf("manifest.agentId '%s' does not match the bot id", *m.AgentID)
+	}
+	// the chain settings are not part of the schema or the local policy, so the unusual values
+	// are only logged and the bot still runs with the default sharding
+	for key, chainSettings := range m.ChainSettings {
+		lg := log.WithFields(log.Fields{
+			"botId": agentID,
+			"key":   key,
+		})
+		if _, err := strconv.Atoi(key); key != keyDefaultChainSetting && err != nil {
+			lg.Warn("manifest.chainSettings has an invalid key")
+		}
+		if chainSettings.Target > 0 && chainSettings.Shards < minShardCount {
+			lg.Warn("manifest.chainSettings has a target but no shards")
+		}
+	}
+
+	policy := cfg.Registry.Manifest.Policy
+	for _, field := range policy.RequiredFields {
+		if !hasManifestField(m, field) {
+			return fmt.Errorf("manifest.%s is required by the local policy", field)
+		}
+	}
+	if len(policy.AllowedRegistries) > 0 {
+		imageRef, _ := utils.SplitImageRef(*m.ImageReference)
+		if parts := strings.Split(imageRef, "/"); len(parts) > 1 && !isAllowedRegistry(policy.AllowedRegistries, parts[0]) {
+			return fmt.Errorf("registry '%s' is not allowed by the local policy", parts[0])
+		}
+	}
+	return nil
+}
+
+func hasManifestField(m *manifest.AgentManifest, field string) bool {
+	var value *string
+	switch field {
+	case "from":
+		value = m.From
+	case "name":
+		value = m.Name
+	case "agentIdHash":
+		value = m.AgentIDHash
+	case "version":
+		value = m.Version
+	case "timestamp":
+		value = m.Timestamp
+	case "repository":
+		value = m.Repository
+	case "documentation":
+		value = m.Documentation
+	case "chainIds":
+		return len(m.ChainIDs) > 0
+	}
+	return value != nil && len(*value) > 0
+}
+
+func isAllowedRegistry(allowedRegistries []string, host string) bool {
+	for _, allowedRegistry := range allowedRegistries {
+		if strings.EqualFold(allowedRegistry, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkImageSize checks the image size before pulling the image. Only the policy violations
+// make the bot invalid and the other errors cause loading the bot to be retried.
+func checkImageSize(ctx context.Context, cfg config.Config, image string) error {
+	maxSizeMB := cfg.Registry.Manifest.Policy.MaxImageSizeMB
+	if maxSizeMB == 0 {
+		return nil
+	}
+	size, err := getRegistryImageSize(ctx, cfg.Registry, image)
+	if err != nil {
+		return fmt.Errorf("failed to get the image size: %v", err)
+	}
+	if size > int64(maxSizeMB)*1024*1024 {
+		return fmt.Errorf("%w: image size %dMB exceeds the %dMB limit of the local policy", errInvalidBot, size/1024/1024, maxSizeMB)
+	}
+	return nil
+}
+
+// getRegistryImageSize sums the compressed layer sizes from the image manifest in the registry.
+func getRegistryImageSize(ctx context.Context, cfg config.RegistryConfig, image string) (int64, error) {
+	imageRef, digest := utils.SplitImageRef(image)
+	parts := strings.SplitN(imageRef, "/", 2)
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid image reference: %s", image)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, imageManifestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodGet, fmt.Sprintf("https://%s/v2/%s/manifests/sha256:%s", parts[0], parts[1], digest), nil,
+	)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/vnd.docker.distribution.manifest.v2+json, application/vnd.oci.image.manifest.v1+json")
+	if len(cfg.Username) > 0 {
+		req.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var imageManifest struct {
+		Config struct {
+			Size int64 `json:"size"`
+		} `json:"config"`
+		Layers []struct {
+			Size int64 `json:"size"`
+		} `json:"layers"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&imageManifest); err != nil {
+		return 0, fmt.Errorf("failed to decode the image manifest: %v", err)
+	}
+	size := imageManifest.Config.Size
+	for _, layer := range imageManifest.Layers {
+		size += layer.Size
+	}
+	return size, nil
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/forta-network/forta-core-go/manifest"
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
+)
+
+func testManifest(imageRef string) *manifest.SignedAgentManifest {
+	return &manifest.SignedAgentManifest{Manifest: &manifest.AgentManifest{ImageReference: &imageRef}}
+}
+
+func TestValidateManifest(t *testing.T) {
+	r := require.New(t)
+
+	var cfg config.Config
+	r.NoError(validateManifest(cfg, "0x01", testManifest(testManifestImage)))
+
+	otherID := "0x02"
+	agentData := testManifest(testManifestImage)
+	agentData.Manifest.AgentID = &otherID
+	r.Error(validateManifest(cfg, "0x01", agentData))
+
+	agentData = testManifest(testManifestImage)
+	agentData.Manifest.ChainSettings = map[string]manifest.AgentChainSettings{"mainnet": {}, "1": {Target: 2}}
+	// the unusual chain settings do not reject the bot
+	r.NoError(validateManifest(cfg, "0x01", agentData))
+
+	cfg.Registry.Manifest.Policy.RequiredFields = []string{"repository", "chainIds"}
+	err := validateManifest(cfg, "0x01", testManifest(testManifestImage))
+	r.Error(err)
+	r.Contains(err.Error(), "manifest.repository")
+	repository := "https://github.com/forta-network/forta-bot"
+	agentData = testManifest(testManifestImage)
+	agentData.Manifest.Repository = &repository
+	agentData.Manifest.ChainIDs = []int64{1}
+	r.NoError(validateManifest(cfg, "0x01", agentData))
+
+	cfg.Registry.Manifest.Policy = config.ManifestPolicyConfig{AllowedRegistries: []string{"disco.forta.network"}}
+	r.NoError(validateManifest(cfg, "0x01", testManifest("disco.forta.network/"+testManifestImage)))
+	r.Error(validateManifest(cfg, "0x01", testManifest("registry.example.com/"+testManifestImage)))
+}
+
+func TestCheckImageSize(t *testing.T) {
+	r := require.New(t)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.True(strings.HasPrefix(req.URL.Path, "/v2/bafybeielvnt5apaxbk6chthc4dc3p6vscpx3ai4uvti7gwh253j7facsxu/manifests/sha256:"))
+		w.Write([]byte(`{"config":{"size":1024},"layers":[{"size":1048576},{"size":2097152}]}`))
+	}))
+	defer server.Close()
+	defaultClient := http.DefaultClient
+	http.DefaultClient = server.Client()
+	defer func() {
+		http.DefaultClient = defaultClient
+	}()
+
+	var cfg config.Config
+	image := strings.TrimPrefix(server.URL, "https://") + "/" + testManifestImage
+	r.NoError(checkImageSize(context.Background(), cfg, image))
+
+	cfg.Registry.Manifest.Policy.MaxImageSizeMB = 4
+	r.NoError(checkImageSize(context.Background(), cfg, image))
+
+	cfg.Registry.Manifest.Policy.MaxImageSizeMB = 2
+	err := checkImageSize(context.Background(), cfg, image)
+	r.True(errors.Is(err, errInvalidBot))
+
+	// registry errors should not invalidate the bot
+	server.Close()
+	err = checkImageSize(context.Background(), cfg, image)
+	r.Error(err)
+	r.False(errors.Is(err, errInvalidBot))
+}
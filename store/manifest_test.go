@@ -0,0 +1,170 @@
+package store
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testManifestImage = "bafybeielvnt5apaxbk6chthc4dc3p6vscpx3ai4uvti7gwh253j7facsxu@sha256:e0e9efb6699b02750f6a9668084d37314f1de3a80da7e8e1f6b9e6ec3c5e4f8f"
+)
+
+// testFileRef returns the cid which 'ipfs add' would return for the content.
+func testFileRef(r *require.Assertions, content []byte) string {
+	c, err := unixfsCid(cid.Prefix{Version: 0, Codec: cid.DagProtobuf, MhType: multihash.SHA2_256, MhLength: -1}, content)
+	r.NoError(err)
+	return c.String()
+}
+
+func TestIPFSFileStore(t *testing.T) {
+	r := require.New(t)
+
+	content := []byte(`{"manifest":{"imageReference":"` + testManifestImage + `","capabilities":["gpu"]}}`)
+	testManifestRef := testFileRef(r, content)
+
+	failingGateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failingGateway.Close()
+
+	var served int
+	workingGateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		served++
+		r.Equal("/ipfs/"+testManifestRef, req.URL.Path)
+		w.Write(content)
+	}))
+	defer workingGateway.Close()
+
+	var cfg config.Config
+	cfg.FortaDir = t.TempDir()
+	cfg.Registry.IPFS.GatewayURL = failingGateway.URL
+	cfg.Registry.Manifest.FallbackGatewayURLs = []string{"https://not.allowed", workingGateway.URL}
+	cfg.Registry.Manifest.AllowedGatewayHosts = []string{"127.0.0.1"}
+
+	fs, err := NewIPFSFileStore(cfg)
+	r.NoError(err)
+	r.Len(fs.gateways, 2)
+
+	// should fall back to the working gateway
+	m, err := fs.GetAgentManifest(context.Background(), testManifestRef)
+	r.NoError(err)
+	r.Equal(testManifestImage, *m.Manifest.ImageReference)
+	r.Equal(1, served)
+
+	// should be served from the memory cache
+	_, err = fs.GetAgentManifest(context.Background(), testManifestRef)
+	r.NoError(err)
+	r.Equal(1, served)
+
+	// the capabilities are decoded from the cached manifest
+	capabilities, err := getManifestCapabilities(context.Background(), fs, testManifestRef)
+	r.NoError(err)
+	r.Equal([]string{"gpu"}, capabilities)
+	r.Equal(1, served)
+
+	// should be served from the disk cache by a new store
+	fs, err = NewIPFSFileStore(cfg)
+	r.NoError(err)
+	_, err = fs.GetAgentManifest(context.Background(), testManifestRef)
+	r.NoError(err)
+	r.Equal(1, served)
+
+	// should not accept references which are not cids
+	_, err = fs.GetFile(context.Background(), "../config.yml")
+	r.Error(err)
+}
+
+func TestIPFSFileStore_InvalidContent(t *testing.T) {
+	r := require.New(t)
+
+	// the first gateway returns an error page with a 200 status
+	var servedErrPage int
+	errPageGateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		servedErrPage++
+		w.Write([]byte(`<html>rate limited</html>`))
+	}))
+	defer errPageGateway.Close()
+
+	content := []byte(`{"manifest":{"imageReference":"` + testManifestImage + `"}}`)
+	testManifestRef := testFileRef(r, content)
+	workingGateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write(content)
+	}))
+	defer workingGateway.Close()
+
+	var cfg config.Config
+	cfg.FortaDir = t.TempDir()
+	cfg.Registry.IPFS.GatewayURL = errPageGateway.URL
+	cfg.Registry.Manifest.FallbackGatewayURLs = []string{workingGateway.URL}
+
+	fs, err := NewIPFSFileStore(cfg)
+	r.NoError(err)
+
+	// should skip the error page and should not cache it
+	m, err := fs.GetAgentManifest(context.Background(), testManifestRef)
+	r.NoError(err)
+	r.Equal(testManifestImage, *m.Manifest.ImageReference)
+	r.Equal(1, servedErrPage)
+
+	// raw cids are verified against the content
+	rawCid, err := cid.Prefix{Version: 1, Codec: cid.Raw, MhType: multihash.SHA2_256, MhLength: -1}.Sum(content)
+	r.NoError(err)
+	b, err := fs.GetFile(context.Background(), rawCid.String())
+	r.NoError(err)
+	r.Equal(content, b)
+	r.Equal(2, servedErrPage)
+
+	otherCid, err := cid.Prefix{Version: 1, Codec: cid.Raw, MhType: multihash.SHA2_256, MhLength: -1}.Sum([]byte("other content"))
+	r.NoError(err)
+	_, err = fs.GetFile(context.Background(), otherCid.String())
+	r.Error(err)
+	r.Contains(err.Error(), "does not match")
+
+	// dag-pb cids are verified against the content too
+	_, err = fs.GetFile(context.Background(), testFileRef(r, []byte("other content")))
+	r.Error(err)
+	r.Contains(err.Error(), "does not match")
+}
+
+func TestVerifyContent(t *testing.T) {
+	r := require.New(t)
+
+	// the cid from 'ipfs add' with the default settings
+	c, err := cid.Decode("QmT78zSuBmuS4z925WZfrqQ1qHaJ56DQaTfyMUF7F8ff5o")
+	r.NoError(err)
+	r.NoError(verifyContent(c, []byte("hello world\n")))
+	r.Error(verifyContent(c, []byte("hello world")))
+
+	// the content of the multi-chunk files is verified against the root cid
+	content := make([]byte, 1<<20)
+	c, err = cid.Decode(testFileRef(r, content))
+	r.NoError(err)
+	r.NoError(verifyContent(c, content))
+	content[len(content)-1] = 1
+	r.Error(verifyContent(c, content))
+
+	// the other cid types cannot be verified
+	c, err = cid.Prefix{Version: 1, Codec: cid.DagCBOR, MhType: multihash.SHA2_256, MhLength: -1}.Sum([]byte("hello world\n"))
+	r.NoError(err)
+	err = verifyContent(c, []byte("hello world\n"))
+	r.Error(err)
+	r.Contains(err.Error(), "unsupported cid type")
+}
+
+func TestIPFSFileStore_NoAllowedGateways(t *testing.T) {
+	r := require.New(t)
+
+	var cfg config.Config
+	cfg.Registry.IPFS.GatewayURL = "https://ipfs.forta.network"
+	cfg.Registry.Manifest.AllowedGatewayHosts = []string{"ipfs.io"}
+
+	_, err := NewIPFSFileStore(cfg)
+	r.ErrorIs(err, errNoAllowedGateways)
+}
@@ -8,6 +8,7 @@ import (
 	reflect "reflect"
 
 	config "github.com/forta-network/forta-node/config"
+	store "github.com/forta-network/forta-node/store"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -81,3 +82,17 @@ func (mr *MockRegistryStoreMockRecorder) GetAgentsIfChanged(scanner interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAgentsIfChanged", reflect.TypeOf((*MockRegistryStore)(nil).GetAgentsIfChanged), scanner)
 }
+
+// RejectedBots mocks base method.
+func (m *MockRegistryStore) RejectedBots() []*store.RejectedBot {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectedBots")
+	ret0, _ := ret[0].([]*store.RejectedBot)
+	return ret0
+}
+
+// RejectedBots indicates an expected call of RejectedBots.
+func (mr *MockRegistryStoreMockRecorder) RejectedBots() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectedBots", reflect.TypeOf((*MockRegistryStore)(nil).RejectedBots))
+}
@@ -34,6 +34,7 @@ type RegistryStore interface {
 	FindAgentGlobally(agentID string) (*config.AgentConfig, error)
 	GetAgentsIfChanged(scanner string) ([]*config.AgentConfig, bool, error)
 	FindScannerShardIDForBot(agentID, scannerAddress string) (uint, uint, uint, error)
+	RejectedBots() []*RejectedBot
 }
 
 type registryStore struct {
@@ -45,7 +46,7 @@ type registryStore struct {
 	lastUpdate           time.Time
 	lastCompletedVersion string
 	loadedBots           []*config.AgentConfig
-	invalidBots          []*registry.Agent
+	invalidBots          []*RejectedBot
 	mu                   sync.Mutex
 }
 
@@ -70,16 +71,16 @@ func (rs *registryStore) GetAgentsIfChanged(scanner string) ([]*config.AgentConf
 
 	var (
 		loadedBots       []*config.AgentConfig
-		invalidBots      []*registry.Agent
+		invalidBots      []*RejectedBot
 		failedLoadingAny bool
 	)
 	err = rs.rc.ForEachAssignedAgent(scanner, func(bot *registry.Agent) error {
 		logger := log.WithField("botId", bot.AgentID)
 
 		// if already invalidated, remember it for next time
-		if rs.isInvalidBot(bot) {
-			invalidBots = append(invalidBots, bot)
-			logger.WithError(err).Warn("invalid bot - skipping")
+		if invalidBot, ok := rs.getInvalidBot(bot); ok {
+			invalidBots = append(invalidBots, invalidBot)
+			logger.WithField("reason", invalidBot.Reason).Warn("invalid bot - skipping")
 			return nil
 		}
 		// if already loaded, remember it for next time
@@ -109,7 +110,9 @@ func (rs *registryStore) GetAgentsIfChanged(scanner string) ([]*config.AgentConf
 			return nil
 
 		case errors.Is(err, errInvalidBot):
-			invalidBots = append(invalidBots, bot) // remember for next time
+			invalidBots = append(invalidBots, &RejectedBot{
+				ID: bot.AgentID, Manifest: bot.Manifest, Reason: err.Error(),
+			}) // remember for next time
 			logger.WithError(err).Warn("invalid bot - skipping")
 			return nil
 
@@ -240,13 +243,20 @@ func (rs *registryStore) getLoadedBot(bot *registry.Agent) (*config.AgentConfig,
 	return nil, false
 }
 
-func (rs *registryStore) isInvalidBot(bot *registry.Agent) bool {
+func (rs *registryStore) getInvalidBot(bot *registry.Agent) (*RejectedBot, bool) {
 	for _, invalidBot := range rs.invalidBots {
 		if bot.Manifest == invalidBot.Manifest {
-			return true
+			return invalidBot, true
 		}
 	}
-	return false
+	return nil, false
+}
+
+// RejectedBots returns the assigned bots which were rejected in the latest update.
+func (rs *registryStore) RejectedBots() []*RejectedBot {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return append([]*RejectedBot(nil), rs.invalidBots...)
 }
 
 func loadBot(ctx context.Context, cfg config.Config, mc manifest.Client, agentID string, ref string) (*config.AgentConfig, error) {
@@ -267,25 +277,40 @@ func loadBot(ctx context.Context, cfg config.Config, mc manifest.Client, agentID
 		return nil, fmt.Errorf("failed to load the bot manifest: %v", err)
 	}
 
-	if agentData.Manifest.ImageReference == nil {
+	if agentData.Manifest == nil || agentData.Manifest.ImageReference == nil {
 		return nil, fmt.Errorf("%w: invalid bot image reference, it is nil", errInvalidBot)
 	}
 
+	if err := validateManifest(cfg, agentID, agentData); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidBot, err)
+	}
+
 	image, err := utils.ValidateDiscoImageRef(cfg.Registry.ContainerRegistry, *agentData.Manifest.ImageReference)
 	if err != nil {
 		return nil, fmt.Errorf("%w: invalid bot image reference '%s': %v", errInvalidBot, *agentData.Manifest.ImageReference, err)
 	}
 
+	if err := checkImageSize(ctx, cfg, image); err != nil {
+		return nil, err
+	}
+
+	capabilities, err := getManifestCapabilities(ctx, mc, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the bot capabilities: %v", err)
+	}
+	go cacheDocumentation(ctx, mc, agentID, agentData.Manifest)
+
 	return &config.AgentConfig{
-		ID:       agentID,
-		Image:    image,
-		Manifest: ref,
-		ChainID:  cfg.ChainID,
+		ID:           agentID,
+		Image:        image,
+		Manifest:     ref,
+		ChainID:      cfg.ChainID,
+		Capabilities: capabilities,
 	}, nil
 }
 
 func NewRegistryStore(ctx context.Context, cfg config.Config, ethClient ethereum.Client, blockFeed feeds.BlockFeed) (*registryStore, error) {
-	mc, err := manifest.NewClient(cfg.Registry.IPFS.GatewayURL)
+	mc, err := NewIPFSFileStore(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -330,6 +355,8 @@ type privateRegistryStore struct {
 	rc  registry.Client
 	mc  manifest.Client
 	mu  sync.Mutex
+
+	rejectedBots []*RejectedBot
 }
 
 func (rs *privateRegistryStore) FindScannerShardIDForBot(agentID, scannerAddress string) (uint, uint, uint, error) {
@@ -340,7 +367,10 @@ func (rs *privateRegistryStore) GetAgentsIfChanged(scanner string) ([]*config.Ag
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
 
-	var agentConfigs []*config.AgentConfig
+	var (
+		agentConfigs []*config.AgentConfig
+		rejectedBots []*RejectedBot
+	)
 
 	// load by image references
 	for i, agentImage := range rs.cfg.LocalModeConfig.BotImages {
@@ -352,6 +382,20 @@ func (rs *privateRegistryStore) GetAgentsIfChanged(scanner string) ([]*config.Ag
 		agentConfigs = append(agentConfigs, rs.makePrivateModeAgentConfig(agentID, agentImage, nil))
 	}
 
+	// load the bots which run from the local images
+	for i, localBot := range rs.cfg.LocalModeConfig.LocalBots {
+		agentID := strconv.Itoa(len(rs.cfg.LocalModeConfig.BotImages) + i + 1)
+		agentConfig := rs.makePrivateModeAgentConfig(agentID, localBot.Image, nil)
+		agentConfig.LocalImage = true
+		if sourcePath := localBot.SourcePath(rs.cfg.FortaDir); len(sourcePath) > 0 {
+			agentConfig.Build = &config.AgentBuildConfig{
+				Source:     sourcePath,
+				Dockerfile: localBot.Dockerfile,
+			}
+		}
+		agentConfigs = append(agentConfigs, agentConfig)
+	}
+
 	// load by bot IDs
 	for _, agentID := range rs.cfg.LocalModeConfig.BotIDs {
 		agt, err := rs.rc.GetAgent(agentID)
@@ -363,6 +407,9 @@ func (rs *privateRegistryStore) GetAgentsIfChanged(scanner string) ([]*config.Ag
 			continue
 		}
 		agtCfg, err := loadBot(rs.ctx, rs.cfg, rs.mc, agentID, agt.Manifest)
+		if errors.Is(err, errInvalidBot) {
+			rejectedBots = append(rejectedBots, &RejectedBot{ID: agentID, Manifest: agt.Manifest, Reason: err.Error()})
+		}
 		if err != nil {
 			logger.WithError(err).Error("failed to load bot")
 			continue
@@ -402,9 +449,18 @@ func (rs *privateRegistryStore) GetAgentsIfChanged(scanner string) ([]*config.Ag
 		}
 	}
 
+	rs.rejectedBots = rejectedBots
+
 	return agentConfigs, true, nil
 }
 
+// RejectedBots returns the bots which were rejected in the latest update.
+func (rs *privateRegistryStore) RejectedBots() []*RejectedBot {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return append([]*RejectedBot(nil), rs.rejectedBots...)
+}
+
 func (rs *privateRegistryStore) FindAgentGlobally(agentID string) (*config.AgentConfig, error) {
 	return nil, errors.New("feature not available (private/local registry)")
 }
@@ -423,7 +479,7 @@ func (rs *privateRegistryStore) makePrivateModeAgentConfig(
 }
 
 func NewPrivateRegistryStore(ctx context.Context, cfg config.Config) (*privateRegistryStore, error) {
-	mc, err := manifest.NewClient(cfg.Registry.IPFS.GatewayURL)
+	mc, err := NewIPFSFileStore(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -1,7 +1,11 @@
 package store
 
 import (
+	"context"
 	"testing"
+
+	"github.com/forta-network/forta-node/config"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_calculateShardID(t *testing.T) {
@@ -50,3 +54,42 @@ func Test_calculateShardID(t *testing.T) {
 		)
 	}
 }
+
+func TestPrivateRegistryStore_LocalBots(t *testing.T) {
+	r := require.New(t)
+
+	rs := &privateRegistryStore{
+		ctx: context.Background(),
+		cfg: config.Config{
+			FortaDir: "/forta",
+			ChainID:  1,
+			LocalModeConfig: config.LocalModeConfig{
+				BotImages: []string{"bot-image"},
+				LocalBots: []config.LocalBotConfig{
+					{Image: "local-bot-1"},
+					{Image: "local-bot-2", Source: "bots/bot-2", Dockerfile: "Dockerfile.dev"},
+					{Image: "local-bot-3", Source: "/src/bot-3"},
+				},
+			},
+		},
+	}
+	agentConfigs, changed, err := rs.GetAgentsIfChanged("")
+	r.NoError(err)
+	r.True(changed)
+	r.Len(agentConfigs, 4)
+
+	r.Equal("1", agentConfigs[0].ID)
+	r.False(agentConfigs[0].LocalImage)
+
+	r.Equal("2", agentConfigs[1].ID)
+	r.Equal("local-bot-1", agentConfigs[1].Image)
+	r.True(agentConfigs[1].IsLocal)
+	r.True(agentConfigs[1].LocalImage)
+	r.Nil(agentConfigs[1].Build)
+
+	r.Equal("3", agentConfigs[2].ID)
+	r.Equal(&config.AgentBuildConfig{Source: "/forta/bots/bot-2", Dockerfile: "Dockerfile.dev"}, agentConfigs[2].Build)
+
+	r.Equal("4", agentConfigs[3].ID)
+	r.Equal(&config.AgentBuildConfig{Source: "/src/bot-3"}, agentConfigs[3].Build)
+}
@@ -0,0 +1,242 @@
+package store
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/forta-network/forta-core-go/release"
+	"github.com/forta-network/forta-core-go/security"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+// the files in an update bundle
+const (
+	bundleManifestFileName = "bundle.json"
+	bundleImagesFileName   = "images.tar"
+)
+
+// UpdateBundle describes a release which is packaged with its images, for updating the nodes which
+// can not reach the registry and the container registry.
+type UpdateBundle struct {
+	Release release.ReleaseInfo `json:"release"`
+	// the IDs of the images in the images tarball by their refs
+	Images map[string]string `json:"images"`
+	// the sha256 digest of the images tarball
+	ImagesDigest string `json:"imagesDigest"`
+	Signer       string `json:"signer"`
+	Signature    string `json:"signature"`
+}
+
+// NewUpdateBundle creates a bundle for the release and the images tarball.
+func NewUpdateBundle(releaseInfo release.ReleaseInfo, images map[string]string, imagesPath string) (*UpdateBundle, error) {
+	digest, err := fileDigest(imagesPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash the images: %v", err)
+	}
+	return &UpdateBundle{
+		Release:      releaseInfo,
+		Images:       images,
+		ImagesDigest: digest,
+	}, nil
+}
+
+func (bundle *UpdateBundle) signingMessage() []byte {
+	unsigned := *bundle
+	unsigned.Signer = ""
+	unsigned.Signature = ""
+	b, _ := json.Marshal(&unsigned)
+	return append([]byte("forta-update-bundle\n"), b...)
+}
+
+// Sign signs the bundle with the key.
+func (bundle *UpdateBundle) Sign(key *keystore.Key) error {
+	sig, err := security.SignBytes(key, bundle.signingMessage())
+	if err != nil {
+		return err
+	}
+	bundle.Signer = sig.Signer
+	bundle.Signature = sig.Signature
+	return nil
+}
+
+// Verify checks that the bundle is signed by one of the signers.
+func (bundle *UpdateBundle) Verify(signers []string) error {
+	var allowed bool
+	for _, signer := range signers {
+		if strings.EqualFold(signer, bundle.Signer) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("signer %s is not allowed", bundle.Signer)
+	}
+	signer := common.HexToAddress(bundle.Signer).Hex()
+	if err := security.VerifySignature(bundle.signingMessage(), signer, bundle.Signature); err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	return nil
+}
+
+// LocalRelease returns the release which refers to the service images with their IDs, since the
+// registry digests of the images are lost when they are loaded from a tarball.
+func (bundle *UpdateBundle) LocalRelease() (*release.ReleaseInfo, error) {
+	releaseInfo := bundle.Release
+	services := &releaseInfo.Manifest.Release.Services
+	for _, ref := range []*string{&services.Updater, &services.Supervisor} {
+		imageID, ok := bundle.Images[*ref]
+		if !ok {
+			return nil, fmt.Errorf("release image %s is not in the bundle", *ref)
+		}
+		*ref = imageID
+	}
+	return &releaseInfo, nil
+}
+
+// WriteUpdateBundle writes the bundle and the images tarball as a gzipped tarball.
+func WriteUpdateBundle(w io.Writer, bundle *UpdateBundle, imagesPath string) error {
+	manifest, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	images, err := os.Open(imagesPath)
+	if err != nil {
+		return err
+	}
+	defer images.Close()
+	info, err := images.Stat()
+	if err != nil {
+		return err
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	if err := tw.WriteHeader(&tar.Header{Name: bundleManifestFileName, Mode: 0644, Size: int64(len(manifest))}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: bundleImagesFileName, Mode: 0644, Size: info.Size()}); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tw, images); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// ReadUpdateBundle reads the bundle, extracts the images tarball to the path and checks its digest.
+// The signature of the bundle should be verified separately.
+func ReadUpdateBundle(r io.Reader, imagesPath string) (*UpdateBundle, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a gzipped bundle: %v", err)
+	}
+	defer gr.Close()
+
+	var (
+		bundle          *UpdateBundle
+		extractedDigest string
+	)
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the bundle: %v", err)
+		}
+		switch header.Name {
+		case bundleManifestFileName:
+			bundle = &UpdateBundle{}
+			if err := json.NewDecoder(tr).Decode(bundle); err != nil {
+				return nil, fmt.Errorf("failed to decode the bundle manifest: %v", err)
+			}
+
+		case bundleImagesFileName:
+			extractedDigest, err = extractFile(tr, imagesPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract the images: %v", err)
+			}
+		}
+	}
+	if bundle == nil {
+		return nil, errors.New("bundle manifest not found")
+	}
+	if len(extractedDigest) == 0 {
+		return nil, errors.New("bundle images not found")
+	}
+	if extractedDigest != bundle.ImagesDigest {
+		return nil, fmt.Errorf("images digest mismatch: expected %s, got %s", bundle.ImagesDigest, extractedDigest)
+	}
+	return bundle, nil
+}
+
+// WriteImportedRelease saves the release imported from a bundle to the Forta dir.
+func WriteImportedRelease(fortaDir string, releaseInfo *release.ReleaseInfo) error {
+	b, err := json.MarshalIndent(releaseInfo, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path.Join(fortaDir, config.DefaultImportedReleaseFileName), b, 0644)
+}
+
+// ReadImportedRelease reads the release imported from a bundle. It returns nil if no release was
+// imported yet.
+func ReadImportedRelease(fortaDir string) (*release.ReleaseInfo, error) {
+	b, err := os.ReadFile(path.Join(fortaDir, config.DefaultImportedReleaseFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var releaseInfo release.ReleaseInfo
+	if err := json.Unmarshal(b, &releaseInfo); err != nil {
+		return nil, fmt.Errorf("failed to decode the imported release: %v", err)
+	}
+	return &releaseInfo, nil
+}
+
+func extractFile(r io.Reader, filePath string) (string, error) {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func fileDigest(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/forta-network/forta-core-go/release"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateBundle(t *testing.T) {
+	r := require.New(t)
+
+	dir := t.TempDir()
+	imagesPath := path.Join(dir, "images.tar")
+	r.NoError(os.WriteFile(imagesPath, []byte("images"), 0644))
+
+	privateKey, err := crypto.GenerateKey()
+	r.NoError(err)
+	key := &keystore.Key{PrivateKey: privateKey, Address: crypto.PubkeyToAddress(privateKey.PublicKey)}
+
+	releaseInfo := release.ReleaseInfo{IPFS: "Qm1234"}
+	releaseInfo.Manifest.Release.Version = "v1.2.3"
+	releaseInfo.Manifest.Release.Services.Updater = "disco.forta.network/updater@sha256:1234"
+	releaseInfo.Manifest.Release.Services.Supervisor = "disco.forta.network/supervisor@sha256:5678"
+	bundle, err := NewUpdateBundle(releaseInfo, map[string]string{
+		"disco.forta.network/updater@sha256:1234":    "sha256:abcd",
+		"disco.forta.network/supervisor@sha256:5678": "sha256:ef01",
+		"nats:2.3.2": "sha256:2345",
+	}, imagesPath)
+	r.NoError(err)
+	r.NoError(bundle.Sign(key))
+
+	var buf bytes.Buffer
+	r.NoError(WriteUpdateBundle(&buf, bundle, imagesPath))
+	bundleBytes := buf.Bytes()
+
+	extractedPath := path.Join(dir, "extracted.tar")
+	readBundle, err := ReadUpdateBundle(bytes.NewReader(bundleBytes), extractedPath)
+	r.NoError(err)
+	r.NoError(readBundle.Verify([]string{key.Address.Hex()}))
+	r.Error(readBundle.Verify([]string{"0x0000000000000000000000000000000000000001"}))
+	extracted, err := os.ReadFile(extractedPath)
+	r.NoError(err)
+	r.Equal("images", string(extracted))
+
+	// the signature covers the release
+	readBundle.Release.Manifest.Release.Services.Updater = "disco.forta.network/other@sha256:1234"
+	r.Error(readBundle.Verify([]string{key.Address.Hex()}))
+
+	// the images are checked against the digest
+	bundle.ImagesDigest = "1234"
+	buf.Reset()
+	r.NoError(WriteUpdateBundle(&buf, bundle, imagesPath))
+	_, err = ReadUpdateBundle(&buf, extractedPath)
+	r.Error(err)
+
+	localRelease, err := bundle.LocalRelease()
+	r.NoError(err)
+	r.Equal("sha256:abcd", localRelease.Manifest.Release.Services.Updater)
+	r.Equal("sha256:ef01", localRelease.Manifest.Release.Services.Supervisor)
+	r.Equal("v1.2.3", localRelease.Manifest.Release.Version)
+	r.Equal("disco.forta.network/updater@sha256:1234", bundle.Release.Manifest.Release.Services.Updater)
+
+	imported, err := ReadImportedRelease(dir)
+	r.NoError(err)
+	r.Nil(imported)
+	r.NoError(WriteImportedRelease(dir, localRelease))
+	imported, err = ReadImportedRelease(dir)
+	r.NoError(err)
+	r.Equal(localRelease, imported)
+}
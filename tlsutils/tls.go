@@ -0,0 +1,336 @@
+package tlsutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/forta-network/forta-node/config"
+)
+
+const (
+	authorityName     = "forta-node"
+	authorityValidity = time.Hour * 24 * 365
+	certValidity      = time.Hour * 24 * 365
+)
+
+// Role is what a certificate can be used for. The roles are combined for the containers which both
+// serve and send the requests.
+type Role int
+
+// Roles
+const (
+	RoleServer Role = 1 << iota
+	RoleClient
+)
+
+func (role Role) extKeyUsage() []x509.ExtKeyUsage {
+	var usage []x509.ExtKeyUsage
+	if role&RoleServer != 0 {
+		usage = append(usage, x509.ExtKeyUsageServerAuth)
+	}
+	if role&RoleClient != 0 {
+		usage = append(usage, x509.ExtKeyUsageClientAuth)
+	}
+	return usage
+}
+
+// PeerMatcher reports whether a peer is allowed by the common name of its certificate.
+type PeerMatcher func(commonName string) bool
+
+// AllowPeers allows the peers with one of the names. The names which end with '*' match the
+// common names which start with the rest of the name.
+func AllowPeers(names ...string) PeerMatcher {
+	return func(commonName string) bool {
+		for _, name := range names {
+			if prefix := strings.TrimSuffix(name, "*"); prefix != name {
+				if strings.HasPrefix(commonName, prefix) {
+					return true
+				}
+				continue
+			}
+			if commonName == name {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// verify checks the peer certificate after it is verified with the authority.
+func (match PeerMatcher) verify(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	if len(verifiedChains) == 0 || len(verifiedChains[0]) == 0 {
+		return errors.New("no verified peer certificate")
+	}
+	commonName := verifiedChains[0][0].Subject.CommonName
+	if !match(commonName) {
+		return fmt.Errorf("peer '%s' is not allowed", commonName)
+	}
+	return nil
+}
+
+// Authority issues the certificates of the node and the agent containers.
+type Authority struct {
+	cert    *x509.Certificate
+	certPEM []byte
+	key     *ecdsa.PrivateKey
+}
+
+// NewAuthority creates a new authority with a new self-signed certificate. The authority lives only
+// in the supervisor memory and the containers are restarted with new certificates together with it.
+func NewAuthority() (*Authority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the authority key: %v", err)
+	}
+	serial, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: authorityName},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(authorityValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the authority certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &Authority{
+		cert:    cert,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		key:     key,
+	}, nil
+}
+
+// CertPEM returns the authority certificate.
+func (authority *Authority) CertPEM() []byte {
+	return authority.certPEM
+}
+
+// Issue issues a certificate for the given names which can be used only in the given role.
+func (authority *Authority) Issue(role Role, commonName string, dnsNames ...string) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate the key: %v", err)
+	}
+	serial, err := newSerialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     append([]string{commonName}, dnsNames...),
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(certValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  role.extKeyUsage(),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, authority.cert, &key.PublicKey, authority.key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create the certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode the key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// ContainerFiles issues a certificate and returns the files which should be copied to the container.
+func (authority *Authority) ContainerFiles(role Role, commonName string, dnsNames ...string) (map[string][]byte, error) {
+	return authority.containerFiles(defaultContainerFiles, role, commonName, dnsNames...)
+}
+
+// NatsContainerFiles issues a certificate for the message bus and returns the files which should be
+// copied to the container.
+func (authority *Authority) NatsContainerFiles(role Role, commonName string, dnsNames ...string) (map[string][]byte, error) {
+	return authority.containerFiles(natsContainerFiles, role, commonName, dnsNames...)
+}
+
+func (authority *Authority) containerFiles(files containerFiles, role Role, commonName string, dnsNames ...string) (map[string][]byte, error) {
+	certPEM, keyPEM, err := authority.Issue(role, commonName, dnsNames...)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{
+		files.Cert: certPEM,
+		files.Key:  keyPEM,
+		files.CA:   authority.certPEM,
+	}, nil
+}
+
+// ClientConfig issues a client certificate and makes a client config with it, for the clients which run
+// in the same process with the authority.
+func (authority *Authority) ClientConfig(commonName string, allowedServers PeerMatcher) (*tls.Config, error) {
+	certPEM, keyPEM, err := authority.Issue(RoleClient, commonName)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(authority.cert)
+	return &tls.Config{
+		Certificates:          []tls.Certificate{cert},
+		RootCAs:               pool,
+		VerifyPeerCertificate: allowedServers.verify,
+		MinVersion:            tls.VersionTLS12,
+	}, nil
+}
+
+func newSerialNumber() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the serial number: %v", err)
+	}
+	return serial, nil
+}
+
+// containerFiles are the paths of the TLS files in the container.
+type containerFiles struct {
+	Cert string
+	Key  string
+	CA   string
+}
+
+var defaultContainerFiles = containerFiles{
+	Cert: config.DefaultContainerTLSCertPath,
+	Key:  config.DefaultContainerTLSKeyPath,
+	CA:   config.DefaultContainerTLSCAPath,
+}
+
+var natsContainerFiles = containerFiles{
+	Cert: config.DefaultContainerNatsCertPath,
+	Key:  config.DefaultContainerNatsKeyPath,
+	CA:   config.DefaultContainerNatsCAPath,
+}
+
+// load loads the certificate and the authority certificate from the container files.
+func (files containerFiles) load() (tls.Certificate, []byte, error) {
+	cert, err := tls.LoadX509KeyPair(files.Cert, files.Key)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to load the certificate: %v", err)
+	}
+	caPEM, err := ioutil.ReadFile(files.CA)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("failed to read the authority certificate: %v", err)
+	}
+	return cert, caPEM, nil
+}
+
+// loadWithPool loads the certificate and a pool which contains only the authority certificate.
+func (files containerFiles) loadWithPool() (tls.Certificate, *x509.CertPool, error) {
+	cert, caPEM, err := files.load()
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return tls.Certificate{}, nil, errors.New("failed to parse the authority certificate")
+	}
+	return cert, pool, nil
+}
+
+// ServerConfig makes a server config which requires the client certificates issued by the node to
+// the allowed clients.
+func ServerConfig(allowedClients PeerMatcher) (*tls.Config, error) {
+	return serverConfig(defaultContainerFiles, allowedClients)
+}
+
+func serverConfig(files containerFiles, allowedClients PeerMatcher) (*tls.Config, error) {
+	cert, pool, err := files.loadWithPool()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates:          []tls.Certificate{cert},
+		ClientCAs:             pool,
+		ClientAuth:            tls.RequireAndVerifyClientCert,
+		VerifyPeerCertificate: allowedClients.verify,
+		MinVersion:            tls.VersionTLS12,
+	}, nil
+}
+
+// ClientConfig makes a client config which presents the container certificate and trusts only
+// the certificates issued by the node to the allowed servers.
+func ClientConfig(allowedServers PeerMatcher) (*tls.Config, error) {
+	return clientConfig(defaultContainerFiles, allowedServers)
+}
+
+// NatsClientConfig makes a client config for connecting to the message bus with the certificate which
+// the supervisor issued to the container.
+func NatsClientConfig() (*tls.Config, error) {
+	return clientConfig(natsContainerFiles, AllowPeers(config.DockerNatsContainerName))
+}
+
+func clientConfig(files containerFiles, allowedServers PeerMatcher) (*tls.Config, error) {
+	cert, pool, err := files.loadWithPool()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates:          []tls.Certificate{cert},
+		RootCAs:               pool,
+		VerifyPeerCertificate: allowedServers.verify,
+		MinVersion:            tls.VersionTLS12,
+	}, nil
+}
+
+// ClientTransport makes an HTTP transport which presents the container certificate only to the
+// allowed servers. The other HTTP clients in the container are not affected.
+func ClientTransport(allowedServers PeerMatcher) (*http.Transport, error) {
+	tlsConfig, err := ClientConfig(allowedServers)
+	if err != nil {
+		return nil, err
+	}
+	base, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil, errors.New("unexpected default transport")
+	}
+	transport := base.Clone()
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// HostTransport sends the requests to the host with the transport and the other requests with
+// the next transport, for the libraries which only use the default HTTP client.
+type HostTransport struct {
+	Host      string
+	Transport http.RoundTripper
+	Next      http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *HostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Hostname() == t.Host {
+		return t.Transport.RoundTrip(req)
+	}
+	return t.Next.RoundTrip(req)
+}
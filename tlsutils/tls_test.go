@@ -0,0 +1,168 @@
+package tlsutils
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeContainerFiles(t *testing.T, authority *Authority, role Role, name string) containerFiles {
+	r := require.New(t)
+
+	certPEM, keyPEM, err := authority.Issue(role, name)
+	r.NoError(err)
+	dir := t.TempDir()
+	files := containerFiles{
+		Cert: path.Join(dir, "tls.crt"),
+		Key:  path.Join(dir, "tls.key"),
+		CA:   path.Join(dir, "ca.crt"),
+	}
+	r.NoError(ioutil.WriteFile(files.Cert, certPEM, 0600))
+	r.NoError(ioutil.WriteFile(files.Key, keyPEM, 0600))
+	r.NoError(ioutil.WriteFile(files.CA, authority.CertPEM(), 0600))
+	return files
+}
+
+func testHandshake(t *testing.T, serverFiles, clientFiles containerFiles, serverName string, allowedClients PeerMatcher) error {
+	r := require.New(t)
+
+	serverConfig, err := serverConfig(serverFiles, allowedClients)
+	r.NoError(err)
+	clientConfig, err := clientConfig(clientFiles, AllowPeers(serverName))
+	r.NoError(err)
+	clientConfig.ServerName = serverName
+
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	r.NoError(err)
+	defer lis.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+		serverErr <- conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := net.Dial("tcp", lis.Addr().String())
+	r.NoError(err)
+	defer conn.Close()
+	clientErr := tls.Client(conn, clientConfig).Handshake()
+	if err := <-serverErr; err != nil {
+		return err
+	}
+	return clientErr
+}
+
+func TestMutualTLS(t *testing.T) {
+	r := require.New(t)
+
+	authority, err := NewAuthority()
+	r.NoError(err)
+	agentFiles := writeContainerFiles(t, authority, RoleServer|RoleClient, "forta-agent-0x01")
+	scannerFiles := writeContainerFiles(t, authority, RoleClient, "forta-scanner")
+	allowScanner := AllowPeers("forta-scanner")
+
+	r.NoError(testHandshake(t, agentFiles, scannerFiles, "forta-agent-0x01", allowScanner))
+
+	// the certificate of another agent cannot be used for impersonating the agent
+	r.Error(testHandshake(t, agentFiles, scannerFiles, "forta-agent-0x02", allowScanner))
+
+	// the certificates issued by another authority are rejected by both sides
+	rogueAuthority, err := NewAuthority()
+	r.NoError(err)
+	rogueFiles := writeContainerFiles(t, rogueAuthority, RoleClient, "forta-scanner")
+	r.Error(testHandshake(t, agentFiles, rogueFiles, "forta-agent-0x01", allowScanner))
+	rogueAgentFiles := writeContainerFiles(t, rogueAuthority, RoleServer|RoleClient, "forta-agent-0x01")
+	r.Error(testHandshake(t, rogueAgentFiles, scannerFiles, "forta-agent-0x01", allowScanner))
+}
+
+func TestMutualTLS_Roles(t *testing.T) {
+	r := require.New(t)
+
+	authority, err := NewAuthority()
+	r.NoError(err)
+	agentFiles := writeContainerFiles(t, authority, RoleServer|RoleClient, "forta-agent-0x01")
+	otherAgentFiles := writeContainerFiles(t, authority, RoleServer|RoleClient, "forta-agent-0x02")
+	scannerFiles := writeContainerFiles(t, authority, RoleClient, "forta-scanner")
+	natsFiles := writeContainerFiles(t, authority, RoleServer, "forta-nats")
+
+	// the client certificates cannot be used by the servers
+	r.Error(testHandshake(t, scannerFiles, agentFiles, "forta-scanner", AllowPeers("forta-agent-*")))
+	// and the server certificates cannot be used by the clients
+	r.Error(testHandshake(t, agentFiles, natsFiles, "forta-agent-0x01", AllowPeers("forta-nats")))
+
+	// the servers accept only the allowed clients
+	r.NoError(testHandshake(t, agentFiles, otherAgentFiles, "forta-agent-0x01", AllowPeers("forta-agent-*")))
+	r.Error(testHandshake(t, agentFiles, otherAgentFiles, "forta-agent-0x01", AllowPeers("forta-scanner")))
+}
+
+func TestAllowPeers(t *testing.T) {
+	r := require.New(t)
+
+	match := AllowPeers("forta-scanner", "forta-agent-*")
+	r.True(match("forta-scanner"))
+	r.True(match("forta-agent-0x01"))
+	r.False(match("forta-scanner-2"))
+	r.False(match("forta-json-rpc"))
+}
+
+func TestContainerFiles(t *testing.T) {
+	r := require.New(t)
+
+	authority, err := NewAuthority()
+	r.NoError(err)
+	files, err := authority.ContainerFiles(RoleServer, "forta-json-rpc", "localhost")
+	r.NoError(err)
+	r.Len(files, 3)
+	r.Equal(authority.CertPEM(), files["/forta-tls-ca.crt"])
+}
+
+func TestNatsContainerFiles(t *testing.T) {
+	r := require.New(t)
+
+	authority, err := NewAuthority()
+	r.NoError(err)
+	files, err := authority.NatsContainerFiles(RoleClient, "forta-scanner")
+	r.NoError(err)
+	r.Len(files, 3)
+	r.Equal(authority.CertPEM(), files["/forta-nats-ca.crt"])
+}
+
+func TestAuthorityClientConfig(t *testing.T) {
+	r := require.New(t)
+
+	authority, err := NewAuthority()
+	r.NoError(err)
+	serverConfig, err := serverConfig(writeContainerFiles(t, authority, RoleServer, "forta-nats"), AllowPeers("forta-supervisor"))
+	r.NoError(err)
+	clientConfig, err := authority.ClientConfig("forta-supervisor", AllowPeers("forta-nats"))
+	r.NoError(err)
+	clientConfig.ServerName = "forta-nats"
+
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	r.NoError(err)
+	defer lis.Close()
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+		serverErr <- conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", lis.Addr().String(), clientConfig)
+	r.NoError(err)
+	conn.Close()
+	r.NoError(<-serverErr)
+}